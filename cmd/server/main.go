@@ -3,6 +3,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
@@ -20,9 +21,19 @@ import (
 	"gorm.io/gorm/logger"
 
 	"github.com/ashwinyue/next-show/internal/biz"
+	"github.com/ashwinyue/next-show/internal/biz/knowledge"
+	"github.com/ashwinyue/next-show/internal/biz/mcp"
+	"github.com/ashwinyue/next-show/internal/biz/session"
 	handler "github.com/ashwinyue/next-show/internal/handler/http"
 	"github.com/ashwinyue/next-show/internal/model"
+	agenttools "github.com/ashwinyue/next-show/internal/pkg/agent/tools"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
 	embeddingpkg "github.com/ashwinyue/next-show/internal/pkg/embedding"
+	"github.com/ashwinyue/next-show/internal/pkg/events"
+	"github.com/ashwinyue/next-show/internal/pkg/limiter"
+	"github.com/ashwinyue/next-show/internal/pkg/moderation"
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
 	"github.com/ashwinyue/next-show/internal/pkg/trace"
 	"github.com/ashwinyue/next-show/internal/store"
 )
@@ -37,11 +48,29 @@ func main() {
 
 	// 初始化本地日志追踪（开发环境）
 	if viper.GetBool("trace.log_enabled") {
-		logTracer := trace.NewLogTracer(viper.GetBool("trace.verbose"))
+		logCfg := &trace.LogTracerConfig{
+			Verbose: viper.GetBool("trace.verbose"),
+			Format:  trace.LogFormat(viper.GetString("trace.format")),
+		}
+		if viper.IsSet("trace.redact_keys") {
+			logCfg.RedactKeys = viper.GetStringSlice("trace.redact_keys")
+		}
+		if viper.IsSet("trace.no_color") {
+			noColor := viper.GetBool("trace.no_color")
+			logCfg.NoColor = &noColor
+		}
+		logTracer := trace.NewLogTracerWithConfig(logCfg)
 		logTracer.Register()
 		log.Println("log tracer initialized")
 	}
 
+	// 初始化运行用量与费用统计
+	var pricing trace.Pricing
+	if err := viper.UnmarshalKey("pricing.models", &pricing); err != nil {
+		log.Fatalf("failed to parse pricing config: %v", err)
+	}
+	trace.NewUsageTracer(pricing).Register()
+
 	// 初始化 Coze-Loop 追踪（可选）
 	if viper.GetBool("cozeloop.enabled") {
 		tracer, err := trace.NewCozeLoopTracer(&trace.CozeLoopConfig{
@@ -69,25 +98,175 @@ func main() {
 		if err := autoMigrate(db); err != nil {
 			log.Fatalf("failed to auto migrate: %v", err)
 		}
+		if viper.GetBool("database.vector_index.enabled") {
+			if err := ensureVectorIndex(ctx, db); err != nil {
+				log.Fatalf("failed to create vector index: %v", err)
+			}
+		}
 	}
 
 	// 依赖注入
 	s := store.NewStore(db)
 
+	// pgvector 不可用时知识库检索退化为仅全文检索，扩展安装后自动恢复
+	if support, err := s.Knowledge().CheckVectorSupport(ctx); err != nil {
+		log.Printf("failed to check pgvector: %v", err)
+	} else if !support.Available {
+		log.Printf("WARNING: %s, vector search is DISABLED and knowledge search falls back to full-text only", support.Reason)
+	}
+
+	// 外部调用的重试策略
+	var retryCfg retry.Config
+	if err := viper.UnmarshalKey("retry", &retryCfg); err != nil {
+		log.Fatalf("failed to parse retry config: %v", err)
+	}
+
 	// 初始化 Embedding 模型
 	var embedder embedding.Embedder
 	if viper.GetString("embedding.api_key") != "" {
 		var err error
-		embedder, err = initEmbedding(ctx)
+		embedder, err = initEmbedding(ctx, "embedding")
 		if err != nil {
 			log.Printf("failed to init embedding: %v, RAG tools will be disabled", err)
 		} else {
+			embedder = embeddingpkg.WithRetry(embedder, retryCfg.Embedding)
 			log.Println("embedding model initialized")
 		}
 	}
 
-	b := biz.NewBiz(s, embedder)
-	h := handler.NewHandler(b)
+	// 知识库导入配置；语义分块使用的 Embedding 模型可选，未配置时使用主模型
+	knowledgeCfg := &knowledge.BizConfig{
+		EmbeddingBatchSize:      viper.GetInt("embedding.batch_size"),
+		EmbeddingMaxConcurrency: viper.GetInt("embedding.max_concurrency"),
+
+		SplitEmbeddingBatchSize:      viper.GetInt("embedding.splitter.batch_size"),
+		SplitEmbeddingMaxConcurrency: viper.GetInt("embedding.splitter.max_concurrency"),
+
+		MaxChunksPerDocument: viper.GetInt("knowledge.max_chunks_per_document"),
+		ChunkLimitPolicy:     viper.GetString("knowledge.chunk_limit_policy"),
+		ImportStaleTimeout:   viper.GetDuration("knowledge.import_stale_timeout"),
+		IndexRebuildInterval: viper.GetDuration("knowledge.index_rebuild_interval"),
+	}
+	if err := viper.UnmarshalKey("embedding.import_limits", &knowledgeCfg.ImportLimits); err != nil {
+		log.Fatalf("failed to parse embedding.import_limits config: %v", err)
+	}
+	if viper.GetBool("database.vector_index.enabled") {
+		knowledgeCfg.VectorIndex = &knowledge.VectorIndexConfig{
+			M:              viper.GetInt("database.vector_index.m"),
+			EfConstruction: viper.GetInt("database.vector_index.ef_construction"),
+		}
+	}
+	if embedder != nil && viper.GetString("embedding.splitter.model") != "" {
+		splitEmbedder, err := initEmbedding(ctx, "embedding.splitter")
+		if err != nil {
+			log.Printf("failed to init splitter embedding: %v, semantic splitting will use the main embedding model", err)
+		} else {
+			knowledgeCfg.SplitEmbedder = embeddingpkg.WithRetry(splitEmbedder, retryCfg.Embedding)
+			log.Println("splitter embedding model initialized")
+		}
+	}
+
+	// 知识库变更事件，配置了 Webhook 时投递到外部地址
+	bus, err := initEvents()
+	if err != nil {
+		log.Fatalf("failed to init events: %v", err)
+	}
+	knowledgeCfg.Events = bus
+
+	// 初始化内容审核（可选）
+	var moderator *moderation.Moderator
+	if viper.GetBool("moderation.enabled") {
+		moderator, err = initModeration(s)
+		if err != nil {
+			log.Fatalf("failed to init moderation: %v", err)
+		}
+		log.Println("content moderation initialized")
+	}
+
+	// 初始化原始文件存储
+	var blobCfg blob.Config
+	if err := viper.UnmarshalKey("storage", &blobCfg); err != nil {
+		log.Fatalf("failed to parse storage config: %v", err)
+	}
+	files, err := blob.New(&blobCfg)
+	if err != nil {
+		log.Fatalf("failed to init storage: %v", err)
+	}
+
+	// 数据分析工具（DuckDB）
+	var dataCfg agenttools.DataAnalysisConfig
+	if err := viper.UnmarshalKey("data_analysis", &dataCfg); err != nil {
+		log.Fatalf("failed to parse data_analysis config: %v", err)
+	}
+
+	// Agent 使用的 MCP 工具
+	var mcpCfg mcp.Config
+	if err := viper.UnmarshalKey("mcp", &mcpCfg); err != nil {
+		log.Fatalf("failed to parse mcp config: %v", err)
+	}
+
+	b := biz.NewBiz(s, embedder, moderator, files, knowledgeCfg, &retryCfg, &dataCfg, &mcpCfg)
+	expvar.Publish("mcp_tool_cache", expvar.Func(func() any { return b.MCP().ToolCacheStats() }))
+
+	// 将未登记的旧设置迁移到 custom. 前缀下
+	if n, err := b.Settings().MigrateCustomSettings(ctx); err != nil {
+		log.Printf("failed to migrate custom settings: %v", err)
+	} else if n > 0 {
+		log.Printf("migrated %d settings to the custom. prefix", n)
+	}
+
+	// 会话保留策略
+	var retentionCfg session.RetentionConfig
+	if err := viper.UnmarshalKey("retention", &retentionCfg); err != nil {
+		log.Fatalf("failed to parse retention config: %v", err)
+	}
+	pruner := session.NewPruner(s, &retentionCfg)
+	expvar.Publish("session_retention", expvar.Func(func() any { return pruner.Stats() }))
+	if retentionCfg.Enabled {
+		pruneCtx, stopPruner := context.WithCancel(ctx)
+		defer stopPruner()
+		go pruner.Run(pruneCtx)
+		log.Println("session retention pruner started")
+	}
+
+	// 重启前未完成的文档导入和重建任务不会继续执行，标记为失败以便重新发起
+	if n, err := b.Knowledge().RecoverInterruptedImports(ctx); err != nil {
+		log.Printf("failed to recover document imports: %v", err)
+	} else if n > 0 {
+		log.Printf("marked %d interrupted document imports as failed", n)
+	}
+	if n, err := b.Knowledge().RecoverInterruptedReindexJobs(ctx); err != nil {
+		log.Printf("failed to recover reindex jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("marked %d interrupted reindex jobs as failed", n)
+	}
+
+	// 处理重启前未完成的评估任务
+	if n, err := b.Evaluation().RecoverInterrupted(ctx, viper.GetBool("evaluation.resume_on_startup")); err != nil {
+		log.Printf("failed to recover evaluation tasks: %v", err)
+	} else if n > 0 {
+		log.Printf("recovered %d unfinished evaluation tasks", n)
+	}
+
+	// Agent 运行并发限制
+	var limiterCfg limiter.Config
+	if err := viper.UnmarshalKey("agent_runs", &limiterCfg); err != nil {
+		log.Fatalf("failed to parse agent_runs config: %v", err)
+	}
+	runLimiter := limiter.New(&limiterCfg)
+	expvar.Publish("agent_runs", expvar.Func(func() any { return runLimiter.Stats() }))
+
+	// 按接口类别的请求超时
+	var timeoutCfg handler.TimeoutConfig
+	if err := viper.UnmarshalKey("server.timeouts", &timeoutCfg); err != nil {
+		log.Fatalf("failed to parse server.timeouts config: %v", err)
+	}
+
+	h := handler.NewHandler(b, &sse.BufferedWriterConfig{
+		BufferSize:        viper.GetInt("sse.buffer_size"),
+		LagPolicy:         sse.LagPolicy(viper.GetString("sse.lag_policy")),
+		HeartbeatInterval: viper.GetDuration("sse.heartbeat_interval"),
+	}, runLimiter, &timeoutCfg)
 
 	// 初始化 Gin
 	if viper.GetString("server.mode") == "release" {
@@ -123,10 +302,29 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("server forced to shutdown: %v", err)
 	}
+	bus.Close()
 
 	log.Println("server exited")
 }
 
+// initEvents 创建事件总线并注册配置的 Webhook.
+func initEvents() (*events.Bus, error) {
+	var webhooks []events.WebhookConfig
+	if err := viper.UnmarshalKey("events.webhooks", &webhooks); err != nil {
+		return nil, fmt.Errorf("parse events.webhooks: %w", err)
+	}
+	bus := events.NewBus(viper.GetInt("events.buffer_size"))
+	for _, cfg := range webhooks {
+		sink, err := events.NewWebhookSink(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sink.Subscribe(bus, cfg.Types)
+		log.Printf("event webhook registered: %s", cfg.URL)
+	}
+	return bus, nil
+}
+
 func loadConfig() error {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -216,26 +414,57 @@ func autoMigrate(db *gorm.DB) error {
 		&model.DatasetItem{},
 		&model.EvaluationTask{},
 		&model.EvaluationResult{},
+		&model.ReindexJob{},
 		&model.Skill{},
+		&model.ModerationRecord{},
 	)
 }
 
-func initEmbedding(ctx context.Context) (embedding.Embedder, error) {
+// ensureVectorIndex 迁移后按配置为 embeddings 表创建向量索引.
+func ensureVectorIndex(ctx context.Context, db *gorm.DB) error {
+	distance := store.DistanceFunction(viper.GetString("database.vector_index.distance_function"))
+	if distance == "" {
+		distance = store.DistanceCosine
+	}
+	method, err := store.NewStore(db).Knowledge().EnsureVectorIndex(ctx, distance,
+		viper.GetInt("database.vector_index.m"), viper.GetInt("database.vector_index.ef_construction"))
+	if err != nil {
+		return err
+	}
+	log.Printf("vector index ready: %s (%s)", method, distance)
+	return nil
+}
+
+// initEmbedding 根据 prefix 下的配置创建 Embedding 模型.
+// embedding.splitter 下未配置的 provider、api_key、base_url、timeout 沿用 embedding 下的配置.
+func initEmbedding(ctx context.Context, prefix string) (embedding.Embedder, error) {
 	factory := embeddingpkg.NewFactory()
 
+	get := func(key string) string {
+		if v := viper.GetString(prefix + "." + key); v != "" {
+			return v
+		}
+		return viper.GetString("embedding." + key)
+	}
+
 	// 验证配置
-	provider := embeddingpkg.ProviderType(viper.GetString("embedding.provider"))
+	provider := embeddingpkg.ProviderType(get("provider"))
 	if provider == "" {
 		provider = embeddingpkg.ProviderDashScope
 	}
 
+	timeout := viper.GetInt(prefix + ".timeout")
+	if timeout == 0 {
+		timeout = viper.GetInt("embedding.timeout")
+	}
+
 	cfg := &embeddingpkg.Config{
 		Provider:   provider,
-		APIKey:     viper.GetString("embedding.api_key"),
-		BaseURL:    viper.GetString("embedding.base_url"),
-		Model:      viper.GetString("embedding.model"),
-		Dimensions: viper.GetInt("embedding.dimensions"),
-		Timeout:    time.Duration(viper.GetInt("embedding.timeout")) * time.Second,
+		APIKey:     get("api_key"),
+		BaseURL:    get("base_url"),
+		Model:      viper.GetString(prefix + ".model"),
+		Dimensions: viper.GetInt(prefix + ".dimensions"),
+		Timeout:    time.Duration(timeout) * time.Second,
 	}
 
 	if cfg.Model == "" {
@@ -250,3 +479,39 @@ func initEmbedding(ctx context.Context) (embedding.Embedder, error) {
 
 	return factory.Create(ctx, cfg)
 }
+
+func initModeration(s store.Store) (*moderation.Moderator, error) {
+	var provider moderation.Provider
+	switch viper.GetString("moderation.provider") {
+	case "openai":
+		p, err := moderation.NewOpenAIProvider(&moderation.OpenAIConfig{
+			APIKey:  viper.GetString("moderation.api_key"),
+			BaseURL: viper.GetString("moderation.base_url"),
+			Model:   viper.GetString("moderation.model"),
+			Timeout: time.Duration(viper.GetInt("moderation.timeout")) * time.Second,
+		})
+		if err != nil {
+			return nil, err
+		}
+		provider = p
+	case "", "keyword":
+		var rules []moderation.KeywordRule
+		if err := viper.UnmarshalKey("moderation.keyword_rules", &rules); err != nil {
+			return nil, fmt.Errorf("parse keyword rules: %w", err)
+		}
+		p, err := moderation.NewKeywordProvider(rules)
+		if err != nil {
+			return nil, err
+		}
+		provider = p
+	default:
+		return nil, fmt.Errorf("unsupported moderation provider: %s", viper.GetString("moderation.provider"))
+	}
+
+	return moderation.NewModerator(provider, s.Moderation(), &moderation.Config{
+		ImportAction:      moderation.Action(viper.GetString("moderation.import_action")),
+		AnswerAction:      moderation.Action(viper.GetString("moderation.answer_action")),
+		FailOpen:          viper.GetString("moderation.fail_mode") != "closed",
+		RedactPlaceholder: viper.GetString("moderation.redact_placeholder"),
+	}), nil
+}
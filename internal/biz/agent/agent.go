@@ -4,77 +4,189 @@ package agent
 import (
 	"context"
 	"fmt"
+	"io"
+	"log"
+	"strings"
 	"sync"
 
+	einomodel "github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 
+	"github.com/ashwinyue/next-show/internal/biz/mcp"
+	"github.com/ashwinyue/next-show/internal/biz/tenant"
 	"github.com/ashwinyue/next-show/internal/model"
 	"github.com/ashwinyue/next-show/internal/pkg/agent/agentic"
 	agentcallbacks "github.com/ashwinyue/next-show/internal/pkg/agent/callbacks"
 	agenttools "github.com/ashwinyue/next-show/internal/pkg/agent/tools"
 	"github.com/ashwinyue/next-show/internal/pkg/models"
+	"github.com/ashwinyue/next-show/internal/pkg/moderation"
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
 	"github.com/ashwinyue/next-show/internal/pkg/sse"
+	"github.com/ashwinyue/next-show/internal/pkg/trace"
 	"github.com/ashwinyue/next-show/internal/store"
 )
 
 // AgentBiz Agent 业务接口.
 type AgentBiz interface {
 	// Chat 执行 Agent 对话，通过 SSE writer 发送事件.
-	Chat(ctx context.Context, sessionID string, content string, sseWriter sse.Writer) error
+	Chat(ctx context.Context, sessionID string, content string, sseWriter sse.Writer) (*ChatResult, error)
+	// Preview 使用临时会话运行 Agent，不保存会话和消息.
+	Preview(ctx context.Context, agentID string, req *PreviewRequest, sseWriter sse.Writer) (*ChatResult, error)
+	// Compare 使用临时会话并发运行多个 Agent 并返回各自结果.
+	Compare(ctx context.Context, req *CompareRequest) []*CompareRun
 	// CallWithEvaluationCallback 调用 RAG Agent 并使用评估 Callback 收集数据.
-	CallWithEvaluationCallback(ctx context.Context, agentID, knowledgeBaseID, query string, callback *agentcallbacks.EvaluationCallbackHandler) error
+	// opts 覆盖 Agent 的模型配置（如评估时固定 temperature）.
+	CallWithEvaluationCallback(ctx context.Context, agentID, knowledgeBaseID, query string, callback *agentcallbacks.EvaluationCallbackHandler, opts ...einomodel.Option) error
 	// Close 关闭业务层，清理资源.
 	Close()
 }
 
+// ChatResult 对话结果.
+type ChatResult struct {
+	// Answer 最终回答（启用审核时为审核后的内容）
+	Answer string
+	// Usage 本次运行的 token 用量与费用汇总
+	Usage *trace.UsageSummary
+}
+
 type agentBiz struct {
-	store   store.Store
-	runners map[string]*agentic.Agent // agentID -> Agent 缓存
-	mu      sync.RWMutex
+	store        store.Store
+	moderator    *moderation.Moderator
+	retry        *retry.Config
+	sessionTools agenttools.SessionToolProvider
+	mcpTools     *mcp.ToolFactory
+	runners      map[string]*agentic.Agent // runnerKey -> Agent 缓存
+	mu           sync.RWMutex
 }
 
-// NewAgentBiz 创建 Agent 业务实例.
-func NewAgentBiz(s store.Store) AgentBiz {
+// NewAgentBiz 创建 Agent 业务实例，moderator 为 nil 时不审核回答，retryCfg 为 nil 时模型和工具使用默认重试策略，
+// sessionTools 为 nil 时不支持会话级工具（如 data_schema），配置了这些工具的 Agent 运行时跳过它们，
+// mcpTools 为 nil 时同样跳过 MCP 工具.
+func NewAgentBiz(s store.Store, moderator *moderation.Moderator, retryCfg *retry.Config, sessionTools agenttools.SessionToolProvider, mcpTools *mcp.ToolFactory) AgentBiz {
+	if retryCfg == nil {
+		retryCfg = &retry.Config{}
+	}
 	return &agentBiz{
-		store:   s,
-		runners: make(map[string]*agentic.Agent),
+		store:        s,
+		moderator:    moderator,
+		retry:        retryCfg,
+		sessionTools: sessionTools,
+		mcpTools:     mcpTools,
+		runners:      make(map[string]*agentic.Agent),
 	}
 }
 
+// createAgenticModel 按 Provider 配置创建模型，测试时替换为假模型.
+var createAgenticModel = models.CreateAgenticModel
+
+// runnerScope 构建运行实例时的调用方上下文.
+type runnerScope struct {
+	// toolPolicy 租户工具策略，nil 表示不过滤
+	toolPolicy *model.ToolPolicy
+	// sessionID 会话 ID，用于注入会话级工具，为空时不注入
+	sessionID string
+}
+
+// resolveModel 返回 Agent 使用的 Provider 和模型.
+// Agent 未指定 Provider 时使用 userID 所属租户的默认 Provider 和模型，fromDefaults 为 true.
+func (b *agentBiz) resolveModel(ctx context.Context, agent *model.Agent, userID string) (providerID, modelName string, fromDefaults bool, err error) {
+	if agent.ProviderID != "" {
+		return agent.ProviderID, agent.ModelName, false, nil
+	}
+	defaults := tenant.ResolveUserDefaults(ctx, b.store, userID)
+	if defaults.ChatProviderID == "" {
+		return "", "", false, fmt.Errorf("agent %s has no provider and no default chat provider is configured", agent.Name)
+	}
+	return defaults.ChatProviderID, defaults.ChatModel, true, nil
+}
+
 // getOrCreateAgent 获取或创建 Agent.
-func (b *agentBiz) getOrCreateAgent(ctx context.Context, agent *model.Agent) (*agentic.Agent, error) {
+// Agent 未指定 Provider 时使用 userID 所属租户的默认 Provider 和模型，工具按该租户的工具策略过滤.
+// 配置了会话级工具的 Agent 按 sessionID 创建运行实例且不缓存.
+func (b *agentBiz) getOrCreateAgent(ctx context.Context, agent *model.Agent, userID, sessionID string) (*agentic.Agent, error) {
+	providerID, modelName, fromDefaults, err := b.resolveModel(ctx, agent, userID)
+	if err != nil {
+		return nil, err
+	}
+	policy := tenant.ResolveUserToolPolicy(ctx, b.store, userID)
+	runnerKey := agent.ID
+	if fromDefaults {
+		// 不同租户的默认模型不同，分别缓存
+		runnerKey = agent.ID + "@" + providerID + "/" + modelName
+	}
+	if key := policy.Key(); key != "" {
+		// 工具策略不同时可用工具不同，分别缓存
+		runnerKey += "#" + key
+	}
+
 	b.mu.RLock()
-	if agentInst, ok := b.runners[agent.ID]; ok {
+	if agentInst, ok := b.runners[runnerKey]; ok {
 		b.mu.RUnlock()
 		return agentInst, nil
 	}
 	b.mu.RUnlock()
 
+	scope := &runnerScope{toolPolicy: policy, sessionID: sessionID}
+	sessionScoped, err := b.usesSessionTools(ctx, agent.ID)
+	if err != nil {
+		return nil, err
+	}
+	if sessionScoped {
+		return b.newRunner(ctx, agent, providerID, modelName, scope)
+	}
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	// 双重检查
-	if agentInst, ok := b.runners[agent.ID]; ok {
+	if agentInst, ok := b.runners[runnerKey]; ok {
 		return agentInst, nil
 	}
 
+	agentInst, err := b.newRunner(ctx, agent, providerID, modelName, scope)
+	if err != nil {
+		return nil, err
+	}
+	b.runners[runnerKey] = agentInst
+	return agentInst, nil
+}
+
+// invalidateRunners 丢弃 Agent 的所有缓存运行实例（各租户默认模型、各工具策略），下次对话时按最新配置重建.
+func (b *agentBiz) invalidateRunners(agentID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key := range b.runners {
+		if key == agentID || strings.HasPrefix(key, agentID+"@") || strings.HasPrefix(key, agentID+"#") {
+			delete(b.runners, key)
+		}
+	}
+}
+
+// newRunner 使用指定的 Provider 和模型创建 Agent 运行实例（不缓存）.
+func (b *agentBiz) newRunner(ctx context.Context, agent *model.Agent, providerID, modelName string, scope *runnerScope) (*agentic.Agent, error) {
 	// 获取 Provider 配置
-	provider, err := b.store.Providers().Get(ctx, agent.ProviderID)
+	provider, err := b.store.Providers().Get(ctx, providerID)
 	if err != nil {
 		return nil, fmt.Errorf("get provider: %w", err)
 	}
 
+	// 未指定模型时使用 Provider 默认模型
+	if modelName == "" {
+		modelName = provider.DefaultModel
+	}
+
 	// 创建 AgenticModel
 	modelCfg := &models.ModelConfig{
 		Provider: provider.Name,
-		Model:    agent.ModelName,
+		Model:    modelName,
 		APIKey:   provider.APIKey,
 		BaseURL:  provider.BaseURL,
+		Retry:    b.retry.Chat.WithDefaults(),
 	}
 
-	agenticModel, err := models.CreateAgenticModel(ctx, modelCfg)
+	agenticModel, err := createAgenticModel(ctx, modelCfg)
 	if err != nil {
 		return nil, fmt.Errorf("create agentic model: %w", err)
 	}
@@ -87,6 +199,13 @@ func (b *agentBiz) getOrCreateAgent(ctx context.Context, agent *model.Agent) (*a
 	skillTool := agenttools.NewSkillTool(skillBackend)
 	tools = append(tools, skillTool)
 
+	// 添加 Agent 配置的工具（按优先级排序）
+	agentTools, returnDirect, err := b.loadAgentTools(ctx, agent.ID, scope)
+	if err != nil {
+		return nil, err
+	}
+	tools = append(tools, agentTools...)
+
 	toolsConfig := compose.ToolsNodeConfig{
 		Tools: tools,
 	}
@@ -98,15 +217,14 @@ func (b *agentBiz) getOrCreateAgent(ctx context.Context, agent *model.Agent) (*a
 	}
 
 	agentInst, err := agentic.NewAgent(ctx, &agentic.AgentConfig{
-		Model:       agenticModel,
-		ToolsConfig: toolsConfig,
-		MaxStep:     maxStep,
+		Model:            agenticModel,
+		ToolsConfig:      toolsConfig,
+		MaxStep:          maxStep,
+		ToolReturnDirect: returnDirect,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("create agent: %w", err)
 	}
-
-	b.runners[agent.ID] = agentInst
 	return agentInst, nil
 }
 
@@ -136,25 +254,47 @@ func convertToAgenticMessages(session *model.Session, content string) []*schema.
 }
 
 // Chat 执行 Agent 对话.
-func (b *agentBiz) Chat(ctx context.Context, sessionID string, content string, sseWriter sse.Writer) error {
+func (b *agentBiz) Chat(ctx context.Context, sessionID string, content string, sseWriter sse.Writer) (*ChatResult, error) {
 	// 获取 Session 信息
 	session, err := b.store.Sessions().GetWithAgent(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("get session: %w", err)
+		return nil, fmt.Errorf("get session: %w", err)
 	}
 
 	// 发送开始事件
 	if err := sseWriter.SendStart(session.ID, session.ID); err != nil {
-		return err
+		return nil, err
+	}
+
+	if session.Agent == nil {
+		err := fmt.Errorf("session %s has no agent", session.ID)
+		sseWriter.SendError(err.Error())
+		return nil, err
 	}
 
 	// 获取或创建 Agent
-	agentInst, err := b.getOrCreateAgent(ctx, session.Agent)
+	agentInst, err := b.getOrCreateAgent(ctx, session.Agent, session.UserID, session.ID)
 	if err != nil {
 		sseWriter.SendError(err.Error())
-		return err
+		return nil, err
 	}
 
+	return b.run(ctx, session, agentInst, content, sseWriter)
+}
+
+// run 运行 Agent 并通过 SSE 发送事件，返回最终回答和用量.
+func (b *agentBiz) run(ctx context.Context, session *model.Session, agentInst *agentic.Agent, content string, sseWriter sse.Writer) (*ChatResult, error) {
+	// 启用审核时，回答经审核后再发送
+	var mw *moderatedWriter
+	if b.moderator.Enabled() {
+		mw = newModeratedWriter(ctx, sseWriter, b.moderator, session.ID)
+		defer mw.finish()
+		sseWriter = mw
+	}
+
+	// 运行级用量统计
+	ctx, usage := trace.WithRunUsage(ctx)
+
 	// 创建 SSE 适配器
 	adapter := sse.NewAgenticAdapter(sseWriter)
 
@@ -168,19 +308,56 @@ func (b *agentBiz) Chat(ctx context.Context, sessionID string, content string, s
 	stream, err := agentInst.Stream(ctx, messages, cb)
 	if err != nil {
 		sseWriter.SendError(err.Error())
-		return err
+		return nil, err
 	}
 	defer stream.Close()
 
-	// 消费流（事件已在 adapter 中发送）
+	// 消费流（事件已在 adapter 中发送），同时收集最终回答
+	var answer strings.Builder
 	for {
-		_, err := stream.Recv()
+		msg, err := stream.Recv()
 		if err != nil {
 			break
 		}
+		answer.WriteString(agenticText(msg))
+	}
+	// 等待适配器发送完所有事件，审核缓冲中剩余的回答随之发送
+	adapter.Wait()
+	if mw != nil {
+		_ = mw.flush()
 	}
 
-	return nil
+	// 汇总用量并发送
+	usage.Wait()
+	summary := usage.Summary()
+	log.Printf("agent run usage: session=%s calls=%d tokens=%d→%d cost=%.6f",
+		session.ID, summary.Calls, summary.PromptTokens, summary.CompletionTokens, summary.Cost)
+	_ = sseWriter.Send(sse.Event{
+		Type:      sse.EventTypeUsage,
+		SessionID: session.ID,
+		Data:      summary.Map(),
+	})
+
+	result := &ChatResult{Answer: answer.String(), Usage: summary}
+	if mw != nil {
+		// 持久化的回答与发送给客户端的保持一致（审核结果已由 mw 记录）
+		result.Answer = mw.answerText()
+	}
+	return result, nil
+}
+
+// agenticText 提取 AgenticMessage 中的生成文本.
+func agenticText(msg *schema.AgenticMessage) string {
+	if msg == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, block := range msg.ContentBlocks {
+		if block != nil && block.Type == schema.ContentBlockTypeAssistantGenText && block.AssistantGenText != nil {
+			sb.WriteString(block.AssistantGenText.Text)
+		}
+	}
+	return sb.String()
 }
 
 // Close 关闭业务层，清理资源.
@@ -190,10 +367,19 @@ func (b *agentBiz) Close() {
 
 	// 清理所有 Agent
 	b.runners = nil
+
+	if closer, ok := b.sessionTools.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("close session tools: %v", err)
+		}
+	}
+	if b.mcpTools != nil {
+		b.mcpTools.Close()
+	}
 }
 
 // CallWithEvaluationCallback 调用 RAG Agent 并使用评估 Callback 收集数据.
-func (b *agentBiz) CallWithEvaluationCallback(ctx context.Context, agentID, knowledgeBaseID, query string, callback *agentcallbacks.EvaluationCallbackHandler) error {
+func (b *agentBiz) CallWithEvaluationCallback(ctx context.Context, agentID, knowledgeBaseID, query string, callback *agentcallbacks.EvaluationCallbackHandler, opts ...einomodel.Option) error {
 	// 获取 Agent 配置
 	agent, err := b.store.Agents().Get(ctx, agentID)
 	if err != nil {
@@ -201,7 +387,7 @@ func (b *agentBiz) CallWithEvaluationCallback(ctx context.Context, agentID, know
 	}
 
 	// 获取或创建 Agent 实例
-	agentInst, err := b.getOrCreateAgent(ctx, agent)
+	agentInst, err := b.getOrCreateAgent(ctx, agent, "", "")
 	if err != nil {
 		return fmt.Errorf("create agent: %w", err)
 	}
@@ -215,8 +401,11 @@ func (b *agentBiz) CallWithEvaluationCallback(ctx context.Context, agentID, know
 	messages := convertToAgenticMessages(tempSession, query)
 
 	// 使用 Callback 调用 Agent
-	cb := compose.WithCallbacks(callback)
-	_, err = agentInst.Generate(ctx, messages, cb)
+	callOpts := []compose.Option{compose.WithCallbacks(callback)}
+	if len(opts) > 0 {
+		callOpts = append(callOpts, compose.WithChatModelOption(opts...))
+	}
+	_, err = agentInst.Generate(ctx, messages, callOpts...)
 	if err != nil {
 		return fmt.Errorf("agent generate: %w", err)
 	}
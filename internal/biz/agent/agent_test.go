@@ -0,0 +1,183 @@
+package agent
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/agent/agentic"
+)
+
+func webhookToolRequest(name string) AddAgentToolRequest {
+	return AddAgentToolRequest{
+		ToolType: model.ToolTypeCustom,
+		CustomToolConfig: model.JSONMap{
+			"name":        name,
+			"description": name + " tool",
+			"url":         "http://127.0.0.1:1/" + name,
+		},
+	}
+}
+
+func loadedToolNames(t *testing.T, b *agentBiz, agentID string, scope *runnerScope) []string {
+	t.Helper()
+	ctx := context.Background()
+	tools, _, err := b.loadAgentTools(ctx, agentID, scope)
+	if err != nil {
+		t.Fatalf("loadAgentTools: %v", err)
+	}
+	var names []string
+	for _, tl := range tools {
+		info, err := tl.Info(ctx)
+		if err != nil {
+			t.Fatalf("tool info: %v", err)
+		}
+		names = append(names, info.Name)
+	}
+	return names
+}
+
+func TestToolChangeInvalidatesCachedRunners(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.agents.agents["a1"] = &model.Agent{ID: "a1", Name: "a1"}
+	ab := NewAgentBiz(fs, nil, nil, nil, nil).(*agentBiz)
+	cb := NewConfigBiz(fs, ab)
+
+	if _, err := cb.SetAgentTools(ctx, "a1", []AddAgentToolRequest{webhookToolRequest("lookup")}); err != nil {
+		t.Fatalf("SetAgentTools: %v", err)
+	}
+	if got := loadedToolNames(t, ab, "a1", &runnerScope{}); !slices.Equal(got, []string{"lookup"}) {
+		t.Fatalf("tools before change = %v", got)
+	}
+
+	// 模拟此前对话缓存的运行实例：默认模型、工具策略各一份，另有 ID 前缀相同的其他 Agent
+	for _, key := range []string{"a1", "a1@p/m", "a1#allow=x;deny=", "a10", "other"} {
+		ab.runners[key] = &agentic.Agent{}
+	}
+
+	if _, err := cb.SetAgentTools(ctx, "a1", []AddAgentToolRequest{webhookToolRequest("search")}); err != nil {
+		t.Fatalf("SetAgentTools: %v", err)
+	}
+
+	for _, key := range []string{"a1", "a1@p/m", "a1#allow=x;deny="} {
+		if _, ok := ab.runners[key]; ok {
+			t.Errorf("runner %q still cached after tool change", key)
+		}
+	}
+	for _, key := range []string{"a10", "other"} {
+		if _, ok := ab.runners[key]; !ok {
+			t.Errorf("runner %q of another agent was dropped", key)
+		}
+	}
+	// 下次对话重建运行实例时使用新的工具集
+	if got := loadedToolNames(t, ab, "a1", &runnerScope{}); !slices.Equal(got, []string{"search"}) {
+		t.Fatalf("tools after change = %v, want [search]", got)
+	}
+}
+
+func TestAgentMutationsInvalidateRunners(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.agents.agents["a1"] = &model.Agent{ID: "a1", Name: "a1"}
+	ab := NewAgentBiz(fs, nil, nil, nil, nil).(*agentBiz)
+	cb := NewConfigBiz(fs, ab)
+	added, err := cb.AddAgentTool(ctx, "a1", &AddAgentToolRequest{ToolType: model.ToolTypeBuiltin, BuiltinToolName: "web_fetch"})
+	if err != nil {
+		t.Fatalf("AddAgentTool: %v", err)
+	}
+
+	prompt := "new prompt"
+	enabled := false
+	mutations := map[string]func() error{
+		"UpdateAgent": func() error {
+			_, err := cb.UpdateAgent(ctx, "a1", &UpdateAgentRequest{SystemPrompt: &prompt})
+			return err
+		},
+		"UpdateAgentTool": func() error {
+			_, err := cb.UpdateAgentTool(ctx, added.ID, &UpdateAgentToolRequest{IsEnabled: &enabled})
+			return err
+		},
+		"ReorderAgentTools": func() error {
+			_, err := cb.ReorderAgentTools(ctx, "a1", []string{added.ID})
+			return err
+		},
+		"RemoveAgentTool": func() error {
+			return cb.RemoveAgentTool(ctx, added.ID)
+		},
+	}
+	for _, name := range []string{"UpdateAgent", "UpdateAgentTool", "ReorderAgentTools", "RemoveAgentTool"} {
+		ab.runners["a1"] = &agentic.Agent{}
+		if err := mutations[name](); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, ok := ab.runners["a1"]; ok {
+			t.Errorf("%s did not invalidate the cached runner", name)
+		}
+	}
+}
+
+func TestToolsLoadInPriorityOrder(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.agents.agents["a1"] = &model.Agent{ID: "a1", Name: "a1"}
+	ab := NewAgentBiz(fs, nil, nil, nil, nil).(*agentBiz)
+	cb := NewConfigBiz(fs, ab)
+
+	// 按与优先级无关的顺序写入；存储按 map 返回，每次列出的顺序都不固定
+	for _, tt := range []struct {
+		id       string
+		name     string
+		priority int
+	}{
+		{"t1", "lookup", 0},
+		{"t2", "search", 5},
+		{"t3", "archive", 0},
+		{"t4", "notify", 10},
+		{"t5", "export", 5},
+	} {
+		fs.tools.tools[tt.id] = &model.AgentTool{
+			ID:               tt.id,
+			AgentID:          "a1",
+			ToolType:         model.ToolTypeCustom,
+			CustomToolConfig: webhookToolRequest(tt.name).CustomToolConfig,
+			IsEnabled:        true,
+			Priority:         tt.priority,
+		}
+	}
+	fs.tools.tools["t6"] = &model.AgentTool{ID: "t6", AgentID: "a1", ToolType: model.ToolTypeBuiltin, BuiltinToolName: "web_fetch", IsEnabled: true, Priority: 5}
+
+	// 优先级高的在前，优先级相同的按名称排列
+	want := []string{"notify", "export", "search", "web_fetch", "archive", "lookup"}
+	for i := 0; i < 20; i++ {
+		if got := loadedToolNames(t, ab, "a1", &runnerScope{}); !slices.Equal(got, want) {
+			t.Fatalf("tools = %v, want %v", got, want)
+		}
+	}
+
+	// 批量调整顺序后按新的优先级加载，未列出的工具保持原优先级
+	if _, err := cb.ReorderAgentTools(ctx, "a1", []string{"t1", "t3", "t2"}); err != nil {
+		t.Fatalf("ReorderAgentTools: %v", err)
+	}
+	want = []string{"notify", "export", "web_fetch", "lookup", "archive", "search"}
+	if got := loadedToolNames(t, ab, "a1", &runnerScope{}); !slices.Equal(got, want) {
+		t.Fatalf("tools after reorder = %v, want %v", got, want)
+	}
+}
+
+func TestSortAgentToolsBreaksTiesByID(t *testing.T) {
+	tools := []*model.AgentTool{
+		{ID: "c", ToolType: model.ToolTypeBuiltin, BuiltinToolName: "web_fetch"},
+		{ID: "a", ToolType: model.ToolTypeBuiltin, BuiltinToolName: "web_fetch"},
+		{ID: "b", ToolType: model.ToolTypeBuiltin, BuiltinToolName: "web_fetch"},
+	}
+	sortAgentTools(tools)
+	var ids []string
+	for _, at := range tools {
+		ids = append(ids, at.ID)
+	}
+	if !slices.Equal(ids, []string{"a", "b", "c"}) {
+		t.Errorf("order = %v, want ids ascending when priority and name are equal", ids)
+	}
+}
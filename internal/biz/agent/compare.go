@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
+	"github.com/ashwinyue/next-show/internal/pkg/trace"
+)
+
+// CompareRequest 多个 Agent 对比运行请求.
+type CompareRequest struct {
+	AgentIDs []string
+	Query    string
+	UserID   string
+}
+
+// CompareRun 单个 Agent 的对比运行结果.
+type CompareRun struct {
+	AgentID   string              `json:"agent_id"`
+	Answer    string              `json:"answer"`
+	Usage     *trace.UsageSummary `json:"usage,omitempty"`
+	LatencyMS int64               `json:"latency_ms"`
+	Error     string              `json:"error,omitempty"`
+	Events    []sse.Event         `json:"events"` // 完整运行过程（思考、工具调用等）
+}
+
+// Compare 使用临时会话并发运行多个 Agent，按请求顺序返回各自的结果.
+// 某个 Agent 失败不影响其它 Agent，失败原因记录在对应结果的 Error 中.
+func (b *agentBiz) Compare(ctx context.Context, req *CompareRequest) []*CompareRun {
+	runs := make([]*CompareRun, len(req.AgentIDs))
+
+	var wg sync.WaitGroup
+	for i, agentID := range req.AgentIDs {
+		wg.Add(1)
+		go func(i int, agentID string) {
+			defer wg.Done()
+
+			writer := sse.NewRecordingWriter()
+			run := &CompareRun{AgentID: agentID}
+			start := time.Now()
+			result, err := b.Preview(ctx, agentID, &PreviewRequest{Query: req.Query, UserID: req.UserID}, writer)
+			run.LatencyMS = time.Since(start).Milliseconds()
+			if err != nil {
+				run.Error = err.Error()
+			} else {
+				run.Answer = result.Answer
+				run.Usage = result.Usage
+			}
+			run.Events = writer.Events()
+			runs[i] = run
+		}(i, agentID)
+	}
+	wg.Wait()
+
+	return runs
+}
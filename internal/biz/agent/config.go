@@ -3,19 +3,26 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
+	"github.com/ashwinyue/next-show/internal/biz/errs"
 	"github.com/ashwinyue/next-show/internal/model"
 	"github.com/ashwinyue/next-show/internal/pkg/agent/builtin"
+	agenttools "github.com/ashwinyue/next-show/internal/pkg/agent/tools"
 	"github.com/ashwinyue/next-show/internal/store"
 )
 
+// ErrInvalidAgent Agent 配置不合法（如模型不被 Provider 支持）.
+var ErrInvalidAgent = errs.New(errs.ErrValidation, "invalid agent")
+
 // ConfigBiz Agent 配置业务接口.
 type ConfigBiz interface {
-	// ListAgents 列出所有 Agent.
-	ListAgents(ctx context.Context) ([]*model.Agent, error)
+	// ListAgents 列出所有 Agent，opts 指定排序和过滤（type、role）.
+	ListAgents(ctx context.Context, opts *store.ListOptions) ([]*model.Agent, error)
 	// GetAgent 获取 Agent 详情.
 	GetAgent(ctx context.Context, id string) (*model.Agent, error)
 	// CreateAgent 创建 Agent.
@@ -45,6 +52,10 @@ type ConfigBiz interface {
 	UpdateAgentTool(ctx context.Context, toolID string, req *UpdateAgentToolRequest) (*model.AgentTool, error)
 	// RemoveAgentTool 移除 Agent 工具.
 	RemoveAgentTool(ctx context.Context, toolID string) error
+	// SetAgentTools 整体替换 Agent 的工具集.
+	SetAgentTools(ctx context.Context, agentID string, reqs []AddAgentToolRequest) ([]*model.AgentTool, error)
+	// ReorderAgentTools 按给定顺序批量更新 Agent 工具优先级.
+	ReorderAgentTools(ctx context.Context, agentID string, toolIDs []string) ([]*model.AgentTool, error)
 	// ListBuiltinTools 列出可用的内置工具.
 	ListBuiltinTools(ctx context.Context) []string
 }
@@ -84,17 +95,35 @@ type UpdateAgentRequest struct {
 	SubAgentIDs   []string         `json:"sub_agent_ids,omitempty"`
 }
 
+// runnerInvalidator 在 Agent 配置变更后丢弃缓存的运行实例.
+type runnerInvalidator interface {
+	invalidateRunners(agentID string)
+}
+
 type configBiz struct {
-	store store.Store
+	store   store.Store
+	runners runnerInvalidator
 }
 
-// NewConfigBiz 创建 Agent 配置业务实例.
-func NewConfigBiz(s store.Store) ConfigBiz {
-	return &configBiz{store: s}
+// NewConfigBiz 创建 Agent 配置业务实例，agents 为运行 Agent 的业务实例，
+// Agent 或其工具变更后丢弃它缓存的运行实例；为 nil 时不处理.
+func NewConfigBiz(s store.Store, agents AgentBiz) ConfigBiz {
+	b := &configBiz{store: s}
+	if inv, ok := agents.(runnerInvalidator); ok {
+		b.runners = inv
+	}
+	return b
+}
+
+// invalidate 丢弃 Agent 缓存的运行实例.
+func (b *configBiz) invalidate(agentID string) {
+	if b.runners != nil {
+		b.runners.invalidateRunners(agentID)
+	}
 }
 
-func (b *configBiz) ListAgents(ctx context.Context) ([]*model.Agent, error) {
-	return b.store.Agents().ListAll(ctx)
+func (b *configBiz) ListAgents(ctx context.Context, opts *store.ListOptions) ([]*model.Agent, error) {
+	return b.store.Agents().ListAll(ctx, opts)
 }
 
 func (b *configBiz) GetAgent(ctx context.Context, id string) (*model.Agent, error) {
@@ -131,6 +160,12 @@ func (b *configBiz) CreateAgent(ctx context.Context, req *CreateAgentRequest) (*
 		agent.MaxIterations = 10
 	}
 
+	modelName, err := b.resolveModel(ctx, agent.ProviderID, agent.ModelName)
+	if err != nil {
+		return nil, err
+	}
+	agent.ModelName = modelName
+
 	if err := b.store.Agents().Create(ctx, agent); err != nil {
 		return nil, fmt.Errorf("create agent: %w", err)
 	}
@@ -146,16 +181,12 @@ func (b *configBiz) CreateAgent(ctx context.Context, req *CreateAgentRequest) (*
 }
 
 func (b *configBiz) UpdateAgent(ctx context.Context, id string, req *UpdateAgentRequest) (*model.Agent, error) {
-	agent, err := b.store.Agents().Get(ctx, id)
+	// 内置 Agent 不能修改
+	agent, err := b.getCustomAgent(ctx, id, "update")
 	if err != nil {
 		return nil, err
 	}
 
-	// 内置 Agent 不能修改
-	if agent.IsBuiltin {
-		return nil, fmt.Errorf("cannot update builtin agent")
-	}
-
 	// 更新字段
 	if req.Name != nil {
 		agent.Name = *req.Name
@@ -197,10 +228,20 @@ func (b *configBiz) UpdateAgent(ctx context.Context, id string, req *UpdateAgent
 		agent.IsEnabled = *req.IsEnabled
 	}
 
+	if req.ProviderID != nil || req.ModelName != nil {
+		modelName, err := b.resolveModel(ctx, agent.ProviderID, agent.ModelName)
+		if err != nil {
+			return nil, err
+		}
+		agent.ModelName = modelName
+	}
+
 	if err := b.store.Agents().Update(ctx, agent); err != nil {
 		return nil, fmt.Errorf("update agent: %w", err)
 	}
 
+	b.invalidate(id)
+
 	// 如果有子 Agent，更新关系
 	if req.SubAgentIDs != nil {
 		if err := b.SetAgentRelations(ctx, id, req.SubAgentIDs); err != nil {
@@ -211,15 +252,55 @@ func (b *configBiz) UpdateAgent(ctx context.Context, id string, req *UpdateAgent
 	return agent, nil
 }
 
-func (b *configBiz) DeleteAgent(ctx context.Context, id string) error {
+// getCustomAgent 获取可修改的自定义 Agent：不存在时返回 errs.ErrNotFound，内置 Agent 返回 errs.ErrForbidden.
+func (b *configBiz) getCustomAgent(ctx context.Context, id, action string) (*model.Agent, error) {
+	if builtin.GetBuiltinAgent(id) != nil {
+		return nil, fmt.Errorf("%w: cannot %s builtin agent", errs.ErrForbidden, action)
+	}
 	agent, err := b.store.Agents().Get(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: agent %s", errs.ErrNotFound, id)
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("get agent: %w", err)
 	}
+	if agent.IsBuiltin {
+		return nil, fmt.Errorf("%w: cannot %s builtin agent", errs.ErrForbidden, action)
+	}
+	return agent, nil
+}
+
+// resolveModel 校验 Agent 使用的 Provider 具备对话能力且模型受支持，未指定模型时使用 Provider 的默认模型.
+func (b *configBiz) resolveModel(ctx context.Context, providerID, modelName string) (string, error) {
+	if providerID == "" {
+		return modelName, nil
+	}
+
+	provider, err := b.store.Providers().Get(ctx, providerID)
+	if err != nil {
+		return "", fmt.Errorf("get provider: %w", err)
+	}
+
+	if !provider.HasCapability(model.ModelCategoryChat) {
+		return "", fmt.Errorf("%w: provider %s is not chat-capable and cannot be used by an agent", ErrInvalidAgent, provider.Name)
+	}
+
+	if modelName == "" {
+		modelName = provider.DefaultModel
+	}
+	if modelName == "" {
+		return "", fmt.Errorf("%w: model_name is required because provider %s has no default model", ErrInvalidAgent, provider.Name)
+	}
+	if !provider.SupportsModel(modelName) {
+		return "", fmt.Errorf("%w: model %q is not supported by provider %s", ErrInvalidAgent, modelName, provider.Name)
+	}
+	return modelName, nil
+}
 
+func (b *configBiz) DeleteAgent(ctx context.Context, id string) error {
 	// 内置 Agent 不能删除
-	if agent.IsBuiltin {
-		return fmt.Errorf("cannot delete builtin agent")
+	if _, err := b.getCustomAgent(ctx, id, "delete"); err != nil {
+		return err
 	}
 
 	// 删除关联关系
@@ -227,7 +308,11 @@ func (b *configBiz) DeleteAgent(ctx context.Context, id string) error {
 		return fmt.Errorf("delete agent relations: %w", err)
 	}
 
-	return b.store.Agents().Delete(ctx, id)
+	if err := b.store.Agents().Delete(ctx, id); err != nil {
+		return err
+	}
+	b.invalidate(id)
+	return nil
 }
 
 func (b *configBiz) ListBuiltinAgents(ctx context.Context) ([]*model.Agent, error) {
@@ -302,8 +387,22 @@ type AddAgentToolRequest struct {
 	Priority         int            `json:"priority"`
 }
 
+// validate 校验自定义工具配置，目前只支持 HTTP Webhook 工具.
+func (r *AddAgentToolRequest) validate() error {
+	if r.ToolType != model.ToolTypeCustom {
+		return nil
+	}
+	if _, err := agenttools.ParseWebhookToolConfig(r.CustomToolConfig); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAgent, err)
+	}
+	return nil
+}
+
 // AddAgentTool 为 Agent 添加工具.
 func (b *configBiz) AddAgentTool(ctx context.Context, agentID string, req *AddAgentToolRequest) (*model.AgentTool, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
 	agentTool := &model.AgentTool{
 		ID:               uuid.New().String(),
 		AgentID:          agentID,
@@ -319,6 +418,7 @@ func (b *configBiz) AddAgentTool(ctx context.Context, agentID string, req *AddAg
 	if err := b.store.AgentTools().Create(ctx, agentTool); err != nil {
 		return nil, fmt.Errorf("create agent tool: %w", err)
 	}
+	b.invalidate(agentID)
 
 	return agentTool, nil
 }
@@ -350,13 +450,133 @@ func (b *configBiz) UpdateAgentTool(ctx context.Context, toolID string, req *Upd
 	if err := b.store.AgentTools().Update(ctx, agentTool); err != nil {
 		return nil, fmt.Errorf("update agent tool: %w", err)
 	}
+	b.invalidate(agentTool.AgentID)
 
 	return agentTool, nil
 }
 
 // RemoveAgentTool 移除 Agent 工具.
 func (b *configBiz) RemoveAgentTool(ctx context.Context, toolID string) error {
-	return b.store.AgentTools().Delete(ctx, toolID)
+	agentTool, err := b.store.AgentTools().Get(ctx, toolID)
+	if err != nil {
+		return err
+	}
+	if err := b.store.AgentTools().Delete(ctx, toolID); err != nil {
+		return err
+	}
+	b.invalidate(agentTool.AgentID)
+	return nil
+}
+
+// SetAgentTools 整体替换 Agent 的工具集.
+// 与现有工具指向同一工具（同类型且同一 MCP 工具 / 内置工具 / 自定义工具名）的请求保留原 ID，
+// 其余请求新建，未出现在请求中的现有工具被删除，整个过程在一个事务中完成.
+func (b *configBiz) SetAgentTools(ctx context.Context, agentID string, reqs []AddAgentToolRequest) ([]*model.AgentTool, error) {
+	if _, err := b.store.Agents().Get(ctx, agentID); err != nil {
+		return nil, fmt.Errorf("get agent: %w", err)
+	}
+
+	existing, err := b.store.AgentTools().ListByAgent(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("list agent tools: %w", err)
+	}
+	byKey := make(map[string]*model.AgentTool, len(existing))
+	for _, t := range existing {
+		if key := agentToolKey(t.ToolType, t.MCPToolID, t.BuiltinToolName, t.CustomToolConfig); key != "" {
+			byKey[key] = t
+		}
+	}
+
+	tools := make([]*model.AgentTool, 0, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+	for i := range reqs {
+		req := &reqs[i]
+		if err := req.validate(); err != nil {
+			return nil, err
+		}
+		key := agentToolKey(req.ToolType, req.MCPToolID, req.BuiltinToolName, req.CustomToolConfig)
+		if key != "" {
+			if seen[key] {
+				return nil, fmt.Errorf("%w: duplicate tool %s", ErrInvalidAgent, key)
+			}
+			seen[key] = true
+		}
+
+		tool := &model.AgentTool{
+			ID:        uuid.New().String(),
+			AgentID:   agentID,
+			IsEnabled: true,
+		}
+		if prev, ok := byKey[key]; ok {
+			tool.ID = prev.ID
+			tool.IsEnabled = prev.IsEnabled
+			tool.CreatedAt = prev.CreatedAt
+		}
+		tool.ToolType = req.ToolType
+		tool.MCPToolID = req.MCPToolID
+		tool.BuiltinToolName = req.BuiltinToolName
+		tool.CustomToolConfig = req.CustomToolConfig
+		tool.ReturnDirectly = req.ReturnDirectly
+		tool.Priority = req.Priority
+		tools = append(tools, tool)
+	}
+
+	if err := b.store.AgentTools().ReplaceByAgent(ctx, agentID, tools); err != nil {
+		return nil, fmt.Errorf("replace agent tools: %w", err)
+	}
+	b.invalidate(agentID)
+
+	return tools, nil
+}
+
+// ReorderAgentTools 按给定顺序批量更新 Agent 工具优先级.
+// 排在前面的工具优先级更高，未列出的工具优先级保持不变.
+func (b *configBiz) ReorderAgentTools(ctx context.Context, agentID string, toolIDs []string) ([]*model.AgentTool, error) {
+	existing, err := b.store.AgentTools().ListByAgent(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("list agent tools: %w", err)
+	}
+	owned := make(map[string]bool, len(existing))
+	for _, t := range existing {
+		owned[t.ID] = true
+	}
+
+	priorities := make(map[string]int, len(toolIDs))
+	for i, id := range toolIDs {
+		if !owned[id] {
+			return nil, fmt.Errorf("%w: tool %s does not belong to agent %s", ErrInvalidAgent, id, agentID)
+		}
+		if _, dup := priorities[id]; dup {
+			return nil, fmt.Errorf("%w: duplicate tool %s", ErrInvalidAgent, id)
+		}
+		priorities[id] = len(toolIDs) - i
+	}
+
+	if err := b.store.AgentTools().UpdatePriorities(ctx, agentID, priorities); err != nil {
+		return nil, fmt.Errorf("update agent tool priorities: %w", err)
+	}
+	b.invalidate(agentID)
+
+	return b.store.AgentTools().ListByAgent(ctx, agentID)
+}
+
+// agentToolKey 返回工具的唯一标识，无法识别时（如未命名的自定义工具）返回空字符串.
+func agentToolKey(toolType model.ToolType, mcpToolID *string, builtinName string, customConfig model.JSONMap) string {
+	switch toolType {
+	case model.ToolTypeMCP:
+		if mcpToolID != nil && *mcpToolID != "" {
+			return "mcp:" + *mcpToolID
+		}
+	case model.ToolTypeBuiltin:
+		if builtinName != "" {
+			return "builtin:" + builtinName
+		}
+	case model.ToolTypeCustom:
+		if name, _ := customConfig["name"].(string); name != "" {
+			return "custom:" + name
+		}
+	}
+	return ""
 }
 
 // ListBuiltinTools 列出可用的内置工具.
@@ -367,6 +587,7 @@ func (b *configBiz) ListBuiltinTools(ctx context.Context) []string {
 		"knowledge_search",
 		"grep_chunks",
 		"list_knowledge_chunks",
+		"summarize_document",
 		"data_schema",
 		"data_analysis",
 	}
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/agent/builtin"
+)
+
+func TestBuiltinAgentMutationsForbidden(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.agents.agents["seeded"] = &model.Agent{ID: "seeded", Name: "seeded", IsBuiltin: true}
+	cb := NewConfigBiz(fs, nil)
+	name := "renamed"
+
+	for _, id := range []string{builtin.ListBuiltinAgents()[0].ID, "seeded"} {
+		if _, err := cb.UpdateAgent(ctx, id, &UpdateAgentRequest{Name: &name}); !errors.Is(err, errs.ErrForbidden) {
+			t.Errorf("UpdateAgent(%s) error = %v, want ErrForbidden", id, err)
+		}
+		if err := cb.DeleteAgent(ctx, id); !errors.Is(err, errs.ErrForbidden) {
+			t.Errorf("DeleteAgent(%s) error = %v, want ErrForbidden", id, err)
+		}
+	}
+	if _, err := cb.UpdateAgent(ctx, "missing", &UpdateAgentRequest{Name: &name}); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("UpdateAgent(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAgentModelValidatedAgainstProvider(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.providers.providers["p1"] = &model.Provider{
+		ID: "p1", Name: "openai", ModelCategory: model.ModelCategoryChat,
+		DefaultModel: "gpt-4o", SupportedModels: model.JSONSlice{"gpt-4o", "gpt-4o-mini"},
+	}
+	fs.providers.providers["p2"] = &model.Provider{ID: "p2", Name: "ollama", ModelCategory: model.ModelCategoryChat}
+	cb := NewConfigBiz(fs, nil)
+
+	// 创建时拒绝 Provider 不支持的模型
+	if _, err := cb.CreateAgent(ctx, &CreateAgentRequest{Name: "a", ProviderID: "p1", ModelName: "claude-3"}); !errors.Is(err, ErrInvalidAgent) || !errors.Is(err, errs.ErrValidation) {
+		t.Errorf("CreateAgent(unsupported model) error = %v, want a validation error", err)
+	}
+	if _, err := cb.CreateAgent(ctx, &CreateAgentRequest{Name: "a", ProviderID: "p2"}); !errors.Is(err, ErrInvalidAgent) {
+		t.Errorf("CreateAgent(no model, no provider default) error = %v, want a validation error", err)
+	}
+	if len(fs.agents.agents) != 0 {
+		t.Fatalf("rejected agents were stored: %v", fs.agents.agents)
+	}
+
+	// 未指定模型时使用 Provider 默认模型
+	agent, err := cb.CreateAgent(ctx, &CreateAgentRequest{Name: "a", ProviderID: "p1"})
+	if err != nil {
+		t.Fatalf("CreateAgent: %v", err)
+	}
+	if agent.ModelName != "gpt-4o" {
+		t.Errorf("model = %q, want the provider default", agent.ModelName)
+	}
+
+	// 更新时同样拒绝不支持的模型，已保存的配置不变
+	unsupported := "claude-3"
+	if _, err := cb.UpdateAgent(ctx, agent.ID, &UpdateAgentRequest{ModelName: &unsupported}); !errors.Is(err, ErrInvalidAgent) || !errors.Is(err, errs.ErrValidation) {
+		t.Errorf("UpdateAgent(unsupported model) error = %v, want a validation error", err)
+	}
+	if got := fs.agents.agents[agent.ID].ModelName; got != "gpt-4o" {
+		t.Errorf("stored model = %q after rejected update, want gpt-4o", got)
+	}
+	supported := "gpt-4o-mini"
+	if updated, err := cb.UpdateAgent(ctx, agent.ID, &UpdateAgentRequest{ModelName: &supported}); err != nil || updated.ModelName != supported {
+		t.Errorf("UpdateAgent(supported model) = %v, %v, want %s", updated, err, supported)
+	}
+}
+
+func TestAgentRequiresChatProvider(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.providers.providers["embedding"] = &model.Provider{ID: "embedding", Name: "bge", ModelCategory: model.ModelCategoryEmbedding, DefaultModel: "bge-m3"}
+	fs.providers.providers["rerank"] = &model.Provider{ID: "rerank", Name: "cohere", ModelCategory: model.ModelCategoryRerank, DefaultModel: "rerank-v3"}
+	fs.providers.providers["chat"] = &model.Provider{ID: "chat", Name: "openai", ModelCategory: model.ModelCategoryChat, DefaultModel: "gpt-4o"}
+	cb := NewConfigBiz(fs, nil)
+
+	agent, err := cb.CreateAgent(ctx, &CreateAgentRequest{Name: "a", ProviderID: "chat"})
+	if err != nil {
+		t.Fatalf("CreateAgent(chat provider): %v", err)
+	}
+	for _, providerID := range []string{"embedding", "rerank"} {
+		if _, err := cb.CreateAgent(ctx, &CreateAgentRequest{Name: "b", ProviderID: providerID}); !errors.Is(err, ErrInvalidAgent) {
+			t.Errorf("CreateAgent(%s provider) error = %v, want ErrInvalidAgent", providerID, err)
+		}
+		if _, err := cb.UpdateAgent(ctx, agent.ID, &UpdateAgentRequest{ProviderID: &providerID}); !errors.Is(err, ErrInvalidAgent) {
+			t.Errorf("UpdateAgent(%s provider) error = %v, want ErrInvalidAgent", providerID, err)
+		}
+	}
+	if got := fs.agents.agents[agent.ID].ProviderID; got != "chat" {
+		t.Errorf("stored provider = %q after rejected updates, want chat", got)
+	}
+}
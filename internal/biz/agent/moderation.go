@@ -0,0 +1,219 @@
+// Package agent 提供 Agent 业务逻辑.
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ashwinyue/next-show/internal/pkg/moderation"
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
+)
+
+// answerBlockedMessage 回答被拦截时发送给客户端的提示.
+const answerBlockedMessage = "回答内容未通过审核，已被拦截"
+
+// 流式回答的审核窗口：累计到句子边界且不少于 moderationWindowMinRunes 时审核并输出，
+// 一直没有句子边界时最多缓冲 moderationWindowMaxRunes.
+const (
+	moderationWindowMinRunes = 80
+	moderationWindowMaxRunes = 400
+)
+
+// sentenceEnds 窗口可以切分的句子结束符.
+const sentenceEnds = "。！？；!?;\n"
+
+// moderatedWriter 对流式回答进行审核的 SSE 写入器.
+//
+// block 和 redact 模式下回答增量先缓冲，按窗口（句子边界，见 moderationWindowMinRunes）审核通过后再发送，
+// 客户端不会收到未经审核的内容；block 命中时丢弃当前窗口并停止输出，已发送的窗口都已单独通过审核.
+// redact 模式下替换窗口内的命中片段，提供方无法定位片段时替换整个窗口.
+// 各窗口独立审核，跨越窗口边界的关键词可能漏检.
+// flag 模式下原样输出，仅在结束时审核完整回答并记录.
+type moderatedWriter struct {
+	sse.Writer
+	ctx       context.Context
+	moderator *moderation.Moderator
+	sessionID string
+
+	mu       sync.Mutex
+	answer   strings.Builder // 模型生成的完整回答
+	pending  []rune          // 尚未审核的回答
+	sent     strings.Builder // 审核后已发送的回答
+	template sse.Event       // 最近一个回答事件，窗口按它的字段发送
+	blocked  bool
+	recorded bool
+	outcome  moderation.Result
+}
+
+func newModeratedWriter(ctx context.Context, w sse.Writer, m *moderation.Moderator, sessionID string) *moderatedWriter {
+	return &moderatedWriter{
+		Writer:    w,
+		ctx:       ctx,
+		moderator: m,
+		sessionID: sessionID,
+	}
+}
+
+// Send 缓冲回答事件，窗口审核通过后发送.
+func (w *moderatedWriter) Send(event sse.Event) error {
+	if event.Type != sse.EventTypeAnswer {
+		return w.Writer.Send(event)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.blocked {
+		return nil
+	}
+	if w.moderator.AnswerAction() == moderation.ActionFlag {
+		w.answer.WriteString(event.Content)
+		return w.Writer.Send(event)
+	}
+	if event.Content == "" {
+		// 图片等非文本回答保持与前面文本的顺序
+		if err := w.releaseLocked(true); err != nil || w.blocked {
+			return err
+		}
+		return w.Writer.Send(event)
+	}
+
+	w.answer.WriteString(event.Content)
+	w.pending = append(w.pending, []rune(event.Content)...)
+	w.template = event
+	return w.releaseLocked(false)
+}
+
+// SendComplete 发送剩余的回答后发送完成事件.
+func (w *moderatedWriter) SendComplete(sessionID, messageID string) error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.Writer.SendComplete(sessionID, messageID)
+}
+
+// SendError 发送剩余的回答后发送错误事件.
+func (w *moderatedWriter) SendError(message string) error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.Writer.SendError(message)
+}
+
+// flush 审核并发送缓冲中剩余的回答.
+func (w *moderatedWriter) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.releaseLocked(true)
+}
+
+// releaseLocked 依次审核并发送缓冲中已完整的窗口，force 时不等待句子边界发送全部剩余内容.
+func (w *moderatedWriter) releaseLocked(force bool) error {
+	for !w.blocked && len(w.pending) > 0 {
+		n := windowEnd(w.pending, force)
+		if n == 0 {
+			return nil
+		}
+		window := string(w.pending[:n])
+		w.pending = w.pending[n:]
+
+		result := w.moderator.EvaluateAnswer(w.ctx, window)
+		w.merge(result)
+		if result.Blocked {
+			w.blocked = true
+			w.pending = nil
+			w.moderator.Record(w.ctx, moderation.TargetAnswer, w.sessionID, &w.outcome)
+			w.recorded = true
+			return w.Writer.SendError(answerBlockedMessage)
+		}
+
+		event := w.template
+		event.Content = result.Content
+		w.sent.WriteString(result.Content)
+		if err := w.Writer.Send(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// windowEnd 返回下一个审核窗口的长度，缓冲内容还不足一个窗口时返回 0.
+func windowEnd(pending []rune, force bool) int {
+	if force {
+		return len(pending)
+	}
+	if len(pending) < moderationWindowMinRunes {
+		return 0
+	}
+	limit := min(len(pending), moderationWindowMaxRunes)
+	for i := limit - 1; i >= moderationWindowMinRunes-1; i-- {
+		if strings.ContainsRune(sentenceEnds, pending[i]) {
+			return i + 1
+		}
+	}
+	if len(pending) >= moderationWindowMaxRunes {
+		return moderationWindowMaxRunes
+	}
+	return 0
+}
+
+// answerText 返回发送给客户端的回答，用于持久化.
+func (w *moderatedWriter) answerText() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case w.blocked:
+		return answerBlockedMessage
+	case w.moderator.AnswerAction() == moderation.ActionFlag:
+		return w.answer.String()
+	default:
+		return w.sent.String()
+	}
+}
+
+// finish 在回答结束后记录审核结果.
+func (w *moderatedWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.recorded {
+		return
+	}
+	w.recorded = true
+
+	if w.moderator.AnswerAction() == moderation.ActionFlag {
+		w.moderator.CheckAnswer(w.ctx, w.sessionID, w.answer.String())
+		return
+	}
+	w.moderator.Record(w.ctx, moderation.TargetAnswer, w.sessionID, &w.outcome)
+}
+
+// merge 汇总各窗口的审核结果.
+func (w *moderatedWriter) merge(result *moderation.Result) {
+	if result.Flagged {
+		w.outcome.Flagged = true
+		w.outcome.Action = result.Action
+		for _, c := range result.Categories {
+			if !containsString(w.outcome.Categories, c) {
+				w.outcome.Categories = append(w.outcome.Categories, c)
+			}
+		}
+	}
+	if result.Blocked {
+		w.outcome.Blocked = true
+	}
+	if result.Err != nil {
+		w.outcome.Err = result.Err
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
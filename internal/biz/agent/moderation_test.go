@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/pkg/moderation"
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
+)
+
+// recordingWriter 记录发送的 SSE 事件.
+type recordingWriter struct {
+	mu     sync.Mutex
+	events []sse.Event
+}
+
+func (w *recordingWriter) Send(event sse.Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = append(w.events, event)
+	return nil
+}
+
+func (w *recordingWriter) Flush()                      {}
+func (w *recordingWriter) SetHeaders()                 {}
+func (w *recordingWriter) SendStart(_, _ string) error { return nil }
+func (w *recordingWriter) SendComplete(_, _ string) error {
+	return w.Send(sse.Event{Type: sse.EventTypeComplete})
+}
+func (w *recordingWriter) SendError(message string) error {
+	return w.Send(sse.Event{Type: sse.EventTypeError, Content: message})
+}
+
+// answer 返回客户端收到的回答文本.
+func (w *recordingWriter) answer() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var sb strings.Builder
+	for _, e := range w.events {
+		if e.Type == sse.EventTypeAnswer {
+			sb.WriteString(e.Content)
+		}
+	}
+	return sb.String()
+}
+
+func (w *recordingWriter) count(t sse.EventType) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for _, e := range w.events {
+		if e.Type == t {
+			n++
+		}
+	}
+	return n
+}
+
+// countingProvider 统计审核调用次数，keyword 为空时不命中；spans 为 false 时模拟无法定位片段的提供方（如 OpenAI）.
+type countingProvider struct {
+	keyword string
+	spans   bool
+	calls   int
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Check(_ context.Context, text string) (*moderation.Verdict, error) {
+	p.calls++
+	if p.keyword == "" || !strings.Contains(text, p.keyword) {
+		return &moderation.Verdict{}, nil
+	}
+	verdict := &moderation.Verdict{Flagged: true, Categories: []string{"test"}}
+	if p.spans {
+		verdict.Spans = []string{p.keyword}
+	}
+	return verdict, nil
+}
+
+// sentence 生成一句 n 个字的句子.
+func sentence(word string, n int) string {
+	return strings.Repeat(word, n) + "。"
+}
+
+// streamAnswer 以每次 5 个字的增量发送回答，结束时发送完成事件.
+func streamAnswer(t *testing.T, w *moderatedWriter, text string) {
+	t.Helper()
+	runes := []rune(text)
+	for i := 0; i < len(runes); i += 5 {
+		end := min(i+5, len(runes))
+		if err := w.Send(sse.Event{Type: sse.EventTypeAnswer, Content: string(runes[i:end])}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+	if err := w.SendComplete("", ""); err != nil {
+		t.Fatalf("SendComplete() error = %v", err)
+	}
+}
+
+func newTestModeratedWriter(provider moderation.Provider, action moderation.Action) (*moderatedWriter, *recordingWriter) {
+	inner := &recordingWriter{}
+	m := moderation.NewModerator(provider, nil, &moderation.Config{AnswerAction: action, RedactPlaceholder: "***"})
+	return newModeratedWriter(context.Background(), inner, m, "s1"), inner
+}
+
+func TestModeratedWriterBlockStopsBeforeFlaggedWindow(t *testing.T) {
+	provider := &countingProvider{keyword: "坏"}
+	w, inner := newTestModeratedWriter(provider, moderation.ActionBlock)
+
+	clean := sentence("好", 90) + sentence("中", 90)
+	streamAnswer(t, w, clean+sentence("好", 40)+"坏"+sentence("好", 49)+sentence("后", 90))
+
+	if got := inner.answer(); got != clean {
+		t.Errorf("sent answer = %q, want only the windows before the flagged one", got)
+	}
+	if strings.Contains(inner.answer(), "坏") {
+		t.Error("flagged content reached the client")
+	}
+	if inner.count(sse.EventTypeError) != 1 {
+		t.Errorf("error events = %d, want 1", inner.count(sse.EventTypeError))
+	}
+	// 每个窗口审核一次，而不是每个增量审核一次累计回答
+	if provider.calls != 3 {
+		t.Errorf("moderation calls = %d, want 3 windows", provider.calls)
+	}
+	if got := w.answerText(); got != answerBlockedMessage {
+		t.Errorf("answerText() = %q, want blocked message", got)
+	}
+}
+
+func TestModeratedWriterBuffersUntilWindowPasses(t *testing.T) {
+	provider := &countingProvider{}
+	w, inner := newTestModeratedWriter(provider, moderation.ActionBlock)
+
+	if err := w.Send(sse.Event{Type: sse.EventTypeAnswer, Content: "短句。"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if inner.count(sse.EventTypeAnswer) != 0 || provider.calls != 0 {
+		t.Fatalf("short answer sent before a full window: events=%d calls=%d", inner.count(sse.EventTypeAnswer), provider.calls)
+	}
+	if err := w.SendComplete("", ""); err != nil {
+		t.Fatalf("SendComplete() error = %v", err)
+	}
+	if inner.answer() != "短句。" || provider.calls != 1 {
+		t.Errorf("after complete: answer=%q calls=%d", inner.answer(), provider.calls)
+	}
+	if inner.events[len(inner.events)-1].Type != sse.EventTypeComplete {
+		t.Error("complete event was sent before the remaining answer")
+	}
+}
+
+func TestModeratedWriterRedact(t *testing.T) {
+	text := sentence("好", 90) + sentence("好", 40) + "坏" + sentence("好", 49)
+	want := strings.Replace(text, "坏", "***", 1)
+
+	provider := &countingProvider{keyword: "坏", spans: true}
+	w, inner := newTestModeratedWriter(provider, moderation.ActionRedact)
+	streamAnswer(t, w, text)
+
+	if got := inner.answer(); got != want {
+		t.Errorf("sent answer = %q, want %q", got, want)
+	}
+	if got := w.answerText(); got != want {
+		t.Errorf("answerText() = %q, want the redacted answer that was sent", got)
+	}
+	if inner.count(sse.EventTypeError) != 0 {
+		t.Error("redact mode must not block the answer")
+	}
+}
+
+func TestModeratedWriterRedactWithoutSpans(t *testing.T) {
+	first := sentence("好", 90)
+	last := sentence("后", 90)
+	provider := &countingProvider{keyword: "坏", spans: false}
+	w, inner := newTestModeratedWriter(provider, moderation.ActionRedact)
+	streamAnswer(t, w, first+sentence("坏", 90)+last)
+
+	// 无法定位片段时替换整个窗口，回答继续输出
+	if got, want := inner.answer(), first+"***"+last; got != want {
+		t.Errorf("sent answer = %q, want %q", got, want)
+	}
+	if inner.count(sse.EventTypeError) != 0 {
+		t.Error("redact mode must not degrade to block")
+	}
+}
+
+func TestModeratedWriterFlagPassesThrough(t *testing.T) {
+	provider := &countingProvider{keyword: "坏"}
+	w, inner := newTestModeratedWriter(provider, moderation.ActionFlag)
+	text := "有坏内容。"
+	streamAnswer(t, w, text)
+	w.finish()
+
+	if inner.answer() != text || w.answerText() != text {
+		t.Errorf("flag mode changed the answer: sent=%q persisted=%q", inner.answer(), w.answerText())
+	}
+	if provider.calls != 1 {
+		t.Errorf("moderation calls = %d, want 1 for the full answer", provider.calls)
+	}
+}
+
+func TestWindowEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		force bool
+		want  int
+	}{
+		{name: "too short", text: sentence("字", 10), want: 0},
+		{name: "short forced", text: "字字", force: true, want: 2},
+		{name: "boundary after min", text: sentence("字", 90) + "字字", want: 91},
+		{name: "last boundary wins", text: sentence("字", 90) + sentence("字", 9) + "字", want: 101},
+		{name: "no boundary yet", text: strings.Repeat("字", 200), want: 0},
+		{name: "no boundary at max", text: strings.Repeat("字", moderationWindowMaxRunes+10), want: moderationWindowMaxRunes},
+		{name: "boundary before min only", text: sentence("字", 10) + strings.Repeat("字", 100), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windowEnd([]rune(tt.text), tt.force); got != tt.want {
+				t.Errorf("windowEnd() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/ashwinyue/next-show/internal/biz/tenant"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/agent/agentic"
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
+)
+
+// PreviewSessionPrefix 预览运行使用的临时会话 ID 前缀.
+const PreviewSessionPrefix = "preview-"
+
+// PreviewRequest Agent 预览运行请求，覆盖字段为空时使用 Agent 自身配置.
+type PreviewRequest struct {
+	Query         string
+	UserID        string
+	ProviderID    string
+	ModelName     string
+	SystemPrompt  *string
+	MaxIterations int
+}
+
+// Preview 使用内存中的临时会话运行 Agent，不创建会话也不保存消息.
+// 覆盖 Provider、模型或最大迭代次数时创建独立的运行实例，不影响缓存的 Agent.
+func (b *agentBiz) Preview(ctx context.Context, agentID string, req *PreviewRequest, sseWriter sse.Writer) (*ChatResult, error) {
+	agent, err := b.store.Agents().Get(ctx, agentID)
+	if err != nil {
+		return nil, fmt.Errorf("get agent: %w", err)
+	}
+
+	// 在副本上应用覆盖，避免修改缓存中的配置
+	preview := *agent
+	if req.SystemPrompt != nil {
+		preview.SystemPrompt = *req.SystemPrompt
+	}
+	session := &model.Session{
+		ID:      PreviewSessionPrefix + uuid.New().String(),
+		AgentID: agent.ID,
+		UserID:  req.UserID,
+		Agent:   &preview,
+	}
+
+	if err := sseWriter.SendStart(session.ID, session.ID); err != nil {
+		return nil, err
+	}
+
+	var agentInst *agentic.Agent
+	if req.ProviderID == "" && req.ModelName == "" && req.MaxIterations <= 0 {
+		agentInst, err = b.getOrCreateAgent(ctx, &preview, req.UserID, session.ID)
+	} else {
+		agentInst, err = b.newPreviewRunner(ctx, &preview, req, session.ID)
+	}
+	if err != nil {
+		sseWriter.SendError(err.Error())
+		return nil, err
+	}
+
+	// 临时会话不会再使用，释放会话级工具占用的资源
+	defer b.releaseSessionTools(session.ID)
+
+	return b.run(ctx, session, agentInst, req.Query, sseWriter)
+}
+
+// newPreviewRunner 按覆盖配置创建不缓存的运行实例.
+func (b *agentBiz) newPreviewRunner(ctx context.Context, agent *model.Agent, req *PreviewRequest, sessionID string) (*agentic.Agent, error) {
+	if req.ProviderID != "" {
+		agent.ProviderID = req.ProviderID
+		agent.ModelName = req.ModelName
+	} else if req.ModelName != "" {
+		agent.ModelName = req.ModelName
+	}
+	if req.MaxIterations > 0 {
+		agent.MaxIterations = req.MaxIterations
+	}
+
+	providerID, modelName, _, err := b.resolveModel(ctx, agent, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ModelName != "" {
+		modelName = req.ModelName
+	}
+	return b.newRunner(ctx, agent, providerID, modelName, &runnerScope{
+		toolPolicy: tenant.ResolveUserToolPolicy(ctx, b.store, req.UserID),
+		sessionID:  sessionID,
+	})
+}
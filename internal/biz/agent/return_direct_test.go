@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	einomodel "github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/models"
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
+)
+
+// countingModel 首次调用请求 lookup 工具，之后返回自己的回答，记录被调用的次数.
+type countingModel struct {
+	calls atomic.Int32
+}
+
+func (m *countingModel) Generate(context.Context, []*schema.AgenticMessage, ...einomodel.Option) (*schema.AgenticMessage, error) {
+	if m.calls.Add(1) == 1 {
+		return &schema.AgenticMessage{
+			Role: schema.AgenticRoleTypeAssistant,
+			ContentBlocks: []*schema.ContentBlock{schema.NewContentBlock(&schema.FunctionToolCall{
+				CallID: "call1", Name: "lookup", Arguments: `{"id":1}`,
+			})},
+		}, nil
+	}
+	return &schema.AgenticMessage{
+		Role:          schema.AgenticRoleTypeAssistant,
+		ContentBlocks: []*schema.ContentBlock{schema.NewContentBlock(&schema.AssistantGenText{Text: "model answer"})},
+	}, nil
+}
+
+func (m *countingModel) Stream(ctx context.Context, input []*schema.AgenticMessage, opts ...einomodel.Option) (*schema.StreamReader[*schema.AgenticMessage], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.AgenticMessage{msg}), nil
+}
+
+func (m *countingModel) WithTools([]*schema.ToolInfo) (einomodel.AgenticModel, error) { return m, nil }
+
+func TestReturnDirectlyToolEndsRun(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "order 1 shipped")
+	}))
+	defer srv.Close()
+
+	fake := &countingModel{}
+	defaultCreate := createAgenticModel
+	createAgenticModel = func(context.Context, *models.ModelConfig) (einomodel.AgenticModel, error) { return fake, nil }
+	defer func() { createAgenticModel = defaultCreate }()
+
+	fs := newFakeStore()
+	fs.providers.providers["p1"] = &model.Provider{ID: "p1", Name: "openai", DefaultModel: "gpt"}
+	fs.agents.agents["a1"] = &model.Agent{ID: "a1", Name: "a1", ProviderID: "p1"}
+	ab := NewAgentBiz(fs, nil, nil, nil, nil).(*agentBiz)
+	cb := NewConfigBiz(fs, ab)
+
+	req := webhookToolRequest("lookup")
+	req.CustomToolConfig["url"] = srv.URL
+	req.ReturnDirectly = true
+	if _, err := cb.AddAgentTool(ctx, "a1", &req); err != nil {
+		t.Fatalf("AddAgentTool: %v", err)
+	}
+
+	result, err := ab.Preview(ctx, "a1", &PreviewRequest{Query: "where is order 1?"}, sse.NewRecordingWriter())
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if result.Answer != "order 1 shipped" {
+		t.Errorf("answer = %q, want the tool result", result.Answer)
+	}
+	if got := fake.calls.Load(); got != 1 {
+		t.Errorf("model called %d times, want 1", got)
+	}
+}
@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// fakeStore 内存实现的 store.Store，只实现测试用到的方法，其余方法调用时 panic.
+type fakeStore struct {
+	store.Store
+	agents    *fakeAgentStore
+	tools     *fakeAgentToolStore
+	providers *fakeProviderStore
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		agents:    &fakeAgentStore{agents: make(map[string]*model.Agent)},
+		tools:     &fakeAgentToolStore{tools: make(map[string]*model.AgentTool)},
+		providers: &fakeProviderStore{providers: make(map[string]*model.Provider)},
+	}
+}
+
+func (s *fakeStore) Agents() store.AgentStore         { return s.agents }
+func (s *fakeStore) AgentTools() store.AgentToolStore { return s.tools }
+func (s *fakeStore) Providers() store.ProviderStore   { return s.providers }
+func (s *fakeStore) Skills() store.SkillStore         { return fakeSkillStore{} }
+
+type fakeProviderStore struct {
+	store.ProviderStore
+	providers map[string]*model.Provider
+}
+
+func (s *fakeProviderStore) Get(_ context.Context, id string) (*model.Provider, error) {
+	if p, ok := s.providers[id]; ok {
+		return p, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// fakeSkillStore 没有任何技能的技能存储.
+type fakeSkillStore struct {
+	store.SkillStore
+}
+
+func (fakeSkillStore) ListEnabled(context.Context) ([]*model.Skill, error) { return nil, nil }
+
+type fakeAgentStore struct {
+	store.AgentStore
+	agents map[string]*model.Agent
+}
+
+func (s *fakeAgentStore) Get(_ context.Context, id string) (*model.Agent, error) {
+	// 与数据库一样返回副本，调用方修改后需 Update 才会保存
+	if a, ok := s.agents[id]; ok {
+		cp := *a
+		return &cp, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *fakeAgentStore) Create(_ context.Context, agent *model.Agent) error {
+	s.agents[agent.ID] = agent
+	return nil
+}
+
+func (s *fakeAgentStore) Update(_ context.Context, agent *model.Agent) error {
+	s.agents[agent.ID] = agent
+	return nil
+}
+
+type fakeAgentToolStore struct {
+	store.AgentToolStore
+	mu    sync.Mutex
+	tools map[string]*model.AgentTool
+}
+
+func (s *fakeAgentToolStore) Get(_ context.Context, id string) (*model.AgentTool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tools[id]; ok {
+		return t, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *fakeAgentToolStore) Create(_ context.Context, t *model.AgentTool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tools[t.ID] = t
+	return nil
+}
+
+func (s *fakeAgentToolStore) Update(ctx context.Context, t *model.AgentTool) error {
+	return s.Create(ctx, t)
+}
+
+func (s *fakeAgentToolStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tools, id)
+	return nil
+}
+
+func (s *fakeAgentToolStore) ListByAgent(_ context.Context, agentID string) ([]*model.AgentTool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tools []*model.AgentTool
+	for _, t := range s.tools {
+		if t.AgentID == agentID {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+func (s *fakeAgentToolStore) ListEnabledByAgent(ctx context.Context, agentID string) ([]*model.AgentTool, error) {
+	all, _ := s.ListByAgent(ctx, agentID)
+	var tools []*model.AgentTool
+	for _, t := range all {
+		if t.IsEnabled {
+			tools = append(tools, t)
+		}
+	}
+	return tools, nil
+}
+
+func (s *fakeAgentToolStore) ReplaceByAgent(_ context.Context, agentID string, tools []*model.AgentTool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, t := range s.tools {
+		if t.AgentID == agentID {
+			delete(s.tools, id)
+		}
+	}
+	for _, t := range tools {
+		s.tools[t.ID] = t
+	}
+	return nil
+}
+
+func (s *fakeAgentToolStore) UpdatePriorities(_ context.Context, _ string, priorities map[string]int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, p := range priorities {
+		s.tools[id].Priority = p
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+// Package agent 提供 Agent 业务逻辑.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/cloudwego/eino/components/tool"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	agenttools "github.com/ashwinyue/next-show/internal/pkg/agent/tools"
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
+)
+
+// loadAgentTools 按优先级加载 Agent 已启用的工具，并返回配置了 ReturnDirectly 的工具名.
+// 工具顺序会影响模型的工具选择，因此按 Priority 降序、名称升序排列以保证确定性.
+// 租户工具策略不允许的工具会被过滤并记录日志；会话级工具绑定 scope 中的会话创建，没有会话时跳过；
+// MCP 工具通过共享的工具工厂创建，Server 不可用时跳过；自定义工具配置不合法时返回错误.
+func (b *agentBiz) loadAgentTools(ctx context.Context, agentID string, scope *runnerScope) ([]tool.BaseTool, map[string]struct{}, error) {
+	agentTools, err := b.store.AgentTools().ListEnabledByAgent(ctx, agentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list agent tools: %w", err)
+	}
+	sortAgentTools(agentTools)
+
+	registry, err := builtinToolRegistry(b.retry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var filtered []string
+	var mcpTools []*model.MCPTool
+	for _, at := range agentTools {
+		if at.ToolType != model.ToolTypeMCP {
+			continue
+		}
+		switch {
+		case at.MCPTool == nil || !at.MCPTool.IsEnabled:
+			log.Printf("agent %s: mcp tool %s is missing or disabled, skipped", agentID, agentToolName(at))
+		case !scope.toolPolicy.Permits(at.MCPTool.Name):
+			filtered = append(filtered, at.MCPTool.Name)
+		case b.mcpTools == nil:
+			log.Printf("agent %s: mcp tools are not supported, %s skipped", agentID, at.MCPTool.Name)
+		default:
+			mcpTools = append(mcpTools, at.MCPTool)
+		}
+	}
+	var mcpByID map[string]tool.BaseTool
+	if len(mcpTools) > 0 {
+		mcpByID = b.mcpTools.Tools(ctx, mcpTools)
+	}
+
+	tools := make([]tool.BaseTool, 0, len(agentTools))
+	returnDirect := make(map[string]struct{})
+	for _, at := range agentTools {
+		if at.ToolType == model.ToolTypeMCP {
+			if at.MCPTool == nil {
+				continue
+			}
+			t, ok := mcpByID[at.MCPTool.ID]
+			if !ok {
+				continue
+			}
+			tools = append(tools, t)
+			if at.ReturnDirectly || at.MCPTool.ReturnDirectly {
+				returnDirect[at.MCPTool.Name] = struct{}{}
+			}
+			continue
+		}
+		if at.ToolType == model.ToolTypeCustom {
+			cfg, err := agenttools.ParseWebhookToolConfig(at.CustomToolConfig)
+			if err != nil {
+				return nil, nil, fmt.Errorf("agent tool %s: %w", at.ID, err)
+			}
+			if !scope.toolPolicy.Permits(cfg.Name) {
+				filtered = append(filtered, cfg.Name)
+				continue
+			}
+			t, err := agenttools.NewWebhookTool(cfg)
+			if err != nil {
+				return nil, nil, fmt.Errorf("create custom tool %s: %w", cfg.Name, err)
+			}
+			tools = append(tools, t)
+			if at.ReturnDirectly {
+				returnDirect[cfg.Name] = struct{}{}
+			}
+			continue
+		}
+		if at.ToolType != model.ToolTypeBuiltin {
+			log.Printf("agent %s: tool type %s is not supported at runtime, skipped", agentID, at.ToolType)
+			continue
+		}
+		if !scope.toolPolicy.Permits(at.BuiltinToolName) {
+			filtered = append(filtered, at.BuiltinToolName)
+			continue
+		}
+		var t tool.BaseTool
+		if b.isSessionTool(at.BuiltinToolName) {
+			if scope.sessionID == "" {
+				log.Printf("agent %s: session tool %s requires a session, skipped", agentID, at.BuiltinToolName)
+				continue
+			}
+			sessionTools, err := b.sessionTools.SessionTools(ctx, scope.sessionID, []string{at.BuiltinToolName})
+			if err != nil {
+				return nil, nil, fmt.Errorf("create session tool %s: %w", at.BuiltinToolName, err)
+			}
+			t = sessionTools[0]
+		} else {
+			t, err = registry.Get(at.BuiltinToolName)
+			if err != nil {
+				log.Printf("agent %s: builtin tool %s is not available, skipped", agentID, at.BuiltinToolName)
+				continue
+			}
+		}
+		tools = append(tools, t)
+		if at.ReturnDirectly {
+			returnDirect[at.BuiltinToolName] = struct{}{}
+		}
+	}
+	if len(filtered) > 0 {
+		log.Printf("agent %s: tools %s are disallowed by tool policy, filtered", agentID, strings.Join(filtered, ", "))
+	}
+	return tools, returnDirect, nil
+}
+
+// isSessionTool 判断内置工具是否需要按会话注入.
+func (b *agentBiz) isSessionTool(name string) bool {
+	return b.sessionTools != nil && b.sessionTools.Provides(name)
+}
+
+// usesSessionTools 判断 Agent 是否启用了会话级工具，启用时运行实例与会话绑定、不能缓存.
+func (b *agentBiz) usesSessionTools(ctx context.Context, agentID string) (bool, error) {
+	if b.sessionTools == nil {
+		return false, nil
+	}
+	agentTools, err := b.store.AgentTools().ListEnabledByAgent(ctx, agentID)
+	if err != nil {
+		return false, fmt.Errorf("list agent tools: %w", err)
+	}
+	for _, at := range agentTools {
+		if at.ToolType == model.ToolTypeBuiltin && b.isSessionTool(at.BuiltinToolName) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// releaseSessionTools 释放会话级工具为会话占用的资源（如 DuckDB 中加载的数据表）.
+func (b *agentBiz) releaseSessionTools(sessionID string) {
+	if b.sessionTools != nil {
+		b.sessionTools.ReleaseSession(context.Background(), sessionID)
+	}
+}
+
+// builtinToolRegistry 创建可直接使用的内置工具注册表.
+func builtinToolRegistry(retryCfg *retry.Config) (*agenttools.ToolRegistry, error) {
+	registry, err := agenttools.DefaultRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("create tool registry: %w", err)
+	}
+	if err := registry.RegisterWebSearchTool(nil); err != nil {
+		return nil, fmt.Errorf("register web search tool: %w", err)
+	}
+	webFetchConfig := agenttools.DefaultWebFetchConfig()
+	webFetchConfig.Retry = retryCfg.WebFetch
+	if err := registry.Register(agenttools.NewWebFetchTool(webFetchConfig)); err != nil {
+		return nil, fmt.Errorf("register web fetch tool: %w", err)
+	}
+	return registry, nil
+}
+
+// sortAgentTools 按 Priority 降序、工具名称升序排序，名称相同时按 ID 排序.
+func sortAgentTools(tools []*model.AgentTool) {
+	sort.SliceStable(tools, func(i, j int) bool {
+		if tools[i].Priority != tools[j].Priority {
+			return tools[i].Priority > tools[j].Priority
+		}
+		ni, nj := agentToolName(tools[i]), agentToolName(tools[j])
+		if ni != nj {
+			return ni < nj
+		}
+		return tools[i].ID < tools[j].ID
+	})
+}
+
+// agentToolName 返回用于排序的工具名称.
+func agentToolName(t *model.AgentTool) string {
+	switch t.ToolType {
+	case model.ToolTypeBuiltin:
+		return t.BuiltinToolName
+	case model.ToolTypeMCP:
+		if t.MCPTool != nil {
+			return t.MCPTool.Name
+		}
+		if t.MCPToolID != nil {
+			return *t.MCPToolID
+		}
+	case model.ToolTypeCustom:
+		if name, _ := t.CustomToolConfig["name"].(string); name != "" {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	agenttools "github.com/ashwinyue/next-show/internal/pkg/agent/tools"
+)
+
+func TestToolPolicyRemovesConfiguredTools(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.agents.agents["a1"] = &model.Agent{ID: "a1", Name: "a1"}
+	ab := NewAgentBiz(fs, nil, nil, nil, nil).(*agentBiz)
+	cb := NewConfigBiz(fs, ab)
+
+	_, err := cb.SetAgentTools(ctx, "a1", []AddAgentToolRequest{
+		{ToolType: model.ToolTypeBuiltin, BuiltinToolName: agenttools.ToolWebFetch, Priority: 10},
+		webhookToolRequest("lookup"),
+		webhookToolRequest("delete_records"),
+	})
+	if err != nil {
+		t.Fatalf("SetAgentTools: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		policy *model.ToolPolicy
+		want   []string
+	}{
+		{"no policy", nil, []string{agenttools.ToolWebFetch, "delete_records", "lookup"}},
+		{"deny custom tool", &model.ToolPolicy{Deny: []string{"delete_records"}}, []string{agenttools.ToolWebFetch, "lookup"}},
+		{"deny builtin tool", &model.ToolPolicy{Deny: []string{agenttools.ToolWebFetch}}, []string{"delete_records", "lookup"}},
+		{"allow list", &model.ToolPolicy{Allow: []string{"lookup"}}, []string{"lookup"}},
+		{"deny wins over allow", &model.ToolPolicy{Allow: []string{"lookup", "delete_records"}, Deny: []string{"delete_records"}}, []string{"lookup"}},
+	}
+	for _, tt := range tests {
+		if got := loadedToolNames(t, ab, "a1", &runnerScope{toolPolicy: tt.policy}); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: tools = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestToolPolicyKeySeparatesRunners(t *testing.T) {
+	a := &model.ToolPolicy{Allow: []string{"b", "a"}}
+	b := &model.ToolPolicy{Allow: []string{"a", "b"}}
+	if a.Key() != b.Key() {
+		t.Errorf("Key() = %q and %q, want order-independent", a.Key(), b.Key())
+	}
+	if a.Key() == (&model.ToolPolicy{Deny: []string{"a", "b"}}).Key() {
+		t.Error("allow and deny policies share a runner key")
+	}
+	var none *model.ToolPolicy
+	if none.Key() != "" || !none.Permits("anything") {
+		t.Error("nil policy should have an empty key and permit all tools")
+	}
+}
+
+// sessionTool 记录所属会话的工具.
+type sessionTool struct {
+	name      string
+	sessionID string
+}
+
+func (t *sessionTool) Info(context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{Name: t.name}, nil
+}
+
+// fakeSessionToolProvider 按会话提供 data_schema 和 data_analysis，记录创建和释放的会话.
+type fakeSessionToolProvider struct {
+	created  []*sessionTool
+	released []string
+}
+
+func (p *fakeSessionToolProvider) Provides(name string) bool {
+	return name == agenttools.ToolDataSchema || name == agenttools.ToolDataAnalysis
+}
+
+func (p *fakeSessionToolProvider) SessionTools(_ context.Context, sessionID string, names []string) ([]tool.BaseTool, error) {
+	tools := make([]tool.BaseTool, 0, len(names))
+	for _, name := range names {
+		t := &sessionTool{name: name, sessionID: sessionID}
+		p.created = append(p.created, t)
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+func (p *fakeSessionToolProvider) ReleaseSession(_ context.Context, sessionID string) {
+	p.released = append(p.released, sessionID)
+}
+
+func TestDataAnalystRunnerGetsSessionTools(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.agents.agents["analyst"] = &model.Agent{ID: "analyst", Name: "analyst"}
+	fs.agents.agents["plain"] = &model.Agent{ID: "plain", Name: "plain"}
+	provider := &fakeSessionToolProvider{}
+	ab := NewAgentBiz(fs, nil, nil, provider, nil).(*agentBiz)
+	cb := NewConfigBiz(fs, ab)
+
+	if _, err := cb.SetAgentTools(ctx, "analyst", []AddAgentToolRequest{
+		{ToolType: model.ToolTypeBuiltin, BuiltinToolName: agenttools.ToolDataSchema, Priority: 2},
+		{ToolType: model.ToolTypeBuiltin, BuiltinToolName: agenttools.ToolDataAnalysis, Priority: 1},
+	}); err != nil {
+		t.Fatalf("SetAgentTools: %v", err)
+	}
+	if _, err := cb.SetAgentTools(ctx, "plain", []AddAgentToolRequest{webhookToolRequest("lookup")}); err != nil {
+		t.Fatalf("SetAgentTools: %v", err)
+	}
+
+	// 配置了会话级工具的 Agent 按会话创建运行实例，不能缓存
+	if scoped, err := ab.usesSessionTools(ctx, "analyst"); err != nil || !scoped {
+		t.Errorf("usesSessionTools(analyst) = %v, %v, want true", scoped, err)
+	}
+	if scoped, err := ab.usesSessionTools(ctx, "plain"); err != nil || scoped {
+		t.Errorf("usesSessionTools(plain) = %v, %v, want false", scoped, err)
+	}
+
+	got := loadedToolNames(t, ab, "analyst", &runnerScope{sessionID: "s1"})
+	if !slices.Equal(got, []string{agenttools.ToolDataSchema, agenttools.ToolDataAnalysis}) {
+		t.Fatalf("tools = %v, want data_schema and data_analysis", got)
+	}
+	for _, st := range provider.created {
+		if st.sessionID != "s1" {
+			t.Errorf("tool %s bound to session %q, want s1", st.name, st.sessionID)
+		}
+	}
+
+	// 没有会话时（如评估调用）跳过会话级工具
+	if got := loadedToolNames(t, ab, "analyst", &runnerScope{}); len(got) != 0 {
+		t.Errorf("tools without session = %v, want none", got)
+	}
+
+	ab.releaseSessionTools("s1")
+	if !slices.Equal(provider.released, []string{"s1"}) {
+		t.Errorf("released sessions = %v, want [s1]", provider.released)
+	}
+}
+
+func TestSessionToolsSkippedWithoutProvider(t *testing.T) {
+	ctx := context.Background()
+	fs := newFakeStore()
+	fs.agents.agents["analyst"] = &model.Agent{ID: "analyst", Name: "analyst"}
+	ab := NewAgentBiz(fs, nil, nil, nil, nil).(*agentBiz)
+	if _, err := NewConfigBiz(fs, ab).SetAgentTools(ctx, "analyst", []AddAgentToolRequest{
+		{ToolType: model.ToolTypeBuiltin, BuiltinToolName: agenttools.ToolDataAnalysis},
+	}); err != nil {
+		t.Fatalf("SetAgentTools: %v", err)
+	}
+
+	if scoped, _ := ab.usesSessionTools(ctx, "analyst"); scoped {
+		t.Error("usesSessionTools() = true without a session tool provider")
+	}
+	if got := loadedToolNames(t, ab, "analyst", &runnerScope{sessionID: "s1"}); slices.Contains(got, agenttools.ToolDataAnalysis) {
+		t.Errorf("tools = %v, data_analysis should not be built without a provider", got)
+	}
+}
+
+func TestCustomWebhookToolLoaded(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, r.URL.Path+" "+string(data))
+	}))
+	defer srv.Close()
+
+	fs := newFakeStore()
+	fs.agents.agents["a1"] = &model.Agent{ID: "a1", Name: "a1"}
+	ab := NewAgentBiz(fs, nil, nil, nil, nil).(*agentBiz)
+	cb := NewConfigBiz(fs, ab)
+
+	req := webhookToolRequest("lookup")
+	req.CustomToolConfig["url"] = srv.URL + "/lookup"
+	req.ReturnDirectly = true
+	if _, err := cb.AddAgentTool(ctx, "a1", &req); err != nil {
+		t.Fatalf("AddAgentTool: %v", err)
+	}
+	tools, returnDirect, err := ab.loadAgentTools(ctx, "a1", &runnerScope{})
+	if err != nil {
+		t.Fatalf("loadAgentTools: %v", err)
+	}
+	if len(tools) != 1 {
+		t.Fatalf("loaded %d tools, want the custom tool", len(tools))
+	}
+	if _, ok := returnDirect["lookup"]; !ok {
+		t.Errorf("return directly = %v, want lookup", returnDirect)
+	}
+	output, err := tools[0].(tool.InvokableTool).InvokableRun(ctx, `{"id":1}`)
+	if err != nil || output != `/lookup {"id":1}` {
+		t.Errorf("InvokableRun = %q, %v, want the arguments posted to the configured url", output, err)
+	}
+
+	// 配置不合法时拒绝保存
+	invalid := webhookToolRequest("lookup")
+	delete(invalid.CustomToolConfig, "url")
+	if _, err := cb.AddAgentTool(ctx, "a1", &invalid); !errors.Is(err, ErrInvalidAgent) || !strings.Contains(err.Error(), "url is required") {
+		t.Errorf("AddAgentTool(missing url) error = %v, want ErrInvalidAgent naming the missing field", err)
+	}
+	// 已保存的配置不合法时加载失败，而不是静默跳过
+	fs.tools.tools["broken"] = &model.AgentTool{ID: "broken", AgentID: "a1", ToolType: model.ToolTypeCustom, CustomToolConfig: model.JSONMap{"name": "broken"}, IsEnabled: true}
+	if _, _, err := ab.loadAgentTools(ctx, "a1", &runnerScope{}); !errors.Is(err, agenttools.ErrInvalidCustomTool) {
+		t.Errorf("loadAgentTools(broken config) error = %v, want ErrInvalidCustomTool", err)
+	}
+}
@@ -2,6 +2,8 @@
 package biz
 
 import (
+	"log"
+
 	"github.com/ashwinyue/next-show/internal/biz/agent"
 	"github.com/ashwinyue/next-show/internal/biz/auth"
 	"github.com/ashwinyue/next-show/internal/biz/evaluation"
@@ -13,6 +15,10 @@ import (
 	"github.com/ashwinyue/next-show/internal/biz/skill"
 	"github.com/ashwinyue/next-show/internal/biz/tenant"
 	"github.com/ashwinyue/next-show/internal/biz/websearch"
+	agenttools "github.com/ashwinyue/next-show/internal/pkg/agent/tools"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+	"github.com/ashwinyue/next-show/internal/pkg/moderation"
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
 	"github.com/ashwinyue/next-show/internal/store"
 	"github.com/cloudwego/eino/components/embedding"
 )
@@ -48,18 +54,21 @@ type biz struct {
 	skillBiz       skill.Biz
 }
 
-// NewBiz 创建业务层实例.
-func NewBiz(store store.Store, embedder embedding.Embedder) Biz {
-	agentBiz := agent.NewAgentBiz(store)
+// NewBiz 创建业务层实例，moderator 为 nil 时不启用内容审核，files 为 nil 时原始文件保存到本地 data/files，
+// knowledgeCfg 为知识库业务的可选配置，retryCfg 为模型和工具调用的重试策略，dataCfg 为数据分析工具的可选配置，
+// mcpCfg 为 MCP 工具的可选配置.
+func NewBiz(store store.Store, embedder embedding.Embedder, moderator *moderation.Moderator, files blob.Store, knowledgeCfg *knowledge.BizConfig, retryCfg *retry.Config, dataCfg *agenttools.DataAnalysisConfig, mcpCfg *mcp.Config) Biz {
+	mcpTools := mcp.NewToolFactory(store, mcpCfg)
+	agentBiz := agent.NewAgentBiz(store, moderator, retryCfg, newSessionTools(store, files, dataCfg), mcpTools)
 	return &biz{
 		agentBiz:       agentBiz,
-		agentConfigBiz: agent.NewConfigBiz(store),
+		agentConfigBiz: agent.NewConfigBiz(store, agentBiz),
 		providerBiz:    provider.NewBiz(store),
-		mcpBiz:         mcp.NewBiz(store),
+		mcpBiz:         mcp.NewBiz(store, mcpTools),
 		webSearchBiz:   websearch.NewBiz(store),
 		settingsBiz:    settings.NewBiz(store),
 		sessionBiz:     session.NewSessionBiz(store),
-		knowledgeBiz:   knowledge.NewBiz(store, embedder),
+		knowledgeBiz:   knowledge.NewBiz(store, embedder, moderator, files, knowledgeCfg),
 		tenantBiz:      tenant.NewBiz(store),
 		authBiz:        auth.NewBiz(store, nil),
 		evaluationSvc:  evaluation.NewService(store.DB(), agentBiz),
@@ -67,6 +76,19 @@ func NewBiz(store store.Store, embedder embedding.Embedder) Biz {
 	}
 }
 
+// newSessionTools 创建会话级数据分析工具（DuckDB），创建失败时返回 nil，Agent 运行时跳过这些工具.
+func newSessionTools(s store.Store, files blob.Store, cfg *agenttools.DataAnalysisConfig) agenttools.SessionToolProvider {
+	if files == nil {
+		files = blob.NewLocalStore(knowledge.DataFilesBaseDir)
+	}
+	manager, err := agenttools.NewDataAnalysisManager(cfg)
+	if err != nil {
+		log.Printf("failed to init data analysis tools: %v, data_schema and data_analysis will be unavailable", err)
+		return nil
+	}
+	return agenttools.NewDataAnalysisToolProvider(manager, agenttools.NewDocumentFilePathFunc(s, files, ""), 0)
+}
+
 func (b *biz) Agents() agent.AgentBiz {
 	return b.agentBiz
 }
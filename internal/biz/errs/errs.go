@@ -0,0 +1,32 @@
+// Package errs 定义业务层通用的错误类型，Handler 据此选择 HTTP 状态码.
+package errs
+
+import "errors"
+
+// 错误类型，业务错误通过 New 或 fmt.Errorf("%w: ...") 归入其中之一.
+var (
+	// ErrNotFound 资源不存在.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict 与资源当前状态冲突.
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden 不允许执行该操作.
+	ErrForbidden = errors.New("forbidden")
+	// ErrValidation 参数校验失败.
+	ErrValidation = errors.New("validation failed")
+)
+
+// kindError 带有错误类型的业务错误.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+
+func (e *kindError) Unwrap() error { return e.kind }
+
+// New 创建属于 kind 类型的业务错误，错误信息为 msg.
+// 返回的错误可以作为哨兵错误使用，errors.Is 对其自身和 kind 均成立.
+func New(kind error, msg string) error {
+	return &kindError{kind: kind, msg: msg}
+}
@@ -0,0 +1,97 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/biz/evaluation/metrics"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// approxEqual 比较浮点分数.
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestAggregateMatchesHandComputedScores(t *testing.T) {
+	task := &model.EvaluationTask{ID: "task1", Metrics: DefaultMetrics}
+	items := []*metrics.MetricInput{
+		{
+			// P = 1/2, R = 1, F1 = 2/3
+			RetrievedIDs: []string{"d1", "d2"},
+			RelevantIDs:  []string{"d1"},
+			// 6 个词中 5 个重叠；二元组 5 个中 3 个重叠；LCS 为 the cat on the mat
+			GeneratedText: "the cat sat on the mat",
+			ExpectedText:  "the cat is on the mat",
+		},
+		{
+			// P = R = 0，F1 为 0 而不是 NaN
+			RetrievedIDs:  []string{"d3"},
+			RelevantIDs:   []string{"d4"},
+			GeneratedText: "hello",
+			ExpectedText:  "goodbye world",
+		},
+	}
+	var results []*model.EvaluationResult
+	for _, input := range items {
+		result := &model.EvaluationResult{}
+		computeMetrics(task, result, input)
+		results = append(results, result)
+	}
+
+	first := results[0].Metrics
+	for name, c := range map[string]struct{ got, want float64 }{
+		"f1":     {first.F1, 2.0 / 3},
+		"rouge1": {first.ROUGE.ROUGE1, 5.0 / 6},
+		"rouge2": {first.ROUGE.ROUGE2, 3.0 / 5},
+		"rougel": {first.ROUGE.ROUGEL, 5.0 / 6},
+	} {
+		if !approxEqual(c.got, c.want) || !approxEqual(first.Scores[name], c.want) {
+			t.Errorf("item 1 %s = %v (scores %v), want %v", name, c.got, first.Scores[name], c.want)
+		}
+	}
+	second := results[1].Metrics
+	if second.F1 != 0 || math.IsNaN(second.F1) || second.ROUGE.ROUGE1 != 0 || second.ROUGE.ROUGE2 != 0 || second.ROUGE.ROUGEL != 0 {
+		t.Errorf("item 2 metrics = %+v, want all zero", second)
+	}
+
+	NewService(nil, nil).aggregateResults(task, results)
+	for name, c := range map[string]struct {
+		got  *float64
+		want float64
+	}{
+		"recall":    {task.AvgRecall, 0.5},
+		"precision": {task.AvgPrecision, 0.25},
+		"mrr":       {task.AvgMRR, 0.5},
+		"f1":        {task.AvgF1, 1.0 / 3},
+		"rouge1":    {task.AvgROUGE1, 5.0 / 12},
+		"rouge2":    {task.AvgROUGE2, 0.3},
+		"rougel":    {task.AvgROUGEL, 5.0 / 12},
+	} {
+		if c.got == nil || !approxEqual(*c.got, c.want) || !approxEqual(task.AvgScores[name], c.want) {
+			t.Errorf("average %s = %v (avg scores %v), want %v", name, c.got, task.AvgScores[name], c.want)
+		}
+	}
+}
+
+func TestResultScoreDerivesF1ForLegacyResults(t *testing.T) {
+	tests := []struct {
+		precision, recall float64
+		want              float64
+	}{
+		{0.5, 1, 2.0 / 3},
+		{0, 0, 0},
+	}
+	for _, tt := range tests {
+		result := &model.EvaluationResult{}
+		result.Metrics.Precision, result.Metrics.Recall = tt.precision, tt.recall
+		got, ok := resultScore(result, MetricF1)
+		if !ok || !approxEqual(got, tt.want) {
+			t.Errorf("resultScore(P=%v, R=%v) = %v, %v, want %v", tt.precision, tt.recall, got, ok, tt.want)
+		}
+	}
+	// 旧结果没有 ROUGE，不参与平均
+	if _, ok := resultScore(&model.EvaluationResult{}, MetricROUGE1); ok {
+		t.Error("legacy result has a rouge1 score")
+	}
+}
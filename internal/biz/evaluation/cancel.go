@@ -0,0 +1,82 @@
+package evaluation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// cancelledMessage 被取消任务的错误信息.
+const cancelledMessage = "cancelled by user"
+
+var (
+	// ErrTaskNotCancellable 任务已结束，无法取消.
+	ErrTaskNotCancellable = errs.New(errs.ErrConflict, "evaluation task is not cancellable")
+	// ErrTaskAlreadyRunning 任务已有执行中的 goroutine.
+	ErrTaskAlreadyRunning = errs.New(errs.ErrConflict, "evaluation task is already running")
+)
+
+// runningTask 执行中任务的上下文和取消函数.
+type runningTask struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// startTask 为任务创建可取消的执行上下文并登记，任务结束时需以同一上下文调用 finishTask.
+// 任务已登记（上一次执行尚未结束）时返回 ErrTaskAlreadyRunning，避免同一任务并发执行.
+func (s *Service) startTask(taskID string) (context.Context, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.running[taskID]; ok {
+		return nil, ErrTaskAlreadyRunning
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.running[taskID] = runningTask{ctx: ctx, cancel: cancel}
+	return ctx, nil
+}
+
+// finishTask 注销任务的执行上下文，任务已被恢复并登记了新的上下文时不影响新的执行.
+func (s *Service) finishTask(ctx context.Context, taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run, ok := s.running[taskID]; ok && run.ctx == ctx {
+		run.cancel()
+		delete(s.running, taskID)
+	}
+}
+
+// CancelTask 取消等待中或运行中的评估任务.
+// 已保存的结果会保留，执行中的 goroutine 停止后按已完成的条目重新计算汇总指标.
+func (s *Service) CancelTask(ctx context.Context, tenantID uint, taskID string) (*model.EvaluationTask, error) {
+	task, err := s.GetTask(ctx, tenantID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status != model.EvaluationStatusPending && task.Status != model.EvaluationStatusRunning {
+		return nil, fmt.Errorf("%w: status is %s", ErrTaskNotCancellable, task.Status)
+	}
+
+	s.mu.Lock()
+	run, ok := s.running[taskID]
+	s.mu.Unlock()
+	if ok {
+		run.cancel()
+	}
+
+	now := time.Now()
+	task.Status = model.EvaluationStatusCancelled
+	task.ErrorMessage = cancelledMessage
+	task.CompletedAt = &now
+	err = s.db.WithContext(ctx).Model(task).Updates(map[string]any{
+		"status":        task.Status,
+		"error_message": task.ErrorMessage,
+		"completed_at":  task.CompletedAt,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel task: %w", err)
+	}
+	return task, nil
+}
@@ -0,0 +1,41 @@
+package evaluation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStartTaskRefusesRunningTask(t *testing.T) {
+	s := NewService(nil, nil)
+
+	first, err := s.startTask("t1")
+	if err != nil {
+		t.Fatalf("startTask() error = %v", err)
+	}
+	if _, err := s.startTask("t1"); !errors.Is(err, ErrTaskAlreadyRunning) {
+		t.Fatalf("second startTask() error = %v, want ErrTaskAlreadyRunning", err)
+	}
+	if first.Err() != nil {
+		t.Fatalf("refused start cancelled the running task: %v", first.Err())
+	}
+	if _, err := s.startTask("t2"); err != nil {
+		t.Fatalf("startTask() for another task error = %v", err)
+	}
+
+	s.finishTask(first, "t1")
+	if first.Err() == nil {
+		t.Fatal("finishTask did not cancel the task context")
+	}
+	second, err := s.startTask("t1")
+	if err != nil {
+		t.Fatalf("startTask() after finish error = %v", err)
+	}
+	// 旧执行的 finishTask 不影响新登记的执行
+	s.finishTask(first, "t1")
+	if second.Err() != nil {
+		t.Fatal("stale finishTask cancelled the new execution")
+	}
+	if _, err := s.startTask("t1"); !errors.Is(err, ErrTaskAlreadyRunning) {
+		t.Fatalf("startTask() error = %v, want ErrTaskAlreadyRunning", err)
+	}
+}
@@ -0,0 +1,71 @@
+package evaluation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// 评估条目并发数.
+const (
+	DefaultConcurrency = 4
+	MaxConcurrency     = 64
+)
+
+// ErrInvalidConcurrency 评估并发数或速率限制不合法.
+var ErrInvalidConcurrency = errs.New(errs.ErrValidation, "invalid evaluation concurrency")
+
+// validateConcurrency 校验并发数（0 表示默认值）和每秒条目数限制（0 表示不限制）.
+func validateConcurrency(concurrency int, rateLimit float64) error {
+	if concurrency < 0 || concurrency > MaxConcurrency {
+		return fmt.Errorf("%w: concurrency must be between 1 and %d", ErrInvalidConcurrency, MaxConcurrency)
+	}
+	if rateLimit < 0 {
+		return fmt.Errorf("%w: rate_limit must not be negative", ErrInvalidConcurrency)
+	}
+	return nil
+}
+
+// taskConcurrency 返回任务的并发数，未设置时为 DefaultConcurrency.
+func taskConcurrency(task *model.EvaluationTask) int {
+	if task.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return task.Concurrency
+}
+
+// itemLimiter 按每秒条目数限制开始评估条目的速率.
+type itemLimiter struct {
+	ticker *time.Ticker
+}
+
+// newItemLimiter 创建速率限制器，perSecond <= 0 时返回 nil（不限制）.
+func newItemLimiter(perSecond float64) *itemLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &itemLimiter{ticker: time.NewTicker(time.Duration(float64(time.Second) / perSecond))}
+}
+
+// wait 等待下一个令牌，ctx 结束时返回 false；l 为 nil 时立即返回.
+func (l *itemLimiter) wait(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case <-l.ticker.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// stop 释放定时器.
+func (l *itemLimiter) stop() {
+	if l != nil {
+		l.ticker.Stop()
+	}
+}
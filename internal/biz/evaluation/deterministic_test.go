@@ -0,0 +1,50 @@
+package evaluation
+
+import (
+	"context"
+	"testing"
+
+	einomodel "github.com/cloudwego/eino/components/model"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	agentcallbacks "github.com/ashwinyue/next-show/internal/pkg/agent/callbacks"
+)
+
+// fakeAgentCaller 记录调用时传入的模型选项.
+type fakeAgentCaller struct {
+	opts []einomodel.Option
+}
+
+func (c *fakeAgentCaller) CallWithEvaluationCallback(_ context.Context, _, _, _ string, _ *agentcallbacks.EvaluationCallbackHandler, opts ...einomodel.Option) error {
+	c.opts = opts
+	return nil
+}
+
+func TestEvaluateItemDeterministicTemperature(t *testing.T) {
+	tests := []struct {
+		name          string
+		deterministic bool
+		want          *float32
+	}{
+		{name: "deterministic", deterministic: true, want: new(float32)},
+		{name: "agent settings", deterministic: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller := &fakeAgentCaller{}
+			s := NewService(nil, caller)
+			task := &model.EvaluationTask{ID: "task1", AgentID: "agent1", Deterministic: tt.deterministic}
+
+			if _, err := s.evaluateItem(context.Background(), task, model.DatasetItem{ID: "item1", Query: "q"}); err != nil {
+				t.Fatalf("evaluateItem() error = %v", err)
+			}
+			got := einomodel.GetCommonOptions(&einomodel.Options{}, caller.opts...).Temperature
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("temperature = %v, want agent default", *got)
+			case tt.want != nil && (got == nil || *got != *tt.want):
+				t.Errorf("temperature = %v, want %v", got, *tt.want)
+			}
+		})
+	}
+}
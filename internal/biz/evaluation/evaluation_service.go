@@ -4,9 +4,14 @@ package evaluation
 import (
 	"context"
 	"fmt"
+	"log"
+	"slices"
 	"sync"
 	"time"
 
+	einomodel "github.com/cloudwego/eino/components/model"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
 	"github.com/ashwinyue/next-show/internal/biz/evaluation/metrics"
 	"github.com/ashwinyue/next-show/internal/model"
 	agentcallbacks "github.com/ashwinyue/next-show/internal/pkg/agent/callbacks"
@@ -14,22 +19,29 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrTaskNotResumable 任务状态不允许恢复（已完成或仍在运行）.
+var ErrTaskNotResumable = errs.New(errs.ErrConflict, "evaluation task is not resumable")
+
 // AgentR Caller RAG Agent 调用接口.
 // 用于评估服务调用 RAG Agent 并收集数据.
 type AgentRCaller interface {
 	// CallWithEvaluationCallback 调用 RAG Agent 并使用评估 Callback 收集数据.
-	CallWithEvaluationCallback(ctx context.Context, agentID, knowledgeBaseID, query string, callback *agentcallbacks.EvaluationCallbackHandler) error
+	// opts 为覆盖 Agent 模型配置的调用选项（如 temperature）.
+	CallWithEvaluationCallback(ctx context.Context, agentID, knowledgeBaseID, query string, callback *agentcallbacks.EvaluationCallbackHandler, opts ...einomodel.Option) error
 }
 
 // Service 评估服务.
 type Service struct {
 	db          *gorm.DB
 	agentCaller AgentRCaller // 用于调用 RAG Agent
+
+	mu      sync.Mutex
+	running map[string]runningTask // 执行中的任务，按任务 ID 索引
 }
 
 // NewService 创建评估服务.
 func NewService(db *gorm.DB, agentCaller AgentRCaller) *Service {
-	return &Service{db: db, agentCaller: agentCaller}
+	return &Service{db: db, agentCaller: agentCaller, running: make(map[string]runningTask)}
 }
 
 // CreateDatasetRequest 创建数据集请求.
@@ -61,7 +73,7 @@ func (s *Service) CreateDataset(ctx context.Context, req *CreateDatasetRequest)
 	}
 
 	// 使用事务创建数据集和条目
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(dataset).Error; err != nil {
 			return fmt.Errorf("failed to create dataset: %w", err)
 		}
@@ -93,19 +105,13 @@ func (s *Service) CreateDataset(ctx context.Context, req *CreateDatasetRequest)
 
 // GetDataset 获取数据集详情.
 func (s *Service) GetDataset(ctx context.Context, tenantID uint, datasetID string) (*model.EvaluationDataset, error) {
-	var dataset model.EvaluationDataset
-	err := s.db.Where("tenant_id = ? AND id = ?", tenantID, datasetID).First(&dataset).Error
-	if err != nil {
-		return nil, fmt.Errorf("failed to get dataset: %w", err)
-	}
-
-	return &dataset, nil
+	return findDataset(ctx, s.db, tenantID, datasetID)
 }
 
 // ListDatasets 列出数据集.
 func (s *Service) ListDatasets(ctx context.Context, tenantID uint) ([]model.EvaluationDataset, error) {
 	var datasets []model.EvaluationDataset
-	err := s.db.Where("tenant_id = ?", tenantID).Find(&datasets).Error
+	err := s.db.WithContext(ctx).Scopes(tenantScope(tenantID)).Find(&datasets).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to list datasets: %w", err)
 	}
@@ -116,14 +122,12 @@ func (s *Service) ListDatasets(ctx context.Context, tenantID uint) ([]model.Eval
 // GetDatasetItems 获取数据集的条目.
 func (s *Service) GetDatasetItems(ctx context.Context, tenantID uint, datasetID string) ([]model.DatasetItem, error) {
 	// 先验证权限
-	var dataset model.EvaluationDataset
-	err := s.db.Where("tenant_id = ? AND id = ?", tenantID, datasetID).First(&dataset).Error
-	if err != nil {
-		return nil, fmt.Errorf("dataset not found: %w", err)
+	if _, err := findDataset(ctx, s.db, tenantID, datasetID); err != nil {
+		return nil, err
 	}
 
 	var items []model.DatasetItem
-	err = s.db.Where("dataset_id = ?", datasetID).Find(&items).Error
+	err := s.db.WithContext(ctx).Where("dataset_id = ?", datasetID).Find(&items).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to get dataset items: %w", err)
 	}
@@ -137,12 +141,42 @@ type RunEvaluationRequest struct {
 	DatasetID       string `json:"dataset_id" binding:"required"`
 	AgentID         string `json:"agent_id" binding:"required"`
 	KnowledgeBaseID string `json:"knowledge_base_id"`
+	// Deterministic 可复现模式：评估期间强制 temperature=0，覆盖 Agent 的模型配置.
+	// 目前接入的 OpenAI（Responses API）和 ARK Agentic 模型均不支持 seed 参数，
+	// 因此只能固定温度，模型输出仍可能存在少量差异.
+	Deterministic bool `json:"deterministic"`
+	// Metrics 要计算的指标，为空时计算 DefaultMetrics 中的全部指标
+	Metrics []string `json:"metrics"`
+	// BLEUMaxN BLEU 的最大 n-gram 阶数，<=0 时为 4
+	BLEUMaxN int `json:"bleu_max_n"`
+	// BLEUSmoothing BLEU 的平滑方法（none/epsilon/add_one），为空时为 epsilon
+	BLEUSmoothing string `json:"bleu_smoothing"`
+	// Concurrency 同时评估的条目数，<=0 时为 4
+	Concurrency int `json:"concurrency"`
+	// RateLimit 每秒开始评估的条目数上限，用于避免触发模型服务的限流，0 表示不限制
+	RateLimit float64 `json:"rate_limit"`
 }
 
 // RunEvaluation 运行评估任务（异步）.
 func (s *Service) RunEvaluation(ctx context.Context, req *RunEvaluationRequest) (*model.EvaluationTask, error) {
+	selected, err := resolveMetrics(req.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	smoothing, err := metrics.ParseBLEUSmoothing(req.BLEUSmoothing)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMetrics, err)
+	}
+	bleuMaxN := req.BLEUMaxN
+	if bleuMaxN <= 0 {
+		bleuMaxN = 4
+	}
+	if err := validateConcurrency(req.Concurrency, req.RateLimit); err != nil {
+		return nil, err
+	}
+
 	// 1. 验证数据集存在
-	_, err := s.GetDataset(ctx, req.TenantID, req.DatasetID)
+	_, err = s.GetDataset(ctx, req.TenantID, req.DatasetID)
 	if err != nil {
 		return nil, fmt.Errorf("dataset not found: %w", err)
 	}
@@ -161,23 +195,141 @@ func (s *Service) RunEvaluation(ctx context.Context, req *RunEvaluationRequest)
 		DatasetID:       req.DatasetID,
 		AgentID:         req.AgentID,
 		KnowledgeBaseID: req.KnowledgeBaseID,
+		Deterministic:   req.Deterministic,
+		Metrics:         selected,
+		BLEUMaxN:        bleuMaxN,
+		BLEUSmoothing:   string(smoothing),
+		Concurrency:     req.Concurrency,
+		RateLimit:       req.RateLimit,
 		Status:          model.EvaluationStatusPending,
 		TotalItems:      len(items),
 		StartedAt:       &now,
 	}
 
-	if err := s.db.Create(task).Error; err != nil {
+	if err := s.db.WithContext(ctx).Create(task).Error; err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
 	// 4. 异步执行评估
-	go s.executeEvaluation(context.Background(), task, items)
+	runCtx, err := s.startTask(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	go s.executeEvaluation(runCtx, task, items, 0)
+
+	return task, nil
+}
 
+// ResumeEvaluation 恢复中断或失败的评估任务，只评估尚无结果的条目.
+// 已保存的结果不会重新计算，汇总指标基于全部结果重新计算.
+func (s *Service) ResumeEvaluation(ctx context.Context, tenantID uint, taskID string) (*model.EvaluationTask, error) {
+	task, err := s.GetTask(ctx, tenantID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	resumable := []model.EvaluationStatus{model.EvaluationStatusInterrupted, model.EvaluationStatusFailed}
+	if !slices.Contains(resumable, task.Status) {
+		return nil, fmt.Errorf("%w: status is %s", ErrTaskNotResumable, task.Status)
+	}
+
+	// 以条件更新抢占任务，并发的恢复请求只有一个能成功
+	previous := task.Status
+	res := s.db.WithContext(ctx).Model(&model.EvaluationTask{}).
+		Where("id = ? AND status IN ?", task.ID, resumable).
+		Update("status", model.EvaluationStatusRunning)
+	if res.Error != nil {
+		return nil, fmt.Errorf("failed to claim task: %w", res.Error)
+	}
+	if res.RowsAffected != 1 {
+		return nil, fmt.Errorf("%w: task was resumed concurrently", ErrTaskNotResumable)
+	}
+	task.Status = model.EvaluationStatusRunning
+
+	if err := s.resume(ctx, task); err != nil {
+		// 未能启动时恢复原状态，之后可以再次恢复
+		if rerr := s.db.WithContext(ctx).Model(&model.EvaluationTask{}).
+			Where("id = ?", task.ID).Update("status", previous).Error; rerr != nil {
+			log.Printf("failed to restore status of evaluation task %s: %v", task.ID, rerr)
+		}
+		return nil, err
+	}
 	return task, nil
 }
 
-// executeEvaluation 执行评估任务.
-func (s *Service) executeEvaluation(ctx context.Context, task *model.EvaluationTask, items []model.DatasetItem) {
+// RecoverInterrupted 处理服务重启前未完成的评估任务（pending 或 running）.
+// resume 为 true 时在后台继续评估剩余条目，否则标记为 interrupted，之后可通过 ResumeEvaluation 手动恢复.
+// 应在服务启动时、开始接收请求前调用.
+func (s *Service) RecoverInterrupted(ctx context.Context, resume bool) (int, error) {
+	var tasks []model.EvaluationTask
+	err := s.db.WithContext(ctx).
+		Where("status IN ?", []model.EvaluationStatus{model.EvaluationStatusPending, model.EvaluationStatusRunning}).
+		Find(&tasks).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unfinished tasks: %w", err)
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		if resume {
+			err := s.resume(ctx, task)
+			if err == nil {
+				continue
+			}
+			log.Printf("failed to resume evaluation task %s: %v", task.ID, err)
+		}
+		task.Status = model.EvaluationStatusInterrupted
+		task.ErrorMessage = "interrupted by server restart"
+		if err := s.db.WithContext(ctx).Save(task).Error; err != nil {
+			return i, fmt.Errorf("failed to mark task %s interrupted: %w", task.ID, err)
+		}
+	}
+	return len(tasks), nil
+}
+
+// resume 加载任务的剩余条目并在后台继续评估.
+func (s *Service) resume(ctx context.Context, task *model.EvaluationTask) error {
+	runCtx, err := s.startTask(task.ID)
+	if err != nil {
+		return err
+	}
+	items, err := s.GetDatasetItems(ctx, task.TenantID, task.DatasetID)
+	if err != nil {
+		s.finishTask(runCtx, task.ID)
+		return fmt.Errorf("failed to get dataset items: %w", err)
+	}
+
+	var doneIDs []string
+	if err := s.db.WithContext(ctx).Model(&model.EvaluationResult{}).
+		Where("task_id = ?", task.ID).Pluck("item_id", &doneIDs).Error; err != nil {
+		s.finishTask(runCtx, task.ID)
+		return fmt.Errorf("failed to get completed items: %w", err)
+	}
+	done := make(map[string]bool, len(doneIDs))
+	for _, id := range doneIDs {
+		done[id] = true
+	}
+
+	remaining := make([]model.DatasetItem, 0, len(items))
+	for _, item := range items {
+		if !done[item.ID] {
+			remaining = append(remaining, item)
+		}
+	}
+
+	task.TotalItems = len(items)
+	task.ErrorMessage = ""
+	task.CompletedAt = nil
+	go s.executeEvaluation(runCtx, task, remaining, len(items)-len(remaining))
+	return nil
+}
+
+// executeEvaluation 执行评估任务，每个条目完成后立即保存结果.
+// 至多 task.Concurrency 个条目同时评估，设置了 task.RateLimit 时按每秒条目数限制开始评估的速率.
+// completed 为之前已保存结果的条目数，用于计算进度；进度只在百分比增加时写入数据库.
+// ctx 被 CancelTask 取消后不再开始新的条目，任务标记为 cancelled，汇总指标只包含已保存结果的条目.
+func (s *Service) executeEvaluation(ctx context.Context, task *model.EvaluationTask, items []model.DatasetItem, completed int) {
+	defer s.finishTask(ctx, task.ID)
+
 	// 更新任务状态为运行中
 	task.Status = model.EvaluationStatusRunning
 	s.db.Save(task)
@@ -186,57 +338,80 @@ func (s *Service) executeEvaluation(ctx context.Context, task *model.EvaluationT
 	resultsChan := make(chan *model.EvaluationResult, len(items))
 	errorsChan := make(chan error, len(items))
 
-	// 并发执行评估（可配置并发数）
-	for _, item := range items {
-		wg.Add(1)
-		go func(item model.DatasetItem) {
-			defer wg.Done()
-
-			result, err := s.evaluateItem(ctx, task, item)
-			if err != nil {
-				errorsChan <- err
-				return
-			}
-			resultsChan <- result
-		}(item)
-	}
-
-	// 等待所有评估完成
+	limiter := newItemLimiter(task.RateLimit)
+	sem := make(chan struct{}, taskConcurrency(task))
 	go func() {
+		defer limiter.stop()
+	dispatch:
+		for _, item := range items {
+			if !limiter.wait(ctx) {
+				break
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				break dispatch
+			}
+			wg.Add(1)
+			go func(item model.DatasetItem) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				result, err := s.evaluateItem(ctx, task, item)
+				if err != nil {
+					if ctx.Err() != nil {
+						// 任务被取消，中断的条目不计为失败
+						return
+					}
+					errorsChan <- fmt.Errorf("item %s: %w", item.ID, err)
+					return
+				}
+				resultsChan <- result
+			}(item)
+		}
+		// 等待所有评估完成
 		wg.Wait()
 		close(resultsChan)
 		close(errorsChan)
 	}()
 
-	// 收集结果
-	var results []*model.EvaluationResult
+	// 收集结果，只有当前 goroutine 修改任务
 	var errorCount int
 
 	for result := range resultsChan {
-		results = append(results, result)
-
 		// 保存结果到数据库
 		if err := s.db.Create(result).Error; err != nil {
+			log.Printf("evaluation task %s: save result of item %s: %v", task.ID, result.ItemID, err)
 			errorCount++
 			continue
 		}
+		completed++
 
 		// 更新进度
-		task.Progress = int(float64(len(results)) / float64(task.TotalItems) * 100)
-		s.db.Save(task)
+		if progress := completed * 100 / task.TotalItems; progress > task.Progress {
+			task.Progress = progress
+			s.db.Model(task).UpdateColumn("progress", progress)
+		}
 	}
 
 	// 处理错误
 	for err := range errorsChan {
 		errorCount++
-		_ = err // 记录错误日志
+		log.Printf("evaluation task %s: %v", task.ID, err)
 	}
 
-	// 计算平均指标并更新任务
+	// 基于全部已保存的结果（含恢复前的结果）计算平均指标并更新任务
+	var results []*model.EvaluationResult
+	if err := s.db.Where("task_id = ?", task.ID).Find(&results).Error; err != nil {
+		errorCount++
+	}
 	s.aggregateResults(task, results)
 
 	task.Status = model.EvaluationStatusCompleted
-	if errorCount > 0 {
+	if ctx.Err() != nil {
+		task.Status = model.EvaluationStatusCancelled
+		task.ErrorMessage = cancelledMessage
+	} else if errorCount > 0 {
 		task.Status = model.EvaluationStatusFailed
 		task.ErrorMessage = fmt.Sprintf("%d items failed", errorCount)
 	}
@@ -251,7 +426,7 @@ func (s *Service) evaluateItem(ctx context.Context, task *model.EvaluationTask,
 	evalCallback := agentcallbacks.NewEvaluationCallbackHandler()
 
 	// 调用 RAG Agent 并传入 Callback Handler
-	err := s.agentCaller.CallWithEvaluationCallback(ctx, task.AgentID, task.KnowledgeBaseID, item.Query, evalCallback)
+	err := s.agentCaller.CallWithEvaluationCallback(ctx, task.AgentID, task.KnowledgeBaseID, item.Query, evalCallback, modelOptions(task)...)
 	if err != nil {
 		return nil, fmt.Errorf("call RAG agent: %w", err)
 	}
@@ -287,51 +462,70 @@ func (s *Service) evaluateItem(ctx context.Context, task *model.EvaluationTask,
 		ExpectedText:  item.ExpectedAnswer,
 	}
 
-	result.Metrics.Recall = metrics.NewRecallMetric().Compute(metricInput)
-	result.Metrics.Precision = metrics.NewPrecisionMetric().Compute(metricInput)
-	result.Metrics.MRR = metrics.NewMRRMetric().Compute(metricInput)
-	result.Metrics.BLEU = metrics.NewBLEUMetric(4).Compute(metricInput)
+	computeMetrics(task, result, metricInput)
 
 	return result, nil
 }
 
-// aggregateResults 聚合评估结果.
+// modelOptions 返回评估任务对 Agent 模型的覆盖选项.
+func modelOptions(task *model.EvaluationTask) []einomodel.Option {
+	if !task.Deterministic {
+		return nil
+	}
+	return []einomodel.Option{einomodel.WithTemperature(0)}
+}
+
+// aggregateResults 聚合评估结果，只汇总任务计算的指标，其余内置指标的汇总值保持为空.
 func (s *Service) aggregateResults(task *model.EvaluationTask, results []*model.EvaluationResult) {
 	if len(results) == 0 {
 		return
 	}
 
-	var totalRecall, totalPrecision, totalMRR, totalBLEU float64
-
-	for _, result := range results {
-		totalRecall += result.Metrics.Recall
-		totalPrecision += result.Metrics.Precision
-		totalMRR += result.Metrics.MRR
-		totalBLEU += result.Metrics.BLEU
+	task.AvgScores = make(map[string]float64)
+	for _, name := range taskMetrics(task) {
+		var total float64
+		var count int
+		for _, result := range results {
+			if score, ok := resultScore(result, name); ok {
+				total += score
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		avg := total / float64(count)
+		task.AvgScores[name] = avg
+		switch name {
+		case MetricRecall:
+			task.AvgRecall = &avg
+		case MetricPrecision:
+			task.AvgPrecision = &avg
+		case MetricMRR:
+			task.AvgMRR = &avg
+		case MetricBLEU:
+			task.AvgBLEU = &avg
+		case MetricF1:
+			task.AvgF1 = &avg
+		case MetricROUGE1:
+			task.AvgROUGE1 = &avg
+		case MetricROUGE2:
+			task.AvgROUGE2 = &avg
+		case MetricROUGEL:
+			task.AvgROUGEL = &avg
+		}
 	}
-
-	count := float64(len(results))
-	task.AvgRecall = &[]float64{totalRecall / count}[0]
-	task.AvgPrecision = &[]float64{totalPrecision / count}[0]
-	task.AvgMRR = &[]float64{totalMRR / count}[0]
-	task.AvgBLEU = &[]float64{totalBLEU / count}[0]
 }
 
 // GetTask 获取评估任务.
 func (s *Service) GetTask(ctx context.Context, tenantID uint, taskID string) (*model.EvaluationTask, error) {
-	var task model.EvaluationTask
-	err := s.db.Where("tenant_id = ? AND id = ?", tenantID, taskID).First(&task).Error
-	if err != nil {
-		return nil, fmt.Errorf("failed to get task: %w", err)
-	}
-
-	return &task, nil
+	return findTask(ctx, s.db, tenantID, taskID)
 }
 
 // ListTasks 列出评估任务.
 func (s *Service) ListTasks(ctx context.Context, tenantID uint, datasetID string) ([]model.EvaluationTask, error) {
 	var tasks []model.EvaluationTask
-	query := s.db.Where("tenant_id = ?", tenantID)
+	query := s.db.WithContext(ctx).Scopes(tenantScope(tenantID))
 
 	if datasetID != "" {
 		query = query.Where("dataset_id = ?", datasetID)
@@ -348,14 +542,12 @@ func (s *Service) ListTasks(ctx context.Context, tenantID uint, datasetID string
 // GetTaskResults 获取评估任务的结果.
 func (s *Service) GetTaskResults(ctx context.Context, tenantID uint, taskID string) ([]model.EvaluationResult, error) {
 	// 验证任务存在
-	var task model.EvaluationTask
-	err := s.db.Where("tenant_id = ? AND id = ?", tenantID, taskID).First(&task).Error
-	if err != nil {
-		return nil, fmt.Errorf("task not found: %w", err)
+	if _, err := findTask(ctx, s.db, tenantID, taskID); err != nil {
+		return nil, err
 	}
 
 	var results []model.EvaluationResult
-	err = s.db.Where("task_id = ?", taskID).Find(&results).Error
+	err := s.db.WithContext(ctx).Where("task_id = ?", taskID).Find(&results).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to get task results: %w", err)
 	}
@@ -365,15 +557,19 @@ func (s *Service) GetTaskResults(ctx context.Context, tenantID uint, taskID stri
 
 // DeleteDataset 删除数据集.
 func (s *Service) DeleteDataset(ctx context.Context, tenantID uint, datasetID string) error {
-	// 使用事务删除数据集及其关联数据
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	// 使用事务删除数据集及其关联数据，数据集不属于当前租户时不删除任何数据
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := findDataset(ctx, tx, tenantID, datasetID); err != nil {
+			return err
+		}
+
 		// 删除数据集条目
 		if err := tx.Where("dataset_id = ?", datasetID).Delete(&model.DatasetItem{}).Error; err != nil {
 			return err
 		}
 
 		// 删除数据集
-		if err := tx.Where("tenant_id = ? AND id = ?", tenantID, datasetID).Delete(&model.EvaluationDataset{}).Error; err != nil {
+		if err := tx.Scopes(tenantScope(tenantID)).Where("id = ?", datasetID).Delete(&model.EvaluationDataset{}).Error; err != nil {
 			return err
 		}
 
@@ -383,15 +579,19 @@ func (s *Service) DeleteDataset(ctx context.Context, tenantID uint, datasetID st
 
 // DeleteTask 删除评估任务.
 func (s *Service) DeleteTask(ctx context.Context, tenantID uint, taskID string) error {
-	// 使用事务删除任务及其关联数据
-	return s.db.Transaction(func(tx *gorm.DB) error {
+	// 使用事务删除任务及其关联数据，任务不属于当前租户时不删除任何数据
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := findTask(ctx, tx, tenantID, taskID); err != nil {
+			return err
+		}
+
 		// 删除评估结果
 		if err := tx.Where("task_id = ?", taskID).Delete(&model.EvaluationResult{}).Error; err != nil {
 			return err
 		}
 
 		// 删除任务
-		if err := tx.Where("tenant_id = ? AND id = ?", tenantID, taskID).Delete(&model.EvaluationTask{}).Error; err != nil {
+		if err := tx.Scopes(tenantScope(tenantID)).Where("id = ?", taskID).Delete(&model.EvaluationTask{}).Error; err != nil {
 			return err
 		}
 
@@ -0,0 +1,185 @@
+package evaluation
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// 评估结果导出格式.
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+// ErrInvalidExportFormat 不支持的导出格式.
+var ErrInvalidExportFormat = errs.New(errs.ErrValidation, "invalid export format")
+
+// ExportRow 导出的单条评估结果.
+type ExportRow struct {
+	ItemID          string             `json:"item_id"`
+	Query           string             `json:"query"`
+	ExpectedAnswer  string             `json:"expected_answer"`
+	GeneratedAnswer string             `json:"generated_answer"`
+	RetrievedDocIDs []string           `json:"retrieved_doc_ids"`
+	Scores          map[string]float64 `json:"scores"`
+}
+
+// TaskExport JSON 格式的导出内容，Summary 为任务级别的各指标平均分.
+type TaskExport struct {
+	Task    *model.EvaluationTask `json:"task"`
+	Metrics []string              `json:"metrics"`
+	Results []ExportRow           `json:"results"`
+	Summary map[string]float64    `json:"summary"`
+}
+
+// ExportTaskResults 导出评估任务的逐条结果，format 为 csv 或 json（为空时为 csv）.
+// CSV 的列为 item_id、query、expected_answer、generated_answer、retrieved_doc_ids（以 ; 分隔）和任务计算的各指标，
+// 最后一行为各指标的平均分；JSON 的平均分放在 summary 中.
+func (s *Service) ExportTaskResults(ctx context.Context, tenantID uint, taskID string, format string) ([]byte, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = ExportFormatCSV
+	}
+	if format != ExportFormatCSV && format != ExportFormatJSON {
+		return nil, fmt.Errorf("%w: %q, supported: csv, json", ErrInvalidExportFormat, format)
+	}
+
+	export, err := s.buildTaskExport(ctx, tenantID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if format == ExportFormatJSON {
+		data, err := json.Marshal(export)
+		if err != nil {
+			return nil, fmt.Errorf("marshal export: %w", err)
+		}
+		return data, nil
+	}
+	return export.csv()
+}
+
+// buildTaskExport 查询任务结果并关联数据集条目，按数据集条目顺序排列.
+func (s *Service) buildTaskExport(ctx context.Context, tenantID uint, taskID string) (*TaskExport, error) {
+	task, err := findTask(ctx, s.db, tenantID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []model.EvaluationResult
+	if err := s.db.WithContext(ctx).Where("task_id = ?", taskID).Order("created_at").Find(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to get task results: %w", err)
+	}
+	var items []model.DatasetItem
+	if err := s.db.WithContext(ctx).Where("dataset_id = ?", task.DatasetID).Order("created_at").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get dataset items: %w", err)
+	}
+	order := make(map[string]int, len(items))
+	itemByID := make(map[string]*model.DatasetItem, len(items))
+	for i := range items {
+		order[items[i].ID] = i
+		itemByID[items[i].ID] = &items[i]
+	}
+	position := func(itemID string) int {
+		if i, ok := order[itemID]; ok {
+			return i
+		}
+		// 条目已被删除的结果排在最后
+		return len(items)
+	}
+
+	names := taskMetrics(task)
+	export := &TaskExport{
+		Task:    task,
+		Metrics: names,
+		Results: make([]ExportRow, 0, len(results)),
+		Summary: make(map[string]float64, len(names)),
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return position(results[i].ItemID) < position(results[j].ItemID)
+	})
+
+	totals := make(map[string]float64, len(names))
+	counts := make(map[string]int, len(names))
+	for i := range results {
+		result := &results[i]
+		row := ExportRow{
+			ItemID:          result.ItemID,
+			GeneratedAnswer: result.GeneratedAnswer,
+			RetrievedDocIDs: result.RetrievedDocIDs,
+			Scores:          make(map[string]float64, len(names)),
+		}
+		if row.RetrievedDocIDs == nil {
+			row.RetrievedDocIDs = []string{}
+		}
+		if item, ok := itemByID[result.ItemID]; ok {
+			row.Query = item.Query
+			row.ExpectedAnswer = item.ExpectedAnswer
+		}
+		for _, name := range names {
+			if score, ok := resultScore(result, name); ok {
+				row.Scores[name] = score
+				totals[name] += score
+				counts[name]++
+			}
+		}
+		export.Results = append(export.Results, row)
+	}
+	for _, name := range names {
+		if counts[name] > 0 {
+			export.Summary[name] = totals[name] / float64(counts[name])
+		}
+	}
+	return export, nil
+}
+
+// csv 编码为 CSV，encoding/csv 会为包含换行、逗号和引号的字段加引号转义.
+func (e *TaskExport) csv() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := append([]string{"item_id", "query", "expected_answer", "generated_answer", "retrieved_doc_ids"}, e.Metrics...)
+	if err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("write csv header: %w", err)
+	}
+	for _, row := range e.Results {
+		record := []string{row.ItemID, row.Query, row.ExpectedAnswer, row.GeneratedAnswer, strings.Join(row.RetrievedDocIDs, ";")}
+		for _, name := range e.Metrics {
+			record = append(record, formatScore(row.Scores, name))
+		}
+		if err := w.Write(record); err != nil {
+			return nil, fmt.Errorf("write csv row: %w", err)
+		}
+	}
+
+	summary := []string{"average", "", "", "", ""}
+	for _, name := range e.Metrics {
+		summary = append(summary, formatScore(e.Summary, name))
+	}
+	if err := w.Write(summary); err != nil {
+		return nil, fmt.Errorf("write csv summary: %w", err)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("write csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatScore 格式化指标分数，未计算的指标为空.
+func formatScore(scores map[string]float64, name string) string {
+	score, ok := scores[name]
+	if !ok {
+		return ""
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
@@ -0,0 +1,122 @@
+package evaluation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/biz/evaluation/metrics"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// 内置评估指标名称，对应 EvaluationMetrics 中的固定字段.
+const (
+	MetricRecall    = "recall"
+	MetricPrecision = "precision"
+	MetricMRR       = "mrr"
+	MetricBLEU      = "bleu"
+	MetricF1        = "f1"
+	MetricROUGE1    = "rouge1"
+	MetricROUGE2    = "rouge2"
+	MetricROUGEL    = "rougel"
+)
+
+// DefaultMetrics 未指定指标时计算的指标（全部内置指标）.
+var DefaultMetrics = []string{MetricRecall, MetricPrecision, MetricMRR, MetricF1, MetricBLEU, MetricROUGE1, MetricROUGE2, MetricROUGEL}
+
+// legacyMetrics 指标选择功能之前创建的任务计算的指标.
+var legacyMetrics = []string{MetricRecall, MetricPrecision, MetricMRR, MetricBLEU}
+
+// ErrInvalidMetrics 请求的评估指标未注册.
+var ErrInvalidMetrics = errs.New(errs.ErrValidation, "invalid evaluation metrics")
+
+// resolveMetrics 校验并去重请求的指标，为空时返回 DefaultMetrics.
+func resolveMetrics(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return append([]string(nil), DefaultMetrics...), nil
+	}
+	seen := make(map[string]bool, len(names))
+	var selected []string
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := metrics.Lookup(name); !ok {
+			return nil, fmt.Errorf("%w: unknown metric %q, supported: %s", ErrInvalidMetrics, name, strings.Join(metrics.Names(), ", "))
+		}
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, name)
+		}
+	}
+	return selected, nil
+}
+
+// taskMetrics 返回任务计算的指标，指标选择功能之前创建的任务计算当时的四个内置指标.
+func taskMetrics(task *model.EvaluationTask) []string {
+	if len(task.Metrics) == 0 {
+		return legacyMetrics
+	}
+	return task.Metrics
+}
+
+// computeMetrics 依次计算任务选择的指标，结果写入 Scores，内置指标同时写入对应字段.
+func computeMetrics(task *model.EvaluationTask, result *model.EvaluationResult, input *metrics.MetricInput) {
+	result.Metrics.Scores = make(map[string]float64)
+	for _, name := range taskMetrics(task) {
+		m, ok := metrics.Lookup(name)
+		if !ok {
+			continue
+		}
+		if name == MetricBLEU {
+			// BLEU 的阶数和平滑方法按任务配置
+			m = metrics.NewBLEUMetricWithSmoothing(task.BLEUMaxN, metrics.BLEUSmoothing(task.BLEUSmoothing))
+		}
+		score := m.Compute(input)
+		result.Metrics.Scores[name] = score
+		switch name {
+		case MetricRecall:
+			result.Metrics.Recall = score
+		case MetricPrecision:
+			result.Metrics.Precision = score
+		case MetricMRR:
+			result.Metrics.MRR = score
+		case MetricBLEU:
+			result.Metrics.BLEU = score
+		case MetricF1:
+			result.Metrics.F1 = score
+		case MetricROUGE1:
+			result.Metrics.ROUGE.ROUGE1 = score
+		case MetricROUGE2:
+			result.Metrics.ROUGE.ROUGE2 = score
+		case MetricROUGEL:
+			result.Metrics.ROUGE.ROUGEL = score
+		}
+	}
+}
+
+// resultScore 返回结果中指标的分数，兼容没有 Scores 的旧结果.
+func resultScore(result *model.EvaluationResult, name string) (float64, bool) {
+	if score, ok := result.Metrics.Scores[name]; ok {
+		return score, true
+	}
+	if result.Metrics.Scores != nil {
+		return 0, false
+	}
+	switch name {
+	case MetricRecall:
+		return result.Metrics.Recall, true
+	case MetricPrecision:
+		return result.Metrics.Precision, true
+	case MetricMRR:
+		return result.Metrics.MRR, true
+	case MetricBLEU:
+		return result.Metrics.BLEU, true
+	case MetricF1:
+		// 旧结果没有 F1，由精确率和召回率计算
+		p, r := result.Metrics.Precision, result.Metrics.Recall
+		if p+r == 0 {
+			return 0, true
+		}
+		return 2 * p * r / (p + r), true
+	}
+	return 0, false
+}
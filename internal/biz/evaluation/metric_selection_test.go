@@ -0,0 +1,91 @@
+package evaluation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/biz/evaluation/metrics"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// answerLength 以生成文本长度（最多 10 个字符）计分的自定义指标.
+type answerLength struct{}
+
+func (answerLength) Name() string { return "answer_length" }
+
+func (answerLength) Compute(input *metrics.MetricInput) float64 {
+	return min(float64(len(input.GeneratedText)), 10) / 10
+}
+
+func (answerLength) Validate(*metrics.MetricInput) error { return nil }
+
+func TestCustomMetricComputedAndAggregated(t *testing.T) {
+	if err := metrics.Register("answer_length", answerLength{}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	selected, err := resolveMetrics([]string{" Answer_Length ", "recall", "answer_length"})
+	if err != nil {
+		t.Fatalf("resolveMetrics: %v", err)
+	}
+	if len(selected) != 2 || selected[0] != "answer_length" || selected[1] != MetricRecall {
+		t.Fatalf("resolveMetrics() = %v, want [answer_length recall]", selected)
+	}
+	if _, err := resolveMetrics([]string{"unknown"}); !errors.Is(err, ErrInvalidMetrics) {
+		t.Errorf("resolveMetrics(unknown) error = %v, want ErrInvalidMetrics", err)
+	}
+
+	task := &model.EvaluationTask{ID: "task1", Metrics: selected}
+	var results []*model.EvaluationResult
+	for _, answer := range []string{"short", "a much longer answer"} {
+		result := &model.EvaluationResult{}
+		computeMetrics(task, result, &metrics.MetricInput{
+			GeneratedText: answer,
+			RetrievedIDs:  []string{"d1"},
+			RelevantIDs:   []string{"d1", "d2"},
+		})
+		results = append(results, result)
+	}
+
+	if got := results[0].Metrics.Scores["answer_length"]; got != 0.5 {
+		t.Errorf("custom score = %v, want 0.5", got)
+	}
+	// 内置指标同时写入 Scores 和对应字段，未选择的指标不计算
+	if results[0].Metrics.Scores[MetricRecall] != 0.5 || results[0].Metrics.Recall != 0.5 {
+		t.Errorf("recall = %v / %v, want 0.5", results[0].Metrics.Scores[MetricRecall], results[0].Metrics.Recall)
+	}
+	if _, ok := results[0].Metrics.Scores[MetricBLEU]; ok {
+		t.Error("unselected bleu was computed")
+	}
+
+	s := NewService(nil, nil)
+	s.aggregateResults(task, results)
+	if got := task.AvgScores["answer_length"]; got != 0.75 {
+		t.Errorf("average custom score = %v, want 0.75", got)
+	}
+	if task.AvgRecall == nil || *task.AvgRecall != 0.5 || task.AvgBLEU != nil {
+		t.Errorf("AvgRecall = %v, AvgBLEU = %v, want 0.5 and unset", task.AvgRecall, task.AvgBLEU)
+	}
+}
+
+func TestComputeMetricsUsesTaskBLEUConfig(t *testing.T) {
+	input := &metrics.MetricInput{GeneratedText: "the cat sat", ExpectedText: "the cat sat down"}
+	tests := []struct {
+		name string
+		task *model.EvaluationTask
+		zero bool
+	}{
+		{name: "default smoothed bleu-4", task: &model.EvaluationTask{Metrics: []string{MetricBLEU}}},
+		{name: "unsmoothed bleu-4", task: &model.EvaluationTask{Metrics: []string{MetricBLEU}, BLEUSmoothing: "none"}, zero: true},
+		{name: "unsmoothed bleu-2", task: &model.EvaluationTask{Metrics: []string{MetricBLEU}, BLEUSmoothing: "none", BLEUMaxN: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &model.EvaluationResult{}
+			computeMetrics(tt.task, result, input)
+			if got := result.Metrics.BLEU; (got == 0) != tt.zero || result.Metrics.Scores[MetricBLEU] != got {
+				t.Errorf("BLEU = %v (scores %v), want zero = %v", got, result.Metrics.Scores, tt.zero)
+			}
+		})
+	}
+}
@@ -7,17 +7,52 @@ import (
 	"strings"
 )
 
+// BLEUSmoothing BLEU 的平滑方法，避免短文本缺少高阶 n-gram 重叠时分数为 0.
+type BLEUSmoothing string
+
+const (
+	// BLEUSmoothingNone 不平滑，任一阶 n-gram 无重叠时分数为 0.
+	BLEUSmoothingNone BLEUSmoothing = "none"
+	// BLEUSmoothingEpsilon 无重叠的阶以 epsilon 代替匹配数（Chen & Cherry method 1）.
+	BLEUSmoothingEpsilon BLEUSmoothing = "epsilon"
+	// BLEUSmoothingAddOne 二阶及以上的匹配数和总数各加 1（Chen & Cherry method 2）.
+	BLEUSmoothingAddOne BLEUSmoothing = "add_one"
+)
+
+// bleuEpsilon BLEUSmoothingEpsilon 使用的匹配数.
+const bleuEpsilon = 0.1
+
+// ParseBLEUSmoothing 解析平滑方法名称，为空时返回 BLEUSmoothingEpsilon.
+func ParseBLEUSmoothing(s string) (BLEUSmoothing, error) {
+	switch BLEUSmoothing(s) {
+	case "":
+		return BLEUSmoothingEpsilon, nil
+	case BLEUSmoothingNone, BLEUSmoothingEpsilon, BLEUSmoothingAddOne:
+		return BLEUSmoothing(s), nil
+	}
+	return "", fmt.Errorf("unsupported bleu smoothing %q (none, epsilon, add_one)", s)
+}
+
 // BLEUMetric BLEU 指标（用于评估机器翻译质量）.
 type BLEUMetric struct {
-	MaxN int // 最大 n-gram, 通常为 4
+	MaxN      int           // 最大 n-gram, 通常为 4
+	Smoothing BLEUSmoothing // 平滑方法
 }
 
-// NewBLEUMetric 创建 BLEU 指标.
+// NewBLEUMetric 创建使用 epsilon 平滑的 BLEU 指标，maxN <= 0 时为 4.
 func NewBLEUMetric(maxN int) *BLEUMetric {
+	return NewBLEUMetricWithSmoothing(maxN, BLEUSmoothingEpsilon)
+}
+
+// NewBLEUMetricWithSmoothing 创建指定平滑方法的 BLEU 指标，maxN <= 0 时为 4.
+func NewBLEUMetricWithSmoothing(maxN int, smoothing BLEUSmoothing) *BLEUMetric {
 	if maxN <= 0 {
 		maxN = 4
 	}
-	return &BLEUMetric{MaxN: maxN}
+	if smoothing == "" {
+		smoothing = BLEUSmoothingEpsilon
+	}
+	return &BLEUMetric{MaxN: maxN, Smoothing: smoothing}
 }
 
 func (m *BLEUMetric) Name() string {
@@ -32,19 +67,15 @@ func (m *BLEUMetric) Compute(input *MetricInput) float64 {
 		return 0.0
 	}
 
-	// 计算 modified precision
-	precisions := make([]float64, m.MaxN)
-	for n := 1; n <= m.MaxN; n++ {
-		precisions[n-1] = m.modifiedPrecision(candidate, reference, n)
-	}
-
-	// 计算 geometric mean
+	// 各阶 modified precision 的几何平均（权重均为 1/MaxN）
 	logSum := 0.0
 	weight := 1.0 / float64(m.MaxN)
-	for _, p := range precisions {
-		if p > 0 {
-			logSum += weight * math.Log(p)
+	for n := 1; n <= m.MaxN; n++ {
+		p := m.smoothedPrecision(candidate, reference, n)
+		if p <= 0 {
+			return 0.0
 		}
+		logSum += weight * math.Log(p)
 	}
 
 	// Brevity penalty
@@ -53,35 +84,49 @@ func (m *BLEUMetric) Compute(input *MetricInput) float64 {
 	return bp * math.Exp(logSum)
 }
 
+// smoothedPrecision 返回按平滑方法调整后的 n 阶 precision.
+func (m *BLEUMetric) smoothedPrecision(candidate, reference []string, n int) float64 {
+	matches, total := m.modifiedPrecision(candidate, reference, n)
+	switch m.Smoothing {
+	case BLEUSmoothingEpsilon:
+		if matches == 0 {
+			return bleuEpsilon / math.Max(float64(total), 1)
+		}
+	case BLEUSmoothingAddOne:
+		if n > 1 {
+			return float64(matches+1) / float64(total+1)
+		}
+	}
+	if total == 0 {
+		return 0.0
+	}
+	return float64(matches) / float64(total)
+}
+
 func (m *BLEUMetric) splitIntoWords(text string) []string {
 	return strings.Fields(strings.ToLower(text))
 }
 
-func (m *BLEUMetric) modifiedPrecision(candidate, reference []string, n int) float64 {
-	if n > len(candidate) || n > len(reference) {
-		return 0.0
+// modifiedPrecision 返回 n 阶 clipped 匹配数和候选文本的 n-gram 总数.
+func (m *BLEUMetric) modifiedPrecision(candidate, reference []string, n int) (matches, total int) {
+	if n > len(candidate) {
+		return 0, 0
 	}
 
 	// 提取 n-grams
 	candidateNGrams := m.getNGrams(candidate, n)
 	referenceNGrams := m.getNGrams(reference, n)
 
-	if len(candidateNGrams) == 0 {
-		return 0.0
-	}
-
-	// 计算 clipped counts
-	clippedCount := 0
+	// 每个 n-gram 的匹配数不超过其在参考文本中出现的次数
 	for ngram, count := range candidateNGrams {
-		refCount := referenceNGrams[ngram]
-		if refCount > count {
-			clippedCount += refCount
+		if refCount := referenceNGrams[ngram]; refCount < count {
+			matches += refCount
 		} else {
-			clippedCount += count
+			matches += count
 		}
 	}
 
-	return float64(clippedCount) / float64(len(candidateNGrams))
+	return matches, len(candidate) - n + 1
 }
 
 func (m *BLEUMetric) getNGrams(words []string, n int) map[string]int {
@@ -155,11 +200,14 @@ func (m *ROUGEMetric) Compute(input *MetricInput) float64 {
 	}
 }
 
+// computeROUGEN 计算 ROUGE-N 的 F1：重叠 n-gram 数（按次数截断）分别除以候选和参考的 n-gram 总数得到精确率和召回率.
 func (m *ROUGEMetric) computeROUGEN(candidate, reference []string, n int) float64 {
 	candidateNGrams := m.getNGrams(candidate, n)
 	referenceNGrams := m.getNGrams(reference, n)
 
-	if len(candidateNGrams) == 0 {
+	candidateTotal := len(candidate) - n + 1
+	referenceTotal := len(reference) - n + 1
+	if candidateTotal <= 0 || referenceTotal <= 0 {
 		return 0.0
 	}
 
@@ -175,30 +223,27 @@ func (m *ROUGEMetric) computeROUGEN(candidate, reference []string, n int) float6
 		}
 	}
 
-	// ROUGE-N = match / max(|candidate|, |reference|)
-	denominator := len(candidateNGrams)
-	if len(referenceNGrams) > len(candidateNGrams) {
-		denominator = len(referenceNGrams)
-	}
-
-	return float64(matchCount) / float64(denominator)
+	return fMeasure(float64(matchCount)/float64(candidateTotal), float64(matchCount)/float64(referenceTotal))
 }
 
+// computeROUGEL 计算 ROUGE-L 的 F1：最长公共子序列长度分别除以候选和参考的长度得到精确率和召回率.
 func (m *ROUGEMetric) computeROUGEL(candidate, reference []string) float64 {
-	// 计算最长公共子序列
-	lcs := m.longestCommonSubsequence(candidate, reference)
-
 	if len(candidate) == 0 || len(reference) == 0 {
 		return 0.0
 	}
 
-	// ROUGE-L = LCS / max(|candidate|, |reference|)
-	denominator := len(candidate)
-	if len(reference) > len(candidate) {
-		denominator = len(reference)
-	}
+	// 计算最长公共子序列
+	lcs := m.longestCommonSubsequence(candidate, reference)
 
-	return float64(lcs) / float64(denominator)
+	return fMeasure(float64(lcs)/float64(len(candidate)), float64(lcs)/float64(len(reference)))
+}
+
+// fMeasure 精确率和召回率的调和平均，两者之和为 0 时返回 0.
+func fMeasure(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0.0
+	}
+	return 2 * precision * recall / (precision + recall)
 }
 
 func (m *ROUGEMetric) longestCommonSubsequence(a, b []string) int {
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+// approx 比较浮点分数.
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-4
+}
+
+func TestBLEUSmoothingOnShortPairs(t *testing.T) {
+	// 候选只有 3 个词，没有 4-gram：不平滑时 BLEU-4 为 0
+	short := &MetricInput{GeneratedText: "the cat sat", ExpectedText: "the cat sat down"}
+	// 与参考没有任何重叠
+	unrelated := &MetricInput{GeneratedText: "dogs bark", ExpectedText: "the cat sat"}
+	identical := &MetricInput{GeneratedText: "the quick brown fox jumps", ExpectedText: "The quick brown fox jumps"}
+	bp := math.Exp(1 - 4.0/3.0)
+
+	tests := []struct {
+		name      string
+		smoothing BLEUSmoothing
+		input     *MetricInput
+		want      float64
+	}{
+		{"short none", BLEUSmoothingNone, short, 0},
+		// 1-3 阶完全匹配，4 阶以 0.1 代替
+		{"short epsilon", BLEUSmoothingEpsilon, short, bp * math.Pow(0.1, 0.25)},
+		{"short add one", BLEUSmoothingAddOne, short, bp},
+		{"unrelated none", BLEUSmoothingNone, unrelated, 0},
+		{"identical none", BLEUSmoothingNone, identical, 1},
+		{"identical epsilon", BLEUSmoothingEpsilon, identical, 1},
+		{"identical add one", BLEUSmoothingAddOne, identical, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewBLEUMetricWithSmoothing(4, tt.smoothing).Compute(tt.input)
+			if !approx(got, tt.want) {
+				t.Errorf("BLEU-4 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 平滑后没有重叠的文本仍然得分很低
+	if got := NewBLEUMetric(4).Compute(unrelated); got <= 0 || got >= 0.1 {
+		t.Errorf("smoothed BLEU of unrelated texts = %v, want a small positive score", got)
+	}
+}
+
+func TestBLEUMaxN(t *testing.T) {
+	short := &MetricInput{GeneratedText: "the cat sat", ExpectedText: "the cat sat down"}
+	// BLEU-2 只看 1、2 阶，不平滑也有分数
+	if got, want := NewBLEUMetricWithSmoothing(2, BLEUSmoothingNone).Compute(short), math.Exp(1-4.0/3.0); !approx(got, want) {
+		t.Errorf("BLEU-2 = %v, want %v", got, want)
+	}
+
+	m := NewBLEUMetric(0)
+	if m.MaxN != 4 || m.Smoothing != BLEUSmoothingEpsilon || m.Name() != "bleu-4" {
+		t.Errorf("NewBLEUMetric(0) = %+v, want smoothed BLEU-4", m)
+	}
+}
+
+func TestParseBLEUSmoothing(t *testing.T) {
+	for in, want := range map[string]BLEUSmoothing{
+		"":        BLEUSmoothingEpsilon,
+		"none":    BLEUSmoothingNone,
+		"epsilon": BLEUSmoothingEpsilon,
+		"add_one": BLEUSmoothingAddOne,
+	} {
+		if got, err := ParseBLEUSmoothing(in); err != nil || got != want {
+			t.Errorf("ParseBLEUSmoothing(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseBLEUSmoothing("laplace"); err == nil {
+		t.Error("ParseBLEUSmoothing(laplace) succeeded, want error")
+	}
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrDuplicateMetric 指标名称已被注册.
+var ErrDuplicateMetric = errors.New("metric already registered")
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Metric{
+		"recall":    NewRecallMetric(),
+		"precision": NewPrecisionMetric(),
+		"mrr":       NewMRRMetric(),
+		"bleu":      NewBLEUMetric(4),
+		"f1":        NewF1Metric(),
+		"rouge1":    NewROUGEMetric(ROUGE1),
+		"rouge2":    NewROUGEMetric(ROUGE2),
+		"rougel":    NewROUGEMetric(ROUGEL),
+	}
+)
+
+// Register 以 name 注册指标，评估任务通过名称选择要计算的指标.
+// 扩展（领域指标）可以在 init 中调用，名称已存在时返回 ErrDuplicateMetric.
+func Register(name string, m Metric) error {
+	if name == "" || m == nil {
+		return fmt.Errorf("metric name and implementation are required")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateMetric, name)
+	}
+	registry[name] = m
+	return nil
+}
+
+// Lookup 返回名称对应的指标.
+func Lookup(name string) (Metric, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	m, ok := registry[name]
+	return m, ok
+}
+
+// Names 返回已注册的指标名称（按名称排序）.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
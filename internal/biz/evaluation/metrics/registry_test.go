@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// exactMatch 生成文本与期望文本完全一致时为 1 的测试指标.
+type exactMatch struct{}
+
+func (exactMatch) Name() string { return "exact_match" }
+
+func (exactMatch) Compute(input *MetricInput) float64 {
+	if input.GeneratedText == input.ExpectedText {
+		return 1
+	}
+	return 0
+}
+
+func (exactMatch) Validate(*MetricInput) error { return nil }
+
+func TestRegisterCustomMetric(t *testing.T) {
+	if err := Register("registry_test_exact", exactMatch{}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	m, ok := Lookup("registry_test_exact")
+	if !ok {
+		t.Fatal("Lookup did not find the registered metric")
+	}
+	if got := m.Compute(&MetricInput{GeneratedText: "a", ExpectedText: "a"}); got != 1 {
+		t.Errorf("Compute() = %v, want 1", got)
+	}
+	names := Names()
+	if !slices.Contains(names, "registry_test_exact") || !slices.Contains(names, "bleu") || !slices.IsSorted(names) {
+		t.Errorf("Names() = %v, want sorted names with builtin and custom metrics", names)
+	}
+
+	if err := Register("registry_test_exact", exactMatch{}); !errors.Is(err, ErrDuplicateMetric) {
+		t.Errorf("second Register() error = %v, want ErrDuplicateMetric", err)
+	}
+	// 内置指标不能被覆盖
+	if err := Register("recall", exactMatch{}); !errors.Is(err, ErrDuplicateMetric) {
+		t.Errorf("Register(recall) error = %v, want ErrDuplicateMetric", err)
+	}
+	if err := Register("", exactMatch{}); err == nil {
+		t.Error("Register with empty name succeeded")
+	}
+}
@@ -141,11 +141,7 @@ func (m *F1Metric) Compute(input *MetricInput) float64 {
 	recall := recallMetric.Compute(input)
 	precision := precisionMetric.Compute(input)
 
-	if recall+precision == 0 {
-		return 0.0
-	}
-
-	return 2 * (recall * precision) / (recall + precision)
+	return fMeasure(precision, recall)
 }
 
 func (m *F1Metric) Validate(input *MetricInput) error {
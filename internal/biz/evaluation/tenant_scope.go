@@ -0,0 +1,56 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+var (
+	// ErrDatasetNotFound 数据集不存在或不属于当前租户.
+	ErrDatasetNotFound = errs.New(errs.ErrNotFound, "evaluation dataset not found")
+	// ErrTaskNotFound 评估任务不存在或不属于当前租户.
+	ErrTaskNotFound = errs.New(errs.ErrNotFound, "evaluation task not found")
+)
+
+// tenantScope 将查询限定在租户内，租户 ID 为 0（未识别租户）时不匹配任何记录.
+// 数据集和任务的读取、删除都经过该条件，已知 ID 也无法跨租户访问.
+func tenantScope(tenantID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenantID == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where("tenant_id = ?", tenantID)
+	}
+}
+
+// findDataset 查询租户内的数据集，不存在或属于其他租户时返回 ErrDatasetNotFound.
+func findDataset(ctx context.Context, db *gorm.DB, tenantID uint, datasetID string) (*model.EvaluationDataset, error) {
+	var dataset model.EvaluationDataset
+	err := db.WithContext(ctx).Scopes(tenantScope(tenantID)).Where("id = ?", datasetID).First(&dataset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrDatasetNotFound, datasetID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dataset: %w", err)
+	}
+	return &dataset, nil
+}
+
+// findTask 查询租户内的评估任务，不存在或属于其他租户时返回 ErrTaskNotFound.
+func findTask(ctx context.Context, db *gorm.DB, tenantID uint, taskID string) (*model.EvaluationTask, error) {
+	var task model.EvaluationTask
+	err := db.WithContext(ctx).Scopes(tenantScope(tenantID)).Where("id = ?", taskID).First(&task).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrTaskNotFound, taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return &task, nil
+}
@@ -0,0 +1,188 @@
+package evaluation
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+)
+
+// ownedRow 测试库中的一条数据集或任务记录.
+type ownedRow struct {
+	table    string
+	id       string
+	tenantID uint
+}
+
+// tenantDB 只理解按 tenant_id 和 id 查询的内存数据库驱动，并记录执行的写语句.
+type tenantDB struct {
+	mu    sync.Mutex
+	rows  []ownedRow
+	execs []string
+}
+
+var (
+	fromTable   = regexp.MustCompile(`FROM "(\w+)"`)
+	idParam     = regexp.MustCompile(`\bid = \$(\d+)`)
+	tenantParam = regexp.MustCompile(`\btenant_id = \$(\d+)`)
+)
+
+// paramValue 返回 pattern 匹配的 $n 占位符对应的参数.
+func paramValue(pattern *regexp.Regexp, query string, args []driver.NamedValue) (string, bool) {
+	m := pattern.FindStringSubmatch(query)
+	if m == nil {
+		return "", false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n < 1 || n > len(args) {
+		return "", false
+	}
+	return fmt.Sprint(args[n-1].Value), true
+}
+
+func (d *tenantDB) Connect(context.Context) (driver.Conn, error) { return &tenantConn{db: d}, nil }
+func (d *tenantDB) Driver() driver.Driver                        { return nil }
+
+type tenantConn struct{ db *tenantDB }
+
+func (c *tenantConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *tenantConn) Close() error              { return nil }
+func (c *tenantConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *tenantConn) Commit() error             { return nil }
+func (c *tenantConn) Rollback() error           { return nil }
+
+// QueryContext 按 id 和 tenant_id 条件返回匹配的记录，tenantScope 的 1 = 0 不返回记录.
+func (c *tenantConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	m := fromTable.FindStringSubmatch(query)
+	if m == nil {
+		return nil, fmt.Errorf("unexpected query: %s", query)
+	}
+	rows := &tenantRows{}
+	if strings.Contains(query, "1 = 0") {
+		return rows, nil
+	}
+	id, okID := paramValue(idParam, query, args)
+	tenantID, okTenant := paramValue(tenantParam, query, args)
+	if !okID || !okTenant {
+		return nil, fmt.Errorf("query is not scoped to the tenant: %s", query)
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, r := range c.db.rows {
+		if r.table == m[1] && r.id == id && fmt.Sprint(r.tenantID) == tenantID {
+			rows.values = append(rows.values, []driver.Value{r.id, int64(r.tenantID)})
+		}
+	}
+	return rows, nil
+}
+
+func (c *tenantConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.execs = append(c.db.execs, query)
+	return driver.RowsAffected(1), nil
+}
+
+type tenantRows struct {
+	values [][]driver.Value
+}
+
+func (r *tenantRows) Columns() []string { return []string{"id", "tenant_id"} }
+func (r *tenantRows) Close() error      { return nil }
+
+func (r *tenantRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// newTenantService 返回使用 tenantDB 的评估服务.
+func newTenantService(t *testing.T, rows ...ownedRow) (*Service, *tenantDB) {
+	t.Helper()
+	fake := &tenantDB{rows: rows}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fake)}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	return NewService(db, nil), fake
+}
+
+func TestCrossTenantReadsReturnNotFound(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTenantService(t,
+		ownedRow{table: "evaluation_datasets", id: "ds1", tenantID: 1},
+		ownedRow{table: "evaluation_tasks", id: "task1", tenantID: 1},
+	)
+
+	if ds, err := s.GetDataset(ctx, 1, "ds1"); err != nil || ds.ID != "ds1" {
+		t.Fatalf("GetDataset(owner) = %v, %v", ds, err)
+	}
+	if task, err := s.GetTask(ctx, 1, "task1"); err != nil || task.ID != "task1" {
+		t.Fatalf("GetTask(owner) = %v, %v", task, err)
+	}
+
+	// 其他租户和未识别租户（0）即使知道 ID 也读不到
+	reads := []struct {
+		name string
+		read func(tenantID uint) error
+		want error
+	}{
+		{"GetDataset", func(tenantID uint) error { _, err := s.GetDataset(ctx, tenantID, "ds1"); return err }, ErrDatasetNotFound},
+		{"GetDatasetItems", func(tenantID uint) error { _, err := s.GetDatasetItems(ctx, tenantID, "ds1"); return err }, ErrDatasetNotFound},
+		{"GetTask", func(tenantID uint) error { _, err := s.GetTask(ctx, tenantID, "task1"); return err }, ErrTaskNotFound},
+		{"GetTaskResults", func(tenantID uint) error { _, err := s.GetTaskResults(ctx, tenantID, "task1"); return err }, ErrTaskNotFound},
+	}
+	for _, tenantID := range []uint{2, 0} {
+		for _, r := range reads {
+			if err := r.read(tenantID); !errors.Is(err, r.want) || !errors.Is(err, errs.ErrNotFound) {
+				t.Errorf("tenant %d %s() error = %v, want %v", tenantID, r.name, err, r.want)
+			}
+		}
+	}
+}
+
+func TestCrossTenantDeleteRemovesNothing(t *testing.T) {
+	ctx := context.Background()
+	s, fake := newTenantService(t,
+		ownedRow{table: "evaluation_datasets", id: "ds1", tenantID: 1},
+		ownedRow{table: "evaluation_tasks", id: "task1", tenantID: 1},
+	)
+
+	if err := s.DeleteDataset(ctx, 2, "ds1"); !errors.Is(err, ErrDatasetNotFound) {
+		t.Errorf("DeleteDataset(other tenant) error = %v, want ErrDatasetNotFound", err)
+	}
+	if err := s.DeleteTask(ctx, 2, "task1"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("DeleteTask(other tenant) error = %v, want ErrTaskNotFound", err)
+	}
+	if len(fake.execs) != 0 {
+		t.Errorf("cross-tenant deletes executed %v, want nothing", fake.execs)
+	}
+
+	if err := s.DeleteDataset(ctx, 1, "ds1"); err != nil {
+		t.Fatalf("DeleteDataset(owner): %v", err)
+	}
+	if len(fake.execs) == 0 {
+		t.Error("owner delete executed nothing")
+	}
+}
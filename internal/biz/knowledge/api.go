@@ -3,13 +3,32 @@ package knowledge
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/cloudwego/eino/components/embedding"
 
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/biz/tenant"
 	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+	"github.com/ashwinyue/next-show/internal/pkg/connector"
+	"github.com/ashwinyue/next-show/internal/pkg/events"
+	"github.com/ashwinyue/next-show/internal/pkg/moderation"
+	"github.com/ashwinyue/next-show/internal/pkg/tokenizer"
 	"github.com/ashwinyue/next-show/internal/store"
 )
 
+// ErrInvalidKnowledgeBase 知识库配置不合法.
+var ErrInvalidKnowledgeBase = errs.New(errs.ErrValidation, "invalid knowledge base")
+
+// ErrInvalidSearchRequest 检索参数不合法.
+var ErrInvalidSearchRequest = errs.New(errs.ErrValidation, "invalid search request")
+
 // Biz 知识库业务接口.
 type Biz interface {
 	// KnowledgeBase
@@ -22,8 +41,11 @@ type Biz interface {
 	// Document
 	CreateDocument(ctx context.Context, doc *model.KnowledgeDocument) error
 	GetDocument(ctx context.Context, id string) (*model.KnowledgeDocument, error)
-	ListDocuments(ctx context.Context, kbID string) ([]*model.KnowledgeDocument, error)
+	ListDocuments(ctx context.Context, kbID string, opts *store.ListOptions) ([]*model.KnowledgeDocument, error)
 	DeleteDocument(ctx context.Context, id string) error
+	DeleteDocuments(ctx context.Context, kbID string, filter *DocumentFilter) (int64, error)
+	DownloadDocument(ctx context.Context, id string, viewer *Viewer) (*DocumentDownload, error)
+	MoveDocument(ctx context.Context, docID, targetKBID string, viewer *Viewer) (*model.KnowledgeDocument, error)
 
 	// Chunk
 	GetChunk(ctx context.Context, id string) (*model.KnowledgeChunk, error)
@@ -36,6 +58,10 @@ type Biz interface {
 	CreateTag(ctx context.Context, tag *model.KnowledgeTag) error
 	GetTag(ctx context.Context, id string) (*model.KnowledgeTag, error)
 	ListTags(ctx context.Context, kbID string) ([]*model.KnowledgeTag, error)
+	GetTagTree(ctx context.Context, kbID string) ([]*TagNode, error)
+	MoveTag(ctx context.Context, tagID string, parentID *string) (*model.KnowledgeTag, error)
+	ListTagsWithCounts(ctx context.Context, tenantID string, byKnowledgeBase bool) ([]*TenantTag, error)
+	ApplyTagByQuery(ctx context.Context, kbID, tagID string, req *ApplyTagByQueryRequest) (*ApplyTagResult, error)
 	UpdateTag(ctx context.Context, tag *model.KnowledgeTag) error
 	DeleteTag(ctx context.Context, id string) error
 
@@ -47,24 +73,190 @@ type Biz interface {
 
 	// Import
 	ImportDocument(ctx context.Context, req *ImportRequest) (*ImportResult, error)
+	ImportTable(ctx context.Context, req *TableImportRequest) (*TableImportResult, error)
+	GetDocumentStatus(ctx context.Context, kbID, docID string) (*DocumentStatus, error)
+	RecoverInterruptedImports(ctx context.Context) (int64, error)
+	GetKnowledgeBaseStats(ctx context.Context, kbID string) (*KnowledgeBaseStats, error)
+
+	// Sync
+	HandleSourceWebhook(ctx context.Context, kbID string, header http.Header, body []byte) (*SyncResult, error)
+	SyncKnowledgeBase(ctx context.Context, kbID string) (*SyncResult, error)
+
+	// Rechunk
+	RechunkDocument(ctx context.Context, docID string, req *RechunkRequest) (*RechunkResult, error)
+
+	// Related
+	GetRelatedDocuments(ctx context.Context, docID string, topK int) ([]*RelatedDocument, error)
+
+	// Maintenance
+	RepairOrphans(ctx context.Context, req *RepairOrphansRequest) (*OrphanReport, error)
+	StartReindex(ctx context.Context, kbID string, req *ReindexRequest) (*model.ReindexJob, error)
+	GetReindexJob(ctx context.Context, jobID string) (*model.ReindexJob, error)
+	ReembedDocuments(ctx context.Context, kbID string, documentIDs []string) (*model.ReindexJob, error)
+	RecoverInterruptedReindexJobs(ctx context.Context) (int64, error)
+	CheckVectorSupport(ctx context.Context) (*store.VectorSupport, error)
+
+	// Clone
+	CloneKnowledgeBase(ctx context.Context, id string, req *CloneRequest, viewer *Viewer) (*CloneJob, error)
+	GetCloneJob(ctx context.Context, jobID string) (*CloneJob, error)
+
+	// Archive
+	ExportKnowledgeBase(ctx context.Context, id string, w io.Writer, viewer *Viewer) error
+	ImportKnowledgeBaseArchive(ctx context.Context, r io.Reader, req *ArchiveImportRequest, viewer *Viewer) (*model.KnowledgeBase, error)
 
 	// Search
-	Search(ctx context.Context, kbID, query string, topK int, vectorWeight, bm25Weight float64) (*SearchResult, error)
+	Search(ctx context.Context, kbID string, req *HybridSearchRequest) (*SearchResult, error)
+}
+
+// BizConfig 知识库业务的可选配置.
+type BizConfig struct {
+	// SplitEmbedder 语义分块计算分块边界使用的 Embedding 模型，可以使用比检索更便宜的模型.
+	// 为空时使用主 Embedding 模型
+	SplitEmbedder embedding.Embedder
+	// Tokenizer 按 token 分块时使用的 token 计数器，为空时使用近似估算
+	Tokenizer tokenizer.Counter
+	// EmbeddingBatchSize 导入文档时每次调用 Embedding 模型的分块数，默认 64
+	EmbeddingBatchSize int
+	// EmbeddingMaxConcurrency 导入文档时同时调用 Embedding 模型的批次数，默认 4
+	EmbeddingMaxConcurrency int
+	// SplitEmbeddingBatchSize 语义分块时每次调用 Embedding 模型的句子窗口数，默认同 EmbeddingBatchSize
+	SplitEmbeddingBatchSize int
+	// SplitEmbeddingMaxConcurrency 语义分块时同时调用 Embedding 模型的批次数，默认同 EmbeddingMaxConcurrency
+	SplitEmbeddingMaxConcurrency int
+	// VectorIndex 不为空时，创建或修改知识库后为其距离函数创建向量索引
+	VectorIndex *VectorIndexConfig
+	// Events 不为空时发布文档和分块的变更事件（document.created / updated / deleted、chunk.updated）
+	Events *events.Bus
+	// ImportLimits 按知识库和租户限制同时导入的文档数，超过限制的导入排队等待，默认不限制
+	ImportLimits ImportLimitConfig
+	// MaxChunksPerDocument 导入文档的最大分块数，超过时按 ChunkLimitPolicy 处理，<= 0 不限制
+	MaxChunksPerDocument int
+	// ChunkLimitPolicy 分块数超过上限时的处理方式：reject（默认）/ truncate
+	ChunkLimitPolicy string
+	// ImportStaleTimeout 导入中的文档超过该时长没有进展时视为中断，不再参与文件哈希去重，默认 1 小时
+	ImportStaleTimeout time.Duration
+	// IndexRebuildInterval 两次重建全局搜索索引（影响所有知识库）的最小间隔，默认 1 小时
+	IndexRebuildInterval time.Duration
+}
+
+// VectorIndexConfig 向量索引参数，<= 0 时使用默认值.
+type VectorIndexConfig struct {
+	M              int
+	EfConstruction int
 }
 
 // bizImpl 知识库业务实现.
 type bizImpl struct {
-	store    store.Store
-	embedder embedding.Embedder
+	store         store.Store
+	embedder      embedding.Embedder
+	splitEmbedder embedding.Embedder
+	tokenizer     tokenizer.Counter
+	moderator     *moderation.Moderator
+	files         blob.Store
+
+	embeddingBatchSize      int
+	embeddingMaxConcurrency int
+	vectorIndex             *VectorIndexConfig
+	events                  *events.Bus
+	maxChunksPerDocument    int
+	chunkLimitPolicy        string
+	importStaleTimeout      time.Duration
+	indexRebuildInterval    time.Duration
+
+	importJobs    *importJobs
+	importLimiter *importLimiter
+	reindexJobs   *reindexJobs
+	cloneJobs     *cloneJobs
+	// indexRebuildMu 串行化重建间隔的检查和任务登记
+	indexRebuildMu sync.Mutex
 }
 
-// NewBiz 创建知识库业务实例.
-func NewBiz(s store.Store, embedder embedding.Embedder) Biz {
-	return &bizImpl{store: s, embedder: embedder}
+// NewBiz 创建知识库业务实例，moderator 为 nil 时导入不做内容审核，files 为 nil 时使用本地存储，
+// cfg 为 nil 时使用默认配置.
+func NewBiz(s store.Store, embedder embedding.Embedder, moderator *moderation.Moderator, files blob.Store, cfg *BizConfig) Biz {
+	if files == nil {
+		files = blob.NewLocalStore(DataFilesBaseDir)
+	}
+	if cfg == nil {
+		cfg = &BizConfig{}
+	}
+	splitEmbedder := cfg.SplitEmbedder
+	if splitEmbedder == nil {
+		splitEmbedder = embedder
+	}
+	counter := cfg.Tokenizer
+	if counter == nil {
+		counter = tokenizer.Estimator{}
+	}
+	batchSize := cfg.EmbeddingBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEmbeddingBatchSize
+	}
+	concurrency := cfg.EmbeddingMaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultEmbeddingMaxConcurrency
+	}
+	if splitEmbedder != nil {
+		splitBatchSize := cfg.SplitEmbeddingBatchSize
+		if splitBatchSize <= 0 {
+			splitBatchSize = batchSize
+		}
+		splitConcurrency := cfg.SplitEmbeddingMaxConcurrency
+		if splitConcurrency <= 0 {
+			splitConcurrency = concurrency
+		}
+		splitEmbedder = &batchingEmbedder{embedder: splitEmbedder, batchSize: splitBatchSize, concurrency: splitConcurrency}
+	}
+	staleTimeout := cfg.ImportStaleTimeout
+	if staleTimeout <= 0 {
+		staleTimeout = defaultImportStaleTimeout
+	}
+	rebuildInterval := cfg.IndexRebuildInterval
+	if rebuildInterval <= 0 {
+		rebuildInterval = defaultIndexRebuildInterval
+	}
+	return &bizImpl{
+		store:         s,
+		embedder:      embedder,
+		splitEmbedder: splitEmbedder,
+		tokenizer:     counter,
+		moderator:     moderator,
+		files:         files,
+
+		embeddingBatchSize:      batchSize,
+		embeddingMaxConcurrency: concurrency,
+		vectorIndex:             cfg.VectorIndex,
+		events:                  cfg.Events,
+		maxChunksPerDocument:    cfg.MaxChunksPerDocument,
+		chunkLimitPolicy:        cfg.ChunkLimitPolicy,
+		importStaleTimeout:      staleTimeout,
+		indexRebuildInterval:    rebuildInterval,
+
+		importJobs:    newImportJobs(),
+		importLimiter: newImportLimiter(cfg.ImportLimits),
+		reindexJobs:   newReindexJobs(s),
+		cloneJobs:     newCloneJobs(),
+	}
 }
 
 func (b *bizImpl) CreateKnowledgeBase(ctx context.Context, kb *model.KnowledgeBase) error {
-	return b.store.Knowledge().CreateKnowledgeBase(ctx, kb)
+	b.applyEmbeddingDefaults(ctx, kb)
+	if err := b.validateEmbeddingProvider(ctx, kb); err != nil {
+		return err
+	}
+	if err := validateSyncConfig(kb); err != nil {
+		return err
+	}
+	if err := validateDistanceFunction(kb); err != nil {
+		return err
+	}
+	if err := validateTextSearchConfig(kb); err != nil {
+		return err
+	}
+	if err := b.store.Knowledge().CreateKnowledgeBase(ctx, kb); err != nil {
+		return err
+	}
+	return b.ensureVectorIndex(ctx, kb)
 }
 
 func (b *bizImpl) GetKnowledgeBase(ctx context.Context, id string) (*model.KnowledgeBase, error) {
@@ -76,7 +268,119 @@ func (b *bizImpl) ListKnowledgeBases(ctx context.Context) ([]*model.KnowledgeBas
 }
 
 func (b *bizImpl) UpdateKnowledgeBase(ctx context.Context, kb *model.KnowledgeBase) error {
-	return b.store.Knowledge().UpdateKnowledgeBase(ctx, kb)
+	if err := b.validateEmbeddingProvider(ctx, kb); err != nil {
+		return err
+	}
+	stored, err := b.store.Knowledge().GetKnowledgeBase(ctx, kb.ID)
+	if err != nil {
+		return fmt.Errorf("get knowledge base: %w", err)
+	}
+	// 所属租户只在创建时确定，不能通过更新转移
+	kb.TenantID = stored.TenantID
+	// 响应中的密钥是占位值，客户端原样提交时沿用已保存的密钥
+	connector.RestoreRedacted(kb.SyncConfig, stored.SyncConfig)
+	if err := validateSyncConfig(kb); err != nil {
+		return err
+	}
+	if err := validateDistanceFunction(kb); err != nil {
+		return err
+	}
+	if err := validateTextSearchConfig(kb); err != nil {
+		return err
+	}
+	if err := b.store.Knowledge().UpdateKnowledgeBase(ctx, kb); err != nil {
+		return err
+	}
+	return b.ensureVectorIndex(ctx, kb)
+}
+
+// applyEmbeddingDefaults 未指定 Embedding Provider 时使用租户/系统默认值.
+// 默认值在创建时写入知识库，之后修改默认值不会影响已有向量的维度和模型.
+func (b *bizImpl) applyEmbeddingDefaults(ctx context.Context, kb *model.KnowledgeBase) {
+	if providerID, _ := kb.EmbeddingConfig["provider_id"].(string); providerID != "" {
+		return
+	}
+	defaults := tenant.ResolveDefaults(ctx, b.store, kb.TenantID)
+	if defaults.EmbeddingProviderID == "" {
+		return
+	}
+	if kb.EmbeddingConfig == nil {
+		kb.EmbeddingConfig = model.JSONMap{}
+	}
+	kb.EmbeddingConfig["provider_id"] = defaults.EmbeddingProviderID
+	if modelName, _ := kb.EmbeddingConfig["model"].(string); modelName == "" && defaults.EmbeddingModel != "" {
+		kb.EmbeddingConfig["model"] = defaults.EmbeddingModel
+	}
+}
+
+// validateEmbeddingProvider 校验 embedding_config.provider_id 指向具备 Embedding 能力的 Provider.
+func (b *bizImpl) validateEmbeddingProvider(ctx context.Context, kb *model.KnowledgeBase) error {
+	providerID, _ := kb.EmbeddingConfig["provider_id"].(string)
+	if providerID == "" {
+		return nil
+	}
+	provider, err := b.store.Providers().Get(ctx, providerID)
+	if err != nil {
+		return fmt.Errorf("%w: embedding provider %s not found", ErrInvalidKnowledgeBase, providerID)
+	}
+	if !provider.HasCapability(model.ModelCategoryEmbedding) {
+		return fmt.Errorf("%w: provider %s is not embedding-capable", ErrInvalidKnowledgeBase, provider.Name)
+	}
+	return nil
+}
+
+// validateDistanceFunction 校验距离函数，未指定时使用 cosine.
+func validateDistanceFunction(kb *model.KnowledgeBase) error {
+	if kb.DistanceFunction == "" {
+		kb.DistanceFunction = string(store.DistanceCosine)
+	}
+	if err := store.DistanceFunction(kb.DistanceFunction).Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKnowledgeBase, err)
+	}
+	return nil
+}
+
+// validateTextSearchConfig 校验文本检索配置名，未指定时使用 simple.
+// 配置是否存在在检索时检查，不存在时退化为 simple，因此可以先配置再安装扩展.
+func validateTextSearchConfig(kb *model.KnowledgeBase) error {
+	if kb.TextSearchConfig == "" {
+		kb.TextSearchConfig = store.DefaultTextSearchConfig
+	}
+	if err := store.ValidateTextSearchConfig(kb.TextSearchConfig); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKnowledgeBase, err)
+	}
+	return nil
+}
+
+// ensureVectorIndex 为知识库的距离函数创建对应算子类的向量索引，未配置 VectorIndex 时不创建.
+// 索引建在整张 embeddings 表上，使用相同距离函数的知识库共用一个索引.
+func (b *bizImpl) ensureVectorIndex(ctx context.Context, kb *model.KnowledgeBase) error {
+	if b.vectorIndex == nil {
+		return nil
+	}
+	if _, err := b.store.Knowledge().EnsureVectorIndex(ctx, store.DistanceFunction(kb.DistanceFunction),
+		b.vectorIndex.M, b.vectorIndex.EfConstruction); err != nil {
+		return fmt.Errorf("ensure vector index: %w", err)
+	}
+	return nil
+}
+
+// validateSyncConfig 校验 sync_config 能创建出连接器，启用 Webhook 时必须配置签名密钥.
+func validateSyncConfig(kb *model.KnowledgeBase) error {
+	if len(kb.SyncConfig) == 0 {
+		return nil
+	}
+	cfg, err := connector.ParseConfig(kb.SyncConfig)
+	if err != nil {
+		return fmt.Errorf("%w: sync_config: %v", ErrInvalidKnowledgeBase, err)
+	}
+	if !cfg.DisableWebhook && cfg.WebhookSecret == "" {
+		return fmt.Errorf("%w: sync_config: webhook_secret is required unless disable_webhook is set", ErrInvalidKnowledgeBase)
+	}
+	if _, err := connector.New(kb.SyncConfig); err != nil {
+		return fmt.Errorf("%w: sync_config: %v", ErrInvalidKnowledgeBase, err)
+	}
+	return nil
 }
 
 func (b *bizImpl) DeleteKnowledgeBase(ctx context.Context, id string) error {
@@ -91,12 +395,20 @@ func (b *bizImpl) GetDocument(ctx context.Context, id string) (*model.KnowledgeD
 	return b.store.Knowledge().GetDocument(ctx, id)
 }
 
-func (b *bizImpl) ListDocuments(ctx context.Context, kbID string) ([]*model.KnowledgeDocument, error) {
-	return b.store.Knowledge().ListDocumentsByKnowledgeBase(ctx, kbID)
+func (b *bizImpl) ListDocuments(ctx context.Context, kbID string, opts *store.ListOptions) ([]*model.KnowledgeDocument, error) {
+	return b.store.Knowledge().ListDocumentsByKnowledgeBase(ctx, kbID, opts)
 }
 
 func (b *bizImpl) DeleteDocument(ctx context.Context, id string) error {
-	return b.store.Knowledge().DeleteDocument(ctx, id)
+	doc, err := b.store.Knowledge().GetDocument(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := b.store.Knowledge().DeleteDocument(ctx, id); err != nil {
+		return err
+	}
+	b.publishDocument(events.DocumentDeleted, doc, nil)
+	return nil
 }
 
 func (b *bizImpl) GetChunk(ctx context.Context, id string) (*model.KnowledgeChunk, error) {
@@ -112,19 +424,38 @@ func (b *bizImpl) ListChunksByKnowledgeBase(ctx context.Context, kbID string, li
 }
 
 func (b *bizImpl) UpdateChunk(ctx context.Context, chunk *model.KnowledgeChunk) error {
-	return b.store.Knowledge().UpdateChunk(ctx, chunk)
+	if err := b.store.Knowledge().UpdateChunk(ctx, chunk); err != nil {
+		return err
+	}
+	b.evictDocumentSummary(ctx, chunk.DocumentID)
+	b.publishChunk(events.ChunkUpdated, chunk)
+	return nil
 }
 
 func (b *bizImpl) DeleteChunk(ctx context.Context, id string) error {
-	return b.store.Knowledge().DeleteChunk(ctx, id)
+	chunk, err := b.store.Knowledge().GetChunk(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := b.store.Knowledge().DeleteChunk(ctx, id); err != nil {
+		return err
+	}
+	b.evictDocumentSummary(ctx, chunk.DocumentID)
+	return nil
 }
 
-// Tag 相关方法
-
-func (b *bizImpl) CreateTag(ctx context.Context, tag *model.KnowledgeTag) error {
-	return b.store.Knowledge().CreateTag(ctx, tag)
+// evictDocumentSummary 分块变化后清除文档缓存的摘要；清除失败时缓存仍会因内容哈希不一致而失效.
+func (b *bizImpl) evictDocumentSummary(ctx context.Context, docID string) {
+	if docID == "" {
+		return
+	}
+	if err := b.store.Knowledge().UpdateDocumentSummary(ctx, docID, "", ""); err != nil {
+		log.Printf("evict summary of document %s failed: %v", docID, err)
+	}
 }
 
+// Tag 相关方法
+
 func (b *bizImpl) GetTag(ctx context.Context, id string) (*model.KnowledgeTag, error) {
 	return b.store.Knowledge().GetTag(ctx, id)
 }
@@ -159,6 +490,53 @@ func (b *bizImpl) ListChunksByTag(ctx context.Context, tagID string, limit, offs
 	return b.store.Knowledge().ListChunksByTag(ctx, tagID, limit, offset)
 }
 
+// HybridSearchRequest 知识库混合检索请求.
+type HybridSearchRequest struct {
+	Query        string
+	TopK         int
+	VectorWeight float64
+	BM25Weight   float64
+	// DistanceFunction 向量距离函数（cosine/l2/ip），为空时使用知识库的距离函数，
+	// 与知识库不一致时无法使用向量索引，返回 ErrInvalidSearchRequest
+	DistanceFunction string
+	// DocumentMetadata 按文档元数据过滤，例如 {"department": "legal"}
+	DocumentMetadata map[string]any
+	// MetadataFilters 按分块元数据过滤，多个条件为 AND 关系
+	MetadataFilters []store.MetadataFilter
+	// FusionMethod 合并向量和全文检索结果的方式（weighted_sum/rrf），为空时使用 weighted_sum
+	FusionMethod string
+	// RRFK FusionMethod 为 rrf 时的平滑常数 k，<=0 时使用 60
+	RRFK int
+	// Rerank 是否对检索结果重排序，结果中同时返回重排序前后的分数
+	Rerank bool
+	// TagIDs 只检索带有这些标签或其子孙标签的分块
+	TagIDs []string
+}
+
+// Validate 校验检索参数：权重不能为负数，距离函数必须受支持.
+func (r *HybridSearchRequest) Validate() error {
+	if r.VectorWeight < 0 {
+		return fmt.Errorf("%w: vector_weight must be non-negative", ErrInvalidSearchRequest)
+	}
+	if r.BM25Weight < 0 {
+		return fmt.Errorf("%w: bm25_weight must be non-negative", ErrInvalidSearchRequest)
+	}
+	if r.DistanceFunction != "" {
+		if err := store.DistanceFunction(r.DistanceFunction).Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSearchRequest, err)
+		}
+	}
+	if err := store.ValidateMetadataFilters(r.MetadataFilters); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSearchRequest, err)
+	}
+	if r.FusionMethod != "" {
+		if err := store.FusionMethod(r.FusionMethod).Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSearchRequest, err)
+		}
+	}
+	return nil
+}
+
 // SearchResult 检索结果.
 type SearchResult struct {
 	Chunks     []*ChunkSearchResult `json:"chunks"`
@@ -174,14 +552,54 @@ type ChunkSearchResult struct {
 	ChunkIndex      int     `json:"chunk_index"`
 	Content         string  `json:"content"`
 	Score           float64 `json:"score"`
+	// 以下字段仅在重排序时返回：重排序前的分数和名次（从 1 开始）、重排序后的分数
+	PreRerankScore *float64 `json:"pre_rerank_score,omitempty"`
+	PreRerankRank  int      `json:"pre_rerank_rank,omitempty"`
+	RerankScore    *float64 `json:"rerank_score,omitempty"`
+}
+
+// searchDistanceFunction 返回检索使用的距离函数：未指定时使用知识库的距离函数；
+// 指定的距离函数与知识库不一致时向量索引不可用，只能全表扫描，直接拒绝.
+func searchDistanceFunction(kb *model.KnowledgeBase, requested string) (store.DistanceFunction, error) {
+	distance := store.DistanceFunction(kb.DistanceFunction)
+	if distance == "" {
+		distance = store.DistanceCosine
+	}
+	if requested != "" && store.DistanceFunction(requested) != distance {
+		return "", fmt.Errorf("%w: distance_function %s does not match knowledge base index (%s)", ErrInvalidSearchRequest, requested, distance)
+	}
+	return distance, nil
 }
 
 // Search 混合检索.
-func (b *bizImpl) Search(ctx context.Context, kbID, query string, topK int, vectorWeight, bm25Weight float64) (*SearchResult, error) {
+func (b *bizImpl) Search(ctx context.Context, kbID string, req *HybridSearchRequest) (*SearchResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
 	if b.embedder == nil {
 		return &SearchResult{Chunks: []*ChunkSearchResult{}, TotalCount: 0}, nil
 	}
 
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	distance, err := searchDistanceFunction(kb, req.DistanceFunction)
+	if err != nil {
+		return nil, err
+	}
+	var tagIDs []string
+	if len(req.TagIDs) > 0 {
+		if tagIDs, err = b.expandTagIDs(ctx, kbID, req.TagIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	query := req.Query
+	topK := req.TopK
+	vectorWeight := req.VectorWeight
+	bm25Weight := req.BM25Weight
+
 	if topK <= 0 {
 		topK = 10
 	}
@@ -208,7 +626,14 @@ func (b *bizImpl) Search(ctx context.Context, kbID, query string, topK int, vect
 
 	// 执行混合检索
 	kbIDs := []string{kbID}
-	results, err := b.store.Knowledge().HybridSearch(ctx, kbIDs, queryVector, query, topK, vectorWeight, bm25Weight)
+	results, err := b.store.Knowledge().HybridSearch(ctx, kbIDs, queryVector, query, topK, vectorWeight, bm25Weight, store.SearchOptions{
+		DistanceFunction: distance,
+		DocumentMetadata: req.DocumentMetadata,
+		MetadataFilters:  req.MetadataFilters,
+		Fusion:           store.FusionMethod(req.FusionMethod),
+		RRFK:             req.RRFK,
+		TagIDs:           tagIDs,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -231,6 +656,9 @@ func (b *bizImpl) Search(ctx context.Context, kbID, query string, topK int, vect
 			Score:           r.Score,
 		})
 	}
+	if req.Rerank {
+		chunks = rerankSearchResults(ctx, chunks)
+	}
 
 	return &SearchResult{
 		Chunks:     chunks,
@@ -0,0 +1,597 @@
+package knowledge
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// ArchiveFormatVersion 知识库归档格式版本.
+const ArchiveFormatVersion = 1
+
+// archiveBatchSize 导出导入归档时每批处理的文档数和分块数，每批写成一个归档条目.
+const archiveBatchSize = 200
+
+// 归档条目.
+// 条目按 manifest.json、tags.json、documents/、files/、chunks/ 的顺序写入，导入时按顺序流式读取：
+// 文档条目之后紧跟这批文档的原始文件，分块条目在所有文档之后.
+const (
+	archiveManifestEntry  = "manifest.json"
+	archiveTagsEntry      = "tags.json"
+	archiveDocumentsDir   = "documents/"
+	archiveFilesDir       = "files/"
+	archiveChunksDir      = "chunks/"
+	archiveBatchEntryName = "%06d.jsonl"
+)
+
+// ErrInvalidArchive 归档文件格式不正确.
+var ErrInvalidArchive = errs.New(errs.ErrValidation, "invalid knowledge base archive")
+
+// ArchiveManifest 归档清单.
+type ArchiveManifest struct {
+	FormatVersion int                  `json:"format_version"`
+	ExportedAt    time.Time            `json:"exported_at"`
+	KnowledgeBase *model.KnowledgeBase `json:"knowledge_base"`
+	// EmbeddingSpaces 归档中向量的维度和模型，导入时据此校验兼容性
+	EmbeddingSpaces []*store.EmbeddingSpace `json:"embedding_spaces"`
+	DocumentCount   int64                   `json:"document_count"`
+}
+
+// archiveChunk 归档中的分块，包含向量和标签关联.
+type archiveChunk struct {
+	Chunk     *model.KnowledgeChunk `json:"chunk"`
+	TagIDs    []string              `json:"tag_ids,omitempty"`
+	Embedding *archiveEmbedding     `json:"embedding,omitempty"`
+}
+
+type archiveEmbedding struct {
+	Dim      int           `json:"dim"`
+	Model    string        `json:"model"`
+	Vector   []float32     `json:"vector"`
+	Metadata model.JSONMap `json:"metadata,omitempty"`
+}
+
+// ArchiveImportRequest 导入知识库归档请求.
+type ArchiveImportRequest struct {
+	// Name 新知识库名称，为空时使用归档中的名称
+	Name string
+}
+
+// ExportKnowledgeBase 将知识库的文档、分块、向量、标签和原始文件导出为 tar.gz 归档写入 w.
+// 按批读取和写入，内存占用与知识库大小无关；原始文件流式写入归档.
+func (b *bizImpl) ExportKnowledgeBase(ctx context.Context, id string, w io.Writer, viewer *Viewer) error {
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get knowledge base: %w", err)
+	}
+	if !canAccess(kb, viewer) {
+		return ErrForbidden
+	}
+	spaces, err := b.store.Knowledge().ListEmbeddingSpaces(ctx, id, "")
+	if err != nil {
+		return fmt.Errorf("list embedding spaces: %w", err)
+	}
+	docCount, err := b.store.Knowledge().CountDocuments(ctx, id)
+	if err != nil {
+		return fmt.Errorf("count documents: %w", err)
+	}
+	tags, err := b.store.Knowledge().ListTagsByKnowledgeBase(ctx, id)
+	if err != nil {
+		return fmt.Errorf("list tags: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	if err := writeArchiveJSON(tw, archiveManifestEntry, &ArchiveManifest{
+		FormatVersion:   ArchiveFormatVersion,
+		ExportedAt:      time.Now(),
+		KnowledgeBase:   kb,
+		EmbeddingSpaces: spaces,
+		DocumentCount:   docCount,
+	}); err != nil {
+		return err
+	}
+	if err := writeArchiveJSON(tw, archiveTagsEntry, tags); err != nil {
+		return err
+	}
+	if err := b.exportDocuments(ctx, tw, id); err != nil {
+		return err
+	}
+	if err := b.exportChunks(ctx, tw, id); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// exportDocuments 按批写入文档，每批之后写入这批文档的原始文件.
+func (b *bizImpl) exportDocuments(ctx context.Context, tw *tar.Writer, kbID string) error {
+	afterID := ""
+	for batch := 1; ; batch++ {
+		docs, err := b.store.Knowledge().ListDocumentsAfter(ctx, kbID, afterID, archiveBatchSize)
+		if err != nil {
+			return fmt.Errorf("list documents: %w", err)
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		lines := make([]any, len(docs))
+		for i, d := range docs {
+			lines[i] = d
+		}
+		if err := writeArchiveLines(tw, archiveDocumentsDir+fmt.Sprintf(archiveBatchEntryName, batch), lines); err != nil {
+			return err
+		}
+
+		for _, d := range docs {
+			if d.SourceType != model.DocumentSourceTypeFile || d.SourceURI == "" {
+				continue
+			}
+			if err := b.exportFile(ctx, tw, d); err != nil {
+				return err
+			}
+		}
+		afterID = docs[len(docs)-1].ID
+	}
+}
+
+// exportFile 将文档的原始文件流式写入归档，原始文件已丢失时跳过，其它读取错误中止导出.
+func (b *bizImpl) exportFile(ctx context.Context, tw *tar.Writer, doc *model.KnowledgeDocument) error {
+	r, err := b.files.Get(ctx, doc.SourceURI)
+	if errors.Is(err, blob.ErrNotFound) {
+		// 原始文件丢失不影响导出分块和向量
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get file %s: %w", doc.SourceURI, err)
+	}
+	defer r.Close()
+
+	var body io.Reader = r
+	size := blob.Size(r)
+	if size < 0 {
+		// 存储未提供长度时先写入临时文件，tar 头需要已知长度
+		tmp, err := os.CreateTemp("", "kb-export-*")
+		if err != nil {
+			return fmt.Errorf("create temp file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if size, err = io.Copy(tmp, r); err != nil {
+			return fmt.Errorf("read file %s: %w", doc.SourceURI, err)
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewind temp file: %w", err)
+		}
+		body = tmp
+	}
+
+	name := archiveFilesDir + doc.ID + "/" + path.Base(doc.SourceURI)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("write %s header: %w", name, err)
+	}
+	if _, err := io.CopyN(tw, body, size); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// exportChunks 按批写入分块及其向量和标签关联.
+func (b *bizImpl) exportChunks(ctx context.Context, tw *tar.Writer, kbID string) error {
+	afterID := ""
+	for batch := 1; ; batch++ {
+		chunks, err := b.store.Knowledge().ListChunksAfter(ctx, kbID, afterID, archiveBatchSize)
+		if err != nil {
+			return fmt.Errorf("list chunks: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(chunks))
+		for i, c := range chunks {
+			ids[i] = c.ID
+		}
+		embeddings, err := b.store.Knowledge().ListChunkEmbeddings(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("list embeddings: %w", err)
+		}
+		tagIDs, err := b.store.Knowledge().ListChunkTagIDs(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("list chunk tags: %w", err)
+		}
+
+		lines := make([]any, len(chunks))
+		for i, c := range chunks {
+			line := &archiveChunk{Chunk: c, TagIDs: tagIDs[c.ID]}
+			if e, ok := embeddings[c.ID]; ok {
+				line.Embedding = &archiveEmbedding{
+					Dim:      e.EmbeddingDim,
+					Model:    e.EmbeddingModel,
+					Vector:   e.Embedding,
+					Metadata: e.Metadata,
+				}
+			}
+			lines[i] = line
+		}
+		if err := writeArchiveLines(tw, archiveChunksDir+fmt.Sprintf(archiveBatchEntryName, batch), lines); err != nil {
+			return err
+		}
+		afterID = chunks[len(chunks)-1].ID
+	}
+}
+
+func writeArchiveJSON(tw *tar.Writer, name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", name, err)
+	}
+	return writeArchiveEntry(tw, name, data)
+}
+
+func writeArchiveLines(tw *tar.Writer, name string, lines []any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, line := range lines {
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("marshal %s: %w", name, err)
+		}
+	}
+	return writeArchiveEntry(tw, name, buf.Bytes())
+}
+
+func writeArchiveEntry(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("write %s header: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// archiveImport 导入归档过程中的 ID 映射.
+type archiveImport struct {
+	kb    *model.KnowledgeBase
+	tags  map[string]string
+	docs  map[string]string
+	files []string
+}
+
+// ImportKnowledgeBaseArchive 从 ExportKnowledgeBase 导出的归档创建新知识库.
+// 文档、分块、标签使用新 ID，向量原样写入无需重新生成；向量维度与数据库不一致时拒绝导入.
+// 新知识库归属 viewer 所在租户，导入期间状态为 inactive，失败时删除已导入的数据.
+func (b *bizImpl) ImportKnowledgeBaseArchive(ctx context.Context, r io.Reader, req *ArchiveImportRequest, viewer *Viewer) (*model.KnowledgeBase, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != archiveManifestEntry {
+		return nil, fmt.Errorf("%w: archive must start with %s", ErrInvalidArchive, archiveManifestEntry)
+	}
+	var manifest ArchiveManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidArchive, archiveManifestEntry, err)
+	}
+	if manifest.FormatVersion != ArchiveFormatVersion || manifest.KnowledgeBase == nil {
+		return nil, fmt.Errorf("%w: unsupported format version %d", ErrInvalidArchive, manifest.FormatVersion)
+	}
+	if err := b.checkArchiveEmbeddings(ctx, &manifest); err != nil {
+		return nil, err
+	}
+
+	kb, err := b.createArchiveKnowledgeBase(ctx, manifest.KnowledgeBase, req, viewer)
+	if err != nil {
+		return nil, err
+	}
+	imp := &archiveImport{kb: kb, tags: make(map[string]string), docs: make(map[string]string)}
+
+	if err := b.importArchiveEntries(ctx, tr, imp); err != nil {
+		// 导入失败时删除知识库（文档、分块、向量随之级联删除）和已保存的原始文件
+		cleanupCtx := context.WithoutCancel(ctx)
+		for _, key := range imp.files {
+			_ = b.files.Delete(cleanupCtx, key)
+		}
+		_ = b.store.Knowledge().DeleteKnowledgeBase(cleanupCtx, kb.ID)
+		return nil, err
+	}
+
+	kb.Status = manifest.KnowledgeBase.Status
+	if kb.Status == "" {
+		kb.Status = model.KnowledgeBaseStatusActive
+	}
+	if err := b.store.Knowledge().UpdateKnowledgeBase(ctx, kb); err != nil {
+		return nil, fmt.Errorf("activate knowledge base: %w", err)
+	}
+	return kb, nil
+}
+
+// checkArchiveEmbeddings 校验归档中向量的维度与 embeddings 表一致.
+func (b *bizImpl) checkArchiveEmbeddings(ctx context.Context, manifest *ArchiveManifest) error {
+	dim, err := b.store.Knowledge().VectorDimension(ctx)
+	if err != nil {
+		return err
+	}
+	if dim == 0 {
+		return nil
+	}
+	for _, space := range manifest.EmbeddingSpaces {
+		if space.Dim != dim {
+			return fmt.Errorf("%w: archive embeddings have dimension %d but this database stores %d-dimensional vectors",
+				ErrIncompatibleEmbedding, space.Dim, dim)
+		}
+	}
+	return nil
+}
+
+// createArchiveKnowledgeBase 按归档中的配置创建知识库.
+// 外部源同步配置不导入；Embedding Provider 在当前环境不存在时改用租户/系统默认值.
+func (b *bizImpl) createArchiveKnowledgeBase(ctx context.Context, src *model.KnowledgeBase, req *ArchiveImportRequest, viewer *Viewer) (*model.KnowledgeBase, error) {
+	kb := &model.KnowledgeBase{
+		ID:               uuid.New().String(),
+		Name:             src.Name,
+		Description:      src.Description,
+		ChunkingConfig:   src.ChunkingConfig,
+		ParserConfig:     src.ParserConfig,
+		IndexerType:      src.IndexerType,
+		IndexerConfig:    src.IndexerConfig,
+		EmbeddingConfig:  src.EmbeddingConfig,
+		DistanceFunction: src.DistanceFunction,
+		TextSearchConfig: src.TextSearchConfig,
+		Status:           model.KnowledgeBaseStatusInactive,
+		Metadata:         src.Metadata,
+	}
+	if req != nil && req.Name != "" {
+		kb.Name = req.Name
+	}
+	if viewer != nil {
+		kb.TenantID = viewer.TenantID
+	}
+	if err := b.validateEmbeddingProvider(ctx, kb); err != nil {
+		delete(kb.EmbeddingConfig, "provider_id")
+		b.applyEmbeddingDefaults(ctx, kb)
+	}
+	if err := validateDistanceFunction(kb); err != nil {
+		return nil, err
+	}
+	if err := validateTextSearchConfig(kb); err != nil {
+		return nil, err
+	}
+	if err := b.store.Knowledge().CreateKnowledgeBase(ctx, kb); err != nil {
+		return nil, fmt.Errorf("create knowledge base: %w", err)
+	}
+	if err := b.ensureVectorIndex(ctx, kb); err != nil {
+		return nil, err
+	}
+	return kb, nil
+}
+
+// importArchiveEntries 按顺序读取 manifest 之后的归档条目.
+func (b *bizImpl) importArchiveEntries(ctx context.Context, tr *tar.Reader, imp *archiveImport) error {
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		switch name := hdr.Name; {
+		case name == archiveTagsEntry:
+			err = b.importArchiveTags(ctx, tr, imp)
+		case strings.HasPrefix(name, archiveDocumentsDir):
+			err = b.importArchiveDocuments(ctx, tr, imp)
+		case strings.HasPrefix(name, archiveFilesDir):
+			err = b.importArchiveFile(ctx, tr, strings.TrimPrefix(name, archiveFilesDir), imp)
+		case strings.HasPrefix(name, archiveChunksDir):
+			err = b.importArchiveChunks(ctx, tr, imp)
+		}
+		if err != nil {
+			return fmt.Errorf("import %s: %w", hdr.Name, err)
+		}
+	}
+}
+
+func (b *bizImpl) importArchiveTags(ctx context.Context, r io.Reader, imp *archiveImport) error {
+	var tags []*model.KnowledgeTag
+	if err := json.NewDecoder(r).Decode(&tags); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	parents := make(map[*model.KnowledgeTag]string)
+	for _, t := range tags {
+		oldID := t.ID
+		t.ID = uuid.New().String()
+		t.KnowledgeBaseID = imp.kb.ID
+		t.KnowledgeBase = nil
+		if t.ParentID != nil {
+			parents[t] = *t.ParentID
+			t.ParentID = nil
+		}
+		if err := b.store.Knowledge().CreateTag(ctx, t); err != nil {
+			return fmt.Errorf("create tag: %w", err)
+		}
+		imp.tags[oldID] = t.ID
+	}
+	// 父标签可能排在子标签之后，全部创建后再设置
+	for t, oldParentID := range parents {
+		parentID, ok := imp.tags[oldParentID]
+		if !ok {
+			continue
+		}
+		t.ParentID = &parentID
+		if err := b.store.Knowledge().UpdateTag(ctx, t); err != nil {
+			return fmt.Errorf("update tag parent: %w", err)
+		}
+	}
+	return nil
+}
+
+func (b *bizImpl) importArchiveDocuments(ctx context.Context, r io.Reader, imp *archiveImport) error {
+	return decodeArchiveLines(r, func(dec *json.Decoder) error {
+		var doc model.KnowledgeDocument
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+		oldID := doc.ID
+		doc.ID = uuid.New().String()
+		doc.KnowledgeBaseID = imp.kb.ID
+		doc.KnowledgeBase = nil
+		doc.Chunks = nil
+		// 原始文件的存储路径在读取到文件条目后更新
+		if doc.SourceType == model.DocumentSourceTypeFile {
+			doc.SourceURI = ""
+		}
+		if err := b.store.Knowledge().CreateDocument(ctx, &doc); err != nil {
+			return fmt.Errorf("create document: %w", err)
+		}
+		imp.docs[oldID] = doc.ID
+		return nil
+	})
+}
+
+// importArchiveFile 保存原始文件并更新文档的存储路径，name 为 "<原文档 ID>/<文件名>".
+func (b *bizImpl) importArchiveFile(ctx context.Context, r io.Reader, name string, imp *archiveImport) error {
+	oldDocID, fileName, ok := strings.Cut(name, "/")
+	if !ok {
+		return fmt.Errorf("%w: unexpected file entry", ErrInvalidArchive)
+	}
+	docID, ok := imp.docs[oldDocID]
+	if !ok {
+		return fmt.Errorf("%w: file of unknown document %s", ErrInvalidArchive, oldDocID)
+	}
+	doc, err := b.store.Knowledge().GetDocument(ctx, docID)
+	if err != nil {
+		return fmt.Errorf("get document: %w", err)
+	}
+
+	key := path.Join(imp.kb.ID, docID, path.Base(fileName))
+	if err := b.files.Put(ctx, key, r, -1, mime.TypeByExtension(path.Ext(key))); err != nil {
+		return fmt.Errorf("put %s: %w", key, err)
+	}
+	imp.files = append(imp.files, key)
+
+	doc.SourceURI = key
+	if err := b.store.Knowledge().UpdateDocument(ctx, doc); err != nil {
+		return fmt.Errorf("update document: %w", err)
+	}
+	return nil
+}
+
+func (b *bizImpl) importArchiveChunks(ctx context.Context, r io.Reader, imp *archiveImport) error {
+	var (
+		chunks     []*model.KnowledgeChunk
+		embeddings []*model.Embedding
+		chunkTags  []*model.ChunkTag
+	)
+	err := decodeArchiveLines(r, func(dec *json.Decoder) error {
+		var line archiveChunk
+		if err := dec.Decode(&line); err != nil {
+			return err
+		}
+		if line.Chunk == nil {
+			return fmt.Errorf("%w: chunk line without chunk", ErrInvalidArchive)
+		}
+		c := line.Chunk
+		docID, ok := imp.docs[c.DocumentID]
+		if !ok {
+			return fmt.Errorf("%w: chunk of unknown document %s", ErrInvalidArchive, c.DocumentID)
+		}
+		c.ID = uuid.New().String()
+		c.KnowledgeBaseID = imp.kb.ID
+		c.DocumentID = docID
+		c.KnowledgeBase = nil
+		c.Document = nil
+		chunks = append(chunks, c)
+
+		if e := line.Embedding; e != nil && len(e.Vector) > 0 {
+			if e.Dim != len(e.Vector) {
+				return fmt.Errorf("%w: embedding dimension %d does not match vector length %d", ErrInvalidArchive, e.Dim, len(e.Vector))
+			}
+			embeddings = append(embeddings, &model.Embedding{
+				KnowledgeBaseID: imp.kb.ID,
+				ChunkID:         c.ID,
+				Embedding:       e.Vector,
+				EmbeddingDim:    e.Dim,
+				EmbeddingModel:  e.Model,
+				Metadata:        e.Metadata,
+			})
+		}
+		for _, tagID := range line.TagIDs {
+			if newID, ok := imp.tags[tagID]; ok {
+				chunkTags = append(chunkTags, &model.ChunkTag{ChunkID: c.ID, TagID: newID})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := b.store.Knowledge().CreateChunks(ctx, chunks); err != nil {
+		return fmt.Errorf("create chunks: %w", err)
+	}
+	if err := b.store.Knowledge().CreateEmbeddings(ctx, embeddings); err != nil {
+		return fmt.Errorf("create embeddings: %w", err)
+	}
+	if err := b.store.Knowledge().CreateChunkTags(ctx, chunkTags); err != nil {
+		return fmt.Errorf("create chunk tags: %w", err)
+	}
+	return nil
+}
+
+// decodeArchiveLines 逐行解码 JSON Lines 条目.
+func decodeArchiveLines(r io.Reader, decode func(dec *json.Decoder) error) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for dec.More() {
+		if err := decode(dec); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
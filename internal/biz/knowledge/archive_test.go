@@ -0,0 +1,79 @@
+package knowledge
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestExportFile(t *testing.T) {
+	files := newFakeBlobStore()
+	files.objects["kb1/d1/a.txt"] = "hello archive"
+	errUnavailable := errors.New("storage unavailable")
+	files.errs["kb1/d3/c.txt"] = errUnavailable
+	b := NewBiz(newFakeStore(), nil, nil, files, nil).(*bizImpl)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := b.exportFile(ctx, tw, &model.KnowledgeDocument{ID: "d1", SourceURI: "kb1/d1/a.txt"}); err != nil {
+		t.Fatalf("exportFile() error = %v", err)
+	}
+	// 原始文件丢失时跳过
+	if err := b.exportFile(ctx, tw, &model.KnowledgeDocument{ID: "d2", SourceURI: "kb1/d2/b.txt"}); err != nil {
+		t.Fatalf("exportFile() for missing file error = %v", err)
+	}
+	// 其它读取错误中止导出
+	if err := b.exportFile(ctx, tw, &model.KnowledgeDocument{ID: "d3", SourceURI: "kb1/d3/c.txt"}); !errors.Is(err, errUnavailable) {
+		t.Fatalf("exportFile() error = %v, want storage error", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+
+	tr := tar.NewReader(&buf)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatalf("read entry: %v", err)
+	}
+	if hdr.Name != archiveFilesDir+"d1/a.txt" || hdr.Size != int64(len("hello archive")) {
+		t.Errorf("entry = %s (%d bytes)", hdr.Name, hdr.Size)
+	}
+	data, _ := io.ReadAll(tr)
+	if string(data) != "hello archive" {
+		t.Errorf("entry content = %q", data)
+	}
+	if hdr, err := tr.Next(); err != io.EOF {
+		t.Errorf("unexpected entry %v (err %v), missing files must be skipped", hdr, err)
+	}
+}
+
+func TestExportFileLarge(t *testing.T) {
+	files := newFakeBlobStore()
+	content := strings.Repeat("0123456789", 100_000)
+	files.objects["kb1/d1/big.bin"] = content
+	b := NewBiz(newFakeStore(), nil, nil, files, nil).(*bizImpl)
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := b.exportFile(context.Background(), tw, &model.KnowledgeDocument{ID: "d1", SourceURI: "kb1/d1/big.bin"}); err != nil {
+		t.Fatalf("exportFile() error = %v", err)
+	}
+	tw.Close()
+
+	tr := tar.NewReader(&buf)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatalf("read entry: %v", err)
+	}
+	data, _ := io.ReadAll(tr)
+	if hdr.Size != int64(len(content)) || string(data) != content {
+		t.Errorf("entry size = %d, content length = %d, want %d", hdr.Size, len(data), len(content))
+	}
+}
@@ -0,0 +1,42 @@
+package knowledge
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// 文档分块数超过上限时的处理方式.
+const (
+	// ChunkLimitReject 导入失败（默认）
+	ChunkLimitReject = "reject"
+	// ChunkLimitTruncate 只保留前 MaxChunksPerDocument 个分块，并在文档元数据中记录截断
+	ChunkLimitTruncate = "truncate"
+)
+
+// ErrTooManyChunks 文档分块数超过上限.
+var ErrTooManyChunks = errs.New(errs.ErrValidation, "document exceeds the maximum number of chunks")
+
+// applyChunkLimit 在生成向量前检查文档分块数，超过上限时按策略拒绝导入或截断.
+// 截断时在文档元数据中记录原始分块数和上限.
+func (b *bizImpl) applyChunkLimit(doc *model.KnowledgeDocument, chunks []*schema.Document) ([]*schema.Document, error) {
+	if b.maxChunksPerDocument <= 0 || len(chunks) <= b.maxChunksPerDocument {
+		return chunks, nil
+	}
+	if b.chunkLimitPolicy != ChunkLimitTruncate {
+		return nil, fmt.Errorf("%w: %d chunks, limit is %d", ErrTooManyChunks, len(chunks), b.maxChunksPerDocument)
+	}
+
+	log.Printf("document %s has %d chunks, truncated to %d", doc.ID, len(chunks), b.maxChunksPerDocument)
+	if doc.Metadata == nil {
+		doc.Metadata = model.JSONMap{}
+	}
+	doc.Metadata["chunks_truncated"] = true
+	doc.Metadata["original_chunk_count"] = len(chunks)
+	doc.Metadata["max_chunks_per_document"] = b.maxChunksPerDocument
+	return chunks[:b.maxChunksPerDocument], nil
+}
@@ -0,0 +1,89 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// paragraphsRequest 返回按段落分为 n 个分块的文本导入请求.
+func paragraphsRequest(n int) *ImportRequest {
+	paragraphs := make([]string, n)
+	for i := range paragraphs {
+		paragraphs[i] = fmt.Sprintf("Paragraph number %d of the handbook.", i)
+	}
+	return &ImportRequest{
+		KnowledgeBaseID: "kb1",
+		Title:           "员工手册",
+		SourceType:      "text",
+		Content:         strings.Join(paragraphs, "\n\n"),
+		ChunkSize:       40,
+		ChunkOverlap:    1,
+		Separators:      []string{"\n\n"},
+	}
+}
+
+func TestMaxChunksPerDocument(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("within limit", func(t *testing.T) {
+		s := newFakeStore()
+		s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+		b := NewBiz(s, &fakeEmbedder{}, nil, nil, &BizConfig{MaxChunksPerDocument: 5}).(*bizImpl)
+		result, err := b.ImportDocument(ctx, paragraphsRequest(5))
+		if err != nil || result.ChunkCount != 5 {
+			t.Fatalf("ImportDocument = %+v, %v, want 5 chunks", result, err)
+		}
+		if _, ok := s.knowledge.docs[result.DocumentID].Metadata["chunks_truncated"]; ok {
+			t.Error("document within the limit marked as truncated")
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		s := newFakeStore()
+		s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+		embedder := &fakeEmbedder{}
+		b := NewBiz(s, embedder, nil, nil, &BizConfig{MaxChunksPerDocument: 5}).(*bizImpl)
+		_, err := b.ImportDocument(ctx, paragraphsRequest(8))
+		if !errors.Is(err, ErrTooManyChunks) {
+			t.Fatalf("ImportDocument error = %v, want ErrTooManyChunks", err)
+		}
+		if len(embedder.calls) != 0 || len(s.knowledge.chunks) != 0 {
+			t.Errorf("rejected document embedded %d batches and stored %d chunks, want none", len(embedder.calls), len(s.knowledge.chunks))
+		}
+		if len(s.knowledge.docs) != 1 {
+			t.Fatalf("stored %d documents, want the failed one", len(s.knowledge.docs))
+		}
+		for _, doc := range s.knowledge.docs {
+			if doc.ParseStatus != model.DocumentParseStatusFailed || !strings.Contains(doc.ErrorMessage, "limit is 5") {
+				t.Errorf("rejected document status = %s (%q), want failed with the limit", doc.ParseStatus, doc.ErrorMessage)
+			}
+		}
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		s := newFakeStore()
+		s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+		embedder := &fakeEmbedder{}
+		b := NewBiz(s, embedder, nil, nil, &BizConfig{MaxChunksPerDocument: 5, ChunkLimitPolicy: ChunkLimitTruncate}).(*bizImpl)
+		result, err := b.ImportDocument(ctx, paragraphsRequest(8))
+		if err != nil {
+			t.Fatalf("ImportDocument: %v", err)
+		}
+		chunks := s.knowledge.documentChunks(result.DocumentID)
+		if result.ChunkCount != 5 || len(chunks) != 5 || len(s.knowledge.embeddings) != 5 {
+			t.Fatalf("truncated import = %d chunks (%d stored, %d embedded), want 5", result.ChunkCount, len(chunks), len(s.knowledge.embeddings))
+		}
+		if !strings.Contains(chunks[4].Content, "number 4") {
+			t.Errorf("last chunk = %q, want the first 5 paragraphs kept", chunks[4].Content)
+		}
+		meta := s.knowledge.docs[result.DocumentID].Metadata
+		if meta["chunks_truncated"] != true || meta["original_chunk_count"] != 8 || meta["max_chunks_per_document"] != 5 {
+			t.Errorf("document metadata = %v, want truncation recorded", meta)
+		}
+	})
+}
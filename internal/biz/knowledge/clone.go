@@ -0,0 +1,255 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// cloneBatchSize 复制知识库时每个事务处理的文档数.
+const cloneBatchSize = 50
+
+// ErrCloneJobNotFound 复制任务不存在.
+var ErrCloneJobNotFound = errs.New(errs.ErrNotFound, "clone job not found")
+
+// CloneStatus 复制任务状态.
+type CloneStatus string
+
+const (
+	CloneStatusRunning   CloneStatus = "running"
+	CloneStatusCompleted CloneStatus = "completed"
+	CloneStatusFailed    CloneStatus = "failed"
+)
+
+// CloneRequest 复制知识库请求.
+type CloneRequest struct {
+	// Name 新知识库名称，为空时使用 "<原名称> (copy)"
+	Name string `json:"name"`
+	// CopyFiles 是否复制原始文件；不复制时新文档与源文档引用同一个原始文件
+	CopyFiles bool `json:"copy_files"`
+}
+
+// CloneJob 复制知识库任务.
+type CloneJob struct {
+	ID                    string      `json:"id"`
+	SourceKnowledgeBaseID string      `json:"source_knowledge_base_id"`
+	KnowledgeBaseID       string      `json:"knowledge_base_id"`
+	CopyFiles             bool        `json:"copy_files"`
+	Status                CloneStatus `json:"status"`
+	TotalDocuments        int64       `json:"total_documents"`
+	CopiedDocuments       int64       `json:"copied_documents"`
+	Error                 string      `json:"error,omitempty"`
+	StartedAt             time.Time   `json:"started_at"`
+	FinishedAt            *time.Time  `json:"finished_at,omitempty"`
+}
+
+// cloneJobs 内存中的复制任务记录.
+type cloneJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*CloneJob
+}
+
+func newCloneJobs() *cloneJobs {
+	return &cloneJobs{jobs: make(map[string]*CloneJob)}
+}
+
+func (r *cloneJobs) add(job *CloneJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = job
+}
+
+// update 在锁内修改任务.
+func (r *cloneJobs) update(id string, fn func(job *CloneJob)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// get 返回任务快照.
+func (r *cloneJobs) get(id string) (*CloneJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *job
+	return &snapshot, true
+}
+
+// CloneKnowledgeBase 复制知识库：立即创建新知识库并返回任务，文档、分块、向量和标签在后台按批复制.
+// 复制期间新知识库状态为 inactive，完成后变为 active. 新知识库与源知识库归属同一租户.
+func (b *bizImpl) CloneKnowledgeBase(ctx context.Context, id string, req *CloneRequest, viewer *Viewer) (*CloneJob, error) {
+	src, err := b.store.Knowledge().GetKnowledgeBase(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	if !canAccess(src, viewer) {
+		return nil, ErrForbidden
+	}
+
+	total, err := b.store.Knowledge().CountDocuments(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("count documents: %w", err)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = src.Name + " (copy)"
+	}
+	dst := &model.KnowledgeBase{
+		Name:            name,
+		Description:     src.Description,
+		TenantID:        src.TenantID,
+		ChunkingConfig:  src.ChunkingConfig,
+		ParserConfig:    src.ParserConfig,
+		IndexerType:     src.IndexerType,
+		IndexerConfig:   src.IndexerConfig,
+		EmbeddingConfig: src.EmbeddingConfig,
+		// 复制的向量使用同一距离函数检索
+		DistanceFunction: src.DistanceFunction,
+		TextSearchConfig: src.TextSearchConfig,
+		Status:           model.KnowledgeBaseStatusInactive,
+		Metadata:         src.Metadata,
+	}
+	// 外部源同步配置不复制，避免两个知识库同时接收同一个源的变更
+	if err := b.store.Knowledge().CreateKnowledgeBase(ctx, dst); err != nil {
+		return nil, fmt.Errorf("create knowledge base: %w", err)
+	}
+
+	job := &CloneJob{
+		ID:                    uuid.New().String(),
+		SourceKnowledgeBaseID: src.ID,
+		KnowledgeBaseID:       dst.ID,
+		CopyFiles:             req.CopyFiles,
+		Status:                CloneStatusRunning,
+		TotalDocuments:        total,
+		StartedAt:             time.Now(),
+	}
+	b.cloneJobs.add(job)
+	snapshot := *job
+
+	go b.runClone(context.Background(), job.ID, src.ID, dst.ID, req.CopyFiles)
+
+	return &snapshot, nil
+}
+
+// GetCloneJob 获取复制任务进度.
+func (b *bizImpl) GetCloneJob(_ context.Context, jobID string) (*CloneJob, error) {
+	job, ok := b.cloneJobs.get(jobID)
+	if !ok {
+		return nil, ErrCloneJobNotFound
+	}
+	return job, nil
+}
+
+// runClone 执行复制任务并记录结果.
+func (b *bizImpl) runClone(ctx context.Context, jobID, srcID, dstID string, copyFiles bool) {
+	err := b.clone(ctx, jobID, srcID, dstID, copyFiles)
+
+	now := time.Now()
+	b.cloneJobs.update(jobID, func(job *CloneJob) {
+		job.FinishedAt = &now
+		if err != nil {
+			job.Status = CloneStatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = CloneStatusCompleted
+	})
+	if err != nil {
+		log.Printf("clone knowledge base %s to %s failed: %v", srcID, dstID, err)
+	}
+}
+
+func (b *bizImpl) clone(ctx context.Context, jobID, srcID, dstID string, copyFiles bool) error {
+	tagIDs, err := b.store.Knowledge().CloneTags(ctx, srcID, dstID)
+	if err != nil {
+		return fmt.Errorf("clone tags: %w", err)
+	}
+
+	afterID := ""
+	for {
+		copies, err := b.store.Knowledge().CloneDocuments(ctx, srcID, dstID, afterID, cloneBatchSize, tagIDs)
+		if err != nil {
+			return fmt.Errorf("clone documents: %w", err)
+		}
+		if len(copies) == 0 {
+			break
+		}
+		if copyFiles {
+			for _, c := range copies {
+				if err := b.copyOriginalFile(ctx, dstID, c); err != nil {
+					return err
+				}
+			}
+		}
+
+		afterID = copies[len(copies)-1].SourceID
+		b.cloneJobs.update(jobID, func(job *CloneJob) {
+			job.CopiedDocuments += int64(len(copies))
+		})
+	}
+
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, dstID)
+	if err != nil {
+		return fmt.Errorf("get knowledge base: %w", err)
+	}
+	kb.Status = model.KnowledgeBaseStatusActive
+	if err := b.store.Knowledge().UpdateKnowledgeBase(ctx, kb); err != nil {
+		return fmt.Errorf("activate knowledge base: %w", err)
+	}
+	return nil
+}
+
+// copyOriginalFile 将复制出的文件类文档的原始文件复制到新知识库的存储路径下.
+// 源文件已丢失时保留原引用.
+func (b *bizImpl) copyOriginalFile(ctx context.Context, kbID string, c *store.DocumentCopy) error {
+	if c.SourceType != model.DocumentSourceTypeFile || c.SourceURI == "" {
+		return nil
+	}
+
+	r, err := b.files.Get(ctx, c.SourceURI)
+	if errors.Is(err, blob.ErrNotFound) {
+		log.Printf("clone document %s: original file %s not found, keeping reference", c.SourceID, c.SourceURI)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get file %s: %w", c.SourceURI, err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return fmt.Errorf("read file %s: %w", c.SourceURI, err)
+	}
+
+	key, err := b.saveFile(ctx, kbID, c.ID, path.Base(c.SourceURI), data)
+	if err != nil {
+		return err
+	}
+
+	doc, err := b.store.Knowledge().GetDocument(ctx, c.ID)
+	if err != nil {
+		return fmt.Errorf("get document: %w", err)
+	}
+	doc.SourceURI = key
+	if err := b.store.Knowledge().UpdateDocument(ctx, doc); err != nil {
+		return fmt.Errorf("update document: %w", err)
+	}
+	return nil
+}
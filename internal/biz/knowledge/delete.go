@@ -0,0 +1,45 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// deleteBatchSize 批量删除文档时每个事务处理的文档数.
+const deleteBatchSize = 100
+
+// ErrEmptyDocumentFilter 批量删除未指定任何过滤条件.
+var ErrEmptyDocumentFilter = errs.New(errs.ErrValidation, "document filter requires source_type, parse_status or ids")
+
+// DocumentFilter 批量删除文档的过滤条件，至少指定一项，多项同时满足.
+type DocumentFilter struct {
+	SourceType  string   `json:"source_type,omitempty"`
+	ParseStatus string   `json:"parse_status,omitempty"`
+	IDs         []string `json:"ids,omitempty"`
+}
+
+// DeleteDocuments 删除知识库中符合条件的文档（如所有解析失败的文档），返回删除的文档数.
+func (b *bizImpl) DeleteDocuments(ctx context.Context, kbID string, filter *DocumentFilter) (int64, error) {
+	if filter.SourceType == "" && filter.ParseStatus == "" && len(filter.IDs) == 0 {
+		return 0, ErrEmptyDocumentFilter
+	}
+	if _, err := b.store.Knowledge().GetKnowledgeBase(ctx, kbID); err != nil {
+		return 0, fmt.Errorf("get knowledge base: %w", err)
+	}
+
+	deleted, err := b.store.Knowledge().DeleteDocuments(ctx, kbID, &store.DocumentFilter{
+		SourceType:  filter.SourceType,
+		ParseStatus: filter.ParseStatus,
+		IDs:         filter.IDs,
+	}, deleteBatchSize)
+	if err != nil {
+		return deleted, fmt.Errorf("delete documents: %w", err)
+	}
+	if deleted > 0 {
+		b.publishDeletedDocuments(kbID, filter, deleted)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,76 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestDeleteFailedDocuments(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	s.knowledge.kbs["kb2"] = &model.KnowledgeBase{ID: "kb2"}
+	for _, doc := range []*model.KnowledgeDocument{
+		{ID: "failed1", KnowledgeBaseID: "kb1", ParseStatus: model.DocumentParseStatusFailed},
+		{ID: "failed2", KnowledgeBaseID: "kb1", ParseStatus: model.DocumentParseStatusFailed},
+		{ID: "parsed", KnowledgeBaseID: "kb1", ParseStatus: model.DocumentParseStatusParsed},
+		{ID: "other-kb", KnowledgeBaseID: "kb2", ParseStatus: model.DocumentParseStatusFailed},
+	} {
+		s.knowledge.docs[doc.ID] = doc
+		chunkID := doc.ID + "-c0"
+		s.knowledge.chunks[chunkID] = &model.KnowledgeChunk{ID: chunkID, KnowledgeBaseID: doc.KnowledgeBaseID, DocumentID: doc.ID}
+		s.knowledge.embeddings[chunkID] = &model.Embedding{ChunkID: chunkID}
+		s.knowledge.chunkTags[chunkID] = []string{"tag1"}
+	}
+	b := NewBiz(s, nil, nil, nil, nil)
+
+	deleted, err := b.DeleteDocuments(context.Background(), "kb1", &DocumentFilter{ParseStatus: string(model.DocumentParseStatusFailed)})
+	if err != nil {
+		t.Fatalf("DeleteDocuments() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted = %d, want 2", deleted)
+	}
+	for _, id := range []string{"failed1", "failed2"} {
+		if _, ok := s.knowledge.docs[id]; ok {
+			t.Errorf("document %s not deleted", id)
+		}
+		chunkID := id + "-c0"
+		if _, ok := s.knowledge.chunks[chunkID]; ok {
+			t.Errorf("chunk %s of deleted document kept", chunkID)
+		}
+		if _, ok := s.knowledge.embeddings[chunkID]; ok {
+			t.Errorf("embedding of %s kept", chunkID)
+		}
+		if _, ok := s.knowledge.chunkTags[chunkID]; ok {
+			t.Errorf("tags of %s kept", chunkID)
+		}
+	}
+	// 解析成功的文档和其他知识库的失败文档不受影响
+	for _, id := range []string{"parsed", "other-kb"} {
+		if _, ok := s.knowledge.docs[id]; !ok {
+			t.Errorf("document %s deleted, want kept", id)
+		}
+		if _, ok := s.knowledge.embeddings[id+"-c0"]; !ok {
+			t.Errorf("embedding of %s deleted, want kept", id)
+		}
+	}
+}
+
+func TestDeleteDocumentsRequiresFilter(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	s.knowledge.docs["d1"] = &model.KnowledgeDocument{ID: "d1", KnowledgeBaseID: "kb1"}
+	b := NewBiz(s, nil, nil, nil, nil)
+
+	_, err := b.DeleteDocuments(context.Background(), "kb1", &DocumentFilter{})
+	if !errors.Is(err, ErrEmptyDocumentFilter) || !errors.Is(err, errs.ErrValidation) {
+		t.Errorf("DeleteDocuments() error = %v, want ErrEmptyDocumentFilter", err)
+	}
+	if _, ok := s.knowledge.docs["d1"]; !ok {
+		t.Errorf("document deleted with empty filter")
+	}
+}
@@ -0,0 +1,103 @@
+// Package knowledge 提供知识库业务逻辑.
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+)
+
+// DownloadURLExpiry 预签名下载地址的有效期.
+const DownloadURLExpiry = 15 * time.Minute
+
+var (
+	// ErrDocumentNotFound 文档不存在.
+	ErrDocumentNotFound = errs.New(errs.ErrNotFound, "document not found")
+	// ErrForbidden 无权访问该知识库.
+	ErrForbidden = errs.New(errs.ErrForbidden, "access to knowledge base denied")
+	// ErrNoOriginalFile 文档没有保存原始文件（如 URL、文本导入）或原始文件已丢失.
+	ErrNoOriginalFile = errs.New(errs.ErrNotFound, "document has no original file")
+)
+
+// Viewer 访问者身份，用于知识库访问控制.
+type Viewer struct {
+	TenantID string
+	Admin    bool
+}
+
+// DocumentDownload 原始文件下载信息.
+// 存储支持预签名时返回 URL，否则返回文件内容 Body（使用完毕后需关闭）.
+type DocumentDownload struct {
+	FileName    string
+	ContentType string
+	URL         string
+	ExpiresAt   time.Time
+	Body        io.ReadCloser
+}
+
+// DownloadDocument 获取文档的原始文件.
+// 归属租户的知识库只允许同租户用户或管理员访问，未归属租户的知识库只允许管理员访问，viewer 为 nil 表示匿名访问.
+func (b *bizImpl) DownloadDocument(ctx context.Context, docID string, viewer *Viewer) (*DocumentDownload, error) {
+	doc, err := b.store.Knowledge().GetDocument(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDocumentNotFound, err)
+	}
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, doc.KnowledgeBaseID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	if !canAccess(kb, viewer) {
+		return nil, ErrForbidden
+	}
+
+	if doc.SourceType != model.DocumentSourceTypeFile || doc.SourceURI == "" {
+		return nil, ErrNoOriginalFile
+	}
+
+	key := doc.SourceURI
+	fileName := path.Base(key)
+	contentType := mime.TypeByExtension(path.Ext(fileName))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	download := &DocumentDownload{FileName: fileName, ContentType: contentType}
+
+	if signer, ok := b.files.(blob.URLSigner); ok {
+		url, err := signer.SignedURL(ctx, key, DownloadURLExpiry, fileName, contentType)
+		if err != nil {
+			return nil, fmt.Errorf("sign download url: %w", err)
+		}
+		download.URL = url
+		download.ExpiresAt = time.Now().Add(DownloadURLExpiry)
+		return download, nil
+	}
+
+	body, err := b.files.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrNoOriginalFile, err)
+		}
+		return nil, fmt.Errorf("get file: %w", err)
+	}
+	download.Body = body
+	return download, nil
+}
+
+// canAccess 判断访问者能否访问知识库，未归属租户的知识库只有管理员可以访问.
+func canAccess(kb *model.KnowledgeBase, viewer *Viewer) bool {
+	if viewer == nil {
+		return false
+	}
+	if viewer.Admin {
+		return true
+	}
+	return kb.TenantID != "" && viewer.TenantID == kb.TenantID
+}
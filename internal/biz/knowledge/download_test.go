@@ -0,0 +1,34 @@
+package knowledge
+
+import (
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestCanAccess(t *testing.T) {
+	tenanted := &model.KnowledgeBase{TenantID: "t1"}
+	untenanted := &model.KnowledgeBase{}
+	tests := []struct {
+		name   string
+		kb     *model.KnowledgeBase
+		viewer *Viewer
+		want   bool
+	}{
+		{name: "same tenant", kb: tenanted, viewer: &Viewer{TenantID: "t1"}, want: true},
+		{name: "other tenant", kb: tenanted, viewer: &Viewer{TenantID: "t2"}},
+		{name: "admin of other tenant", kb: tenanted, viewer: &Viewer{TenantID: "t2", Admin: true}, want: true},
+		{name: "anonymous", kb: tenanted},
+		{name: "untenanted anonymous", kb: untenanted},
+		{name: "untenanted user", kb: untenanted, viewer: &Viewer{TenantID: "t1"}},
+		{name: "untenanted user without tenant", kb: untenanted, viewer: &Viewer{}},
+		{name: "untenanted admin", kb: untenanted, viewer: &Viewer{Admin: true}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canAccess(tt.kb, tt.viewer); got != tt.want {
+				t.Errorf("canAccess() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/embedding"
+)
+
+// 默认的分批生成向量参数.
+const (
+	defaultEmbeddingBatchSize      = 64
+	defaultEmbeddingMaxConcurrency = 4
+)
+
+// EmbeddingBatchFailure 一个生成失败的批次.
+type EmbeddingBatchFailure struct {
+	// Batch 批次序号，从 0 开始
+	Batch int
+	// Start、End 批次覆盖的分块序号范围 [Start, End)
+	Start int
+	End   int
+	Err   error
+}
+
+// EmbeddingBatchError 部分批次生成向量失败，其余批次的向量已经生成.
+type EmbeddingBatchError struct {
+	Failures []*EmbeddingBatchFailure
+}
+
+func (e *EmbeddingBatchError) Error() string {
+	parts := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		parts[i] = fmt.Sprintf("batch %d (chunks %d-%d): %v", f.Batch, f.Start, f.End-1, f.Err)
+	}
+	return "embedding batches failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap 返回各批次的错误.
+func (e *EmbeddingBatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// embedInBatches 按 embeddingBatchSize 分批、至多 embeddingMaxConcurrency 个批次并发生成向量.
+// 返回的向量与 texts 一一对应；部分批次失败时这些位置为 nil，并返回 *EmbeddingBatchError.
+// onBatch 不为空时在每个批次成功后以该批次的分块数调用，可能并发调用.
+func (b *bizImpl) embedInBatches(ctx context.Context, texts []string, onBatch func(n int)) ([][]float64, error) {
+	if b.embedder == nil {
+		return nil, fmt.Errorf("embedder not configured")
+	}
+	return embedBatches(ctx, b.embedder, texts, b.embeddingBatchSize, b.embeddingMaxConcurrency, onBatch)
+}
+
+// embedBatches 按 batchSize 分批、至多 concurrency 个批次并发调用 embedder，结果与 texts 一一对应.
+func embedBatches(ctx context.Context, embedder embedding.Embedder, texts []string, batchSize, concurrency int, onBatch func(n int), opts ...embedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	batches := (len(texts) + batchSize - 1) / batchSize
+
+	var (
+		mu       sync.Mutex
+		failures []*EmbeddingBatchFailure
+		wg       sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+	for batch := 0; batch < batches; batch++ {
+		start := batch * batchSize
+		end := min(start+batchSize, len(texts))
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := embedder.EmbedStrings(ctx, texts[start:end], opts...)
+			if err == nil && len(result) != end-start {
+				err = fmt.Errorf("expected %d vectors, got %d", end-start, len(result))
+			}
+			if err != nil {
+				mu.Lock()
+				failures = append(failures, &EmbeddingBatchFailure{Batch: batch, Start: start, End: end, Err: err})
+				mu.Unlock()
+				return
+			}
+			copy(vectors[start:end], result)
+			if onBatch != nil {
+				onBatch(end - start)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failures) == 0 {
+		return vectors, nil
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Batch < failures[j].Batch })
+	return vectors, &EmbeddingBatchError{Failures: failures}
+}
+
+// batchingEmbedder 将一次 EmbedStrings 调用拆成多个批次并发执行，结果顺序与输入一致.
+// 语义分块器一次性为文档的所有句子窗口生成向量，长文档用它包装后按批次并发请求.
+type batchingEmbedder struct {
+	embedder    embedding.Embedder
+	batchSize   int
+	concurrency int
+}
+
+// EmbedStrings 实现 embedding.Embedder，任一批次失败时返回错误.
+func (e *batchingEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	if len(texts) <= e.batchSize {
+		return e.embedder.EmbedStrings(ctx, texts, opts...)
+	}
+	return embedBatches(ctx, e.embedder, texts, e.batchSize, e.concurrency, nil, opts...)
+}
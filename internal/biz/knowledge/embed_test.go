@@ -0,0 +1,121 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// longSemanticContent 重复 semanticContent 的主题，得到有上百个句子的长文档.
+func longSemanticContent(repeat int) string {
+	var sb strings.Builder
+	for i := 0; i < repeat; i++ {
+		fmt.Fprintf(&sb, "Section %d. %s ", i+1, semanticContent)
+	}
+	return sb.String()
+}
+
+// slowEmbedder 每次调用等待 delay，并记录同时进行的最大调用数.
+type slowEmbedder struct {
+	fakeEmbedder
+	delay    time.Duration
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (e *slowEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	n := e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+	for {
+		peak := e.peak.Load()
+		if n <= peak || e.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(e.delay)
+	return e.fakeEmbedder.EmbedStrings(ctx, texts, opts...)
+}
+
+func TestSemanticSplitBatchesLongDocument(t *testing.T) {
+	ctx := context.Background()
+	content := longSemanticContent(20)
+	kb := &model.KnowledgeBase{ID: "kb1"}
+	opts := &splitOptions{splitterType: SplitterTypeSemantic}
+
+	// 不分批时一次请求所有句子窗口，作为对照
+	single := &fakeEmbedder{}
+	want, err := NewBiz(nil, single, nil, nil, &BizConfig{SplitEmbeddingBatchSize: 100000}).(*bizImpl).splitContent(ctx, kb, content, opts)
+	if err != nil {
+		t.Fatalf("splitContent(unbatched): %v", err)
+	}
+	if len(single.calls) != 1 || len(single.calls[0]) < 100 {
+		t.Fatalf("unbatched calls = %d, want one call with all windows", len(single.calls))
+	}
+	windows := len(single.calls[0])
+
+	batched := &slowEmbedder{delay: time.Millisecond}
+	got, err := NewBiz(nil, batched, nil, nil, &BizConfig{SplitEmbeddingBatchSize: 16, SplitEmbeddingMaxConcurrency: 3}).(*bizImpl).splitContent(ctx, kb, content, opts)
+	if err != nil {
+		t.Fatalf("splitContent(batched): %v", err)
+	}
+
+	// 每批不超过 16 个窗口，所有窗口都只请求一次
+	total := 0
+	for _, call := range batched.calls {
+		if len(call) > 16 {
+			t.Errorf("batch of %d windows, want at most 16", len(call))
+		}
+		total += len(call)
+	}
+	if total != windows || len(batched.calls) != (windows+15)/16 {
+		t.Errorf("batched %d windows in %d calls, want %d windows in %d calls", total, len(batched.calls), windows, (windows+15)/16)
+	}
+	if peak := batched.peak.Load(); peak < 2 || peak > 3 {
+		t.Errorf("peak concurrent calls = %d, want 2-3", peak)
+	}
+
+	// 分块边界与不分批时一致，说明每个窗口用的是自己的向量
+	if len(got) != len(want) {
+		t.Fatalf("batched split = %d chunks, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Content != want[i].Content {
+			t.Errorf("chunk %d = %q, want %q", i, got[i].Content, want[i].Content)
+		}
+	}
+}
+
+func BenchmarkSemanticSplitLongDocument(b *testing.B) {
+	ctx := context.Background()
+	content := longSemanticContent(50)
+	kb := &model.KnowledgeBase{ID: "kb1"}
+	opts := &splitOptions{splitterType: SplitterTypeSemantic}
+
+	for _, bc := range []struct {
+		name        string
+		batchSize   int
+		concurrency int
+	}{
+		{"sequential", 16, 1},
+		{"concurrent", 16, 4},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			biz := NewBiz(nil, &slowEmbedder{delay: time.Millisecond}, nil, nil, &BizConfig{
+				SplitEmbeddingBatchSize:      bc.batchSize,
+				SplitEmbeddingMaxConcurrency: bc.concurrency,
+			}).(*bizImpl)
+			for i := 0; i < b.N; i++ {
+				if _, err := biz.splitContent(ctx, kb, content, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package knowledge
+
+import (
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/events"
+)
+
+// publishDocument 发布文档变更事件，未配置事件总线时忽略.
+func (b *bizImpl) publishDocument(typ events.Type, doc *model.KnowledgeDocument, data map[string]any) {
+	b.events.Publish(&events.Event{
+		Type:            typ,
+		KnowledgeBaseID: doc.KnowledgeBaseID,
+		DocumentID:      doc.ID,
+		Data:            data,
+	})
+}
+
+// publishChunk 发布分块变更事件.
+func (b *bizImpl) publishChunk(typ events.Type, chunk *model.KnowledgeChunk) {
+	b.events.Publish(&events.Event{
+		Type:            typ,
+		KnowledgeBaseID: chunk.KnowledgeBaseID,
+		DocumentID:      chunk.DocumentID,
+		ChunkID:         chunk.ID,
+	})
+}
+
+// publishDeletedDocuments 发布批量删除事件：按 ID 删除时逐个发布，按条件删除时发布一个不带 DocumentID 的事件.
+func (b *bizImpl) publishDeletedDocuments(kbID string, filter *DocumentFilter, deleted int64) {
+	if filter.SourceType == "" && filter.ParseStatus == "" {
+		for _, id := range filter.IDs {
+			b.publishDocument(events.DocumentDeleted, &model.KnowledgeDocument{ID: id, KnowledgeBaseID: kbID}, nil)
+		}
+		return
+	}
+	b.events.Publish(&events.Event{
+		Type:            events.DocumentDeleted,
+		KnowledgeBaseID: kbID,
+		Data: map[string]any{
+			"source_type":  filter.SourceType,
+			"parse_status": filter.ParseStatus,
+			"ids":          filter.IDs,
+			"deleted":      deleted,
+		},
+	})
+}
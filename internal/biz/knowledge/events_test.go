@@ -0,0 +1,123 @@
+package knowledge
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/events"
+)
+
+// eventRecorder 记录收到的事件.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []*events.Event
+}
+
+func (r *eventRecorder) Handle(_ context.Context, e *events.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+// newEventBiz 返回发布事件到 recorder 的 bizImpl，测试结束时关闭总线.
+func newEventBiz(t *testing.T) (*bizImpl, *fakeStore, *events.Bus, *eventRecorder) {
+	t.Helper()
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	bus := events.NewBus(0)
+	recorder := &eventRecorder{}
+	bus.Subscribe(recorder)
+	b := NewBiz(s, &fakeEmbedder{}, nil, nil, &BizConfig{Events: bus}).(*bizImpl)
+	return b, s, bus, recorder
+}
+
+func TestEventsFireOnImportAndDelete(t *testing.T) {
+	ctx := context.Background()
+	b, s, bus, recorder := newEventBiz(t)
+
+	imported, err := b.ImportDocument(ctx, &ImportRequest{
+		KnowledgeBaseID: "kb1",
+		Title:           "退款政策",
+		SourceType:      "text",
+		Content:         strings.Repeat("Refunds are issued within seven days. ", 10),
+	})
+	if err != nil {
+		t.Fatalf("ImportDocument: %v", err)
+	}
+	chunk := s.knowledge.documentChunks(imported.DocumentID)[0]
+	chunk.Content = "Refunds are issued within ten days."
+	if err := b.UpdateChunk(ctx, chunk); err != nil {
+		t.Fatalf("UpdateChunk: %v", err)
+	}
+	if err := b.DeleteDocument(ctx, imported.DocumentID); err != nil {
+		t.Fatalf("DeleteDocument: %v", err)
+	}
+	// 删除失败时不发布事件
+	if err := b.DeleteDocument(ctx, imported.DocumentID); err == nil {
+		t.Fatal("second DeleteDocument succeeded, want not found")
+	}
+	bus.Close()
+
+	got := recorder.events
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want created, chunk updated and deleted", len(got))
+	}
+	want := []struct {
+		typ     events.Type
+		chunkID string
+	}{
+		{events.DocumentCreated, ""},
+		{events.ChunkUpdated, chunk.ID},
+		{events.DocumentDeleted, ""},
+	}
+	for i, w := range want {
+		e := got[i]
+		if e.Type != w.typ || e.KnowledgeBaseID != "kb1" || e.DocumentID != imported.DocumentID || e.ChunkID != w.chunkID {
+			t.Errorf("event %d = %s kb=%s doc=%s chunk=%s, want %s for the imported document", i, e.Type, e.KnowledgeBaseID, e.DocumentID, e.ChunkID, w.typ)
+		}
+		if e.ID == "" || e.Time.IsZero() {
+			t.Errorf("event %d missing ID or time", i)
+		}
+	}
+	if got[0].Data["title"] != "退款政策" || got[0].Data["chunk_count"] != imported.ChunkCount {
+		t.Errorf("created event data = %v", got[0].Data)
+	}
+}
+
+func TestEventsFireOnBulkDelete(t *testing.T) {
+	ctx := context.Background()
+	b, s, bus, recorder := newEventBiz(t)
+	for _, id := range []string{"d1", "d2", "d3"} {
+		s.knowledge.docs[id] = &model.KnowledgeDocument{ID: id, KnowledgeBaseID: "kb1", SourceType: "text", ParseStatus: model.DocumentParseStatusFailed}
+	}
+	s.knowledge.docs["d3"].ParseStatus = model.DocumentParseStatusParsed
+
+	// 按 ID 删除时逐个发布
+	if _, err := b.DeleteDocuments(ctx, "kb1", &DocumentFilter{IDs: []string{"d1"}}); err != nil {
+		t.Fatalf("DeleteDocuments(ids): %v", err)
+	}
+	// 按条件删除时发布一个带条件和数量的事件
+	if _, err := b.DeleteDocuments(ctx, "kb1", &DocumentFilter{ParseStatus: string(model.DocumentParseStatusFailed)}); err != nil {
+		t.Fatalf("DeleteDocuments(status): %v", err)
+	}
+	// 没有删除任何文档时不发布
+	if _, err := b.DeleteDocuments(ctx, "kb1", &DocumentFilter{ParseStatus: string(model.DocumentParseStatusFailed)}); err != nil {
+		t.Fatalf("DeleteDocuments(status): %v", err)
+	}
+	bus.Close()
+
+	got := recorder.events
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	if got[0].Type != events.DocumentDeleted || got[0].DocumentID != "d1" {
+		t.Errorf("id event = %s %s, want d1 deleted", got[0].Type, got[0].DocumentID)
+	}
+	if e := got[1]; e.Type != events.DocumentDeleted || e.DocumentID != "" || e.Data["deleted"] != int64(1) || e.Data["parse_status"] != string(model.DocumentParseStatusFailed) {
+		t.Errorf("filter event = %+v", e)
+	}
+}
@@ -0,0 +1,36 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSaveFileUsesStorageKey(t *testing.T) {
+	files := newFakeBlobStore()
+	b := NewBiz(newFakeStore(), nil, nil, files, nil).(*bizImpl)
+
+	tests := []struct {
+		name     string
+		fileName string
+		want     string
+	}{
+		{name: "plain name", fileName: "report.pdf", want: "kb1/d1/report.pdf"},
+		{name: "nested path", fileName: "uploads/2024/report.pdf", want: "kb1/d1/report.pdf"},
+		{name: "traversal", fileName: "../../etc/report.pdf", want: "kb1/d1/report.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := b.saveFile(context.Background(), "kb1", "d1", tt.fileName, []byte("content"))
+			if err != nil {
+				t.Fatalf("saveFile() error = %v", err)
+			}
+			// SourceURI 保存的是与存储无关的 key，而不是本地路径
+			if key != tt.want {
+				t.Fatalf("saveFile() key = %q, want %q", key, tt.want)
+			}
+			if got := files.objects[key]; got != "content" {
+				t.Fatalf("stored object = %q, want content", got)
+			}
+		})
+	}
+}
@@ -5,30 +5,38 @@ import (
 	"bytes"
 	"context"
 	"crypto/md5"
-	"encoding/csv"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"log"
+	"mime"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/cloudwego/eino-ext/components/document/loader/url"
-	"github.com/cloudwego/eino-ext/components/document/parser/docx"
-	"github.com/cloudwego/eino-ext/components/document/parser/pdf"
-	"github.com/cloudwego/eino-ext/components/document/parser/xlsx"
-	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/recursive"
-	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/semantic"
 	"github.com/cloudwego/eino/components/document"
-	"github.com/cloudwego/eino/components/document/parser"
 	"github.com/cloudwego/eino/schema"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+	"github.com/ashwinyue/next-show/internal/pkg/docparse"
+	"github.com/ashwinyue/next-show/internal/pkg/events"
+	"github.com/ashwinyue/next-show/internal/pkg/moderation"
 )
 
-// DataFilesBaseDir 数据文件存储基础目录.
-const DataFilesBaseDir = "data/files"
+// DataFilesBaseDir 本地存储原始文件的默认目录.
+const DataFilesBaseDir = blob.DefaultLocalDir
+
+// defaultImportStaleTimeout 导入中的文档无进展多久后视为中断.
+const defaultImportStaleTimeout = time.Hour
+
+// interruptedImportMessage 因服务重启中断的导入的错误信息.
+const interruptedImportMessage = "import interrupted by server restart"
 
 // SplitterType 分块器类型.
 type SplitterType string
@@ -42,42 +50,78 @@ const (
 type ImportRequest struct {
 	KnowledgeBaseID string    `json:"knowledge_base_id"`
 	Title           string    `json:"title"`
-	SourceType      string    `json:"source_type"` // "url", "text", "file"
+	SourceType      string    `json:"source_type"` // "url", "text", "file", "s3"
 	SourceURI       string    `json:"source_uri,omitempty"`
 	Content         string    `json:"content,omitempty"`
 	FileName        string    `json:"-"` // 文件名（用于判断文件类型）
 	FileReader      io.Reader `json:"-"` // 文件内容读取器
 
+	// Metadata 文档元数据（如 author、publish_date、department），可用于检索过滤
+	Metadata model.JSONMap `json:"metadata,omitempty"`
+	// PropagateMetadataKeys 需要复制到每个分块元数据中的文档元数据键，使按分块元数据过滤的检索也能使用这些字段
+	PropagateMetadataKeys []string `json:"propagate_metadata_keys,omitempty"`
+
 	// Splitter options
 	SplitterType SplitterType `json:"splitter_type,omitempty"` // 分块类型：recursive（默认）或 semantic
 	ChunkSize    int          `json:"chunk_size,omitempty"`    // 递归分块的块大小
+	ChunkUnit    ChunkUnit    `json:"chunk_unit,omitempty"`    // 块大小的单位：chars（默认）或 tokens
 	ChunkOverlap int          `json:"chunk_overlap,omitempty"` // 递归分块的重叠大小
 	Percentile   float64      `json:"percentile,omitempty"`    // 语义分块的百分位阈值（0-1，默认0.9）
+	// SemanticOverlap 语义分块的重叠句数：每个分块开头带上前一个分块末尾的若干句，默认不重叠
+	SemanticOverlap int `json:"semantic_overlap,omitempty"`
+	// SeparatorPreset 递归分块的分隔符预设（default / prose / code / zh / ja），为空时使用知识库的分块配置
+	SeparatorPreset SeparatorPreset `json:"separator_preset,omitempty"`
+	// Separators 自定义递归分块分隔符，按优先级排列，优先于 SeparatorPreset
+	Separators []string `json:"separators,omitempty"`
+
+	// Force 知识库中已有相同文件时仍重新导入，默认直接返回已有文档
+	Force bool `json:"force,omitempty"`
+	// Async 读取文件并创建文档记录后立即返回，解析、分块和生成向量在后台执行
+	Async bool `json:"-"`
+}
+
+func (req *ImportRequest) splitOptions() *splitOptions {
+	return &splitOptions{
+		splitterType:    req.SplitterType,
+		chunkSize:       req.ChunkSize,
+		chunkOverlap:    req.ChunkOverlap,
+		chunkUnit:       req.ChunkUnit,
+		percentile:      req.Percentile,
+		semanticOverlap: req.SemanticOverlap,
+		separatorPreset: req.SeparatorPreset,
+		separators:      req.Separators,
+	}
 }
 
 // ImportResult 文档导入结果.
 type ImportResult struct {
 	DocumentID string `json:"document_id"`
 	ChunkCount int    `json:"chunk_count"`
+	// Deduplicated 知识库中已有相同文件，未重新导入，DocumentID 为已有文档
+	Deduplicated bool `json:"deduplicated,omitempty"`
+	// JobID 异步导入的任务 ID，进度通过 GetDocumentStatus 查询
+	JobID       string                    `json:"job_id,omitempty"`
+	ParseStatus model.DocumentParseStatus `json:"parse_status,omitempty"`
 }
 
 // ImportDocument 导入文档到知识库.
+// 读取并保存原始文件、创建文档记录后，解析、分块和生成向量；req.Async 时这些步骤在后台执行，立即返回 JobID.
 func (b *bizImpl) ImportDocument(ctx context.Context, req *ImportRequest) (*ImportResult, error) {
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, req.KnowledgeBaseID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+
 	docID := uuid.New().String()
 	var fileData []byte
 	var fileHash string
 	var sourceURI string
 
-	// 1. 获取文档内容
-	var docs []*schema.Document
-	var err error
-
+	// 1. 读取文件内容，解析在后续阶段进行
 	switch req.SourceType {
 	case "url":
-		docs, err = b.loadFromURL(ctx, req.SourceURI)
 		sourceURI = req.SourceURI
 	case "text":
-		docs = []*schema.Document{{Content: req.Content}}
 	case "file":
 		// 先读取文件内容到内存
 		fileData, err = io.ReadAll(req.FileReader)
@@ -85,35 +129,40 @@ func (b *bizImpl) ImportDocument(ctx context.Context, req *ImportRequest) (*Impo
 			return nil, fmt.Errorf("read file: %w", err)
 		}
 		fileHash = md5HashBytes(fileData)
+		if existing, err := b.duplicateDocument(ctx, req, fileHash); existing != nil || err != nil {
+			return existing, err
+		}
 
-		// 保存原始文件到本地
-		sourceURI, err = b.saveFileToLocal(req.KnowledgeBaseID, docID, req.FileName, fileData)
+		// 保存原始文件，SourceURI 记录存储无关的 key
+		sourceURI, err = b.saveFile(ctx, req.KnowledgeBaseID, docID, req.FileName, fileData)
 		if err != nil {
 			return nil, fmt.Errorf("save file: %w", err)
 		}
-
-		// 解析文件内容
-		docs, err = b.parseFile(ctx, req.FileName, bytes.NewReader(fileData))
+	case string(model.DocumentSourceTypeS3):
+		// 外部源文档由连接器获取，SourceURI 指向源地址，不另存原始文件
+		fileData, err = io.ReadAll(req.FileReader)
+		if err != nil {
+			return nil, fmt.Errorf("read file: %w", err)
+		}
+		fileHash = md5HashBytes(fileData)
+		if existing, err := b.duplicateDocument(ctx, req, fileHash); existing != nil || err != nil {
+			return existing, err
+		}
+		sourceURI = req.SourceURI
 	default:
 		return nil, fmt.Errorf("unsupported source type: %s", req.SourceType)
 	}
-	if err != nil {
-		return nil, fmt.Errorf("load document: %w", err)
-	}
-
-	if len(docs) == 0 {
-		return nil, fmt.Errorf("no content loaded")
-	}
 
-	// 2. 合并所有文档内容
-	var contentBuilder strings.Builder
-	for _, doc := range docs {
-		contentBuilder.WriteString(doc.Content)
-		contentBuilder.WriteString("\n")
+	// 记录递归分块使用的长度单位
+	metadata := req.Metadata
+	if req.SplitterType != SplitterTypeSemantic {
+		if metadata == nil {
+			metadata = model.JSONMap{}
+		}
+		metadata["chunk_unit"] = string(chunkUnitOrDefault(req.ChunkUnit))
 	}
-	fullContent := contentBuilder.String()
 
-	// 3. 创建文档记录
+	// 2. 创建文档记录
 	docModel := &model.KnowledgeDocument{
 		ID:              docID,
 		KnowledgeBaseID: req.KnowledgeBaseID,
@@ -121,34 +170,133 @@ func (b *bizImpl) ImportDocument(ctx context.Context, req *ImportRequest) (*Impo
 		SourceType:      model.DocumentSourceType(req.SourceType),
 		SourceURI:       sourceURI,
 		FileHash:        fileHash,
+		Metadata:        metadata,
 		ParseStatus:     model.DocumentParseStatusPending,
 	}
 	if err := b.store.Knowledge().CreateDocument(ctx, docModel); err != nil {
 		return nil, fmt.Errorf("create document: %w", err)
 	}
 
-	// 4. 分块
-	var chunks []*schema.Document
-	switch req.SplitterType {
-	case SplitterTypeSemantic:
-		// 语义分块
-		percentile := req.Percentile
-		if percentile <= 0 || percentile > 1 {
-			percentile = 0.9
+	job := b.importJobs.start(req.KnowledgeBaseID, docID)
+	if !req.Async {
+		return b.processImport(ctx, kb, req, docModel, fileData)
+	}
+
+	go func() {
+		if _, err := b.processImport(context.Background(), kb, req, docModel, fileData); err != nil {
+			log.Printf("import document %s failed: %v", docID, err)
 		}
-		chunks, err = b.splitDocumentSemantic(ctx, fullContent, percentile)
+	}()
+	return &ImportResult{
+		DocumentID:  docID,
+		JobID:       job.ID,
+		ParseStatus: model.DocumentParseStatusPending,
+	}, nil
+}
+
+// RecoverInterruptedImports 将服务重启前未完成的导入（pending / parsing / embedding）标记为失败，
+// 失败的文档可以重新导入. 异步导入只在进程内执行，应在服务启动时、开始接收请求前调用.
+func (b *bizImpl) RecoverInterruptedImports(ctx context.Context) (int64, error) {
+	n, err := b.store.Knowledge().FailStaleImports(ctx, time.Now(), interruptedImportMessage)
+	if err != nil {
+		return 0, fmt.Errorf("fail interrupted imports: %w", err)
+	}
+	return n, nil
+}
+
+// processImport 解析、分块并生成向量，文档状态依次推进为 parsing → embedding → parsed，出错时标记为 failed.
+// 知识库或租户的导入数达到上限时，文档保持 pending 排队等待.
+func (b *bizImpl) processImport(ctx context.Context, kb *model.KnowledgeBase, req *ImportRequest, doc *model.KnowledgeDocument, fileData []byte) (*ImportResult, error) {
+	var result *ImportResult
+	release, err := b.importLimiter.acquire(ctx, kb.ID, kb.TenantID)
+	if err == nil {
+		result, err = b.importContent(ctx, kb, req, doc, fileData)
+		release()
+	}
+	b.importJobs.finish(doc.ID, err)
+	if err == nil {
+		b.publishDocument(events.DocumentCreated, doc, map[string]any{
+			"title":       doc.Title,
+			"source_type": doc.SourceType,
+			"chunk_count": result.ChunkCount,
+		})
+		return result, nil
+	}
+
+	doc.ParseStatus = model.DocumentParseStatusFailed
+	doc.ErrorMessage = err.Error()
+	if updateErr := b.store.Knowledge().UpdateDocument(ctx, doc); updateErr != nil {
+		return nil, fmt.Errorf("%w (update document status: %v)", err, updateErr)
+	}
+	return nil, err
+}
+
+// setParseStatus 更新文档所处的导入阶段.
+func (b *bizImpl) setParseStatus(ctx context.Context, doc *model.KnowledgeDocument, status model.DocumentParseStatus) error {
+	doc.ParseStatus = status
+	b.importJobs.update(doc.ID, func(job *ImportJob) {
+		job.Stage = status
+	})
+	if err := b.store.Knowledge().UpdateDocument(ctx, doc); err != nil {
+		return fmt.Errorf("update document status: %w", err)
+	}
+	return nil
+}
+
+func (b *bizImpl) importContent(ctx context.Context, kb *model.KnowledgeBase, req *ImportRequest, doc *model.KnowledgeDocument, fileData []byte) (*ImportResult, error) {
+	if err := b.setParseStatus(ctx, doc, model.DocumentParseStatusParsing); err != nil {
+		return nil, err
+	}
+
+	// 3. 解析文档内容
+	var docs []*schema.Document
+	var err error
+	switch req.SourceType {
+	case "url":
+		docs, err = b.loadFromURL(ctx, req.SourceURI)
+	case "text":
+		docs = []*schema.Document{{Content: req.Content}}
 	default:
-		// 递归分块（默认）
-		chunkSize := req.ChunkSize
-		if chunkSize <= 0 {
-			chunkSize = 512
+		docs, err = b.parseFile(ctx, req.FileName, bytes.NewReader(fileData))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load document: %w", err)
+	}
+
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no content loaded")
+	}
+
+	// 合并所有文档内容
+	var contentBuilder strings.Builder
+	for _, d := range docs {
+		contentBuilder.WriteString(d.Content)
+		contentBuilder.WriteString("\n")
+	}
+	fullContent := contentBuilder.String()
+
+	// 内容审核（可选）
+	if b.moderator.Enabled() {
+		result := b.moderator.CheckDocument(ctx, doc.ID, fullContent)
+		if result.Blocked {
+			if req.SourceType == "file" {
+				_ = b.files.Delete(ctx, doc.SourceURI)
+			}
+			return nil, fmt.Errorf("moderate document: %w", moderation.ErrContentBlocked)
 		}
-		chunkOverlap := req.ChunkOverlap
-		if chunkOverlap <= 0 {
-			chunkOverlap = 50
+		if result.Flagged {
+			if doc.Metadata == nil {
+				doc.Metadata = model.JSONMap{}
+			}
+			doc.Metadata["moderation_flagged"] = true
+			doc.Metadata["moderation_categories"] = result.Categories
 		}
-		chunks, err = b.splitDocumentRecursive(ctx, fullContent, chunkSize, chunkOverlap)
+		fullContent = result.Content
 	}
+	doc.ContentText = fullContent
+
+	// 4. 分块
+	chunks, err := b.splitContent(ctx, kb, fullContent, req.splitOptions())
 	if err != nil {
 		return nil, fmt.Errorf("split document: %w", err)
 	}
@@ -156,16 +304,32 @@ func (b *bizImpl) ImportDocument(ctx context.Context, req *ImportRequest) (*Impo
 	if len(chunks) == 0 {
 		return nil, fmt.Errorf("no chunks after splitting")
 	}
+	if chunks, err = b.applyChunkLimit(doc, chunks); err != nil {
+		return nil, err
+	}
 
 	// 5. 生成 embedding
+	b.importJobs.update(doc.ID, func(job *ImportJob) {
+		job.TotalChunks = len(chunks)
+	})
+	if err := b.setParseStatus(ctx, doc, model.DocumentParseStatusEmbedding); err != nil {
+		return nil, err
+	}
+
 	var chunkContents []string
 	for _, c := range chunks {
 		chunkContents = append(chunkContents, c.Content)
 	}
 
-	embeddingVectors, err := b.embedder.EmbedStrings(ctx, chunkContents)
-	if err != nil {
-		return nil, fmt.Errorf("embed chunks: %w", err)
+	// 分批生成，部分批次失败时仍写入已生成的向量，文档标记为失败
+	embeddingVectors, embedErr := b.embedInBatches(ctx, chunkContents, func(n int) {
+		b.importJobs.update(doc.ID, func(job *ImportJob) {
+			job.EmbeddedChunks += n
+		})
+	})
+	var batchErr *EmbeddingBatchError
+	if embedErr != nil && !errors.As(embedErr, &batchErr) {
+		return nil, fmt.Errorf("embed chunks: %w", embedErr)
 	}
 
 	// 6. 创建 chunk 和 embedding 记录
@@ -178,21 +342,22 @@ func (b *bizImpl) ImportDocument(ctx context.Context, req *ImportRequest) (*Impo
 
 		chunkModels = append(chunkModels, &model.KnowledgeChunk{
 			ID:              chunkID,
-			KnowledgeBaseID: req.KnowledgeBaseID,
-			DocumentID:      docID,
+			KnowledgeBaseID: doc.KnowledgeBaseID,
+			DocumentID:      doc.ID,
 			ChunkIndex:      i,
 			Content:         c.Content,
 			ContentHash:     contentHash,
+			Metadata:        chunkMetadata(c.MetaData, doc.Metadata, req.PropagateMetadataKeys),
 			IsEnabled:       true,
 		})
 
-		if i < len(embeddingVectors) {
+		if i < len(embeddingVectors) && embeddingVectors[i] != nil {
 			vec32 := make([]float32, len(embeddingVectors[i]))
 			for j, v := range embeddingVectors[i] {
 				vec32[j] = float32(v)
 			}
 			embeddingModels = append(embeddingModels, &model.Embedding{
-				KnowledgeBaseID: req.KnowledgeBaseID,
+				KnowledgeBaseID: doc.KnowledgeBaseID,
 				ChunkID:         chunkID,
 				Embedding:       vec32,
 				EmbeddingDim:    len(vec32),
@@ -210,159 +375,94 @@ func (b *bizImpl) ImportDocument(ctx context.Context, req *ImportRequest) (*Impo
 		return nil, fmt.Errorf("create embeddings: %w", err)
 	}
 
-	// 8. 更新文档解析状态
-	docModel.ParseStatus = model.DocumentParseStatusParsed
-	if err := b.store.Knowledge().UpdateDocument(ctx, docModel); err != nil {
-		return nil, fmt.Errorf("update document status: %w", err)
+	// 8. 更新文档解析状态，部分批次失败时由 processImport 标记为失败
+	if batchErr != nil {
+		return nil, fmt.Errorf("embed chunks of document %s: %w", doc.ID, batchErr)
+	}
+	if err := b.setParseStatus(ctx, doc, model.DocumentParseStatusParsed); err != nil {
+		return nil, err
 	}
 
 	return &ImportResult{
-		DocumentID: docID,
-		ChunkCount: len(chunkModels),
+		DocumentID:  doc.ID,
+		ChunkCount:  len(chunkModels),
+		ParseStatus: model.DocumentParseStatusParsed,
 	}, nil
 }
 
-// loadFromURL 从 URL 加载文档.
-func (b *bizImpl) loadFromURL(ctx context.Context, uri string) ([]*schema.Document, error) {
-	loader, err := url.NewLoader(ctx, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create url loader: %w", err)
+// duplicateDocument 查找知识库中文件哈希相同的文档，找到时返回去重结果；req.Force 时不查找.
+func (b *bizImpl) duplicateDocument(ctx context.Context, req *ImportRequest, fileHash string) (*ImportResult, error) {
+	if req.Force || fileHash == "" {
+		return nil, nil
 	}
-
-	docs, err := loader.Load(ctx, document.Source{URI: uri})
-	if err != nil {
-		return nil, fmt.Errorf("load from url: %w", err)
+	existing, err := b.store.Knowledge().GetDocumentByHash(ctx, req.KnowledgeBaseID, fileHash, time.Now().Add(-b.importStaleTimeout))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
 	}
-
-	return docs, nil
-}
-
-// splitDocumentRecursive 递归分块文档.
-func (b *bizImpl) splitDocumentRecursive(ctx context.Context, content string, chunkSize, chunkOverlap int) ([]*schema.Document, error) {
-	splitter, err := recursive.NewSplitter(ctx, &recursive.Config{
-		ChunkSize:   chunkSize,
-		OverlapSize: chunkOverlap,
-		Separators:  []string{"\n\n", "\n", "。", ".", " ", ""},
-	})
 	if err != nil {
-		return nil, fmt.Errorf("create splitter: %w", err)
+		return nil, fmt.Errorf("find duplicate document: %w", err)
 	}
-
-	docs := []*schema.Document{{Content: content}}
-	chunks, err := splitter.Transform(ctx, docs)
+	_, chunkCount, err := b.store.Knowledge().ListChunksByDocument(ctx, existing.ID, 1, 0)
 	if err != nil {
-		return nil, fmt.Errorf("split: %w", err)
+		return nil, fmt.Errorf("count chunks: %w", err)
 	}
-
-	return chunks, nil
+	return &ImportResult{
+		DocumentID:   existing.ID,
+		ChunkCount:   int(chunkCount),
+		Deduplicated: true,
+		ParseStatus:  existing.ParseStatus,
+	}, nil
 }
 
-// splitDocumentSemantic 语义分块文档.
-func (b *bizImpl) splitDocumentSemantic(ctx context.Context, content string, percentile float64) ([]*schema.Document, error) {
-	if b.embedder == nil {
-		return nil, fmt.Errorf("embedder is required for semantic splitting")
+// chunkMetadata 构建分块元数据：分块自身的元数据（如页码、章节）加上 keys 指定的文档元数据.
+// 同名键以分块自身的值为准.
+func chunkMetadata(own map[string]any, docMetadata model.JSONMap, keys []string) model.JSONMap {
+	if len(own) == 0 && len(keys) == 0 {
+		return nil
 	}
-
-	splitter, err := semantic.NewSplitter(ctx, &semantic.Config{
-		Embedding:    b.embedder,
-		Percentile:   percentile,
-		BufferSize:   1,
-		MinChunkSize: 100,
-		Separators:   []string{"\n\n", "\n", "。", ".", "?", "!", " "},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("create semantic splitter: %w", err)
+	metadata := make(model.JSONMap, len(own)+len(keys))
+	for _, key := range keys {
+		if v, ok := docMetadata[key]; ok {
+			metadata[key] = v
+		}
 	}
-
-	docs := []*schema.Document{{Content: content}}
-	chunks, err := splitter.Transform(ctx, docs)
-	if err != nil {
-		return nil, fmt.Errorf("semantic split: %w", err)
+	for k, v := range own {
+		metadata[k] = v
 	}
-
-	return chunks, nil
-}
-
-// parseFile 解析文件内容.
-func (b *bizImpl) parseFile(ctx context.Context, fileName string, reader io.Reader) ([]*schema.Document, error) {
-	ext := strings.ToLower(filepath.Ext(fileName))
-
-	var p parser.Parser
-	var err error
-
-	switch ext {
-	case ".pdf":
-		p, err = pdf.NewPDFParser(ctx, nil)
-	case ".docx":
-		p, err = docx.NewDocxParser(ctx, nil)
-	case ".xlsx", ".xls":
-		p, err = xlsx.NewXlsxParser(ctx, nil)
-	case ".csv":
-		// CSV 解析为表格文本
-		return b.parseCSV(reader)
-	case ".txt", ".md":
-		// 纯文本直接读取
-		content, readErr := io.ReadAll(reader)
-		if readErr != nil {
-			return nil, fmt.Errorf("read text file: %w", readErr)
-		}
-		return []*schema.Document{{Content: string(content)}}, nil
-	default:
-		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	if len(metadata) == 0 {
+		return nil
 	}
+	return metadata
+}
 
+// loadFromURL 从 URL 加载文档.
+func (b *bizImpl) loadFromURL(ctx context.Context, uri string) ([]*schema.Document, error) {
+	loader, err := url.NewLoader(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("create parser for %s: %w", ext, err)
+		return nil, fmt.Errorf("create url loader: %w", err)
 	}
 
-	docs, err := p.Parse(ctx, reader)
+	docs, err := loader.Load(ctx, document.Source{URI: uri})
 	if err != nil {
-		return nil, fmt.Errorf("parse %s file: %w", ext, err)
+		return nil, fmt.Errorf("load from url: %w", err)
 	}
 
 	return docs, nil
 }
 
-// parseCSV 解析 CSV 文件为文档.
-func (b *bizImpl) parseCSV(reader io.Reader) ([]*schema.Document, error) {
-	csvReader := csv.NewReader(reader)
-	records, err := csvReader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("read csv: %w", err)
-	}
-
-	if len(records) == 0 {
-		return nil, fmt.Errorf("empty csv file")
-	}
-
-	// 将 CSV 转换为表格文本格式
-	var sb strings.Builder
-	for i, row := range records {
-		if i == 0 {
-			sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
-			sb.WriteString("|" + strings.Repeat("---|", len(row)) + "\n")
-		} else {
-			sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
-		}
-	}
-
-	return []*schema.Document{{Content: sb.String()}}, nil
+// parseFile 解析文件内容.
+func (b *bizImpl) parseFile(ctx context.Context, fileName string, reader io.Reader) ([]*schema.Document, error) {
+	return docparse.Parse(ctx, fileName, reader)
 }
 
-// saveFileToLocal 保存文件到本地存储.
-func (b *bizImpl) saveFileToLocal(kbID, docID, fileName string, data []byte) (string, error) {
-	// 构建存储路径: data/files/<kbID>/<docID>/<filename>
-	dir := filepath.Join(DataFilesBaseDir, kbID, docID)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("create directory: %w", err)
-	}
-
-	filePath := filepath.Join(dir, fileName)
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return "", fmt.Errorf("write file: %w", err)
+// saveFile 保存原始文件到对象存储，返回存储 key: <kbID>/<docID>/<filename>.
+func (b *bizImpl) saveFile(ctx context.Context, kbID, docID, fileName string, data []byte) (string, error) {
+	key := path.Join(kbID, docID, path.Base(filepath.ToSlash(fileName)))
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if err := b.files.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return "", fmt.Errorf("put %s: %w", key, err)
 	}
-
-	return filePath, nil
+	return key, nil
 }
 
 func md5Hash(s string) string {
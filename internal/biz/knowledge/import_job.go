@@ -0,0 +1,142 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// importJobRetention 已结束的导入任务在内存中保留的时间，之后只能从文档记录查询状态.
+const importJobRetention = time.Hour
+
+// ImportJob 文档导入任务，记录解析和生成向量的进度.
+type ImportJob struct {
+	ID              string                    `json:"id"`
+	KnowledgeBaseID string                    `json:"knowledge_base_id"`
+	DocumentID      string                    `json:"document_id"`
+	Stage           model.DocumentParseStatus `json:"stage"`
+	TotalChunks     int                       `json:"total_chunks"`
+	EmbeddedChunks  int                       `json:"embedded_chunks"`
+	Error           string                    `json:"error,omitempty"`
+	StartedAt       time.Time                 `json:"started_at"`
+	FinishedAt      *time.Time                `json:"finished_at,omitempty"`
+}
+
+// importJobs 内存中的导入任务记录，按文档 ID 索引.
+type importJobs struct {
+	mu   sync.Mutex
+	jobs map[string]*ImportJob
+}
+
+func newImportJobs() *importJobs {
+	return &importJobs{jobs: make(map[string]*ImportJob)}
+}
+
+// start 登记新任务，并清理结束超过 importJobRetention 的任务.
+func (r *importJobs) start(kbID, docID string) *ImportJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, j := range r.jobs {
+		if j.FinishedAt != nil && time.Since(*j.FinishedAt) > importJobRetention {
+			delete(r.jobs, id)
+		}
+	}
+	job := &ImportJob{
+		ID:              uuid.New().String(),
+		KnowledgeBaseID: kbID,
+		DocumentID:      docID,
+		Stage:           model.DocumentParseStatusPending,
+		StartedAt:       time.Now(),
+	}
+	r.jobs[docID] = job
+	snapshot := *job
+	return &snapshot
+}
+
+// update 在锁内修改任务.
+func (r *importJobs) update(docID string, fn func(job *ImportJob)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[docID]; ok {
+		fn(job)
+	}
+}
+
+// finish 记录任务结束.
+func (r *importJobs) finish(docID string, err error) {
+	now := time.Now()
+	r.update(docID, func(job *ImportJob) {
+		job.FinishedAt = &now
+		if err != nil {
+			job.Stage = model.DocumentParseStatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Stage = model.DocumentParseStatusParsed
+	})
+}
+
+// get 返回任务快照.
+func (r *importJobs) get(docID string) (*ImportJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[docID]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *job
+	return &snapshot, true
+}
+
+// DocumentStatus 文档导入状态.
+type DocumentStatus struct {
+	DocumentID string `json:"document_id"`
+	// JobID 导入任务 ID，任务记录已过期（或服务重启）时为空
+	JobID string                    `json:"job_id,omitempty"`
+	Stage model.DocumentParseStatus `json:"stage"`
+	// TotalChunks 分块数，分块完成前为 0
+	TotalChunks int `json:"total_chunks"`
+	// EmbeddedChunks 已生成向量的分块数
+	EmbeddedChunks int        `json:"embedded_chunks"`
+	Error          string     `json:"error,omitempty"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// GetDocumentStatus 查询文档的导入阶段和进度.
+// 阶段以文档记录为准，进度来自内存中的导入任务；任务记录不存在时按已写入的分块数估算.
+func (b *bizImpl) GetDocumentStatus(ctx context.Context, kbID, docID string) (*DocumentStatus, error) {
+	doc, err := b.store.Knowledge().GetDocument(ctx, docID)
+	if err != nil || doc.KnowledgeBaseID != kbID {
+		return nil, fmt.Errorf("%w: %s", ErrDocumentNotFound, docID)
+	}
+
+	status := &DocumentStatus{
+		DocumentID: doc.ID,
+		Stage:      doc.ParseStatus,
+		Error:      doc.ErrorMessage,
+	}
+	if job, ok := b.importJobs.get(docID); ok {
+		status.JobID = job.ID
+		status.TotalChunks = job.TotalChunks
+		status.EmbeddedChunks = job.EmbeddedChunks
+		status.StartedAt = &job.StartedAt
+		status.FinishedAt = job.FinishedAt
+		return status, nil
+	}
+
+	if doc.ParseStatus == model.DocumentParseStatusParsed {
+		_, total, err := b.store.Knowledge().ListChunksByDocument(ctx, docID, 1, 0)
+		if err != nil {
+			return nil, fmt.Errorf("count chunks: %w", err)
+		}
+		status.TotalChunks = int(total)
+		status.EmbeddedChunks = int(total)
+	}
+	return status, nil
+}
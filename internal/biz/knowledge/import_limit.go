@@ -0,0 +1,184 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ImportLimitConfig 导入并发限制，避免单个知识库或租户的批量导入耗尽共享的 Embedding 配额.
+// 超过限制的导入排队等待，<= 0 表示不限制.
+type ImportLimitConfig struct {
+	// PerKnowledgeBase 单个知识库同时解析和生成向量的文档数
+	PerKnowledgeBase int `mapstructure:"per_knowledge_base"`
+	// PerTenant 单个租户（所有知识库合计）同时解析和生成向量的文档数
+	PerTenant int `mapstructure:"per_tenant"`
+}
+
+// ImportQueueStats 导入并发和排队情况，未配置限制时均为 0.
+type ImportQueueStats struct {
+	Active int `json:"active"`
+	Queued int `json:"queued"`
+	// Limit 并发上限，0 表示不限制
+	Limit int `json:"limit"`
+}
+
+// importSlots 一个知识库或租户的导入许可.
+type importSlots struct {
+	ch     chan struct{}
+	queued int
+}
+
+// importLimiter 按知识库和租户限制同时导入的文档数，nil 表示不限制.
+type importLimiter struct {
+	perKB     int
+	perTenant int
+
+	mu      sync.Mutex
+	kbs     map[string]*importSlots
+	tenants map[string]*importSlots
+}
+
+// newImportLimiter 创建导入限制器，均未配置限制时返回 nil.
+func newImportLimiter(cfg ImportLimitConfig) *importLimiter {
+	if cfg.PerKnowledgeBase <= 0 && cfg.PerTenant <= 0 {
+		return nil
+	}
+	return &importLimiter{
+		perKB:     cfg.PerKnowledgeBase,
+		perTenant: cfg.PerTenant,
+		kbs:       make(map[string]*importSlots),
+		tenants:   make(map[string]*importSlots),
+	}
+}
+
+// acquire 依次获取知识库和租户的导入许可，无空闲许可时排队等待，返回归还许可的函数.
+// 先获取知识库许可，排队中的导入不会占用租户许可；获取顺序固定，不会互相等待.
+func (l *importLimiter) acquire(ctx context.Context, kbID, tenantID string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	kb := l.slots(l.kbs, kbID, l.perKB)
+	if err := l.wait(ctx, kb); err != nil {
+		return nil, fmt.Errorf("wait for knowledge base import slot: %w", err)
+	}
+	tenant := l.slots(l.tenants, tenantID, l.perTenant)
+	if err := l.wait(ctx, tenant); err != nil {
+		release(kb)
+		return nil, fmt.Errorf("wait for tenant import slot: %w", err)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			release(tenant)
+			release(kb)
+		})
+	}, nil
+}
+
+// slots 返回 key 对应的许可，未配置限制或 key 为空时返回 nil.
+func (l *importLimiter) slots(m map[string]*importSlots, key string, limit int) *importSlots {
+	if key == "" || limit <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := m[key]
+	if !ok {
+		s = &importSlots{ch: make(chan struct{}, limit)}
+		m[key] = s
+	}
+	return s
+}
+
+// wait 获取一个许可，没有空闲许可时计入排队数并等待.
+func (l *importLimiter) wait(ctx context.Context, s *importSlots) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.ch <- struct{}{}:
+		return nil
+	default:
+	}
+
+	l.mu.Lock()
+	s.queued++
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		s.queued--
+		l.mu.Unlock()
+	}()
+
+	select {
+	case s.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func release(s *importSlots) {
+	if s != nil {
+		<-s.ch
+	}
+}
+
+// stats 返回知识库和租户的导入并发和排队情况.
+func (l *importLimiter) stats(kbID, tenantID string) (kb, tenant ImportQueueStats) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	read := func(s *importSlots, limit int) ImportQueueStats {
+		stats := ImportQueueStats{Limit: limit}
+		if s != nil {
+			stats.Active = len(s.ch)
+			stats.Queued = s.queued
+		}
+		return stats
+	}
+	if l.perKB > 0 {
+		kb = read(l.kbs[kbID], l.perKB)
+	}
+	if l.perTenant > 0 && tenantID != "" {
+		tenant = read(l.tenants[tenantID], l.perTenant)
+	}
+	return kb, tenant
+}
+
+// KnowledgeBaseStats 知识库统计.
+type KnowledgeBaseStats struct {
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	DocumentCount   int64  `json:"document_count"`
+	ChunkCount      int64  `json:"chunk_count"`
+	// Imports 知识库正在导入和排队的文档数，TenantImports 为所属租户所有知识库合计
+	Imports       ImportQueueStats `json:"imports"`
+	TenantImports ImportQueueStats `json:"tenant_imports"`
+}
+
+// GetKnowledgeBaseStats 返回知识库的文档数、分块数和导入队列情况.
+func (b *bizImpl) GetKnowledgeBaseStats(ctx context.Context, kbID string) (*KnowledgeBaseStats, error) {
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	docs, err := b.store.Knowledge().CountDocuments(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("count documents: %w", err)
+	}
+	_, chunks, err := b.store.Knowledge().ListChunksByKnowledgeBase(ctx, kbID, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("count chunks: %w", err)
+	}
+
+	stats := &KnowledgeBaseStats{
+		KnowledgeBaseID: kbID,
+		DocumentCount:   docs,
+		ChunkCount:      chunks,
+	}
+	stats.Imports, stats.TenantImports = b.importLimiter.stats(kbID, kb.TenantID)
+	return stats, nil
+}
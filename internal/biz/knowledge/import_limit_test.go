@@ -0,0 +1,144 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// acquireAsync 在后台获取导入许可，返回获取结果的通道.
+func acquireAsync(l *importLimiter, ctx context.Context, kbID, tenantID string) <-chan func() {
+	acquired := make(chan func(), 1)
+	go func() {
+		release, err := l.acquire(ctx, kbID, tenantID)
+		if err != nil {
+			close(acquired)
+			return
+		}
+		acquired <- release
+	}()
+	return acquired
+}
+
+// waitQueued 轮询直到知识库或租户有 n 个排队的导入.
+func waitQueued(t *testing.T, l *importLimiter, kbID, tenantID string, kbQueued, tenantQueued int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		kb, tenant := l.stats(kbID, tenantID)
+		if kb.Queued == kbQueued && tenant.Queued == tenantQueued {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	kb, tenant := l.stats(kbID, tenantID)
+	t.Fatalf("queued = %d/%d, want %d/%d", kb.Queued, tenant.Queued, kbQueued, tenantQueued)
+}
+
+func TestImportsSerializedPastKnowledgeBaseLimit(t *testing.T) {
+	l := newImportLimiter(ImportLimitConfig{PerKnowledgeBase: 1})
+	ctx := context.Background()
+
+	first, err := l.acquire(ctx, "kb1", "t1")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	second := acquireAsync(l, ctx, "kb1", "t1")
+	waitQueued(t, l, "kb1", "t1", 1, 0)
+	if kb, _ := l.stats("kb1", "t1"); kb.Active != 1 || kb.Limit != 1 {
+		t.Errorf("kb stats = %+v, want 1 active of limit 1", kb)
+	}
+
+	// 其他知识库不受影响
+	other, err := l.acquire(ctx, "kb2", "t1")
+	if err != nil {
+		t.Fatalf("acquire other kb: %v", err)
+	}
+	other()
+
+	select {
+	case <-second:
+		t.Fatal("second import started before the first finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	first()
+	first() // 重复归还不多释放许可
+	select {
+	case release := <-second:
+		if release == nil {
+			t.Fatal("second acquire failed")
+		}
+		if kb, _ := l.stats("kb1", "t1"); kb.Active != 1 || kb.Queued != 0 {
+			t.Errorf("kb stats after handoff = %+v, want 1 active and none queued", kb)
+		}
+		release()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second import did not start after the first finished")
+	}
+	if kb, _ := l.stats("kb1", "t1"); kb.Active != 0 {
+		t.Errorf("kb stats after release = %+v, want idle", kb)
+	}
+}
+
+func TestImportsSerializedPastTenantLimit(t *testing.T) {
+	l := newImportLimiter(ImportLimitConfig{PerTenant: 1})
+	ctx := context.Background()
+
+	first, err := l.acquire(ctx, "kb1", "t1")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	// 同一租户的其他知识库排队，其他租户不受影响
+	second := acquireAsync(l, ctx, "kb2", "t1")
+	waitQueued(t, l, "kb2", "t1", 0, 1)
+	other, err := l.acquire(ctx, "kb3", "t2")
+	if err != nil {
+		t.Fatalf("acquire other tenant: %v", err)
+	}
+	other()
+
+	first()
+	select {
+	case release := <-second:
+		if release == nil {
+			t.Fatal("second acquire failed")
+		}
+		release()
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued import did not start after the tenant slot was released")
+	}
+}
+
+func TestQueuedImportCanceled(t *testing.T) {
+	l := newImportLimiter(ImportLimitConfig{PerKnowledgeBase: 1, PerTenant: 1})
+	first, err := l.acquire(context.Background(), "kb1", "t1")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer first()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "kb1", "t1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued acquire error = %v, want deadline exceeded", err)
+	}
+	if kb, tenant := l.stats("kb1", "t1"); kb.Queued != 0 || tenant.Queued != 0 || kb.Active != 1 || tenant.Active != 1 {
+		t.Errorf("stats after cancel = %+v / %+v, want only the first import active", kb, tenant)
+	}
+}
+
+func TestNoImportLimitByDefault(t *testing.T) {
+	l := newImportLimiter(ImportLimitConfig{})
+	if l != nil {
+		t.Fatalf("newImportLimiter() = %+v, want nil without limits", l)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := l.acquire(context.Background(), "kb1", "t1"); err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+	}
+	if kb, tenant := l.stats("kb1", "t1"); kb != (ImportQueueStats{}) || tenant != (ImportQueueStats{}) {
+		t.Errorf("stats = %+v / %+v, want zero", kb, tenant)
+	}
+}
@@ -0,0 +1,64 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestDuplicateDocumentIgnoresStaleImports(t *testing.T) {
+	s := newFakeStore()
+	b := NewBiz(s, nil, nil, nil, &BizConfig{ImportStaleTimeout: 10 * time.Minute}).(*bizImpl)
+
+	req := &ImportRequest{KnowledgeBaseID: "kb1"}
+	before := time.Now()
+	result, err := b.duplicateDocument(context.Background(), req, "hash")
+	if err != nil || result != nil {
+		t.Fatalf("duplicateDocument() = %v, %v, want no duplicate", result, err)
+	}
+	// 只有 10 分钟内仍有进展的导入中文档参与去重
+	wantSince := before.Add(-10 * time.Minute)
+	if d := s.knowledge.inFlightSince.Sub(wantSince); d < 0 || d > time.Second {
+		t.Errorf("inFlightSince = %v, want about %v", s.knowledge.inFlightSince, wantSince)
+	}
+
+	s.knowledge.byHash = &model.KnowledgeDocument{ID: "d1", ParseStatus: model.DocumentParseStatusParsed}
+	result, err = b.duplicateDocument(context.Background(), req, "hash")
+	if err != nil {
+		t.Fatalf("duplicateDocument() error = %v", err)
+	}
+	if result == nil || !result.Deduplicated || result.DocumentID != "d1" || result.ChunkCount != 3 {
+		t.Errorf("duplicateDocument() = %+v, want deduplicated d1 with 3 chunks", result)
+	}
+
+	req.Force = true
+	if result, _ := b.duplicateDocument(context.Background(), req, "hash"); result != nil {
+		t.Errorf("duplicateDocument() with Force = %+v, want nil", result)
+	}
+}
+
+func TestRecoverInterruptedImports(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.staleCount = 2
+	b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+
+	start := time.Now()
+	n, err := b.RecoverInterruptedImports(context.Background())
+	if err != nil {
+		t.Fatalf("RecoverInterruptedImports() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("RecoverInterruptedImports() = %d, want 2", n)
+	}
+	if s.knowledge.staleBefore.Before(start) {
+		t.Errorf("updatedBefore = %v, want all imports started before recovery", s.knowledge.staleBefore)
+	}
+	if s.knowledge.staleMessage != interruptedImportMessage {
+		t.Errorf("message = %q", s.knowledge.staleMessage)
+	}
+	if b.importStaleTimeout != defaultImportStaleTimeout {
+		t.Errorf("importStaleTimeout = %v, want default %v", b.importStaleTimeout, defaultImportStaleTimeout)
+	}
+}
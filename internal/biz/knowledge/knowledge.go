@@ -3,11 +3,13 @@ package knowledge
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
-	"github.com/cloudwego/eino-ext/components/document/transformer/reranker/score"
 	"github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/schema"
 
+	"github.com/ashwinyue/next-show/internal/model"
 	"github.com/ashwinyue/next-show/internal/pkg/agent/tools"
 	"github.com/ashwinyue/next-show/internal/store"
 )
@@ -74,7 +76,11 @@ func (s *Service) SemanticSearch(ctx context.Context, req *tools.SemanticSearchR
 		topK = 10
 	}
 
-	results, err := s.store.Knowledge().SearchChunksByVector(ctx, req.KnowledgeBaseIDs, queryVector, topK)
+	results, err := s.store.Knowledge().SearchChunksByVectorWithOptions(ctx, req.KnowledgeBaseIDs, queryVector, topK, store.SearchOptions{
+		DistanceFunction: store.DistanceCosine,
+		DocumentMetadata: req.DocumentMetadata,
+		MetadataFilters:  req.MetadataFilters,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -112,7 +118,7 @@ func (s *Service) KeywordSearch(ctx context.Context, req *tools.KeywordSearchReq
 		topK = 20
 	}
 
-	results, err := s.store.Knowledge().SearchChunksByKeyword(ctx, req.KnowledgeBaseIDs, req.Keywords, topK)
+	results, err := s.store.Knowledge().SearchChunksByKeyword(ctx, req.KnowledgeBaseIDs, req.Keywords, topK, !req.MatchAny)
 	if err != nil {
 		return nil, err
 	}
@@ -122,17 +128,18 @@ func (s *Service) KeywordSearch(ctx context.Context, req *tools.KeywordSearchReq
 	for _, r := range results {
 		// 获取文档标题
 		docTitle := ""
-		if doc, err := s.store.Knowledge().GetDocument(ctx, r.DocumentID); err == nil && doc != nil {
+		if doc, err := s.store.Knowledge().GetDocument(ctx, r.Chunk.DocumentID); err == nil && doc != nil {
 			docTitle = doc.Title
 		}
 
 		chunks = append(chunks, &tools.ChunkResult{
-			ID:              r.ID,
-			DocumentID:      r.DocumentID,
+			ID:              r.Chunk.ID,
+			DocumentID:      r.Chunk.DocumentID,
 			DocumentTitle:   docTitle,
-			KnowledgeBaseID: r.KnowledgeBaseID,
-			ChunkIndex:      r.ChunkIndex,
-			Content:         r.Content,
+			KnowledgeBaseID: r.Chunk.KnowledgeBaseID,
+			ChunkIndex:      r.Chunk.ChunkIndex,
+			Content:         r.Chunk.Content,
+			Score:           r.Score,
 		})
 	}
 
@@ -142,8 +149,13 @@ func (s *Service) KeywordSearch(ctx context.Context, req *tools.KeywordSearchReq
 	}, nil
 }
 
-// HybridSearch 混合检索（向量 + BM25）.
+// HybridSearch 混合检索（向量 + BM25），req.RerankSearch 时返回重排序后的结果.
 func (s *Service) HybridSearch(ctx context.Context, req *tools.HybridSearchRequest) (*tools.HybridSearchResult, error) {
+	if req.RerankSearch {
+		plain := *req
+		plain.RerankSearch = false
+		return s.RerankedSearch(ctx, &plain)
+	}
 	if s.embeddingModel == nil {
 		return &tools.HybridSearchResult{
 			Chunks:     []*tools.ChunkResult{},
@@ -185,7 +197,12 @@ func (s *Service) HybridSearch(ctx context.Context, req *tools.HybridSearchReque
 	}
 
 	// 执行混合检索
-	results, err := s.store.Knowledge().HybridSearch(ctx, req.KnowledgeBaseIDs, queryVector, req.Query, topK, vectorWeight, bm25Weight)
+	results, err := s.store.Knowledge().HybridSearch(ctx, req.KnowledgeBaseIDs, queryVector, req.Query, topK, vectorWeight, bm25Weight, store.SearchOptions{
+		DocumentMetadata: req.DocumentMetadata,
+		MetadataFilters:  req.MetadataFilters,
+		Fusion:           req.FusionMethod,
+		RRFK:             req.RRFK,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -256,6 +273,53 @@ func (s *Service) ListChunks(ctx context.Context, req *tools.ListChunksRequest)
 	}, nil
 }
 
+// GetDocumentSummary 获取文档缓存的摘要，缓存不是按当前分块内容生成的（重新分块、编辑分块、刷新来源后）时摘要为空.
+func (s *Service) GetDocumentSummary(ctx context.Context, req *tools.DocumentSummaryRequest) (*tools.DocumentSummary, error) {
+	doc, err := s.summaryDocument(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := s.store.Knowledge().DocumentContentHash(ctx, doc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("hash document content: %w", err)
+	}
+	result := &tools.DocumentSummary{ContentHash: hash}
+	if hash != "" && doc.SummaryHash == hash {
+		result.Summary = doc.Summary
+	}
+	return result, nil
+}
+
+// SaveDocumentSummary 缓存文档摘要，summary.ContentHash 应为生成摘要前通过 GetDocumentSummary 取得的哈希.
+func (s *Service) SaveDocumentSummary(ctx context.Context, req *tools.DocumentSummaryRequest, summary *tools.DocumentSummary) error {
+	doc, err := s.summaryDocument(ctx, req)
+	if err != nil {
+		return err
+	}
+	return s.store.Knowledge().UpdateDocumentSummary(ctx, doc.ID, summary.ContentHash, summary.Summary)
+}
+
+// summaryDocument 获取文档并校验其在请求的知识库和租户范围内，范围外的文档按不存在处理.
+func (s *Service) summaryDocument(ctx context.Context, req *tools.DocumentSummaryRequest) (*model.KnowledgeDocument, error) {
+	doc, err := s.store.Knowledge().GetDocument(ctx, req.DocumentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDocumentNotFound, err)
+	}
+	if len(req.KnowledgeBaseIDs) > 0 && !slices.Contains(req.KnowledgeBaseIDs, doc.KnowledgeBaseID) {
+		return nil, ErrDocumentNotFound
+	}
+	if req.TenantID != "" {
+		kb, err := s.store.Knowledge().GetKnowledgeBase(ctx, doc.KnowledgeBaseID)
+		if err != nil {
+			return nil, fmt.Errorf("get knowledge base: %w", err)
+		}
+		if kb.TenantID != req.TenantID {
+			return nil, ErrDocumentNotFound
+		}
+	}
+	return doc, nil
+}
+
 // RerankedSearch 带重排序的混合检索.
 func (s *Service) RerankedSearch(ctx context.Context, req *tools.HybridSearchRequest) (*tools.HybridSearchResult, error) {
 	// 先执行混合检索
@@ -285,17 +349,11 @@ func (s *Service) RerankedSearch(ctx context.Context, req *tools.HybridSearchReq
 		docs[i].WithScore(chunk.Score)
 	}
 
-	// 使用 score reranker 重排序（高分放首尾，利用 LLM 首尾效应）
-	reranker, err := score.NewReranker(ctx, &score.Config{})
+	rerankedDocs, err := rerankDocuments(ctx, docs)
 	if err != nil {
 		return result, nil // 重排序失败，返回原结果
 	}
 
-	rerankedDocs, err := reranker.Transform(ctx, docs)
-	if err != nil {
-		return result, nil
-	}
-
 	// 转换回 ChunkResult
 	rerankedChunks := make([]*tools.ChunkResult, len(rerankedDocs))
 	for i, doc := range rerankedDocs {
@@ -307,6 +365,7 @@ func (s *Service) RerankedSearch(ctx context.Context, req *tools.HybridSearchReq
 			KnowledgeBaseID: doc.MetaData["knowledge_base_id"].(string),
 			ChunkIndex:      doc.MetaData["chunk_index"].(int),
 			Score:           doc.Score(),
+			PreRerankScore:  doc.MetaData["original_score"].(float64),
 		}
 	}
 
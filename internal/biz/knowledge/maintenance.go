@@ -0,0 +1,91 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// DefaultOrphanBatchSize 清理孤立数据的默认批大小.
+const DefaultOrphanBatchSize = 1000
+
+// orphanRepairOrder 清理顺序：先删孤立分块，其向量和标签关联随之成为孤立数据，在后续步骤中一并清理.
+var orphanRepairOrder = []store.OrphanKind{store.OrphanChunks, store.OrphanEmbeddings, store.OrphanChunkTags}
+
+// OrphanCounts 各类孤立数据条数.
+type OrphanCounts struct {
+	Chunks     int64 `json:"chunks"`
+	Embeddings int64 `json:"embeddings"`
+	ChunkTags  int64 `json:"chunk_tags"`
+}
+
+func (c *OrphanCounts) add(kind store.OrphanKind, n int64) {
+	switch kind {
+	case store.OrphanChunks:
+		c.Chunks += n
+	case store.OrphanEmbeddings:
+		c.Embeddings += n
+	case store.OrphanChunkTags:
+		c.ChunkTags += n
+	}
+}
+
+// RepairOrphansRequest 孤立数据修复请求.
+type RepairOrphansRequest struct {
+	// Confirm 为 false 时只统计不删除
+	Confirm bool `json:"confirm"`
+	// BatchSize 每批删除条数，默认 1000
+	BatchSize int `json:"batch_size"`
+}
+
+// OrphanReport 孤立数据修复结果.
+type OrphanReport struct {
+	// Found 修复前统计到的孤立数据（不含因删除孤立分块而新产生的）
+	Found OrphanCounts `json:"found"`
+	// Deleted 实际删除的数据，仅 Confirm 时返回
+	Deleted *OrphanCounts `json:"deleted,omitempty"`
+}
+
+// RepairOrphans 查找（并在 Confirm 时分批删除）孤立的分块、向量和分块标签关联.
+func (b *bizImpl) RepairOrphans(ctx context.Context, req *RepairOrphansRequest) (*OrphanReport, error) {
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultOrphanBatchSize
+	}
+
+	report := &OrphanReport{}
+	for _, kind := range orphanRepairOrder {
+		n, err := b.store.Knowledge().CountOrphans(ctx, kind)
+		if err != nil {
+			return nil, fmt.Errorf("count orphan %s: %w", kind, err)
+		}
+		report.Found.add(kind, n)
+	}
+	if !req.Confirm {
+		return report, nil
+	}
+
+	report.Deleted = &OrphanCounts{}
+	for _, kind := range orphanRepairOrder {
+		for {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			n, err := b.store.Knowledge().DeleteOrphans(ctx, kind, batchSize)
+			if err != nil {
+				return report, fmt.Errorf("delete orphan %s: %w", kind, err)
+			}
+			report.Deleted.add(kind, n)
+			if n < int64(batchSize) {
+				break
+			}
+		}
+	}
+	return report, nil
+}
+
+// CheckVectorSupport 重新检测 pgvector 是否可用，不可用时检索退化为仅全文检索，扩展安装后自动恢复.
+func (b *bizImpl) CheckVectorSupport(ctx context.Context) (*store.VectorSupport, error) {
+	return b.store.Knowledge().CheckVectorSupport(ctx)
+}
@@ -0,0 +1,58 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+func seedOrphans() *fakeStore {
+	s := newFakeStore()
+	s.knowledge.orphans[store.OrphanChunks] = 5
+	s.knowledge.orphans[store.OrphanEmbeddings] = 2
+	s.knowledge.orphans[store.OrphanChunkTags] = 1
+	return s
+}
+
+func TestRepairOrphansDryRun(t *testing.T) {
+	s := seedOrphans()
+	b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+
+	report, err := b.RepairOrphans(context.Background(), &RepairOrphansRequest{})
+	if err != nil {
+		t.Fatalf("RepairOrphans() error = %v", err)
+	}
+	if want := (OrphanCounts{Chunks: 5, Embeddings: 2, ChunkTags: 1}); report.Found != want {
+		t.Errorf("Found = %+v, want %+v", report.Found, want)
+	}
+	if report.Deleted != nil || s.knowledge.orphanDeletes != 0 {
+		t.Errorf("dry run deleted %+v in %d calls, want nothing", report.Deleted, s.knowledge.orphanDeletes)
+	}
+}
+
+func TestRepairOrphansCleansUpInBatches(t *testing.T) {
+	s := seedOrphans()
+	b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+
+	report, err := b.RepairOrphans(context.Background(), &RepairOrphansRequest{Confirm: true, BatchSize: 2})
+	if err != nil {
+		t.Fatalf("RepairOrphans() error = %v", err)
+	}
+	if want := (OrphanCounts{Chunks: 5, Embeddings: 2, ChunkTags: 1}); report.Found != want {
+		t.Errorf("Found = %+v, want %+v", report.Found, want)
+	}
+	// 删除孤立分块后产生的向量和标签关联在同一次修复中清理
+	if want := (OrphanCounts{Chunks: 5, Embeddings: 7, ChunkTags: 6}); report.Deleted == nil || *report.Deleted != want {
+		t.Errorf("Deleted = %+v, want %+v", report.Deleted, want)
+	}
+	for kind, n := range s.knowledge.orphans {
+		if n != 0 {
+			t.Errorf("%s orphans left = %d, want 0", kind, n)
+		}
+	}
+	// 分块 5 条 3 批、向量 7 条 4 批、标签关联 6 条 4 批（最后一批为空）
+	if s.knowledge.orphanDeletes != 11 {
+		t.Errorf("DeleteOrphans called %d times, want 11", s.knowledge.orphanDeletes)
+	}
+}
@@ -0,0 +1,95 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/events"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// ErrIncompatibleEmbedding 源知识库与目标知识库的向量空间不兼容.
+var ErrIncompatibleEmbedding = errs.New(errs.ErrValidation, "incompatible embedding space")
+
+// MoveDocument 将文档连同分块和向量移动到目标知识库，无需重新导入.
+// 两个知识库的向量维度或嵌入模型不同时拒绝移动，此时应将文档重新导入目标知识库以重新生成向量.
+// 原始文件保留在原存储路径.
+func (b *bizImpl) MoveDocument(ctx context.Context, docID, targetKBID string, viewer *Viewer) (*model.KnowledgeDocument, error) {
+	doc, err := b.store.Knowledge().GetDocument(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDocumentNotFound, err)
+	}
+	if doc.KnowledgeBaseID == targetKBID {
+		return doc, nil
+	}
+
+	src, err := b.store.Knowledge().GetKnowledgeBase(ctx, doc.KnowledgeBaseID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	dst, err := b.store.Knowledge().GetKnowledgeBase(ctx, targetKBID)
+	if err != nil {
+		return nil, fmt.Errorf("get target knowledge base: %w", err)
+	}
+	if !canAccess(src, viewer) || !canAccess(dst, viewer) {
+		return nil, ErrForbidden
+	}
+
+	if err := b.checkEmbeddingCompatible(ctx, doc, src, dst); err != nil {
+		return nil, err
+	}
+
+	if err := b.store.Knowledge().MoveDocument(ctx, docID, targetKBID); err != nil {
+		return nil, fmt.Errorf("move document: %w", err)
+	}
+	doc.KnowledgeBaseID = targetKBID
+	b.publishDocument(events.DocumentUpdated, doc, map[string]any{
+		"from_knowledge_base_id": src.ID,
+	})
+	return doc, nil
+}
+
+// checkEmbeddingCompatible 校验文档的向量能否直接放入目标知识库：
+// 两个知识库配置的嵌入模型必须相同，文档已有向量的维度和模型必须与目标知识库已有向量一致.
+func (b *bizImpl) checkEmbeddingCompatible(ctx context.Context, doc *model.KnowledgeDocument, src, dst *model.KnowledgeBase) error {
+	srcModel, _ := src.EmbeddingConfig["model"].(string)
+	dstModel, _ := dst.EmbeddingConfig["model"].(string)
+	if srcModel != "" && dstModel != "" && srcModel != dstModel {
+		return incompatibleEmbedding(dst, fmt.Sprintf("embedding model %q differs from target model %q", srcModel, dstModel))
+	}
+
+	docSpaces, err := b.store.Knowledge().ListEmbeddingSpaces(ctx, src.ID, doc.ID)
+	if err != nil {
+		return fmt.Errorf("list document embeddings: %w", err)
+	}
+	if len(docSpaces) == 0 {
+		return nil
+	}
+	dstSpaces, err := b.store.Knowledge().ListEmbeddingSpaces(ctx, dst.ID, "")
+	if err != nil {
+		return fmt.Errorf("list target embeddings: %w", err)
+	}
+
+	for _, ds := range docSpaces {
+		for _, ts := range dstSpaces {
+			if ds.Dim != ts.Dim {
+				return incompatibleEmbedding(dst, fmt.Sprintf("document embeddings have dimension %d but target has %d", ds.Dim, ts.Dim))
+			}
+			if !sameEmbeddingModel(ds, ts) {
+				return incompatibleEmbedding(dst, fmt.Sprintf("document embeddings use model %q but target uses %q", ds.Model, ts.Model))
+			}
+		}
+	}
+	return nil
+}
+
+// sameEmbeddingModel 判断两个向量空间的模型是否相同，导入和重建索引时写入的 "default" 视为与任意模型相同.
+func sameEmbeddingModel(a, b *store.EmbeddingSpace) bool {
+	return a.Model == b.Model || a.Model == "default" || b.Model == "default"
+}
+
+func incompatibleEmbedding(dst *model.KnowledgeBase, reason string) error {
+	return fmt.Errorf("%w: %s; re-import the document into knowledge base %s to re-embed it instead", ErrIncompatibleEmbedding, reason, dst.ID)
+}
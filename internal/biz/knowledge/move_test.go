@@ -0,0 +1,79 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+func TestMoveDocument(t *testing.T) {
+	tests := []struct {
+		name      string
+		dstConfig model.JSONMap
+		docSpace  *store.EmbeddingSpace
+		dstSpace  *store.EmbeddingSpace
+		wantErr   bool
+	}{
+		{
+			name:     "same embedding space",
+			docSpace: &store.EmbeddingSpace{Dim: 1024, Model: "text-embedding-v3"},
+			dstSpace: &store.EmbeddingSpace{Dim: 1024, Model: "text-embedding-v3"},
+		},
+		{
+			name:     "empty target",
+			docSpace: &store.EmbeddingSpace{Dim: 1024, Model: "text-embedding-v3"},
+		},
+		{
+			name:     "different dimension",
+			docSpace: &store.EmbeddingSpace{Dim: 1024, Model: "text-embedding-v3"},
+			dstSpace: &store.EmbeddingSpace{Dim: 1536, Model: "text-embedding-v3"},
+			wantErr:  true,
+		},
+		{
+			name:     "different model",
+			docSpace: &store.EmbeddingSpace{Dim: 1024, Model: "text-embedding-v3"},
+			dstSpace: &store.EmbeddingSpace{Dim: 1024, Model: "bge-m3"},
+			wantErr:  true,
+		},
+		{
+			name:      "different configured model",
+			dstConfig: model.JSONMap{"model": "bge-m3"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeStore()
+			s.knowledge.kbs["src"] = &model.KnowledgeBase{ID: "src", EmbeddingConfig: model.JSONMap{"model": "text-embedding-v3"}}
+			s.knowledge.kbs["dst"] = &model.KnowledgeBase{ID: "dst", EmbeddingConfig: tt.dstConfig}
+			s.knowledge.docs["doc1"] = &model.KnowledgeDocument{ID: "doc1", KnowledgeBaseID: "src"}
+			if tt.docSpace != nil {
+				s.knowledge.spaces["src/doc1"] = []*store.EmbeddingSpace{tt.docSpace}
+			}
+			if tt.dstSpace != nil {
+				s.knowledge.spaces["dst/"] = []*store.EmbeddingSpace{tt.dstSpace}
+			}
+			b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+
+			doc, err := b.MoveDocument(context.Background(), "doc1", "dst", &Viewer{Admin: true})
+			if tt.wantErr {
+				if !errors.Is(err, ErrIncompatibleEmbedding) {
+					t.Fatalf("MoveDocument() error = %v, want ErrIncompatibleEmbedding", err)
+				}
+				if got := s.knowledge.docs["doc1"].KnowledgeBaseID; got != "src" {
+					t.Fatalf("rejected move left document in %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MoveDocument() error = %v", err)
+			}
+			if doc.KnowledgeBaseID != "dst" || s.knowledge.docs["doc1"].KnowledgeBaseID != "dst" {
+				t.Fatalf("document knowledge base = %s, want dst", doc.KnowledgeBaseID)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestImportPropagatesDocumentMetadata(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	b := NewBiz(s, &fakeEmbedder{}, nil, nil, nil).(*bizImpl)
+
+	result, err := b.ImportDocument(context.Background(), &ImportRequest{
+		KnowledgeBaseID:       "kb1",
+		SourceType:            "text",
+		Content:               strings.Repeat("Contract clauses for the legal team. ", 40),
+		ChunkSize:             200,
+		ChunkOverlap:          20,
+		Metadata:              model.JSONMap{"department": "legal", "owner": "alice"},
+		PropagateMetadataKeys: []string{"department", "missing"},
+	})
+	if err != nil {
+		t.Fatalf("ImportDocument() error = %v", err)
+	}
+	chunks := s.knowledge.documentChunks(result.DocumentID)
+	if len(chunks) < 2 {
+		t.Fatalf("chunks = %d, want several", len(chunks))
+	}
+	for _, c := range chunks {
+		if c.Metadata["department"] != "legal" {
+			t.Errorf("chunk %d metadata = %v, want department propagated", c.ChunkIndex, c.Metadata)
+		}
+		// 未列出的键和文档中不存在的键都不复制
+		if _, ok := c.Metadata["owner"]; ok {
+			t.Errorf("chunk %d metadata = %v, owner should stay on the document", c.ChunkIndex, c.Metadata)
+		}
+		if _, ok := c.Metadata["missing"]; ok {
+			t.Errorf("chunk %d metadata = %v, missing key copied", c.ChunkIndex, c.Metadata)
+		}
+		// 分块元数据过滤按 JSONB 包含匹配（c.metadata @> '{"department":"legal"}'）
+		data, _ := json.Marshal(c.Metadata)
+		if !strings.Contains(string(data), `"department":"legal"`) {
+			t.Errorf("chunk %d metadata json = %s, not matchable by department filter", c.ChunkIndex, data)
+		}
+	}
+}
+
+func TestChunkMetadataKeepsChunkValues(t *testing.T) {
+	got := chunkMetadata(map[string]any{"page": 3, "section": "intro"}, model.JSONMap{"page": 1, "department": "legal"}, []string{"page", "department"})
+	if got["page"] != 3 || got["section"] != "intro" || got["department"] != "legal" {
+		t.Errorf("chunkMetadata() = %v, want chunk page kept and department added", got)
+	}
+	if got := chunkMetadata(nil, model.JSONMap{"department": "legal"}, nil); got != nil {
+		t.Errorf("chunkMetadata() without keys = %v, want nil", got)
+	}
+}
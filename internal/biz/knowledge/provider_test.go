@@ -0,0 +1,33 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestKnowledgeBaseRequiresEmbeddingProvider(t *testing.T) {
+	s := newFakeStore()
+	s.providers.providers["chat"] = &model.Provider{ID: "chat", Name: "openai", ModelCategory: model.ModelCategoryChat}
+	s.providers.providers["rerank"] = &model.Provider{ID: "rerank", Name: "cohere", ModelCategory: model.ModelCategoryRerank}
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1", Name: "kb1"}
+	b := NewBiz(s, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	for _, providerID := range []string{"chat", "rerank", "missing"} {
+		kb := &model.KnowledgeBase{Name: "kb", EmbeddingConfig: model.JSONMap{"provider_id": providerID}}
+		if err := b.CreateKnowledgeBase(ctx, kb); !errors.Is(err, ErrInvalidKnowledgeBase) || !errors.Is(err, errs.ErrValidation) {
+			t.Errorf("CreateKnowledgeBase(%s provider) error = %v, want ErrInvalidKnowledgeBase", providerID, err)
+		}
+		kb.ID = "kb1"
+		if err := b.UpdateKnowledgeBase(ctx, kb); !errors.Is(err, ErrInvalidKnowledgeBase) {
+			t.Errorf("UpdateKnowledgeBase(%s provider) error = %v, want ErrInvalidKnowledgeBase", providerID, err)
+		}
+	}
+	if got := s.knowledge.kbs["kb1"].EmbeddingConfig; got != nil {
+		t.Errorf("stored embedding config = %v, want the rejected update not saved", got)
+	}
+}
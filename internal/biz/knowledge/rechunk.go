@@ -0,0 +1,278 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/google/uuid"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+	"github.com/ashwinyue/next-show/internal/pkg/events"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// RechunkRequest 重新分块文档请求，分块参数含义与导入时相同.
+type RechunkRequest struct {
+	SplitterType          SplitterType    `json:"splitter_type,omitempty"`
+	ChunkSize             int             `json:"chunk_size,omitempty"`
+	ChunkOverlap          int             `json:"chunk_overlap,omitempty"`
+	ChunkUnit             ChunkUnit       `json:"chunk_unit,omitempty"`
+	Percentile            float64         `json:"percentile,omitempty"`
+	SemanticOverlap       int             `json:"semantic_overlap,omitempty"`
+	SeparatorPreset       SeparatorPreset `json:"separator_preset,omitempty"`
+	Separators            []string        `json:"separators,omitempty"`
+	PropagateMetadataKeys []string        `json:"propagate_metadata_keys,omitempty"`
+}
+
+func (req *RechunkRequest) splitOptions() *splitOptions {
+	return &splitOptions{
+		splitterType:    req.SplitterType,
+		chunkSize:       req.ChunkSize,
+		chunkOverlap:    req.ChunkOverlap,
+		chunkUnit:       req.ChunkUnit,
+		percentile:      req.Percentile,
+		semanticOverlap: req.SemanticOverlap,
+		separatorPreset: req.SeparatorPreset,
+		separators:      req.Separators,
+	}
+}
+
+// ChunkChange 一个分块的变更.
+type ChunkChange struct {
+	// ChunkID 分块 ID，删除的分块为旧 ID，其余为新 ID
+	ChunkID string `json:"chunk_id"`
+	// PreviousChunkID 被修改的分块替换掉的旧分块 ID
+	PreviousChunkID string `json:"previous_chunk_id,omitempty"`
+	ChunkIndex      int    `json:"chunk_index"`
+	ContentHash     string `json:"content_hash"`
+}
+
+// ChunkDiff 重新分块前后的分块差异，按内容哈希比对.
+// 内容未变的分块保留原 ID、向量和标签；同一序号上内容不同的分块视为修改，其余为新增或删除.
+type ChunkDiff struct {
+	Added     []*ChunkChange `json:"added"`
+	Removed   []*ChunkChange `json:"removed"`
+	Modified  []*ChunkChange `json:"modified"`
+	Unchanged int            `json:"unchanged"`
+}
+
+// RechunkResult 重新分块结果.
+type RechunkResult struct {
+	DocumentID string     `json:"document_id"`
+	ChunkCount int        `json:"chunk_count"`
+	Diff       *ChunkDiff `json:"diff"`
+}
+
+// RechunkDocument 使用新的分块参数重新切分文档并返回分块差异.
+// 内容未变的分块原样保留（包括标签和启用状态），只为新增和修改的分块生成向量.
+func (b *bizImpl) RechunkDocument(ctx context.Context, docID string, req *RechunkRequest) (*RechunkResult, error) {
+	doc, err := b.store.Knowledge().GetDocument(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDocumentNotFound, err)
+	}
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, doc.KnowledgeBaseID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	content, err := b.documentContent(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := b.splitContent(ctx, kb, content, req.splitOptions())
+	if err != nil {
+		return nil, fmt.Errorf("split document: %w", err)
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("no chunks after splitting")
+	}
+
+	oldChunks, err := b.store.Knowledge().ListDocumentChunks(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("list chunks: %w", err)
+	}
+
+	// 按内容哈希匹配新旧分块，相同内容的多个分块按出现顺序一一对应
+	byHash := make(map[string][]*model.KnowledgeChunk, len(oldChunks))
+	for _, c := range oldChunks {
+		hash := c.ContentHash
+		if hash == "" {
+			hash = md5Hash(c.Content)
+		}
+		byHash[hash] = append(byHash[hash], c)
+	}
+
+	diff := &ChunkDiff{}
+	replacement := &store.ChunkReplacement{Keep: make(map[string]int)}
+	var pending []*model.KnowledgeChunk
+	for i, c := range chunks {
+		hash := md5Hash(c.Content)
+		if matches := byHash[hash]; len(matches) > 0 {
+			replacement.Keep[matches[0].ID] = i
+			byHash[hash] = matches[1:]
+			diff.Unchanged++
+			continue
+		}
+		pending = append(pending, &model.KnowledgeChunk{
+			ID:              uuid.New().String(),
+			KnowledgeBaseID: doc.KnowledgeBaseID,
+			DocumentID:      docID,
+			ChunkIndex:      i,
+			Content:         c.Content,
+			ContentHash:     hash,
+			Metadata:        chunkMetadata(c.MetaData, doc.Metadata, req.PropagateMetadataKeys),
+			IsEnabled:       true,
+		})
+	}
+
+	// 未匹配的旧分块：与新分块序号相同的视为被修改，其余为删除
+	removedAt := make(map[int]*model.KnowledgeChunk)
+	for _, c := range oldChunks {
+		if _, kept := replacement.Keep[c.ID]; kept {
+			continue
+		}
+		replacement.Remove = append(replacement.Remove, c.ID)
+		removedAt[c.ChunkIndex] = c
+	}
+	replaced := make(map[string]bool)
+	for _, c := range pending {
+		change := &ChunkChange{ChunkID: c.ID, ChunkIndex: c.ChunkIndex, ContentHash: c.ContentHash}
+		if old, ok := removedAt[c.ChunkIndex]; ok && !replaced[old.ID] {
+			change.PreviousChunkID = old.ID
+			replaced[old.ID] = true
+			diff.Modified = append(diff.Modified, change)
+			continue
+		}
+		diff.Added = append(diff.Added, change)
+	}
+	for _, c := range oldChunks {
+		if _, kept := replacement.Keep[c.ID]; kept || replaced[c.ID] {
+			continue
+		}
+		diff.Removed = append(diff.Removed, &ChunkChange{ChunkID: c.ID, ChunkIndex: c.ChunkIndex, ContentHash: c.ContentHash})
+	}
+
+	if len(pending) > 0 {
+		contents := make([]string, len(pending))
+		for i, c := range pending {
+			contents[i] = c.Content
+		}
+		vectors, err := b.embedInBatches(ctx, contents, nil)
+		if err != nil {
+			return nil, fmt.Errorf("embed chunks: %w", err)
+		}
+		for i, c := range pending {
+			if i >= len(vectors) {
+				break
+			}
+			vec32 := make([]float32, len(vectors[i]))
+			for j, v := range vectors[i] {
+				vec32[j] = float32(v)
+			}
+			replacement.Embeddings = append(replacement.Embeddings, &model.Embedding{
+				KnowledgeBaseID: doc.KnowledgeBaseID,
+				ChunkID:         c.ID,
+				Embedding:       vec32,
+				EmbeddingDim:    len(vec32),
+				EmbeddingModel:  "default",
+			})
+		}
+	}
+	replacement.Chunks = pending
+
+	if err := b.store.Knowledge().ReplaceDocumentChunks(ctx, replacement); err != nil {
+		return nil, fmt.Errorf("replace chunks: %w", err)
+	}
+
+	doc.ContentText = content
+	// 分块变化后缓存的摘要失效
+	doc.Summary, doc.SummaryHash = "", ""
+	if req.SplitterType != SplitterTypeSemantic {
+		if doc.Metadata == nil {
+			doc.Metadata = model.JSONMap{}
+		}
+		doc.Metadata["chunk_unit"] = string(chunkUnitOrDefault(req.ChunkUnit))
+	}
+	if err := b.store.Knowledge().UpdateDocument(ctx, doc); err != nil {
+		return nil, fmt.Errorf("update document: %w", err)
+	}
+	b.publishDocument(events.DocumentUpdated, doc, map[string]any{
+		"chunk_count": len(chunks),
+	})
+
+	return &RechunkResult{
+		DocumentID: docID,
+		ChunkCount: len(chunks),
+		Diff:       diff,
+	}, nil
+}
+
+// documentContent 返回文档全文：优先使用导入时保存的文本，早期导入的文档从原始文件或 URL 重新解析.
+func (b *bizImpl) documentContent(ctx context.Context, doc *model.KnowledgeDocument) (string, error) {
+	if doc.ContentText != "" {
+		return doc.ContentText, nil
+	}
+	src, err := b.readSource(ctx, doc, false)
+	if err != nil {
+		return "", err
+	}
+	return src.content, nil
+}
+
+// documentSource 从原始文件或 URL 读取的文档内容.
+type documentSource struct {
+	content  string
+	fileHash string // 原始文件的 MD5，URL 文档为空
+}
+
+// readSource 从原始文件或 URL 重新读取并解析文档，没有可读取的来源时返回 ErrNoOriginalFile.
+// skipIfHash 为 true 时，原始文件的哈希与 doc.FileHash 相同则不解析，返回的 content 为空.
+func (b *bizImpl) readSource(ctx context.Context, doc *model.KnowledgeDocument, skipIfHash bool) (*documentSource, error) {
+	src := &documentSource{}
+	var docs []*schema.Document
+	switch {
+	case doc.SourceType == model.DocumentSourceTypeFile && doc.SourceURI != "":
+		r, err := b.files.Get(ctx, doc.SourceURI)
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrNoOriginalFile, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get file: %w", err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read file: %w", err)
+		}
+		src.fileHash = md5HashBytes(data)
+		if skipIfHash && src.fileHash == doc.FileHash {
+			return src, nil
+		}
+		docs, err = b.parseFile(ctx, doc.SourceURI, bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("parse file: %w", err)
+		}
+	case doc.SourceType == model.DocumentSourceTypeURL && doc.SourceURI != "":
+		var err error
+		docs, err = b.loadFromURL(ctx, doc.SourceURI)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrNoOriginalFile
+	}
+
+	var sb strings.Builder
+	for _, d := range docs {
+		sb.WriteString(d.Content)
+		sb.WriteString("\n")
+	}
+	src.content = sb.String()
+	return src, nil
+}
@@ -0,0 +1,88 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestRechunkPreservesTagsOfUnchangedChunks(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	paragraphs := []string{
+		"Refund requests within 30 days.",
+		"Shipping takes five work days.",
+		"Support is open on weekdays.",
+	}
+	s.knowledge.docs["d1"] = &model.KnowledgeDocument{
+		ID:              "d1",
+		KnowledgeBaseID: "kb1",
+		ContentText:     paragraphs[0] + "\n\n" + paragraphs[1] + "\n\n" + paragraphs[2],
+	}
+	embedder := &fakeEmbedder{}
+	b := NewBiz(s, embedder, nil, nil, nil).(*bizImpl)
+	req := &RechunkRequest{ChunkSize: 40, ChunkOverlap: 1, Separators: []string{"\n\n"}}
+	ctx := context.Background()
+
+	result, err := b.RechunkDocument(ctx, "d1", req)
+	if err != nil {
+		t.Fatalf("RechunkDocument() error = %v", err)
+	}
+	if result.ChunkCount != len(paragraphs) || len(result.Diff.Added) != len(paragraphs) {
+		t.Fatalf("first rechunk = %d chunks, %d added, want one per paragraph", result.ChunkCount, len(result.Diff.Added))
+	}
+	original := s.knowledge.documentChunks("d1")
+	for _, c := range original {
+		s.knowledge.chunkTags[c.ID] = []string{"tag-faq"}
+	}
+
+	// 内容不变时所有分块保留原 ID 和标签，不重新生成向量
+	embedCalls := len(embedder.calls)
+	result, err = b.RechunkDocument(ctx, "d1", req)
+	if err != nil {
+		t.Fatalf("RechunkDocument() error = %v", err)
+	}
+	if result.Diff.Unchanged != len(paragraphs) || len(result.Diff.Added)+len(result.Diff.Modified)+len(result.Diff.Removed) != 0 {
+		t.Errorf("unchanged rechunk diff = %+v, want all unchanged", result.Diff)
+	}
+	if len(embedder.calls) != embedCalls {
+		t.Errorf("embed calls = %d, want %d (nothing to embed)", len(embedder.calls), embedCalls)
+	}
+	for i, c := range s.knowledge.documentChunks("d1") {
+		if c.ID != original[i].ID {
+			t.Errorf("chunk %d id = %s, want %s kept", i, c.ID, original[i].ID)
+		}
+		if tags := s.knowledge.chunkTags[c.ID]; len(tags) != 1 || tags[0] != "tag-faq" {
+			t.Errorf("chunk %d tags = %v, want [tag-faq]", i, tags)
+		}
+	}
+
+	// 只修改中间一段：其余分块保留标签，被修改的分块换成新 ID，旧分块的标签随之删除
+	s.knowledge.docs["d1"].ContentText = paragraphs[0] + "\n\nShipping takes ten work days.\n\n" + paragraphs[2]
+	result, err = b.RechunkDocument(ctx, "d1", req)
+	if err != nil {
+		t.Fatalf("RechunkDocument() error = %v", err)
+	}
+	if result.Diff.Unchanged != 2 || len(result.Diff.Modified) != 1 || len(result.Diff.Added) != 0 || len(result.Diff.Removed) != 0 {
+		t.Fatalf("modified rechunk diff = %+v, want 2 unchanged and 1 modified", result.Diff)
+	}
+	if got := result.Diff.Modified[0].PreviousChunkID; got != original[1].ID {
+		t.Errorf("modified previous chunk = %s, want %s", got, original[1].ID)
+	}
+	chunks := s.knowledge.documentChunks("d1")
+	for _, i := range []int{0, 2} {
+		if chunks[i].ID != original[i].ID || len(s.knowledge.chunkTags[chunks[i].ID]) != 1 {
+			t.Errorf("unchanged chunk %d = %s tags %v, want %s tagged", i, chunks[i].ID, s.knowledge.chunkTags[chunks[i].ID], original[i].ID)
+		}
+	}
+	if len(s.knowledge.chunkTags[chunks[1].ID]) != 0 {
+		t.Errorf("modified chunk tags = %v, want none", s.knowledge.chunkTags[chunks[1].ID])
+	}
+	if _, ok := s.knowledge.chunkTags[original[1].ID]; ok {
+		t.Errorf("tags of replaced chunk %s not deleted", original[1].ID)
+	}
+	if _, ok := s.knowledge.embeddings[chunks[1].ID]; !ok {
+		t.Errorf("modified chunk has no embedding")
+	}
+}
@@ -0,0 +1,164 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// ErrNoDocuments 未指定需要处理的文档.
+var ErrNoDocuments = errs.New(errs.ErrValidation, "document_ids is required")
+
+// ReembedRequest 重新生成指定文档向量的请求.
+type ReembedRequest struct {
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// ReembedDocuments 在后台为指定文档的现有分块重新生成向量，不重新分块，进度通过 GetReindexJob 查询.
+// 与知识库的重建任务互斥. 每个文档处理时重新读取分块，任务创建后分块数变化（重新分块、删除分块）时按最新分块生成，
+// 并相应调整任务的总分块数.
+func (b *bizImpl) ReembedDocuments(ctx context.Context, kbID string, documentIDs []string) (*model.ReindexJob, error) {
+	if len(documentIDs) == 0 {
+		return nil, ErrNoDocuments
+	}
+	if b.embedder == nil {
+		return nil, fmt.Errorf("%w: embedder not configured", ErrInvalidKnowledgeBase)
+	}
+	if _, err := b.store.Knowledge().GetKnowledgeBase(ctx, kbID); err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+
+	var total int64
+	counts := make(map[string]int64, len(documentIDs))
+	for _, id := range documentIDs {
+		doc, err := b.store.Knowledge().GetDocument(ctx, id)
+		if err != nil || doc.KnowledgeBaseID != kbID {
+			return nil, fmt.Errorf("%w: %s", ErrDocumentNotFound, id)
+		}
+		_, count, err := b.store.Knowledge().ListChunksByDocument(ctx, id, 1, 0)
+		if err != nil {
+			return nil, fmt.Errorf("count chunks: %w", err)
+		}
+		counts[id] = count
+		total += count
+	}
+
+	job := &model.ReindexJob{
+		ID:              uuid.New().String(),
+		KnowledgeBaseID: kbID,
+		ReEmbed:         true,
+		DocumentIDs:     documentIDs,
+		Status:          model.ReindexStatusRunning,
+		Stage:           model.ReindexStageEmbedding,
+		TotalChunks:     total,
+		StartedAt:       time.Now(),
+	}
+	if err := b.reindexJobs.start(ctx, job); err != nil {
+		return nil, err
+	}
+	snapshot := *job
+
+	go b.runReembed(context.Background(), job.ID, documentIDs, counts)
+
+	return &snapshot, nil
+}
+
+// runReembed 逐个文档重新生成向量并记录结果，counts 为任务创建时各文档的分块数.
+func (b *bizImpl) runReembed(ctx context.Context, jobID string, documentIDs []string, counts map[string]int64) {
+	var failures []error
+	for _, id := range documentIDs {
+		if err := b.reembedDocument(ctx, jobID, id, counts[id]); err != nil {
+			failures = append(failures, fmt.Errorf("document %s: %w", id, err))
+		}
+	}
+	err := errors.Join(failures...)
+
+	now := time.Now()
+	b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+		job.FinishedAt = &now
+		if err != nil {
+			job.Status = model.ReindexStatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = model.ReindexStatusCompleted
+		job.Stage = model.ReindexStageDone
+	})
+	if err != nil {
+		log.Printf("re-embed documents failed: %v", err)
+	}
+}
+
+// reembedDocument 为文档当前的全部分块重新生成向量并覆盖写入.
+// 因部分向量生成失败而标记为失败的文档，全部生成成功后恢复为已解析.
+func (b *bizImpl) reembedDocument(ctx context.Context, jobID, docID string, counted int64) error {
+	doc, err := b.store.Knowledge().GetDocument(ctx, docID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDocumentNotFound, err)
+	}
+	chunks, err := b.store.Knowledge().ListDocumentChunks(ctx, docID)
+	if err != nil {
+		return fmt.Errorf("list chunks: %w", err)
+	}
+	b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+		// 任务创建时按当时的分块数计算了总数，这里按最新分块数修正
+		job.TotalChunks += int64(len(chunks)) - counted
+	})
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	contents := make([]string, len(chunks))
+	for i, c := range chunks {
+		contents[i] = c.Content
+	}
+	vectors, embedErr := b.embedInBatches(ctx, contents, func(n int) {
+		b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+			job.ProcessedChunks += int64(n)
+		})
+	})
+	var batchErr *EmbeddingBatchError
+	if embedErr != nil && !errors.As(embedErr, &batchErr) {
+		return fmt.Errorf("embed chunks: %w", embedErr)
+	}
+
+	embeddings := make([]*model.Embedding, 0, len(chunks))
+	for i, c := range chunks {
+		if i >= len(vectors) || vectors[i] == nil {
+			continue
+		}
+		vec32 := make([]float32, len(vectors[i]))
+		for j, v := range vectors[i] {
+			vec32[j] = float32(v)
+		}
+		embeddings = append(embeddings, &model.Embedding{
+			KnowledgeBaseID: doc.KnowledgeBaseID,
+			ChunkID:         c.ID,
+			Embedding:       vec32,
+			EmbeddingDim:    len(vec32),
+			EmbeddingModel:  "default",
+		})
+	}
+	if err := b.store.Knowledge().CreateEmbeddings(ctx, embeddings); err != nil {
+		return fmt.Errorf("save embeddings: %w", err)
+	}
+	if batchErr != nil {
+		return batchErr
+	}
+
+	if doc.ParseStatus == model.DocumentParseStatusFailed {
+		doc.ParseStatus = model.DocumentParseStatusParsed
+		doc.ErrorMessage = ""
+		if err := b.store.Knowledge().UpdateDocument(ctx, doc); err != nil {
+			return fmt.Errorf("update document status: %w", err)
+		}
+	}
+	return nil
+}
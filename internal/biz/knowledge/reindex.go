@@ -0,0 +1,352 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// reindexBatchSize 重新生成向量时每批处理的分块数.
+const reindexBatchSize = 100
+
+var (
+	// ErrReindexInProgress 知识库已有正在运行的重建任务.
+	ErrReindexInProgress = errs.New(errs.ErrConflict, "reindex already in progress")
+	// ErrReindexJobNotFound 重建任务不存在.
+	ErrReindexJobNotFound = errs.New(errs.ErrNotFound, "reindex job not found")
+	// ErrEmptyReindexRequest 重建请求没有选择任何阶段.
+	ErrEmptyReindexRequest = errs.New(errs.ErrValidation, "one of re_embed, refresh or rebuild_indexes is required")
+	// ErrIndexRebuildTooSoon 距上次重建全局索引的时间不足重建间隔，或上次重建仍在运行.
+	ErrIndexRebuildTooSoon = errs.New(errs.ErrConflict, "search indexes were rebuilt too recently")
+)
+
+// defaultIndexRebuildInterval 两次重建全局索引的最小间隔.
+const defaultIndexRebuildInterval = time.Hour
+
+// interruptedReindexMessage 因服务重启中断的重建任务的错误信息.
+const interruptedReindexMessage = "reindex interrupted by server restart"
+
+// ReindexRequest 重建搜索索引请求.
+type ReindexRequest struct {
+	// ReEmbed 是否使用当前 Embedding 模型重新生成所有分块的向量
+	ReEmbed bool `json:"re_embed"`
+	// Refresh 先从原始文件或 URL 重新读取文档，只有内容（文件哈希或文本哈希）变化的文档重新分块并生成向量
+	Refresh bool `json:"refresh"`
+	// RebuildIndexes 最后重建全文和向量索引. 索引建在整张表上，重建是覆盖所有知识库的全局操作，
+	// 距上次重建不足 IndexRebuildInterval 时拒绝
+	RebuildIndexes bool `json:"rebuild_indexes"`
+}
+
+// reindexJobs 重建任务记录：运行中的任务在内存中更新进度，每次更新后写入数据库，结束后只保存在数据库中.
+type reindexJobs struct {
+	store store.Store
+	// mu 保护 jobs，并使同一任务的进度按更新顺序写入
+	mu   sync.Mutex
+	jobs map[string]*model.ReindexJob
+}
+
+func newReindexJobs(s store.Store) *reindexJobs {
+	return &reindexJobs{store: s, jobs: make(map[string]*model.ReindexJob)}
+}
+
+// start 登记新任务，同一知识库已有运行中的任务时返回 ErrReindexInProgress.
+func (r *reindexJobs) start(ctx context.Context, job *model.ReindexJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	started, err := r.store.Knowledge().StartReindexJob(ctx, job)
+	if err != nil {
+		return fmt.Errorf("save reindex job: %w", err)
+	}
+	if !started {
+		return fmt.Errorf("%w: knowledge base %s", ErrReindexInProgress, job.KnowledgeBaseID)
+	}
+	r.jobs[job.ID] = job
+	return nil
+}
+
+// update 在锁内修改任务并写入数据库，任务结束（Status 不再是 running）后从内存中移除.
+func (r *reindexJobs) update(id string, fn func(job *model.ReindexJob)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return
+	}
+	fn(job)
+	if job.Status != model.ReindexStatusRunning {
+		delete(r.jobs, id)
+	}
+	// 任务在后台运行，写入不受请求 ctx 影响
+	if err := r.store.Knowledge().UpdateReindexJob(context.Background(), job); err != nil {
+		log.Printf("save reindex job %s failed: %v", id, err)
+	}
+}
+
+// get 返回任务快照，不在内存中的任务从数据库读取.
+func (r *reindexJobs) get(ctx context.Context, id string) (*model.ReindexJob, error) {
+	r.mu.Lock()
+	job, ok := r.jobs[id]
+	if ok {
+		snapshot := *job
+		r.mu.Unlock()
+		return &snapshot, nil
+	}
+	r.mu.Unlock()
+
+	job, err := r.store.Knowledge().GetReindexJob(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReindexJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get reindex job: %w", err)
+	}
+	return job, nil
+}
+
+// StartReindex 在后台重建知识库的搜索数据：可选地重新读取来源、重新生成向量，最后可选地并发重建全文和向量索引.
+// 向量按分块逐批覆盖写入，索引通过 REINDEX CONCURRENTLY 构建后替换，整个过程不影响检索.
+// 索引重建作用于所有知识库，距上次重建不足 IndexRebuildInterval 时返回 ErrIndexRebuildTooSoon.
+func (b *bizImpl) StartReindex(ctx context.Context, kbID string, req *ReindexRequest) (*model.ReindexJob, error) {
+	if !req.ReEmbed && !req.Refresh && !req.RebuildIndexes {
+		return nil, ErrEmptyReindexRequest
+	}
+	if _, err := b.store.Knowledge().GetKnowledgeBase(ctx, kbID); err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	if req.ReEmbed && b.embedder == nil {
+		return nil, fmt.Errorf("%w: embedder not configured", ErrInvalidKnowledgeBase)
+	}
+
+	job := &model.ReindexJob{
+		ID:              uuid.New().String(),
+		KnowledgeBaseID: kbID,
+		ReEmbed:         req.ReEmbed,
+		Refresh:         req.Refresh,
+		RebuildIndexes:  req.RebuildIndexes,
+		Status:          model.ReindexStatusRunning,
+		Stage:           model.ReindexStageIndexing,
+		StartedAt:       time.Now(),
+	}
+	switch {
+	case req.Refresh:
+		job.Stage = model.ReindexStageRefreshing
+	case req.ReEmbed:
+		job.Stage = model.ReindexStageEmbedding
+	}
+
+	// 检查重建间隔和登记任务在同一把锁内完成，避免并发请求同时通过检查
+	b.indexRebuildMu.Lock()
+	defer b.indexRebuildMu.Unlock()
+	if req.RebuildIndexes {
+		last, err := b.store.Knowledge().LatestIndexRebuild(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get latest index rebuild: %w", err)
+		}
+		if last != nil && (last.Status == model.ReindexStatusRunning || time.Since(last.StartedAt) < b.indexRebuildInterval) {
+			return nil, fmt.Errorf("%w: last rebuild started at %s (job %s), interval is %s",
+				ErrIndexRebuildTooSoon, last.StartedAt.Format(time.RFC3339), last.ID, b.indexRebuildInterval)
+		}
+	}
+	if err := b.reindexJobs.start(ctx, job); err != nil {
+		return nil, err
+	}
+	snapshot := *job
+
+	go b.runReindex(context.Background(), job.ID, kbID, *req)
+
+	return &snapshot, nil
+}
+
+// GetReindexJob 获取重建任务进度，已结束的任务在服务重启后仍可查询.
+func (b *bizImpl) GetReindexJob(ctx context.Context, jobID string) (*model.ReindexJob, error) {
+	return b.reindexJobs.get(ctx, jobID)
+}
+
+// RecoverInterruptedReindexJobs 将重启前未完成的重建任务标记为失败，返回标记的任务数.
+// 任务进度只在执行它的进程中推进，重启后不会继续执行，可以重新发起.
+func (b *bizImpl) RecoverInterruptedReindexJobs(ctx context.Context) (int64, error) {
+	return b.store.Knowledge().FailRunningReindexJobs(ctx, interruptedReindexMessage)
+}
+
+// runReindex 执行重建任务并记录结果.
+func (b *bizImpl) runReindex(ctx context.Context, jobID, kbID string, req ReindexRequest) {
+	err := b.reindex(ctx, jobID, kbID, req)
+
+	now := time.Now()
+	b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+		job.FinishedAt = &now
+		if err != nil {
+			job.Status = model.ReindexStatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = model.ReindexStatusCompleted
+		job.Stage = model.ReindexStageDone
+	})
+	if err != nil {
+		log.Printf("reindex knowledge base %s failed: %v", kbID, err)
+	}
+}
+
+func (b *bizImpl) reindex(ctx context.Context, jobID, kbID string, req ReindexRequest) error {
+	if req.Refresh {
+		if err := b.refreshDocuments(ctx, jobID, kbID); err != nil {
+			return err
+		}
+		b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+			job.Stage = model.ReindexStageIndexing
+			if req.ReEmbed {
+				job.Stage = model.ReindexStageEmbedding
+			}
+		})
+	}
+	if req.ReEmbed {
+		if err := b.reEmbedChunks(ctx, jobID, kbID); err != nil {
+			return err
+		}
+		b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+			job.Stage = model.ReindexStageIndexing
+		})
+	}
+	if !req.RebuildIndexes {
+		return nil
+	}
+
+	// 全文检索基于 content 实时计算 tsvector，重建索引即可反映新的检索配置
+	if err := b.store.Knowledge().RebuildSearchIndexes(ctx); err != nil {
+		return fmt.Errorf("rebuild search indexes: %w", err)
+	}
+	return nil
+}
+
+// reEmbedChunks 按 ID 顺序分批重新生成分块向量，覆盖已有向量.
+func (b *bizImpl) reEmbedChunks(ctx context.Context, jobID, kbID string) error {
+	_, total, err := b.store.Knowledge().ListChunksByKnowledgeBase(ctx, kbID, 1, 0)
+	if err != nil {
+		return fmt.Errorf("count chunks: %w", err)
+	}
+	b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+		job.TotalChunks = total
+	})
+
+	afterID := ""
+	for {
+		chunks, err := b.store.Knowledge().ListChunksAfter(ctx, kbID, afterID, reindexBatchSize)
+		if err != nil {
+			return fmt.Errorf("list chunks: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+
+		contents := make([]string, len(chunks))
+		for i, c := range chunks {
+			contents[i] = c.Content
+		}
+		vectors, err := b.embedder.EmbedStrings(ctx, contents)
+		if err != nil {
+			return fmt.Errorf("embed chunks: %w", err)
+		}
+
+		embeddings := make([]*model.Embedding, 0, len(chunks))
+		for i, c := range chunks {
+			if i >= len(vectors) {
+				break
+			}
+			vec32 := make([]float32, len(vectors[i]))
+			for j, v := range vectors[i] {
+				vec32[j] = float32(v)
+			}
+			embeddings = append(embeddings, &model.Embedding{
+				KnowledgeBaseID: kbID,
+				ChunkID:         c.ID,
+				Embedding:       vec32,
+				EmbeddingDim:    len(vec32),
+				EmbeddingModel:  "default",
+			})
+		}
+		if err := b.store.Knowledge().CreateEmbeddings(ctx, embeddings); err != nil {
+			return fmt.Errorf("save embeddings: %w", err)
+		}
+
+		afterID = chunks[len(chunks)-1].ID
+		b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+			job.ProcessedChunks += int64(len(chunks))
+		})
+	}
+}
+
+// refreshDocuments 按 ID 顺序逐个从来源重新读取文档，内容变化的文档以新内容重新分块（未变化的分块保留向量），
+// 未变化或没有可读取来源（文本、外部连接器同步的文档）的文档跳过，单个文档失败时记录并继续.
+func (b *bizImpl) refreshDocuments(ctx context.Context, jobID, kbID string) error {
+	afterID := ""
+	for {
+		docs, err := b.store.Knowledge().ListDocumentsAfter(ctx, kbID, afterID, reindexBatchSize)
+		if err != nil {
+			return fmt.Errorf("list documents: %w", err)
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		for _, doc := range docs {
+			reprocessed, err := b.refreshDocument(ctx, doc)
+			if err != nil {
+				log.Printf("refresh document %s failed: %v", doc.ID, err)
+			}
+			b.reindexJobs.update(jobID, func(job *model.ReindexJob) {
+				switch {
+				case err != nil:
+					job.FailedDocuments++
+				case reprocessed:
+					job.ReprocessedDocuments++
+				default:
+					job.SkippedDocuments++
+				}
+			})
+		}
+		afterID = docs[len(docs)-1].ID
+	}
+}
+
+// refreshDocument 比较来源的当前内容与已导入的内容，变化时更新文档内容并重新分块，返回是否重新处理.
+func (b *bizImpl) refreshDocument(ctx context.Context, doc *model.KnowledgeDocument) (bool, error) {
+	if doc.ParseStatus != model.DocumentParseStatusParsed {
+		return false, nil
+	}
+	src, err := b.readSource(ctx, doc, true)
+	if errors.Is(err, ErrNoOriginalFile) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if src.fileHash != "" && src.fileHash == doc.FileHash {
+		return false, nil
+	}
+	if doc.ContentText != "" && md5Hash(src.content) == md5Hash(doc.ContentText) {
+		return false, nil
+	}
+
+	doc.ContentText = src.content
+	if src.fileHash != "" {
+		doc.FileHash = src.fileHash
+	}
+	if err := b.store.Knowledge().UpdateDocument(ctx, doc); err != nil {
+		return false, fmt.Errorf("update document: %w", err)
+	}
+	unit, _ := doc.Metadata["chunk_unit"].(string)
+	if _, err := b.RechunkDocument(ctx, doc.ID, &RechunkRequest{ChunkUnit: ChunkUnit(unit)}); err != nil {
+		return false, fmt.Errorf("rechunk: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,186 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// waitReindexJob 轮询直到任务结束.
+func waitReindexJob(t *testing.T, b *bizImpl, id string) *model.ReindexJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := b.GetReindexJob(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetReindexJob: %v", err)
+		}
+		if job.Status != model.ReindexStatusRunning {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("reindex job %s did not finish", id)
+	return nil
+}
+
+func TestReindexJobPersistedAcrossRestart(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	ctx := context.Background()
+
+	b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+	job, err := b.StartReindex(ctx, "kb1", &ReindexRequest{RebuildIndexes: true})
+	if err != nil {
+		t.Fatalf("StartReindex: %v", err)
+	}
+	waitReindexJob(t, b, job.ID)
+
+	// 新实例（模拟重启）只能从存储读取任务
+	restarted := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+	got, err := restarted.GetReindexJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetReindexJob after restart: %v", err)
+	}
+	if got.Status != model.ReindexStatusCompleted || got.Stage != model.ReindexStageDone || got.FinishedAt == nil {
+		t.Fatalf("job after restart = %+v, want completed", got)
+	}
+	if _, err := restarted.GetReindexJob(ctx, "missing"); !errors.Is(err, ErrReindexJobNotFound) {
+		t.Fatalf("GetReindexJob(missing) err = %v, want ErrReindexJobNotFound", err)
+	}
+}
+
+func TestRecoverInterruptedReindexJobs(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.reindexJobs["running"] = &model.ReindexJob{ID: "running", KnowledgeBaseID: "kb1", Status: model.ReindexStatusRunning}
+	s.knowledge.reindexJobs["done"] = &model.ReindexJob{ID: "done", KnowledgeBaseID: "kb1", Status: model.ReindexStatusCompleted}
+	b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+
+	n, err := b.RecoverInterruptedReindexJobs(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("RecoverInterruptedReindexJobs = %d, %v, want 1", n, err)
+	}
+	job, _ := b.GetReindexJob(context.Background(), "running")
+	if job.Status != model.ReindexStatusFailed || job.Error != interruptedReindexMessage {
+		t.Fatalf("interrupted job = %+v, want failed with restart message", job)
+	}
+
+	// 中断的任务不再阻止同一知识库发起新任务
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	if _, err := b.StartReindex(context.Background(), "kb1", &ReindexRequest{RebuildIndexes: true}); err != nil {
+		t.Fatalf("StartReindex after recovery: %v", err)
+	}
+}
+
+func TestIndexRebuildRateLimited(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	s.knowledge.kbs["kb2"] = &model.KnowledgeBase{ID: "kb2"}
+	ctx := context.Background()
+	b := NewBiz(s, nil, nil, nil, &BizConfig{IndexRebuildInterval: time.Hour}).(*bizImpl)
+
+	if _, err := b.StartReindex(ctx, "kb1", &ReindexRequest{}); !errors.Is(err, ErrEmptyReindexRequest) {
+		t.Fatalf("empty request err = %v, want ErrEmptyReindexRequest", err)
+	}
+
+	first, err := b.StartReindex(ctx, "kb1", &ReindexRequest{RebuildIndexes: true})
+	if err != nil {
+		t.Fatalf("first rebuild: %v", err)
+	}
+	waitReindexJob(t, b, first.ID)
+
+	// 索引是全局的，其他知识库在间隔内也不能再次重建
+	if _, err := b.StartReindex(ctx, "kb2", &ReindexRequest{RebuildIndexes: true}); !errors.Is(err, ErrIndexRebuildTooSoon) {
+		t.Fatalf("second rebuild err = %v, want ErrIndexRebuildTooSoon", err)
+	}
+	s.knowledge.reindexMu.Lock()
+	rebuilds := s.knowledge.indexRebuilds
+	s.knowledge.reindexMu.Unlock()
+	if rebuilds != 1 {
+		t.Fatalf("index rebuilds = %d, want 1", rebuilds)
+	}
+
+	// 间隔过后允许重建
+	s.knowledge.reindexMu.Lock()
+	s.knowledge.reindexJobs[first.ID].StartedAt = time.Now().Add(-2 * time.Hour)
+	s.knowledge.reindexMu.Unlock()
+	second, err := b.StartReindex(ctx, "kb2", &ReindexRequest{RebuildIndexes: true})
+	if err != nil {
+		t.Fatalf("rebuild after interval: %v", err)
+	}
+	waitReindexJob(t, b, second.ID)
+}
+
+func TestRefreshReindexSkipsUnchangedDocuments(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	files := newFakeBlobStore()
+	errUnavailable := errors.New("storage unavailable")
+	sources := map[string]string{
+		"d1": "Refund requests within 30 days.",
+		"d2": "Shipping takes five work days.",
+		"d3": "Support is open on weekdays.",
+		"d4": "Invoices are sent monthly.",
+	}
+	for id, content := range sources {
+		key := "kb1/" + id + "/doc.txt"
+		files.objects[key] = content
+		s.knowledge.docs[id] = &model.KnowledgeDocument{
+			ID:              id,
+			KnowledgeBaseID: "kb1",
+			SourceType:      model.DocumentSourceTypeFile,
+			SourceURI:       key,
+			FileHash:        md5HashBytes([]byte(content)),
+			ContentText:     content,
+			ParseStatus:     model.DocumentParseStatusParsed,
+		}
+	}
+	// 只有 d2 的原始文件变化；d4 的来源读取失败；没有来源的文本文档跳过
+	files.objects["kb1/d2/doc.txt"] = "Shipping takes ten work days."
+	files.errs["kb1/d4/doc.txt"] = errUnavailable
+	s.knowledge.docs["d5"] = &model.KnowledgeDocument{
+		ID:              "d5",
+		KnowledgeBaseID: "kb1",
+		SourceType:      model.DocumentSourceTypeText,
+		ContentText:     "Pasted note.",
+		ParseStatus:     model.DocumentParseStatusParsed,
+	}
+
+	embedder := &fakeEmbedder{}
+	b := NewBiz(s, embedder, nil, files, nil).(*bizImpl)
+	job, err := b.StartReindex(context.Background(), "kb1", &ReindexRequest{Refresh: true})
+	if err != nil {
+		t.Fatalf("StartReindex: %v", err)
+	}
+	job = waitReindexJob(t, b, job.ID)
+
+	if job.Status != model.ReindexStatusCompleted {
+		t.Fatalf("job = %+v, want completed", job)
+	}
+	if job.ReprocessedDocuments != 1 || job.SkippedDocuments != 3 || job.FailedDocuments != 1 {
+		t.Errorf("documents reprocessed/skipped/failed = %d/%d/%d, want 1/3/1",
+			job.ReprocessedDocuments, job.SkippedDocuments, job.FailedDocuments)
+	}
+
+	// 只有变化的文档重新分块并生成向量
+	var embedded []string
+	for _, call := range embedder.calls {
+		embedded = append(embedded, call...)
+	}
+	if len(embedded) != 1 || !strings.Contains(embedded[0], "ten work days") {
+		t.Errorf("embedded texts = %q, want only the changed document", embedded)
+	}
+	d2 := s.knowledge.docs["d2"]
+	if !strings.Contains(d2.ContentText, "ten work days") || d2.FileHash != md5HashBytes([]byte("Shipping takes ten work days.")) {
+		t.Errorf("refreshed document = %q (hash %s), want new content and hash", d2.ContentText, d2.FileHash)
+	}
+	for _, id := range []string{"d1", "d3", "d5"} {
+		if chunks := s.knowledge.documentChunks(id); len(chunks) != 0 {
+			t.Errorf("unchanged document %s was rechunked into %d chunks", id, len(chunks))
+		}
+	}
+}
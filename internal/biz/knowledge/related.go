@@ -0,0 +1,102 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+const (
+	// defaultRelatedTopK 相关文档默认返回数量.
+	defaultRelatedTopK = 5
+	// maxRelatedTopK 相关文档最大返回数量.
+	maxRelatedTopK = 50
+	// relatedChunkFactor 每个相关文档预取的分块数，同一文档的多个分块可能同时命中.
+	relatedChunkFactor = 10
+)
+
+// RelatedDocument 相关文档.
+type RelatedDocument struct {
+	DocumentID string `json:"document_id"`
+	Title      string `json:"title"`
+	// Score 文档中与源文档向量中心最相似的分块的分数
+	Score float64 `json:"score"`
+	// MatchedChunks 命中的分块数
+	MatchedChunks int `json:"matched_chunks"`
+}
+
+// GetRelatedDocuments 查找同一知识库中与文档内容相近的其他文档.
+// 以文档所有分块向量的平均值作为文档向量检索最相近的分块，按所属文档去重，取各文档的最高分排序.
+// 文档没有向量时返回空列表.
+func (b *bizImpl) GetRelatedDocuments(ctx context.Context, docID string, topK int) ([]*RelatedDocument, error) {
+	if topK <= 0 {
+		topK = defaultRelatedTopK
+	}
+	if topK > maxRelatedTopK {
+		topK = maxRelatedTopK
+	}
+
+	doc, err := b.store.Knowledge().GetDocument(ctx, docID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDocumentNotFound, err)
+	}
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, doc.KnowledgeBaseID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	distance, err := searchDistanceFunction(kb, "")
+	if err != nil {
+		return nil, err
+	}
+
+	centroid, err := b.store.Knowledge().DocumentCentroid(ctx, doc.ID)
+	if err != nil {
+		return nil, fmt.Errorf("document centroid: %w", err)
+	}
+	if len(centroid) == 0 {
+		return []*RelatedDocument{}, nil
+	}
+
+	chunks, err := b.store.Knowledge().SearchChunksByVectorWithOptions(ctx, []string{doc.KnowledgeBaseID}, centroid, topK*relatedChunkFactor, store.SearchOptions{
+		DistanceFunction:   distance,
+		ExcludeDocumentIDs: []string{doc.ID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search related chunks: %w", err)
+	}
+
+	byDoc := make(map[string]*RelatedDocument)
+	for _, c := range chunks {
+		related, ok := byDoc[c.Chunk.DocumentID]
+		if !ok {
+			related = &RelatedDocument{DocumentID: c.Chunk.DocumentID, Score: c.Score}
+			byDoc[c.Chunk.DocumentID] = related
+		}
+		if c.Score > related.Score {
+			related.Score = c.Score
+		}
+		related.MatchedChunks++
+	}
+
+	results := make([]*RelatedDocument, 0, len(byDoc))
+	for _, related := range byDoc {
+		results = append(results, related)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].DocumentID < results[j].DocumentID
+	})
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	for _, related := range results {
+		if d, err := b.store.Knowledge().GetDocument(ctx, related.DocumentID); err == nil && d != nil {
+			related.Title = d.Title
+		}
+	}
+	return results, nil
+}
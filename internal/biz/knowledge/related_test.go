@@ -0,0 +1,63 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// addEmbeddedDocument 添加一个文档，每个向量对应一个启用的分块.
+func addEmbeddedDocument(s *fakeStore, kbID, docID, title string, vectors ...[]float32) {
+	s.knowledge.docs[docID] = &model.KnowledgeDocument{ID: docID, KnowledgeBaseID: kbID, Title: title}
+	for i, v := range vectors {
+		id := fmt.Sprintf("%s-c%d", docID, i)
+		s.knowledge.chunks[id] = &model.KnowledgeChunk{ID: id, KnowledgeBaseID: kbID, DocumentID: docID, ChunkIndex: i, IsEnabled: true}
+		s.knowledge.embeddings[id] = &model.Embedding{ChunkID: id, KnowledgeBaseID: kbID, Embedding: v, EmbeddingDim: len(v)}
+	}
+}
+
+func TestGetRelatedDocuments(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	s.knowledge.kbs["kb2"] = &model.KnowledgeBase{ID: "kb2"}
+	// 源文档的向量中心为 (1, 1, 0)
+	addEmbeddedDocument(s, "kb1", "refund", "退款政策", []float32{1, 0, 0}, []float32{1, 2, 0})
+	addEmbeddedDocument(s, "kb1", "returns", "退货流程", []float32{1, 1, 0}, []float32{2, 1, 0})
+	addEmbeddedDocument(s, "kb1", "shipping", "配送说明", []float32{0, 1, 1})
+	addEmbeddedDocument(s, "kb1", "careers", "招聘", []float32{0, 0, 1})
+	addEmbeddedDocument(s, "kb1", "draft", "草稿")
+	// 其他知识库中内容相同的文档不返回
+	addEmbeddedDocument(s, "kb2", "copy", "退款政策副本", []float32{1, 1, 0})
+	b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+	ctx := context.Background()
+
+	related, err := b.GetRelatedDocuments(ctx, "refund", 0)
+	if err != nil {
+		t.Fatalf("GetRelatedDocuments: %v", err)
+	}
+	var got []string
+	for _, r := range related {
+		got = append(got, r.DocumentID)
+	}
+	if fmt.Sprint(got) != "[returns shipping careers]" {
+		t.Fatalf("related documents = %v, want [returns shipping careers] (source and other kb excluded)", got)
+	}
+	if related[0].Title != "退货流程" || related[0].MatchedChunks != 2 || math.Abs(related[0].Score-1) > 1e-6 {
+		t.Errorf("top related document = %+v, want 退货流程 with 2 matched chunks and score 1", related[0])
+	}
+
+	// topK 截断，文档没有向量时返回空列表
+	if top, err := b.GetRelatedDocuments(ctx, "refund", 1); err != nil || len(top) != 1 || top[0].DocumentID != "returns" {
+		t.Errorf("GetRelatedDocuments(topK=1) = %v, %v, want only returns", top, err)
+	}
+	if none, err := b.GetRelatedDocuments(ctx, "draft", 0); err != nil || none == nil || len(none) != 0 {
+		t.Errorf("GetRelatedDocuments(no embeddings) = %v, %v, want empty list", none, err)
+	}
+	if _, err := b.GetRelatedDocuments(ctx, "missing", 0); !errors.Is(err, ErrDocumentNotFound) {
+		t.Errorf("GetRelatedDocuments(missing) error = %v, want ErrDocumentNotFound", err)
+	}
+}
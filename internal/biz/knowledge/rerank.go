@@ -0,0 +1,61 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino-ext/components/document/transformer/reranker/score"
+	"github.com/cloudwego/eino/schema"
+)
+
+// rerankDocuments 使用 score reranker 重排序（高分放首尾，利用 LLM 首尾效应）.
+// 文档需已通过 WithScore 设置检索分数.
+func rerankDocuments(ctx context.Context, docs []*schema.Document) ([]*schema.Document, error) {
+	reranker, err := score.NewReranker(ctx, &score.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("create reranker: %w", err)
+	}
+	reranked, err := reranker.Transform(ctx, docs)
+	if err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+	return reranked, nil
+}
+
+// rerankSearchResults 重排序检索结果，并在每个结果上记录重排序前后的分数和名次.
+// 重排序失败时返回原结果.
+func rerankSearchResults(ctx context.Context, chunks []*ChunkSearchResult) []*ChunkSearchResult {
+	if len(chunks) <= 1 {
+		return chunks
+	}
+
+	docs := make([]*schema.Document, len(chunks))
+	byID := make(map[string]*ChunkSearchResult, len(chunks))
+	for i, c := range chunks {
+		docs[i] = (&schema.Document{ID: c.ID, Content: c.Content}).WithScore(c.Score)
+		byID[c.ID] = c
+	}
+	reranked, err := rerankDocuments(ctx, docs)
+	if err != nil {
+		return chunks
+	}
+
+	rank := make(map[string]int, len(chunks))
+	for i, c := range chunks {
+		rank[c.ID] = i + 1
+	}
+	result := make([]*ChunkSearchResult, 0, len(reranked))
+	for _, doc := range reranked {
+		c, ok := byID[doc.ID]
+		if !ok {
+			continue
+		}
+		preScore, rerankScore := c.Score, doc.Score()
+		c.PreRerankScore = &preScore
+		c.PreRerankRank = rank[c.ID]
+		c.RerankScore = &rerankScore
+		c.Score = rerankScore
+		result = append(result, c)
+	}
+	return result
+}
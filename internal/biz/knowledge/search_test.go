@@ -0,0 +1,98 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+func TestHybridSearchRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     HybridSearchRequest
+		wantErr bool
+	}{
+		{name: "defaults", req: HybridSearchRequest{Query: "q"}},
+		{name: "explicit weights", req: HybridSearchRequest{Query: "q", VectorWeight: 0.5, BM25Weight: 0.5}},
+		{name: "l2", req: HybridSearchRequest{Query: "q", DistanceFunction: "l2"}},
+		{name: "ip", req: HybridSearchRequest{Query: "q", DistanceFunction: "ip"}},
+		{name: "negative vector weight", req: HybridSearchRequest{Query: "q", VectorWeight: -0.1}, wantErr: true},
+		{name: "negative bm25 weight", req: HybridSearchRequest{Query: "q", BM25Weight: -1}, wantErr: true},
+		{name: "unknown distance", req: HybridSearchRequest{Query: "q", DistanceFunction: "manhattan"}, wantErr: true},
+		{name: "distance injection", req: HybridSearchRequest{Query: "q", DistanceFunction: "cosine; DROP TABLE embeddings"}, wantErr: true},
+		{name: "unknown fusion", req: HybridSearchRequest{Query: "q", FusionMethod: "max"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr && !errors.Is(err, ErrInvalidSearchRequest) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidSearchRequest", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("Validate() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestSearchUsesKnowledgeBaseDistanceFunction(t *testing.T) {
+	tests := []struct {
+		name      string
+		kbDist    string
+		requested string
+		want      store.DistanceFunction
+		wantErr   bool
+	}{
+		{name: "knowledge base default", kbDist: "l2", want: store.DistanceL2},
+		{name: "matching request", kbDist: "ip", requested: "ip", want: store.DistanceIP},
+		{name: "legacy knowledge base", want: store.DistanceCosine},
+		{name: "mismatched request", kbDist: "l2", requested: "cosine", wantErr: true},
+	}
+	for _, tt := range tests {
+		s := newFakeStore()
+		s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1", DistanceFunction: tt.kbDist}
+		b := NewBiz(s, &fakeEmbedder{}, nil, nil, nil)
+
+		_, err := b.Search(context.Background(), "kb1", &HybridSearchRequest{Query: "refund", DistanceFunction: tt.requested})
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidSearchRequest) {
+				t.Errorf("%s: Search() error = %v, want ErrInvalidSearchRequest", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Search() error = %v", tt.name, err)
+			continue
+		}
+		if got := s.knowledge.searchOpts.DistanceFunction; got != tt.want {
+			t.Errorf("%s: search distance = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKnowledgeBaseDistanceFunction(t *testing.T) {
+	kb := &model.KnowledgeBase{}
+	if err := validateDistanceFunction(kb); err != nil || kb.DistanceFunction != string(store.DistanceCosine) {
+		t.Errorf("validateDistanceFunction() = %v, distance %q, want cosine default", err, kb.DistanceFunction)
+	}
+	if err := validateDistanceFunction(&model.KnowledgeBase{DistanceFunction: "manhattan"}); !errors.Is(err, ErrInvalidKnowledgeBase) {
+		t.Errorf("validateDistanceFunction(manhattan) = %v, want ErrInvalidKnowledgeBase", err)
+	}
+
+	// 配置了向量索引时为知识库的距离函数建索引，未配置时不建
+	s := newFakeStore()
+	b := NewBiz(s, nil, nil, nil, &BizConfig{VectorIndex: &VectorIndexConfig{}}).(*bizImpl)
+	if err := b.ensureVectorIndex(context.Background(), &model.KnowledgeBase{DistanceFunction: "ip"}); err != nil {
+		t.Fatalf("ensureVectorIndex() error = %v", err)
+	}
+	if len(s.knowledge.vectorIndexes) != 1 || s.knowledge.vectorIndexes[0] != store.DistanceIP {
+		t.Errorf("vector indexes = %v, want [ip]", s.knowledge.vectorIndexes)
+	}
+	b = NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+	if err := b.ensureVectorIndex(context.Background(), &model.KnowledgeBase{DistanceFunction: "l2"}); err != nil || len(s.knowledge.vectorIndexes) != 1 {
+		t.Errorf("ensureVectorIndex() without config = %v, indexes %v, want no index", err, s.knowledge.vectorIndexes)
+	}
+}
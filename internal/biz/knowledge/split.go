@@ -0,0 +1,244 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/recursive"
+	"github.com/cloudwego/eino-ext/components/document/transformer/splitter/semantic"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// ErrInvalidSplitOptions 分块参数无效.
+var ErrInvalidSplitOptions = errs.New(errs.ErrValidation, "invalid split options")
+
+// SeparatorPreset 递归分块的分隔符预设.
+type SeparatorPreset string
+
+const (
+	SeparatorPresetDefault  SeparatorPreset = "default" // 中英文混排文本
+	SeparatorPresetProse    SeparatorPreset = "prose"   // 以空格分词的文本（英文等）
+	SeparatorPresetCode     SeparatorPreset = "code"    // 源代码
+	SeparatorPresetChinese  SeparatorPreset = "zh"      // 中文
+	SeparatorPresetJapanese SeparatorPreset = "ja"      // 日文
+)
+
+// separatorPresets 各预设的分隔符，按优先级排列，末尾的 "" 表示最后按字符切分.
+var separatorPresets = map[SeparatorPreset][]string{
+	SeparatorPresetDefault:  {"\n\n", "\n", "。", ".", " ", ""},
+	SeparatorPresetProse:    {"\n\n", "\n", ". ", "! ", "? ", "; ", ", ", " ", ""},
+	SeparatorPresetCode:     {"\n\n\n", "\nfunc ", "\ntype ", "\nclass ", "\ndef ", "\n\n", "\n", ";", " ", ""},
+	SeparatorPresetChinese:  {"\n\n", "\n", "。", "！", "？", "；", "，", ""},
+	SeparatorPresetJapanese: {"\n\n", "\n", "。", "！", "？", "」", "、", ""},
+}
+
+// ChunkUnit 递归分块的长度单位.
+type ChunkUnit string
+
+const (
+	ChunkUnitChars  ChunkUnit = "chars"  // 按字符（字节）计算块大小，默认
+	ChunkUnitTokens ChunkUnit = "tokens" // 按 token 计算块大小
+)
+
+// splitOptions 分块参数，零值表示使用默认值.
+type splitOptions struct {
+	splitterType SplitterType
+	// chunkSize、chunkOverlap 递归分块的块大小和重叠大小，单位由 chunkUnit 决定
+	chunkSize    int
+	chunkOverlap int
+	chunkUnit    ChunkUnit
+	// percentile 语义分块的百分位阈值
+	percentile float64
+	// semanticOverlap 语义分块的重叠句数
+	semanticOverlap int
+	// separatorPreset、separators 递归分块的分隔符预设和自定义分隔符
+	separatorPreset SeparatorPreset
+	separators      []string
+}
+
+// splitContent 按指定分块器切分文本，未指定的参数使用知识库分块配置或默认值.
+func (b *bizImpl) splitContent(ctx context.Context, kb *model.KnowledgeBase, content string, opts *splitOptions) ([]*schema.Document, error) {
+	switch opts.splitterType {
+	case SplitterTypeSemantic:
+		// 语义分块
+		percentile := opts.percentile
+		if percentile <= 0 || percentile > 1 {
+			percentile = 0.9
+		}
+		chunks, err := b.splitDocumentSemantic(ctx, content, percentile)
+		if err != nil {
+			return nil, err
+		}
+		return overlapChunks(chunks, opts.semanticOverlap), nil
+	default:
+		// 递归分块（默认）
+		chunkSize := opts.chunkSize
+		if chunkSize <= 0 {
+			chunkSize = 512
+		}
+		chunkOverlap := opts.chunkOverlap
+		if chunkOverlap <= 0 {
+			chunkOverlap = 50
+		}
+		separators, keepType, err := recursiveSeparators(kb, opts)
+		if err != nil {
+			return nil, err
+		}
+		lenFunc, err := b.chunkLenFunc(opts.chunkUnit)
+		if err != nil {
+			return nil, err
+		}
+		return b.splitDocumentRecursive(ctx, content, chunkSize, chunkOverlap, separators, keepType, lenFunc)
+	}
+}
+
+// chunkUnitOrDefault 返回分块单位，未指定时为按字符.
+func chunkUnitOrDefault(unit ChunkUnit) ChunkUnit {
+	if unit == "" {
+		return ChunkUnitChars
+	}
+	return unit
+}
+
+// chunkLenFunc 返回按分块单位计算文本长度的函数，按字符计算时返回 nil 使用分块器默认的长度函数.
+func (b *bizImpl) chunkLenFunc(unit ChunkUnit) (func(string) int, error) {
+	switch unit {
+	case "", ChunkUnitChars:
+		return nil, nil
+	case ChunkUnitTokens:
+		return b.tokenizer.Count, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown chunk unit %q", ErrInvalidSplitOptions, unit)
+	}
+}
+
+// recursiveSeparators 确定递归分块的分隔符，优先级：请求的自定义分隔符、请求的预设、
+// 知识库 chunking_config 中的 separators、知识库的 separator_preset、默认预设.
+// 代码预设的分隔符（如 "\nfunc "）保留在分块开头，其余情况丢弃分隔符.
+func recursiveSeparators(kb *model.KnowledgeBase, opts *splitOptions) ([]string, recursive.KeepType, error) {
+	if len(opts.separators) > 0 {
+		return opts.separators, recursive.KeepTypeNone, nil
+	}
+	preset := opts.separatorPreset
+	if preset == "" && kb != nil {
+		if raw, ok := kb.ChunkingConfig["separators"].([]any); ok && len(raw) > 0 {
+			separators := make([]string, 0, len(raw))
+			for _, v := range raw {
+				sep, ok := v.(string)
+				if !ok {
+					return nil, recursive.KeepTypeNone, fmt.Errorf("%w: knowledge base separators must be strings", ErrInvalidSplitOptions)
+				}
+				separators = append(separators, sep)
+			}
+			return separators, recursive.KeepTypeNone, nil
+		}
+		if p, ok := kb.ChunkingConfig["separator_preset"].(string); ok {
+			preset = SeparatorPreset(p)
+		}
+	}
+	if preset == "" {
+		preset = SeparatorPresetDefault
+	}
+	separators, ok := separatorPresets[preset]
+	if !ok {
+		return nil, recursive.KeepTypeNone, fmt.Errorf("%w: unknown separator preset %q", ErrInvalidSplitOptions, preset)
+	}
+	if preset == SeparatorPresetCode {
+		return separators, recursive.KeepTypeStart, nil
+	}
+	return separators, recursive.KeepTypeNone, nil
+}
+
+// splitDocumentRecursive 递归分块文档.
+func (b *bizImpl) splitDocumentRecursive(ctx context.Context, content string, chunkSize, chunkOverlap int, separators []string, keepType recursive.KeepType, lenFunc func(string) int) ([]*schema.Document, error) {
+	splitter, err := recursive.NewSplitter(ctx, &recursive.Config{
+		ChunkSize:   chunkSize,
+		OverlapSize: chunkOverlap,
+		Separators:  separators,
+		LenFunc:     lenFunc,
+		KeepType:    keepType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create splitter: %w", err)
+	}
+
+	docs := []*schema.Document{{Content: content}}
+	chunks, err := splitter.Transform(ctx, docs)
+	if err != nil {
+		return nil, fmt.Errorf("split: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// splitDocumentSemantic 语义分块文档.
+func (b *bizImpl) splitDocumentSemantic(ctx context.Context, content string, percentile float64) ([]*schema.Document, error) {
+	if b.splitEmbedder == nil {
+		return nil, fmt.Errorf("embedder is required for semantic splitting")
+	}
+
+	splitter, err := semantic.NewSplitter(ctx, &semantic.Config{
+		Embedding:    b.splitEmbedder,
+		Percentile:   percentile,
+		BufferSize:   1,
+		MinChunkSize: 100,
+		Separators:   []string{"\n\n", "\n", "。", ".", "?", "!", " "},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create semantic splitter: %w", err)
+	}
+
+	docs := []*schema.Document{{Content: content}}
+	chunks, err := splitter.Transform(ctx, docs)
+	if err != nil {
+		return nil, fmt.Errorf("semantic split: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// overlapChunks 在每个分块开头加上前一个分块（重叠前的原文）末尾的 sentences 句.
+// 内容哈希基于加上重叠后的完整内容计算，相邻分块共享的重叠部分不会使它们被视为重复分块.
+func overlapChunks(chunks []*schema.Document, sentences int) []*schema.Document {
+	if sentences <= 0 || len(chunks) < 2 {
+		return chunks
+	}
+	out := make([]*schema.Document, len(chunks))
+	out[0] = chunks[0]
+	for i := 1; i < len(chunks); i++ {
+		tail := lastSentences(chunks[i-1].Content, sentences)
+		out[i] = &schema.Document{
+			ID:       chunks[i].ID,
+			Content:  tail + chunks[i].Content,
+			MetaData: chunks[i].MetaData,
+		}
+	}
+	return out
+}
+
+// sentenceEnds 句子结束符.
+const sentenceEnds = "。！？.!?\n"
+
+// lastSentences 返回文本末尾的 n 句（保留原有标点和空白）.
+func lastSentences(text string, n int) string {
+	runes := []rune(text)
+	end := len(runes)
+	// 跳过末尾的结束符和空白，避免把它们算作一句
+	for end > 0 && (strings.ContainsRune(sentenceEnds, runes[end-1]) || runes[end-1] == ' ') {
+		end--
+	}
+	start := end
+	for count := 0; start > 0; start-- {
+		if strings.ContainsRune(sentenceEnds, runes[start-1]) {
+			count++
+			if count == n {
+				break
+			}
+		}
+	}
+	return string(runes[start:])
+}
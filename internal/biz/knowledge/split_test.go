@@ -0,0 +1,224 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/tokenizer"
+)
+
+// semanticContent 几个主题不同的句子，语义分块会在主题切换处断开.
+const semanticContent = "Apples and pears grow in the orchard every autumn. " +
+	"The orchard harvest fills baskets with apples and pears. " +
+	"Quantum computers use qubits for parallel computation. " +
+	"Qubits make quantum computation very different from classic bits. " +
+	"Rivers carry water from the mountains down to the sea. " +
+	"The sea receives river water carrying mountain sediment."
+
+func TestSemanticSplitUsesSplitEmbedder(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	main, splitter := &fakeEmbedder{}, &fakeEmbedder{}
+	b := NewBiz(s, main, nil, nil, &BizConfig{SplitEmbedder: splitter})
+
+	result, err := b.ImportDocument(context.Background(), &ImportRequest{
+		KnowledgeBaseID: "kb1",
+		SourceType:      "text",
+		Content:         semanticContent,
+		SplitterType:    SplitterTypeSemantic,
+	})
+	if err != nil {
+		t.Fatalf("ImportDocument() error = %v", err)
+	}
+	if len(splitter.calls) == 0 {
+		t.Fatal("split embedder not called for semantic splitting")
+	}
+	// 分块边界由分块模型计算，主模型只为最终的分块生成向量
+	chunks := s.knowledge.documentChunks(result.DocumentID)
+	var embedded []string
+	for _, call := range main.calls {
+		embedded = append(embedded, call...)
+	}
+	if len(embedded) != len(chunks) {
+		t.Fatalf("main embedder embedded %d texts, want %d chunks", len(embedded), len(chunks))
+	}
+	for i, c := range chunks {
+		if embedded[i] != c.Content {
+			t.Errorf("main embedder text %d = %q, want chunk content %q", i, embedded[i], c.Content)
+		}
+	}
+}
+
+func TestSemanticSplitDefaultsToMainEmbedder(t *testing.T) {
+	main := &fakeEmbedder{}
+	b := NewBiz(nil, main, nil, nil, nil).(*bizImpl)
+
+	chunks, err := b.splitContent(context.Background(), &model.KnowledgeBase{ID: "kb1"}, semanticContent, &splitOptions{splitterType: SplitterTypeSemantic})
+	if err != nil {
+		t.Fatalf("splitContent() error = %v", err)
+	}
+	if len(chunks) == 0 || len(main.calls) == 0 {
+		t.Fatalf("chunks = %d, main embedder calls = %d, want main embedder used for splitting", len(chunks), len(main.calls))
+	}
+	var joined []string
+	for _, c := range chunks {
+		joined = append(joined, c.Content)
+	}
+	if got := strings.Join(joined, ""); !strings.Contains(got, "Qubits") {
+		t.Errorf("semantic chunks = %q, lost content", got)
+	}
+}
+
+func TestOverlapChunks(t *testing.T) {
+	chunks := []*schema.Document{
+		{ID: "a", Content: "First one. Second one. Third one. "},
+		{ID: "b", Content: "Fourth one. Fifth one.", MetaData: map[string]any{"page": 2}},
+		{ID: "c", Content: "第六句。第七句。"},
+	}
+
+	got := overlapChunks(chunks, 2)
+	want := []string{
+		"First one. Second one. Third one. ",
+		" Second one. Third one. Fourth one. Fifth one.",
+		"Fourth one. Fifth one.第六句。第七句。",
+	}
+	for i, c := range got {
+		if c.Content != want[i] {
+			t.Errorf("chunk %d content = %q, want %q", i, c.Content, want[i])
+		}
+		if c.ID != chunks[i].ID {
+			t.Errorf("chunk %d id = %q, want %q", i, c.ID, chunks[i].ID)
+		}
+	}
+	if got[1].MetaData["page"] != 2 {
+		t.Errorf("chunk metadata = %v, want kept", got[1].MetaData)
+	}
+	// 重叠基于原文计算，不会把前一个分块的重叠部分继续带到下一个分块
+	if strings.Contains(got[2].Content, "Third") {
+		t.Errorf("chunk 2 content = %q, overlap cascaded from chunk 0", got[2].Content)
+	}
+	if chunks[1].Content != "Fourth one. Fifth one." {
+		t.Errorf("input chunk modified: %q", chunks[1].Content)
+	}
+
+	if got := overlapChunks(chunks, 0); got[1].Content != chunks[1].Content {
+		t.Errorf("overlapChunks(0) content = %q, want unchanged", got[1].Content)
+	}
+}
+
+func TestLastSentences(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"One. Two. Three.", 1, " Three."},
+		{"One. Two. Three.", 2, " Two. Three."},
+		{"One. Two.", 5, "One. Two."},
+		{"第一句。第二句！", 1, "第二句！"},
+		{"no terminator", 1, "no terminator"},
+	}
+	for _, tt := range tests {
+		if got := lastSentences(tt.text, tt.n); got != tt.want {
+			t.Errorf("lastSentences(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCodePresetSplitsOnFunctions(t *testing.T) {
+	code := "package demo\n\nfunc add(a, b int) int {\n\tsum := a + b\n\treturn sum\n}\n\nfunc sub(a, b int) int {\n\tdiff := a - b\n\treturn diff\n}\n\nfunc mul(a, b int) int {\n\tprod := a * b\n\treturn prod\n}\n"
+	b := NewBiz(nil, nil, nil, nil, nil).(*bizImpl)
+
+	chunks, err := b.splitContent(context.Background(), nil, code, &splitOptions{chunkSize: 60, chunkOverlap: 1, separatorPreset: SeparatorPresetCode})
+	if err != nil {
+		t.Fatalf("splitContent() error = %v", err)
+	}
+	want := []string{"package demo", "func add", "func sub", "func mul"}
+	if len(chunks) != len(want) {
+		t.Fatalf("chunks = %d, want one per declaration", len(chunks))
+	}
+	for i, c := range chunks {
+		if !strings.HasPrefix(strings.TrimSpace(c.Content), want[i]) {
+			t.Errorf("chunk %d = %q, want it to start with %q", i, c.Content, want[i])
+		}
+	}
+	// 函数体不会被截断到两个分块中
+	if !strings.HasSuffix(strings.TrimSpace(chunks[2].Content), "return diff\n}") {
+		t.Errorf("chunk 2 = %q, want the whole sub function", chunks[2].Content)
+	}
+}
+
+func TestRecursiveSeparators(t *testing.T) {
+	kb := &model.KnowledgeBase{ChunkingConfig: model.JSONMap{"separator_preset": "code"}}
+	tests := []struct {
+		name string
+		kb   *model.KnowledgeBase
+		opts *splitOptions
+		want []string
+	}{
+		{"default", nil, &splitOptions{}, separatorPresets[SeparatorPresetDefault]},
+		{"request preset", kb, &splitOptions{separatorPreset: SeparatorPresetChinese}, separatorPresets[SeparatorPresetChinese]},
+		{"request separators", kb, &splitOptions{separators: []string{"---"}}, []string{"---"}},
+		{"knowledge base preset", kb, &splitOptions{}, separatorPresets[SeparatorPresetCode]},
+		{"knowledge base separators", &model.KnowledgeBase{ChunkingConfig: model.JSONMap{"separators": []any{"##", ""}}}, &splitOptions{}, []string{"##", ""}},
+	}
+	for _, tt := range tests {
+		got, _, err := recursiveSeparators(tt.kb, tt.opts)
+		if err != nil {
+			t.Errorf("%s: recursiveSeparators() error = %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: recursiveSeparators() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, _, err := recursiveSeparators(nil, &splitOptions{separatorPreset: "klingon"}); !errors.Is(err, ErrInvalidSplitOptions) {
+		t.Errorf("unknown preset error = %v, want ErrInvalidSplitOptions", err)
+	}
+}
+
+// wordCounter 按空格分词计数的分词器.
+type wordCounter struct{}
+
+func (wordCounter) Count(text string) int { return len(strings.Fields(text)) }
+
+func TestTokenSizedChunksStayUnderLimit(t *testing.T) {
+	content := strings.Repeat("alpha beta gamma delta epsilon zeta eta theta. ", 30)
+	tests := []struct {
+		name    string
+		counter tokenizer.Counter
+	}{
+		{"estimator", nil},
+		{"custom tokenizer", wordCounter{}},
+	}
+	for _, tt := range tests {
+		b := NewBiz(nil, nil, nil, nil, &BizConfig{Tokenizer: tt.counter}).(*bizImpl)
+		counter := b.tokenizer
+
+		chunks, err := b.splitContent(context.Background(), nil, content, &splitOptions{chunkSize: 20, chunkOverlap: 4, chunkUnit: ChunkUnitTokens})
+		if err != nil {
+			t.Fatalf("%s: splitContent() error = %v", tt.name, err)
+		}
+		if len(chunks) < 2 {
+			t.Fatalf("%s: chunks = %d, want several", tt.name, len(chunks))
+		}
+		for i, c := range chunks {
+			if n := counter.Count(c.Content); n > 20 {
+				t.Errorf("%s: chunk %d has %d tokens, want <= 20", tt.name, i, n)
+			}
+		}
+	}
+
+	// 未知的分块单位返回参数错误
+	b := NewBiz(nil, nil, nil, nil, nil).(*bizImpl)
+	if _, err := b.splitContent(context.Background(), nil, content, &splitOptions{chunkUnit: "words"}); !errors.Is(err, ErrInvalidSplitOptions) {
+		t.Errorf("unknown chunk unit error = %v, want ErrInvalidSplitOptions", err)
+	}
+}
@@ -0,0 +1,609 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/embedding"
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// fakeStore 测试用的 Store，只实现用到的子存储.
+type fakeStore struct {
+	store.Store
+	knowledge *fakeKnowledgeStore
+	tenants   *fakeTenantStore
+	providers *fakeProviderStore
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		knowledge: &fakeKnowledgeStore{
+			kbs:           make(map[string]*model.KnowledgeBase),
+			docs:          make(map[string]*model.KnowledgeDocument),
+			chunks:        make(map[string]*model.KnowledgeChunk),
+			contentHashes: make(map[string]string),
+			reindexJobs:   make(map[string]*model.ReindexJob),
+			orphans:       make(map[store.OrphanKind]int64),
+			spaces:        make(map[string][]*store.EmbeddingSpace),
+			embeddings:    make(map[string]*model.Embedding),
+			chunkTags:     make(map[string][]string),
+			tags:          make(map[string]*model.KnowledgeTag),
+		},
+		tenants:   &fakeTenantStore{tenants: make(map[string]*model.Tenant)},
+		providers: &fakeProviderStore{providers: make(map[string]*model.Provider)},
+	}
+}
+
+func (s *fakeStore) Knowledge() store.KnowledgeStore { return s.knowledge }
+func (s *fakeStore) Tenants() store.TenantStore      { return s.tenants }
+func (s *fakeStore) Providers() store.ProviderStore  { return s.providers }
+
+// fakeProviderStore 只支持按 ID 读取 Provider.
+type fakeProviderStore struct {
+	store.ProviderStore
+	providers map[string]*model.Provider
+}
+
+func (s *fakeProviderStore) Get(_ context.Context, id string) (*model.Provider, error) {
+	provider, ok := s.providers[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return provider, nil
+}
+
+// fakeTenantStore 只支持按 ID 读取租户.
+type fakeTenantStore struct {
+	store.TenantStore
+	tenants map[string]*model.Tenant
+}
+
+func (s *fakeTenantStore) Get(_ context.Context, id string) (*model.Tenant, error) {
+	tenant, ok := s.tenants[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return tenant, nil
+}
+
+// fakeKnowledgeStore 记录调用参数的 KnowledgeStore，未实现的方法会 panic.
+type fakeKnowledgeStore struct {
+	store.KnowledgeStore
+
+	kbs        map[string]*model.KnowledgeBase
+	docs       map[string]*model.KnowledgeDocument
+	chunks     map[string]*model.KnowledgeChunk
+	embeddings map[string]*model.Embedding // 分块 ID 到向量
+	chunkTags  map[string][]string         // 分块 ID 到标签 ID
+	tags       map[string]*model.KnowledgeTag
+	// contentHashes 文档 ID 到分块内容哈希
+	contentHashes map[string]string
+
+	byHash        *model.KnowledgeDocument
+	inFlightSince time.Time
+
+	staleBefore  time.Time
+	staleMessage string
+	staleCount   int64
+
+	// reindexMu 保护后台任务并发访问的重建任务记录
+	reindexMu     sync.Mutex
+	reindexJobs   map[string]*model.ReindexJob
+	indexRebuilds int
+
+	// orphans 各类孤立数据条数，删除孤立分块时其向量和标签关联随之成为孤立数据
+	orphans       map[store.OrphanKind]int64
+	orphanDeletes int
+
+	// spaces 按 "<kbID>/<docID>" 登记的向量空间，docID 为空表示整个知识库
+	spaces map[string][]*store.EmbeddingSpace
+
+	// searchOpts 最近一次混合检索的选项，searchResults 为检索返回的结果
+	searchOpts    store.SearchOptions
+	searchResults []*store.ChunkWithScore
+	// vectorIndexes 创建过向量索引的距离函数
+	vectorIndexes []store.DistanceFunction
+}
+
+func (s *fakeKnowledgeStore) HybridSearch(_ context.Context, _ []string, _ []float32, _ string, _ int, _, _ float64, options ...store.SearchOptions) ([]*store.ChunkWithScore, error) {
+	if len(options) > 0 {
+		s.searchOpts = options[0]
+	}
+	if len(s.searchOpts.TagIDs) == 0 {
+		return s.searchResults, nil
+	}
+	// 与真实实现相同：只返回带有其中任一标签的分块
+	var results []*store.ChunkWithScore
+	for _, r := range s.searchResults {
+		if slices.ContainsFunc(s.chunkTags[r.Chunk.ID], func(id string) bool { return slices.Contains(s.searchOpts.TagIDs, id) }) {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+func (s *fakeKnowledgeStore) EnsureVectorIndex(_ context.Context, distanceFunc store.DistanceFunction, _, _ int) (store.VectorIndexMethod, error) {
+	s.vectorIndexes = append(s.vectorIndexes, distanceFunc)
+	return store.VectorIndexHNSW, nil
+}
+
+func (s *fakeKnowledgeStore) ListEmbeddingSpaces(_ context.Context, kbID, docID string) ([]*store.EmbeddingSpace, error) {
+	return s.spaces[kbID+"/"+docID], nil
+}
+
+func (s *fakeKnowledgeStore) MoveDocument(_ context.Context, docID, targetKBID string) error {
+	s.docs[docID].KnowledgeBaseID = targetKBID
+	return nil
+}
+
+func (s *fakeKnowledgeStore) CountOrphans(_ context.Context, kind store.OrphanKind) (int64, error) {
+	return s.orphans[kind], nil
+}
+
+func (s *fakeKnowledgeStore) DeleteOrphans(_ context.Context, kind store.OrphanKind, batchSize int) (int64, error) {
+	s.orphanDeletes++
+	n := min(s.orphans[kind], int64(batchSize))
+	s.orphans[kind] -= n
+	if kind == store.OrphanChunks {
+		s.orphans[store.OrphanEmbeddings] += n
+		s.orphans[store.OrphanChunkTags] += n
+	}
+	return n, nil
+}
+
+func (s *fakeKnowledgeStore) StartReindexJob(_ context.Context, job *model.ReindexJob) (bool, error) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	for _, j := range s.reindexJobs {
+		if j.KnowledgeBaseID == job.KnowledgeBaseID && j.Status == model.ReindexStatusRunning {
+			return false, nil
+		}
+	}
+	snapshot := *job
+	s.reindexJobs[job.ID] = &snapshot
+	return true, nil
+}
+
+func (s *fakeKnowledgeStore) UpdateReindexJob(_ context.Context, job *model.ReindexJob) error {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	snapshot := *job
+	s.reindexJobs[job.ID] = &snapshot
+	return nil
+}
+
+func (s *fakeKnowledgeStore) GetReindexJob(_ context.Context, id string) (*model.ReindexJob, error) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	job, ok := s.reindexJobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+func (s *fakeKnowledgeStore) LatestIndexRebuild(context.Context) (*model.ReindexJob, error) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	var latest *model.ReindexJob
+	for _, j := range s.reindexJobs {
+		if j.RebuildIndexes && (latest == nil || j.StartedAt.After(latest.StartedAt)) {
+			latest = j
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+	snapshot := *latest
+	return &snapshot, nil
+}
+
+func (s *fakeKnowledgeStore) FailRunningReindexJobs(_ context.Context, message string) (int64, error) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	var n int64
+	for _, j := range s.reindexJobs {
+		if j.Status == model.ReindexStatusRunning {
+			j.Status, j.Error = model.ReindexStatusFailed, message
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *fakeKnowledgeStore) RebuildSearchIndexes(context.Context) error {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	s.indexRebuilds++
+	return nil
+}
+
+func (s *fakeKnowledgeStore) GetKnowledgeBase(_ context.Context, id string) (*model.KnowledgeBase, error) {
+	kb, ok := s.kbs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return kb, nil
+}
+
+func (s *fakeKnowledgeStore) CreateDocument(_ context.Context, doc *model.KnowledgeDocument) error {
+	s.docs[doc.ID] = doc
+	return nil
+}
+
+func (s *fakeKnowledgeStore) UpdateDocument(_ context.Context, doc *model.KnowledgeDocument) error {
+	s.docs[doc.ID] = doc
+	return nil
+}
+
+func (s *fakeKnowledgeStore) CreateChunks(_ context.Context, chunks []*model.KnowledgeChunk) error {
+	for _, c := range chunks {
+		s.chunks[c.ID] = c
+	}
+	return nil
+}
+
+func (s *fakeKnowledgeStore) CreateEmbeddings(_ context.Context, embeddings []*model.Embedding) error {
+	for _, e := range embeddings {
+		s.embeddings[e.ChunkID] = e
+	}
+	return nil
+}
+
+// documentChunks 按分块序号返回文档的分块.
+func (s *fakeKnowledgeStore) documentChunks(docID string) []*model.KnowledgeChunk {
+	var chunks []*model.KnowledgeChunk
+	for _, c := range s.chunks {
+		if c.DocumentID == docID {
+			chunks = append(chunks, c)
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+	return chunks
+}
+
+// DeleteDocuments 按过滤条件删除文档，连同其分块、向量和分块标签.
+func (s *fakeKnowledgeStore) DeleteDocuments(_ context.Context, kbID string, filter *store.DocumentFilter, _ int) (int64, error) {
+	var deleted int64
+	for id, doc := range s.docs {
+		if doc.KnowledgeBaseID != kbID ||
+			(filter.SourceType != "" && string(doc.SourceType) != filter.SourceType) ||
+			(filter.ParseStatus != "" && string(doc.ParseStatus) != filter.ParseStatus) ||
+			(len(filter.IDs) > 0 && !slices.Contains(filter.IDs, id)) {
+			continue
+		}
+		s.deleteDocument(id)
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (s *fakeKnowledgeStore) DeleteDocument(_ context.Context, id string) error {
+	s.deleteDocument(id)
+	return nil
+}
+
+// deleteDocument 删除文档及其分块、向量和分块标签.
+func (s *fakeKnowledgeStore) deleteDocument(id string) {
+	for _, c := range s.documentChunks(id) {
+		delete(s.chunks, c.ID)
+		delete(s.embeddings, c.ID)
+		delete(s.chunkTags, c.ID)
+	}
+	delete(s.docs, id)
+}
+
+// ListDocumentsAfter 按 ID 顺序返回 afterID 之后的文档.
+func (s *fakeKnowledgeStore) ListDocumentsAfter(_ context.Context, kbID, afterID string, limit int) ([]*model.KnowledgeDocument, error) {
+	var docs []*model.KnowledgeDocument
+	for id, doc := range s.docs {
+		if doc.KnowledgeBaseID == kbID && id > afterID {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	if len(docs) > limit {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+// DocumentCentroid 返回文档启用分块向量的平均值.
+func (s *fakeKnowledgeStore) DocumentCentroid(_ context.Context, docID string) ([]float32, error) {
+	var sum []float32
+	n := 0
+	for _, c := range s.documentChunks(docID) {
+		e, ok := s.embeddings[c.ID]
+		if !ok || !c.IsEnabled {
+			continue
+		}
+		if sum == nil {
+			sum = make([]float32, len(e.Embedding))
+		}
+		for i, v := range e.Embedding {
+			sum[i] += v
+		}
+		n++
+	}
+	for i := range sum {
+		sum[i] /= float32(n)
+	}
+	return sum, nil
+}
+
+// SearchChunksByVectorWithOptions 按余弦相似度返回知识库中未排除文档的分块.
+func (s *fakeKnowledgeStore) SearchChunksByVectorWithOptions(_ context.Context, kbIDs []string, vector []float32, limit int, options ...store.SearchOptions) ([]*store.ChunkWithScore, error) {
+	var opts store.SearchOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	var results []*store.ChunkWithScore
+	for id, e := range s.embeddings {
+		c := s.chunks[id]
+		if c == nil || !c.IsEnabled || !slices.Contains(kbIDs, c.KnowledgeBaseID) || slices.Contains(opts.ExcludeDocumentIDs, c.DocumentID) {
+			continue
+		}
+		results = append(results, &store.ChunkWithScore{Chunk: c, Score: cosine(vector, e.Embedding)})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func cosine(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+func (s *fakeKnowledgeStore) GetTag(_ context.Context, id string) (*model.KnowledgeTag, error) {
+	tag, ok := s.tags[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return tag, nil
+}
+
+// ListTagsByKnowledgeBase 按名称返回知识库的标签.
+func (s *fakeKnowledgeStore) ListTagsByKnowledgeBase(_ context.Context, kbID string) ([]*model.KnowledgeTag, error) {
+	var tags []*model.KnowledgeTag
+	for _, tag := range s.tags {
+		if tag.KnowledgeBaseID == kbID {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+func (s *fakeKnowledgeStore) UpdateTag(_ context.Context, tag *model.KnowledgeTag) error {
+	s.tags[tag.ID] = tag
+	return nil
+}
+
+// SearchChunksByFullText 按查询词在分块中出现的次数打分，返回出现过的分块.
+func (s *fakeKnowledgeStore) SearchChunksByFullText(_ context.Context, kbIDs []string, query string, limit int, _ ...store.SearchOptions) ([]*store.ChunkWithScore, error) {
+	var results []*store.ChunkWithScore
+	for _, c := range s.chunks {
+		n := strings.Count(strings.ToLower(c.Content), strings.ToLower(query))
+		if n > 0 && slices.Contains(kbIDs, c.KnowledgeBaseID) {
+			results = append(results, &store.ChunkWithScore{Chunk: c, Score: float64(n)})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// ApplyTagToChunks 为分块添加标签，已带有该标签的分块跳过，返回新增关联数.
+func (s *fakeKnowledgeStore) ApplyTagToChunks(_ context.Context, tagID string, chunkIDs []string, _ int) (int64, error) {
+	var tagged int64
+	for _, id := range chunkIDs {
+		if !slices.Contains(s.chunkTags[id], tagID) {
+			s.chunkTags[id] = append(s.chunkTags[id], tagID)
+			tagged++
+		}
+	}
+	return tagged, nil
+}
+
+// ListTagUsageByTenant 与真实实现相同：统计租户各知识库标签关联的分块数，按标签名和知识库名排序.
+func (s *fakeKnowledgeStore) ListTagUsageByTenant(_ context.Context, tenantID string) ([]*store.TagUsage, error) {
+	counts := make(map[string]int64)
+	for _, tagIDs := range s.chunkTags {
+		for _, id := range tagIDs {
+			counts[id]++
+		}
+	}
+	var usage []*store.TagUsage
+	for _, tag := range s.tags {
+		kb, ok := s.kbs[tag.KnowledgeBaseID]
+		if !ok || kb.TenantID != tenantID {
+			continue
+		}
+		usage = append(usage, &store.TagUsage{
+			TagID:             tag.ID,
+			Name:              tag.Name,
+			Color:             tag.Color,
+			KnowledgeBaseID:   kb.ID,
+			KnowledgeBaseName: kb.Name,
+			ChunkCount:        counts[tag.ID],
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		a, b := usage[i], usage[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.KnowledgeBaseName != b.KnowledgeBaseName {
+			return a.KnowledgeBaseName < b.KnowledgeBaseName
+		}
+		return a.TagID < b.TagID
+	})
+	return usage, nil
+}
+
+func (s *fakeKnowledgeStore) ListDocumentChunks(_ context.Context, docID string) ([]*model.KnowledgeChunk, error) {
+	return s.documentChunks(docID), nil
+}
+
+// ReplaceDocumentChunks 与真实实现相同：删除的分块连同向量和标签一起删除，保留的分块只更新序号.
+func (s *fakeKnowledgeStore) ReplaceDocumentChunks(_ context.Context, change *store.ChunkReplacement) error {
+	for _, id := range change.Remove {
+		delete(s.chunks, id)
+		delete(s.embeddings, id)
+		delete(s.chunkTags, id)
+	}
+	for id, index := range change.Keep {
+		s.chunks[id].ChunkIndex = index
+	}
+	for _, c := range change.Chunks {
+		s.chunks[c.ID] = c
+	}
+	for _, e := range change.Embeddings {
+		s.embeddings[e.ChunkID] = e
+	}
+	return nil
+}
+
+func (s *fakeKnowledgeStore) GetDocument(_ context.Context, id string) (*model.KnowledgeDocument, error) {
+	doc, ok := s.docs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return doc, nil
+}
+
+func (s *fakeKnowledgeStore) UpdateDocumentSummary(_ context.Context, id, contentHash, summary string) error {
+	if doc, ok := s.docs[id]; ok {
+		doc.Summary, doc.SummaryHash = summary, contentHash
+	}
+	return nil
+}
+
+func (s *fakeKnowledgeStore) DocumentContentHash(_ context.Context, id string) (string, error) {
+	return s.contentHashes[id], nil
+}
+
+func (s *fakeKnowledgeStore) GetChunk(_ context.Context, id string) (*model.KnowledgeChunk, error) {
+	chunk, ok := s.chunks[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return chunk, nil
+}
+
+func (s *fakeKnowledgeStore) UpdateChunk(_ context.Context, chunk *model.KnowledgeChunk) error {
+	s.chunks[chunk.ID] = chunk
+	return nil
+}
+
+func (s *fakeKnowledgeStore) DeleteChunk(_ context.Context, id string) error {
+	delete(s.chunks, id)
+	return nil
+}
+
+func (s *fakeKnowledgeStore) GetDocumentByHash(_ context.Context, _, _ string, inFlightSince time.Time) (*model.KnowledgeDocument, error) {
+	s.inFlightSince = inFlightSince
+	if s.byHash == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.byHash, nil
+}
+
+func (s *fakeKnowledgeStore) ListChunksByDocument(context.Context, string, int, int) ([]*model.KnowledgeChunk, int64, error) {
+	return nil, 3, nil
+}
+
+func (s *fakeKnowledgeStore) FailStaleImports(_ context.Context, updatedBefore time.Time, message string) (int64, error) {
+	s.staleBefore = updatedBefore
+	s.staleMessage = message
+	return s.staleCount, nil
+}
+
+// fakeEmbedder 以字母频次作为向量的嵌入模型，内容相近的文本向量也相近；记录每次调用的文本.
+type fakeEmbedder struct {
+	mu    sync.Mutex
+	calls [][]string
+}
+
+func (e *fakeEmbedder) EmbedStrings(_ context.Context, texts []string, _ ...embedding.Option) ([][]float64, error) {
+	e.mu.Lock()
+	e.calls = append(e.calls, append([]string(nil), texts...))
+	e.mu.Unlock()
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		v := make([]float64, 26)
+		for _, r := range strings.ToLower(text) {
+			if r >= 'a' && r <= 'z' {
+				v[r-'a']++
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// fakeBlobStore 内存中的对象存储，errs 中的 key 读取时返回对应错误.
+type fakeBlobStore struct {
+	mu      sync.Mutex
+	objects map[string]string
+	errs    map[string]error
+}
+
+func newFakeBlobStore() *fakeBlobStore {
+	return &fakeBlobStore{objects: make(map[string]string), errs: make(map[string]error)}
+}
+
+func (s *fakeBlobStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = string(data)
+	return nil
+}
+
+func (s *fakeBlobStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.errs[key]; err != nil {
+		return nil, err
+	}
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", blob.ErrNotFound, key)
+	}
+	// 不暴露长度，模拟无法提供大小的存储
+	return io.NopCloser(io.MultiReader(strings.NewReader(data))), nil
+}
+
+func (s *fakeBlobStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
@@ -0,0 +1,119 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/agent/tools"
+)
+
+func newSummaryFixture() (*fakeStore, *Service) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1", TenantID: "t1"}
+	s.knowledge.docs["doc1"] = &model.KnowledgeDocument{ID: "doc1", KnowledgeBaseID: "kb1"}
+	s.knowledge.chunks["c1"] = &model.KnowledgeChunk{ID: "c1", DocumentID: "doc1", KnowledgeBaseID: "kb1"}
+	s.knowledge.contentHashes["doc1"] = "hash-v1"
+	return s, NewService(&Config{Store: s})
+}
+
+func TestDocumentSummaryKeyedOnContentHash(t *testing.T) {
+	s, svc := newSummaryFixture()
+	ctx := context.Background()
+	req := &tools.DocumentSummaryRequest{DocumentID: "doc1"}
+
+	got, err := svc.GetDocumentSummary(ctx, req)
+	if err != nil {
+		t.Fatalf("GetDocumentSummary: %v", err)
+	}
+	if got.Summary != "" || got.ContentHash != "hash-v1" {
+		t.Fatalf("empty cache = %+v, want no summary and hash-v1", got)
+	}
+	if err := svc.SaveDocumentSummary(ctx, req, &tools.DocumentSummary{Summary: "v1 summary", ContentHash: got.ContentHash}); err != nil {
+		t.Fatalf("SaveDocumentSummary: %v", err)
+	}
+	if got, _ := svc.GetDocumentSummary(ctx, req); got.Summary != "v1 summary" {
+		t.Fatalf("cached summary = %q, want v1 summary", got.Summary)
+	}
+
+	// 分块内容变化（重新分块、刷新来源）后旧摘要不再命中
+	s.knowledge.contentHashes["doc1"] = "hash-v2"
+	if got, _ := svc.GetDocumentSummary(ctx, req); got.Summary != "" || got.ContentHash != "hash-v2" {
+		t.Fatalf("after content change = %+v, want no summary and hash-v2", got)
+	}
+
+	// 文档没有分块时不命中
+	s.knowledge.contentHashes["doc1"] = ""
+	s.knowledge.docs["doc1"].SummaryHash = ""
+	if got, _ := svc.GetDocumentSummary(ctx, req); got.Summary != "" {
+		t.Fatalf("without chunks summary = %q, want empty", got.Summary)
+	}
+}
+
+func TestDocumentSummaryScope(t *testing.T) {
+	s, svc := newSummaryFixture()
+	s.knowledge.docs["doc1"].Summary, s.knowledge.docs["doc1"].SummaryHash = "secret", "hash-v1"
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     *tools.DocumentSummaryRequest
+		wantErr bool
+	}{
+		{name: "unscoped", req: &tools.DocumentSummaryRequest{DocumentID: "doc1"}},
+		{name: "allowed knowledge base", req: &tools.DocumentSummaryRequest{DocumentID: "doc1", KnowledgeBaseIDs: []string{"kb1"}}},
+		{name: "same tenant", req: &tools.DocumentSummaryRequest{DocumentID: "doc1", TenantID: "t1"}},
+		{name: "other knowledge base", req: &tools.DocumentSummaryRequest{DocumentID: "doc1", KnowledgeBaseIDs: []string{"kb2"}}, wantErr: true},
+		{name: "other tenant", req: &tools.DocumentSummaryRequest{DocumentID: "doc1", TenantID: "t2"}, wantErr: true},
+		{name: "missing document", req: &tools.DocumentSummaryRequest{DocumentID: "doc2"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.GetDocumentSummary(ctx, tt.req)
+			if tt.wantErr {
+				if !errors.Is(err, ErrDocumentNotFound) {
+					t.Fatalf("GetDocumentSummary err = %v, want ErrDocumentNotFound", err)
+				}
+				if err := svc.SaveDocumentSummary(ctx, tt.req, &tools.DocumentSummary{Summary: "overwrite", ContentHash: "hash-v1"}); !errors.Is(err, ErrDocumentNotFound) {
+					t.Fatalf("SaveDocumentSummary err = %v, want ErrDocumentNotFound", err)
+				}
+				return
+			}
+			if err != nil || got.Summary != "secret" {
+				t.Fatalf("GetDocumentSummary = %+v, %v, want cached summary", got, err)
+			}
+		})
+	}
+	if doc := s.knowledge.docs["doc1"]; doc.Summary != "secret" {
+		t.Fatalf("out-of-scope save overwrote summary with %q", doc.Summary)
+	}
+}
+
+func TestChunkMutationsEvictSummary(t *testing.T) {
+	for _, mutate := range []struct {
+		name string
+		fn   func(b *bizImpl) error
+	}{
+		{name: "update", fn: func(b *bizImpl) error {
+			return b.UpdateChunk(context.Background(), &model.KnowledgeChunk{ID: "c1", DocumentID: "doc1", Content: "edited"})
+		}},
+		{name: "delete", fn: func(b *bizImpl) error {
+			return b.DeleteChunk(context.Background(), "c1")
+		}},
+	} {
+		t.Run(mutate.name, func(t *testing.T) {
+			s, _ := newSummaryFixture()
+			doc := s.knowledge.docs["doc1"]
+			doc.Summary, doc.SummaryHash = "stale", "hash-v1"
+			b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+
+			if err := mutate.fn(b); err != nil {
+				t.Fatalf("%s chunk: %v", mutate.name, err)
+			}
+			if doc.Summary != "" || doc.SummaryHash != "" {
+				t.Fatalf("summary after %s = %q (%q), want evicted", mutate.name, doc.Summary, doc.SummaryHash)
+			}
+		})
+	}
+}
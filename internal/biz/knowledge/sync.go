@@ -0,0 +1,169 @@
+// Package knowledge 提供知识库业务逻辑.
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/connector"
+	"github.com/ashwinyue/next-show/internal/pkg/events"
+)
+
+// ErrSyncNotConfigured 知识库未配置外部源同步.
+var ErrSyncNotConfigured = errs.New(errs.ErrValidation, "knowledge base sync not configured")
+
+// SyncResult 外部源同步结果.
+type SyncResult struct {
+	Imported []string `json:"imported"` // 新导入的文档 ID
+	Deleted  int      `json:"deleted"`  // 删除的文档数
+	Skipped  int      `json:"skipped"`  // 内容未变化而跳过的文档数
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// RedactSecrets 返回隐藏了 sync_config 中密钥的知识库副本，用于 API 响应.
+func RedactSecrets(kb *model.KnowledgeBase) *model.KnowledgeBase {
+	if kb == nil || len(kb.SyncConfig) == 0 {
+		return kb
+	}
+	out := *kb
+	out.SyncConfig = connector.Redact(kb.SyncConfig)
+	return &out
+}
+
+// HandleSourceWebhook 处理外部源的变更通知：校验签名后获取变更文档并重新导入.
+// 单个文档失败不影响其它文档，错误记录在结果中.
+func (b *bizImpl) HandleSourceWebhook(ctx context.Context, kbID string, header http.Header, body []byte) (*SyncResult, error) {
+	kb, conn, err := b.sourceConnector(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.VerifySignature(header, body); err != nil {
+		return nil, err
+	}
+
+	events, err := conn.ParseEvents(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKnowledgeBase, err)
+	}
+
+	result := &SyncResult{}
+	for _, event := range events {
+		var err error
+		switch event.Action {
+		case connector.ActionDelete:
+			err = b.deleteSourceDocuments(ctx, kb.ID, conn.URI(event.Key), result)
+		default:
+			err = b.syncSourceDocument(ctx, kb, conn, event.Key, result)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", event.Key, err))
+		}
+	}
+	return result, nil
+}
+
+// SyncKnowledgeBase 全量同步外部源中的所有文档.
+func (b *bizImpl) SyncKnowledgeBase(ctx context.Context, kbID string) (*SyncResult, error) {
+	kb, conn, err := b.sourceConnector(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := conn.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list source documents: %w", err)
+	}
+
+	result := &SyncResult{}
+	for _, key := range keys {
+		if err := b.syncSourceDocument(ctx, kb, conn, key, result); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+	return result, nil
+}
+
+// sourceConnector 根据知识库的 sync_config 创建连接器.
+func (b *bizImpl) sourceConnector(ctx context.Context, kbID string) (*model.KnowledgeBase, connector.Connector, error) {
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	if len(kb.SyncConfig) == 0 {
+		return nil, nil, ErrSyncNotConfigured
+	}
+
+	conn, err := connector.New(kb.SyncConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidKnowledgeBase, err)
+	}
+	return kb, conn, nil
+}
+
+// syncSourceDocument 获取文档并导入，内容未变化时跳过；导入成功后删除旧版本.
+func (b *bizImpl) syncSourceDocument(ctx context.Context, kb *model.KnowledgeBase, conn connector.Connector, key string, result *SyncResult) error {
+	doc, err := conn.Fetch(ctx, key)
+	if err != nil {
+		return fmt.Errorf("fetch: %w", err)
+	}
+
+	existing, err := b.store.Knowledge().ListDocumentsBySourceURI(ctx, kb.ID, doc.URI)
+	if err != nil {
+		return fmt.Errorf("list existing documents: %w", err)
+	}
+	hash := md5HashBytes(doc.Data)
+	for _, d := range existing {
+		if d.FileHash == hash && d.ParseStatus == model.DocumentParseStatusParsed {
+			result.Skipped++
+			return nil
+		}
+	}
+
+	imported, err := b.ImportDocument(ctx, &ImportRequest{
+		KnowledgeBaseID: kb.ID,
+		Title:           doc.Title,
+		SourceType:      conn.Type(),
+		SourceURI:       doc.URI,
+		FileName:        doc.Name,
+		FileReader:      bytes.NewReader(doc.Data),
+		Metadata: model.JSONMap{
+			"source_connector": conn.Type(),
+			"source_key":       doc.Key,
+		},
+		// 是否变化已按源地址判断，不与知识库中其他文档去重
+		Force: true,
+	})
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	result.Imported = append(result.Imported, imported.DocumentID)
+
+	for _, d := range existing {
+		if err := b.store.Knowledge().DeleteDocument(ctx, d.ID); err != nil {
+			return fmt.Errorf("delete previous version %s: %w", d.ID, err)
+		}
+		b.publishDocument(events.DocumentDeleted, d, nil)
+		result.Deleted++
+	}
+	return nil
+}
+
+// deleteSourceDocuments 删除源地址对应的所有文档.
+func (b *bizImpl) deleteSourceDocuments(ctx context.Context, kbID, uri string, result *SyncResult) error {
+	existing, err := b.store.Knowledge().ListDocumentsBySourceURI(ctx, kbID, uri)
+	if err != nil {
+		return fmt.Errorf("list existing documents: %w", err)
+	}
+	for _, d := range existing {
+		if err := b.store.Knowledge().DeleteDocument(ctx, d.ID); err != nil {
+			return fmt.Errorf("delete document %s: %w", d.ID, err)
+		}
+		b.publishDocument(events.DocumentDeleted, d, nil)
+		result.Deleted++
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package knowledge
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/connector"
+)
+
+func TestValidateSyncConfigRequiresWebhookSecret(t *testing.T) {
+	options := map[string]any{"bucket": "docs", "region": "us-east-1"}
+	tests := []struct {
+		name    string
+		cfg     model.JSONMap
+		wantErr bool
+	}{
+		{name: "no sync config", cfg: nil},
+		{name: "webhook without secret", cfg: model.JSONMap{"type": connector.TypeS3, "options": options}, wantErr: true},
+		{name: "webhook with secret", cfg: model.JSONMap{"type": connector.TypeS3, "webhook_secret": "s3cret", "options": options}},
+		{name: "webhook disabled", cfg: model.JSONMap{"type": connector.TypeS3, "disable_webhook": true, "options": options}},
+		{name: "unknown type", cfg: model.JSONMap{"type": "ftp", "webhook_secret": "s3cret"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSyncConfig(&model.KnowledgeBase{SyncConfig: tt.cfg})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKnowledgeBase) {
+					t.Fatalf("validateSyncConfig() error = %v, want ErrInvalidKnowledgeBase", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateSyncConfig() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	kb := &model.KnowledgeBase{ID: "kb1", SyncConfig: model.JSONMap{"type": connector.TypeS3, "webhook_secret": "s3cret"}}
+	got := RedactSecrets(kb)
+	if got.SyncConfig["webhook_secret"] != connector.RedactedValue {
+		t.Errorf("webhook_secret = %v, want redacted", got.SyncConfig["webhook_secret"])
+	}
+	if kb.SyncConfig["webhook_secret"] != "s3cret" {
+		t.Errorf("RedactSecrets modified the stored knowledge base")
+	}
+}
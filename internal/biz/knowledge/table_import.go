@@ -0,0 +1,195 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/docparse"
+)
+
+var (
+	// ErrInvalidColumnMapping 表格导入的列映射不合法.
+	ErrInvalidColumnMapping = errs.New(errs.ErrValidation, "invalid column mapping")
+	// ErrInvalidTableFile 表格文件无法读取或格式不支持.
+	ErrInvalidTableFile = errs.New(errs.ErrValidation, "invalid table file")
+)
+
+// ColumnMapping 表格列到文档字段的映射.
+type ColumnMapping struct {
+	// Title 作为文档标题的列，为空时标题为 "<文件名> #<行号>"
+	Title string `json:"title,omitempty"`
+	// Content 作为文档内容的列（必填），内容为空的行会被跳过
+	Content string `json:"content"`
+	// Metadata 文档元数据键 -> 列名，例如 {"url": "链接", "category": "分类"}，元数据会复制到分块中用于过滤
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// TableImportRequest 表格导入请求：CSV / XLSX 的每一行按列映射导入为一个文档.
+type TableImportRequest struct {
+	KnowledgeBaseID string
+	FileName        string
+	FileReader      io.Reader
+	Mapping         ColumnMapping
+	// Metadata 所有行共用的文档元数据，与列映射的键相同时以列值为准
+	Metadata model.JSONMap
+
+	ChunkSize    int
+	ChunkOverlap int
+	ChunkUnit    ChunkUnit
+}
+
+// TableRowError 导入失败的行.
+type TableRowError struct {
+	Row   int    `json:"row"` // 文件中的行号（表头为第 1 行）
+	Error string `json:"error"`
+}
+
+// TableImportResult 表格导入结果.
+type TableImportResult struct {
+	Imported  int             `json:"imported"`
+	Skipped   int             `json:"skipped"` // 内容为空而跳过的行数
+	Documents []*ImportResult `json:"documents"`
+	Errors    []TableRowError `json:"errors,omitempty"`
+}
+
+// tableColumns 列映射解析出的列下标.
+type tableColumns struct {
+	title    int // 未映射时为 -1
+	content  int
+	metadata map[string]int
+}
+
+// resolve 按表头校验列映射并返回列下标.
+func (m *ColumnMapping) resolve(header []string) (*tableColumns, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if name == "" {
+			continue
+		}
+		if _, ok := index[name]; ok {
+			// 重名列只有被映射时才报错
+			index[name] = -1
+			continue
+		}
+		index[name] = i
+	}
+	lookup := func(field, column string) (int, error) {
+		i, ok := index[column]
+		if !ok {
+			return 0, fmt.Errorf("%w: %s column %q not found in header", ErrInvalidColumnMapping, field, column)
+		}
+		if i < 0 {
+			return 0, fmt.Errorf("%w: %s column %q is ambiguous, header has duplicate columns", ErrInvalidColumnMapping, field, column)
+		}
+		return i, nil
+	}
+
+	if strings.TrimSpace(m.Content) == "" {
+		return nil, fmt.Errorf("%w: content column is required", ErrInvalidColumnMapping)
+	}
+	cols := &tableColumns{title: -1, metadata: make(map[string]int, len(m.Metadata))}
+	var err error
+	if cols.content, err = lookup("content", m.Content); err != nil {
+		return nil, err
+	}
+	if m.Title != "" {
+		if cols.title, err = lookup("title", m.Title); err != nil {
+			return nil, err
+		}
+	}
+	for key, column := range m.Metadata {
+		if strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("%w: metadata key is required", ErrInvalidColumnMapping)
+		}
+		if cols.metadata[key], err = lookup("metadata "+key, column); err != nil {
+			return nil, err
+		}
+	}
+	return cols, nil
+}
+
+// ImportTable 按列映射导入 CSV / XLSX 表格，每行生成一个文本文档.
+// 映射的元数据键会复制到分块元数据中，单行导入失败时记录错误并继续导入其余行.
+func (b *bizImpl) ImportTable(ctx context.Context, req *TableImportRequest) (*TableImportResult, error) {
+	if _, err := b.store.Knowledge().GetKnowledgeBase(ctx, req.KnowledgeBaseID); err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+
+	header, rows, err := docparse.ReadTable(req.FileName, req.FileReader)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTableFile, err)
+	}
+	cols, err := req.Mapping.resolve(header)
+	if err != nil {
+		return nil, err
+	}
+
+	propagateKeys := make([]string, 0, len(cols.metadata))
+	for key := range cols.metadata {
+		propagateKeys = append(propagateKeys, key)
+	}
+	baseName := strings.TrimSuffix(filepath.Base(req.FileName), filepath.Ext(req.FileName))
+
+	result := &TableImportResult{Documents: []*ImportResult{}}
+	for i, row := range rows {
+		rowNum := i + 2
+		content := strings.TrimSpace(cell(row, cols.content))
+		if content == "" {
+			result.Skipped++
+			continue
+		}
+
+		title := fmt.Sprintf("%s #%d", baseName, rowNum)
+		if cols.title >= 0 {
+			if t := strings.TrimSpace(cell(row, cols.title)); t != "" {
+				title = t
+			}
+		}
+
+		metadata := model.JSONMap{}
+		for k, v := range req.Metadata {
+			metadata[k] = v
+		}
+		for key, col := range cols.metadata {
+			if v := strings.TrimSpace(cell(row, col)); v != "" {
+				metadata[key] = v
+			} else {
+				delete(metadata, key)
+			}
+		}
+		metadata["source_file"] = req.FileName
+		metadata["source_row"] = rowNum
+
+		imported, err := b.ImportDocument(ctx, &ImportRequest{
+			KnowledgeBaseID:       req.KnowledgeBaseID,
+			Title:                 title,
+			SourceType:            "text",
+			Content:               content,
+			Metadata:              metadata,
+			PropagateMetadataKeys: propagateKeys,
+			ChunkSize:             req.ChunkSize,
+			ChunkOverlap:          req.ChunkOverlap,
+			ChunkUnit:             req.ChunkUnit,
+		})
+		if err != nil {
+			result.Errors = append(result.Errors, TableRowError{Row: rowNum, Error: err.Error()})
+			continue
+		}
+		result.Imported++
+		result.Documents = append(result.Documents, imported)
+	}
+	return result, nil
+}
+
+// cell 返回行中第 i 列的值，行长度不足时返回空字符串.
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
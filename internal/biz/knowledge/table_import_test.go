@@ -0,0 +1,108 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+const productsCSV = `名称,描述,链接,分类
+退款政策,订单签收后 7 天内可申请退款。,https://example.com/refund,售后
+空行,,https://example.com/empty,售后
+配送说明,默认使用顺丰配送。,,物流
+`
+
+func TestImportTableMapsColumns(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	b := NewBiz(s, &fakeEmbedder{}, nil, nil, nil).(*bizImpl)
+
+	result, err := b.ImportTable(context.Background(), &TableImportRequest{
+		KnowledgeBaseID: "kb1",
+		FileName:        "products.csv",
+		FileReader:      strings.NewReader(productsCSV),
+		Mapping: ColumnMapping{
+			Title:    "名称",
+			Content:  "描述",
+			Metadata: map[string]string{"url": "链接", "category": "分类"},
+		},
+		Metadata: model.JSONMap{"url": "https://example.com", "team": "support"},
+	})
+	if err != nil {
+		t.Fatalf("ImportTable() error = %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 1 || len(result.Errors) != 0 {
+		t.Fatalf("result = %+v, want 2 imported and the empty row skipped", result)
+	}
+
+	refund := s.knowledge.docs[result.Documents[0].DocumentID]
+	if refund.Title != "退款政策" || refund.Metadata["url"] != "https://example.com/refund" ||
+		refund.Metadata["category"] != "售后" || refund.Metadata["team"] != "support" || refund.Metadata["source_row"] != 2 {
+		t.Errorf("first document = %q %v", refund.Title, refund.Metadata)
+	}
+	// 映射列为空时不保留共用元数据中的同名键
+	shipping := s.knowledge.docs[result.Documents[1].DocumentID]
+	if _, ok := shipping.Metadata["url"]; ok || shipping.Metadata["category"] != "物流" || shipping.Metadata["source_row"] != 4 {
+		t.Errorf("second document metadata = %v", shipping.Metadata)
+	}
+
+	// 映射的元数据复制到分块，用于检索过滤
+	chunks := s.knowledge.documentChunks(refund.ID)
+	if len(chunks) == 0 || !strings.Contains(chunks[0].Content, "7 天内") {
+		t.Fatalf("chunks = %v, want the description column as content", chunks)
+	}
+	if chunks[0].Metadata["category"] != "售后" || chunks[0].Metadata["url"] != "https://example.com/refund" {
+		t.Errorf("chunk metadata = %v, want mapped keys", chunks[0].Metadata)
+	}
+	if _, ok := chunks[0].Metadata["team"]; ok {
+		t.Errorf("chunk metadata = %v, unmapped keys should stay on the document", chunks[0].Metadata)
+	}
+}
+
+func TestImportTableValidatesMapping(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	b := NewBiz(s, &fakeEmbedder{}, nil, nil, nil).(*bizImpl)
+
+	tests := []struct {
+		name    string
+		csv     string
+		mapping ColumnMapping
+	}{
+		{"content required", productsCSV, ColumnMapping{Title: "名称"}},
+		{"unknown content column", productsCSV, ColumnMapping{Content: "正文"}},
+		{"unknown metadata column", productsCSV, ColumnMapping{Content: "描述", Metadata: map[string]string{"url": "网址"}}},
+		{"empty metadata key", productsCSV, ColumnMapping{Content: "描述", Metadata: map[string]string{" ": "链接"}}},
+		{"duplicate column", "描述,描述\na,b\n", ColumnMapping{Content: "描述"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := b.ImportTable(context.Background(), &TableImportRequest{
+				KnowledgeBaseID: "kb1",
+				FileName:        "products.csv",
+				FileReader:      strings.NewReader(tt.csv),
+				Mapping:         tt.mapping,
+			})
+			if !errors.Is(err, ErrInvalidColumnMapping) || !errors.Is(err, errs.ErrValidation) {
+				t.Errorf("ImportTable() error = %v, want ErrInvalidColumnMapping", err)
+			}
+		})
+	}
+	if len(s.knowledge.docs) != 0 {
+		t.Errorf("invalid mappings created %d documents", len(s.knowledge.docs))
+	}
+
+	_, err := b.ImportTable(context.Background(), &TableImportRequest{
+		KnowledgeBaseID: "kb1",
+		FileName:        "products.pdf",
+		FileReader:      strings.NewReader(productsCSV),
+		Mapping:         ColumnMapping{Content: "描述"},
+	})
+	if !errors.Is(err, ErrInvalidTableFile) {
+		t.Errorf("ImportTable(pdf) error = %v, want ErrInvalidTableFile", err)
+	}
+}
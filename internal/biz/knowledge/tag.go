@@ -0,0 +1,194 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// ErrInvalidTagParent 父标签不存在、不在同一知识库或会形成环.
+var ErrInvalidTagParent = errs.New(errs.ErrValidation, "invalid tag parent")
+
+// TagNode 标签树的节点.
+type TagNode struct {
+	*model.KnowledgeTag
+	Children []*TagNode `json:"children"`
+}
+
+// TenantTag 租户内同名标签的使用情况，各知识库的同名标签视为同一标签.
+type TenantTag struct {
+	Name string `json:"name"`
+	// ChunkCount 所有知识库中使用该标签的分块数
+	ChunkCount         int64 `json:"chunk_count"`
+	KnowledgeBaseCount int   `json:"knowledge_base_count"`
+	// KnowledgeBases 按知识库的明细，仅在请求明细时返回
+	KnowledgeBases []*TagKnowledgeBaseUsage `json:"knowledge_bases,omitempty"`
+}
+
+// TagKnowledgeBaseUsage 标签在单个知识库中的使用情况.
+type TagKnowledgeBaseUsage struct {
+	TagID             string `json:"tag_id"`
+	KnowledgeBaseID   string `json:"knowledge_base_id"`
+	KnowledgeBaseName string `json:"knowledge_base_name"`
+	Color             string `json:"color,omitempty"`
+	ChunkCount        int64  `json:"chunk_count"`
+}
+
+// ListTagsWithCounts 列出租户所有知识库的标签及使用的分块数，按标签名合并，byKnowledgeBase 为 true 时返回各知识库的明细.
+func (b *bizImpl) ListTagsWithCounts(ctx context.Context, tenantID string, byKnowledgeBase bool) ([]*TenantTag, error) {
+	if _, err := b.store.Tenants().Get(ctx, tenantID); err != nil {
+		return nil, fmt.Errorf("get tenant: %w", err)
+	}
+	usage, err := b.store.Knowledge().ListTagUsageByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list tag usage: %w", err)
+	}
+
+	// 结果已按标签名排序，同名标签相邻
+	tags := make([]*TenantTag, 0, len(usage))
+	var current *TenantTag
+	for _, u := range usage {
+		if current == nil || current.Name != u.Name {
+			current = &TenantTag{Name: u.Name}
+			tags = append(tags, current)
+		}
+		current.ChunkCount += u.ChunkCount
+		current.KnowledgeBaseCount++
+		if byKnowledgeBase {
+			current.KnowledgeBases = append(current.KnowledgeBases, &TagKnowledgeBaseUsage{
+				TagID:             u.TagID,
+				KnowledgeBaseID:   u.KnowledgeBaseID,
+				KnowledgeBaseName: u.KnowledgeBaseName,
+				Color:             u.Color,
+				ChunkCount:        u.ChunkCount,
+			})
+		}
+	}
+	return tags, nil
+}
+
+// CreateTag 创建标签，指定父标签时父标签必须属于同一知识库.
+func (b *bizImpl) CreateTag(ctx context.Context, tag *model.KnowledgeTag) error {
+	if tag.ParentID != nil && *tag.ParentID == "" {
+		tag.ParentID = nil
+	}
+	if tag.ParentID != nil {
+		parent, err := b.store.Knowledge().GetTag(ctx, *tag.ParentID)
+		if err != nil {
+			return fmt.Errorf("%w: parent tag %s not found", ErrInvalidTagParent, *tag.ParentID)
+		}
+		if parent.KnowledgeBaseID != tag.KnowledgeBaseID {
+			return fmt.Errorf("%w: parent tag belongs to another knowledge base", ErrInvalidTagParent)
+		}
+	}
+	return b.store.Knowledge().CreateTag(ctx, tag)
+}
+
+// MoveTag 将标签移到 parentID 下，parentID 为空时移为顶层标签.
+// 父标签必须属于同一知识库，且不能是标签自身或其子孙标签.
+func (b *bizImpl) MoveTag(ctx context.Context, tagID string, parentID *string) (*model.KnowledgeTag, error) {
+	tag, err := b.store.Knowledge().GetTag(ctx, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("get tag: %w", err)
+	}
+	if parentID != nil && *parentID == "" {
+		parentID = nil
+	}
+	if parentID != nil {
+		tags, err := b.store.Knowledge().ListTagsByKnowledgeBase(ctx, tag.KnowledgeBaseID)
+		if err != nil {
+			return nil, fmt.Errorf("list tags: %w", err)
+		}
+		byID := make(map[string]*model.KnowledgeTag, len(tags))
+		for _, t := range tags {
+			byID[t.ID] = t
+		}
+		if _, ok := byID[*parentID]; !ok {
+			return nil, fmt.Errorf("%w: parent tag %s not found in knowledge base", ErrInvalidTagParent, *parentID)
+		}
+		// 沿新父标签向上查找，遇到标签自身说明会形成环
+		visited := make(map[string]bool)
+		for id := *parentID; id != "" && !visited[id]; {
+			if id == tag.ID {
+				return nil, fmt.Errorf("%w: tag cannot be moved under itself or its descendants", ErrInvalidTagParent)
+			}
+			visited[id] = true
+			next := ""
+			if p := byID[id]; p != nil && p.ParentID != nil {
+				next = *p.ParentID
+			}
+			id = next
+		}
+	}
+
+	tag.ParentID = parentID
+	if err := b.store.Knowledge().UpdateTag(ctx, tag); err != nil {
+		return nil, fmt.Errorf("update tag: %w", err)
+	}
+	return tag, nil
+}
+
+// GetTagTree 返回知识库的标签树，同级标签按名称排序.
+// 父标签不存在的标签作为顶层标签返回.
+func (b *bizImpl) GetTagTree(ctx context.Context, kbID string) ([]*TagNode, error) {
+	tags, err := b.store.Knowledge().ListTagsByKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	nodes := make(map[string]*TagNode, len(tags))
+	for _, t := range tags {
+		nodes[t.ID] = &TagNode{KnowledgeTag: t, Children: []*TagNode{}}
+	}
+	// 标签已按名称排序，按顺序挂到父节点下即保持同级有序
+	roots := make([]*TagNode, 0)
+	for _, t := range tags {
+		node := nodes[t.ID]
+		if t.ParentID != nil {
+			if parent, ok := nodes[*t.ParentID]; ok && parent != node {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots, nil
+}
+
+// expandTagIDs 返回 tagIDs 及其所有子孙标签的 ID，标签必须属于知识库 kbID.
+func (b *bizImpl) expandTagIDs(ctx context.Context, kbID string, tagIDs []string) ([]string, error) {
+	tags, err := b.store.Knowledge().ListTagsByKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	known := make(map[string]bool, len(tags))
+	children := make(map[string][]string)
+	for _, t := range tags {
+		known[t.ID] = true
+		if t.ParentID != nil {
+			children[*t.ParentID] = append(children[*t.ParentID], t.ID)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	queue := make([]string, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if !known[id] {
+			return nil, fmt.Errorf("%w: tag %s not found in knowledge base", ErrInvalidSearchRequest, id)
+		}
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		queue = append(queue, children[id]...)
+	}
+	return ids, nil
+}
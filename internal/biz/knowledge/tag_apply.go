@@ -0,0 +1,129 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// 按检索结果批量打标签时的检索方式.
+const (
+	// ApplyTagModeKeyword 全文检索（默认）
+	ApplyTagModeKeyword = "keyword"
+	// ApplyTagModeSemantic 向量检索
+	ApplyTagModeSemantic = "semantic"
+)
+
+const (
+	// defaultApplyTagMaxChunks 未指定时最多打标签的分块数
+	defaultApplyTagMaxChunks = 1000
+	// maxApplyTagMaxChunks 单次请求最多打标签的分块数
+	maxApplyTagMaxChunks = 10000
+	// applyTagBatchSize 每批写入的分块标签关联数
+	applyTagBatchSize = 500
+)
+
+// ErrTagNotInKnowledgeBase 标签不属于指定的知识库.
+var ErrTagNotInKnowledgeBase = errs.New(errs.ErrValidation, "tag does not belong to knowledge base")
+
+// ApplyTagByQueryRequest 按检索结果批量打标签的请求.
+type ApplyTagByQueryRequest struct {
+	Query string
+	// Mode 检索方式（keyword/semantic），为空时使用 keyword
+	Mode string
+	// ScoreThreshold 只为分数不低于该值的分块打标签，keyword 为 ts_rank_cd 分数，semantic 为相似度
+	ScoreThreshold float64
+	// MaxChunks 最多打标签的分块数，<=0 时使用 1000，最大 10000
+	MaxChunks int
+}
+
+// ApplyTagResult 批量打标签的结果.
+type ApplyTagResult struct {
+	TagID string `json:"tag_id"`
+	// Matched 检索命中且分数达到阈值的分块数，Tagged 为新增标签的分块数，其余分块已带有该标签
+	Matched int   `json:"matched"`
+	Tagged  int64 `json:"tagged"`
+}
+
+// ApplyTagByQuery 在知识库中检索 req.Query，为分数达到阈值的分块批量添加标签.
+func (b *bizImpl) ApplyTagByQuery(ctx context.Context, kbID, tagID string, req *ApplyTagByQueryRequest) (*ApplyTagResult, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: query is required", ErrInvalidSearchRequest)
+	}
+	if req.ScoreThreshold < 0 {
+		return nil, fmt.Errorf("%w: score_threshold must be non-negative", ErrInvalidSearchRequest)
+	}
+	limit := req.MaxChunks
+	if limit <= 0 {
+		limit = defaultApplyTagMaxChunks
+	}
+	limit = min(limit, maxApplyTagMaxChunks)
+
+	kb, err := b.store.Knowledge().GetKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, fmt.Errorf("get knowledge base: %w", err)
+	}
+	tag, err := b.store.Knowledge().GetTag(ctx, tagID)
+	if err != nil {
+		return nil, fmt.Errorf("get tag: %w", err)
+	}
+	if tag.KnowledgeBaseID != kb.ID {
+		return nil, ErrTagNotInKnowledgeBase
+	}
+
+	var results []*store.ChunkWithScore
+	switch req.Mode {
+	case ApplyTagModeKeyword, "":
+		results, err = b.store.Knowledge().SearchChunksByFullText(ctx, []string{kb.ID}, query, limit)
+	case ApplyTagModeSemantic:
+		results, err = b.searchChunksBySemantic(ctx, kb, query, limit, req.ScoreThreshold)
+	default:
+		return nil, fmt.Errorf("%w: unsupported mode %q", ErrInvalidSearchRequest, req.Mode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("search chunks: %w", err)
+	}
+
+	chunkIDs := make([]string, 0, len(results))
+	for _, r := range results {
+		if r.Score >= req.ScoreThreshold {
+			chunkIDs = append(chunkIDs, r.Chunk.ID)
+		}
+	}
+	tagged, err := b.store.Knowledge().ApplyTagToChunks(ctx, tag.ID, chunkIDs, applyTagBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("apply tag: %w", err)
+	}
+	return &ApplyTagResult{TagID: tag.ID, Matched: len(chunkIDs), Tagged: tagged}, nil
+}
+
+// searchChunksBySemantic 按查询文本的向量检索知识库分块.
+func (b *bizImpl) searchChunksBySemantic(ctx context.Context, kb *model.KnowledgeBase, query string, limit int, threshold float64) ([]*store.ChunkWithScore, error) {
+	if b.embedder == nil {
+		return nil, fmt.Errorf("%w: semantic mode requires an embedder", ErrInvalidSearchRequest)
+	}
+	distance, err := searchDistanceFunction(kb, "")
+	if err != nil {
+		return nil, err
+	}
+	embeddings, err := b.embedder.EmbedStrings(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(embeddings) == 0 {
+		return nil, nil
+	}
+	queryVector := make([]float32, len(embeddings[0]))
+	for i, v := range embeddings[0] {
+		queryVector[i] = float32(v)
+	}
+	return b.store.Knowledge().SearchChunksByVectorWithOptions(ctx, []string{kb.ID}, queryVector, limit, store.SearchOptions{
+		DistanceFunction: distance,
+		ScoreThreshold:   &threshold,
+	})
+}
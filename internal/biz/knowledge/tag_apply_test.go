@@ -0,0 +1,82 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestApplyTagByKeywordQuery(t *testing.T) {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	s.knowledge.kbs["kb2"] = &model.KnowledgeBase{ID: "kb2"}
+	s.knowledge.tags["compliance"] = &model.KnowledgeTag{ID: "compliance", KnowledgeBaseID: "kb1", Name: "compliance"}
+	s.knowledge.tags["other"] = &model.KnowledgeTag{ID: "other", KnowledgeBaseID: "kb2", Name: "compliance"}
+	for id, content := range map[string]string{
+		"gdpr-rights":   "GDPR gives users the right to erasure. GDPR requests are answered within 30 days.",
+		"gdpr-mention":  "Our privacy policy follows GDPR.",
+		"gdpr-tagged":   "GDPR data processing agreements are signed by legal.",
+		"shipping":      "Orders ship within five work days.",
+		"other-kb-gdpr": "GDPR in another knowledge base.",
+	} {
+		kbID := "kb1"
+		if id == "other-kb-gdpr" {
+			kbID = "kb2"
+		}
+		s.knowledge.chunks[id] = &model.KnowledgeChunk{ID: id, KnowledgeBaseID: kbID, DocumentID: "d1", Content: content}
+	}
+	s.knowledge.chunkTags["gdpr-tagged"] = []string{"compliance"}
+	b := NewBiz(s, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	result, err := b.ApplyTagByQuery(ctx, "kb1", "compliance", &ApplyTagByQueryRequest{Query: "gdpr"})
+	if err != nil {
+		t.Fatalf("ApplyTagByQuery: %v", err)
+	}
+	// 已带有标签的分块计入命中但不重复添加
+	if result.TagID != "compliance" || result.Matched != 3 || result.Tagged != 2 {
+		t.Errorf("result = %+v, want 3 matched and 2 newly tagged", result)
+	}
+	for _, id := range []string{"gdpr-rights", "gdpr-mention", "gdpr-tagged"} {
+		if tags := s.knowledge.chunkTags[id]; !slices.Equal(tags, []string{"compliance"}) {
+			t.Errorf("chunk %s tags = %v, want [compliance] once", id, tags)
+		}
+	}
+	for _, id := range []string{"shipping", "other-kb-gdpr"} {
+		if tags := s.knowledge.chunkTags[id]; len(tags) != 0 {
+			t.Errorf("chunk %s tags = %v, want untouched", id, tags)
+		}
+	}
+
+	// 分数阈值之下的分块不打标签
+	delete(s.knowledge.chunkTags, "gdpr-rights")
+	delete(s.knowledge.chunkTags, "gdpr-mention")
+	result, err = b.ApplyTagByQuery(ctx, "kb1", "compliance", &ApplyTagByQueryRequest{Query: "gdpr", ScoreThreshold: 2})
+	if err != nil {
+		t.Fatalf("ApplyTagByQuery with threshold: %v", err)
+	}
+	if result.Matched != 1 || result.Tagged != 1 || len(s.knowledge.chunkTags["gdpr-mention"]) != 0 {
+		t.Errorf("thresholded result = %+v, want only the chunk scoring 2 tagged", result)
+	}
+
+	invalid := []struct {
+		name  string
+		tagID string
+		req   *ApplyTagByQueryRequest
+		want  error
+	}{
+		{"empty query", "compliance", &ApplyTagByQueryRequest{Query: "  "}, ErrInvalidSearchRequest},
+		{"negative threshold", "compliance", &ApplyTagByQueryRequest{Query: "gdpr", ScoreThreshold: -1}, ErrInvalidSearchRequest},
+		{"unknown mode", "compliance", &ApplyTagByQueryRequest{Query: "gdpr", Mode: "fuzzy"}, ErrInvalidSearchRequest},
+		{"semantic without embedder", "compliance", &ApplyTagByQueryRequest{Query: "gdpr", Mode: ApplyTagModeSemantic}, ErrInvalidSearchRequest},
+		{"tag of another knowledge base", "other", &ApplyTagByQueryRequest{Query: "gdpr"}, ErrTagNotInKnowledgeBase},
+	}
+	for _, tt := range invalid {
+		if _, err := b.ApplyTagByQuery(ctx, "kb1", tt.tagID, tt.req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
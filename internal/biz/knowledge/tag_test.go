@@ -0,0 +1,200 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+func TestListTagsWithCounts(t *testing.T) {
+	s := newFakeStore()
+	s.tenants.tenants["t1"] = &model.Tenant{ID: "t1"}
+	s.tenants.tenants["t2"] = &model.Tenant{ID: "t2"}
+	s.knowledge.kbs["kb-faq"] = &model.KnowledgeBase{ID: "kb-faq", Name: "FAQ", TenantID: "t1"}
+	s.knowledge.kbs["kb-policy"] = &model.KnowledgeBase{ID: "kb-policy", Name: "Policy", TenantID: "t1"}
+	s.knowledge.kbs["kb-other"] = &model.KnowledgeBase{ID: "kb-other", Name: "Other", TenantID: "t2"}
+	for _, tag := range []*model.KnowledgeTag{
+		{ID: "faq-refund", KnowledgeBaseID: "kb-faq", Name: "refund", Color: "red"},
+		{ID: "policy-refund", KnowledgeBaseID: "kb-policy", Name: "refund", Color: "blue"},
+		{ID: "faq-shipping", KnowledgeBaseID: "kb-faq", Name: "shipping"},
+		{ID: "policy-unused", KnowledgeBaseID: "kb-policy", Name: "archived"},
+		{ID: "other-refund", KnowledgeBaseID: "kb-other", Name: "refund"},
+	} {
+		s.knowledge.tags[tag.ID] = tag
+	}
+	s.knowledge.chunkTags["c1"] = []string{"faq-refund", "faq-shipping"}
+	s.knowledge.chunkTags["c2"] = []string{"faq-refund"}
+	s.knowledge.chunkTags["c3"] = []string{"policy-refund"}
+	s.knowledge.chunkTags["c4"] = []string{"faq-shipping"}
+	s.knowledge.chunkTags["c5"] = []string{"other-refund"}
+	b := NewBiz(s, nil, nil, nil, nil).(*bizImpl)
+	ctx := context.Background()
+
+	tags, err := b.ListTagsWithCounts(ctx, "t1", true)
+	if err != nil {
+		t.Fatalf("ListTagsWithCounts: %v", err)
+	}
+	// 同名标签跨知识库合并，其他租户的标签不计入，未使用的标签计数为 0
+	want := []struct {
+		name   string
+		chunks int64
+		kbs    int
+	}{
+		{"archived", 0, 1},
+		{"refund", 3, 2},
+		{"shipping", 2, 1},
+	}
+	if len(tags) != len(want) {
+		t.Fatalf("got %d tags, want %d", len(tags), len(want))
+	}
+	for i, w := range want {
+		if tags[i].Name != w.name || tags[i].ChunkCount != w.chunks || tags[i].KnowledgeBaseCount != w.kbs {
+			t.Errorf("tag %d = %s (%d chunks, %d kbs), want %s (%d chunks, %d kbs)",
+				i, tags[i].Name, tags[i].ChunkCount, tags[i].KnowledgeBaseCount, w.name, w.chunks, w.kbs)
+		}
+	}
+	refund := tags[1].KnowledgeBases
+	if len(refund) != 2 {
+		t.Fatalf("refund breakdown has %d knowledge bases, want 2", len(refund))
+	}
+	if refund[0].TagID != "faq-refund" || refund[0].KnowledgeBaseName != "FAQ" || refund[0].ChunkCount != 2 || refund[0].Color != "red" ||
+		refund[1].TagID != "policy-refund" || refund[1].KnowledgeBaseName != "Policy" || refund[1].ChunkCount != 1 {
+		t.Errorf("refund breakdown = %+v %+v, want FAQ with 2 chunks and Policy with 1", refund[0], refund[1])
+	}
+
+	// 不请求明细时不返回各知识库的使用情况
+	tags, err = b.ListTagsWithCounts(ctx, "t1", false)
+	if err != nil {
+		t.Fatalf("ListTagsWithCounts: %v", err)
+	}
+	for _, tag := range tags {
+		if tag.KnowledgeBases != nil {
+			t.Errorf("tag %s breakdown = %v, want none", tag.Name, tag.KnowledgeBases)
+		}
+	}
+
+	if _, err := b.ListTagsWithCounts(ctx, "missing", false); err == nil {
+		t.Error("ListTagsWithCounts(missing tenant) succeeded, want error")
+	}
+}
+
+// addTag 在 kb1 中添加标签，parentID 为空表示顶层标签.
+func addTag(s *fakeStore, id, name, parentID string) {
+	tag := &model.KnowledgeTag{ID: id, KnowledgeBaseID: "kb1", Name: name}
+	if parentID != "" {
+		tag.ParentID = &parentID
+	}
+	s.knowledge.tags[id] = tag
+}
+
+// newTagTreeStore 返回带有 products > (phones > android, laptops) 和 support 标签树的知识库.
+func newTagTreeStore() *fakeStore {
+	s := newFakeStore()
+	s.knowledge.kbs["kb1"] = &model.KnowledgeBase{ID: "kb1"}
+	addTag(s, "products", "products", "")
+	addTag(s, "phones", "phones", "products")
+	addTag(s, "android", "android", "phones")
+	addTag(s, "laptops", "laptops", "products")
+	addTag(s, "support", "support", "")
+	return s
+}
+
+func TestSearchTagFilterIncludesDescendants(t *testing.T) {
+	s := newTagTreeStore()
+	for _, id := range []string{"c-products", "c-android", "c-laptops", "c-support", "c-untagged"} {
+		chunk := &model.KnowledgeChunk{ID: id, KnowledgeBaseID: "kb1", DocumentID: "d1", Content: id}
+		s.knowledge.chunks[id] = chunk
+		s.knowledge.searchResults = append(s.knowledge.searchResults, &store.ChunkWithScore{Chunk: chunk, Score: 0.5})
+	}
+	s.knowledge.chunkTags["c-products"] = []string{"products"}
+	s.knowledge.chunkTags["c-android"] = []string{"android"}
+	s.knowledge.chunkTags["c-laptops"] = []string{"laptops"}
+	s.knowledge.chunkTags["c-support"] = []string{"support"}
+	b := NewBiz(s, &fakeEmbedder{}, nil, nil, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		tagIDs []string
+		want   []string
+	}{
+		{"parent includes all descendants", []string{"products"}, []string{"c-android", "c-laptops", "c-products"}},
+		{"intermediate tag", []string{"phones"}, []string{"c-android"}},
+		{"leaf tag", []string{"laptops"}, []string{"c-laptops"}},
+		{"several tags", []string{"phones", "support"}, []string{"c-android", "c-support"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := b.Search(ctx, "kb1", &HybridSearchRequest{Query: "q", TagIDs: tt.tagIDs})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			var got []string
+			for _, c := range result.Chunks {
+				got = append(got, c.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("chunks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 没有标签过滤时不限制
+	if _, err := b.Search(ctx, "kb1", &HybridSearchRequest{Query: "q"}); err != nil || len(s.knowledge.searchOpts.TagIDs) != 0 {
+		t.Errorf("unfiltered search tag IDs = %v, %v, want none", s.knowledge.searchOpts.TagIDs, err)
+	}
+	// 其他知识库的标签不能用于过滤
+	s.knowledge.tags["foreign"] = &model.KnowledgeTag{ID: "foreign", KnowledgeBaseID: "kb2", Name: "foreign"}
+	if _, err := b.Search(ctx, "kb1", &HybridSearchRequest{Query: "q", TagIDs: []string{"foreign"}}); !errors.Is(err, ErrInvalidSearchRequest) {
+		t.Errorf("Search(foreign tag) error = %v, want ErrInvalidSearchRequest", err)
+	}
+}
+
+func TestMoveTagRejectsCycles(t *testing.T) {
+	s := newTagTreeStore()
+	b := NewBiz(s, nil, nil, nil, nil)
+	ctx := context.Background()
+	ptr := func(s string) *string { return &s }
+
+	for _, parent := range []string{"products", "phones", "android"} {
+		if _, err := b.MoveTag(ctx, "products", ptr(parent)); !errors.Is(err, ErrInvalidTagParent) {
+			t.Errorf("MoveTag(products under %s) error = %v, want ErrInvalidTagParent", parent, err)
+		}
+	}
+	if _, err := b.MoveTag(ctx, "android", ptr("missing")); !errors.Is(err, ErrInvalidTagParent) {
+		t.Errorf("MoveTag(missing parent) error = %v, want ErrInvalidTagParent", err)
+	}
+
+	// 移动后子孙标签随之移动，过滤按新的层级展开
+	if _, err := b.MoveTag(ctx, "phones", ptr("support")); err != nil {
+		t.Fatalf("MoveTag(phones under support): %v", err)
+	}
+	if _, err := b.MoveTag(ctx, "laptops", ptr("")); err != nil {
+		t.Fatalf("MoveTag(laptops to top level): %v", err)
+	}
+	tree, err := b.GetTagTree(ctx, "kb1")
+	if err != nil {
+		t.Fatalf("GetTagTree: %v", err)
+	}
+	if got := formatTagTree(tree); got != "laptops products support(phones(android))" {
+		t.Errorf("tag tree = %s", got)
+	}
+}
+
+// formatTagTree 以 name(children) 的形式输出标签树.
+func formatTagTree(nodes []*TagNode) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = n.Name
+		if len(n.Children) > 0 {
+			parts[i] += "(" + formatTagTree(n.Children) + ")"
+		}
+	}
+	return strings.Join(parts, " ")
+}
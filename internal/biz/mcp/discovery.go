@@ -0,0 +1,225 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/mcpclient"
+)
+
+// defaultTimeout MCP Server 未配置超时时间时连接和请求的超时时间.
+const defaultTimeout = 30 * time.Second
+
+// ErrUnsupportedTransport MCP Server 的传输类型或连接配置不支持检查和发现工具.
+var ErrUnsupportedTransport = errs.New(errs.ErrValidation, "unsupported mcp server transport")
+
+// 连接检查状态.
+const (
+	PingStatusOK          = "ok"
+	PingStatusUnreachable = "unreachable"
+)
+
+// PingResult MCP Server 连接检查结果.
+type PingResult struct {
+	ServerID        string `json:"server_id"`
+	Status          string `json:"status"`
+	TransportType   string `json:"transport_type"`
+	ServerName      string `json:"server_name,omitempty"`
+	ServerVersion   string `json:"server_version,omitempty"`
+	ProtocolVersion string `json:"protocol_version,omitempty"`
+	// ConnectMs 建立连接并完成 initialize 握手的耗时，LatencyMs 为 ping 请求的往返耗时
+	ConnectMs int64  `json:"connect_ms"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DiscoverResult 工具发现结果.
+type DiscoverResult struct {
+	ServerID  string           `json:"server_id"`
+	Created   int              `json:"created"`
+	Updated   int              `json:"updated"`
+	Unchanged int              `json:"unchanged"`
+	Tools     []*model.MCPTool `json:"tools"`
+	// Missing 已登记但 Server 不再提供的工具名称，不会被删除或禁用
+	Missing []string `json:"missing,omitempty"`
+}
+
+// PingServer 按 Server 配置的传输类型建立连接并发送 ping.
+// Server 不可达时返回 Status 为 unreachable 的结果而不是错误，Server 不存在或传输类型不支持时返回错误.
+func (b *bizImpl) PingServer(ctx context.Context, id string) (*PingResult, error) {
+	server, err := b.store.MCPServers().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := clientConfig(server)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, serverTimeout(server))
+	defer cancel()
+
+	result := &PingResult{ServerID: server.ID, TransportType: cfg.Transport, Status: PingStatusUnreachable}
+	start := time.Now()
+	session, err := mcpclient.Connect(ctx, cfg)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer session.Close()
+	result.ConnectMs = time.Since(start).Milliseconds()
+	result.ServerName = session.ServerInfo.Name
+	result.ServerVersion = session.ServerInfo.Version
+	result.ProtocolVersion = session.ProtocolVersion
+
+	start = time.Now()
+	if err := session.Ping(ctx); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.Status = PingStatusOK
+	return result, nil
+}
+
+// DiscoverTools 调用 Server 的 tools/list，按名称新增或更新 MCPTool 的描述和输入参数 Schema.
+// 已有工具的显示名称、启用状态和 ReturnDirectly 保持不变.
+func (b *bizImpl) DiscoverTools(ctx context.Context, id string) (*DiscoverResult, error) {
+	server, err := b.store.MCPServers().Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := clientConfig(server)
+	if err != nil {
+		return nil, err
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, serverTimeout(server))
+	defer cancel()
+	session, err := mcpclient.Connect(listCtx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect mcp server: %w", err)
+	}
+	defer session.Close()
+	remote, err := session.ListTools(listCtx)
+	if err != nil {
+		return nil, fmt.Errorf("list mcp tools: %w", err)
+	}
+
+	existing, err := b.store.MCPTools().ListByServer(ctx, server.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list mcp tools: %w", err)
+	}
+	byName := make(map[string]*model.MCPTool, len(existing))
+	for _, tool := range existing {
+		byName[tool.Name] = tool
+	}
+
+	result := &DiscoverResult{ServerID: server.ID, Tools: make([]*model.MCPTool, 0, len(remote))}
+	seen := make(map[string]bool, len(remote))
+	for _, t := range remote {
+		if t.Name == "" || seen[t.Name] {
+			continue
+		}
+		seen[t.Name] = true
+		schema := model.JSONMap(t.InputSchema)
+
+		tool, ok := byName[t.Name]
+		if !ok {
+			tool = &model.MCPTool{
+				ID:          uuid.New().String(),
+				MCPServerID: server.ID,
+				Name:        t.Name,
+				Description: t.Description,
+				InputSchema: schema,
+				IsEnabled:   true,
+			}
+			if err := b.store.MCPTools().Create(ctx, tool); err != nil {
+				return nil, fmt.Errorf("create mcp tool %s: %w", t.Name, err)
+			}
+			result.Created++
+		} else if tool.Description != t.Description || !schemaEqual(tool.InputSchema, schema) {
+			tool.Description = t.Description
+			tool.InputSchema = schema
+			if err := b.store.MCPTools().Update(ctx, tool); err != nil {
+				return nil, fmt.Errorf("update mcp tool %s: %w", t.Name, err)
+			}
+			result.Updated++
+		} else {
+			result.Unchanged++
+		}
+		result.Tools = append(result.Tools, tool)
+	}
+	for _, tool := range existing {
+		if !seen[tool.Name] {
+			result.Missing = append(result.Missing, tool.Name)
+		}
+	}
+	return result, nil
+}
+
+// clientConfig 将 Server 配置转换为客户端连接配置.
+func clientConfig(server *model.MCPServer) (*mcpclient.Config, error) {
+	cfg := &mcpclient.Config{
+		Command: server.Command,
+		Args:    server.Args,
+		URL:     server.ServerURL,
+		Env:     stringMap(server.Env),
+		Headers: stringMap(server.CustomHeaders),
+	}
+	switch server.TransportType {
+	case model.TransportTypeStdio, "":
+		cfg.Transport = mcpclient.TransportStdio
+	case model.TransportTypeStreamableHTTP:
+		cfg.Transport = mcpclient.TransportStreamableHTTP
+	case model.TransportTypeSSE:
+		cfg.Transport = mcpclient.TransportSSE
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedTransport, server.TransportType)
+	}
+	if cfg.Transport == mcpclient.TransportStdio && cfg.Command == "" {
+		return nil, fmt.Errorf("%w: stdio transport requires a command", ErrUnsupportedTransport)
+	}
+	if cfg.Transport != mcpclient.TransportStdio && cfg.URL == "" {
+		return nil, fmt.Errorf("%w: %s transport requires a server url", ErrUnsupportedTransport, cfg.Transport)
+	}
+	return cfg, nil
+}
+
+// serverTimeout 返回 Server 配置的超时时间.
+func serverTimeout(server *model.MCPServer) time.Duration {
+	if server.TimeoutSeconds <= 0 {
+		return defaultTimeout
+	}
+	return time.Duration(server.TimeoutSeconds) * time.Second
+}
+
+// stringMap 将 JSON 对象的值转换为字符串.
+func stringMap(m model.JSONMap) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		} else {
+			out[k] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
+
+// schemaEqual 比较两个 Schema，两者都经过 JSON 解码，数字均为 float64，可以直接比较.
+func schemaEqual(a, b model.JSONMap) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	return reflect.DeepEqual(map[string]any(a), map[string]any(b))
+}
@@ -4,9 +4,12 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/google/uuid"
 
+	"github.com/ashwinyue/next-show/internal/biz/errs"
 	"github.com/ashwinyue/next-show/internal/model"
 	"github.com/ashwinyue/next-show/internal/store"
 )
@@ -23,6 +26,12 @@ type Biz interface {
 	UpdateServer(ctx context.Context, id string, req *UpdateServerRequest) (*model.MCPServer, error)
 	// DeleteServer 删除 MCP Server.
 	DeleteServer(ctx context.Context, id string) error
+	// PingServer 连接 MCP Server 并返回状态和延迟.
+	PingServer(ctx context.Context, id string) (*PingResult, error)
+	// DiscoverTools 从 MCP Server 获取工具列表并同步到 MCPTool.
+	DiscoverTools(ctx context.Context, id string) (*DiscoverResult, error)
+	// ToolCacheStats 返回 Agent 使用的 MCP 连接缓存统计.
+	ToolCacheStats() ToolCacheStats
 
 	// ListTools 列出 MCP Server 的工具.
 	ListTools(ctx context.Context, serverID string) ([]*model.MCPTool, error)
@@ -48,6 +57,7 @@ type CreateServerRequest struct {
 	ServerURL      string              `json:"server_url"`
 	CustomHeaders  model.JSONMap       `json:"custom_headers"`
 	TimeoutSeconds int                 `json:"timeout_seconds"`
+	MaxRetries     int                 `json:"max_retries"`
 }
 
 // UpdateServerRequest 更新 MCP Server 请求.
@@ -62,6 +72,7 @@ type UpdateServerRequest struct {
 	ServerURL      *string              `json:"server_url,omitempty"`
 	CustomHeaders  model.JSONMap        `json:"custom_headers,omitempty"`
 	TimeoutSeconds *int                 `json:"timeout_seconds,omitempty"`
+	MaxRetries     *int                 `json:"max_retries,omitempty"`
 	IsEnabled      *bool                `json:"is_enabled,omitempty"`
 }
 
@@ -84,13 +95,63 @@ type UpdateToolRequest struct {
 	IsEnabled      *bool         `json:"is_enabled,omitempty"`
 }
 
+// ConfigError MCP Server 配置校验失败，Problems 列出所有问题.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid mcp server config: " + strings.Join(e.Problems, "; ")
+}
+
+// Unwrap 使 errors.Is(err, errs.ErrValidation) 成立.
+func (e *ConfigError) Unwrap() error {
+	return errs.ErrValidation
+}
+
+// maxRetries MCP 工具调用的最大重试次数.
+const maxRetries = 10
+
+// validateServer 校验 Server 配置：stdio 传输需要 Command，HTTP 传输需要有效的 http(s) ServerURL，TimeoutSeconds 不能为负数.
+func validateServer(server *model.MCPServer) error {
+	var problems []string
+	if strings.TrimSpace(server.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	switch server.TransportType {
+	case model.TransportTypeStdio, "":
+		if strings.TrimSpace(server.Command) == "" {
+			problems = append(problems, "command is required for stdio transport")
+		}
+	case model.TransportTypeStreamableHTTP, model.TransportTypeSSE:
+		if server.ServerURL == "" {
+			problems = append(problems, fmt.Sprintf("server_url is required for %s transport", server.TransportType))
+		} else if u, err := url.Parse(server.ServerURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("server_url %q is not a valid http(s) url", server.ServerURL))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("transport_type %q is not supported (stdio, streamable_http or sse)", server.TransportType))
+	}
+	if server.TimeoutSeconds < 0 {
+		problems = append(problems, "timeout_seconds must not be negative")
+	}
+	if server.MaxRetries < 0 || server.MaxRetries > maxRetries {
+		problems = append(problems, fmt.Sprintf("max_retries must be between 0 and %d", maxRetries))
+	}
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
 type bizImpl struct {
 	store store.Store
+	tools *ToolFactory
 }
 
-// NewBiz 创建 MCP 业务实例.
-func NewBiz(s store.Store) Biz {
-	return &bizImpl{store: s}
+// NewBiz 创建 MCP 业务实例，tools 为 Agent 使用的 MCP 工具工厂，Server 更新或删除时丢弃其缓存的连接.
+func NewBiz(s store.Store, tools *ToolFactory) Biz {
+	return &bizImpl{store: s, tools: tools}
 }
 
 func (b *bizImpl) ListServers(ctx context.Context) ([]*model.MCPServer, error) {
@@ -114,13 +175,17 @@ func (b *bizImpl) CreateServer(ctx context.Context, req *CreateServerRequest) (*
 		ServerURL:      req.ServerURL,
 		CustomHeaders:  req.CustomHeaders,
 		TimeoutSeconds: req.TimeoutSeconds,
+		MaxRetries:     req.MaxRetries,
 		IsEnabled:      true,
 	}
 
 	if server.TransportType == "" {
 		server.TransportType = model.TransportTypeStdio
 	}
-	if server.TimeoutSeconds <= 0 {
+	if err := validateServer(server); err != nil {
+		return nil, err
+	}
+	if server.TimeoutSeconds == 0 {
 		server.TimeoutSeconds = 30
 	}
 
@@ -167,19 +232,34 @@ func (b *bizImpl) UpdateServer(ctx context.Context, id string, req *UpdateServer
 	if req.TimeoutSeconds != nil {
 		server.TimeoutSeconds = *req.TimeoutSeconds
 	}
+	if req.MaxRetries != nil {
+		server.MaxRetries = *req.MaxRetries
+	}
 	if req.IsEnabled != nil {
 		server.IsEnabled = *req.IsEnabled
 	}
+	if err := validateServer(server); err != nil {
+		return nil, err
+	}
 
 	if err := b.store.MCPServers().Update(ctx, server); err != nil {
 		return nil, fmt.Errorf("update mcp server: %w", err)
 	}
+	b.tools.Invalidate(id)
 
 	return server, nil
 }
 
 func (b *bizImpl) DeleteServer(ctx context.Context, id string) error {
-	return b.store.MCPServers().Delete(ctx, id)
+	if err := b.store.MCPServers().Delete(ctx, id); err != nil {
+		return err
+	}
+	b.tools.Invalidate(id)
+	return nil
+}
+
+func (b *bizImpl) ToolCacheStats() ToolCacheStats {
+	return b.tools.Stats()
 }
 
 func (b *bizImpl) ListTools(ctx context.Context, serverID string) ([]*model.MCPTool, error) {
@@ -0,0 +1,403 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/mcpclient"
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// DefaultToolCacheTTL MCP Server 连接和工具列表的默认缓存时间.
+const DefaultToolCacheTTL = 5 * time.Minute
+
+// Config MCP 业务配置.
+type Config struct {
+	// ToolCacheTTL Agent 使用的 MCP Server 连接和工具列表的缓存时间，<= 0 使用 DefaultToolCacheTTL
+	ToolCacheTTL time.Duration `mapstructure:"tool_cache_ttl"`
+}
+
+// ToolCacheStats 工具缓存统计.
+type ToolCacheStats struct {
+	// Servers 当前缓存的 Server 连接数
+	Servers int   `json:"servers"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// serverConn 缓存的 Server 连接和工具列表.
+type serverConn struct {
+	server  *model.MCPServer
+	session *mcpclient.Session
+	tools   map[string]mcpclient.Tool
+	expires time.Time
+	// inflight 正在使用该连接的调用，过期或失效后等待它们结束再关闭连接
+	inflight sync.WaitGroup
+}
+
+// connSlot 一个 Server 的缓存位置，同一 Server 同时只建立一个连接.
+type connSlot struct {
+	mu      sync.Mutex
+	conn    *serverConn
+	removed bool
+}
+
+// ToolFactory 将 MCP Server 的工具包装为 Agent 可调用的工具.
+// 按 Server ID 缓存已连接的会话和 tools/list 结果，多个 Agent 共享同一 Server 时不必每次重新连接.
+type ToolFactory struct {
+	store store.Store
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	slots  map[string]*connSlot
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewToolFactory 创建 MCP 工具工厂，cfg 为 nil 时使用默认配置.
+func NewToolFactory(s store.Store, cfg *Config) *ToolFactory {
+	ttl := DefaultToolCacheTTL
+	if cfg != nil && cfg.ToolCacheTTL > 0 {
+		ttl = cfg.ToolCacheTTL
+	}
+	return &ToolFactory{store: s, ttl: ttl, slots: make(map[string]*connSlot)}
+}
+
+// Tools 将 Agent 关联的 MCP 工具包装为可调用的工具，返回 MCPTool ID -> 工具.
+// Server 不可用或不再提供某个工具时跳过相应工具并记录日志，不影响其他工具.
+func (f *ToolFactory) Tools(ctx context.Context, mcpTools []*model.MCPTool) map[string]tool.BaseTool {
+	tools := make(map[string]tool.BaseTool, len(mcpTools))
+	conns := make(map[string]*serverConn)
+	defer func() {
+		for _, conn := range conns {
+			if conn != nil {
+				conn.inflight.Done()
+			}
+		}
+	}()
+	for _, t := range mcpTools {
+		conn, ok := conns[t.MCPServerID]
+		if !ok {
+			var err error
+			if conn, err = f.acquire(ctx, t.MCPServerID); err != nil {
+				log.Printf("mcp server %s: %v, its tools are skipped", t.MCPServerID, err)
+			}
+			conns[t.MCPServerID] = conn
+		}
+		if conn == nil {
+			continue
+		}
+		remote, ok := conn.tools[t.Name]
+		if !ok {
+			log.Printf("mcp server %s no longer provides tool %s, skipped", t.MCPServerID, t.Name)
+			continue
+		}
+		info, err := toolInfo(t, remote)
+		if err != nil {
+			log.Printf("mcp tool %s: %v, skipped", t.Name, err)
+			continue
+		}
+		tools[t.ID] = &mcpTool{factory: f, serverID: t.MCPServerID, info: info, maxRetries: conn.server.MaxRetries}
+	}
+	return tools
+}
+
+// Invalidate 丢弃 Server 的缓存连接，下次使用时按最新配置重新连接.
+// 正在进行的调用不受影响，结束后再关闭旧连接；f 为 nil 时不做处理.
+func (f *ToolFactory) Invalidate(serverID string) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	slot, ok := f.slots[serverID]
+	delete(f.slots, serverID)
+	f.mu.Unlock()
+	if !ok {
+		return
+	}
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	slot.removed = true
+	retire(slot.conn)
+	slot.conn = nil
+}
+
+// Close 关闭所有缓存的连接.
+func (f *ToolFactory) Close() {
+	f.mu.Lock()
+	ids := make([]string, 0, len(f.slots))
+	for id := range f.slots {
+		ids = append(ids, id)
+	}
+	f.mu.Unlock()
+	for _, id := range ids {
+		f.Invalidate(id)
+	}
+}
+
+// Stats 返回缓存统计.
+func (f *ToolFactory) Stats() ToolCacheStats {
+	if f == nil {
+		return ToolCacheStats{}
+	}
+	f.mu.Lock()
+	servers := len(f.slots)
+	f.mu.Unlock()
+	return ToolCacheStats{Servers: servers, Hits: f.hits.Load(), Misses: f.misses.Load()}
+}
+
+// acquire 返回 Server 的连接，缓存不存在或已过期时重新连接并获取工具列表.
+// 使用完毕后需调用 conn.inflight.Done().
+func (f *ToolFactory) acquire(ctx context.Context, serverID string) (*serverConn, error) {
+	for {
+		f.mu.Lock()
+		slot, ok := f.slots[serverID]
+		if !ok {
+			slot = &connSlot{}
+			f.slots[serverID] = slot
+		}
+		f.mu.Unlock()
+
+		slot.mu.Lock()
+		if slot.removed {
+			// 等待期间缓存被丢弃，重新获取
+			slot.mu.Unlock()
+			continue
+		}
+		conn, err := f.connLocked(ctx, serverID, slot)
+		if err == nil {
+			conn.inflight.Add(1)
+		}
+		slot.mu.Unlock()
+		return conn, err
+	}
+}
+
+// connLocked 返回 slot 中未过期的连接，否则建立新连接，调用方需持有 slot.mu.
+func (f *ToolFactory) connLocked(ctx context.Context, serverID string, slot *connSlot) (*serverConn, error) {
+	if slot.conn != nil && time.Now().Before(slot.conn.expires) {
+		hits := f.hits.Add(1)
+		log.Printf("mcp tool cache hit: server %s (hits %d, misses %d)", serverID, hits, f.misses.Load())
+		return slot.conn, nil
+	}
+	misses := f.misses.Add(1)
+	log.Printf("mcp tool cache miss: server %s (hits %d, misses %d)", serverID, f.hits.Load(), misses)
+	retire(slot.conn)
+	slot.conn = nil
+
+	server, err := f.store.MCPServers().Get(ctx, serverID)
+	if err != nil {
+		return nil, fmt.Errorf("get mcp server: %w", err)
+	}
+	if !server.IsEnabled {
+		return nil, errServerDisabled
+	}
+	cfg, err := clientConfig(server)
+	if err != nil {
+		return nil, err
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, serverTimeout(server))
+	defer cancel()
+	session, err := mcpclient.Connect(connectCtx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect mcp server: %w", err)
+	}
+	remote, err := session.ListTools(connectCtx)
+	if err != nil {
+		_ = session.Close()
+		return nil, fmt.Errorf("list mcp tools: %w", err)
+	}
+
+	conn := &serverConn{
+		server:  server,
+		session: session,
+		tools:   make(map[string]mcpclient.Tool, len(remote)),
+		expires: time.Now().Add(f.ttl),
+	}
+	for _, t := range remote {
+		conn.tools[t.Name] = t
+	}
+	slot.conn = conn
+	return conn, nil
+}
+
+// discard 丢弃已断开的连接，slot 中已是其他连接时不做处理.
+func (f *ToolFactory) discard(serverID string, conn *serverConn) {
+	f.mu.Lock()
+	slot, ok := f.slots[serverID]
+	f.mu.Unlock()
+	if !ok {
+		return
+	}
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	if slot.conn == conn {
+		retire(conn)
+		slot.conn = nil
+	}
+}
+
+// retire 在正在进行的调用结束后关闭连接.
+func retire(conn *serverConn) {
+	if conn == nil {
+		return
+	}
+	go func() {
+		conn.inflight.Wait()
+		_ = conn.session.Close()
+	}()
+}
+
+// toolInfo 生成工具信息，描述优先使用 MCPTool 中配置的描述，参数 Schema 使用 Server 返回的定义.
+func toolInfo(t *model.MCPTool, remote mcpclient.Tool) (*schema.ToolInfo, error) {
+	desc := t.Description
+	if desc == "" {
+		desc = remote.Description
+	}
+	info := &schema.ToolInfo{Name: t.Name, Desc: desc}
+
+	inputSchema := remote.InputSchema
+	if len(inputSchema) == 0 {
+		inputSchema = t.InputSchema
+	}
+	if len(inputSchema) > 0 {
+		raw, err := json.Marshal(inputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("marshal input schema: %w", err)
+		}
+		var s jsonschema.Schema
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("invalid input schema: %w", err)
+		}
+		info.ParamsOneOf = schema.NewParamsOneOfByJSONSchema(&s)
+	}
+	return info, nil
+}
+
+// 工具调用最终失败的类型.
+const (
+	toolErrorTimeout     = "timeout"
+	toolErrorUnavailable = "unavailable"
+	toolErrorRPC         = "rpc_error"
+	toolErrorTool        = "tool_error"
+)
+
+// errAttemptTimeout 单次调用超过 Server 的超时时间，与调用方 ctx 的超时区分，可以重试.
+var errAttemptTimeout = errors.New("mcp tool call timed out")
+
+// errServerDisabled Server 已禁用，不重试.
+var errServerDisabled = errors.New("mcp server is disabled")
+
+// toolError 工具调用最终失败时返回给模型的结构化错误，模型可以据此换用其他方式，而不是中断 Agent 运行.
+type toolError struct {
+	Error    string `json:"error"`
+	Type     string `json:"type"`
+	Tool     string `json:"tool"`
+	Attempts int    `json:"attempts"`
+}
+
+// mcpTool 调用 MCP Server 工具的 Agent 工具，每次调用时从缓存获取连接.
+type mcpTool struct {
+	factory  *ToolFactory
+	serverID string
+	info     *schema.ToolInfo
+	// maxRetries 创建工具时 Server 配置的重试次数，获取到连接后以连接中的最新配置为准
+	maxRetries int
+}
+
+func (t *mcpTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+// InvokableRun 调用工具，每次尝试不超过 Server 的 TimeoutSeconds.
+// 超时或连接失败时按 Server 的 MaxRetries 指数退避重试；最终失败时返回结构化的错误信息而不是 error，
+// 只有调用方 ctx 结束时返回 error.
+func (t *mcpTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	maxRetries := t.maxRetries
+	for attempt := 1; ; attempt++ {
+		conn, err := t.factory.acquire(ctx, t.serverID)
+		var result *mcpclient.CallToolResult
+		if err == nil {
+			maxRetries = conn.server.MaxRetries
+			result, err = t.call(ctx, conn, argumentsInJSON)
+			conn.inflight.Done()
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err == nil {
+			if result.IsError {
+				return t.failure(toolErrorTool, result.Text(), attempt), nil
+			}
+			return result.Text(), nil
+		}
+
+		if attempt > maxRetries || !retryable(err) || !retry.Wait(ctx, toolRetryPolicy.Backoff(attempt)) {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			log.Printf("mcp tool %s failed after %d attempts: %v", t.info.Name, attempt, err)
+			return t.failure(errorType(err), err.Error(), attempt), nil
+		}
+	}
+}
+
+// call 使用连接调用一次工具，超过 Server 的超时时间时返回 errAttemptTimeout.
+func (t *mcpTool) call(ctx context.Context, conn *serverConn, argumentsInJSON string) (*mcpclient.CallToolResult, error) {
+	timeout := serverTimeout(conn.server)
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := conn.session.CallTool(callCtx, t.info.Name, json.RawMessage(argumentsInJSON))
+	if err != nil && ctx.Err() == nil && callCtx.Err() != nil {
+		return nil, fmt.Errorf("%w after %s", errAttemptTimeout, timeout)
+	}
+	if errors.Is(err, mcpclient.ErrClosed) {
+		// 连接已断开（如 stdio 进程退出），重试时重新连接
+		t.factory.discard(t.serverID, conn)
+	}
+	return result, err
+}
+
+// failure 生成返回给模型的结构化错误.
+func (t *mcpTool) failure(errType, message string, attempts int) string {
+	data, _ := json.Marshal(toolError{Error: message, Type: errType, Tool: t.info.Name, Attempts: attempts})
+	return string(data)
+}
+
+// toolRetryPolicy MCP 工具调用重试的退避策略，重试次数由 Server 的 MaxRetries 决定.
+var toolRetryPolicy = retry.DefaultPolicy()
+
+// retryable 判断调用失败是否可以重试：Server 返回的 JSON-RPC 错误、配置错误和 Server 已禁用不重试.
+func retryable(err error) bool {
+	var rpcErr *mcpclient.RPCError
+	return !errors.As(err, &rpcErr) &&
+		!errors.Is(err, errServerDisabled) &&
+		!errors.Is(err, ErrUnsupportedTransport)
+}
+
+// errorType 返回调用失败的类型.
+func errorType(err error) string {
+	var rpcErr *mcpclient.RPCError
+	switch {
+	case errors.Is(err, errAttemptTimeout):
+		return toolErrorTimeout
+	case errors.As(err, &rpcErr):
+		return toolErrorRPC
+	default:
+		return toolErrorUnavailable
+	}
+}
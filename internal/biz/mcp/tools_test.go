@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// 假 MCP Server 对 tools/call 的响应方式.
+const (
+	callOK          = "ok"
+	callSlow        = "slow"        // 直到客户端放弃才返回
+	callUnavailable = "unavailable" // HTTP 503
+	callRPCError    = "rpc_error"   // JSON-RPC 错误
+	callToolError   = "tool_error"  // isError 结果
+)
+
+// fakeMCPServer 使用 Streamable HTTP 传输的 MCP Server，tools/call 依次按 script 响应，之后均成功.
+type fakeMCPServer struct {
+	mu     sync.Mutex
+	script []string
+	calls  int
+}
+
+func (s *fakeMCPServer) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *fakeMCPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reply := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "notifications/initialized":
+		w.WriteHeader(http.StatusAccepted)
+		return
+	case "initialize":
+		reply["result"] = map[string]any{"protocolVersion": "2025-03-26", "serverInfo": map[string]string{"name": "fake"}}
+	case "tools/list":
+		reply["result"] = map[string]any{"tools": []map[string]any{{"name": "search", "description": "搜索"}}}
+	case "tools/call":
+		s.mu.Lock()
+		behavior := callOK
+		if s.calls < len(s.script) {
+			behavior = s.script[s.calls]
+		}
+		s.calls++
+		s.mu.Unlock()
+
+		switch behavior {
+		case callSlow:
+			<-r.Context().Done()
+			return
+		case callUnavailable:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		case callRPCError:
+			reply["error"] = map[string]any{"code": -32602, "message": "invalid arguments"}
+		case callToolError:
+			reply["result"] = map[string]any{"content": []map[string]string{{"type": "text", "text": "quota exceeded"}}, "isError": true}
+		default:
+			reply["result"] = map[string]any{"content": []map[string]string{{"type": "text", "text": "found it"}}}
+		}
+	default:
+		reply["error"] = map[string]any{"code": -32601, "message": "method not found"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// fakeStore 只提供 MCP Server 配置的 Store.
+type fakeStore struct {
+	store.Store
+	servers *fakeServerStore
+}
+
+func (s *fakeStore) MCPServers() store.MCPServerStore { return s.servers }
+
+type fakeServerStore struct {
+	store.MCPServerStore
+	servers map[string]*model.MCPServer
+}
+
+func (s *fakeServerStore) Get(_ context.Context, id string) (*model.MCPServer, error) {
+	server, ok := s.servers[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return server, nil
+}
+
+// newSearchTool 启动按 script 响应的假 Server，返回其 search 工具.
+func newSearchTool(t *testing.T, maxRetries int, script ...string) (tool.InvokableTool, *fakeMCPServer) {
+	t.Helper()
+	// 重试间隔缩短到毫秒级
+	defaultPolicy := toolRetryPolicy
+	toolRetryPolicy = &retry.Policy{InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	t.Cleanup(func() { toolRetryPolicy = defaultPolicy })
+
+	fake := &fakeMCPServer{script: script}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	s := &fakeStore{servers: &fakeServerStore{servers: map[string]*model.MCPServer{
+		"s1": {
+			ID:             "s1",
+			TransportType:  model.TransportTypeStreamableHTTP,
+			ServerURL:      srv.URL,
+			TimeoutSeconds: 1,
+			MaxRetries:     maxRetries,
+			IsEnabled:      true,
+		},
+	}}}
+	factory := NewToolFactory(s, nil)
+	t.Cleanup(factory.Close)
+
+	tools := factory.Tools(context.Background(), []*model.MCPTool{{ID: "t1", MCPServerID: "s1", Name: "search"}})
+	search, ok := tools["t1"].(tool.InvokableTool)
+	if !ok {
+		t.Fatalf("tools = %v, want invokable search tool", tools)
+	}
+	return search, fake
+}
+
+// parseToolError 解析返回给模型的结构化错误.
+func parseToolError(t *testing.T, output string) toolError {
+	t.Helper()
+	var e toolError
+	if err := json.Unmarshal([]byte(output), &e); err != nil || e.Type == "" {
+		t.Fatalf("output = %q, want a structured tool error", output)
+	}
+	return e
+}
+
+func TestMCPToolRetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		script     []string
+		calls      int
+	}{
+		{"slow call", 1, []string{callSlow}, 2},
+		{"unavailable server", 2, []string{callUnavailable, callUnavailable}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search, fake := newSearchTool(t, tt.maxRetries, tt.script...)
+			output, err := search.InvokableRun(context.Background(), `{"query":"refund"}`)
+			if err != nil {
+				t.Fatalf("InvokableRun: %v", err)
+			}
+			if output != "found it" {
+				t.Errorf("output = %q, want the result of the successful retry", output)
+			}
+			if got := fake.callCount(); got != tt.calls {
+				t.Errorf("server received %d calls, want %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestMCPToolReturnsStructuredErrorAfterRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		script     []string
+		errType    string
+		attempts   int
+	}{
+		{"timeout without retries", 0, []string{callSlow}, toolErrorTimeout, 1},
+		{"unavailable after retries", 1, []string{callUnavailable, callUnavailable}, toolErrorUnavailable, 2},
+		// Server 明确返回的错误不重试
+		{"rpc error", 3, []string{callRPCError}, toolErrorRPC, 1},
+		{"tool error", 3, []string{callToolError}, toolErrorTool, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search, fake := newSearchTool(t, tt.maxRetries, tt.script...)
+			output, err := search.InvokableRun(context.Background(), `{"query":"refund"}`)
+			if err != nil {
+				t.Fatalf("InvokableRun error = %v, want the failure reported to the model", err)
+			}
+			e := parseToolError(t, output)
+			if e.Type != tt.errType || e.Attempts != tt.attempts || e.Tool != "search" || e.Error == "" {
+				t.Errorf("tool error = %+v, want type %s after %d attempts", e, tt.errType, tt.attempts)
+			}
+			if got := fake.callCount(); got != tt.attempts {
+				t.Errorf("server received %d calls, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestMCPToolStopsWhenCallerCanceled(t *testing.T) {
+	search, fake := newSearchTool(t, 3, callSlow, callSlow, callSlow, callSlow)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := search.InvokableRun(ctx, `{}`); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("InvokableRun error = %v, want the caller's deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("InvokableRun took %s, want to stop with the caller", elapsed)
+	}
+	if got := fake.callCount(); got != 1 {
+		t.Errorf("server received %d calls, want no retries after the caller gave up", got)
+	}
+}
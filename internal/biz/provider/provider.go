@@ -4,13 +4,18 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 
+	"github.com/ashwinyue/next-show/internal/biz/errs"
 	"github.com/ashwinyue/next-show/internal/model"
 	"github.com/ashwinyue/next-show/internal/store"
 )
 
+// ErrInvalidProvider Provider 配置不合法.
+var ErrInvalidProvider = errs.New(errs.ErrValidation, "invalid provider")
+
 // Biz Provider 业务接口.
 type Biz interface {
 	// ListProviders 列出所有 Provider.
@@ -34,29 +39,33 @@ type Biz interface {
 
 // CreateProviderRequest 创建 Provider 请求.
 type CreateProviderRequest struct {
-	Name          string              `json:"name"`
-	DisplayName   string              `json:"display_name"`
-	ProviderType  string              `json:"provider_type"`
-	ModelCategory model.ModelCategory `json:"model_category"`
-	BaseURL       string              `json:"base_url"`
-	APIKey        string              `json:"api_key"`
-	APISecret     string              `json:"api_secret"`
-	DefaultModel  string              `json:"default_model"`
-	Config        model.JSONMap       `json:"config"`
+	Name            string              `json:"name"`
+	DisplayName     string              `json:"display_name"`
+	ProviderType    string              `json:"provider_type"`
+	ModelCategory   model.ModelCategory `json:"model_category"`
+	BaseURL         string              `json:"base_url"`
+	APIKey          string              `json:"api_key"`
+	APISecret       string              `json:"api_secret"`
+	DefaultModel    string              `json:"default_model"`
+	Capabilities    []string            `json:"capabilities"`
+	SupportedModels []string            `json:"supported_models"`
+	Config          model.JSONMap       `json:"config"`
 }
 
 // UpdateProviderRequest 更新 Provider 请求.
 type UpdateProviderRequest struct {
-	Name          *string              `json:"name,omitempty"`
-	DisplayName   *string              `json:"display_name,omitempty"`
-	ProviderType  *string              `json:"provider_type,omitempty"`
-	ModelCategory *model.ModelCategory `json:"model_category,omitempty"`
-	BaseURL       *string              `json:"base_url,omitempty"`
-	APIKey        *string              `json:"api_key,omitempty"`
-	APISecret     *string              `json:"api_secret,omitempty"`
-	DefaultModel  *string              `json:"default_model,omitempty"`
-	Config        model.JSONMap        `json:"config,omitempty"`
-	IsEnabled     *bool                `json:"is_enabled,omitempty"`
+	Name            *string              `json:"name,omitempty"`
+	DisplayName     *string              `json:"display_name,omitempty"`
+	ProviderType    *string              `json:"provider_type,omitempty"`
+	ModelCategory   *model.ModelCategory `json:"model_category,omitempty"`
+	BaseURL         *string              `json:"base_url,omitempty"`
+	APIKey          *string              `json:"api_key,omitempty"`
+	APISecret       *string              `json:"api_secret,omitempty"`
+	DefaultModel    *string              `json:"default_model,omitempty"`
+	Capabilities    []string             `json:"capabilities,omitempty"`
+	SupportedModels []string             `json:"supported_models,omitempty"`
+	Config          model.JSONMap        `json:"config,omitempty"`
+	IsEnabled       *bool                `json:"is_enabled,omitempty"`
 }
 
 type bizImpl struct {
@@ -78,23 +87,32 @@ func (b *bizImpl) GetProvider(ctx context.Context, id string) (*model.Provider,
 
 func (b *bizImpl) CreateProvider(ctx context.Context, req *CreateProviderRequest) (*model.Provider, error) {
 	provider := &model.Provider{
-		ID:            uuid.New().String(),
-		Name:          req.Name,
-		DisplayName:   req.DisplayName,
-		ProviderType:  req.ProviderType,
-		ModelCategory: req.ModelCategory,
-		BaseURL:       req.BaseURL,
-		APIKey:        req.APIKey,
-		APISecret:     req.APISecret,
-		DefaultModel:  req.DefaultModel,
-		Config:        req.Config,
-		IsEnabled:     true,
+		ID:              uuid.New().String(),
+		Name:            req.Name,
+		DisplayName:     req.DisplayName,
+		ProviderType:    req.ProviderType,
+		ModelCategory:   req.ModelCategory,
+		BaseURL:         req.BaseURL,
+		APIKey:          req.APIKey,
+		APISecret:       req.APISecret,
+		DefaultModel:    req.DefaultModel,
+		Config:          req.Config,
+		IsEnabled:       true,
+		Capabilities:    req.Capabilities,
+		SupportedModels: req.SupportedModels,
 	}
 
 	if provider.ModelCategory == "" {
 		provider.ModelCategory = model.ModelCategoryChat
 	}
 
+	if err := validateCapabilities(provider); err != nil {
+		return nil, err
+	}
+	if err := validateModels(provider); err != nil {
+		return nil, err
+	}
+
 	if err := b.store.Providers().Create(ctx, provider); err != nil {
 		return nil, fmt.Errorf("create provider: %w", err)
 	}
@@ -132,6 +150,12 @@ func (b *bizImpl) UpdateProvider(ctx context.Context, id string, req *UpdateProv
 	if req.DefaultModel != nil {
 		provider.DefaultModel = *req.DefaultModel
 	}
+	if req.Capabilities != nil {
+		provider.Capabilities = req.Capabilities
+	}
+	if req.SupportedModels != nil {
+		provider.SupportedModels = req.SupportedModels
+	}
 	if req.Config != nil {
 		provider.Config = req.Config
 	}
@@ -139,6 +163,13 @@ func (b *bizImpl) UpdateProvider(ctx context.Context, id string, req *UpdateProv
 		provider.IsEnabled = *req.IsEnabled
 	}
 
+	if err := validateCapabilities(provider); err != nil {
+		return nil, err
+	}
+	if err := validateModels(provider); err != nil {
+		return nil, err
+	}
+
 	if err := b.store.Providers().Update(ctx, provider); err != nil {
 		return nil, fmt.Errorf("update provider: %w", err)
 	}
@@ -161,3 +192,75 @@ func (b *bizImpl) ListEmbeddingProviders(ctx context.Context) ([]*model.Provider
 func (b *bizImpl) ListRerankProviders(ctx context.Context) ([]*model.Provider, error) {
 	return b.store.Providers().ListByCategory(ctx, model.ModelCategoryRerank)
 }
+
+// validateCapabilities 校验模型类别与能力列表.
+// 配置了能力列表时，模型类别必须包含在其中.
+func validateCapabilities(p *model.Provider) error {
+	if !isValidCategory(p.ModelCategory) {
+		return fmt.Errorf("%w: unknown model_category %q", ErrInvalidProvider, p.ModelCategory)
+	}
+
+	seen := make(map[string]bool, len(p.Capabilities))
+	capabilities := make(model.JSONSlice, 0, len(p.Capabilities))
+	for _, c := range p.Capabilities {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !isValidCategory(model.ModelCategory(c)) {
+			return fmt.Errorf("%w: unknown capability %q", ErrInvalidProvider, c)
+		}
+		if !seen[c] {
+			seen[c] = true
+			capabilities = append(capabilities, c)
+		}
+	}
+	if len(capabilities) == 0 {
+		p.Capabilities = nil
+		return nil
+	}
+	p.Capabilities = capabilities
+
+	if !p.HasCapability(p.ModelCategory) {
+		return fmt.Errorf("%w: capabilities must include model_category %q", ErrInvalidProvider, p.ModelCategory)
+	}
+	return nil
+}
+
+func isValidCategory(c model.ModelCategory) bool {
+	switch c {
+	case model.ModelCategoryChat, model.ModelCategoryEmbedding, model.ModelCategoryRerank:
+		return true
+	}
+	return false
+}
+
+// validateModels 校验并规范化默认模型与支持模型列表.
+// 配置了支持列表但未指定默认模型时，使用列表第一个模型作为默认模型.
+func validateModels(p *model.Provider) error {
+	p.DefaultModel = strings.TrimSpace(p.DefaultModel)
+
+	seen := make(map[string]bool, len(p.SupportedModels))
+	models := make(model.JSONSlice, 0, len(p.SupportedModels))
+	for _, m := range p.SupportedModels {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			return fmt.Errorf("%w: supported_models contains an empty model name", ErrInvalidProvider)
+		}
+		if !seen[m] {
+			seen[m] = true
+			models = append(models, m)
+		}
+	}
+	if len(models) == 0 {
+		p.SupportedModels = nil
+		return nil
+	}
+	p.SupportedModels = models
+
+	if p.DefaultModel == "" {
+		p.DefaultModel = models[0]
+		return nil
+	}
+	if !p.SupportsModel(p.DefaultModel) {
+		return fmt.Errorf("%w: default_model %q is not in supported_models", ErrInvalidProvider, p.DefaultModel)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// 保留策略默认值.
+const (
+	DefaultRetentionInterval  = time.Hour
+	DefaultRetentionBatchSize = 100
+)
+
+// RetentionConfig 会话保留策略配置.
+type RetentionConfig struct {
+	// Enabled 是否启用后台清理
+	Enabled bool `mapstructure:"enabled"`
+	// Interval 清理间隔，默认 1h
+	Interval time.Duration `mapstructure:"interval"`
+	// BatchSize 每批处理的会话数，默认 100
+	BatchSize int `mapstructure:"batch_size"`
+	// MaxMessagesPerSession 全局：每个会话保留的最新消息数，0 不限制
+	MaxMessagesPerSession int `mapstructure:"max_messages_per_session"`
+	// MaxSessionAgeDays 全局：会话最后更新后保留的天数，0 不限制
+	MaxSessionAgeDays int `mapstructure:"max_session_age_days"`
+}
+
+// PruneResult 一次清理的结果.
+type PruneResult struct {
+	DeletedSessions int64 `json:"deleted_sessions"`
+	DeletedMessages int64 `json:"deleted_messages"`
+	TrimmedSessions int64 `json:"trimmed_sessions"`
+}
+
+// PruneStats 累计清理统计.
+type PruneStats struct {
+	Runs            int64      `json:"runs"`
+	DeletedSessions int64      `json:"deleted_sessions"`
+	DeletedMessages int64      `json:"deleted_messages"`
+	TrimmedSessions int64      `json:"trimmed_sessions"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+}
+
+// Pruner 按保留策略清理过期会话和超出数量的历史消息.
+// 置顶会话不会被清理.
+type Pruner struct {
+	store store.Store
+	cfg   RetentionConfig
+
+	mu    sync.Mutex
+	stats PruneStats
+}
+
+// NewPruner 创建会话清理器.
+func NewPruner(s store.Store, cfg *RetentionConfig) *Pruner {
+	p := &Pruner{store: s}
+	if cfg != nil {
+		p.cfg = *cfg
+	}
+	if p.cfg.Interval <= 0 {
+		p.cfg.Interval = DefaultRetentionInterval
+	}
+	if p.cfg.BatchSize <= 0 {
+		p.cfg.BatchSize = DefaultRetentionBatchSize
+	}
+	return p
+}
+
+// Run 定期执行清理，直到 ctx 取消.
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if result, err := p.PruneOnce(ctx); err != nil {
+			log.Printf("session retention: %v", err)
+		} else if result.DeletedSessions > 0 || result.DeletedMessages > 0 {
+			log.Printf("session retention: deleted %d sessions, %d messages, trimmed %d sessions",
+				result.DeletedSessions, result.DeletedMessages, result.TrimmedSessions)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PruneOnce 执行一次清理：有独立策略的租户按租户策略处理，其余会话按全局策略处理.
+func (p *Pruner) PruneOnce(ctx context.Context) (*PruneResult, error) {
+	result := &PruneResult{}
+	err := p.prune(ctx, result)
+
+	now := time.Now()
+	p.mu.Lock()
+	p.stats.Runs++
+	p.stats.DeletedSessions += result.DeletedSessions
+	p.stats.DeletedMessages += result.DeletedMessages
+	p.stats.TrimmedSessions += result.TrimmedSessions
+	p.stats.LastRunAt = &now
+	p.stats.LastError = ""
+	if err != nil {
+		p.stats.LastError = err.Error()
+	}
+	p.mu.Unlock()
+
+	return result, err
+}
+
+// Stats 返回累计清理统计.
+func (p *Pruner) Stats() PruneStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
+
+func (p *Pruner) prune(ctx context.Context, result *PruneResult) error {
+	tenants, err := p.store.Tenants().List(ctx)
+	if err != nil {
+		return fmt.Errorf("list tenants: %w", err)
+	}
+
+	var overridden []string
+	for _, t := range tenants {
+		if t.Retention == nil {
+			continue
+		}
+		overridden = append(overridden, t.ID)
+		if err := p.pruneScope(ctx, store.RetentionScope{TenantID: t.ID}, t.Retention, result); err != nil {
+			return fmt.Errorf("tenant %s: %w", t.ID, err)
+		}
+	}
+	global := &model.RetentionPolicy{
+		MaxMessagesPerSession: p.cfg.MaxMessagesPerSession,
+		MaxSessionAgeDays:     p.cfg.MaxSessionAgeDays,
+	}
+	return p.pruneScope(ctx, store.RetentionScope{ExcludeTenantIDs: overridden}, global, result)
+}
+
+// pruneScope 按策略分批清理范围内的会话.
+func (p *Pruner) pruneScope(ctx context.Context, scope store.RetentionScope, policy *model.RetentionPolicy, result *PruneResult) error {
+	if policy.MaxSessionAgeDays > 0 {
+		before := time.Now().AddDate(0, 0, -policy.MaxSessionAgeDays)
+		for {
+			ids, err := p.store.Sessions().ListExpired(ctx, scope, before, p.cfg.BatchSize)
+			if err != nil {
+				return fmt.Errorf("list expired sessions: %w", err)
+			}
+			for _, id := range ids {
+				n, err := p.store.Sessions().Purge(ctx, id)
+				if err != nil {
+					return fmt.Errorf("purge session %s: %w", id, err)
+				}
+				result.DeletedSessions++
+				result.DeletedMessages += n
+			}
+			if len(ids) < p.cfg.BatchSize {
+				break
+			}
+		}
+	}
+
+	if policy.MaxMessagesPerSession > 0 {
+		for {
+			ids, err := p.store.Sessions().ListOverMessageLimit(ctx, scope, policy.MaxMessagesPerSession, p.cfg.BatchSize)
+			if err != nil {
+				return fmt.Errorf("list sessions over message limit: %w", err)
+			}
+			var trimmed int64
+			for _, id := range ids {
+				n, err := p.store.Messages().TrimSession(ctx, id, policy.MaxMessagesPerSession)
+				if err != nil {
+					return fmt.Errorf("trim session %s: %w", id, err)
+				}
+				result.TrimmedSessions++
+				result.DeletedMessages += n
+				trimmed += n
+			}
+			// 创建时间相同的消息无法再裁剪时停止，避免重复处理同一批会话
+			if len(ids) < p.cfg.BatchSize || trimmed == 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
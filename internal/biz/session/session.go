@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/ashwinyue/next-show/internal/biz/tenant"
 	"github.com/ashwinyue/next-show/internal/model"
 	"github.com/ashwinyue/next-show/internal/store"
 )
@@ -15,10 +16,12 @@ import (
 type SessionBiz interface {
 	Create(ctx context.Context, userID, agentID string) (*model.Session, error)
 	Get(ctx context.Context, id string) (*model.Session, error)
-	List(ctx context.Context, userID string, offset, limit int) ([]*model.Session, int64, error)
+	List(ctx context.Context, userID string, offset, limit int, opts *store.ListOptions) ([]*model.Session, int64, error)
 	UpdateTitle(ctx context.Context, id, title string) error
 	Delete(ctx context.Context, id string) error
+	SetPinned(ctx context.Context, id string, pinned bool) error
 	AddMessage(ctx context.Context, sessionID, role, content string) (*model.Message, error)
+	SaveMessage(ctx context.Context, message *model.Message) error
 	GetMessages(ctx context.Context, sessionID string, beforeTime string, limit int) ([]*model.Message, error)
 }
 
@@ -31,7 +34,11 @@ func NewSessionBiz(s store.Store) SessionBiz {
 	return &sessionBiz{store: s}
 }
 
+// Create 创建会话，未指定 Agent 时使用用户所属租户的默认 Agent.
 func (b *sessionBiz) Create(ctx context.Context, userID, agentID string) (*model.Session, error) {
+	if agentID == "" {
+		agentID = tenant.ResolveUserDefaults(ctx, b.store, userID).AgentID
+	}
 	session := &model.Session{
 		ID:        uuid.New().String(),
 		UserID:    userID,
@@ -49,8 +56,8 @@ func (b *sessionBiz) Get(ctx context.Context, id string) (*model.Session, error)
 	return b.store.Sessions().Get(ctx, id)
 }
 
-func (b *sessionBiz) List(ctx context.Context, userID string, offset, limit int) ([]*model.Session, int64, error) {
-	return b.store.Sessions().List(ctx, userID, offset, limit)
+func (b *sessionBiz) List(ctx context.Context, userID string, offset, limit int, opts *store.ListOptions) ([]*model.Session, int64, error) {
+	return b.store.Sessions().List(ctx, userID, offset, limit, opts)
 }
 
 func (b *sessionBiz) UpdateTitle(ctx context.Context, id, title string) error {
@@ -67,6 +74,14 @@ func (b *sessionBiz) Delete(ctx context.Context, id string) error {
 	return b.store.Sessions().Delete(ctx, id)
 }
 
+// SetPinned 置顶或取消置顶会话，置顶会话不受保留策略清理.
+func (b *sessionBiz) SetPinned(ctx context.Context, id string, pinned bool) error {
+	if _, err := b.store.Sessions().Get(ctx, id); err != nil {
+		return err
+	}
+	return b.store.Sessions().UpdatePinned(ctx, id, pinned)
+}
+
 func (b *sessionBiz) AddMessage(ctx context.Context, sessionID, role, content string) (*model.Message, error) {
 	message := &model.Message{
 		ID:        uuid.New().String(),
@@ -81,6 +96,17 @@ func (b *sessionBiz) AddMessage(ctx context.Context, sessionID, role, content st
 	return message, nil
 }
 
+// SaveMessage 保存完整消息（如携带用量和费用的助手消息）.
+func (b *sessionBiz) SaveMessage(ctx context.Context, message *model.Message) error {
+	if message.ID == "" {
+		message.ID = uuid.New().String()
+	}
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+	return b.store.Messages().Create(ctx, message)
+}
+
 func (b *sessionBiz) GetMessages(ctx context.Context, sessionID string, beforeTime string, limit int) ([]*model.Message, error) {
 	// 如果有 beforeTime，解析为时间戳
 	var beforeTimeFilter time.Time
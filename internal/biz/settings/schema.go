@@ -0,0 +1,136 @@
+// Package settings 提供系统设置业务逻辑.
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// 设置值类型.
+const (
+	ValueTypeString = "string"
+	ValueTypeInt    = "int"
+	ValueTypeFloat  = "float"
+	ValueTypeBool   = "bool"
+	ValueTypeJSON   = "json"
+)
+
+// CustomKeyPrefix 自定义设置的 Key 前缀，不在 Schema 中登记，仅校验 value_type.
+const CustomKeyPrefix = "custom."
+
+// Schema 设置项定义.
+type Schema struct {
+	Key         string   `json:"key"`
+	ValueType   string   `json:"value_type"`
+	Category    string   `json:"category"`
+	Label       string   `json:"label"`
+	Description string   `json:"description,omitempty"`
+	Default     string   `json:"default"`
+	Enum        []string `json:"enum,omitempty"` // 允许的取值，为空时不限制
+	Min         *float64 `json:"min,omitempty"`  // int/float 的最小值
+	Max         *float64 `json:"max,omitempty"`  // int/float 的最大值
+}
+
+// schemas 已登记的设置项，按展示顺序排列.
+var schemas = []*Schema{
+	// 通用设置
+	{Key: model.SettingKeySystemName, ValueType: ValueTypeString, Category: "general", Label: "系统名称", Default: "Next Show"},
+	{Key: model.SettingKeySystemDescription, ValueType: ValueTypeString, Category: "general", Label: "系统描述"},
+	{Key: model.SettingKeySystemLanguage, ValueType: ValueTypeString, Category: "general", Label: "系统语言", Default: "zh-CN", Enum: []string{"zh-CN", "en-US"}},
+
+	// 默认模型设置
+	{Key: model.SettingKeyDefaultChatProvider, ValueType: ValueTypeString, Category: "model", Label: "默认对话 Provider", Description: "Provider ID，需具备 chat 能力"},
+	{Key: model.SettingKeyDefaultEmbeddingProvider, ValueType: ValueTypeString, Category: "model", Label: "默认 Embedding Provider", Description: "Provider ID，需具备 embedding 能力"},
+	{Key: model.SettingKeyDefaultRerankProvider, ValueType: ValueTypeString, Category: "model", Label: "默认 Rerank Provider", Description: "Provider ID，需具备 rerank 能力"},
+
+	// 功能开关
+	{Key: model.SettingKeyWebSearchEnabled, ValueType: ValueTypeBool, Category: "feature", Label: "启用网络搜索", Default: "true"},
+	{Key: model.SettingKeyKnowledgeEnabled, ValueType: ValueTypeBool, Category: "feature", Label: "启用知识库", Default: "true"},
+	{Key: model.SettingKeyMultiAgentEnabled, ValueType: ValueTypeBool, Category: "feature", Label: "启用多 Agent", Default: "false"},
+	{Key: model.SettingKeyStreamingEnabled, ValueType: ValueTypeBool, Category: "feature", Label: "启用流式输出", Default: "true"},
+	{Key: model.SettingKeyWebSearchConfig, ValueType: ValueTypeJSON, Category: "feature", Label: "Web Search", Description: "网络搜索配置，建议通过 /web-search/config 修改"},
+
+	// Ollama 配置
+	{Key: model.SettingKeyOllamaBaseURL, ValueType: ValueTypeString, Category: "ollama", Label: "Ollama 地址", Default: "http://localhost:11434"},
+	{Key: model.SettingKeyOllamaEnabled, ValueType: ValueTypeBool, Category: "ollama", Label: "启用 Ollama", Default: "false"},
+}
+
+var schemaIndex = func() map[string]*Schema {
+	index := make(map[string]*Schema, len(schemas))
+	for _, s := range schemas {
+		index[s.Key] = s
+	}
+	return index
+}()
+
+// Schemas 返回所有已登记的设置项定义.
+func Schemas() []*Schema {
+	return schemas
+}
+
+// LookupSchema 查找设置项定义.
+func LookupSchema(key string) (*Schema, bool) {
+	s, ok := schemaIndex[key]
+	return s, ok
+}
+
+// IsCustomKey 判断是否为自定义设置 Key.
+func IsCustomKey(key string) bool {
+	return strings.HasPrefix(key, CustomKeyPrefix) && len(key) > len(CustomKeyPrefix)
+}
+
+// Validate 校验设置值的类型、枚举和范围.
+func (s *Schema) Validate(value string) error {
+	if err := validateType(s.ValueType, value); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidSetting, s.Key, err)
+	}
+	if len(s.Enum) > 0 && value != "" && !slices.Contains(s.Enum, value) {
+		return fmt.Errorf("%w: %s must be one of %s", ErrInvalidSetting, s.Key, strings.Join(s.Enum, ", "))
+	}
+	if s.Min == nil && s.Max == nil {
+		return nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	if s.Min != nil && n < *s.Min {
+		return fmt.Errorf("%w: %s must be >= %v", ErrInvalidSetting, s.Key, *s.Min)
+	}
+	if s.Max != nil && n > *s.Max {
+		return fmt.Errorf("%w: %s must be <= %v", ErrInvalidSetting, s.Key, *s.Max)
+	}
+	return nil
+}
+
+// validateType 校验值能否按 valueType 解析.
+func validateType(valueType, value string) error {
+	switch valueType {
+	case ValueTypeString:
+		return nil
+	case ValueTypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("invalid int value %q", value)
+		}
+	case ValueTypeFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("invalid float value %q", value)
+		}
+	case ValueTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid bool value %q", value)
+		}
+	case ValueTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("invalid json value")
+		}
+	default:
+		return fmt.Errorf("unknown value_type %q", valueType)
+	}
+	return nil
+}
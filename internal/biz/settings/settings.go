@@ -3,10 +3,13 @@ package settings
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"runtime"
 
 	"github.com/google/uuid"
 
+	"github.com/ashwinyue/next-show/internal/biz/errs"
 	"github.com/ashwinyue/next-show/internal/model"
 	"github.com/ashwinyue/next-show/internal/store"
 )
@@ -26,6 +29,16 @@ type SystemInfo struct {
 	GoVersion string `json:"go_version"`
 }
 
+// ErrInvalidSetting 设置值不合法.
+var ErrInvalidSetting = errs.New(errs.ErrValidation, "invalid setting")
+
+// providerSettingCapabilities 默认 Provider 设置项与所需能力的对应关系.
+var providerSettingCapabilities = map[string]model.ModelCategory{
+	model.SettingKeyDefaultChatProvider:      model.ModelCategoryChat,
+	model.SettingKeyDefaultEmbeddingProvider: model.ModelCategoryEmbedding,
+	model.SettingKeyDefaultRerankProvider:    model.ModelCategoryRerank,
+}
+
 // Biz 系统设置业务接口.
 type Biz interface {
 	// GetSystemInfo 获取系统信息.
@@ -42,8 +55,12 @@ type Biz interface {
 	Delete(ctx context.Context, key string) error
 	// GetMultiple 批量获取设置.
 	GetMultiple(ctx context.Context, keys []string) (map[string]string, error)
-	// SetMultiple 批量设置.
+	// SetMultiple 批量设置，全部校验通过后才写入.
 	SetMultiple(ctx context.Context, settings map[string]string) error
+	// Schemas 获取设置项定义（用于渲染设置表单）.
+	Schemas(ctx context.Context) []*Schema
+	// MigrateCustomSettings 将未登记的旧设置迁移到 custom. 前缀下，返回迁移数量.
+	MigrateCustomSettings(ctx context.Context) (int, error)
 }
 
 // SetRequest 设置请求.
@@ -87,6 +104,10 @@ func (b *bizImpl) Get(ctx context.Context, key string) (*model.SystemSettings, e
 }
 
 func (b *bizImpl) Set(ctx context.Context, req *SetRequest) (*model.SystemSettings, error) {
+	if err := b.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
 	// 尝试获取现有设置
 	existing, _ := b.store.Settings().Get(ctx, req.Key)
 
@@ -105,10 +126,6 @@ func (b *bizImpl) Set(ctx context.Context, req *SetRequest) (*model.SystemSettin
 		setting.ID = uuid.New().String()
 	}
 
-	if setting.ValueType == "" {
-		setting.ValueType = "string"
-	}
-
 	if err := b.store.Settings().Set(ctx, setting); err != nil {
 		return nil, err
 	}
@@ -134,6 +151,12 @@ func (b *bizImpl) GetMultiple(ctx context.Context, keys []string) (map[string]st
 }
 
 func (b *bizImpl) SetMultiple(ctx context.Context, settings map[string]string) error {
+	// 先全部校验，避免部分写入
+	for key, value := range settings {
+		if err := b.validate(ctx, &SetRequest{Key: key, Value: value}); err != nil {
+			return err
+		}
+	}
 	for key, value := range settings {
 		_, err := b.Set(ctx, &SetRequest{
 			Key:   key,
@@ -145,3 +168,88 @@ func (b *bizImpl) SetMultiple(ctx context.Context, settings map[string]string) e
 	}
 	return nil
 }
+
+func (b *bizImpl) Schemas(ctx context.Context) []*Schema {
+	return Schemas()
+}
+
+func (b *bizImpl) MigrateCustomSettings(ctx context.Context) (int, error) {
+	settings, err := b.store.Settings().List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("list settings: %w", err)
+	}
+
+	var migrated int
+	for _, setting := range settings {
+		if _, ok := LookupSchema(setting.Key); ok || IsCustomKey(setting.Key) {
+			continue
+		}
+		newKey := CustomKeyPrefix + setting.Key
+		if existing, _ := b.store.Settings().Get(ctx, newKey); existing != nil {
+			log.Printf("settings: skip migrating %s, %s already exists", setting.Key, newKey)
+			continue
+		}
+		setting.Key = newKey
+		if validateType(setting.ValueType, setting.Value) != nil {
+			setting.ValueType = ValueTypeString
+		}
+		if err := b.store.Settings().Set(ctx, setting); err != nil {
+			return migrated, fmt.Errorf("migrate setting %s: %w", setting.Key, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// validate 按 Schema 校验设置并补全类型、类别等元信息.
+// 未登记的 Key 必须使用 custom. 前缀.
+func (b *bizImpl) validate(ctx context.Context, req *SetRequest) error {
+	schema, ok := LookupSchema(req.Key)
+	if !ok {
+		if !IsCustomKey(req.Key) {
+			return fmt.Errorf("%w: unknown key %s (use the %s prefix for custom settings)", ErrInvalidSetting, req.Key, CustomKeyPrefix)
+		}
+		if req.ValueType == "" {
+			req.ValueType = ValueTypeString
+		}
+		if err := validateType(req.ValueType, req.Value); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidSetting, req.Key, err)
+		}
+		return nil
+	}
+
+	if req.ValueType != "" && req.ValueType != schema.ValueType {
+		return fmt.Errorf("%w: %s has value_type %s, got %s", ErrInvalidSetting, req.Key, schema.ValueType, req.ValueType)
+	}
+	req.ValueType = schema.ValueType
+	if req.Category == "" {
+		req.Category = schema.Category
+	}
+	if req.Label == "" {
+		req.Label = schema.Label
+	}
+	if req.Description == "" {
+		req.Description = schema.Description
+	}
+
+	if err := schema.Validate(req.Value); err != nil {
+		return err
+	}
+	return b.validateProviderSetting(ctx, req.Key, req.Value)
+}
+
+// validateProviderSetting 校验默认 Provider 设置指向具备对应能力的 Provider.
+func (b *bizImpl) validateProviderSetting(ctx context.Context, key, value string) error {
+	capability, ok := providerSettingCapabilities[key]
+	if !ok || value == "" {
+		return nil
+	}
+	provider, err := b.store.Providers().Get(ctx, value)
+	if err != nil {
+		return fmt.Errorf("%w: provider %s not found", ErrInvalidSetting, value)
+	}
+	if !provider.HasCapability(capability) {
+		return fmt.Errorf("%w: provider %s is not %s-capable", ErrInvalidSetting, provider.Name, capability)
+	}
+	return nil
+}
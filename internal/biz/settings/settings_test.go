@@ -0,0 +1,103 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// fakeStore 只提供 Provider 和系统设置的 Store.
+type fakeStore struct {
+	store.Store
+	providers *fakeProviderStore
+	settings  *fakeSettingsStore
+}
+
+func (s *fakeStore) Providers() store.ProviderStore { return s.providers }
+func (s *fakeStore) Settings() store.SettingsStore  { return s.settings }
+
+type fakeProviderStore struct {
+	store.ProviderStore
+	providers map[string]*model.Provider
+}
+
+func (s *fakeProviderStore) Get(_ context.Context, id string) (*model.Provider, error) {
+	if p, ok := s.providers[id]; ok {
+		return p, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeSettingsStore struct {
+	store.SettingsStore
+	settings map[string]*model.SystemSettings
+}
+
+func (s *fakeSettingsStore) Get(_ context.Context, key string) (*model.SystemSettings, error) {
+	if setting, ok := s.settings[key]; ok {
+		return setting, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *fakeSettingsStore) Set(_ context.Context, setting *model.SystemSettings) error {
+	s.settings[setting.Key] = setting
+	return nil
+}
+
+func TestDefaultProviderCapabilityMismatch(t *testing.T) {
+	fs := &fakeStore{
+		providers: &fakeProviderStore{providers: map[string]*model.Provider{
+			"chat":      {ID: "chat", Name: "openai", ModelCategory: model.ModelCategoryChat},
+			"embedding": {ID: "embedding", Name: "bge", ModelCategory: model.ModelCategoryEmbedding},
+			"rerank":    {ID: "rerank", Name: "cohere", ModelCategory: model.ModelCategoryRerank},
+			// 能力列表优先于模型类别
+			"multi": {ID: "multi", Name: "dashscope", ModelCategory: model.ModelCategoryChat, Capabilities: model.JSONSlice{"chat", "rerank"}},
+		}},
+		settings: &fakeSettingsStore{settings: make(map[string]*model.SystemSettings)},
+	}
+	b := NewBiz(fs)
+
+	tests := []struct {
+		capability model.ModelCategory
+		key        string
+		valid      []string
+		invalid    []string
+	}{
+		{model.ModelCategoryChat, model.SettingKeyDefaultChatProvider, []string{"chat", "multi"}, []string{"embedding", "rerank"}},
+		{model.ModelCategoryEmbedding, model.SettingKeyDefaultEmbeddingProvider, []string{"embedding"}, []string{"chat", "rerank", "multi"}},
+		{model.ModelCategoryRerank, model.SettingKeyDefaultRerankProvider, []string{"rerank", "multi"}, []string{"chat", "embedding"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.capability), func(t *testing.T) {
+			for _, id := range tt.invalid {
+				_, err := b.Set(context.Background(), &SetRequest{Key: tt.key, Value: id})
+				if !errors.Is(err, ErrInvalidSetting) || !errors.Is(err, errs.ErrValidation) {
+					t.Errorf("Set(%s, %s) error = %v, want a validation error", tt.key, id, err)
+				}
+				if err != nil && !strings.Contains(err.Error(), string(tt.capability)+"-capable") {
+					t.Errorf("Set(%s, %s) error = %q, want it to name the missing capability", tt.key, id, err)
+				}
+			}
+			if setting, ok := fs.settings.settings[tt.key]; ok {
+				t.Fatalf("rejected provider was saved: %+v", setting)
+			}
+			for _, id := range tt.valid {
+				if _, err := b.Set(context.Background(), &SetRequest{Key: tt.key, Value: id}); err != nil {
+					t.Errorf("Set(%s, %s): %v", tt.key, id, err)
+				}
+			}
+		})
+	}
+
+	if _, err := b.Set(context.Background(), &SetRequest{Key: model.SettingKeyDefaultChatProvider, Value: "missing"}); !errors.Is(err, ErrInvalidSetting) {
+		t.Errorf("Set(missing provider) error = %v, want ErrInvalidSetting", err)
+	}
+}
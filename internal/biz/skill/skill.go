@@ -17,7 +17,7 @@ type Biz interface {
 	Delete(ctx context.Context, id string) error
 
 	// 列表和搜索
-	List(ctx context.Context, page, pageSize int) ([]*model.Skill, int64, error)
+	List(ctx context.Context, page, pageSize int, opts *store.ListOptions) ([]*model.Skill, int64, error)
 	ListAll(ctx context.Context) ([]*model.Skill, error)
 	ListByCategory(ctx context.Context, category string) ([]*model.Skill, error)
 	ListEnabled(ctx context.Context) ([]*model.Skill, error)
@@ -55,9 +55,9 @@ func (b *biz) Delete(ctx context.Context, id string) error {
 	return b.store.Skills().Delete(ctx, id)
 }
 
-func (b *biz) List(ctx context.Context, page, pageSize int) ([]*model.Skill, int64, error) {
+func (b *biz) List(ctx context.Context, page, pageSize int, opts *store.ListOptions) ([]*model.Skill, int64, error) {
 	offset := (page - 1) * pageSize
-	return b.store.Skills().List(ctx, offset, pageSize)
+	return b.store.Skills().List(ctx, offset, pageSize, opts)
 }
 
 func (b *biz) ListAll(ctx context.Context) ([]*model.Skill, error) {
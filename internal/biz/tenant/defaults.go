@@ -0,0 +1,138 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// ErrInvalidDefaults 租户默认配置不合法.
+var ErrInvalidDefaults = errs.New(errs.ErrValidation, "invalid tenant defaults")
+
+// GetDefaults 获取租户自身配置的默认值（未回退到系统设置）.
+func (b *bizImpl) GetDefaults(ctx context.Context, tenantID string) (*model.TenantDefaults, error) {
+	tenant, err := b.store.Tenants().Get(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.Defaults == nil {
+		return &model.TenantDefaults{}, nil
+	}
+	return tenant.Defaults, nil
+}
+
+// SetDefaults 校验引用的 Provider、模型和 Agent 后保存租户默认值.
+func (b *bizImpl) SetDefaults(ctx context.Context, tenantID string, defaults *model.TenantDefaults) (*model.TenantDefaults, error) {
+	tenant, err := b.store.Tenants().Get(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.validateDefaults(ctx, defaults); err != nil {
+		return nil, err
+	}
+
+	tenant.Defaults = defaults
+	if err := b.store.Tenants().Update(ctx, tenant); err != nil {
+		return nil, err
+	}
+	return defaults, nil
+}
+
+// validateDefaults 校验默认值引用的资源存在且具备对应能力.
+func (b *bizImpl) validateDefaults(ctx context.Context, d *model.TenantDefaults) error {
+	if d.ChatModel != "" && d.ChatProviderID == "" {
+		return fmt.Errorf("%w: chat_model requires chat_provider_id", ErrInvalidDefaults)
+	}
+	if d.EmbeddingModel != "" && d.EmbeddingProviderID == "" {
+		return fmt.Errorf("%w: embedding_model requires embedding_provider_id", ErrInvalidDefaults)
+	}
+
+	if err := b.validateProvider(ctx, d.ChatProviderID, d.ChatModel, model.ModelCategoryChat); err != nil {
+		return err
+	}
+	if err := b.validateProvider(ctx, d.EmbeddingProviderID, d.EmbeddingModel, model.ModelCategoryEmbedding); err != nil {
+		return err
+	}
+	if err := b.validateProvider(ctx, d.RerankProviderID, "", model.ModelCategoryRerank); err != nil {
+		return err
+	}
+
+	if d.AgentID != "" {
+		agent, err := b.store.Agents().Get(ctx, d.AgentID)
+		if err != nil {
+			return fmt.Errorf("%w: agent %s not found", ErrInvalidDefaults, d.AgentID)
+		}
+		if !agent.IsEnabled {
+			return fmt.Errorf("%w: agent %s is disabled", ErrInvalidDefaults, agent.Name)
+		}
+	}
+	return nil
+}
+
+func (b *bizImpl) validateProvider(ctx context.Context, providerID, modelName string, capability model.ModelCategory) error {
+	if providerID == "" {
+		return nil
+	}
+	provider, err := b.store.Providers().Get(ctx, providerID)
+	if err != nil {
+		return fmt.Errorf("%w: provider %s not found", ErrInvalidDefaults, providerID)
+	}
+	if !provider.HasCapability(capability) {
+		return fmt.Errorf("%w: provider %s is not %s-capable", ErrInvalidDefaults, provider.Name, capability)
+	}
+	if modelName != "" && !provider.SupportsModel(modelName) {
+		return fmt.Errorf("%w: model %q is not supported by provider %s", ErrInvalidDefaults, modelName, provider.Name)
+	}
+	return nil
+}
+
+// ResolveDefaults 解析生效的默认值：租户配置优先，未配置的字段回退到系统设置.
+// tenantID 为空或租户不存在时仅使用系统设置.
+func ResolveDefaults(ctx context.Context, s store.Store, tenantID string) *model.TenantDefaults {
+	var defaults *model.TenantDefaults
+	if tenantID != "" {
+		if tenant, err := s.Tenants().Get(ctx, tenantID); err == nil {
+			defaults = tenant.Defaults
+		}
+	}
+	return defaults.Merge(systemDefaults(ctx, s))
+}
+
+// ResolveUserDefaults 按用户所属租户解析生效的默认值.
+func ResolveUserDefaults(ctx context.Context, s store.Store, userID string) *model.TenantDefaults {
+	var tenantID string
+	if userID != "" {
+		if user, err := s.Users().Get(ctx, userID); err == nil {
+			tenantID = user.TenantID
+		}
+	}
+	return ResolveDefaults(ctx, s, tenantID)
+}
+
+// systemDefaults 从系统设置读取全局默认 Provider.
+func systemDefaults(ctx context.Context, s store.Store) *model.TenantDefaults {
+	settings, err := s.Settings().GetMultiple(ctx, []string{
+		model.SettingKeyDefaultChatProvider,
+		model.SettingKeyDefaultEmbeddingProvider,
+		model.SettingKeyDefaultRerankProvider,
+	})
+	if err != nil {
+		return nil
+	}
+
+	defaults := &model.TenantDefaults{}
+	for _, setting := range settings {
+		switch setting.Key {
+		case model.SettingKeyDefaultChatProvider:
+			defaults.ChatProviderID = setting.Value
+		case model.SettingKeyDefaultEmbeddingProvider:
+			defaults.EmbeddingProviderID = setting.Value
+		case model.SettingKeyDefaultRerankProvider:
+			defaults.RerankProviderID = setting.Value
+		}
+	}
+	return defaults
+}
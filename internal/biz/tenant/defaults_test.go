@@ -0,0 +1,195 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// fakeStore 内存实现的 store.Store，只实现测试用到的方法，其余方法调用时 panic.
+type fakeStore struct {
+	store.Store
+	tenants  *fakeTenantStore
+	users    *fakeUserStore
+	settings *fakeSettingsStore
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		tenants:  &fakeTenantStore{tenants: make(map[string]*model.Tenant)},
+		users:    &fakeUserStore{users: make(map[string]*model.User)},
+		settings: &fakeSettingsStore{settings: make(map[string]string)},
+	}
+}
+
+func (s *fakeStore) Tenants() store.TenantStore    { return s.tenants }
+func (s *fakeStore) Users() store.UserStore        { return s.users }
+func (s *fakeStore) Settings() store.SettingsStore { return s.settings }
+
+type fakeTenantStore struct {
+	store.TenantStore
+	tenants map[string]*model.Tenant
+}
+
+func (s *fakeTenantStore) Get(_ context.Context, id string) (*model.Tenant, error) {
+	if t, ok := s.tenants[id]; ok {
+		return t, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeUserStore struct {
+	store.UserStore
+	users map[string]*model.User
+}
+
+func (s *fakeUserStore) Get(_ context.Context, id string) (*model.User, error) {
+	if u, ok := s.users[id]; ok {
+		return u, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// fakeSettingsStore 按 key 保存系统设置，err 不为空时读取失败.
+type fakeSettingsStore struct {
+	store.SettingsStore
+	settings map[string]string
+	err      error
+}
+
+func (s *fakeSettingsStore) GetMultiple(_ context.Context, keys []string) ([]*model.SystemSettings, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var settings []*model.SystemSettings
+	for _, key := range keys {
+		if value, ok := s.settings[key]; ok {
+			settings = append(settings, &model.SystemSettings{Key: key, Value: value})
+		}
+	}
+	return settings, nil
+}
+
+// systemSettings 全局配置的默认 Provider.
+var systemSettings = map[string]string{
+	model.SettingKeyDefaultChatProvider:      "sys-chat",
+	model.SettingKeyDefaultEmbeddingProvider: "sys-embed",
+	model.SettingKeyDefaultRerankProvider:    "sys-rerank",
+}
+
+func TestResolveDefaultsFallbackChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenant   *model.TenantDefaults
+		settings map[string]string
+		want     model.TenantDefaults
+	}{
+		{
+			name: "tenant value wins over global config",
+			tenant: &model.TenantDefaults{
+				ChatProviderID: "t-chat", ChatModel: "gpt-4o",
+				EmbeddingProviderID: "t-embed", EmbeddingModel: "bge-m3",
+				RerankProviderID: "t-rerank", AgentID: "t-agent",
+			},
+			settings: systemSettings,
+			want: model.TenantDefaults{
+				ChatProviderID: "t-chat", ChatModel: "gpt-4o",
+				EmbeddingProviderID: "t-embed", EmbeddingModel: "bge-m3",
+				RerankProviderID: "t-rerank", AgentID: "t-agent",
+			},
+		},
+		{
+			name:     "unset tenant fields fall back to global config",
+			tenant:   &model.TenantDefaults{ChatProviderID: "t-chat", ChatModel: "gpt-4o"},
+			settings: systemSettings,
+			want: model.TenantDefaults{
+				ChatProviderID: "t-chat", ChatModel: "gpt-4o",
+				EmbeddingProviderID: "sys-embed", RerankProviderID: "sys-rerank",
+			},
+		},
+		{
+			name:     "tenant without defaults uses global config",
+			settings: systemSettings,
+			want:     model.TenantDefaults{ChatProviderID: "sys-chat", EmbeddingProviderID: "sys-embed", RerankProviderID: "sys-rerank"},
+		},
+		{
+			// 模型名随 Provider 回退，不会与全局 Provider 组合
+			name:     "model falls back together with its provider",
+			tenant:   &model.TenantDefaults{EmbeddingModel: "bge-m3"},
+			settings: systemSettings,
+			want:     model.TenantDefaults{ChatProviderID: "sys-chat", EmbeddingProviderID: "sys-embed", RerankProviderID: "sys-rerank"},
+		},
+		{
+			name:     "partial global config",
+			settings: map[string]string{model.SettingKeyDefaultChatProvider: "sys-chat"},
+			want:     model.TenantDefaults{ChatProviderID: "sys-chat"},
+		},
+		{
+			// 都未配置时返回空值，由调用方使用内置行为（Agent 自身配置、不设默认 Agent 等）
+			name: "nothing configured leaves built-in defaults",
+			want: model.TenantDefaults{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFakeStore()
+			fs.tenants.tenants["t1"] = &model.Tenant{ID: "t1", Defaults: tt.tenant}
+			for k, v := range tt.settings {
+				fs.settings.settings[k] = v
+			}
+			if got := ResolveDefaults(context.Background(), fs, "t1"); *got != tt.want {
+				t.Errorf("ResolveDefaults = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDefaultsWithoutTenant(t *testing.T) {
+	fs := newFakeStore()
+	fs.settings.settings = systemSettings
+	want := model.TenantDefaults{ChatProviderID: "sys-chat", EmbeddingProviderID: "sys-embed", RerankProviderID: "sys-rerank"}
+
+	// 未指定租户或租户不存在时只使用全局配置
+	for _, tenantID := range []string{"", "missing"} {
+		if got := ResolveDefaults(context.Background(), fs, tenantID); *got != want {
+			t.Errorf("ResolveDefaults(%q) = %+v, want %+v", tenantID, *got, want)
+		}
+	}
+}
+
+func TestResolveDefaultsGlobalConfigUnavailable(t *testing.T) {
+	fs := newFakeStore()
+	fs.tenants.tenants["t1"] = &model.Tenant{ID: "t1", Defaults: &model.TenantDefaults{ChatProviderID: "t-chat"}}
+	fs.settings.err = errors.New("connection refused")
+
+	// 读取全局配置失败时保留租户配置，其余字段为空
+	if got := ResolveDefaults(context.Background(), fs, "t1"); *got != (model.TenantDefaults{ChatProviderID: "t-chat"}) {
+		t.Errorf("ResolveDefaults = %+v, want tenant values only", *got)
+	}
+}
+
+func TestResolveUserDefaults(t *testing.T) {
+	fs := newFakeStore()
+	fs.settings.settings = systemSettings
+	fs.tenants.tenants["t1"] = &model.Tenant{ID: "t1", Defaults: &model.TenantDefaults{ChatProviderID: "t-chat", AgentID: "t-agent"}}
+	fs.users.users["u1"] = &model.User{ID: "u1", TenantID: "t1"}
+
+	tests := []struct {
+		userID string
+		want   model.TenantDefaults
+	}{
+		{"u1", model.TenantDefaults{ChatProviderID: "t-chat", EmbeddingProviderID: "sys-embed", RerankProviderID: "sys-rerank", AgentID: "t-agent"}},
+		{"unknown", model.TenantDefaults{ChatProviderID: "sys-chat", EmbeddingProviderID: "sys-embed", RerankProviderID: "sys-rerank"}},
+		{"", model.TenantDefaults{ChatProviderID: "sys-chat", EmbeddingProviderID: "sys-embed", RerankProviderID: "sys-rerank"}},
+	}
+	for _, tt := range tests {
+		if got := ResolveUserDefaults(context.Background(), fs, tt.userID); *got != tt.want {
+			t.Errorf("ResolveUserDefaults(%q) = %+v, want %+v", tt.userID, *got, tt.want)
+		}
+	}
+}
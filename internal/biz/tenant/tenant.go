@@ -20,6 +20,10 @@ type Biz interface {
 	Update(ctx context.Context, id string, req *UpdateTenantRequest) (*model.Tenant, error)
 	Delete(ctx context.Context, id string) error
 
+	// Defaults
+	GetDefaults(ctx context.Context, tenantID string) (*model.TenantDefaults, error)
+	SetDefaults(ctx context.Context, tenantID string, defaults *model.TenantDefaults) (*model.TenantDefaults, error)
+
 	// API Key
 	CreateAPIKey(ctx context.Context, req *CreateAPIKeyRequest) (*APIKeyWithSecret, error)
 	GetAPIKey(ctx context.Context, id string) (*model.APIKey, error)
@@ -46,6 +50,10 @@ type UpdateTenantRequest struct {
 	Status      *model.TenantStatus `json:"status"`
 	Config      model.JSONMap       `json:"config"`
 	Quota       model.JSONMap       `json:"quota"`
+	// Retention 会话保留策略，覆盖全局配置（字段为 0 表示该租户不限制）
+	Retention *model.RetentionPolicy `json:"retention"`
+	// ToolPolicy 工具允许/禁止策略，Allow 和 Deny 都为空时清除策略
+	ToolPolicy *model.ToolPolicy `json:"tool_policy"`
 }
 
 // CreateAPIKeyRequest 创建 API Key 请求.
@@ -124,6 +132,21 @@ func (b *bizImpl) Update(ctx context.Context, id string, req *UpdateTenantReques
 	if req.Quota != nil {
 		tenant.Quota = req.Quota
 	}
+	if req.Retention != nil {
+		if req.Retention.MaxMessagesPerSession < 0 || req.Retention.MaxSessionAgeDays < 0 {
+			return nil, fmt.Errorf("retention limits must not be negative")
+		}
+		tenant.Retention = req.Retention
+	}
+	if req.ToolPolicy != nil {
+		if err := validateToolPolicy(req.ToolPolicy); err != nil {
+			return nil, err
+		}
+		tenant.ToolPolicy = req.ToolPolicy
+		if req.ToolPolicy.IsEmpty() {
+			tenant.ToolPolicy = nil
+		}
+	}
 
 	if err := b.store.Tenants().Update(ctx, tenant); err != nil {
 		return nil, err
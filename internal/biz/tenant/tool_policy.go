@@ -0,0 +1,48 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// ErrInvalidToolPolicy 工具策略不合法.
+var ErrInvalidToolPolicy = errs.New(errs.ErrValidation, "invalid tool policy")
+
+// validateToolPolicy 校验策略中的工具名非空且不重复.
+// 不限制为已知的内置工具，以便提前禁用之后才接入的工具.
+func validateToolPolicy(p *model.ToolPolicy) error {
+	for field, names := range map[string][]string{"allow": p.Allow, "deny": p.Deny} {
+		seen := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			if strings.TrimSpace(name) == "" {
+				return fmt.Errorf("%w: %s contains an empty tool name", ErrInvalidToolPolicy, field)
+			}
+			if _, ok := seen[name]; ok {
+				return fmt.Errorf("%w: duplicate tool %q in %s", ErrInvalidToolPolicy, name, field)
+			}
+			seen[name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// ResolveUserToolPolicy 返回用户所属租户的工具策略，用户未归属租户或租户未配置策略时返回 nil.
+func ResolveUserToolPolicy(ctx context.Context, s store.Store, userID string) *model.ToolPolicy {
+	if userID == "" {
+		return nil
+	}
+	user, err := s.Users().Get(ctx, userID)
+	if err != nil || user.TenantID == "" {
+		return nil
+	}
+	tenant, err := s.Tenants().Get(ctx, user.TenantID)
+	if err != nil || tenant.ToolPolicy.IsEmpty() {
+		return nil
+	}
+	return tenant.ToolPolicy
+}
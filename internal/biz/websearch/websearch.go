@@ -36,7 +36,7 @@ type UpdateConfigRequest struct {
 
 const (
 	// webSearchConfigKey 系统设置中的配置 Key.
-	webSearchConfigKey = "web-search.config"
+	webSearchConfigKey = model.SettingKeyWebSearchConfig
 )
 
 type bizImpl struct {
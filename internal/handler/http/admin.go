@@ -0,0 +1,109 @@
+// Package http 提供 HTTP Handler.
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ashwinyue/next-show/internal/biz/knowledge"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// requireAdmin 校验请求携带管理员 token，失败时写入错误响应并返回 false.
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	token := extractToken(c)
+	if token == "" {
+		writeError(c, http.StatusUnauthorized, "missing authorization header")
+		return false
+	}
+	claims, err := h.biz.Auth().ValidateToken(c.Request.Context(), token)
+	if err != nil {
+		writeError(c, http.StatusUnauthorized, err.Error())
+		return false
+	}
+	if claims.Role != model.UserRoleAdmin {
+		writeError(c, http.StatusForbidden, "admin role required")
+		return false
+	}
+	return true
+}
+
+// RepairOrphans 查找并清理孤立的分块、向量和分块标签关联.
+// 默认只返回统计结果，confirm=true 时分批删除.
+func (h *Handler) RepairOrphans(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	var req knowledge.RepairOrphansRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			writeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	report, err := h.biz.Knowledge().RepairOrphans(c.Request.Context(), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, report)
+}
+
+// StartReindex 在后台重新读取来源、重新生成向量或重建搜索索引（全局操作，有最小间隔），进度通过 /admin/reindex-jobs/:job_id 查询.
+func (h *Handler) StartReindex(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	var req knowledge.ReindexRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			writeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	job, err := h.biz.Knowledge().StartReindex(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusAccepted, job)
+}
+
+// ReembedDocuments 为指定文档重新生成向量（不重新分块），进度通过 /admin/reindex-jobs/:job_id 查询.
+func (h *Handler) ReembedDocuments(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	var req knowledge.ReembedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := h.biz.Knowledge().ReembedDocuments(c.Request.Context(), c.Param("id"), req.DocumentIDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusAccepted, job)
+}
+
+// GetReindexJob 获取重建任务进度.
+func (h *Handler) GetReindexJob(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	job, err := h.biz.Knowledge().GetReindexJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, job)
+}
@@ -10,11 +10,16 @@ import (
 	"github.com/ashwinyue/next-show/internal/model"
 )
 
-// ListAgents 列出所有 Agent.
+// ListAgents 列出所有 Agent，支持 sort/order 排序和 type、role 过滤.
 func (h *Handler) ListAgents(c *gin.Context) {
-	agents, err := h.biz.AgentConfig().ListAgents(c.Request.Context())
+	opts, err := listOptions(c, "type", "role")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+	agents, err := h.biz.AgentConfig().ListAgents(c.Request.Context(), opts)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"agents": agents})
@@ -25,7 +30,7 @@ func (h *Handler) GetAgent(c *gin.Context) {
 	id := c.Param("id")
 	agentModel, err := h.biz.AgentConfig().GetAgent(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, agentModel)
@@ -36,8 +41,8 @@ type CreateAgentRequest struct {
 	Name          string          `json:"name" binding:"required"`
 	DisplayName   string          `json:"display_name" binding:"required"`
 	Description   string          `json:"description"`
-	ProviderID    string          `json:"provider_id" binding:"required"`
-	ModelName     string          `json:"model_name" binding:"required"`
+	ProviderID    string          `json:"provider_id"` // 为空时使用租户/系统默认 Provider
+	ModelName     string          `json:"model_name"`  // 为空时使用 Provider 默认模型
 	SystemPrompt  string          `json:"system_prompt"`
 	AgentType     model.AgentType `json:"agent_type" binding:"required"`
 	AgentRole     model.AgentRole `json:"agent_role"`
@@ -52,7 +57,7 @@ type CreateAgentRequest struct {
 func (h *Handler) CreateAgent(c *gin.Context) {
 	var req CreateAgentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -72,7 +77,7 @@ func (h *Handler) CreateAgent(c *gin.Context) {
 		SubAgentIDs:   req.SubAgentIDs,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -102,7 +107,7 @@ func (h *Handler) UpdateAgent(c *gin.Context) {
 	id := c.Param("id")
 	var req UpdateAgentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -123,7 +128,7 @@ func (h *Handler) UpdateAgent(c *gin.Context) {
 		SubAgentIDs:   req.SubAgentIDs,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -134,7 +139,7 @@ func (h *Handler) UpdateAgent(c *gin.Context) {
 func (h *Handler) DeleteAgent(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.biz.AgentConfig().DeleteAgent(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
@@ -144,7 +149,7 @@ func (h *Handler) DeleteAgent(c *gin.Context) {
 func (h *Handler) ListBuiltinAgents(c *gin.Context) {
 	agents, err := h.biz.AgentConfig().ListBuiltinAgents(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"agents": agents})
@@ -154,7 +159,7 @@ func (h *Handler) ListBuiltinAgents(c *gin.Context) {
 func (h *Handler) ListOrchestratorAgents(c *gin.Context) {
 	agents, err := h.biz.AgentConfig().ListOrchestratorAgents(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"agents": agents})
@@ -164,7 +169,7 @@ func (h *Handler) ListOrchestratorAgents(c *gin.Context) {
 func (h *Handler) ListSpecialistAgents(c *gin.Context) {
 	agents, err := h.biz.AgentConfig().ListSpecialistAgents(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"agents": agents})
@@ -175,7 +180,7 @@ func (h *Handler) GetAgentRelations(c *gin.Context) {
 	id := c.Param("id")
 	relations, err := h.biz.AgentConfig().GetAgentRelations(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"relations": relations})
@@ -191,12 +196,12 @@ func (h *Handler) SetAgentRelations(c *gin.Context) {
 	id := c.Param("id")
 	var req SetAgentRelationsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if err := h.biz.AgentConfig().SetAgentRelations(c.Request.Context(), id, req.SubAgentIDs); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -208,7 +213,7 @@ func (h *Handler) ListAgentTools(c *gin.Context) {
 	id := c.Param("id")
 	tools, err := h.biz.AgentConfig().ListAgentTools(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"tools": tools})
@@ -229,7 +234,7 @@ func (h *Handler) AddAgentTool(c *gin.Context) {
 	id := c.Param("id")
 	var req AddAgentToolRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -242,13 +247,72 @@ func (h *Handler) AddAgentTool(c *gin.Context) {
 		Priority:         req.Priority,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, tool)
 }
 
+// SetAgentToolsRequest 整体替换 Agent 工具请求.
+type SetAgentToolsRequest struct {
+	Tools []AddAgentToolRequest `json:"tools" binding:"dive"`
+}
+
+// SetAgentTools 整体替换 Agent 的工具集.
+func (h *Handler) SetAgentTools(c *gin.Context) {
+	id := c.Param("id")
+	var req SetAgentToolsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reqs := make([]agent.AddAgentToolRequest, 0, len(req.Tools))
+	for _, t := range req.Tools {
+		reqs = append(reqs, agent.AddAgentToolRequest{
+			ToolType:         t.ToolType,
+			MCPToolID:        t.MCPToolID,
+			BuiltinToolName:  t.BuiltinToolName,
+			CustomToolConfig: t.CustomToolConfig,
+			ReturnDirectly:   t.ReturnDirectly,
+			Priority:         t.Priority,
+		})
+	}
+
+	tools, err := h.biz.AgentConfig().SetAgentTools(c.Request.Context(), id, reqs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, http.StatusOK, gin.H{"tools": tools})
+}
+
+// ReorderAgentToolsRequest 批量调整 Agent 工具顺序请求.
+type ReorderAgentToolsRequest struct {
+	// ToolIDs 按优先级从高到低排列的工具 ID
+	ToolIDs []string `json:"tool_ids" binding:"required"`
+}
+
+// ReorderAgentTools 批量调整 Agent 工具优先级.
+func (h *Handler) ReorderAgentTools(c *gin.Context) {
+	id := c.Param("id")
+	var req ReorderAgentToolsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tools, err := h.biz.AgentConfig().ReorderAgentTools(c.Request.Context(), id, req.ToolIDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	respondOK(c, http.StatusOK, gin.H{"tools": tools})
+}
+
 // UpdateAgentToolRequest 更新 Agent 工具请求.
 type UpdateAgentToolRequestHTTP struct {
 	ReturnDirectly *bool `json:"return_directly"`
@@ -261,7 +325,7 @@ func (h *Handler) UpdateAgentTool(c *gin.Context) {
 	toolID := c.Param("tool_id")
 	var req UpdateAgentToolRequestHTTP
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -271,7 +335,7 @@ func (h *Handler) UpdateAgentTool(c *gin.Context) {
 		Priority:       req.Priority,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -282,7 +346,7 @@ func (h *Handler) UpdateAgentTool(c *gin.Context) {
 func (h *Handler) RemoveAgentTool(c *gin.Context) {
 	toolID := c.Param("tool_id")
 	if err := h.biz.AgentConfig().RemoveAgentTool(c.Request.Context(), toolID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
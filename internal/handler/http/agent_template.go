@@ -39,7 +39,7 @@ type CreateAgentFromTemplateRequest struct {
 	Name          string   `json:"name" binding:"required"`          // Agent 名称
 	DisplayName   string   `json:"display_name" binding:"required"`  // 显示名称
 	ProviderID    string   `json:"provider_id" binding:"required"`
-	ModelName     string   `json:"model_name" binding:"required"`
+	ModelName     string   `json:"model_name"`    // 为空时使用 Provider 默认模型
 	SystemPrompt  string   `json:"system_prompt"` // 覆盖默认提示词
 	SubAgentIDs   []string `json:"sub_agent_ids"` // 子 Agent ID 列表
 	MaxIterations int      `json:"max_iterations"`
@@ -49,14 +49,14 @@ type CreateAgentFromTemplateRequest struct {
 func (h *Handler) CreateAgentFromTemplate(c *gin.Context) {
 	var req CreateAgentFromTemplateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// 获取模板
 	template, err := h.getAgentTemplateByCode(req.TemplateCode)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -91,7 +91,7 @@ func (h *Handler) CreateAgentFromTemplate(c *gin.Context) {
 
 	agentModel, err := h.biz.AgentConfig().CreateAgent(c.Request.Context(), createReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
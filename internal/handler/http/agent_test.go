@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ashwinyue/next-show/internal/biz/agent"
+	"github.com/ashwinyue/next-show/internal/pkg/agent/builtin"
+)
+
+func TestUpdateBuiltinAgentForbidden(t *testing.T) {
+	// 内置 Agent 在访问存储前即被拒绝
+	h := &Handler{biz: &fakeBiz{agentConfig: agent.NewConfigBiz(nil, nil)}}
+	r := gin.New()
+	r.PUT("/agents/:id", h.UpdateAgent)
+
+	id := builtin.ListBuiltinAgents()[0].ID
+	req := httptest.NewRequest(http.MethodPut, "/agents/"+id, strings.NewReader(`{"name":"renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403: %s", w.Code, w.Body.String())
+	}
+}
@@ -16,13 +16,13 @@ import (
 func (h *Handler) Register(c *gin.Context) {
 	var req auth.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	user, err := h.biz.Auth().Register(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -37,13 +37,13 @@ func (h *Handler) Register(c *gin.Context) {
 func (h *Handler) Login(c *gin.Context) {
 	var req auth.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	resp, err := h.biz.Auth().Login(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		writeError(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
@@ -54,12 +54,12 @@ func (h *Handler) Login(c *gin.Context) {
 func (h *Handler) Logout(c *gin.Context) {
 	token := extractToken(c)
 	if token == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing authorization header"})
+		writeError(c, http.StatusBadRequest, "missing authorization header")
 		return
 	}
 
 	if err := h.biz.Auth().Logout(c.Request.Context(), token); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -73,13 +73,13 @@ func (h *Handler) Logout(c *gin.Context) {
 func (h *Handler) GetCurrentUser(c *gin.Context) {
 	token := extractToken(c)
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+		writeError(c, http.StatusUnauthorized, "missing authorization header")
 		return
 	}
 
 	user, err := h.biz.Auth().GetCurrentUser(c.Request.Context(), token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		writeError(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
@@ -90,25 +90,25 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 func (h *Handler) UpdateProfile(c *gin.Context) {
 	token := extractToken(c)
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+		writeError(c, http.StatusUnauthorized, "missing authorization header")
 		return
 	}
 
 	claims, err := h.biz.Auth().ValidateToken(c.Request.Context(), token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		writeError(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
 	var req auth.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	user, err := h.biz.Auth().UpdateProfile(c.Request.Context(), claims.UserID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -119,24 +119,24 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 func (h *Handler) ChangePassword(c *gin.Context) {
 	token := extractToken(c)
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+		writeError(c, http.StatusUnauthorized, "missing authorization header")
 		return
 	}
 
 	claims, err := h.biz.Auth().ValidateToken(c.Request.Context(), token)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		writeError(c, http.StatusUnauthorized, err.Error())
 		return
 	}
 
 	var req auth.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if err := h.biz.Auth().ChangePassword(c.Request.Context(), claims.UserID, &req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -153,7 +153,7 @@ func (h *Handler) ListUsers(c *gin.Context) {
 	tenantID := c.Query("tenant_id")
 	users, err := h.biz.Auth().ListUsers(c.Request.Context(), tenantID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"users": users})
@@ -164,7 +164,7 @@ func (h *Handler) GetUser(c *gin.Context) {
 	id := c.Param("id")
 	user, err := h.biz.Auth().GetUser(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, user)
@@ -174,7 +174,7 @@ func (h *Handler) GetUser(c *gin.Context) {
 func (h *Handler) DeleteUser(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.biz.Auth().DeleteUser(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
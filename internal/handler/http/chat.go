@@ -2,11 +2,15 @@
 package http
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/ashwinyue/next-show/internal/biz/agent"
+	"github.com/ashwinyue/next-show/internal/biz/auth"
+	"github.com/ashwinyue/next-show/internal/model"
 	"github.com/ashwinyue/next-show/internal/pkg/sse"
 )
 
@@ -34,15 +38,30 @@ func (h *Handler) AgentChat(c *gin.Context) {
 
 	var req AgentChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	// 申请运行许可，排队已满时直接拒绝
+	ticket, err := h.runLimiter.Reserve(h.requestTenantID(c))
+	if err != nil {
+		writeError(c, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	defer ticket.Release()
+
 	// 生成消息 ID
 	messageID := uuid.New().String()
 
-	// 创建 SSE Writer
-	writer := sse.NewGinWriter(c)
+	// 客户端过慢被断开时取消 Agent 运行
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// 创建带缓冲的 SSE Writer，慢客户端不会阻塞 Agent 运行
+	sseConfig := h.sseConfig
+	sseConfig.OnDisconnect = cancel
+	writer := sse.NewBufferedWriter(sse.NewGinWriter(c), &sseConfig)
+	defer writer.Close()
 	writer.SetHeaders()
 
 	// 发送开始事件
@@ -51,21 +70,161 @@ func (h *Handler) AgentChat(c *gin.Context) {
 		return
 	}
 
+	// 排队等待运行许可，期间心跳保持连接，超时后明确告知客户端服务繁忙
+	if ticket.Queued() {
+		_ = writer.Send(sse.Event{Type: sse.EventTypeQueued, SessionID: sessionID, ID: messageID})
+		if err := ticket.Wait(ctx); err != nil {
+			_ = writer.SendServerBusy(err.Error())
+			_ = writer.SendComplete(sessionID, messageID)
+			return
+		}
+	}
+
+	// 保存用户消息
+	_, _ = h.biz.Sessions().AddMessage(ctx, sessionID, "user", req.Query)
+
 	// 调用 Agent 业务层（事件已在 SSE adapter 中处理）
-	err := h.biz.Agents().Chat(c.Request.Context(), sessionID, req.Query, writer)
+	result, err := h.biz.Agents().Chat(ctx, sessionID, req.Query, writer)
 
 	// 发送完成事件
 	_ = writer.SendComplete(sessionID, messageID)
 
-	// 持久化消息
-	ctx := c.Request.Context()
-
-	// 保存用户消息
-	_, _ = h.biz.Sessions().AddMessage(ctx, sessionID, "user", req.Query)
-
 	// 检查是否有错误
 	if err != nil {
 		// 错误已在 SSE 中发送，这里不需要再处理
 		return
 	}
+
+	// 保存助手消息及用量
+	assistant := &model.Message{
+		ID:        messageID,
+		SessionID: sessionID,
+		Role:      model.MessageRoleAssistant,
+		Content:   result.Answer,
+	}
+	if result.Usage != nil {
+		assistant.TokenUsage = model.JSONMap(result.Usage.Map())
+		assistant.Cost = result.Usage.Cost
+	}
+	_ = h.biz.Sessions().SaveMessage(ctx, assistant)
+}
+
+// PreviewAgentRequest Agent 预览运行请求.
+type PreviewAgentRequest struct {
+	Query         string  `json:"query" binding:"required"`
+	ProviderID    string  `json:"provider_id,omitempty"`
+	ModelName     string  `json:"model_name,omitempty"`
+	SystemPrompt  *string `json:"system_prompt,omitempty"`
+	MaxIterations int     `json:"max_iterations,omitempty"`
+}
+
+// PreviewAgent 预览运行 Agent，通过 SSE 返回结果，不创建会话也不保存消息.
+func (h *Handler) PreviewAgent(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var req PreviewAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 按调用者所属租户限流，并解析租户默认值和工具策略
+	var userID, tenantID string
+	if claims := h.requestClaims(c); claims != nil {
+		userID, tenantID = claims.UserID, claims.TenantID
+	}
+	ticket, err := h.runLimiter.Reserve(tenantID)
+	if err != nil {
+		writeError(c, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	defer ticket.Release()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	sseConfig := h.sseConfig
+	sseConfig.OnDisconnect = cancel
+	writer := sse.NewBufferedWriter(sse.NewGinWriter(c), &sseConfig)
+	defer writer.Close()
+	writer.SetHeaders()
+
+	if ticket.Queued() {
+		_ = writer.Send(sse.Event{Type: sse.EventTypeQueued})
+		if err := ticket.Wait(ctx); err != nil {
+			_ = writer.SendServerBusy(err.Error())
+			return
+		}
+	}
+
+	_, _ = h.biz.Agents().Preview(ctx, agentID, &agent.PreviewRequest{
+		Query:         req.Query,
+		UserID:        userID,
+		ProviderID:    req.ProviderID,
+		ModelName:     req.ModelName,
+		SystemPrompt:  req.SystemPrompt,
+		MaxIterations: req.MaxIterations,
+	}, writer)
+	_ = writer.SendComplete("", "")
+}
+
+// CompareAgentsRequest Agent 对比运行请求.
+type CompareAgentsRequest struct {
+	AgentIDs []string `json:"agent_ids" binding:"required,len=2"`
+	Query    string   `json:"query" binding:"required"`
+}
+
+// CompareAgents 使用相同输入并发运行两个 Agent，返回两侧的回答、运行过程、用量和耗时.
+func (h *Handler) CompareAgents(c *gin.Context) {
+	var req CompareAgentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 两侧都以调用者身份运行，使用其租户的默认值和工具策略
+	var userID, tenantID string
+	if claims := h.requestClaims(c); claims != nil {
+		userID, tenantID = claims.UserID, claims.TenantID
+	}
+
+	// 对比运行整体占用一个并发许可，避免租户并发上限为 1 时两侧互相等待
+	ticket, err := h.runLimiter.Reserve(tenantID)
+	if err != nil {
+		writeError(c, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	defer ticket.Release()
+	if err := ticket.Wait(c.Request.Context()); err != nil {
+		writeError(c, http.StatusTooManyRequests, err.Error())
+		return
+	}
+
+	runs := h.biz.Agents().Compare(c.Request.Context(), &agent.CompareRequest{
+		AgentIDs: req.AgentIDs,
+		Query:    req.Query,
+		UserID:   userID,
+	})
+	respondOK(c, http.StatusOK, gin.H{"query": req.Query, "runs": runs})
+}
+
+// requestTenantID 返回请求 token 所属租户，未携带或无效 token 时返回空.
+func (h *Handler) requestTenantID(c *gin.Context) string {
+	if claims := h.requestClaims(c); claims != nil {
+		return claims.TenantID
+	}
+	return ""
+}
+
+// requestClaims 返回请求 token 的认证信息，未携带或无效 token 时返回 nil.
+func (h *Handler) requestClaims(c *gin.Context) *auth.Claims {
+	token := extractToken(c)
+	if token == "" {
+		return nil
+	}
+	claims, err := h.biz.Auth().ValidateToken(c.Request.Context(), token)
+	if err != nil {
+		return nil
+	}
+	return claims
 }
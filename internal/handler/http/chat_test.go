@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ashwinyue/next-show/internal/biz/agent"
+	"github.com/ashwinyue/next-show/internal/biz/auth"
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
+)
+
+// fakeAuthBiz 只接受 valid-token 的认证业务.
+type fakeAuthBiz struct {
+	auth.Biz
+}
+
+func (b *fakeAuthBiz) ValidateToken(_ context.Context, token string) (*auth.Claims, error) {
+	if token != "valid-token" {
+		return nil, errors.New("invalid token")
+	}
+	return &auth.Claims{UserID: "u1", TenantID: "t1"}, nil
+}
+
+// fakeAgentBiz 记录预览和对比运行请求的 Agent 业务.
+type fakeAgentBiz struct {
+	agent.AgentBiz
+	previews []*agent.PreviewRequest
+	compares []*agent.CompareRequest
+}
+
+func (b *fakeAgentBiz) Preview(_ context.Context, _ string, req *agent.PreviewRequest, _ sse.Writer) (*agent.ChatResult, error) {
+	b.previews = append(b.previews, req)
+	return &agent.ChatResult{}, nil
+}
+
+func (b *fakeAgentBiz) Compare(_ context.Context, req *agent.CompareRequest) []*agent.CompareRun {
+	b.compares = append(b.compares, req)
+	runs := make([]*agent.CompareRun, 0, len(req.AgentIDs))
+	for _, id := range req.AgentIDs {
+		runs = append(runs, &agent.CompareRun{AgentID: id})
+	}
+	return runs
+}
+
+func TestPreviewAgentUsesCaller(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{name: "authenticated", token: "valid-token", want: "u1"},
+		{name: "invalid token", token: "expired", want: ""},
+		{name: "anonymous", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agents := &fakeAgentBiz{}
+			h := &Handler{biz: &fakeBiz{agents: agents, auth: &fakeAuthBiz{}}}
+			r := gin.New()
+			r.POST("/agents/:id/preview", h.PreviewAgent)
+
+			req := httptest.NewRequest(http.MethodPost, "/agents/a1/preview", strings.NewReader(`{"query":"hi"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if len(agents.previews) != 1 {
+				t.Fatalf("Preview called %d times, want 1", len(agents.previews))
+			}
+			if got := agents.previews[0].UserID; got != tt.want {
+				t.Errorf("preview user = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareAgentsUsesCaller(t *testing.T) {
+	agents := &fakeAgentBiz{}
+	h := &Handler{biz: &fakeBiz{agents: agents, auth: &fakeAuthBiz{}}}
+	r := gin.New()
+	r.POST("/agents/compare", h.CompareAgents)
+
+	req := httptest.NewRequest(http.MethodPost, "/agents/compare", strings.NewReader(`{"agent_ids":["a1","a2"],"query":"hi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer valid-token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if len(agents.compares) != 1 || agents.compares[0].UserID != "u1" {
+		t.Fatalf("compare requests = %+v, want one run as u1", agents.compares)
+	}
+	var resp struct {
+		Code string `json:"code"`
+		Data struct {
+			Query string              `json:"query"`
+			Runs  []*agent.CompareRun `json:"runs"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Code != CodeOK || resp.Data.Query != "hi" || len(resp.Data.Runs) != 2 {
+		t.Errorf("response = %s, want both runs in the envelope", w.Body.String())
+	}
+}
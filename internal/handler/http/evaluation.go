@@ -2,8 +2,10 @@
 package http
 
 import (
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/ashwinyue/next-show/internal/biz/evaluation"
 	"github.com/ashwinyue/next-show/internal/model"
@@ -50,7 +52,7 @@ type CreateDatasetItemRequestHTTP struct {
 func (h *EvaluationHandler) CreateDataset(c *gin.Context) {
 	var req CreateDatasetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -81,7 +83,7 @@ func (h *EvaluationHandler) CreateDataset(c *gin.Context) {
 
 	dataset, err := h.evaluationService.CreateDataset(c.Request.Context(), serviceReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -111,7 +113,7 @@ func (h *EvaluationHandler) GetDataset(c *gin.Context) {
 
 	dataset, err := h.evaluationService.GetDataset(c.Request.Context(), tenantID.(uint), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -137,7 +139,7 @@ func (h *EvaluationHandler) ListDatasets(c *gin.Context) {
 
 	datasets, err := h.evaluationService.ListDatasets(c.Request.Context(), tenantID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -166,7 +168,7 @@ func (h *EvaluationHandler) GetDatasetItems(c *gin.Context) {
 
 	items, err := h.evaluationService.GetDatasetItems(c.Request.Context(), tenantID.(uint), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -194,7 +196,7 @@ func (h *EvaluationHandler) DeleteDataset(c *gin.Context) {
 	}
 
 	if err := h.evaluationService.DeleteDataset(c.Request.Context(), tenantID.(uint), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -209,6 +211,15 @@ type RunEvaluationRequest struct {
 	DatasetID       string `json:"dataset_id" binding:"required"`
 	AgentID         string `json:"agent_id" binding:"required"`
 	KnowledgeBaseID string `json:"knowledge_base_id"`
+	Deterministic   bool   `json:"deterministic"` // 可复现模式，强制 temperature=0
+	// Metrics 要计算的指标（recall/precision/mrr/f1/bleu/rouge1/rouge2/rougel），为空时计算全部指标
+	Metrics []string `json:"metrics"`
+	// BLEUMaxN BLEU 的最大 n-gram 阶数（默认 4），BLEUSmoothing 平滑方法（none/epsilon/add_one，默认 epsilon）
+	BLEUMaxN      int    `json:"bleu_max_n"`
+	BLEUSmoothing string `json:"bleu_smoothing"`
+	// Concurrency 同时评估的条目数（默认 4），RateLimit 每秒开始评估的条目数上限（默认不限制）
+	Concurrency int     `json:"concurrency"`
+	RateLimit   float64 `json:"rate_limit"`
 }
 
 // RunEvaluation 运行评估任务.
@@ -224,7 +235,7 @@ type RunEvaluationRequest struct {
 func (h *EvaluationHandler) RunEvaluation(c *gin.Context) {
 	var req RunEvaluationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -238,11 +249,17 @@ func (h *EvaluationHandler) RunEvaluation(c *gin.Context) {
 		DatasetID:       req.DatasetID,
 		AgentID:         req.AgentID,
 		KnowledgeBaseID: req.KnowledgeBaseID,
+		Deterministic:   req.Deterministic,
+		Metrics:         req.Metrics,
+		BLEUMaxN:        req.BLEUMaxN,
+		BLEUSmoothing:   req.BLEUSmoothing,
+		Concurrency:     req.Concurrency,
+		RateLimit:       req.RateLimit,
 	}
 
 	task, err := h.evaluationService.RunEvaluation(c.Request.Context(), serviceReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -272,7 +289,7 @@ func (h *EvaluationHandler) GetTask(c *gin.Context) {
 
 	task, err := h.evaluationService.GetTask(c.Request.Context(), tenantID.(uint), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -301,7 +318,7 @@ func (h *EvaluationHandler) ListTasks(c *gin.Context) {
 
 	tasks, err := h.evaluationService.ListTasks(c.Request.Context(), tenantID.(uint), datasetID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -337,7 +354,7 @@ func (h *EvaluationHandler) GetTaskResults(c *gin.Context) {
 
 	results, err := h.evaluationService.GetTaskResults(c.Request.Context(), tenantID.(uint), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -366,6 +383,44 @@ func (h *EvaluationHandler) GetTaskResults(c *gin.Context) {
 	})
 }
 
+// ExportTaskResults 导出评估任务的结果.
+// @Summary 导出评估结果
+// @Description 以 CSV 或 JSON 文件导出评估任务的逐条结果，包含问题、期望答案、生成答案、检索文档和各指标分数，以及各指标的平均分
+// @Tags 评估
+// @Produce text/csv
+// @Produce json
+// @Param id path string true "任务 ID"
+// @Param format query string false "导出格式（csv/json）" default(csv)
+// @Success 200 {file} file "结果文件"
+// @Failure 400 {object} map[string]string "错误信息"
+// @Failure 404 {object} map[string]string "错误信息"
+// @Router /api/v1/evaluation/tasks/{id}/export [get]
+func (h *EvaluationHandler) ExportTaskResults(c *gin.Context) {
+	id := c.Param("id")
+	format := strings.ToLower(strings.TrimSpace(c.Query("format")))
+	if format == "" {
+		format = evaluation.ExportFormatCSV
+	}
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		tenantID = uint(1)
+	}
+
+	data, err := h.evaluationService.ExportTaskResults(c.Request.Context(), tenantID.(uint), id, format)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == evaluation.ExportFormatJSON {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "evaluation-" + id + "." + format}))
+	c.Data(http.StatusOK, contentType, data)
+}
+
 // DeleteTask 删除评估任务.
 // @Summary 删除评估任务
 // @Description 删除指定的评估任务及其所有结果
@@ -384,7 +439,7 @@ func (h *EvaluationHandler) DeleteTask(c *gin.Context) {
 	}
 
 	if err := h.evaluationService.DeleteTask(c.Request.Context(), tenantID.(uint), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -393,3 +448,62 @@ func (h *EvaluationHandler) DeleteTask(c *gin.Context) {
 		"message": "task deleted",
 	})
 }
+
+// CancelTask 取消评估任务.
+// @Summary 取消评估任务
+// @Description 取消等待中或运行中的评估任务，已完成条目的结果保留，汇总指标只包含已完成的条目
+// @Tags 评估
+// @Accept json
+// @Produce json
+// @Param id path string true "任务 ID"
+// @Success 200 {object} map[string]interface{} "任务信息"
+// @Failure 409 {object} map[string]string "任务已结束"
+// @Router /api/v1/evaluation/tasks/{id}/cancel [post]
+func (h *EvaluationHandler) CancelTask(c *gin.Context) {
+	id := c.Param("id")
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		tenantID = uint(1)
+	}
+
+	task, err := h.evaluationService.CancelTask(c.Request.Context(), tenantID.(uint), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+	})
+}
+
+// ResumeTask 恢复评估任务.
+// @Summary 恢复评估任务
+// @Description 恢复中断或失败的评估任务，只评估尚无结果的条目
+// @Tags 评估
+// @Accept json
+// @Produce json
+// @Param id path string true "任务 ID"
+// @Success 202 {object} map[string]interface{} "任务信息"
+// @Router /api/v1/evaluation/tasks/{id}/resume [post]
+func (h *EvaluationHandler) ResumeTask(c *gin.Context) {
+	id := c.Param("id")
+
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		tenantID = uint(1)
+	}
+
+	task, err := h.evaluationService.ResumeEvaluation(c.Request.Context(), tenantID.(uint), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    task,
+	})
+}
@@ -2,29 +2,50 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/ashwinyue/next-show/internal/biz/knowledge"
 	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
 )
 
+// maxWebhookBodySize Webhook 请求体的最大字节数.
+const maxWebhookBodySize = 1 << 20
+
 // CreateKnowledgeBase 创建知识库.
 func (h *Handler) CreateKnowledgeBase(c *gin.Context) {
 	var req model.KnowledgeBase
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewer, err := h.knowledgeViewer(c)
+	if err != nil {
+		writeError(c, http.StatusUnauthorized, err.Error())
 		return
 	}
+	// 所属租户取自登录凭证，忽略请求体中的 tenant_id
+	req.TenantID = ""
+	if viewer != nil {
+		req.TenantID = viewer.TenantID
+	}
 
 	if err := h.biz.Knowledge().CreateKnowledgeBase(c.Request.Context(), &req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, req)
+	c.JSON(http.StatusCreated, knowledge.RedactSecrets(&req))
 }
 
 // GetKnowledgeBase 获取知识库.
@@ -32,20 +53,35 @@ func (h *Handler) GetKnowledgeBase(c *gin.Context) {
 	id := c.Param("id")
 	kb, err := h.biz.Knowledge().GetKnowledgeBase(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, knowledge.RedactSecrets(kb))
+}
+
+// GetKnowledgeBaseStats 获取知识库的文档数、分块数和导入队列情况.
+func (h *Handler) GetKnowledgeBaseStats(c *gin.Context) {
+	id := c.Param("id")
+	stats, err := h.biz.Knowledge().GetKnowledgeBaseStats(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, kb)
+	respondOK(c, http.StatusOK, stats)
 }
 
 // ListKnowledgeBases 列出知识库.
 func (h *Handler) ListKnowledgeBases(c *gin.Context) {
 	kbs, err := h.biz.Knowledge().ListKnowledgeBases(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"items": kbs, "total": len(kbs)})
+	items := make([]*model.KnowledgeBase, len(kbs))
+	for i, kb := range kbs {
+		items[i] = knowledge.RedactSecrets(kb)
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "total": len(items)})
 }
 
 // UpdateKnowledgeBase 更新知识库.
@@ -53,24 +89,24 @@ func (h *Handler) UpdateKnowledgeBase(c *gin.Context) {
 	id := c.Param("id")
 	var req model.KnowledgeBase
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 	req.ID = id
 
 	if err := h.biz.Knowledge().UpdateKnowledgeBase(c.Request.Context(), &req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, req)
+	c.JSON(http.StatusOK, knowledge.RedactSecrets(&req))
 }
 
 // DeleteKnowledgeBase 删除知识库.
 func (h *Handler) DeleteKnowledgeBase(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.biz.Knowledge().DeleteKnowledgeBase(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
@@ -81,13 +117,13 @@ func (h *Handler) CreateDocument(c *gin.Context) {
 	kbID := c.Param("id")
 	var req model.KnowledgeDocument
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 	req.KnowledgeBaseID = kbID
 
 	if err := h.biz.Knowledge().CreateDocument(c.Request.Context(), &req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -99,18 +135,117 @@ func (h *Handler) GetDocument(c *gin.Context) {
 	id := c.Param("id")
 	doc, err := h.biz.Knowledge().GetDocument(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, doc)
 }
 
-// ListDocuments 列出文档.
+// DownloadDocument 下载文档原始文件.
+// S3 存储返回限时有效的预签名地址（redirect=true 时直接重定向），本地存储直接返回文件内容.
+func (h *Handler) DownloadDocument(c *gin.Context) {
+	id := c.Param("id")
+
+	viewer, err := h.knowledgeViewer(c)
+	if err != nil {
+		writeError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	download, err := h.biz.Knowledge().DownloadDocument(c.Request.Context(), id, viewer)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if download.URL != "" {
+		if c.Query("redirect") == "true" {
+			c.Redirect(http.StatusFound, download.URL)
+			return
+		}
+		respondOK(c, http.StatusOK, gin.H{
+			"url":          download.URL,
+			"expires_at":   download.ExpiresAt,
+			"file_name":    download.FileName,
+			"content_type": download.ContentType,
+		})
+		return
+	}
+
+	defer download.Body.Close()
+	c.DataFromReader(http.StatusOK, -1, download.ContentType, download.Body, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": download.FileName}),
+	})
+}
+
+// RechunkDocument 使用新的分块参数重新切分文档，返回分块差异.
+func (h *Handler) RechunkDocument(c *gin.Context) {
+	id := c.Param("id")
+
+	var req knowledge.RechunkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.biz.Knowledge().RechunkDocument(c.Request.Context(), id, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, result)
+}
+
+// MoveDocument 将文档移动到另一个知识库.
+func (h *Handler) MoveDocument(c *gin.Context) {
+	id := c.Param("id")
+
+	var req struct {
+		TargetKnowledgeBaseID string `json:"target_knowledge_base_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewer, err := h.knowledgeViewer(c)
+	if err != nil {
+		writeError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	doc, err := h.biz.Knowledge().MoveDocument(c.Request.Context(), id, req.TargetKnowledgeBaseID, viewer)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, doc)
+}
+
+// GetRelatedDocuments 获取与文档内容相近的其他文档.
+func (h *Handler) GetRelatedDocuments(c *gin.Context) {
+	id := c.Param("id")
+	topK, _ := strconv.Atoi(c.DefaultQuery("top_k", "5"))
+
+	docs, err := h.biz.Knowledge().GetRelatedDocuments(c.Request.Context(), id, topK)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, gin.H{"document_id": id, "documents": docs})
+}
+
+// ListDocuments 列出文档，支持 sort/order 排序和 status、source_type 过滤.
 func (h *Handler) ListDocuments(c *gin.Context) {
 	kbID := c.Param("id")
-	docs, err := h.biz.Knowledge().ListDocuments(c.Request.Context(), kbID)
+	opts, err := listOptions(c, "status", "source_type")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	docs, err := h.biz.Knowledge().ListDocuments(c.Request.Context(), kbID, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"items": docs, "total": len(docs)})
@@ -120,12 +255,30 @@ func (h *Handler) ListDocuments(c *gin.Context) {
 func (h *Handler) DeleteDocument(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.biz.Knowledge().DeleteDocument(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// DeleteDocuments 按来源类型、解析状态或 ID 列表批量删除知识库中的文档.
+func (h *Handler) DeleteDocuments(c *gin.Context) {
+	kbID := c.Param("id")
+
+	var filter knowledge.DocumentFilter
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deleted, err := h.biz.Knowledge().DeleteDocuments(c.Request.Context(), kbID, &filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, gin.H{"deleted": deleted})
+}
+
 // ListChunksRequest 列出分块请求.
 type ListChunksRequest struct {
 	Limit  int `form:"limit,default=20"`
@@ -137,7 +290,7 @@ func (h *Handler) ListChunks(c *gin.Context) {
 	docID := c.Param("id")
 	var req ListChunksRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -147,7 +300,7 @@ func (h *Handler) ListChunks(c *gin.Context) {
 
 	chunks, total, err := h.biz.Knowledge().ListChunks(c.Request.Context(), docID, req.Limit, req.Offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -164,26 +317,58 @@ func (h *Handler) ImportDocument(c *gin.Context) {
 	kbID := c.Param("id")
 	var req knowledge.ImportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 	req.KnowledgeBaseID = kbID
+	req.Async = importAsync(c)
 
 	result, err := h.biz.Knowledge().ImportDocument(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
+	respondImport(c, result)
+}
+
+// importAsync 导入默认在后台执行，?async=false 时等待导入完成后返回.
+func importAsync(c *gin.Context) bool {
+	return c.Query("async") != "false"
+}
+
+// respondImport 异步导入返回 202 和任务 ID，同步导入返回 201.
+func respondImport(c *gin.Context, result *knowledge.ImportResult) {
+	if result.JobID != "" {
+		c.JSON(http.StatusAccepted, result)
+		return
+	}
 	c.JSON(http.StatusCreated, result)
 }
 
+// GetDocumentStatus 查询文档导入阶段和进度.
+func (h *Handler) GetDocumentStatus(c *gin.Context) {
+	status, err := h.biz.Knowledge().GetDocumentStatus(c.Request.Context(), c.Param("id"), c.Param("doc_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, status)
+}
+
 // HybridSearchRequest 混合检索请求.
 type HybridSearchRequest struct {
-	Query        string  `json:"query" binding:"required"`
-	TopK         int     `json:"top_k,omitempty"`
-	VectorWeight float64 `json:"vector_weight,omitempty"`
-	BM25Weight   float64 `json:"bm25_weight,omitempty"`
+	Query            string         `json:"query" binding:"required"`
+	TopK             int            `json:"top_k,omitempty"`
+	VectorWeight     float64        `json:"vector_weight,omitempty"`
+	BM25Weight       float64        `json:"bm25_weight,omitempty"`
+	DistanceFunction string         `json:"distance_function,omitempty"` // cosine（默认）/ l2 / ip
+	DocumentMetadata map[string]any `json:"document_metadata,omitempty"`
+	// MetadataFilters 按分块元数据过滤，例如 [{"field": "category", "operator": "eq", "value": "tech"}]
+	MetadataFilters []store.MetadataFilter `json:"metadata_filters,omitempty"`
+	FusionMethod    string                 `json:"fusion_method,omitempty"` // weighted_sum（默认）/ rrf
+	RRFK            int                    `json:"rrf_k,omitempty"`         // rrf 的平滑常数，默认 60
+	TagIDs          []string               `json:"tag_ids,omitempty"`       // 按标签过滤，包含子孙标签
 }
 
 // HybridSearch 混合检索（向量 + BM25）.
@@ -191,14 +376,25 @@ func (h *Handler) HybridSearch(c *gin.Context) {
 	kbID := c.Param("id")
 	var req HybridSearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// 调用 knowledge service 的混合检索
-	result, err := h.biz.Knowledge().Search(c.Request.Context(), kbID, req.Query, req.TopK, req.VectorWeight, req.BM25Weight)
+	result, err := h.biz.Knowledge().Search(c.Request.Context(), kbID, &knowledge.HybridSearchRequest{
+		Query:            req.Query,
+		TopK:             req.TopK,
+		VectorWeight:     req.VectorWeight,
+		BM25Weight:       req.BM25Weight,
+		DistanceFunction: req.DistanceFunction,
+		DocumentMetadata: req.DocumentMetadata,
+		MetadataFilters:  req.MetadataFilters,
+		FusionMethod:     req.FusionMethod,
+		RRFK:             req.RRFK,
+		TagIDs:           req.TagIDs,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -212,7 +408,7 @@ func (h *Handler) UploadDocument(c *gin.Context) {
 	// 获取上传的文件
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required: " + err.Error()})
+		writeError(c, http.StatusBadRequest, "file is required: "+err.Error())
 		return
 	}
 	defer file.Close()
@@ -236,31 +432,117 @@ func (h *Handler) UploadDocument(c *gin.Context) {
 		}
 	}
 
+	// 文档元数据（JSON 字符串）
+	var metadata model.JSONMap
+	if md := c.PostForm("metadata"); md != "" {
+		if err := json.Unmarshal([]byte(md), &metadata); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid metadata: "+err.Error())
+			return
+		}
+	}
+
+	// 复制到分块的文档元数据键（逗号分隔）
+	var propagateKeys []string
+	if keys := c.PostForm("propagate_metadata_keys"); keys != "" {
+		for _, key := range strings.Split(keys, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				propagateKeys = append(propagateKeys, key)
+			}
+		}
+	}
+
 	req := &knowledge.ImportRequest{
-		KnowledgeBaseID: kbID,
-		Title:           title,
-		SourceType:      "file",
-		FileName:        header.Filename,
-		FileReader:      file,
-		ChunkSize:       chunkSize,
-		ChunkOverlap:    chunkOverlap,
+		KnowledgeBaseID:       kbID,
+		Title:                 title,
+		SourceType:            "file",
+		FileName:              header.Filename,
+		FileReader:            file,
+		ChunkSize:             chunkSize,
+		ChunkOverlap:          chunkOverlap,
+		ChunkUnit:             knowledge.ChunkUnit(c.PostForm("chunk_unit")),
+		Metadata:              metadata,
+		PropagateMetadataKeys: propagateKeys,
+		SeparatorPreset:       knowledge.SeparatorPreset(c.PostForm("separator_preset")),
+		Force:                 c.PostForm("force") == "true",
+		Async:                 importAsync(c),
 	}
 
 	result, err := h.biz.Knowledge().ImportDocument(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, result)
+	respondImport(c, result)
+}
+
+// ImportTable 按列映射导入 CSV / XLSX 表格，每行生成一个文档（multipart/form-data）.
+// mapping 为 JSON，例如 {"title": "标题", "content": "正文", "metadata": {"url": "链接"}}.
+func (h *Handler) ImportTable(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "file is required: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	var mapping knowledge.ColumnMapping
+	if err := json.Unmarshal([]byte(c.PostForm("mapping")), &mapping); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid mapping: "+err.Error())
+		return
+	}
+
+	var metadata model.JSONMap
+	if md := c.PostForm("metadata"); md != "" {
+		if err := json.Unmarshal([]byte(md), &metadata); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid metadata: "+err.Error())
+			return
+		}
+	}
+
+	req := &knowledge.TableImportRequest{
+		KnowledgeBaseID: c.Param("id"),
+		FileName:        header.Filename,
+		FileReader:      file,
+		Mapping:         mapping,
+		Metadata:        metadata,
+		ChunkUnit:       knowledge.ChunkUnit(c.PostForm("chunk_unit")),
+	}
+	if cs := c.PostForm("chunk_size"); cs != "" {
+		if _, err := fmt.Sscanf(cs, "%d", &req.ChunkSize); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid chunk_size")
+			return
+		}
+	}
+	if co := c.PostForm("chunk_overlap"); co != "" {
+		if _, err := fmt.Sscanf(co, "%d", &req.ChunkOverlap); err != nil {
+			writeError(c, http.StatusBadRequest, "invalid chunk_overlap")
+			return
+		}
+	}
+
+	result, err := h.biz.Knowledge().ImportTable(c.Request.Context(), req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, result)
 }
 
 // SearchKnowledgeBaseRequest 搜索知识库请求.
 type SearchKnowledgeBaseRequest struct {
-	Query        string  `json:"query" binding:"required"`
-	TopK         int     `json:"top_k"`
-	VectorWeight float64 `json:"vector_weight"`
-	BM25Weight   float64 `json:"bm25_weight"`
+	Query            string         `json:"query" binding:"required"`
+	TopK             int            `json:"top_k"`
+	VectorWeight     float64        `json:"vector_weight"`
+	BM25Weight       float64        `json:"bm25_weight"`
+	DistanceFunction string         `json:"distance_function"` // cosine（默认）/ l2 / ip
+	DocumentMetadata map[string]any `json:"document_metadata"` // 按文档元数据过滤，例如 {"department": "legal"}
+	Rerank           bool           `json:"rerank"`            // 重排序结果，返回重排序前后的分数
+	// MetadataFilters 按分块元数据过滤，例如 [{"field": "category", "operator": "eq", "value": "tech"}]
+	MetadataFilters []store.MetadataFilter `json:"metadata_filters"`
+	FusionMethod    string                 `json:"fusion_method"` // weighted_sum（默认）/ rrf
+	RRFK            int                    `json:"rrf_k"`         // rrf 的平滑常数，默认 60
+	TagIDs          []string               `json:"tag_ids"`       // 按标签过滤，包含子孙标签
 }
 
 // SearchKnowledgeBase 搜索知识库.
@@ -268,15 +550,152 @@ func (h *Handler) SearchKnowledgeBase(c *gin.Context) {
 	kbID := c.Param("id")
 	var req SearchKnowledgeBaseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	searchResult, err := h.biz.Knowledge().Search(c.Request.Context(), kbID, req.Query, req.TopK, req.VectorWeight, req.BM25Weight)
+	searchResult, err := h.biz.Knowledge().Search(c.Request.Context(), kbID, &knowledge.HybridSearchRequest{
+		Query:            req.Query,
+		TopK:             req.TopK,
+		VectorWeight:     req.VectorWeight,
+		BM25Weight:       req.BM25Weight,
+		DistanceFunction: req.DistanceFunction,
+		DocumentMetadata: req.DocumentMetadata,
+		MetadataFilters:  req.MetadataFilters,
+		FusionMethod:     req.FusionMethod,
+		RRFK:             req.RRFK,
+		Rerank:           req.Rerank,
+		TagIDs:           req.TagIDs,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, searchResult)
 }
+
+// knowledgeViewer 根据可选的访问令牌返回知识库访问者，未携带令牌时返回 nil（匿名访问）.
+func (h *Handler) knowledgeViewer(c *gin.Context) (*knowledge.Viewer, error) {
+	token := extractToken(c)
+	if token == "" {
+		return nil, nil
+	}
+	claims, err := h.biz.Auth().ValidateToken(c.Request.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	return &knowledge.Viewer{TenantID: claims.TenantID, Admin: claims.Role == model.UserRoleAdmin}, nil
+}
+
+// CloneKnowledgeBase 复制知识库（后台执行），返回复制任务，新知识库 ID 为任务的 knowledge_base_id.
+func (h *Handler) CloneKnowledgeBase(c *gin.Context) {
+	id := c.Param("id")
+	var req knowledge.CloneRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewer, err := h.knowledgeViewer(c)
+	if err != nil {
+		writeError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	job, err := h.biz.Knowledge().CloneKnowledgeBase(c.Request.Context(), id, &req, viewer)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusAccepted, job)
+}
+
+// GetCloneJob 获取知识库复制任务进度.
+func (h *Handler) GetCloneJob(c *gin.Context) {
+	job, err := h.biz.Knowledge().GetCloneJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, job)
+}
+
+// ExportKnowledgeBase 将知识库导出为 tar.gz 归档（流式下载）.
+func (h *Handler) ExportKnowledgeBase(c *gin.Context) {
+	id := c.Param("id")
+
+	viewer, err := h.knowledgeViewer(c)
+	if err != nil {
+		writeError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "knowledge-base-" + id + ".tar.gz"}))
+	err = h.biz.Knowledge().ExportKnowledgeBase(c.Request.Context(), id, c.Writer, viewer)
+	if err == nil {
+		return
+	}
+	// 已开始写入归档时无法再返回错误响应，只能中断连接
+	if c.Writer.Written() {
+		_ = c.Error(err)
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Del("Content-Disposition")
+	respondError(c, err)
+}
+
+// ImportKnowledgeBaseArchive 从导出的归档创建知识库（multipart/form-data，字段 file，可选 name）.
+func (h *Handler) ImportKnowledgeBaseArchive(c *gin.Context) {
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "file is required: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	viewer, err := h.knowledgeViewer(c)
+	if err != nil {
+		writeError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	kb, err := h.biz.Knowledge().ImportKnowledgeBaseArchive(c.Request.Context(), file, &knowledge.ArchiveImportRequest{
+		Name: c.PostForm("name"),
+	}, viewer)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusCreated, knowledge.RedactSecrets(kb))
+}
+
+// KnowledgeSourceWebhook 接收外部源的文档变更通知并同步到知识库.
+func (h *Handler) KnowledgeSourceWebhook(c *gin.Context) {
+	kbID := c.Param("id")
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.biz.Knowledge().HandleSourceWebhook(c.Request.Context(), kbID, c.Request.Header, body)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, result)
+}
+
+// SyncKnowledgeBase 全量同步外部源中的文档.
+func (h *Handler) SyncKnowledgeBase(c *gin.Context) {
+	kbID := c.Param("id")
+	result, err := h.biz.Knowledge().SyncKnowledgeBase(c.Request.Context(), kbID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, result)
+}
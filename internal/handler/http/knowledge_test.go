@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ashwinyue/next-show/internal/biz/knowledge"
+)
+
+func TestSearchKnowledgeBaseRejectsInvalidParams(t *testing.T) {
+	// 参数校验在访问存储和生成向量之前完成
+	h := &Handler{biz: &fakeBiz{knowledge: knowledge.NewBiz(nil, nil, nil, nil, nil)}}
+	r := gin.New()
+	r.POST("/knowledge-bases/:id/search", h.SearchKnowledgeBase)
+
+	for name, body := range map[string]string{
+		"unknown distance": `{"query":"q","distance_function":"manhattan"}`,
+		"negative weight":  `{"query":"q","vector_weight":-0.5}`,
+		"missing query":    `{"distance_function":"cosine"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/knowledge-bases/kb1/search", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
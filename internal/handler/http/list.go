@@ -0,0 +1,32 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// listOptions 从查询参数解析列表的排序和过滤选项.
+// sort 为排序字段（created_at/updated_at/name），order 为 asc 或 desc（默认 desc），
+// filters 列出的查询参数非空时作为过滤条件；字段是否受支持由存储层校验.
+func listOptions(c *gin.Context, filters ...string) (*store.ListOptions, error) {
+	opts := &store.ListOptions{SortBy: c.Query("sort")}
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "desc":
+		opts.SortDesc = true
+	case "asc":
+	default:
+		return nil, fmt.Errorf("%w: order must be asc or desc, got %q", store.ErrInvalidListOption, order)
+	}
+	for _, key := range filters {
+		if value := c.Query(key); value != "" {
+			if opts.Filters == nil {
+				opts.Filters = make(map[string]string)
+			}
+			opts.Filters[key] = value
+		}
+	}
+	return opts, nil
+}
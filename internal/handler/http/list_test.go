@@ -0,0 +1,35 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+func TestListOptions(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/sessions?sort=name&order=asc&status=active&agent_id=&other=x", nil)
+
+	opts, err := listOptions(c, "status", "agent_id")
+	if err != nil {
+		t.Fatalf("listOptions() error = %v", err)
+	}
+	if opts.SortBy != "name" || opts.SortDesc {
+		t.Errorf("sort = %q desc=%v, want name asc", opts.SortBy, opts.SortDesc)
+	}
+	// 只收集声明的非空过滤参数
+	if len(opts.Filters) != 1 || opts.Filters["status"] != "active" {
+		t.Errorf("filters = %v, want only status", opts.Filters)
+	}
+
+	c, _ = gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/sessions?order=sideways", nil)
+	if _, err := listOptions(c); !errors.Is(err, store.ErrInvalidListOption) {
+		t.Errorf("listOptions() error = %v, want ErrInvalidListOption", err)
+	}
+}
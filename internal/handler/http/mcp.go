@@ -14,7 +14,7 @@ import (
 func (h *Handler) ListMCPServers(c *gin.Context) {
 	servers, err := h.biz.MCP().ListServers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"servers": servers})
@@ -25,7 +25,7 @@ func (h *Handler) GetMCPServer(c *gin.Context) {
 	id := c.Param("id")
 	server, err := h.biz.MCP().GetServer(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, server)
@@ -43,13 +43,14 @@ type CreateMCPServerRequest struct {
 	ServerURL      string              `json:"server_url"`
 	CustomHeaders  model.JSONMap       `json:"custom_headers"`
 	TimeoutSeconds int                 `json:"timeout_seconds"`
+	MaxRetries     int                 `json:"max_retries"`
 }
 
 // CreateMCPServer 创建 MCP Server.
 func (h *Handler) CreateMCPServer(c *gin.Context) {
 	var req CreateMCPServerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -64,9 +65,10 @@ func (h *Handler) CreateMCPServer(c *gin.Context) {
 		ServerURL:      req.ServerURL,
 		CustomHeaders:  req.CustomHeaders,
 		TimeoutSeconds: req.TimeoutSeconds,
+		MaxRetries:     req.MaxRetries,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -85,6 +87,7 @@ type UpdateMCPServerRequest struct {
 	ServerURL      *string              `json:"server_url"`
 	CustomHeaders  model.JSONMap        `json:"custom_headers"`
 	TimeoutSeconds *int                 `json:"timeout_seconds"`
+	MaxRetries     *int                 `json:"max_retries"`
 	IsEnabled      *bool                `json:"is_enabled"`
 }
 
@@ -93,7 +96,7 @@ func (h *Handler) UpdateMCPServer(c *gin.Context) {
 	id := c.Param("id")
 	var req UpdateMCPServerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -108,10 +111,11 @@ func (h *Handler) UpdateMCPServer(c *gin.Context) {
 		ServerURL:      req.ServerURL,
 		CustomHeaders:  req.CustomHeaders,
 		TimeoutSeconds: req.TimeoutSeconds,
+		MaxRetries:     req.MaxRetries,
 		IsEnabled:      req.IsEnabled,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -122,18 +126,40 @@ func (h *Handler) UpdateMCPServer(c *gin.Context) {
 func (h *Handler) DeleteMCPServer(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.biz.MCP().DeleteServer(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
+// PingMCPServer 检查 MCP Server 是否可达，返回状态和延迟.
+func (h *Handler) PingMCPServer(c *gin.Context) {
+	id := c.Param("id")
+	result, err := h.biz.MCP().PingServer(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, result)
+}
+
+// DiscoverMCPTools 从 MCP Server 获取工具列表并同步到工具配置.
+func (h *Handler) DiscoverMCPTools(c *gin.Context) {
+	id := c.Param("id")
+	result, err := h.biz.MCP().DiscoverTools(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, result)
+}
+
 // ListMCPTools 列出 MCP Server 的工具.
 func (h *Handler) ListMCPTools(c *gin.Context) {
 	serverID := c.Param("id")
 	tools, err := h.biz.MCP().ListTools(c.Request.Context(), serverID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"tools": tools})
@@ -144,7 +170,7 @@ func (h *Handler) GetMCPTool(c *gin.Context) {
 	toolID := c.Param("tool_id")
 	tool, err := h.biz.MCP().GetTool(c.Request.Context(), toolID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, tool)
@@ -164,7 +190,7 @@ func (h *Handler) CreateMCPTool(c *gin.Context) {
 	serverID := c.Param("id")
 	var req CreateMCPToolRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -176,7 +202,7 @@ func (h *Handler) CreateMCPTool(c *gin.Context) {
 		ReturnDirectly: req.ReturnDirectly,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -198,7 +224,7 @@ func (h *Handler) UpdateMCPTool(c *gin.Context) {
 	toolID := c.Param("tool_id")
 	var req UpdateMCPToolRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -211,7 +237,7 @@ func (h *Handler) UpdateMCPTool(c *gin.Context) {
 		IsEnabled:      req.IsEnabled,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -222,7 +248,7 @@ func (h *Handler) UpdateMCPTool(c *gin.Context) {
 func (h *Handler) DeleteMCPTool(c *gin.Context) {
 	toolID := c.Param("tool_id")
 	if err := h.biz.MCP().DeleteTool(c.Request.Context(), toolID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
@@ -14,7 +14,7 @@ import (
 func (h *Handler) ListProviders(c *gin.Context) {
 	providers, err := h.biz.Providers().ListProviders(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"providers": providers})
@@ -25,7 +25,7 @@ func (h *Handler) GetProvider(c *gin.Context) {
 	id := c.Param("id")
 	p, err := h.biz.Providers().GetProvider(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, p)
@@ -33,38 +33,42 @@ func (h *Handler) GetProvider(c *gin.Context) {
 
 // CreateProviderRequest 创建 Provider 请求.
 type CreateProviderRequest struct {
-	Name          string              `json:"name" binding:"required"`
-	DisplayName   string              `json:"display_name" binding:"required"`
-	ProviderType  string              `json:"provider_type" binding:"required"`
-	ModelCategory model.ModelCategory `json:"model_category"`
-	BaseURL       string              `json:"base_url"`
-	APIKey        string              `json:"api_key"`
-	APISecret     string              `json:"api_secret"`
-	DefaultModel  string              `json:"default_model"`
-	Config        model.JSONMap       `json:"config"`
+	Name            string              `json:"name" binding:"required"`
+	DisplayName     string              `json:"display_name" binding:"required"`
+	ProviderType    string              `json:"provider_type" binding:"required"`
+	ModelCategory   model.ModelCategory `json:"model_category"`
+	BaseURL         string              `json:"base_url"`
+	APIKey          string              `json:"api_key"`
+	APISecret       string              `json:"api_secret"`
+	DefaultModel    string              `json:"default_model"`
+	Capabilities    []string            `json:"capabilities"`
+	SupportedModels []string            `json:"supported_models"`
+	Config          model.JSONMap       `json:"config"`
 }
 
 // CreateProvider 创建 Provider.
 func (h *Handler) CreateProvider(c *gin.Context) {
 	var req CreateProviderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	p, err := h.biz.Providers().CreateProvider(c.Request.Context(), &provider.CreateProviderRequest{
-		Name:          req.Name,
-		DisplayName:   req.DisplayName,
-		ProviderType:  req.ProviderType,
-		ModelCategory: req.ModelCategory,
-		BaseURL:       req.BaseURL,
-		APIKey:        req.APIKey,
-		APISecret:     req.APISecret,
-		DefaultModel:  req.DefaultModel,
-		Config:        req.Config,
+		Name:            req.Name,
+		DisplayName:     req.DisplayName,
+		ProviderType:    req.ProviderType,
+		ModelCategory:   req.ModelCategory,
+		BaseURL:         req.BaseURL,
+		APIKey:          req.APIKey,
+		APISecret:       req.APISecret,
+		DefaultModel:    req.DefaultModel,
+		Capabilities:    req.Capabilities,
+		SupportedModels: req.SupportedModels,
+		Config:          req.Config,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -73,16 +77,18 @@ func (h *Handler) CreateProvider(c *gin.Context) {
 
 // UpdateProviderRequest 更新 Provider 请求.
 type UpdateProviderRequestHTTP struct {
-	Name          *string              `json:"name"`
-	DisplayName   *string              `json:"display_name"`
-	ProviderType  *string              `json:"provider_type"`
-	ModelCategory *model.ModelCategory `json:"model_category"`
-	BaseURL       *string              `json:"base_url"`
-	APIKey        *string              `json:"api_key"`
-	APISecret     *string              `json:"api_secret"`
-	DefaultModel  *string              `json:"default_model"`
-	Config        model.JSONMap        `json:"config"`
-	IsEnabled     *bool                `json:"is_enabled"`
+	Name            *string              `json:"name"`
+	DisplayName     *string              `json:"display_name"`
+	ProviderType    *string              `json:"provider_type"`
+	ModelCategory   *model.ModelCategory `json:"model_category"`
+	BaseURL         *string              `json:"base_url"`
+	APIKey          *string              `json:"api_key"`
+	APISecret       *string              `json:"api_secret"`
+	DefaultModel    *string              `json:"default_model"`
+	Capabilities    []string             `json:"capabilities"`
+	SupportedModels []string             `json:"supported_models"`
+	Config          model.JSONMap        `json:"config"`
+	IsEnabled       *bool                `json:"is_enabled"`
 }
 
 // UpdateProvider 更新 Provider.
@@ -90,24 +96,26 @@ func (h *Handler) UpdateProvider(c *gin.Context) {
 	id := c.Param("id")
 	var req UpdateProviderRequestHTTP
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	p, err := h.biz.Providers().UpdateProvider(c.Request.Context(), id, &provider.UpdateProviderRequest{
-		Name:          req.Name,
-		DisplayName:   req.DisplayName,
-		ProviderType:  req.ProviderType,
-		ModelCategory: req.ModelCategory,
-		BaseURL:       req.BaseURL,
-		APIKey:        req.APIKey,
-		APISecret:     req.APISecret,
-		DefaultModel:  req.DefaultModel,
-		Config:        req.Config,
-		IsEnabled:     req.IsEnabled,
+		Name:            req.Name,
+		DisplayName:     req.DisplayName,
+		ProviderType:    req.ProviderType,
+		ModelCategory:   req.ModelCategory,
+		BaseURL:         req.BaseURL,
+		APIKey:          req.APIKey,
+		APISecret:       req.APISecret,
+		DefaultModel:    req.DefaultModel,
+		Capabilities:    req.Capabilities,
+		SupportedModels: req.SupportedModels,
+		Config:          req.Config,
+		IsEnabled:       req.IsEnabled,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -118,7 +126,7 @@ func (h *Handler) UpdateProvider(c *gin.Context) {
 func (h *Handler) DeleteProvider(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.biz.Providers().DeleteProvider(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
@@ -128,7 +136,7 @@ func (h *Handler) DeleteProvider(c *gin.Context) {
 func (h *Handler) ListChatProviders(c *gin.Context) {
 	providers, err := h.biz.Providers().ListChatProviders(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"providers": providers})
@@ -138,7 +146,7 @@ func (h *Handler) ListChatProviders(c *gin.Context) {
 func (h *Handler) ListEmbeddingProviders(c *gin.Context) {
 	providers, err := h.biz.Providers().ListEmbeddingProviders(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"providers": providers})
@@ -148,7 +156,7 @@ func (h *Handler) ListEmbeddingProviders(c *gin.Context) {
 func (h *Handler) ListRerankProviders(c *gin.Context) {
 	providers, err := h.biz.Providers().ListRerankProviders(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"providers": providers})
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/pkg/connector"
+	"github.com/ashwinyue/next-show/internal/pkg/limiter"
+	"github.com/ashwinyue/next-show/internal/pkg/moderation"
+	"github.com/ashwinyue/next-show/internal/store"
+)
+
+// RequestIDHeader 请求 ID 的请求头和响应头.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey 请求 ID 在 gin.Context 中的键.
+const requestIDKey = "request_id"
+
+// 响应码.
+const (
+	CodeOK              = "ok"
+	CodeInvalidArgument = "invalid_argument"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeUnprocessable   = "unprocessable"
+	CodeTooManyRequests = "too_many_requests"
+	CodeUnavailable     = "unavailable"
+	CodeInternal        = "internal"
+)
+
+// Response 统一响应结构，新增接口的成功和错误响应均使用该结构.
+// 错误响应同时保留 error 字段（与 message 相同），兼容读取 error 的旧客户端.
+type Response struct {
+	Code      string `json:"code"`
+	Message   string `json:"message,omitempty"`
+	Data      any    `json:"data,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// requestID 为每个请求分配请求 ID：沿用客户端传入的 X-Request-ID，否则生成新的 ID.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.New().String()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// respondOK 返回统一结构的成功响应.
+func respondOK(c *gin.Context, status int, data any) {
+	c.JSON(status, Response{
+		Code:      CodeOK,
+		Data:      data,
+		RequestID: c.GetString(requestIDKey),
+	})
+}
+
+// respondError 根据业务错误类型选择 HTTP 状态码并返回错误响应.
+func respondError(c *gin.Context, err error) {
+	writeError(c, errorStatus(err), err.Error())
+}
+
+// writeError 以指定 HTTP 状态码返回错误响应.
+func writeError(c *gin.Context, status int, message string) {
+	c.JSON(status, Response{
+		Code:      errorCode(status),
+		Message:   message,
+		RequestID: c.GetString(requestIDKey),
+		Error:     message,
+	})
+}
+
+// statusRules 已知错误与 HTTP 状态码的对应关系，按顺序匹配.
+// 业务错误按 errs 中的错误类型映射，其余为非业务层的错误.
+var statusRules = []struct {
+	err    error
+	status int
+}{
+	{errs.ErrValidation, http.StatusBadRequest},
+	{errs.ErrForbidden, http.StatusForbidden},
+	{errs.ErrNotFound, http.StatusNotFound},
+	{errs.ErrConflict, http.StatusConflict},
+	// 存储层未转换的记录不存在错误
+	{gorm.ErrRecordNotFound, http.StatusNotFound},
+	{store.ErrInvalidListOption, http.StatusBadRequest},
+	{store.ErrInvalidScoreThreshold, http.StatusBadRequest},
+	{connector.ErrInvalidSignature, http.StatusUnauthorized},
+	{moderation.ErrContentBlocked, http.StatusUnprocessableEntity},
+	{limiter.ErrQueueFull, http.StatusTooManyRequests},
+	{limiter.ErrQueueTimeout, http.StatusTooManyRequests},
+}
+
+// errorStatus 返回业务错误对应的 HTTP 状态码，未知错误返回 500.
+func errorStatus(err error) int {
+	for _, rule := range statusRules {
+		if errors.Is(err, rule.err) {
+			return rule.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// errorCode 返回 HTTP 状态码对应的响应码.
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidArgument
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	return CodeInternal
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/biz"
+	"github.com/ashwinyue/next-show/internal/biz/agent"
+	"github.com/ashwinyue/next-show/internal/biz/auth"
+	"github.com/ashwinyue/next-show/internal/biz/errs"
+	"github.com/ashwinyue/next-show/internal/biz/knowledge"
+	"github.com/ashwinyue/next-show/internal/biz/tenant"
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/connector"
+	"github.com/ashwinyue/next-show/internal/pkg/limiter"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeBiz 测试用的 Biz，只实现用到的子业务.
+type fakeBiz struct {
+	biz.Biz
+	agents      agent.AgentBiz
+	agentConfig agent.ConfigBiz
+	auth        auth.Biz
+	knowledge   knowledge.Biz
+	tenants     tenant.Biz
+}
+
+func (b *fakeBiz) Agents() agent.AgentBiz { return b.agents }
+
+func (b *fakeBiz) AgentConfig() agent.ConfigBiz { return b.agentConfig }
+
+func (b *fakeBiz) Auth() auth.Biz { return b.auth }
+
+func (b *fakeBiz) Knowledge() knowledge.Biz { return b.knowledge }
+
+func (b *fakeBiz) Tenants() tenant.Biz { return b.tenants }
+
+// fakeKnowledgeBiz 返回预设错误的知识库业务.
+type fakeKnowledgeBiz struct {
+	knowledge.Biz
+	err error
+}
+
+func (b *fakeKnowledgeBiz) DeleteKnowledgeBase(context.Context, string) error { return b.err }
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: bad name", errs.New(errs.ErrValidation, "invalid agent")), http.StatusBadRequest},
+		{errs.New(errs.ErrForbidden, "builtin"), http.StatusForbidden},
+		{errs.New(errs.ErrNotFound, "missing"), http.StatusNotFound},
+		{errs.New(errs.ErrConflict, "duplicate"), http.StatusConflict},
+		{fmt.Errorf("get agent: %w", gorm.ErrRecordNotFound), http.StatusNotFound},
+		{connector.ErrInvalidSignature, http.StatusUnauthorized},
+		{limiter.ErrQueueFull, http.StatusTooManyRequests},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestHandlerErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "not found", err: fmt.Errorf("delete: %w", gorm.ErrRecordNotFound), wantStatus: http.StatusNotFound, wantCode: CodeNotFound},
+		{name: "validation", err: errs.New(errs.ErrValidation, "invalid knowledge base"), wantStatus: http.StatusBadRequest, wantCode: CodeInvalidArgument},
+		{name: "conflict", err: errs.New(errs.ErrConflict, "in use"), wantStatus: http.StatusConflict, wantCode: CodeConflict},
+		{name: "internal", err: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantCode: CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{biz: &fakeBiz{knowledge: &fakeKnowledgeBiz{err: tt.err}}}
+			r := gin.New()
+			r.Use(requestID())
+			r.DELETE("/knowledge-bases/:id", h.DeleteKnowledgeBase)
+
+			req := httptest.NewRequest(http.MethodDelete, "/knowledge-bases/kb1", nil)
+			req.Header.Set(RequestIDHeader, "req-123")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get(RequestIDHeader); got != "req-123" {
+				t.Errorf("%s header = %q, want req-123", RequestIDHeader, got)
+			}
+			var resp Response
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Code != tt.wantCode || resp.RequestID != "req-123" {
+				t.Errorf("response = %+v, want code %s and request id", resp, tt.wantCode)
+			}
+			if resp.Message != tt.err.Error() || resp.Error != resp.Message {
+				t.Errorf("message = %q, error = %q, want both %q", resp.Message, resp.Error, tt.err.Error())
+			}
+		})
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	r := gin.New()
+	r.Use(requestID())
+	r.GET("/", func(c *gin.Context) { writeError(c, http.StatusNotFound, "missing") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var resp Response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.RequestID == "" || resp.RequestID != w.Header().Get(RequestIDHeader) {
+		t.Errorf("request id = %q, header = %q", resp.RequestID, w.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestHandlerSuccessEnvelope(t *testing.T) {
+	h := &Handler{biz: &fakeBiz{tenants: &fakeTenantBiz{defaults: &model.TenantDefaults{ChatProviderID: "p1"}}}}
+	r := gin.New()
+	r.Use(requestID())
+	r.GET("/tenants/:id/defaults", h.GetTenantDefaults)
+
+	req := httptest.NewRequest(http.MethodGet, "/tenants/t1/defaults", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Response
+		Data model.TenantDefaults `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Code != CodeOK || resp.RequestID != "req-123" || resp.Message != "" || resp.Error != "" {
+		t.Errorf("response = %+v, want code ok with request id", resp.Response)
+	}
+	if resp.Data.ChatProviderID != "p1" {
+		t.Errorf("data = %+v, want the tenant defaults", resp.Data)
+	}
+}
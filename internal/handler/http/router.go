@@ -2,27 +2,48 @@
 package http
 
 import (
+	"expvar"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/ashwinyue/next-show/internal/biz"
+	"github.com/ashwinyue/next-show/internal/pkg/limiter"
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
 )
 
 // Handler HTTP 处理器聚合.
 type Handler struct {
 	biz               biz.Biz
 	evaluationHandler *EvaluationHandler
+	sseConfig         sse.BufferedWriterConfig
+	runLimiter        *limiter.Limiter
+	timeouts          TimeoutConfig
 }
 
-// NewHandler 创建 Handler 实例.
-func NewHandler(b biz.Biz) *Handler {
-	return &Handler{
+// NewHandler 创建 Handler 实例，sseConfig 为 nil 时使用默认 SSE 缓冲配置（不发送心跳），
+// runLimiter 为 nil 时不限制 Agent 并发运行数，timeouts 为 nil 时使用默认的请求超时.
+func NewHandler(b biz.Biz, sseConfig *sse.BufferedWriterConfig, runLimiter *limiter.Limiter, timeouts *TimeoutConfig) *Handler {
+	h := &Handler{
 		biz:               b,
 		evaluationHandler: NewEvaluationHandler(b.Evaluation()),
+		runLimiter:        runLimiter,
+	}
+	if sseConfig != nil {
+		h.sseConfig = *sseConfig
+	}
+	if timeouts != nil {
+		h.timeouts = *timeouts
 	}
+	return h
 }
 
 // RegisterRoutes 注册 HTTP 路由.
 func (h *Handler) RegisterRoutes(r *gin.Engine) {
+	r.Use(requestID())
+	// 默认按方法设置超时，耗时和流式接口在注册时用 longRoute / streamRoute 声明
+	r.Use(requestTimeout(h.timeouts))
+
 	// API v1 路由组
 	v1 := r.Group("/api/v1")
 
@@ -50,8 +71,26 @@ func (h *Handler) RegisterRoutes(r *gin.Engine) {
 	// Skill 路由
 	h.registerSkillRoutes(v1)
 
+	// Admin 路由
+	h.registerAdminRoutes(v1)
+
 	// 健康检查
 	r.GET("/health", h.Health)
+	r.GET("/health/ready", h.HealthReady)
+
+	// 运行指标（expvar，含 Agent 并发运行数和排队数）
+	r.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+}
+
+// registerAdminRoutes 注册管理员运维路由.
+func (h *Handler) registerAdminRoutes(r *gin.RouterGroup) {
+	admin := r.Group("/admin")
+	{
+		admin.POST("/maintenance/repair-orphans", longRoute, h.RepairOrphans)
+		admin.POST("/knowledge-bases/:id/reindex", h.StartReindex)
+		admin.POST("/knowledge-bases/:id/reembed", h.ReembedDocuments)
+		admin.GET("/reindex-jobs/:job_id", h.GetReindexJob)
+	}
 }
 
 // registerAgentRoutes 注册 Agent 路由.
@@ -63,15 +102,19 @@ func (h *Handler) registerAgentRoutes(r *gin.RouterGroup) {
 		agents.GET("/builtin", h.ListBuiltinAgents)
 		agents.GET("/orchestrators", h.ListOrchestratorAgents)
 		agents.GET("/specialists", h.ListSpecialistAgents)
+		agents.POST("/compare", streamRoute, h.CompareAgents)
 		agents.GET("/:id", h.GetAgent)
 		agents.PUT("/:id", h.UpdateAgent)
 		agents.DELETE("/:id", h.DeleteAgent)
 		agents.GET("/:id/relations", h.GetAgentRelations)
 		agents.PUT("/:id/relations", h.SetAgentRelations)
+		agents.POST("/:id/preview", streamRoute, h.PreviewAgent)
 
 		// Agent Tools
 		agents.GET("/:id/tools", h.ListAgentTools)
 		agents.POST("/:id/tools", h.AddAgentTool)
+		agents.PUT("/:id/tools", h.SetAgentTools)
+		agents.PUT("/:id/tools/order", h.ReorderAgentTools)
 		agents.PUT("/:id/tools/:tool_id", h.UpdateAgentTool)
 		agents.DELETE("/:id/tools/:tool_id", h.RemoveAgentTool)
 	}
@@ -117,6 +160,8 @@ func (h *Handler) registerMCPRoutes(r *gin.RouterGroup) {
 		mcpServers.GET("/:id", h.GetMCPServer)
 		mcpServers.PUT("/:id", h.UpdateMCPServer)
 		mcpServers.DELETE("/:id", h.DeleteMCPServer)
+		mcpServers.POST("/:id/ping", longRoute, h.PingMCPServer)
+		mcpServers.POST("/:id/discover", longRoute, h.DiscoverMCPTools)
 
 		// MCP Tools
 		mcpServers.GET("/:id/tools", h.ListMCPTools)
@@ -173,6 +218,8 @@ func (h *Handler) registerTenantRoutes(r *gin.RouterGroup) {
 		tenants.GET("/:id", h.GetTenant)
 		tenants.PUT("/:id", h.UpdateTenant)
 		tenants.DELETE("/:id", h.DeleteTenant)
+		tenants.GET("/:id/defaults", h.GetTenantDefaults)
+		tenants.PUT("/:id/defaults", h.SetTenantDefaults)
 
 		// API Keys
 		tenants.GET("/:tenant_id/api-keys", h.ListAPIKeys)
@@ -195,6 +242,7 @@ func (h *Handler) registerSettingsRoutes(r *gin.RouterGroup) {
 		settings.POST("", h.SetSetting)
 		settings.POST("/batch", h.SetMultipleSettings)
 		settings.POST("/batch/get", h.GetMultipleSettings)
+		settings.GET("/schema", h.ListSettingSchemas)
 		settings.GET("/:key", h.GetSetting)
 		settings.DELETE("/:key", h.DeleteSetting)
 	}
@@ -208,13 +256,14 @@ func (h *Handler) registerSessionRoutes(r *gin.RouterGroup) {
 		sessions.GET("", h.ListSessions)
 		sessions.GET("/:id", h.GetSession)
 		sessions.DELETE("/:id", h.DeleteSession)
+		sessions.PUT("/:id/pin", h.PinSession)
 	}
 }
 
 // registerChatRoutes 注册 Chat 路由（对齐 WeKnora）.
 func (h *Handler) registerChatRoutes(r *gin.RouterGroup) {
 	// Agent 聊天（对齐 WeKnora: /api/v1/agent-chat/:id）
-	r.POST("/agent-chat/:session_id", h.AgentChat)
+	r.POST("/agent-chat/:session_id", streamRoute, h.AgentChat)
 
 	// 消息列表（对齐 WeKnora: /api/v1/messages/:id/load）
 	r.GET("/messages/:session_id/load", h.GetMessages)
@@ -226,21 +275,44 @@ func (h *Handler) registerKnowledgeRoutes(r *gin.RouterGroup) {
 	{
 		knowledge.POST("", h.CreateKnowledgeBase)
 		knowledge.GET("", h.ListKnowledgeBases)
+		knowledge.POST("/import", longRoute, h.ImportKnowledgeBaseArchive)
 		knowledge.GET("/:id", h.GetKnowledgeBase)
+		knowledge.GET("/:id/stats", h.GetKnowledgeBaseStats)
 		knowledge.PUT("/:id", h.UpdateKnowledgeBase)
 		knowledge.DELETE("/:id", h.DeleteKnowledgeBase)
+		knowledge.POST("/:id/clone", h.CloneKnowledgeBase)
+		knowledge.GET("/:id/export", streamRoute, h.ExportKnowledgeBase)
 
 		// Documents
 		knowledge.GET("/:id/documents", h.ListDocuments)
-		knowledge.POST("/:id/documents", h.ImportDocument)
-		knowledge.POST("/:id/documents/upload", h.UploadDocument)
+		knowledge.POST("/:id/documents", longRoute, h.ImportDocument)
+		knowledge.POST("/:id/documents/upload", longRoute, h.UploadDocument)
+		knowledge.POST("/:id/documents/table", longRoute, h.ImportTable)
+		knowledge.POST("/:id/documents/delete", h.DeleteDocuments)
 		knowledge.DELETE("/:id/documents/:doc_id", h.DeleteDocument)
+		knowledge.GET("/:id/documents/:doc_id/status", h.GetDocumentStatus)
 		knowledge.GET("/:id/documents/:doc_id/chunks", h.ListChunks)
 
 		// Search
 		knowledge.POST("/:id/search", h.SearchKnowledgeBase)
+
+		// 外部源同步
+		knowledge.POST("/:id/webhook", longRoute, h.KnowledgeSourceWebhook)
+		knowledge.POST("/:id/sync", longRoute, h.SyncKnowledgeBase)
 	}
 
+	// 文档原始文件下载、移动、重新分块、相关文档
+	documents := r.Group("/knowledge/documents")
+	{
+		documents.GET("/:id/download", streamRoute, h.DownloadDocument)
+		documents.POST("/:id/move", h.MoveDocument)
+		documents.POST("/:id/rechunk", longRoute, h.RechunkDocument)
+		documents.GET("/:id/related", h.GetRelatedDocuments)
+	}
+
+	// 知识库复制任务
+	r.GET("/knowledge/clone-jobs/:job_id", h.GetCloneJob)
+
 	// Chunk & Tag 路由
 	h.registerChunkTagRoutes(r)
 }
@@ -252,12 +324,20 @@ func (h *Handler) registerChunkTagRoutes(r *gin.RouterGroup) {
 	{
 		kbTags.GET("", h.ListTags)
 		kbTags.POST("", h.CreateTag)
+		kbTags.GET("/tree", h.GetTagTree)
 		kbTags.GET("/:tag_id", h.GetTag)
 		kbTags.PUT("/:tag_id", h.UpdateTag)
 		kbTags.DELETE("/:tag_id", h.DeleteTag)
+		kbTags.POST("/:tag_id/move", h.MoveTag)
 		kbTags.GET("/:tag_id/chunks", h.ListChunksByTag)
 	}
 
+	// 按检索结果批量打标签
+	r.POST("/knowledge/:id/tags/:tag_id/apply-by-query", h.ApplyTagByQuery)
+
+	// 租户所有知识库的标签（按名称合并的使用统计）
+	r.GET("/tenants/:id/tags", h.ListTenantTags)
+
 	// 知识库下的分块
 	kbChunks := r.Group("/knowledge-bases/:kb_id/chunks")
 	{
@@ -276,6 +356,24 @@ func (h *Handler) Health(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "ok"})
 }
 
+// HealthReady 就绪检查：数据库不可达时返回 503；pgvector 不可用时仍可提供全文检索，返回 degraded.
+func (h *Handler) HealthReady(c *gin.Context) {
+	support, err := h.biz.Knowledge().CheckVectorSupport(c.Request.Context())
+	if err != nil {
+		writeError(c, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if !support.Available {
+		respondOK(c, http.StatusOK, gin.H{
+			"status":        "degraded",
+			"vector_search": "unavailable",
+			"reason":        support.Reason,
+		})
+		return
+	}
+	respondOK(c, http.StatusOK, gin.H{"status": "ready", "vector_search": "available"})
+}
+
 // registerEvaluationRoutes 注册评估路由.
 func (h *Handler) registerEvaluationRoutes(r *gin.RouterGroup) {
 	evaluation := r.Group("/evaluation")
@@ -288,11 +386,14 @@ func (h *Handler) registerEvaluationRoutes(r *gin.RouterGroup) {
 		evaluation.GET("/datasets/:id/items", h.evaluationHandler.GetDatasetItems)
 
 		// 评估任务
-		evaluation.POST("/run", h.evaluationHandler.RunEvaluation)
+		evaluation.POST("/run", longRoute, h.evaluationHandler.RunEvaluation)
 		evaluation.GET("/tasks", h.evaluationHandler.ListTasks)
 		evaluation.GET("/tasks/:id", h.evaluationHandler.GetTask)
 		evaluation.DELETE("/tasks/:id", h.evaluationHandler.DeleteTask)
 		evaluation.GET("/tasks/:id/results", h.evaluationHandler.GetTaskResults)
+		evaluation.GET("/tasks/:id/export", longRoute, h.evaluationHandler.ExportTaskResults)
+		evaluation.POST("/tasks/:id/resume", h.evaluationHandler.ResumeTask)
+		evaluation.POST("/tasks/:id/cancel", h.evaluationHandler.CancelTask)
 	}
 }
 
@@ -17,7 +17,7 @@ type CreateSessionRequest struct {
 func (h *Handler) CreateSession(c *gin.Context) {
 	var req CreateSessionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -26,22 +26,27 @@ func (h *Handler) CreateSession(c *gin.Context) {
 
 	session, err := h.biz.Sessions().Create(c.Request.Context(), userID, req.AgentID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, session)
 }
 
-// ListSessions 列出会话.
+// ListSessions 列出会话，支持 sort/order 排序和 status、agent_id 过滤.
 func (h *Handler) ListSessions(c *gin.Context) {
 	userID := "default_user"
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	opts, err := listOptions(c, "status", "agent_id")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-	sessions, total, err := h.biz.Sessions().List(c.Request.Context(), userID, offset, limit)
+	sessions, total, err := h.biz.Sessions().List(c.Request.Context(), userID, offset, limit, opts)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -56,7 +61,7 @@ func (h *Handler) GetSession(c *gin.Context) {
 	id := c.Param("id")
 	session, err := h.biz.Sessions().Get(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		writeError(c, http.StatusNotFound, "session not found")
 		return
 	}
 	c.JSON(http.StatusOK, session)
@@ -66,12 +71,32 @@ func (h *Handler) GetSession(c *gin.Context) {
 func (h *Handler) DeleteSession(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.biz.Sessions().Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
+// PinSessionRequest 置顶会话请求.
+type PinSessionRequest struct {
+	Pinned bool `json:"pinned"`
+}
+
+// PinSession 置顶或取消置顶会话.
+func (h *Handler) PinSession(c *gin.Context) {
+	id := c.Param("id")
+	var req PinSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.biz.Sessions().SetPinned(c.Request.Context(), id, req.Pinned); err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, gin.H{"pinned": req.Pinned})
+}
+
 // GetMessages 获取会话消息（对齐 WeKnora: /api/v1/messages/:id/load）.
 func (h *Handler) GetMessages(c *gin.Context) {
 	sessionID := c.Param("session_id")
@@ -80,7 +105,7 @@ func (h *Handler) GetMessages(c *gin.Context) {
 
 	messages, err := h.biz.Sessions().GetMessages(c.Request.Context(), sessionID, beforeTime, limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"items": messages})
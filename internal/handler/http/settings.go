@@ -27,7 +27,7 @@ func (h *Handler) ListSettings(c *gin.Context) {
 	if category != "" {
 		result, e := h.biz.Settings().ListByCategory(c.Request.Context(), category)
 		if e != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": e.Error()})
+			writeError(c, http.StatusInternalServerError, e.Error())
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"settings": result})
@@ -36,19 +36,25 @@ func (h *Handler) ListSettings(c *gin.Context) {
 
 	result, err := h.biz.Settings().List(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	_ = settingsList
 	c.JSON(http.StatusOK, gin.H{"settings": result})
 }
 
+// ListSettingSchemas 获取设置项定义.
+func (h *Handler) ListSettingSchemas(c *gin.Context) {
+	schemas := h.biz.Settings().Schemas(c.Request.Context())
+	respondOK(c, http.StatusOK, gin.H{"schemas": schemas, "custom_prefix": settings.CustomKeyPrefix})
+}
+
 // GetSetting 获取单个设置.
 func (h *Handler) GetSetting(c *gin.Context) {
 	key := c.Param("key")
 	setting, err := h.biz.Settings().Get(c.Request.Context(), key)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, setting)
@@ -68,7 +74,7 @@ type SetSettingRequest struct {
 func (h *Handler) SetSetting(c *gin.Context) {
 	var req SetSettingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -81,7 +87,7 @@ func (h *Handler) SetSetting(c *gin.Context) {
 		Description: req.Description,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -92,7 +98,7 @@ func (h *Handler) SetSetting(c *gin.Context) {
 func (h *Handler) DeleteSetting(c *gin.Context) {
 	key := c.Param("key")
 	if err := h.biz.Settings().Delete(c.Request.Context(), key); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
@@ -107,13 +113,13 @@ type GetMultipleSettingsRequest struct {
 func (h *Handler) GetMultipleSettings(c *gin.Context) {
 	var req GetMultipleSettingsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	result, err := h.biz.Settings().GetMultiple(c.Request.Context(), req.Keys)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -129,12 +135,12 @@ type SetMultipleSettingsRequest struct {
 func (h *Handler) SetMultipleSettings(c *gin.Context) {
 	var req SetMultipleSettingsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if err := h.biz.Settings().SetMultiple(c.Request.Context(), req.Settings); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
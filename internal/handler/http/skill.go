@@ -49,7 +49,7 @@ type UpdateSkillRequest struct {
 func (h *Handler) CreateSkill(c *gin.Context) {
 	var req CreateSkillRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -81,7 +81,7 @@ func (h *Handler) CreateSkill(c *gin.Context) {
 
 	created, err := h.biz.Skills().Create(c.Request.Context(), skill)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -94,7 +94,7 @@ func (h *Handler) GetSkill(c *gin.Context) {
 
 	skill, err := h.biz.Skills().Get(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "skill not found"})
+		writeError(c, http.StatusNotFound, "skill not found")
 		return
 	}
 
@@ -107,13 +107,13 @@ func (h *Handler) UpdateSkill(c *gin.Context) {
 
 	var req UpdateSkillRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	skill, err := h.biz.Skills().Get(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "skill not found"})
+		writeError(c, http.StatusNotFound, "skill not found")
 		return
 	}
 
@@ -162,7 +162,7 @@ func (h *Handler) UpdateSkill(c *gin.Context) {
 
 	updated, err := h.biz.Skills().Update(c.Request.Context(), skill)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -174,14 +174,14 @@ func (h *Handler) DeleteSkill(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := h.biz.Skills().Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
-// ListSkills 列出所有 Skills（分页）.
+// ListSkills 列出所有 Skills（分页），支持 sort/order 排序和 category 过滤.
 func (h *Handler) ListSkills(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
@@ -193,9 +193,15 @@ func (h *Handler) ListSkills(c *gin.Context) {
 		pageSize = 20
 	}
 
-	skills, total, err := h.biz.Skills().List(c.Request.Context(), page, pageSize)
+	opts, err := listOptions(c, "category")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
+		return
+	}
+
+	skills, total, err := h.biz.Skills().List(c.Request.Context(), page, pageSize, opts)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -213,7 +219,7 @@ func (h *Handler) ListSkillsByCategory(c *gin.Context) {
 
 	skills, err := h.biz.Skills().ListByCategory(c.Request.Context(), category)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -235,7 +241,7 @@ func (h *Handler) SearchSkills(c *gin.Context) {
 
 	skills, total, err := h.biz.Skills().Search(c.Request.Context(), keyword, page, pageSize)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/ashwinyue/next-show/internal/biz/knowledge"
 	"github.com/ashwinyue/next-show/internal/model"
 )
 
@@ -15,18 +16,41 @@ func (h *Handler) ListTags(c *gin.Context) {
 	kbID := c.Param("kb_id")
 	tags, err := h.biz.Knowledge().ListTags(c.Request.Context(), kbID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"tags": tags})
 }
 
+// GetTagTree 返回知识库的标签树.
+func (h *Handler) GetTagTree(c *gin.Context) {
+	kbID := c.Param("kb_id")
+	tree, err := h.biz.Knowledge().GetTagTree(c.Request.Context(), kbID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, gin.H{"tags": tree})
+}
+
+// ListTenantTags 列出租户所有知识库的标签及使用的分块数，by_knowledge_base=true 时返回各知识库的明细.
+func (h *Handler) ListTenantTags(c *gin.Context) {
+	tenantID := c.Param("id")
+	byKB := c.Query("by_knowledge_base") == "true"
+	tags, err := h.biz.Knowledge().ListTagsWithCounts(c.Request.Context(), tenantID, byKB)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, gin.H{"tags": tags})
+}
+
 // GetTag 获取标签详情.
 func (h *Handler) GetTag(c *gin.Context) {
 	tagID := c.Param("tag_id")
 	tag, err := h.biz.Knowledge().GetTag(c.Request.Context(), tagID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, tag)
@@ -34,9 +58,10 @@ func (h *Handler) GetTag(c *gin.Context) {
 
 // CreateTagRequest 创建标签请求.
 type CreateTagRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Color       string `json:"color"`
-	Description string `json:"description"`
+	Name        string  `json:"name" binding:"required"`
+	Color       string  `json:"color"`
+	Description string  `json:"description"`
+	ParentID    *string `json:"parent_id"`
 }
 
 // CreateTag 创建标签.
@@ -44,7 +69,7 @@ func (h *Handler) CreateTag(c *gin.Context) {
 	kbID := c.Param("kb_id")
 	var req CreateTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -53,10 +78,11 @@ func (h *Handler) CreateTag(c *gin.Context) {
 		Name:            req.Name,
 		Color:           req.Color,
 		Description:     req.Description,
+		ParentID:        req.ParentID,
 	}
 
 	if err := h.biz.Knowledge().CreateTag(c.Request.Context(), tag); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -75,13 +101,13 @@ func (h *Handler) UpdateTag(c *gin.Context) {
 	tagID := c.Param("tag_id")
 	var req UpdateTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	tag, err := h.biz.Knowledge().GetTag(c.Request.Context(), tagID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -96,18 +122,69 @@ func (h *Handler) UpdateTag(c *gin.Context) {
 	}
 
 	if err := h.biz.Knowledge().UpdateTag(c.Request.Context(), tag); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, tag)
 }
 
+// MoveTagRequest 移动标签请求，parent_id 为空或 null 时移为顶层标签.
+type MoveTagRequest struct {
+	ParentID *string `json:"parent_id"`
+}
+
+// MoveTag 修改标签的父标签.
+func (h *Handler) MoveTag(c *gin.Context) {
+	tagID := c.Param("tag_id")
+	var req MoveTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	tag, err := h.biz.Knowledge().MoveTag(c.Request.Context(), tagID, req.ParentID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, tag)
+}
+
+// ApplyTagByQueryRequest 按检索结果批量打标签请求.
+type ApplyTagByQueryRequest struct {
+	Query          string  `json:"query" binding:"required"`
+	Mode           string  `json:"mode"`            // keyword（默认）/ semantic
+	ScoreThreshold float64 `json:"score_threshold"` // 只为分数不低于该值的分块打标签
+	MaxChunks      int     `json:"max_chunks"`      // 最多打标签的分块数，默认 1000
+}
+
+// ApplyTagByQuery 检索知识库，为匹配的分块批量添加标签.
+func (h *Handler) ApplyTagByQuery(c *gin.Context) {
+	kbID := c.Param("id")
+	tagID := c.Param("tag_id")
+	var req ApplyTagByQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, err := h.biz.Knowledge().ApplyTagByQuery(c.Request.Context(), kbID, tagID, &knowledge.ApplyTagByQueryRequest{
+		Query:          req.Query,
+		Mode:           req.Mode,
+		ScoreThreshold: req.ScoreThreshold,
+		MaxChunks:      req.MaxChunks,
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, result)
+}
+
 // DeleteTag 删除标签.
 func (h *Handler) DeleteTag(c *gin.Context) {
 	tagID := c.Param("tag_id")
 	if err := h.biz.Knowledge().DeleteTag(c.Request.Context(), tagID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
@@ -122,7 +199,7 @@ func (h *Handler) ListChunksByTag(c *gin.Context) {
 
 	chunks, total, err := h.biz.Knowledge().ListChunksByTag(c.Request.Context(), tagID, pageSize, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -156,7 +233,7 @@ func (h *Handler) ListChunksByKB(c *gin.Context) {
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -173,7 +250,7 @@ func (h *Handler) GetChunkDetail(c *gin.Context) {
 	chunkID := c.Param("chunk_id")
 	chunk, err := h.biz.Knowledge().GetChunk(c.Request.Context(), chunkID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -197,13 +274,13 @@ func (h *Handler) UpdateChunkHandler(c *gin.Context) {
 	chunkID := c.Param("chunk_id")
 	var req UpdateChunkRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	chunk, err := h.biz.Knowledge().GetChunk(c.Request.Context(), chunkID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 
@@ -218,7 +295,7 @@ func (h *Handler) UpdateChunkHandler(c *gin.Context) {
 	}
 
 	if err := h.biz.Knowledge().UpdateChunk(c.Request.Context(), chunk); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -229,7 +306,7 @@ func (h *Handler) UpdateChunkHandler(c *gin.Context) {
 func (h *Handler) DeleteChunkHandler(c *gin.Context) {
 	chunkID := c.Param("chunk_id")
 	if err := h.biz.Knowledge().DeleteChunk(c.Request.Context(), chunkID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
@@ -245,12 +322,12 @@ func (h *Handler) AddChunkTag(c *gin.Context) {
 	chunkID := c.Param("chunk_id")
 	var req AddChunkTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	if err := h.biz.Knowledge().AddTagToChunk(c.Request.Context(), chunkID, req.TagID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -263,7 +340,7 @@ func (h *Handler) RemoveChunkTag(c *gin.Context) {
 	tagID := c.Param("tag_id")
 
 	if err := h.biz.Knowledge().RemoveTagFromChunk(c.Request.Context(), chunkID, tagID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -275,7 +352,7 @@ func (h *Handler) ListChunkTagsHandler(c *gin.Context) {
 	chunkID := c.Param("chunk_id")
 	tags, err := h.biz.Knowledge().ListTagsByChunk(c.Request.Context(), chunkID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"tags": tags})
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/ashwinyue/next-show/internal/biz/tenant"
+	"github.com/ashwinyue/next-show/internal/model"
 )
 
 // === 租户管理 ===
@@ -15,7 +16,7 @@ import (
 func (h *Handler) ListTenants(c *gin.Context) {
 	tenants, err := h.biz.Tenants().List(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"tenants": tenants})
@@ -26,7 +27,7 @@ func (h *Handler) GetTenant(c *gin.Context) {
 	id := c.Param("id")
 	t, err := h.biz.Tenants().Get(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, t)
@@ -36,13 +37,13 @@ func (h *Handler) GetTenant(c *gin.Context) {
 func (h *Handler) CreateTenant(c *gin.Context) {
 	var req tenant.CreateTenantRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	t, err := h.biz.Tenants().Create(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -54,13 +55,13 @@ func (h *Handler) UpdateTenant(c *gin.Context) {
 	id := c.Param("id")
 	var req tenant.UpdateTenantRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	t, err := h.biz.Tenants().Update(c.Request.Context(), id, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -71,12 +72,40 @@ func (h *Handler) UpdateTenant(c *gin.Context) {
 func (h *Handler) DeleteTenant(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.biz.Tenants().Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
+// GetTenantDefaults 获取租户默认配置.
+func (h *Handler) GetTenantDefaults(c *gin.Context) {
+	id := c.Param("id")
+	defaults, err := h.biz.Tenants().GetDefaults(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, defaults)
+}
+
+// SetTenantDefaults 设置租户默认配置（整体替换）.
+func (h *Handler) SetTenantDefaults(c *gin.Context) {
+	id := c.Param("id")
+	var req model.TenantDefaults
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defaults, err := h.biz.Tenants().SetDefaults(c.Request.Context(), id, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondOK(c, http.StatusOK, defaults)
+}
+
 // === API Key 管理 ===
 
 // ListAPIKeys 列出租户的 API Keys.
@@ -84,7 +113,7 @@ func (h *Handler) ListAPIKeys(c *gin.Context) {
 	tenantID := c.Param("tenant_id")
 	keys, err := h.biz.Tenants().ListAPIKeys(c.Request.Context(), tenantID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
@@ -95,7 +124,7 @@ func (h *Handler) GetAPIKey(c *gin.Context) {
 	id := c.Param("key_id")
 	key, err := h.biz.Tenants().GetAPIKey(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, http.StatusNotFound, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, key)
@@ -106,14 +135,14 @@ func (h *Handler) CreateAPIKey(c *gin.Context) {
 	tenantID := c.Param("tenant_id")
 	var req tenant.CreateAPIKeyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 	req.TenantID = tenantID
 
 	keyWithSecret, err := h.biz.Tenants().CreateAPIKey(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -124,7 +153,7 @@ func (h *Handler) CreateAPIKey(c *gin.Context) {
 func (h *Handler) RevokeAPIKey(c *gin.Context) {
 	id := c.Param("key_id")
 	if err := h.biz.Tenants().RevokeAPIKey(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "revoked"})
@@ -134,7 +163,7 @@ func (h *Handler) RevokeAPIKey(c *gin.Context) {
 func (h *Handler) DeleteAPIKey(c *gin.Context) {
 	id := c.Param("key_id")
 	if err := h.biz.Tenants().DeleteAPIKey(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/biz/tenant"
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// fakeTenantBiz 返回预设结果的租户业务.
+type fakeTenantBiz struct {
+	tenant.Biz
+	defaults *model.TenantDefaults
+	err      error
+}
+
+func (b *fakeTenantBiz) GetDefaults(context.Context, string) (*model.TenantDefaults, error) {
+	return b.defaults, b.err
+}
+
+func TestGetTenantDefaultsStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		biz  *fakeTenantBiz
+		want int
+	}{
+		{name: "found", biz: &fakeTenantBiz{defaults: &model.TenantDefaults{}}, want: http.StatusOK},
+		{name: "missing tenant", biz: &fakeTenantBiz{err: fmt.Errorf("get tenant: %w", gorm.ErrRecordNotFound)}, want: http.StatusNotFound},
+		{name: "store failure", biz: &fakeTenantBiz{err: errors.New("connection refused")}, want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{biz: &fakeBiz{tenants: tt.biz}}
+			r := gin.New()
+			r.GET("/tenants/:id/defaults", h.GetTenantDefaults)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tenants/t1/defaults", nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
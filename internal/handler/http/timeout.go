@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 默认的请求超时时间.
+const (
+	DefaultReadTimeout  = 15 * time.Second
+	DefaultWriteTimeout = 30 * time.Second
+	DefaultLongTimeout  = 10 * time.Minute
+)
+
+// TimeoutConfig 按接口类别设置的请求超时，0 使用默认值，负数表示不限制.
+// 超时后请求 ctx 被取消，存储和模型调用随之停止；流式接口（SSE、文件下载）不设超时.
+type TimeoutConfig struct {
+	// Read GET / HEAD 请求，默认 15s
+	Read time.Duration `mapstructure:"read"`
+	// Write 其他写请求，默认 30s
+	Write time.Duration `mapstructure:"write"`
+	// Long 导入、同步、导出等耗时操作（注册路由时声明），默认 10m
+	Long time.Duration `mapstructure:"long"`
+}
+
+// timeoutCategory 接口的超时类别.
+type timeoutCategory int
+
+const (
+	timeoutRead timeoutCategory = iota
+	timeoutWrite
+	timeoutLong
+	timeoutNone
+)
+
+// errRequestTimeout 请求超过所属类别的超时时间，作为请求 ctx 的取消原因.
+var errRequestTimeout = errors.New("request timed out")
+
+// requestDeadlineKey gin.Context 中保存 *requestDeadline 的键.
+const requestDeadlineKey = "request_deadline"
+
+// requestDeadline 可在路由链中调整的请求截止时间，从请求开始计时.
+type requestDeadline struct {
+	cfg    TimeoutConfig
+	start  time.Time
+	cancel context.CancelCauseFunc
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+// set 按类别重设截止时间，<= 0 表示不限制.
+func (d *requestDeadline) set(category timeoutCategory) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.timeout = d.cfg.duration(category)
+	if d.timeout <= 0 {
+		return
+	}
+	d.timer = time.AfterFunc(time.Until(d.start.Add(d.timeout)), func() { d.cancel(errRequestTimeout) })
+}
+
+// stop 请求结束时停止计时.
+func (d *requestDeadline) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
+
+// current 返回当前生效的超时时间.
+func (d *requestDeadline) current() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.timeout
+}
+
+// duration 返回类别的超时时间，<= 0 表示不限制.
+func (cfg TimeoutConfig) duration(category timeoutCategory) time.Duration {
+	pick := func(d, def time.Duration) time.Duration {
+		if d == 0 {
+			return def
+		}
+		return d
+	}
+	switch category {
+	case timeoutRead:
+		return pick(cfg.Read, DefaultReadTimeout)
+	case timeoutWrite:
+		return pick(cfg.Write, DefaultWriteTimeout)
+	case timeoutLong:
+		return pick(cfg.Long, DefaultLongTimeout)
+	}
+	return 0
+}
+
+// requestTimeout 为请求 ctx 设置默认超时（GET / HEAD 为 Read，其他为 Write），超时且尚未写出响应时返回 504.
+// 导入、流式等接口在注册路由时用 routeTimeout 声明自己的类别.
+func requestTimeout(cfg TimeoutConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		category := timeoutWrite
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			category = timeoutRead
+		}
+
+		ctx, cancel := context.WithCancelCause(c.Request.Context())
+		defer cancel(nil)
+		deadline := &requestDeadline{cfg: cfg, start: time.Now(), cancel: cancel}
+		deadline.set(category)
+		defer deadline.stop()
+		c.Set(requestDeadlineKey, deadline)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(context.Cause(ctx), errRequestTimeout) && !c.Writer.Written() {
+			writeError(c, http.StatusGatewayTimeout, "request timed out after "+deadline.current().String())
+		}
+	}
+}
+
+// routeTimeout 将路由的超时改为指定类别，需注册在 requestTimeout 之后.
+func routeTimeout(category timeoutCategory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if v, ok := c.Get(requestDeadlineKey); ok {
+			v.(*requestDeadline).set(category)
+		}
+		c.Next()
+	}
+}
+
+// longRoute 同步执行导入、解析、同步或导出的耗时接口.
+var longRoute = routeTimeout(timeoutLong)
+
+// streamRoute 流式返回的接口，不设超时，由客户端断开连接结束.
+var streamRoute = routeTimeout(timeoutNone)
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// slowHandler 等待 wait 或请求 ctx 结束，ctx 被取消时不写响应.
+func slowHandler(wait time.Duration, cancelled chan<- error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		select {
+		case <-time.After(wait):
+			cancelled <- nil
+			c.String(http.StatusOK, "done")
+		case <-c.Request.Context().Done():
+			cancelled <- context.Cause(c.Request.Context())
+		}
+	}
+}
+
+func newTimeoutEngine(cfg TimeoutConfig, register func(r *gin.Engine, cancelled chan<- error)) (*gin.Engine, chan error) {
+	r := gin.New()
+	r.Use(requestTimeout(cfg))
+	cancelled := make(chan error, 1)
+	register(r, cancelled)
+	return r, cancelled
+}
+
+func TestRequestTimeoutCancelsSlowRead(t *testing.T) {
+	r, cancelled := newTimeoutEngine(TimeoutConfig{Read: 20 * time.Millisecond}, func(r *gin.Engine, cancelled chan<- error) {
+		r.GET("/slow", slowHandler(time.Second, cancelled))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if err := <-cancelled; !errors.Is(err, errRequestTimeout) {
+		t.Fatalf("handler ctx cause = %v, want errRequestTimeout", err)
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", w.Code)
+	}
+}
+
+func TestRouteTimeoutOverridesCategory(t *testing.T) {
+	cfg := TimeoutConfig{Read: 20 * time.Millisecond, Long: time.Second}
+	r, cancelled := newTimeoutEngine(cfg, func(r *gin.Engine, cancelled chan<- error) {
+		r.GET("/export", longRoute, slowHandler(100*time.Millisecond, cancelled))
+		r.GET("/download", streamRoute, slowHandler(100*time.Millisecond, cancelled))
+	})
+
+	for _, path := range []string{"/export", "/download"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if err := <-cancelled; err != nil {
+			t.Errorf("%s: handler ctx cancelled: %v", path, err)
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", path, w.Code)
+		}
+	}
+}
+
+func TestRouteTimeoutLongStillExpires(t *testing.T) {
+	cfg := TimeoutConfig{Read: time.Second, Long: 20 * time.Millisecond}
+	r, cancelled := newTimeoutEngine(cfg, func(r *gin.Engine, cancelled chan<- error) {
+		r.POST("/import", longRoute, slowHandler(time.Second, cancelled))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/import", nil))
+	if err := <-cancelled; !errors.Is(err, errRequestTimeout) {
+		t.Fatalf("handler ctx cause = %v, want errRequestTimeout", err)
+	}
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", w.Code)
+	}
+}
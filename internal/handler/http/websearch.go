@@ -13,7 +13,7 @@ import (
 func (h *Handler) GetWebSearchConfig(c *gin.Context) {
 	config, err := h.biz.WebSearch().GetConfig(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, config)
@@ -31,7 +31,7 @@ type UpdateWebSearchConfigRequest struct {
 func (h *Handler) UpdateWebSearchConfig(c *gin.Context) {
 	var req UpdateWebSearchConfigRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -42,7 +42,7 @@ func (h *Handler) UpdateWebSearchConfig(c *gin.Context) {
 		Blacklist:  req.Blacklist,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -69,6 +69,17 @@ type EvaluationTask struct {
 	// 配置
 	AgentID         string `json:"agent_id" gorm:"not null;index;size:36"`
 	KnowledgeBaseID string `json:"knowledge_base_id,omitempty" gorm:"size:36"`
+	Deterministic   bool   `json:"deterministic" gorm:"default:false"` // 强制 temperature=0，忽略 Agent 的模型温度
+	// Metrics 计算的指标（recall/precision/mrr/f1/bleu/rouge1/rouge2/rougel），未计算的指标汇总值为空
+	Metrics []string `json:"metrics" gorm:"type:jsonb;serializer:json"`
+	// BLEUMaxN BLEU 的最大 n-gram 阶数，0 表示 4
+	BLEUMaxN int `json:"bleu_max_n,omitempty" gorm:"column:bleu_max_n;default:0"`
+	// BLEUSmoothing BLEU 的平滑方法（none/epsilon/add_one），为空表示 epsilon
+	BLEUSmoothing string `json:"bleu_smoothing,omitempty" gorm:"column:bleu_smoothing;size:20"`
+	// Concurrency 同时评估的条目数，0 表示默认值 4
+	Concurrency int `json:"concurrency,omitempty" gorm:"default:0"`
+	// RateLimit 每秒开始评估的条目数上限，0 表示不限制
+	RateLimit float64 `json:"rate_limit,omitempty" gorm:"default:0"`
 
 	// Coze Loop 关联
 	CozeLoopExperimentID *int64 `json:"coze_loop_experiment_id,omitempty"`
@@ -86,6 +97,12 @@ type EvaluationTask struct {
 	AvgPrecision *float64 `json:"avg_precision"`
 	AvgMRR       *float64 `json:"avg_mrr"`
 	AvgBLEU      *float64 `json:"avg_bleu"`
+	AvgF1        *float64 `json:"avg_f1"`
+	AvgROUGE1    *float64 `json:"avg_rouge1" gorm:"column:avg_rouge1"`
+	AvgROUGE2    *float64 `json:"avg_rouge2" gorm:"column:avg_rouge2"`
+	AvgROUGEL    *float64 `json:"avg_rougel" gorm:"column:avg_rougel"`
+	// AvgScores 按指标名称汇总的平均分，包含自定义指标
+	AvgScores map[string]float64 `json:"avg_scores,omitempty" gorm:"type:jsonb;serializer:json"`
 
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
@@ -132,6 +149,7 @@ type EvaluationMetrics struct {
 	Recall    float64 `json:"recall" gorm:"metric_recall"`
 	Precision float64 `json:"precision" gorm:"metric_precision"`
 	MRR       float64 `json:"mrr" gorm:"metric_mrr"` // Mean Reciprocal Rank
+	F1        float64 `json:"f1" gorm:"metric_f1"`   // 精确率和召回率的调和平均
 
 	// 生成指标
 	BLEU  float64      `json:"bleu" gorm:"metric_bleu"`
@@ -140,6 +158,9 @@ type EvaluationMetrics struct {
 	// 质量指标
 	AnswerRelevance float64 `json:"answer_relevance" gorm:"metric_answer_relevance"`
 	ContextCoverage float64 `json:"context_coverage" gorm:"metric_context_coverage"`
+
+	// Scores 按指标名称记录的分数，包含内置指标和通过 metrics.Register 注册的自定义指标
+	Scores map[string]float64 `json:"scores,omitempty" gorm:"type:jsonb;serializer:json"`
 }
 
 // ROUGEMetrics ROUGE 指标.
@@ -166,4 +187,8 @@ const (
 	EvaluationStatusRunning   EvaluationStatus = "running"
 	EvaluationStatusCompleted EvaluationStatus = "completed"
 	EvaluationStatusFailed    EvaluationStatus = "failed"
+	// EvaluationStatusInterrupted 服务重启导致中断，可恢复.
+	EvaluationStatusInterrupted EvaluationStatus = "interrupted"
+	// EvaluationStatusCancelled 被用户取消，已完成条目的结果保留.
+	EvaluationStatusCancelled EvaluationStatus = "cancelled"
 )
@@ -13,18 +13,24 @@ const (
 
 // KnowledgeBase 知识库.
 type KnowledgeBase struct {
-	ID              string              `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
-	Name            string              `json:"name" gorm:"size:255;not null"`
-	Description     string              `json:"description,omitempty" gorm:"type:text"`
-	ChunkingConfig  JSONMap             `json:"chunking_config,omitempty" gorm:"type:jsonb"`
-	ParserConfig    JSONMap             `json:"parser_config,omitempty" gorm:"type:jsonb"`
-	IndexerType     string              `json:"indexer_type,omitempty" gorm:"size:50"`
-	IndexerConfig   JSONMap             `json:"indexer_config,omitempty" gorm:"type:jsonb"`
-	EmbeddingConfig JSONMap             `json:"embedding_config,omitempty" gorm:"type:jsonb"`
-	Status          KnowledgeBaseStatus `json:"status" gorm:"size:20;not null;default:active"`
-	Metadata        JSONMap             `json:"metadata,omitempty" gorm:"type:jsonb"`
-	CreatedAt       time.Time           `json:"created_at"`
-	UpdatedAt       time.Time           `json:"updated_at"`
+	ID              string  `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	Name            string  `json:"name" gorm:"size:255;not null"`
+	Description     string  `json:"description,omitempty" gorm:"type:text"`
+	TenantID        string  `json:"tenant_id,omitempty" gorm:"size:36;index"`
+	ChunkingConfig  JSONMap `json:"chunking_config,omitempty" gorm:"type:jsonb"`
+	ParserConfig    JSONMap `json:"parser_config,omitempty" gorm:"type:jsonb"`
+	IndexerType     string  `json:"indexer_type,omitempty" gorm:"size:50"`
+	IndexerConfig   JSONMap `json:"indexer_config,omitempty" gorm:"type:jsonb"`
+	EmbeddingConfig JSONMap `json:"embedding_config,omitempty" gorm:"type:jsonb"`
+	// DistanceFunction 向量检索的距离函数（cosine / l2 / ip），向量索引按此创建，检索默认使用
+	DistanceFunction string `json:"distance_function,omitempty" gorm:"size:10;not null;default:cosine"`
+	// TextSearchConfig 全文检索的文本检索配置（simple / zhparser / jieba 等），对应扩展未安装时退化为 simple
+	TextSearchConfig string              `json:"text_search_config,omitempty" gorm:"size:64;not null;default:simple"`
+	SyncConfig       JSONMap             `json:"sync_config,omitempty" gorm:"type:jsonb"` // 外部源同步配置（连接器类型、Webhook 密钥等）
+	Status           KnowledgeBaseStatus `json:"status" gorm:"size:20;not null;default:active"`
+	Metadata         JSONMap             `json:"metadata,omitempty" gorm:"type:jsonb"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
 
 	// 关联
 	Documents []KnowledgeDocument `json:"documents,omitempty" gorm:"foreignKey:KnowledgeBaseID"`
@@ -48,9 +54,11 @@ const (
 type DocumentParseStatus string
 
 const (
-	DocumentParseStatusPending DocumentParseStatus = "pending"
-	DocumentParseStatusParsed  DocumentParseStatus = "parsed"
-	DocumentParseStatusFailed  DocumentParseStatus = "failed"
+	DocumentParseStatusPending   DocumentParseStatus = "pending"
+	DocumentParseStatusParsing   DocumentParseStatus = "parsing"   // 解析和分块中
+	DocumentParseStatusEmbedding DocumentParseStatus = "embedding" // 生成向量中
+	DocumentParseStatusParsed    DocumentParseStatus = "parsed"
+	DocumentParseStatusFailed    DocumentParseStatus = "failed"
 )
 
 // KnowledgeDocument 知识文档.
@@ -62,6 +70,8 @@ type KnowledgeDocument struct {
 	SourceURI       string              `json:"source_uri,omitempty" gorm:"type:text"`
 	FileHash        string              `json:"file_hash,omitempty" gorm:"size:64;index"`
 	ContentText     string              `json:"content_text,omitempty" gorm:"type:text"`
+	Summary         string              `json:"summary,omitempty" gorm:"type:text"` // 缓存的整篇文档摘要
+	SummaryHash     string              `json:"-" gorm:"size:32"`                   // 生成摘要时分块内容的哈希，与当前内容不一致时摘要失效
 	Metadata        JSONMap             `json:"metadata,omitempty" gorm:"type:jsonb"`
 	ParseStatus     DocumentParseStatus `json:"parse_status" gorm:"size:20;not null;default:pending;index"`
 	ErrorMessage    string              `json:"error_message,omitempty" gorm:"type:text"`
@@ -125,6 +135,7 @@ func (Embedding) TableName() string {
 type KnowledgeTag struct {
 	ID              string    `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
 	KnowledgeBaseID string    `json:"knowledge_base_id" gorm:"type:uuid;not null;index"`
+	ParentID        *string   `json:"parent_id,omitempty" gorm:"type:uuid;index"` // 父标签 ID，为空表示顶层标签
 	Name            string    `json:"name" gorm:"size:100;not null"`
 	Color           string    `json:"color" gorm:"size:20"`
 	Description     string    `json:"description" gorm:"size:500"`
@@ -155,3 +166,47 @@ type ChunkTag struct {
 func (ChunkTag) TableName() string {
 	return "chunk_tags"
 }
+
+// ReindexStatus 重建任务状态.
+type ReindexStatus string
+
+const (
+	ReindexStatusRunning   ReindexStatus = "running"
+	ReindexStatusCompleted ReindexStatus = "completed"
+	ReindexStatusFailed    ReindexStatus = "failed"
+)
+
+// 重建阶段.
+const (
+	ReindexStageRefreshing = "refreshing"
+	ReindexStageEmbedding  = "embedding"
+	ReindexStageIndexing   = "indexing"
+	ReindexStageDone       = "done"
+)
+
+// ReindexJob 知识库重建任务，进度随执行写入数据库，服务重启后仍可查询.
+type ReindexJob struct {
+	ID              string `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:uuid;not null;index"`
+	ReEmbed         bool   `json:"re_embed"`
+	Refresh         bool   `json:"refresh,omitempty"`
+	// RebuildIndexes 是否重建全局的全文和向量索引（表级操作，影响所有知识库）
+	RebuildIndexes bool `json:"rebuild_indexes,omitempty"`
+	// DocumentIDs 只重新生成这些文档的向量（ReembedDocuments），为空表示整个知识库
+	DocumentIDs     []string      `json:"document_ids,omitempty" gorm:"type:jsonb;serializer:json"`
+	Status          ReindexStatus `json:"status" gorm:"size:20;not null;index"`
+	Stage           string        `json:"stage" gorm:"size:20"`
+	TotalChunks     int64         `json:"total_chunks"`
+	ProcessedChunks int64         `json:"processed_chunks"`
+	// Refresh 的文档统计：内容变化而重新处理、未变化（或没有可读取的来源）而跳过、读取或处理失败的文档数
+	ReprocessedDocuments int64      `json:"reprocessed_documents,omitempty"`
+	SkippedDocuments     int64      `json:"skipped_documents,omitempty"`
+	FailedDocuments      int64      `json:"failed_documents,omitempty"`
+	Error                string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt            time.Time  `json:"started_at"`
+	FinishedAt           *time.Time `json:"finished_at,omitempty"`
+}
+
+func (ReindexJob) TableName() string {
+	return "knowledge_reindex_jobs"
+}
@@ -8,8 +8,10 @@ type TransportType string
 
 const (
 	TransportTypeStdio     TransportType = "stdio"
-	TransportTypeSSE       TransportType = "sse"
+	TransportTypeSSE       TransportType = "sse" // 旧版 HTTP+SSE 传输
 	TransportTypeWebSocket TransportType = "websocket"
+	// TransportTypeStreamableHTTP Streamable HTTP 传输（MCP 2025-03-26）
+	TransportTypeStreamableHTTP TransportType = "streamable_http"
 )
 
 // MCPServer MCP 服务器配置.
@@ -25,6 +27,7 @@ type MCPServer struct {
 	ServerURL      string        `json:"server_url,omitempty" gorm:"size:500"`
 	CustomHeaders  JSONMap       `json:"custom_headers,omitempty" gorm:"type:jsonb"`
 	TimeoutSeconds int           `json:"timeout_seconds" gorm:"default:30"`
+	MaxRetries     int           `json:"max_retries" gorm:"default:0"` // 工具调用超时或连接失败时的重试次数，0 不重试
 	IsEnabled      bool          `json:"is_enabled" gorm:"default:true;index"`
 	CreatedAt      time.Time     `json:"created_at"`
 	UpdatedAt      time.Time     `json:"updated_at"`
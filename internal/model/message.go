@@ -33,6 +33,7 @@ type Message struct {
 	// 响应元信息
 	FinishReason string  `json:"finish_reason,omitempty" gorm:"size:50"`
 	TokenUsage   JSONMap `json:"token_usage,omitempty" gorm:"type:json"`
+	Cost         float64 `json:"cost,omitempty" gorm:"default:0"` // 本次回答的模型调用费用
 
 	// 扩展字段
 	Extra           JSONMap `json:"extra,omitempty" gorm:"type:json"`
@@ -0,0 +1,21 @@
+// Package model 定义数据模型.
+package model
+
+import "time"
+
+// ModerationRecord 内容审核记录.
+type ModerationRecord struct {
+	ID         string    `json:"id" gorm:"primaryKey;size:36"`
+	Target     string    `json:"target" gorm:"size:20;not null;index"` // document, answer
+	TargetID   string    `json:"target_id" gorm:"size:36;index"`       // 文档 ID 或会话 ID
+	Provider   string    `json:"provider" gorm:"size:50"`
+	Flagged    bool      `json:"flagged" gorm:"index"`
+	Action     string    `json:"action" gorm:"size:20"` // block, flag, redact
+	Categories JSONSlice `json:"categories,omitempty" gorm:"type:jsonb"`
+	Error      string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (ModerationRecord) TableName() string {
+	return "moderation_records"
+}
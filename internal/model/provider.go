@@ -27,16 +27,46 @@ type Provider struct {
 	APIKey        string        `json:"-" gorm:"size:500"` // 不序列化到 JSON
 	APISecret     string        `json:"-" gorm:"size:500"` // 不序列化到 JSON
 	DefaultModel  string        `json:"default_model" gorm:"size:200"`
-	Config        JSONMap       `json:"config" gorm:"type:json"`
-	IsEnabled     bool          `json:"is_enabled" gorm:"default:true;index"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	// Capabilities 能力列表（chat/embedding/rerank），为空时按 ModelCategory 判断
+	Capabilities JSONSlice `json:"capabilities,omitempty" gorm:"type:json"`
+	// SupportedModels 支持的模型列表，为空表示不限制
+	SupportedModels JSONSlice `json:"supported_models,omitempty" gorm:"type:json"`
+	Config          JSONMap   `json:"config" gorm:"type:json"`
+	IsEnabled       bool      `json:"is_enabled" gorm:"default:true;index"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 func (Provider) TableName() string {
 	return "providers"
 }
 
+// HasCapability 判断 Provider 是否具备指定能力.
+func (p *Provider) HasCapability(c ModelCategory) bool {
+	if len(p.Capabilities) == 0 {
+		return p.ModelCategory == c
+	}
+	for _, v := range p.Capabilities {
+		if ModelCategory(v) == c {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsModel 判断 Provider 是否支持指定模型（未配置支持列表时不限制）.
+func (p *Provider) SupportsModel(name string) bool {
+	if len(p.SupportedModels) == 0 {
+		return true
+	}
+	for _, m := range p.SupportedModels {
+		if m == name {
+			return true
+		}
+	}
+	return false
+}
+
 // JSONMap JSON Map 类型.
 type JSONMap map[string]interface{}
 
@@ -19,6 +19,7 @@ type Session struct {
 	UserID    string        `json:"user_id" gorm:"size:100;index"`
 	Title     string        `json:"title" gorm:"size:500"`
 	Status    SessionStatus `json:"status" gorm:"size:20;not null;default:active;index"`
+	Pinned    bool          `json:"pinned" gorm:"not null;default:false"` // 置顶会话不受保留策略清理
 	Metadata  JSONMap       `json:"metadata" gorm:"type:json"`
 	Context   JSONMap       `json:"context" gorm:"type:json"` // SessionValues
 	CreatedAt time.Time     `json:"created_at" gorm:"index"`
@@ -32,3 +33,11 @@ type Session struct {
 func (Session) TableName() string {
 	return "sessions"
 }
+
+// RetentionPolicy 会话历史保留策略，字段为 0 表示不限制.
+type RetentionPolicy struct {
+	// MaxMessagesPerSession 每个会话保留的最新消息数（不含工具结果消息）
+	MaxMessagesPerSession int `json:"max_messages_per_session"`
+	// MaxSessionAgeDays 会话最后更新后保留的天数，超过后删除会话及其消息
+	MaxSessionAgeDays int `json:"max_session_age_days"`
+}
@@ -37,6 +37,7 @@ const (
 	SettingKeyKnowledgeEnabled  = "feature.knowledge_enabled"
 	SettingKeyMultiAgentEnabled = "feature.multi_agent_enabled"
 	SettingKeyStreamingEnabled  = "feature.streaming_enabled"
+	SettingKeyWebSearchConfig   = "web-search.config"
 
 	// Ollama 配置
 	SettingKeyOllamaBaseURL = "ollama.base_url"
@@ -4,6 +4,8 @@ package model
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -18,22 +20,98 @@ const (
 
 // Tenant 租户.
 type Tenant struct {
-	ID          string       `json:"id" gorm:"primaryKey;size:36"`
-	Name        string       `json:"name" gorm:"size:100;not null;uniqueIndex"`
-	DisplayName string       `json:"display_name" gorm:"size:200"`
-	Description string       `json:"description" gorm:"size:500"`
-	Status      TenantStatus `json:"status" gorm:"size:20;default:active;index"`
-	Config      JSONMap      `json:"config" gorm:"type:jsonb"`
-	Quota       JSONMap      `json:"quota" gorm:"type:jsonb"`       // 配额限制
-	UsageStats  JSONMap      `json:"usage_stats" gorm:"type:jsonb"` // 使用统计
-	CreatedAt   time.Time    `json:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at"`
+	ID          string           `json:"id" gorm:"primaryKey;size:36"`
+	Name        string           `json:"name" gorm:"size:100;not null;uniqueIndex"`
+	DisplayName string           `json:"display_name" gorm:"size:200"`
+	Description string           `json:"description" gorm:"size:500"`
+	Status      TenantStatus     `json:"status" gorm:"size:20;default:active;index"`
+	Config      JSONMap          `json:"config" gorm:"type:jsonb"`
+	Quota       JSONMap          `json:"quota" gorm:"type:jsonb"`                                 // 配额限制
+	UsageStats  JSONMap          `json:"usage_stats" gorm:"type:jsonb"`                           // 使用统计
+	Defaults    *TenantDefaults  `json:"defaults,omitempty" gorm:"type:jsonb;serializer:json"`    // 默认 Provider/模型/Agent
+	Retention   *RetentionPolicy `json:"retention,omitempty" gorm:"type:jsonb;serializer:json"`   // 会话保留策略，覆盖全局配置
+	ToolPolicy  *ToolPolicy      `json:"tool_policy,omitempty" gorm:"type:jsonb;serializer:json"` // 工具允许/禁止策略，运行时过滤 Agent 配置的工具
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
 }
 
 func (Tenant) TableName() string {
 	return "tenants"
 }
 
+// TenantDefaults 租户默认配置.
+//
+// Agent、知识库、会话未指定对应字段时使用，租户未配置的字段再回退到系统设置.
+type TenantDefaults struct {
+	ChatProviderID      string `json:"chat_provider_id,omitempty"`
+	ChatModel           string `json:"chat_model,omitempty"`
+	EmbeddingProviderID string `json:"embedding_provider_id,omitempty"`
+	EmbeddingModel      string `json:"embedding_model,omitempty"`
+	RerankProviderID    string `json:"rerank_provider_id,omitempty"`
+	AgentID             string `json:"agent_id,omitempty"`
+}
+
+// Merge 用 fallback 填充未配置的字段，返回新的副本.
+// 模型名随 Provider 一起回退，避免与另一个 Provider 的模型组合.
+func (d *TenantDefaults) Merge(fallback *TenantDefaults) *TenantDefaults {
+	merged := &TenantDefaults{}
+	if d != nil {
+		*merged = *d
+	}
+	if fallback == nil {
+		return merged
+	}
+	if merged.ChatProviderID == "" {
+		merged.ChatProviderID = fallback.ChatProviderID
+		merged.ChatModel = fallback.ChatModel
+	}
+	if merged.EmbeddingProviderID == "" {
+		merged.EmbeddingProviderID = fallback.EmbeddingProviderID
+		merged.EmbeddingModel = fallback.EmbeddingModel
+	}
+	if merged.RerankProviderID == "" {
+		merged.RerankProviderID = fallback.RerankProviderID
+	}
+	if merged.AgentID == "" {
+		merged.AgentID = fallback.AgentID
+	}
+	return merged
+}
+
+// ToolPolicy 租户的工具允许/禁止策略，构建 Agent 工具时生效.
+//
+// Deny 优先于 Allow；Allow 为空表示除 Deny 外的工具都允许.
+type ToolPolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// IsEmpty 策略未限制任何工具时返回 true.
+func (p *ToolPolicy) IsEmpty() bool {
+	return p == nil || (len(p.Allow) == 0 && len(p.Deny) == 0)
+}
+
+// Permits 判断策略是否允许使用指定工具，nil 策略允许所有工具.
+func (p *ToolPolicy) Permits(name string) bool {
+	if p == nil {
+		return true
+	}
+	if slices.Contains(p.Deny, name) {
+		return false
+	}
+	return len(p.Allow) == 0 || slices.Contains(p.Allow, name)
+}
+
+// Key 返回策略的规范化表示，用于区分按策略缓存的运行实例.
+func (p *ToolPolicy) Key() string {
+	if p.IsEmpty() {
+		return ""
+	}
+	allow := slices.Sorted(slices.Values(p.Allow))
+	deny := slices.Sorted(slices.Values(p.Deny))
+	return "allow=" + strings.Join(allow, ",") + ";deny=" + strings.Join(deny, ",")
+}
+
 // APIKeyStatus API Key 状态.
 type APIKeyStatus string
 
@@ -4,6 +4,7 @@ package agentic
 import (
 	"context"
 	"io"
+	"strings"
 
 	"github.com/cloudwego/eino/components/model"
 	"github.com/cloudwego/eino/compose"
@@ -12,9 +13,10 @@ import (
 
 // AgentConfig ReAct Agent 配置。
 type AgentConfig struct {
-	Model            model.AgenticModel
-	ToolsConfig      compose.ToolsNodeConfig
-	MaxStep          int
+	Model       model.AgenticModel
+	ToolsConfig compose.ToolsNodeConfig
+	MaxStep     int
+	// ToolReturnDirect 结果直接作为最终回答返回的工具名，命中后不再调用模型
 	ToolReturnDirect map[string]struct{}
 }
 
@@ -95,8 +97,51 @@ func NewAgent(ctx context.Context, config *AgentConfig) (_ *Agent, err error) {
 		},
 	))
 
-	// 工具 -> 模型
-	_ = graph.AddEdge(toolsNodeKey, modelNode)
+	if len(config.ToolReturnDirect) > 0 {
+		// 工具 -> 模型 或 直接返回
+		directReturnNode := "direct_return"
+		_ = graph.AddLambdaNode(directReturnNode, compose.TransformableLambda(
+			func(ctx context.Context, sr *schema.StreamReader[[]*schema.AgenticMessage]) (*schema.StreamReader[*schema.AgenticMessage], error) {
+				msg, err := directReturnMessage(sr, config.ToolReturnDirect)
+				if err != nil {
+					return nil, err
+				}
+				return schema.StreamReaderFromArray([]*schema.AgenticMessage{msg}), nil
+			}), compose.WithNodeName("DirectReturn"))
+
+		toolsPostBranch := func(ctx context.Context, sr *schema.StreamReader[[]*schema.AgenticMessage]) (endNode string, err error) {
+			defer sr.Close()
+
+			for {
+				msgs, err := sr.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return "", err
+				}
+				for _, msg := range msgs {
+					for _, block := range msg.ContentBlocks {
+						if isDirectReturnResult(block, config.ToolReturnDirect) {
+							return directReturnNode, nil
+						}
+					}
+				}
+			}
+			return modelNode, nil
+		}
+
+		_ = graph.AddBranch(toolsNodeKey, compose.NewStreamGraphBranch(toolsPostBranch,
+			map[string]bool{
+				modelNode:        true,
+				directReturnNode: true,
+			},
+		))
+		_ = graph.AddEdge(directReturnNode, compose.END)
+	} else {
+		// 工具 -> 模型
+		_ = graph.AddEdge(toolsNodeKey, modelNode)
+	}
 
 	// 编译
 	compileOpts := []compose.GraphCompileOption{
@@ -146,3 +191,56 @@ func genToolInfos(ctx context.Context, config compose.ToolsNodeConfig) ([]*schem
 	}
 	return toolInfos, nil
 }
+
+// isDirectReturnResult 判断内容块是否为直接返回工具的结果.
+func isDirectReturnResult(block *schema.ContentBlock, returnDirect map[string]struct{}) bool {
+	if block == nil || block.Type != schema.ContentBlockTypeFunctionToolResult || block.FunctionToolResult == nil {
+		return false
+	}
+	_, ok := returnDirect[block.FunctionToolResult.Name]
+	return ok
+}
+
+// directReturnMessage 将直接返回工具的结果转换为最终回答.
+// 流式工具的结果按 CallID 拼接，多个直接返回工具时按调用顺序以空行分隔.
+func directReturnMessage(sr *schema.StreamReader[[]*schema.AgenticMessage], returnDirect map[string]struct{}) (*schema.AgenticMessage, error) {
+	defer sr.Close()
+
+	var order []string
+	results := make(map[string]*strings.Builder)
+	for {
+		msgs, err := sr.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			for _, block := range msg.ContentBlocks {
+				if !isDirectReturnResult(block, returnDirect) {
+					continue
+				}
+				callID := block.FunctionToolResult.CallID
+				b, ok := results[callID]
+				if !ok {
+					b = &strings.Builder{}
+					results[callID] = b
+					order = append(order, callID)
+				}
+				b.WriteString(block.FunctionToolResult.Result)
+			}
+		}
+	}
+
+	texts := make([]string, 0, len(order))
+	for _, callID := range order {
+		texts = append(texts, results[callID].String())
+	}
+	return &schema.AgenticMessage{
+		Role: schema.AgenticRoleTypeAssistant,
+		ContentBlocks: []*schema.ContentBlock{
+			schema.NewContentBlock(&schema.AssistantGenText{Text: strings.Join(texts, "\n\n")}),
+		},
+	}, nil
+}
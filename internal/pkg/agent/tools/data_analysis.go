@@ -5,101 +5,292 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
 	_ "github.com/marcboeker/go-duckdb"
+
+	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/pkg/blob"
+	"github.com/ashwinyue/next-show/internal/store"
 )
 
+// DataAnalysisConfig 数据分析配置.
+type DataAnalysisConfig struct {
+	// MaxTables 所有会话合计最多加载的表数，超过时删除最久未使用的表（再次使用时重新加载），<=0 表示不限制
+	MaxTables int `mapstructure:"max_tables"`
+	// MemoryLimit 每个会话数据库的内存上限（DuckDB memory_limit，如 "512MB"），为空时使用 DuckDB 默认值
+	MemoryLimit string `mapstructure:"memory_limit"`
+}
+
 // DataAnalysisManager 管理 DuckDB 数据分析会话.
+// 每个会话使用独立的内存数据库，会话之间的表互不可见；清理会话时关闭整个数据库，不会残留部分表.
 type DataAnalysisManager struct {
-	db            *sql.DB
-	mu            sync.Mutex
-	loadedTables  map[string]string   // documentID -> tableName
-	sessionTables map[string][]string // sessionID -> []tableName (用于清理)
+	cfg      DataAnalysisConfig
+	mu       sync.Mutex // 保护 sessions 以及各会话表的加载状态
+	sessions map[string]*dataSession
+	closed   bool
 }
 
-// NewDataAnalysisManager 创建数据分析管理器.
-func NewDataAnalysisManager() (*DataAnalysisManager, error) {
-	db, err := sql.Open("duckdb", "")
-	if err != nil {
-		return nil, fmt.Errorf("open duckdb: %w", err)
-	}
+// dataSession 单个会话的 DuckDB 内存数据库.
+type dataSession struct {
+	db *sql.DB
+	// mu 串行化同一会话的建表和删表
+	mu     sync.Mutex
+	tables map[string]*dataTable // documentID -> 表
+	closed bool
+	// cursor 最近一次查询的分页游标，由 DataAnalysisManager.mu 保护
+	cursor *queryCursor
+}
 
-	return &DataAnalysisManager{
-		db:            db,
-		loadedTables:  make(map[string]string),
-		sessionTables: make(map[string][]string),
-	}, nil
+// queryCursor 会话中最近一次查询的分页位置.
+type queryCursor struct {
+	sql      string
+	offset   int // 下一页的起始行
+	pageSize int
+}
+
+// dataTable 会话中为文档创建的表，被淘汰后保留记录以便按需重新加载.
+type dataTable struct {
+	name     string
+	create   func(ctx context.Context, db *sql.DB, name string) error
+	loaded   bool
+	lastUsed time.Time
 }
 
-// Close 关闭数据库连接.
+// NewDataAnalysisManager 创建数据分析管理器，会话数据库在首次加载文件时创建，cfg 为 nil 时不限制表数.
+func NewDataAnalysisManager(cfg *DataAnalysisConfig) (*DataAnalysisManager, error) {
+	m := &DataAnalysisManager{
+		sessions: make(map[string]*dataSession),
+	}
+	if cfg != nil {
+		m.cfg = *cfg
+	}
+	return m, nil
+}
+
+// Close 关闭所有会话的数据库.
 func (m *DataAnalysisManager) Close() error {
-	return m.db.Close()
+	m.mu.Lock()
+	sessions := m.sessions
+	m.sessions = make(map[string]*dataSession)
+	m.closed = true
+	m.mu.Unlock()
+
+	var errs []error
+	for _, sess := range sessions {
+		if err := sess.db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// LoadCSVFile 加载 CSV 文件到 DuckDB.
-func (m *DataAnalysisManager) LoadCSVFile(ctx context.Context, sessionID, documentID, filePath string) (string, error) {
+// session 返回会话的数据库，create 为 true 时不存在则创建.
+func (m *DataAnalysisManager) session(ctx context.Context, sessionID string, create bool) (*dataSession, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// 检查是否已加载
-	if tableName, ok := m.loadedTables[documentID]; ok {
-		return tableName, nil
+	if m.closed {
+		return nil, fmt.Errorf("data analysis manager is closed")
 	}
-
-	// 生成表名（使用 document ID 的前 8 位）
-	tableName := fmt.Sprintf("doc_%s", strings.ReplaceAll(documentID, "-", "")[:8])
-
-	// 创建表
-	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s" AS SELECT * FROM read_csv_auto('%s', header=true)`, tableName, filePath)
-	if _, err := m.db.ExecContext(ctx, query); err != nil {
-		return "", fmt.Errorf("load csv: %w", err)
+	if sess, ok := m.sessions[sessionID]; ok {
+		return sess, nil
+	}
+	if !create {
+		return nil, fmt.Errorf("no data loaded in this session, call data_schema first")
 	}
 
-	m.loadedTables[documentID] = tableName
-	m.sessionTables[sessionID] = append(m.sessionTables[sessionID], tableName)
+	// 空 DSN 打开独立的内存数据库
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("open duckdb: %w", err)
+	}
+	if m.cfg.MemoryLimit != "" {
+		limit := strings.ReplaceAll(m.cfg.MemoryLimit, "'", "''")
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("SET memory_limit = '%s'", limit)); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("set duckdb memory limit: %w", err)
+		}
+	}
+	sess := &dataSession{db: db, tables: make(map[string]*dataTable)}
+	m.sessions[sessionID] = sess
+	return sess, nil
+}
 
-	return tableName, nil
+// LoadCSVFile 加载 CSV 文件到会话的 DuckDB 数据库.
+func (m *DataAnalysisManager) LoadCSVFile(ctx context.Context, sessionID, documentID, filePath string) (string, error) {
+	return m.loadFile(ctx, sessionID, documentID, func(ctx context.Context, db *sql.DB, tableName string) error {
+		query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s" AS SELECT * FROM read_csv_auto('%s', header=true)`, tableName, filePath)
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("load csv: %w", err)
+		}
+		return nil
+	})
 }
 
-// LoadXLSXFile 加载 XLSX 文件到 DuckDB.
+// LoadXLSXFile 加载 XLSX 文件到会话的 DuckDB 数据库.
 func (m *DataAnalysisManager) LoadXLSXFile(ctx context.Context, sessionID, documentID, filePath string) (string, error) {
+	return m.loadFile(ctx, sessionID, documentID, func(ctx context.Context, db *sql.DB, tableName string) error {
+		// DuckDB 需要安装 spatial 扩展来读取 xlsx
+		// 先尝试安装扩展
+		db.ExecContext(ctx, "INSTALL spatial; LOAD spatial;")
+
+		query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s" AS SELECT * FROM st_read('%s')`, tableName, filePath)
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("load xlsx: %w", err)
+		}
+		return nil
+	})
+}
+
+// loadFile 在会话数据库中为文档创建表，已加载时直接返回表名，已被淘汰时重新加载.
+func (m *DataAnalysisManager) loadFile(ctx context.Context, sessionID, documentID string, create func(ctx context.Context, db *sql.DB, tableName string) error) (string, error) {
+	sess, err := m.session(ctx, sessionID, true)
+	if err != nil {
+		return "", err
+	}
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	t, ok := sess.tables[documentID]
+	if !ok {
+		// 生成表名（使用 document ID 的前 8 位），表只在本会话的数据库中可见
+		t = &dataTable{
+			name:   fmt.Sprintf("doc_%s", strings.ReplaceAll(documentID, "-", "")[:8]),
+			create: create,
+		}
+		sess.tables[documentID] = t
+	}
+	m.mu.Unlock()
 
-	// 检查是否已加载
-	if tableName, ok := m.loadedTables[documentID]; ok {
-		return tableName, nil
+	if err := m.useTables(ctx, sess, []*dataTable{t}); err != nil {
+		m.mu.Lock()
+		if !t.loaded {
+			delete(sess.tables, documentID)
+		}
+		m.mu.Unlock()
+		return "", err
 	}
+	return t.name, nil
+}
 
-	// 生成表名
-	tableName := fmt.Sprintf("doc_%s", strings.ReplaceAll(documentID, "-", "")[:8])
+// useTables 记录表的使用时间，加载其中未加载（或已被淘汰）的表，然后淘汰超出上限的表.
+func (m *DataAnalysisManager) useTables(ctx context.Context, sess *dataSession, tables []*dataTable) error {
+	if len(tables) == 0 {
+		return nil
+	}
 
-	// DuckDB 需要安装 spatial 扩展来读取 xlsx
-	// 先尝试安装扩展
-	m.db.ExecContext(ctx, "INSTALL spatial; LOAD spatial;")
+	sess.mu.Lock()
+	var loadErr error
+	reloaded := false
+	for _, t := range tables {
+		m.mu.Lock()
+		t.lastUsed = time.Now()
+		loaded := t.loaded
+		m.mu.Unlock()
+		if loaded {
+			continue
+		}
+		if loadErr = t.create(ctx, sess.db, t.name); loadErr != nil {
+			break
+		}
+		m.mu.Lock()
+		t.loaded = true
+		t.lastUsed = time.Now()
+		m.mu.Unlock()
+		reloaded = true
+	}
+	sess.mu.Unlock()
 
-	// 创建表
-	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s" AS SELECT * FROM st_read('%s')`, tableName, filePath)
-	if _, err := m.db.ExecContext(ctx, query); err != nil {
-		return "", fmt.Errorf("load xlsx: %w", err)
+	if reloaded {
+		m.evict()
 	}
+	return loadErr
+}
 
-	m.loadedTables[documentID] = tableName
-	m.sessionTables[sessionID] = append(m.sessionTables[sessionID], tableName)
+// referencedTables 返回会话中名称出现在 sql 中的表.
+func (m *DataAnalysisManager) referencedTables(sess *dataSession, sql string) []*dataTable {
+	sql = strings.ToLower(sql)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var tables []*dataTable
+	for _, t := range sess.tables {
+		if strings.Contains(sql, t.name) {
+			tables = append(tables, t)
+		}
+	}
+	return tables
+}
 
-	return tableName, nil
+// evict 已加载的表超过 MaxTables 时，按最近使用时间删除最久未使用的表.
+// 删除时只持有被淘汰表所在会话的锁，避免与其他会话的加载互相等待.
+func (m *DataAnalysisManager) evict() {
+	if m.cfg.MaxTables <= 0 {
+		return
+	}
+	for {
+		m.mu.Lock()
+		var (
+			victim     *dataTable
+			victimSess *dataSession
+			count      int
+		)
+		for _, sess := range m.sessions {
+			for _, t := range sess.tables {
+				if !t.loaded {
+					continue
+				}
+				count++
+				if victim == nil || t.lastUsed.Before(victim.lastUsed) {
+					victim, victimSess = t, sess
+				}
+			}
+		}
+		if count <= m.cfg.MaxTables {
+			m.mu.Unlock()
+			return
+		}
+		lastUsed := victim.lastUsed
+		m.mu.Unlock()
+
+		victimSess.mu.Lock()
+		m.mu.Lock()
+		// 选出后表可能刚被使用或会话已清理，重新选择
+		stale := !victim.loaded || !victim.lastUsed.Equal(lastUsed) || victimSess.closed
+		if !stale {
+			victim.loaded = false
+		}
+		m.mu.Unlock()
+		if !stale {
+			if _, err := victimSess.db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS "%s"`, victim.name)); err != nil {
+				log.Printf("data analysis: evict table %s: %v", victim.name, err)
+			}
+		}
+		victimSess.mu.Unlock()
+	}
 }
 
-// GetTableSchema 获取表结构信息.
-func (m *DataAnalysisManager) GetTableSchema(ctx context.Context, tableName string) (*TableSchema, error) {
+// GetTableSchema 获取会话中表的结构信息.
+func (m *DataAnalysisManager) GetTableSchema(ctx context.Context, sessionID, tableName string) (*TableSchema, error) {
+	sess, err := m.session(ctx, sessionID, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.useTables(ctx, sess, m.referencedTables(sess, tableName)); err != nil {
+		return nil, err
+	}
+
 	// 获取列信息
 	query := fmt.Sprintf(`DESCRIBE "%s"`, tableName)
-	rows, err := m.db.QueryContext(ctx, query)
+	rows, err := sess.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("describe table: %w", err)
 	}
@@ -120,7 +311,7 @@ func (m *DataAnalysisManager) GetTableSchema(ctx context.Context, tableName stri
 	// 获取行数
 	var rowCount int64
 	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, tableName)
-	if err := m.db.QueryRowContext(ctx, countQuery).Scan(&rowCount); err != nil {
+	if err := sess.db.QueryRowContext(ctx, countQuery).Scan(&rowCount); err != nil {
 		return nil, fmt.Errorf("count rows: %w", err)
 	}
 
@@ -131,83 +322,257 @@ func (m *DataAnalysisManager) GetTableSchema(ctx context.Context, tableName stri
 	}, nil
 }
 
-// ExecuteQuery 执行只读 SQL 查询.
-func (m *DataAnalysisManager) ExecuteQuery(ctx context.Context, sqlQuery string, maxRows int) (*QueryResult, error) {
-	// 安全检查：只允许 SELECT 查询
+// checkReadOnlyQuery 只允许 SELECT/SHOW/DESCRIBE/EXPLAIN 查询，返回小写形式的语句.
+func checkReadOnlyQuery(sqlQuery string) (string, error) {
 	normalized := strings.TrimSpace(strings.ToLower(sqlQuery))
 	if !strings.HasPrefix(normalized, "select") &&
 		!strings.HasPrefix(normalized, "show") &&
 		!strings.HasPrefix(normalized, "describe") &&
 		!strings.HasPrefix(normalized, "explain") {
-		return nil, fmt.Errorf("only SELECT queries are allowed")
+		return "", fmt.Errorf("only SELECT queries are allowed")
 	}
+	return normalized, nil
+}
 
-	// 添加 LIMIT 如果没有
-	if !strings.Contains(normalized, "limit") && maxRows > 0 {
-		sqlQuery = fmt.Sprintf("%s LIMIT %d", sqlQuery, maxRows)
+// ExecuteQuery 在会话的数据库中执行只读 SQL 查询，返回第一页（最多 pageSize 行）.
+// 查询和下一页的位置记录为会话的游标，之后可以通过 NextPage 继续读取.
+func (m *DataAnalysisManager) ExecuteQuery(ctx context.Context, sessionID, sqlQuery string, pageSize int) (*QueryResult, error) {
+	if _, err := checkReadOnlyQuery(sqlQuery); err != nil {
+		return nil, err
 	}
+	sess, err := m.session(ctx, sessionID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &queryCursor{sql: sqlQuery, pageSize: pageSize}
+	result, err := m.queryPage(ctx, sess, cursor)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	sess.cursor = cursor
+	m.mu.Unlock()
+	return result, nil
+}
+
+// NextPage 读取会话最近一次查询的下一页.
+func (m *DataAnalysisManager) NextPage(ctx context.Context, sessionID string) (*QueryResult, error) {
+	sess, err := m.session(ctx, sessionID, false)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	var cursor *queryCursor
+	if sess.cursor != nil {
+		c := *sess.cursor
+		cursor = &c
+	}
+	m.mu.Unlock()
+	if cursor == nil {
+		return nil, fmt.Errorf("no previous query in this session")
+	}
+
+	result, err := m.queryPage(ctx, sess, cursor)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	// 期间有新的查询时保留新查询的游标
+	if sess.cursor != nil && sess.cursor.sql == cursor.sql {
+		sess.cursor = cursor
+	}
+	m.mu.Unlock()
+	return result, nil
+}
+
+// queryPage 从 cursor.offset 开始读取一页，并将 cursor 移到下一页.
+// SELECT 查询包装为子查询由 DuckDB 分页，其他语句在结果中跳过前面的行.
+func (m *DataAnalysisManager) queryPage(ctx context.Context, sess *dataSession, cursor *queryCursor) (*QueryResult, error) {
+	normalized, err := checkReadOnlyQuery(cursor.sql)
+	if err != nil {
+		return nil, err
+	}
+
+	query, skip := cursor.sql, cursor.offset
+	if strings.HasPrefix(normalized, "select") {
+		// 多取一行判断是否还有下一页
+		query = fmt.Sprintf("SELECT * FROM (%s) AS paged LIMIT %d OFFSET %d",
+			strings.TrimRight(strings.TrimSpace(cursor.sql), ";"), cursor.pageSize+1, cursor.offset)
+		skip = 0
+	}
+
+	result := &QueryResult{Offset: cursor.offset}
+	err = m.streamQuery(ctx, sess, query, func(columns []string, values []interface{}) error {
+		result.Columns = columns
+		if skip > 0 {
+			skip--
+			return nil
+		}
+		if result.RowCount >= cursor.pageSize {
+			result.HasMore = true
+			return errStopRows
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, col := range columns {
+			row[col] = values[i]
+		}
+		result.Data = append(result.Data, row)
+		result.RowCount++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	cursor.offset += result.RowCount
+	return result, nil
+}
+
+// largeTableRows 无过滤条件扫描的表超过该行数时给出警告.
+const largeTableRows = 1000000
+
+// QueryPlan 查询计划.
+type QueryPlan struct {
+	Plan string `json:"plan"`
+	// Warnings 可能代价较高的操作，例如无过滤条件扫描大表
+	Warnings []string `json:"warnings,omitempty"`
+}
 
-	rows, err := m.db.QueryContext(ctx, sqlQuery)
+// ExplainQuery 返回查询在 DuckDB 中的执行计划（EXPLAIN），不执行查询.
+func (m *DataAnalysisManager) ExplainQuery(ctx context.Context, sessionID, sqlQuery string) (*QueryPlan, error) {
+	normalized, err := checkReadOnlyQuery(sqlQuery)
 	if err != nil {
-		return nil, fmt.Errorf("execute query: %w", err)
+		return nil, err
+	}
+	if strings.HasPrefix(normalized, "explain") {
+		return nil, fmt.Errorf("query is already an EXPLAIN statement")
+	}
+	sess, err := m.session(ctx, sessionID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// DuckDB 的 EXPLAIN 返回 (explain_key, explain_value)，计划文本在 explain_value 中
+	var plan strings.Builder
+	err = m.streamQuery(ctx, sess, "EXPLAIN "+sqlQuery, func(columns []string, values []interface{}) error {
+		if len(values) > 0 {
+			plan.WriteString(fmt.Sprintf("%v", values[len(values)-1]))
+			plan.WriteString("\n")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("explain query: %w", err)
+	}
+
+	warnings, err := m.scanWarnings(ctx, sess, sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	return &QueryPlan{Plan: plan.String(), Warnings: warnings}, nil
+}
+
+// ScanWarnings 检查查询是否会无过滤条件地扫描超过 largeTableRows 行的表.
+func (m *DataAnalysisManager) ScanWarnings(ctx context.Context, sessionID, sqlQuery string) ([]string, error) {
+	sess, err := m.session(ctx, sessionID, false)
+	if err != nil {
+		return nil, err
+	}
+	return m.scanWarnings(ctx, sess, sqlQuery)
+}
+
+// scanWarnings 查询没有 WHERE 和 LIMIT 时，对引用的大表给出警告.
+func (m *DataAnalysisManager) scanWarnings(ctx context.Context, sess *dataSession, sqlQuery string) ([]string, error) {
+	normalized := strings.ToLower(sqlQuery)
+	if strings.Contains(normalized, "where") || strings.Contains(normalized, "limit") {
+		return nil, nil
+	}
+	tables := m.referencedTables(sess, normalized)
+	if err := m.useTables(ctx, sess, tables); err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, t := range tables {
+		var rowCount int64
+		if err := sess.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, t.name)).Scan(&rowCount); err != nil {
+			return nil, fmt.Errorf("count rows: %w", err)
+		}
+		if rowCount > largeTableRows {
+			warnings = append(warnings, fmt.Sprintf("查询没有过滤条件，将全表扫描 %s（%d 行），建议添加 WHERE 或 LIMIT", t.name, rowCount))
+		}
+	}
+	return warnings, nil
+}
+
+// errStopRows 由 streamQuery 的回调返回，提前结束读取且不视为错误.
+var errStopRows = errors.New("stop reading rows")
+
+// StreamQuery 在会话的数据库中执行只读 SQL 查询，逐行回调而不缓存整个结果集.
+// fn 返回错误时停止读取并返回该错误.
+func (m *DataAnalysisManager) StreamQuery(ctx context.Context, sessionID, sqlQuery string, fn func(columns []string, values []interface{}) error) error {
+	if _, err := checkReadOnlyQuery(sqlQuery); err != nil {
+		return err
+	}
+	sess, err := m.session(ctx, sessionID, false)
+	if err != nil {
+		return err
+	}
+	return m.streamQuery(ctx, sess, sqlQuery, fn)
+}
+
+// streamQuery 执行查询并逐行回调，查询引用的表被淘汰时先重新加载.
+func (m *DataAnalysisManager) streamQuery(ctx context.Context, sess *dataSession, sqlQuery string, fn func(columns []string, values []interface{}) error) error {
+	if err := m.useTables(ctx, sess, m.referencedTables(sess, sqlQuery)); err != nil {
+		return err
+	}
+	rows, err := sess.db.QueryContext(ctx, sqlQuery)
+	if err != nil {
+		return fmt.Errorf("execute query: %w", err)
 	}
 	defer rows.Close()
 
 	// 获取列名
 	colNames, err := rows.Columns()
 	if err != nil {
-		return nil, fmt.Errorf("get columns: %w", err)
+		return fmt.Errorf("get columns: %w", err)
 	}
 
-	// 读取数据
-	var data []map[string]interface{}
 	for rows.Next() {
 		values := make([]interface{}, len(colNames))
 		valuePtrs := make([]interface{}, len(colNames))
 		for i := range values {
 			valuePtrs[i] = &values[i]
 		}
-
 		if err := rows.Scan(valuePtrs...); err != nil {
-			return nil, fmt.Errorf("scan row: %w", err)
+			return fmt.Errorf("scan row: %w", err)
 		}
-
-		row := make(map[string]interface{})
-		for i, col := range colNames {
-			row[col] = values[i]
+		if err := fn(colNames, values); err != nil {
+			if errors.Is(err, errStopRows) {
+				return nil
+			}
+			return err
 		}
-		data = append(data, row)
 	}
-
-	return &QueryResult{
-		Columns:  colNames,
-		Data:     data,
-		RowCount: len(data),
-	}, nil
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read rows: %w", err)
+	}
+	return nil
 }
 
-// CleanupSession 清理会话相关的表.
+// CleanupSession 关闭会话的数据库，会话加载的所有表随之释放.
 func (m *DataAnalysisManager) CleanupSession(ctx context.Context, sessionID string) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	tables, ok := m.sessionTables[sessionID]
-	if !ok {
-		return
+	sess, ok := m.sessions[sessionID]
+	if ok {
+		sess.closed = true
 	}
+	delete(m.sessions, sessionID)
+	m.mu.Unlock()
 
-	for _, tableName := range tables {
-		m.db.ExecContext(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS "%s"`, tableName))
-		// 从 loadedTables 中移除
-		for docID, tbl := range m.loadedTables {
-			if tbl == tableName {
-				delete(m.loadedTables, docID)
-				break
-			}
-		}
+	if ok {
+		sess.db.Close()
 	}
-
-	delete(m.sessionTables, sessionID)
 }
 
 // TableSchema 表结构信息.
@@ -223,11 +588,15 @@ type ColumnInfo struct {
 	Type string `json:"type"`
 }
 
-// QueryResult 查询结果.
+// QueryResult 查询结果（一页）.
 type QueryResult struct {
 	Columns  []string                 `json:"columns"`
 	Data     []map[string]interface{} `json:"data"`
 	RowCount int                      `json:"row_count"`
+	// Offset 本页第一行在整个结果中的位置
+	Offset int `json:"offset"`
+	// HasMore 是否还有下一页
+	HasMore bool `json:"has_more"`
 }
 
 // DataSchemaTool 数据表结构查询工具.
@@ -292,7 +661,7 @@ func (t *DataSchemaTool) InvokableRun(ctx context.Context, argumentsInJSON strin
 	}
 
 	// 获取表结构
-	schema, err := t.manager.GetTableSchema(ctx, tableName)
+	schema, err := t.manager.GetTableSchema(ctx, t.sessionID, tableName)
 	if err != nil {
 		return "", err
 	}
@@ -312,36 +681,89 @@ func (t *DataSchemaTool) InvokableRun(ctx context.Context, argumentsInJSON strin
 	return sb.String(), nil
 }
 
-// DataAnalysisTool 数据分析 SQL 查询工具.
+// NewDocumentFilePathFunc 创建 DataSchemaTool 使用的文件路径解析函数.
+// 文档原始文件从对象存储读取，非本地存储时下载到 cacheDir 供 DuckDB 加载.
+func NewDocumentFilePathFunc(s store.Store, files blob.Store, cacheDir string) func(ctx context.Context, documentID string) (string, string, error) {
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "next-show", "data-files")
+	}
+	return func(ctx context.Context, documentID string) (string, string, error) {
+		doc, err := s.Knowledge().GetDocument(ctx, documentID)
+		if err != nil {
+			return "", "", fmt.Errorf("get document: %w", err)
+		}
+		if doc.SourceType != model.DocumentSourceTypeFile || doc.SourceURI == "" {
+			return "", "", fmt.Errorf("document %s has no original file", documentID)
+		}
+
+		filePath, err := blob.LocalPath(ctx, files, doc.SourceURI, cacheDir)
+		if err != nil {
+			return "", "", err
+		}
+		fileType := strings.TrimPrefix(strings.ToLower(filepath.Ext(doc.SourceURI)), ".")
+		return filePath, fileType, nil
+	}
+}
+
+// dataAnalysisMaxStreamRows 流式输出查询结果时最多输出的行数.
+const dataAnalysisMaxStreamRows = 10000
+
+// dataAnalysisStreamBatch 流式输出时每个数据块包含的行数.
+const dataAnalysisStreamBatch = 50
+
+// DataAnalysisTool 数据分析 SQL 查询工具，只能查询所属会话加载的表.
+// 结果按 maxRows 分页，也可以流式输出较大的结果集.
 type DataAnalysisTool struct {
-	manager *DataAnalysisManager
-	maxRows int
+	manager   *DataAnalysisManager
+	sessionID string
+	maxRows   int
 }
 
 // DataAnalysisInput 数据分析查询输入.
 type DataAnalysisInput struct {
-	SQL string `json:"sql" jsonschema:"description=要执行的 SQL 查询语句（只支持 SELECT）"`
+	SQL string `json:"sql,omitempty" jsonschema:"description=要执行的 SQL 查询语句（只支持 SELECT）"`
+	// NextPage 为 true 时读取上一次查询的下一页，忽略 SQL
+	NextPage bool `json:"next_page,omitempty"`
+	// Stream 为 true 时流式输出全部结果（最多 dataAnalysisMaxStreamRows 行），仅在流式调用时生效
+	Stream bool `json:"stream,omitempty"`
+	// Explain 为 true 时只返回执行计划，不执行查询
+	Explain bool `json:"explain,omitempty"`
 }
 
-// NewDataAnalysisTool 创建数据分析工具.
-func NewDataAnalysisTool(manager *DataAnalysisManager, maxRows int) tool.InvokableTool {
+// NewDataAnalysisTool 创建数据分析工具，maxRows 为每页行数.
+func NewDataAnalysisTool(manager *DataAnalysisManager, sessionID string, maxRows int) tool.InvokableTool {
 	if maxRows <= 0 {
 		maxRows = 100
 	}
 	return &DataAnalysisTool{
-		manager: manager,
-		maxRows: maxRows,
+		manager:   manager,
+		sessionID: sessionID,
+		maxRows:   maxRows,
 	}
 }
 
 func (t *DataAnalysisTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 	return &schema.ToolInfo{
 		Name: "data_analysis",
-		Desc: "对已加载的数据表执行 SQL 查询。只支持 SELECT 查询，禁止 INSERT/UPDATE/DELETE/CREATE/DROP 等修改操作。使用前必须先调用 data_schema 获取表结构。",
+		Desc: fmt.Sprintf("对已加载的数据表执行 SQL 查询。只支持 SELECT 查询，禁止 INSERT/UPDATE/DELETE/CREATE/DROP 等修改操作。使用前必须先调用 data_schema 获取表结构。"+
+			"结果每页最多 %d 行，还有更多结果时设置 next_page=true 读取下一页；需要一次获取大量结果时设置 stream=true 流式输出。"+
+			"对大表执行复杂查询前，可以设置 explain=true 查看执行计划。", t.maxRows),
 		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
 			"sql": {
 				Type: schema.String,
-				Desc: "要执行的 SQL 查询语句",
+				Desc: "要执行的 SQL 查询语句，next_page 为 true 时可省略",
+			},
+			"next_page": {
+				Type: schema.Boolean,
+				Desc: "读取上一次查询结果的下一页",
+			},
+			"stream": {
+				Type: schema.Boolean,
+				Desc: fmt.Sprintf("流式输出全部结果（最多 %d 行），适合需要逐批处理的大结果集", dataAnalysisMaxStreamRows),
+			},
+			"explain": {
+				Type: schema.Boolean,
+				Desc: "只返回查询的执行计划，不执行查询",
 			},
 		}),
 	}, nil
@@ -352,39 +774,162 @@ func (t *DataAnalysisTool) InvokableRun(ctx context.Context, argumentsInJSON str
 	if err := json.Unmarshal([]byte(argumentsInJSON), &input); err != nil {
 		return "", fmt.Errorf("parse input: %w", err)
 	}
+	if input.Explain {
+		return t.runExplain(ctx, &input)
+	}
+	return t.runPage(ctx, &input)
+}
+
+// StreamableRun 实现 tool.StreamableTool，stream 为 true 时按批输出全部结果，否则与 InvokableRun 一样输出一页.
+func (t *DataAnalysisTool) StreamableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (*schema.StreamReader[string], error) {
+	var input DataAnalysisInput
+	if err := json.Unmarshal([]byte(argumentsInJSON), &input); err != nil {
+		return nil, fmt.Errorf("parse input: %w", err)
+	}
+	if input.Explain || !input.Stream || input.NextPage {
+		output, err := t.InvokableRun(ctx, argumentsInJSON, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return schema.StreamReaderFromArray([]string{output}), nil
+	}
+	if _, err := checkReadOnlyQuery(input.SQL); err != nil {
+		return nil, err
+	}
+
+	sr, sw := schema.Pipe[string](4)
+	go func() {
+		defer sw.Close()
+
+		var sb strings.Builder
+		sb.WriteString("## 查询结果（流式）\n\n")
+		rows, batch := 0, 0
+		truncated := false
+		err := t.manager.StreamQuery(ctx, t.sessionID, input.SQL, func(columns []string, values []interface{}) error {
+			if rows >= dataAnalysisMaxStreamRows {
+				truncated = true
+				return errStopRows
+			}
+			if rows == 0 {
+				writeTableHeader(&sb, columns)
+			}
+			writeTableRow(&sb, values)
+			rows++
+			batch++
+			if batch >= dataAnalysisStreamBatch {
+				batch = 0
+				if closed := sw.Send(sb.String(), nil); closed {
+					return errStopRows
+				}
+				sb.Reset()
+			}
+			return nil
+		})
+		if err != nil {
+			sw.Send("", err)
+			return
+		}
+		if rows == 0 {
+			sb.WriteString("*无数据*\n")
+		}
+		fmt.Fprintf(&sb, "\n**返回行数**: %d\n", rows)
+		if truncated {
+			fmt.Fprintf(&sb, "\n结果超过 %d 行，已截断，请缩小查询范围或使用 next_page 分页读取。\n", dataAnalysisMaxStreamRows)
+		}
+		sw.Send(sb.String(), nil)
+	}()
+	return sr, nil
+}
 
-	// 执行查询
-	result, err := t.manager.ExecuteQuery(ctx, input.SQL, t.maxRows)
+// runPage 执行查询（或读取上一次查询的下一页）并格式化为 Markdown 表格.
+func (t *DataAnalysisTool) runPage(ctx context.Context, input *DataAnalysisInput) (string, error) {
+	var (
+		result *QueryResult
+		err    error
+	)
+	var warnings []string
+	if input.NextPage {
+		result, err = t.manager.NextPage(ctx, t.sessionID)
+	} else {
+		result, err = t.manager.ExecuteQuery(ctx, t.sessionID, input.SQL, t.maxRows)
+		if err == nil {
+			warnings, err = t.manager.ScanWarnings(ctx, t.sessionID, input.SQL)
+		}
+	}
 	if err != nil {
 		return "", err
 	}
 
 	// 格式化输出为 Markdown 表格
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("## 查询结果\n\n"))
-	sb.WriteString(fmt.Sprintf("**返回行数**: %d\n\n", result.RowCount))
+	sb.WriteString("## 查询结果\n\n")
+	writeWarnings(&sb, warnings)
+	if result.RowCount > 0 {
+		fmt.Fprintf(&sb, "**返回行数**: %d（第 %d-%d 行）\n\n", result.RowCount, result.Offset+1, result.Offset+result.RowCount)
+	} else {
+		fmt.Fprintf(&sb, "**返回行数**: %d\n\n", result.RowCount)
+	}
 
 	if len(result.Data) > 0 {
-		// 表头
-		sb.WriteString("| " + strings.Join(result.Columns, " | ") + " |\n")
-		sb.WriteString("|" + strings.Repeat("---|", len(result.Columns)) + "\n")
-
-		// 数据行
+		writeTableHeader(&sb, result.Columns)
 		for _, row := range result.Data {
-			var values []string
-			for _, col := range result.Columns {
-				val := row[col]
-				if val == nil {
-					values = append(values, "NULL")
-				} else {
-					values = append(values, fmt.Sprintf("%v", val))
-				}
+			values := make([]interface{}, len(result.Columns))
+			for i, col := range result.Columns {
+				values[i] = row[col]
 			}
-			sb.WriteString("| " + strings.Join(values, " | ") + " |\n")
+			writeTableRow(&sb, values)
 		}
 	} else {
 		sb.WriteString("*无数据*\n")
 	}
+	if result.HasMore {
+		sb.WriteString("\n还有更多结果，调用 data_analysis 并设置 next_page=true 读取下一页。\n")
+	}
+
+	return sb.String(), nil
+}
+
+// runExplain 返回查询的执行计划.
+func (t *DataAnalysisTool) runExplain(ctx context.Context, input *DataAnalysisInput) (string, error) {
+	plan, err := t.manager.ExplainQuery(ctx, t.sessionID, input.SQL)
+	if err != nil {
+		return "", err
+	}
 
+	var sb strings.Builder
+	sb.WriteString("## 执行计划\n\n")
+	writeWarnings(&sb, plan.Warnings)
+	sb.WriteString("```\n")
+	sb.WriteString(plan.Plan)
+	sb.WriteString("```\n")
 	return sb.String(), nil
 }
+
+// writeWarnings 输出查询警告.
+func writeWarnings(sb *strings.Builder, warnings []string) {
+	for _, w := range warnings {
+		fmt.Fprintf(sb, "> ⚠️ %s\n", w)
+	}
+	if len(warnings) > 0 {
+		sb.WriteString("\n")
+	}
+}
+
+// writeTableHeader 输出 Markdown 表头.
+func writeTableHeader(sb *strings.Builder, columns []string) {
+	sb.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	sb.WriteString("|" + strings.Repeat("---|", len(columns)) + "\n")
+}
+
+// writeTableRow 输出一行 Markdown 表格，NULL 值显示为 NULL.
+func writeTableRow(sb *strings.Builder, values []interface{}) {
+	cells := make([]string, len(values))
+	for i, val := range values {
+		if val == nil {
+			cells[i] = "NULL"
+		} else {
+			cells[i] = fmt.Sprintf("%v", val)
+		}
+	}
+	sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
@@ -0,0 +1,375 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeCSV 在临时目录写入 CSV 文件并返回路径.
+func writeCSV(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	return path
+}
+
+// queryInt 返回会话中只有一个值的查询结果.
+func queryInt(t *testing.T, m *DataAnalysisManager, sessionID, sql string) int64 {
+	t.Helper()
+	result, err := m.ExecuteQuery(context.Background(), sessionID, sql, 10)
+	if err != nil {
+		t.Fatalf("ExecuteQuery(%s, %q): %v", sessionID, sql, err)
+	}
+	if result.RowCount != 1 {
+		t.Fatalf("ExecuteQuery(%s, %q) rows = %d, want 1", sessionID, sql, result.RowCount)
+	}
+	for _, v := range result.Data[0] {
+		switch n := v.(type) {
+		case int64:
+			return n
+		case int32:
+			return int64(n)
+		}
+		t.Fatalf("value %v (%T) is not an integer", v, v)
+	}
+	return 0
+}
+
+func TestDataAnalysisSessionsAreIsolated(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewDataAnalysisManager(nil)
+	if err != nil {
+		t.Fatalf("NewDataAnalysisManager: %v", err)
+	}
+	defer m.Close()
+
+	// 两个会话加载同一文档 ID 的不同版本，表名相同但互不可见
+	docID := "11111111-aaaa"
+	tableA, err := m.LoadCSVFile(ctx, "sA", docID, writeCSV(t, "a.csv", "amount\n1\n2\n3\n"))
+	if err != nil {
+		t.Fatalf("LoadCSVFile(sA): %v", err)
+	}
+	tableB, err := m.LoadCSVFile(ctx, "sB", docID, writeCSV(t, "b.csv", "amount\n100\n200\n"))
+	if err != nil {
+		t.Fatalf("LoadCSVFile(sB): %v", err)
+	}
+	if tableA != tableB {
+		t.Fatalf("table names = %s, %s, want the same name in both sessions", tableA, tableB)
+	}
+
+	// 两个会话并发查询，各自只看到自己的数据
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		for session, want := range map[string]int64{"sA": 6, "sB": 300} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := m.ExecuteQuery(ctx, session, "SELECT SUM(amount) AS total FROM "+tableA, 10)
+				if err != nil {
+					errs <- fmt.Errorf("%s: %w", session, err)
+					return
+				}
+				if got := fmt.Sprint(result.Data[0]["total"]); got != fmt.Sprint(want) {
+					errs <- fmt.Errorf("%s: total = %s, want %d", session, got, want)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// 只在 sA 加载的表在 sB 中不存在
+	onlyA, err := m.LoadCSVFile(ctx, "sA", "22222222-bbbb", writeCSV(t, "c.csv", "x\n1\n"))
+	if err != nil {
+		t.Fatalf("LoadCSVFile: %v", err)
+	}
+	if _, err := m.ExecuteQuery(ctx, "sB", "SELECT * FROM "+onlyA, 10); err == nil {
+		t.Errorf("session sB can read table %s loaded by sA", onlyA)
+	}
+
+	// 清理 sA 不影响 sB
+	m.CleanupSession(ctx, "sA")
+	if _, err := m.ExecuteQuery(ctx, "sA", "SELECT 1", 10); err == nil || !strings.Contains(err.Error(), "no data loaded") {
+		t.Errorf("query after cleanup error = %v, want no data loaded", err)
+	}
+	if got := queryInt(t, m, "sB", "SELECT COUNT(*) FROM "+tableB); got != 2 {
+		t.Errorf("sB rows after sA cleanup = %d, want 2", got)
+	}
+}
+
+// tableExists 判断会话数据库中是否存在表.
+func tableExists(t *testing.T, m *DataAnalysisManager, sessionID, table string) bool {
+	t.Helper()
+	sess, err := m.session(context.Background(), sessionID, false)
+	if err != nil {
+		t.Fatalf("session %s: %v", sessionID, err)
+	}
+	var n int
+	if err := sess.db.QueryRow("SELECT COUNT(*) FROM information_schema.tables WHERE table_name = ?", table).Scan(&n); err != nil {
+		t.Fatalf("check table %s: %v", table, err)
+	}
+	return n > 0
+}
+
+func TestDataAnalysisEvictsLeastRecentlyUsedTable(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewDataAnalysisManager(&DataAnalysisConfig{MaxTables: 2})
+	if err != nil {
+		t.Fatalf("NewDataAnalysisManager: %v", err)
+	}
+	defer m.Close()
+
+	load := func(session, docID, content string) string {
+		name, err := m.LoadCSVFile(ctx, session, docID, writeCSV(t, docID+".csv", content))
+		if err != nil {
+			t.Fatalf("LoadCSVFile(%s): %v", docID, err)
+		}
+		return name
+	}
+	oldest := load("s1", "aaaaaaaa-1", "v\n1\n")
+	middle := load("s1", "bbbbbbbb-2", "v\n2\n")
+	// 上限按所有会话合计，加载第三张表时淘汰最久未使用的表
+	newest := load("s2", "cccccccc-3", "v\n3\n")
+
+	if tableExists(t, m, "s1", oldest) {
+		t.Errorf("oldest table %s still loaded after exceeding MaxTables", oldest)
+	}
+	if !tableExists(t, m, "s1", middle) || !tableExists(t, m, "s2", newest) {
+		t.Errorf("recent tables %s, %s were evicted", middle, newest)
+	}
+
+	// 使用被淘汰的表时按需重新加载，此时最久未使用的是 middle
+	if got := queryInt(t, m, "s1", "SELECT v FROM "+oldest); got != 1 {
+		t.Errorf("reloaded table value = %d, want 1", got)
+	}
+	if !tableExists(t, m, "s1", oldest) {
+		t.Errorf("table %s not reloaded on use", oldest)
+	}
+	if tableExists(t, m, "s1", middle) {
+		t.Errorf("table %s should be evicted after %s was reloaded", middle, oldest)
+	}
+
+	// 查询刷新使用时间：先用 newest，再加载新表时淘汰 oldest 而不是 newest
+	queryInt(t, m, "s2", "SELECT v FROM "+newest)
+	load("s2", "dddddddd-4", "v\n4\n")
+	if tableExists(t, m, "s1", oldest) || !tableExists(t, m, "s2", newest) {
+		t.Errorf("eviction ignored recent use: %s loaded = %v, %s loaded = %v",
+			oldest, tableExists(t, m, "s1", oldest), newest, tableExists(t, m, "s2", newest))
+	}
+}
+
+// numbersCSV 返回包含 1..n 的单列 CSV.
+func numbersCSV(n int) string {
+	var sb strings.Builder
+	sb.WriteString("n\n")
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, "%d\n", i)
+	}
+	return sb.String()
+}
+
+func TestDataAnalysisPagesThroughResults(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewDataAnalysisManager(nil)
+	if err != nil {
+		t.Fatalf("NewDataAnalysisManager: %v", err)
+	}
+	defer m.Close()
+
+	if _, err := m.NextPage(ctx, "s1"); err == nil {
+		t.Error("NextPage() without a previous query succeeded, want error")
+	}
+	table, err := m.LoadCSVFile(ctx, "s1", "33333333-cccc", writeCSV(t, "n.csv", numbersCSV(7)))
+	if err != nil {
+		t.Fatalf("LoadCSVFile: %v", err)
+	}
+
+	result, err := m.ExecuteQuery(ctx, "s1", "SELECT n FROM "+table+" ORDER BY n", 3)
+	if err != nil {
+		t.Fatalf("ExecuteQuery: %v", err)
+	}
+	var got []string
+	pages := 0
+	for {
+		pages++
+		if want := (pages - 1) * 3; result.Offset != want {
+			t.Errorf("page %d offset = %d, want %d", pages, result.Offset, want)
+		}
+		for _, row := range result.Data {
+			got = append(got, fmt.Sprint(row["n"]))
+		}
+		if !result.HasMore {
+			break
+		}
+		if result, err = m.NextPage(ctx, "s1"); err != nil {
+			t.Fatalf("NextPage: %v", err)
+		}
+	}
+	if pages != 3 || strings.Join(got, ",") != "1,2,3,4,5,6,7" {
+		t.Errorf("paged %d pages with rows %v, want 3 pages with 1..7", pages, got)
+	}
+
+	// 新查询重置游标
+	result, err = m.ExecuteQuery(ctx, "s1", "SELECT n FROM "+table+" WHERE n > 5 ORDER BY n", 3)
+	if err != nil {
+		t.Fatalf("ExecuteQuery: %v", err)
+	}
+	if result.RowCount != 2 || result.HasMore || result.Offset != 0 {
+		t.Errorf("new query = %d rows, has more %v, offset %d, want 2 rows on the only page", result.RowCount, result.HasMore, result.Offset)
+	}
+}
+
+func TestDataAnalysisToolNextPage(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewDataAnalysisManager(nil)
+	if err != nil {
+		t.Fatalf("NewDataAnalysisManager: %v", err)
+	}
+	defer m.Close()
+	table, err := m.LoadCSVFile(ctx, "s1", "44444444-dddd", writeCSV(t, "n.csv", numbersCSV(5)))
+	if err != nil {
+		t.Fatalf("LoadCSVFile: %v", err)
+	}
+	tl := NewDataAnalysisTool(m, "s1", 2)
+
+	first, err := tl.InvokableRun(ctx, `{"sql": "SELECT n FROM `+table+` ORDER BY n"}`)
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if !strings.Contains(first, "第 1-2 行") || !strings.Contains(first, "next_page=true") {
+		t.Errorf("first page output missing range or next_page hint:\n%s", first)
+	}
+	second, err := tl.InvokableRun(ctx, `{"next_page": true}`)
+	if err != nil {
+		t.Fatalf("InvokableRun(next_page): %v", err)
+	}
+	if !strings.Contains(second, "第 3-4 行") || !strings.Contains(second, "| 3 |") || strings.Contains(second, "| 1 |") {
+		t.Errorf("second page output:\n%s", second)
+	}
+	last, err := tl.InvokableRun(ctx, `{"next_page": true}`)
+	if err != nil {
+		t.Fatalf("InvokableRun(next_page): %v", err)
+	}
+	if !strings.Contains(last, "第 5-5 行") || strings.Contains(last, "next_page=true") {
+		t.Errorf("last page output:\n%s", last)
+	}
+}
+
+func TestDataAnalysisToolStreamsAllRows(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewDataAnalysisManager(nil)
+	if err != nil {
+		t.Fatalf("NewDataAnalysisManager: %v", err)
+	}
+	defer m.Close()
+	table, err := m.LoadCSVFile(ctx, "s1", "55555555-eeee", writeCSV(t, "n.csv", numbersCSV(120)))
+	if err != nil {
+		t.Fatalf("LoadCSVFile: %v", err)
+	}
+	tl := NewDataAnalysisTool(m, "s1", 10).(*DataAnalysisTool)
+
+	sr, err := tl.StreamableRun(ctx, `{"sql": "SELECT n FROM `+table+` ORDER BY n", "stream": true}`)
+	if err != nil {
+		t.Fatalf("StreamableRun: %v", err)
+	}
+	defer sr.Close()
+	var chunks []string
+	for {
+		chunk, err := sr.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	// 每 dataAnalysisStreamBatch 行一个数据块，不受每页行数限制
+	if len(chunks) != 3 {
+		t.Errorf("got %d chunks, want 3", len(chunks))
+	}
+	output := strings.Join(chunks, "")
+	if !strings.Contains(output, "| 120 |") || !strings.Contains(output, "**返回行数**: 120") {
+		t.Errorf("streamed output missing rows:\n%s", output)
+	}
+}
+
+func TestDataAnalysisToolExplain(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewDataAnalysisManager(nil)
+	if err != nil {
+		t.Fatalf("NewDataAnalysisManager: %v", err)
+	}
+	defer m.Close()
+	table, err := m.LoadCSVFile(ctx, "s1", "66666666-ffff", writeCSV(t, "n.csv", numbersCSV(5)))
+	if err != nil {
+		t.Fatalf("LoadCSVFile: %v", err)
+	}
+	tl := NewDataAnalysisTool(m, "s1", 10)
+
+	output, err := tl.InvokableRun(ctx, `{"sql": "SELECT n FROM `+table+` WHERE n > 2", "explain": true}`)
+	if err != nil {
+		t.Fatalf("InvokableRun(explain): %v", err)
+	}
+	// 返回计划而不是查询结果
+	for _, want := range []string{"## 执行计划", "```", "SCAN", table} {
+		if !strings.Contains(output, want) {
+			t.Errorf("explain output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "查询结果") || strings.Contains(output, "⚠️") {
+		t.Errorf("explain output has results or warnings:\n%s", output)
+	}
+
+	for _, sql := range []string{"DELETE FROM " + table, "EXPLAIN SELECT * FROM " + table} {
+		if _, err := m.ExplainQuery(ctx, "s1", sql); err == nil {
+			t.Errorf("ExplainQuery(%q) succeeded, want error", sql)
+		}
+	}
+}
+
+func TestDataAnalysisWarnsOnLargeUnfilteredScan(t *testing.T) {
+	if testing.Short() {
+		t.Skip("loads a table with more than largeTableRows rows")
+	}
+	ctx := context.Background()
+	m, err := NewDataAnalysisManager(nil)
+	if err != nil {
+		t.Fatalf("NewDataAnalysisManager: %v", err)
+	}
+	defer m.Close()
+	table, err := m.LoadCSVFile(ctx, "s1", "77777777-0000", writeCSV(t, "big.csv", numbersCSV(largeTableRows+1)))
+	if err != nil {
+		t.Fatalf("LoadCSVFile: %v", err)
+	}
+
+	plan, err := m.ExplainQuery(ctx, "s1", "SELECT * FROM "+table)
+	if err != nil {
+		t.Fatalf("ExplainQuery: %v", err)
+	}
+	if len(plan.Warnings) != 1 || !strings.Contains(plan.Warnings[0], table) {
+		t.Errorf("warnings = %v, want one warning for %s", plan.Warnings, table)
+	}
+	for _, sql := range []string{"SELECT * FROM " + table + " WHERE n = 1", "SELECT * FROM " + table + " LIMIT 10"} {
+		plan, err := m.ExplainQuery(ctx, "s1", sql)
+		if err != nil {
+			t.Fatalf("ExplainQuery(%q): %v", sql, err)
+		}
+		if len(plan.Warnings) != 0 {
+			t.Errorf("ExplainQuery(%q) warnings = %v, want none", sql, plan.Warnings)
+		}
+	}
+}
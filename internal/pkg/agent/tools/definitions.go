@@ -9,9 +9,11 @@ const (
 	ToolGrepChunks          = "grep_chunks"
 	ToolWebSearch           = "web_search"
 	ToolWebFetch            = "web_fetch"
+	ToolDataSchema          = "data_schema"
 	ToolDataAnalysis        = "data_analysis"
 	ToolDatabaseQuery       = "database_query"
 	ToolListKnowledgeChunks = "list_knowledge_chunks"
+	ToolSummarizeDocument   = "summarize_document"
 )
 
 // ToolDefinition 工具定义.
@@ -30,6 +32,7 @@ func AvailableTools() []ToolDefinition {
 		{Name: ToolKnowledgeSearch, Label: "语义搜索", Description: "理解问题并查找语义相关内容", Category: "knowledge"},
 		{Name: ToolGrepChunks, Label: "关键词搜索", Description: "快速定位包含特定关键词的文档", Category: "knowledge"},
 		{Name: ToolListKnowledgeChunks, Label: "查看文档分块", Description: "获取文档完整分块内容", Category: "knowledge"},
+		{Name: ToolSummarizeDocument, Label: "文档摘要", Description: "生成整篇文档的摘要", Category: "knowledge"},
 		{Name: ToolWebSearch, Label: "网络搜索", Description: "搜索互联网获取实时信息", Category: "web"},
 		{Name: ToolWebFetch, Label: "网页抓取", Description: "抓取网页内容", Category: "web"},
 		{Name: ToolDataAnalysis, Label: "数据分析", Description: "分析数据文件", Category: "data"},
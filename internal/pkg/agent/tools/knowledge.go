@@ -11,6 +11,7 @@ import (
 	"github.com/cloudwego/eino/schema"
 
 	"github.com/ashwinyue/next-show/internal/model"
+	"github.com/ashwinyue/next-show/internal/store"
 )
 
 // KnowledgeService 知识库服务接口.
@@ -19,17 +20,26 @@ type KnowledgeService interface {
 	SemanticSearch(ctx context.Context, req *SemanticSearchRequest) (*SemanticSearchResult, error)
 	// KeywordSearch 关键词搜索.
 	KeywordSearch(ctx context.Context, req *KeywordSearchRequest) (*KeywordSearchResult, error)
-	// HybridSearch 混合检索（向量 + BM25）.
+	// HybridSearch 混合检索（向量 + BM25），req.RerankSearch 时返回重排序后的结果.
 	HybridSearch(ctx context.Context, req *HybridSearchRequest) (*HybridSearchResult, error)
+	// RerankedSearch 带重排序的混合检索.
+	RerankedSearch(ctx context.Context, req *HybridSearchRequest) (*HybridSearchResult, error)
 	// ListChunks 列出文档分块.
 	ListChunks(ctx context.Context, req *ListChunksRequest) (*ListChunksResult, error)
+	// GetDocumentSummary 获取文档缓存的摘要和当前分块内容的哈希，未生成或分块内容已变化时摘要为空.
+	GetDocumentSummary(ctx context.Context, req *DocumentSummaryRequest) (*DocumentSummary, error)
+	// SaveDocumentSummary 以 summary.ContentHash 为键缓存文档摘要.
+	SaveDocumentSummary(ctx context.Context, req *DocumentSummaryRequest, summary *DocumentSummary) error
 }
 
 // SemanticSearchRequest 语义搜索请求.
 type SemanticSearchRequest struct {
-	Queries          []string `json:"queries"`
-	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"`
-	TopK             int      `json:"top_k,omitempty"`
+	Queries          []string       `json:"queries"`
+	KnowledgeBaseIDs []string       `json:"knowledge_base_ids,omitempty"`
+	TopK             int            `json:"top_k,omitempty"`
+	DocumentMetadata map[string]any `json:"document_metadata,omitempty"` // 按文档元数据过滤
+	// MetadataFilters 按分块元数据过滤，例如 [{"field": "category", "operator": "eq", "value": "tech"}]
+	MetadataFilters []store.MetadataFilter `json:"metadata_filters,omitempty"`
 }
 
 // SemanticSearchResult 语义搜索结果.
@@ -43,6 +53,7 @@ type KeywordSearchRequest struct {
 	Keywords         []string `json:"keywords"`
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"`
 	TopK             int      `json:"top_k,omitempty"`
+	MatchAny         bool     `json:"match_any,omitempty"` // 包含任一关键词即匹配，默认要求包含所有关键词
 }
 
 // KeywordSearchResult 关键词搜索结果.
@@ -53,11 +64,17 @@ type KeywordSearchResult struct {
 
 // HybridSearchRequest 混合检索请求.
 type HybridSearchRequest struct {
-	Query            string   `json:"query"`
-	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"`
-	TopK             int      `json:"top_k,omitempty"`
-	VectorWeight     float64  `json:"vector_weight,omitempty"` // 向量搜索权重，默认 0.7
-	BM25Weight       float64  `json:"bm25_weight,omitempty"`   // BM25 搜索权重，默认 0.3
+	Query            string         `json:"query"`
+	KnowledgeBaseIDs []string       `json:"knowledge_base_ids,omitempty"`
+	TopK             int            `json:"top_k,omitempty"`
+	VectorWeight     float64        `json:"vector_weight,omitempty"`     // 向量搜索权重，默认 0.7
+	BM25Weight       float64        `json:"bm25_weight,omitempty"`       // BM25 搜索权重，默认 0.3
+	DocumentMetadata map[string]any `json:"document_metadata,omitempty"` // 按文档元数据过滤
+	RerankSearch     bool           `json:"rerank_search,omitempty"`     // 对结果重排序
+	// MetadataFilters 按分块元数据过滤，多个条件为 AND 关系
+	MetadataFilters []store.MetadataFilter `json:"metadata_filters,omitempty"`
+	FusionMethod    store.FusionMethod     `json:"fusion_method,omitempty"` // 结果合并方式，默认 weighted_sum
+	RRFK            int                    `json:"rrf_k,omitempty"`         // rrf 的平滑常数，默认 60
 }
 
 // HybridSearchResult 混合检索结果.
@@ -66,6 +83,21 @@ type HybridSearchResult struct {
 	TotalCount int            `json:"total_count"`
 }
 
+// DocumentSummaryRequest 文档摘要缓存请求，范围外的文档按不存在处理.
+type DocumentSummaryRequest struct {
+	DocumentID string `json:"document_id"`
+	// KnowledgeBaseIDs 允许访问的知识库，为空时不限制
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"`
+	// TenantID 调用方租户，非空时文档所属知识库必须属于该租户
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// DocumentSummary 文档摘要缓存，以文档 ID 和分块内容哈希为键.
+type DocumentSummary struct {
+	Summary     string `json:"summary"`
+	ContentHash string `json:"content_hash"`
+}
+
 // ListChunksRequest 列出分块请求.
 type ListChunksRequest struct {
 	DocumentID string `json:"document_id"`
@@ -88,6 +120,8 @@ type ChunkResult struct {
 	ChunkIndex      int     `json:"chunk_index"`
 	Content         string  `json:"content"`
 	Score           float64 `json:"score,omitempty"`
+	// PreRerankScore 重排序前的检索分数，仅重排序结果返回
+	PreRerankScore float64 `json:"pre_rerank_score,omitempty"`
 }
 
 // ============== Knowledge Search Tool ==============
@@ -220,6 +254,7 @@ func (t *KnowledgeSearchTool) formatOutput(queries []string, result *SemanticSea
 		sb.WriteString(fmt.Sprintf("--- 结果 %d ---\n", i+1))
 		sb.WriteString(fmt.Sprintf("文档: %s\n", chunk.DocumentTitle))
 		sb.WriteString(fmt.Sprintf("文档ID: %s\n", chunk.DocumentID))
+		sb.WriteString(fmt.Sprintf("分块ID: %s\n", chunk.ID))
 		sb.WriteString(fmt.Sprintf("分块索引: %d\n", chunk.ChunkIndex))
 		if chunk.Score > 0 {
 			sb.WriteString(fmt.Sprintf("相关度: %.2f\n", chunk.Score))
@@ -252,12 +287,16 @@ const grepChunksToolDesc = `关键词搜索工具，用于快速定位包含特
 
 ## 参数
 - keywords (必填): 1-5 个要搜索的关键词
-- knowledge_base_ids (可选): 限制搜索范围的知识库 ID`
+- knowledge_base_ids (可选): 限制搜索范围的知识库 ID
+- match_all (可选): 是否要求包含所有关键词，默认 true；关键词之间关联较弱时设为 false，包含任一关键词即返回
+
+结果按命中的关键词数和出现次数降序排列。`
 
 // GrepChunksInput 关键词搜索工具输入.
 type GrepChunksInput struct {
 	Keywords         []string `json:"keywords" jsonschema:"description=1-5 个要搜索的关键词"`
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty" jsonschema:"description=限制搜索范围的知识库 ID"`
+	MatchAll         *bool    `json:"match_all,omitempty" jsonschema:"description=是否要求包含所有关键词，默认 true"`
 }
 
 // GrepChunksTool 关键词搜索工具.
@@ -314,6 +353,10 @@ func (t *GrepChunksTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
 					Type: schema.String,
 				},
 			},
+			"match_all": {
+				Type: schema.Boolean,
+				Desc: "是否要求包含所有关键词，默认 true；设为 false 时包含任一关键词即返回",
+			},
 		}),
 	}, nil
 }
@@ -342,6 +385,7 @@ func (t *GrepChunksTool) InvokableRun(ctx context.Context, arguments string, opt
 		Keywords:         input.Keywords,
 		KnowledgeBaseIDs: kbIDs,
 		TopK:             t.topK,
+		MatchAny:         input.MatchAll != nil && !*input.MatchAll,
 	})
 	if err != nil {
 		return t.formatError(fmt.Sprintf("搜索失败: %v", err)), nil
@@ -361,7 +405,9 @@ func (t *GrepChunksTool) formatOutput(keywords []string, result *KeywordSearchRe
 		sb.WriteString(fmt.Sprintf("--- 结果 %d ---\n", i+1))
 		sb.WriteString(fmt.Sprintf("文档: %s\n", chunk.DocumentTitle))
 		sb.WriteString(fmt.Sprintf("文档ID: %s\n", chunk.DocumentID))
+		sb.WriteString(fmt.Sprintf("分块ID: %s\n", chunk.ID))
 		sb.WriteString(fmt.Sprintf("分块索引: %d\n", chunk.ChunkIndex))
+		sb.WriteString(fmt.Sprintf("相关度: %.2f\n", chunk.Score))
 		sb.WriteString(fmt.Sprintf("内容:\n%s\n\n", chunk.Content))
 	}
 
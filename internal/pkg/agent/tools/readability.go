@@ -0,0 +1,305 @@
+// Package tools 提供内置工具和中间件.
+package tools
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// minReadableChars 正文提取结果的最小字符数，低于该值视为提取失败.
+const minReadableChars = 200
+
+var (
+	// readabilityNegative class/id 命中时视为样板内容（菜单、Cookie 提示、相关推荐等）
+	readabilityNegative = regexp.MustCompile(`(?i)comment|cookie|consent|banner|menu|navbar|breadcrumb|footer|header|sidebar|related|recommend|share|social|sponsor|promo|advert|subscribe|newsletter|popup|modal|widget`)
+	// readabilityPositive class/id 命中时更可能是正文容器
+	readabilityPositive = regexp.MustCompile(`(?i)article|body|content|entry|main|post|story|text|blog`)
+	whitespacePattern   = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLinesPattern   = regexp.MustCompile(`\n{3,}`)
+)
+
+// readableArticle 正文提取结果.
+type readableArticle struct {
+	Title   string
+	Content string
+}
+
+// extractReadable 使用 readability 风格的打分算法提取页面标题和正文.
+// 正文不足 minReadableChars 时返回 false，调用方应回退到其它提取方式.
+func extractReadable(r io.Reader) (*readableArticle, bool) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, false
+	}
+
+	article := &readableArticle{Title: extractTitle(doc)}
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		return article, false
+	}
+	pruneBoilerplate(body)
+
+	top := topCandidate(body)
+	if top == nil {
+		return article, false
+	}
+
+	var sb strings.Builder
+	collectText(top, &sb)
+	article.Content = normalizeText(sb.String())
+	return article, len([]rune(article.Content)) >= minReadableChars
+}
+
+// extractTitle 依次使用 og:title、<title>、第一个 <h1> 作为标题.
+func extractTitle(doc *html.Node) string {
+	var ogTitle, title, h1 string
+	walk(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.Meta:
+			if ogTitle == "" && (attr(n, "property") == "og:title" || attr(n, "name") == "og:title") {
+				ogTitle = strings.TrimSpace(attr(n, "content"))
+			}
+		case atom.Title:
+			if title == "" {
+				title = strings.TrimSpace(textContent(n))
+			}
+		case atom.H1:
+			if h1 == "" {
+				h1 = strings.TrimSpace(textContent(n))
+			}
+		}
+		return true
+	})
+	for _, t := range []string{ogTitle, title, h1} {
+		if t != "" {
+			return normalizeText(t)
+		}
+	}
+	return ""
+}
+
+// pruneBoilerplate 移除脚本、导航、页脚以及 class/id 命中样板规则的节点.
+func pruneBoilerplate(root *html.Node) {
+	var remove []*html.Node
+	walk(root, func(n *html.Node) bool {
+		if n.Type == html.CommentNode {
+			remove = append(remove, n)
+			return false
+		}
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.Script, atom.Style, atom.Noscript, atom.Nav, atom.Footer, atom.Header,
+			atom.Aside, atom.Form, atom.Iframe, atom.Svg, atom.Button, atom.Select, atom.Template:
+			remove = append(remove, n)
+			return false
+		case atom.Article, atom.Main, atom.Body:
+			return true
+		}
+		if attr(n, "role") == "navigation" || attr(n, "aria-hidden") == "true" {
+			remove = append(remove, n)
+			return false
+		}
+		if readabilityNegative.MatchString(attr(n, "class")+" "+attr(n, "id")) &&
+			!readabilityPositive.MatchString(attr(n, "id")) {
+			remove = append(remove, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range remove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// topCandidate 按段落文本为父节点打分，返回得分最高的正文容器.
+func topCandidate(root *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			order = append(order, n)
+			scores[n] = classWeight(n)
+			if n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+				scores[n] += 25
+			}
+		}
+		scores[n] += score
+	}
+
+	walk(root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		default:
+			return true
+		}
+		text := strings.TrimSpace(textContent(n))
+		length := len([]rune(text))
+		if length < 25 {
+			return false
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")+strings.Count(text, "。"))
+		score += min(float64(length)/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+		return false
+	})
+
+	var best *html.Node
+	var bestScore float64
+	for _, n := range order {
+		// 文本中链接占比越高越可能是导航，按链接密度折算
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+func classWeight(n *html.Node) float64 {
+	var weight float64
+	for _, v := range []string{attr(n, "class"), attr(n, "id")} {
+		if v == "" {
+			continue
+		}
+		if readabilityNegative.MatchString(v) {
+			weight -= 25
+		}
+		if readabilityPositive.MatchString(v) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len([]rune(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	var linkLen int
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			linkLen += len([]rune(textContent(c)))
+			return false
+		}
+		return true
+	})
+	return float64(linkLen) / float64(total)
+}
+
+// collectText 提取节点文本，块级元素之间保留换行.
+func collectText(n *html.Node, sb *strings.Builder) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if n.DataAtom == atom.Br {
+			sb.WriteString("\n")
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && isBlockElement(n.DataAtom)
+	if block {
+		sb.WriteString("\n")
+	}
+	if n.Type == html.ElementNode && n.DataAtom == atom.Li {
+		sb.WriteString("- ")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collectText(c, sb)
+	}
+	if block {
+		sb.WriteString("\n")
+	}
+}
+
+func isBlockElement(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Pre, atom.Blockquote,
+		atom.Ul, atom.Ol, atom.Li, atom.Table, atom.Tr, atom.H1, atom.H2, atom.H3, atom.H4,
+		atom.H5, atom.H6, atom.Figure, atom.Figcaption, atom.Dl, atom.Dt, atom.Dd, atom.Hr:
+		return true
+	}
+	return false
+}
+
+// normalizeText 压缩空白并去除多余空行.
+func normalizeText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(whitespacePattern.ReplaceAllString(line, " "))
+	}
+	s = strings.Join(lines, "\n")
+	s = blankLinesPattern.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			sb.WriteString(c.Data)
+		}
+		return true
+	})
+	return sb.String()
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// walk 深度优先遍历节点，fn 返回 false 时不再进入子节点.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		walk(c, fn)
+		c = next
+	}
+}
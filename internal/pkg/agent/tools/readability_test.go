@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExtractReadableArticle(t *testing.T) {
+	f, err := os.Open("testdata/article.html")
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer f.Close()
+
+	article, ok := extractReadable(f)
+	if !ok {
+		t.Fatalf("extractReadable = %+v, want the article extracted", article)
+	}
+	if article.Title != "How We Cut Refund Processing Time in Half" {
+		t.Errorf("title = %q, want the og:title", article.Title)
+	}
+	for _, want := range []string{
+		"a refund took an average of six business days",
+		"Replacing the batch jobs with an event-driven pipeline",
+		"refund tickets dropped by forty percent",
+		"apply the same approach to chargebacks",
+	} {
+		if !strings.Contains(article.Content, want) {
+			t.Errorf("content is missing %q:\n%s", want, article.Content)
+		}
+	}
+	// 导航、Cookie 提示、侧栏、相关推荐、订阅和页脚均被去除
+	for _, boilerplate := range []string{
+		"Contact Us",
+		"We use cookies",
+		"Popular posts",
+		"Related articles",
+		"idempotent payment APIs",
+		"Subscribe to our newsletter",
+		"All rights reserved",
+		"window.analytics",
+	} {
+		if strings.Contains(article.Content, boilerplate) {
+			t.Errorf("content contains boilerplate %q:\n%s", boilerplate, article.Content)
+		}
+	}
+}
+
+func TestExtractReadableRejectsShortPages(t *testing.T) {
+	page := `<html><head><title>Login</title></head><body><nav><a href="/">Home</a></nav><form><input name="user"></form><p>Please sign in.</p></body></html>`
+	if article, ok := extractReadable(strings.NewReader(page)); ok {
+		t.Errorf("extractReadable = %+v, want fallback for pages without an article", article)
+	}
+}
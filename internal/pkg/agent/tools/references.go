@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
+)
+
+func init() {
+	sse.RegisterBlockHandler(sse.BlockType(schema.ContentBlockTypeFunctionToolResult), referencesEvents)
+}
+
+// Reference 知识库检索命中的分块，通过 references 事件告知前端回答引用了哪些内容.
+type Reference struct {
+	ChunkID       string  `json:"chunk_id"`
+	DocumentID    string  `json:"document_id"`
+	DocumentTitle string  `json:"document_title"`
+	ChunkIndex    int     `json:"chunk_index"`
+	Score         float64 `json:"score,omitempty"`
+}
+
+// referencesEvents 在 knowledge_search、grep_chunks 的工具结果之后追加 references 事件.
+func referencesEvents(block *schema.ContentBlock) []sse.Event {
+	result := block.FunctionToolResult
+	if result == nil || (result.Name != ToolKnowledgeSearch && result.Name != ToolGrepChunks) {
+		return nil
+	}
+	refs := ParseSearchReferences(result.Result)
+	if len(refs) == 0 {
+		return nil
+	}
+	return []sse.Event{{
+		Type: sse.EventTypeReferences,
+		Data: map[string]interface{}{
+			"tool":       result.Name,
+			"call_id":    result.CallID,
+			"references": refs,
+		},
+	}}
+}
+
+// ParseSearchReferences 从 knowledge_search、grep_chunks 的输出中提取命中的分块.
+// 每个结果以 "--- 结果 N ---" 开始，"内容:" 之后的正文不参与解析.
+func ParseSearchReferences(output string) []Reference {
+	var (
+		refs      []Reference
+		current   *Reference
+		inContent bool
+	)
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "--- 结果 ") && strings.HasSuffix(line, " ---") {
+			refs = append(refs, Reference{})
+			current = &refs[len(refs)-1]
+			inContent = false
+			continue
+		}
+		if current == nil || inContent {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			inContent = strings.HasPrefix(line, "内容:")
+			continue
+		}
+		switch key {
+		case "文档":
+			current.DocumentTitle = value
+		case "文档ID":
+			current.DocumentID = value
+		case "分块ID":
+			current.ChunkID = value
+		case "分块索引":
+			current.ChunkIndex, _ = strconv.Atoi(value)
+		case "相关度":
+			current.Score, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+
+	// 没有分块 ID 的结果无法定位，不作为引用
+	valid := refs[:0]
+	for _, ref := range refs {
+		if ref.ChunkID != "" {
+			valid = append(valid, ref)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/pkg/sse"
+)
+
+// fakeKnowledgeService 返回固定检索结果的知识库服务.
+type fakeKnowledgeService struct {
+	KnowledgeService
+	chunks []*ChunkResult
+}
+
+func (f *fakeKnowledgeService) SemanticSearch(context.Context, *SemanticSearchRequest) (*SemanticSearchResult, error) {
+	return &SemanticSearchResult{Chunks: f.chunks, TotalCount: len(f.chunks)}, nil
+}
+
+func (f *fakeKnowledgeService) KeywordSearch(context.Context, *KeywordSearchRequest) (*KeywordSearchResult, error) {
+	return &KeywordSearchResult{Chunks: f.chunks, TotalCount: len(f.chunks)}, nil
+}
+
+// referencesOf 将工具结果转换为 SSE 事件，返回 references 事件中的引用.
+func referencesOf(t *testing.T, toolName, result string) []Reference {
+	t.Helper()
+	events, err := sse.DefaultRegistry().Convert(&schema.ContentBlock{
+		Type:               schema.ContentBlockTypeFunctionToolResult,
+		FunctionToolResult: &schema.FunctionToolResult{CallID: "call1", Name: toolName, Result: result},
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	var refs []Reference
+	for _, event := range events {
+		if event.Type != sse.EventTypeReferences {
+			continue
+		}
+		data := event.Data
+		if data["call_id"] != "call1" || data["tool"] != toolName {
+			t.Fatalf("references event data = %+v", event.Data)
+		}
+		refs = append(refs, data["references"].([]Reference)...)
+	}
+	return refs
+}
+
+func TestSearchResultsEmitReferencesEvent(t *testing.T) {
+	service := &fakeKnowledgeService{chunks: []*ChunkResult{
+		{ID: "chunk-a", DocumentID: "doc1", DocumentTitle: "退款政策", ChunkIndex: 2, Score: 0.91,
+			// 正文中形似字段的行不影响解析
+			Content: "退款说明\n分块ID: not-a-chunk"},
+		{ID: "chunk-b", DocumentID: "doc2", DocumentTitle: "FAQ", ChunkIndex: 0, Score: 0.42, Content: "常见问题"},
+	}}
+	ctx := context.Background()
+
+	semantic, err := NewKnowledgeSearchTool(&KnowledgeSearchConfig{Service: service}).InvokableRun(ctx, `{"queries": ["退款"]}`)
+	if err != nil {
+		t.Fatalf("knowledge_search: %v", err)
+	}
+	grep, err := NewGrepChunksTool(&GrepChunksConfig{Service: service}).InvokableRun(ctx, `{"keywords": ["退款"]}`)
+	if err != nil {
+		t.Fatalf("grep_chunks: %v", err)
+	}
+
+	for name, output := range map[string]string{ToolKnowledgeSearch: semantic, ToolGrepChunks: grep} {
+		refs := referencesOf(t, name, output)
+		var ids []string
+		for _, ref := range refs {
+			ids = append(ids, ref.ChunkID)
+		}
+		if !slices.Equal(ids, []string{"chunk-a", "chunk-b"}) {
+			t.Fatalf("%s references = %v, want the retrieved chunk IDs", name, ids)
+		}
+		if refs[0].DocumentTitle != "退款政策" || refs[0].DocumentID != "doc1" || refs[0].ChunkIndex != 2 || refs[0].Score != 0.91 {
+			t.Errorf("%s first reference = %+v", name, refs[0])
+		}
+	}
+}
+
+func TestOtherToolResultsEmitNoReferences(t *testing.T) {
+	output := "--- 结果 1 ---\n分块ID: chunk-a\n"
+	if refs := referencesOf(t, "web_search", output); len(refs) != 0 {
+		t.Errorf("web_search references = %v, want none", refs)
+	}
+	if refs := referencesOf(t, ToolKnowledgeSearch, "未找到相关内容"); len(refs) != 0 {
+		t.Errorf("empty search references = %v, want none", refs)
+	}
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// SessionToolProvider 提供绑定会话上下文的动态工具，在构建 Agent 运行实例时按会话注入.
+// 例如 data_schema 加载的数据表归属于会话，需要在调用时携带会话 ID.
+type SessionToolProvider interface {
+	// Provides 判断工具是否由该 Provider 按会话提供.
+	Provides(name string) bool
+	// SessionTools 创建会话 sessionID 可用的指定工具，names 中的工具都应满足 Provides.
+	SessionTools(ctx context.Context, sessionID string, names []string) ([]tool.BaseTool, error)
+	// ReleaseSession 释放会话占用的资源，会话不再运行时调用.
+	ReleaseSession(ctx context.Context, sessionID string)
+}
+
+// DataAnalysisToolProvider 按会话提供 DuckDB 数据分析工具（data_schema、data_analysis）.
+type DataAnalysisToolProvider struct {
+	manager     *DataAnalysisManager
+	getFilePath func(ctx context.Context, documentID string) (string, string, error)
+	maxRows     int
+}
+
+// NewDataAnalysisToolProvider 创建数据分析工具 Provider，maxRows <= 0 时使用默认值.
+func NewDataAnalysisToolProvider(manager *DataAnalysisManager, getFilePath func(ctx context.Context, documentID string) (string, string, error), maxRows int) *DataAnalysisToolProvider {
+	return &DataAnalysisToolProvider{
+		manager:     manager,
+		getFilePath: getFilePath,
+		maxRows:     maxRows,
+	}
+}
+
+// Provides 实现 SessionToolProvider.
+func (p *DataAnalysisToolProvider) Provides(name string) bool {
+	return name == ToolDataSchema || name == ToolDataAnalysis
+}
+
+// SessionTools 实现 SessionToolProvider.
+func (p *DataAnalysisToolProvider) SessionTools(ctx context.Context, sessionID string, names []string) ([]tool.BaseTool, error) {
+	tools := make([]tool.BaseTool, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case ToolDataSchema:
+			tools = append(tools, NewDataSchemaTool(p.manager, sessionID, p.getFilePath))
+		case ToolDataAnalysis:
+			tools = append(tools, NewDataAnalysisTool(p.manager, sessionID, p.maxRows))
+		default:
+			return nil, fmt.Errorf("tool %s is not a session tool", name)
+		}
+	}
+	return tools, nil
+}
+
+// ReleaseSession 实现 SessionToolProvider，删除会话加载的数据表.
+func (p *DataAnalysisToolProvider) ReleaseSession(ctx context.Context, sessionID string) {
+	p.manager.CleanupSession(ctx, sessionID)
+}
+
+// Close 关闭 DuckDB 连接.
+func (p *DataAnalysisToolProvider) Close() error {
+	return p.manager.Close()
+}
@@ -0,0 +1,327 @@
+// Package tools 提供内置工具和中间件.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	defaultSummarizeBatchChars  = 6000 // 单次 map 调用的最大字符数
+	defaultSummarizeConcurrency = 4    // map 阶段的最大并发数
+	summarizeListPageSize       = 100  // 拉取分块的分页大小
+)
+
+const summarizeDocumentToolDesc = `整篇文档摘要工具，对指定文档的全部分块生成摘要。
+
+## 用途
+- 需要了解一篇文档的整体内容，而不是逐页查看分块
+- 回答"这篇文档讲了什么"类问题
+
+## 工作方式
+按顺序读取文档的全部分块，先分批摘要（map），再合并摘要（reduce）。
+摘要结果会缓存在文档上，重复调用直接返回缓存；文档重新分块或内容变化后缓存自动失效。
+
+## 参数
+- document_id (必填): 文档 ID
+- refresh (可选): 忽略缓存重新生成摘要`
+
+const summarizeMapPrompt = `请对以下文档片段进行摘要，保留关键事实、数据和结论，使用与原文相同的语言，不要添加原文没有的信息。
+
+文档: %s
+
+片段:
+%s`
+
+const summarizeReducePrompt = `以下是同一文档各部分的摘要，请将它们合并为一份连贯、完整的文档摘要，去除重复内容，保留关键事实、数据和结论，使用与原文相同的语言。
+
+文档: %s
+
+分段摘要:
+%s`
+
+// SummarizeDocumentInput 文档摘要工具输入.
+type SummarizeDocumentInput struct {
+	DocumentID string `json:"document_id" jsonschema:"description=文档 ID"`
+	Refresh    bool   `json:"refresh,omitempty" jsonschema:"description=忽略缓存重新生成摘要"`
+}
+
+// SummarizeDocumentConfig 文档摘要工具配置.
+type SummarizeDocumentConfig struct {
+	Service KnowledgeService
+	// KnowledgeBaseIDs 允许摘要的知识库，为空时不限制
+	KnowledgeBaseIDs []string
+	// TenantID 调用方租户，非空时只能摘要该租户知识库中的文档
+	TenantID string
+	// Model 用于生成摘要的模型，建议使用低成本模型
+	Model model.AgenticModel
+	// BatchChars 单次 map 调用的最大字符数，默认 6000
+	BatchChars int
+	// Concurrency map 阶段的最大并发数，默认 4
+	Concurrency int
+}
+
+// SummarizeDocumentTool 文档摘要工具.
+type SummarizeDocumentTool struct {
+	service          KnowledgeService
+	knowledgeBaseIDs []string
+	tenantID         string
+	model            model.AgenticModel
+	batchChars       int
+	concurrency      int
+}
+
+// NewSummarizeDocumentTool 创建文档摘要工具.
+func NewSummarizeDocumentTool(config *SummarizeDocumentConfig) *SummarizeDocumentTool {
+	t := &SummarizeDocumentTool{
+		batchChars:  defaultSummarizeBatchChars,
+		concurrency: defaultSummarizeConcurrency,
+	}
+	if config != nil {
+		t.service = config.Service
+		t.knowledgeBaseIDs = config.KnowledgeBaseIDs
+		t.tenantID = config.TenantID
+		t.model = config.Model
+		if config.BatchChars > 0 {
+			t.batchChars = config.BatchChars
+		}
+		if config.Concurrency > 0 {
+			t.concurrency = config.Concurrency
+		}
+	}
+	return t
+}
+
+// Info 返回工具信息.
+func (t *SummarizeDocumentTool) Info(ctx context.Context) (*schema.ToolInfo, error) {
+	return &schema.ToolInfo{
+		Name: ToolSummarizeDocument,
+		Desc: summarizeDocumentToolDesc,
+		ParamsOneOf: schema.NewParamsOneOfByParams(map[string]*schema.ParameterInfo{
+			"document_id": {
+				Type:     schema.String,
+				Desc:     "文档 ID",
+				Required: true,
+			},
+			"refresh": {
+				Type: schema.Boolean,
+				Desc: "忽略缓存重新生成摘要",
+			},
+		}),
+	}, nil
+}
+
+// InvokableRun 执行文档摘要.
+func (t *SummarizeDocumentTool) InvokableRun(ctx context.Context, arguments string, opts ...tool.Option) (string, error) {
+	var input SummarizeDocumentInput
+	if err := json.Unmarshal([]byte(arguments), &input); err != nil {
+		return t.formatError(fmt.Sprintf("参数解析失败: %v", err)), nil
+	}
+
+	docID := strings.TrimSpace(input.DocumentID)
+	if docID == "" {
+		return t.formatError("document_id 参数不能为空"), nil
+	}
+
+	if t.service == nil {
+		return t.formatError("知识库服务未配置"), nil
+	}
+
+	// 缓存以文档 ID 和当前分块内容哈希为键，命中直接返回
+	req := &DocumentSummaryRequest{DocumentID: docID, KnowledgeBaseIDs: t.knowledgeBaseIDs, TenantID: t.tenantID}
+	cached, err := t.service.GetDocumentSummary(ctx, req)
+	if err != nil {
+		return t.formatError(fmt.Sprintf("获取文档失败: %v", err)), nil
+	}
+	if !input.Refresh && cached.Summary != "" {
+		return t.formatOutput(docID, cached.Summary, true), nil
+	}
+
+	if t.model == nil {
+		return t.formatError("摘要模型未配置"), nil
+	}
+
+	chunks, err := t.listAllChunks(ctx, docID)
+	if err != nil {
+		return t.formatError(fmt.Sprintf("获取分块失败: %v", err)), nil
+	}
+	if len(chunks) == 0 {
+		return t.formatError("文档没有可用的分块"), nil
+	}
+
+	summary, err := t.summarize(ctx, chunks[0].DocumentTitle, chunks)
+	if err != nil {
+		return t.formatError(fmt.Sprintf("生成摘要失败: %v", err)), nil
+	}
+
+	// 以生成前取得的内容哈希缓存，期间分块变化时缓存在下次调用时失效；缓存失败不影响本次结果
+	_ = t.service.SaveDocumentSummary(ctx, req, &DocumentSummary{Summary: summary, ContentHash: cached.ContentHash})
+
+	return t.formatOutput(docID, summary, false), nil
+}
+
+// listAllChunks 分页拉取文档的全部分块（按 chunk_index 升序）.
+func (t *SummarizeDocumentTool) listAllChunks(ctx context.Context, docID string) ([]*ChunkResult, error) {
+	var chunks []*ChunkResult
+	for offset := 0; ; offset += summarizeListPageSize {
+		result, err := t.service.ListChunks(ctx, &ListChunksRequest{
+			DocumentID: docID,
+			Limit:      summarizeListPageSize,
+			Offset:     offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, result.Chunks...)
+		if len(result.Chunks) < summarizeListPageSize || len(chunks) >= result.TotalCount {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+// summarize 执行 map-reduce 摘要.
+func (t *SummarizeDocumentTool) summarize(ctx context.Context, title string, chunks []*ChunkResult) (string, error) {
+	texts := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		texts = append(texts, c.Content)
+	}
+
+	// map: 分批摘要
+	summaries, err := t.mapSummaries(ctx, summarizeMapPrompt, title, batchTexts(texts, t.batchChars))
+	if err != nil {
+		return "", err
+	}
+
+	// reduce: 逐轮合并摘要，直到只剩一份
+	for len(summaries) > 1 {
+		batches := batchTexts(summaries, t.batchChars)
+		if len(batches) == len(summaries) {
+			// 每条摘要都已超过单批上限，强制两两合并以保证收敛
+			batches = pairTexts(summaries)
+		}
+		summaries, err = t.mapSummaries(ctx, summarizeReducePrompt, title, batches)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return summaries[0], nil
+}
+
+// mapSummaries 以有限并发对每个批次调用模型生成摘要.
+func (t *SummarizeDocumentTool) mapSummaries(ctx context.Context, prompt, title string, batches []string) ([]string, error) {
+	results := make([]string, len(batches))
+	errs := make([]error, len(batches))
+
+	sem := make(chan struct{}, t.concurrency)
+	var wg sync.WaitGroup
+
+	for i, batch := range batches {
+		wg.Add(1)
+		go func(index int, text string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[index] = ctx.Err()
+				return
+			}
+			results[index], errs[index] = t.generate(ctx, fmt.Sprintf(prompt, title, text))
+		}(i, batch)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// generate 调用模型并提取文本输出.
+func (t *SummarizeDocumentTool) generate(ctx context.Context, prompt string) (string, error) {
+	msg, err := t.model.Generate(ctx, []*schema.AgenticMessage{schema.UserAgenticMessage(prompt)})
+	if err != nil {
+		return "", err
+	}
+	text := agenticMessageText(msg)
+	if text == "" {
+		return "", fmt.Errorf("empty model output")
+	}
+	return text, nil
+}
+
+func (t *SummarizeDocumentTool) formatOutput(docID, summary string, cached bool) string {
+	var sb strings.Builder
+	sb.WriteString("=== 文档摘要 ===\n")
+	sb.WriteString(fmt.Sprintf("文档ID: %s\n", docID))
+	if cached {
+		sb.WriteString("来源: 缓存\n")
+	}
+	sb.WriteString(fmt.Sprintf("\n%s\n", summary))
+	return sb.String()
+}
+
+func (t *SummarizeDocumentTool) formatError(errMsg string) string {
+	return fmt.Sprintf("=== 文档摘要错误 ===\nError: %s\n", errMsg)
+}
+
+// batchTexts 按字符上限将文本顺序合并为批次，单条超限的文本独占一个批次.
+func batchTexts(texts []string, maxChars int) []string {
+	var batches []string
+	var sb strings.Builder
+	for _, text := range texts {
+		if sb.Len() > 0 && sb.Len()+len(text) > maxChars {
+			batches = append(batches, sb.String())
+			sb.Reset()
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(text)
+	}
+	if sb.Len() > 0 {
+		batches = append(batches, sb.String())
+	}
+	return batches
+}
+
+// pairTexts 将文本两两合并.
+func pairTexts(texts []string) []string {
+	pairs := make([]string, 0, (len(texts)+1)/2)
+	for i := 0; i < len(texts); i += 2 {
+		if i+1 < len(texts) {
+			pairs = append(pairs, texts[i]+"\n\n"+texts[i+1])
+		} else {
+			pairs = append(pairs, texts[i])
+		}
+	}
+	return pairs
+}
+
+// agenticMessageText 提取 AgenticMessage 中的生成文本.
+func agenticMessageText(msg *schema.AgenticMessage) string {
+	if msg == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, block := range msg.ContentBlocks {
+		if block != nil && block.Type == schema.ContentBlockTypeAssistantGenText && block.AssistantGenText != nil {
+			sb.WriteString(block.AssistantGenText.Text)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+var _ tool.InvokableTool = (*SummarizeDocumentTool)(nil)
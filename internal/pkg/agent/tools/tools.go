@@ -109,6 +109,12 @@ func (r *ToolRegistry) RegisterListKnowledgeChunksTool(config *ListKnowledgeChun
 	return r.Register(t)
 }
 
+// RegisterSummarizeDocumentTool 注册文档摘要工具.
+func (r *ToolRegistry) RegisterSummarizeDocumentTool(config *SummarizeDocumentConfig) error {
+	t := NewSummarizeDocumentTool(config)
+	return r.Register(t)
+}
+
 // DefaultRegistry 创建并初始化默认工具注册表.
 func DefaultRegistry() (*ToolRegistry, error) {
 	r := NewToolRegistry()
@@ -2,9 +2,14 @@
 package tools
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"path"
 	"strings"
 	"sync"
 	"time"
@@ -12,9 +17,24 @@ import (
 	browseruse "github.com/cloudwego/eino-ext/components/tool/browseruse"
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/pkg/docparse"
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
 )
 
-const webFetchTimeout = 120 * time.Second
+const (
+	webFetchTimeout     = 120 * time.Second
+	webFetchItemTimeout = 60 * time.Second
+	webFetchConcurrency = 5
+
+	webFetchMaxAttempts  = 3
+	webFetchRetryBackoff = 500 * time.Millisecond
+	webFetchMaxBackoff   = 10 * time.Second
+
+	webFetchMaxBodySize     = 10 << 20
+	webFetchMaxContentChars = 50000
+	webFetchUserAgent       = "Mozilla/5.0 (compatible; next-show-web-fetch/1.0)"
+)
 
 const webFetchToolDesc = `抓取网页的完整内容（支持动态渲染）。
 
@@ -27,16 +47,31 @@ const webFetchToolDesc = `抓取网页的完整内容（支持动态渲染）。
 - items: 批量抓取任务，每项包含 url 与 prompt（prompt 可用于描述你希望从页面中提取的内容）
 
 ## 返回
-- 每个 URL 的抓取结果（可能截断），并给出下一步建议
+- 每个 URL 的标题和正文（自动去除菜单、页脚等样板内容，可能截断），并给出下一步建议
+- PDF、DOCX、XLSX 等文档链接会解析为文本返回
 
 ## 注意
 - 返回结果可能仍会因长度被截断
-- 本工具使用浏览器渲染，可以获取 JavaScript 生成的内容
-- 批量处理时会依次处理每个 URL`
+- 网络错误和 5xx 响应会自动重试，4xx 响应不会重试
+- 未提供 prompt 时优先直接提取正文；无法提取时使用浏览器渲染，可以获取 JavaScript 生成的内容
+- 批量处理时会并发抓取（有并发上限），结果按输入顺序返回`
 
 // WebFetchConfig 网页抓取配置.
 type WebFetchConfig struct {
-	Timeout          time.Duration `json:"timeout"`
+	// Timeout 整次调用的超时时间
+	Timeout time.Duration `json:"timeout"`
+	// ItemTimeout 单个 URL 的抓取超时时间，与整体超时独立，默认 60s
+	ItemTimeout time.Duration `json:"item_timeout"`
+	// Concurrency 同时抓取的 URL 数上限，默认 5
+	Concurrency int `json:"concurrency"`
+	// MaxAttempts 单个 URL 的最大尝试次数（含首次），默认 3
+	MaxAttempts int `json:"max_attempts"`
+	// RetryBackoff 首次重试前的等待时间，之后按指数增长，默认 500ms
+	RetryBackoff time.Duration `json:"retry_backoff"`
+	// Retry 重试策略，设置后取代 MaxAttempts 和 RetryBackoff；是否可重试始终由抓取错误类型决定
+	Retry *retry.Policy `json:"-"`
+	// MaxBodySize 单个 URL 下载内容的最大字节数，超过时文档解析失败、网页内容截断，默认 10MB
+	MaxBodySize      int64         `json:"max_body_size"`
 	Headless         bool          `json:"headless"`
 	ChromePath       string        `json:"chrome_path"`
 	ExtractChatModel tool.BaseTool `json:"-"` // 可选：用于智能提取内容的模型
@@ -45,8 +80,13 @@ type WebFetchConfig struct {
 // DefaultWebFetchConfig 默认配置.
 func DefaultWebFetchConfig() *WebFetchConfig {
 	return &WebFetchConfig{
-		Timeout:  webFetchTimeout,
-		Headless: true,
+		Timeout:      webFetchTimeout,
+		ItemTimeout:  webFetchItemTimeout,
+		Concurrency:  webFetchConcurrency,
+		MaxAttempts:  webFetchMaxAttempts,
+		RetryBackoff: webFetchRetryBackoff,
+		MaxBodySize:  webFetchMaxBodySize,
+		Headless:     true,
 	}
 }
 
@@ -62,13 +102,16 @@ type WebFetchItem struct {
 }
 
 type webFetchItemResult struct {
-	output string
-	err    error
+	output   string
+	err      error
+	attempts int
 }
 
 // WebFetchTool 基于 browseruse 的网页抓取工具.
 type WebFetchTool struct {
 	config *WebFetchConfig
+	client *http.Client
+	retry  *retry.Policy
 }
 
 // NewWebFetchTool 创建 web_fetch 工具.
@@ -79,7 +122,34 @@ func NewWebFetchTool(config *WebFetchConfig) *WebFetchTool {
 	if config.Timeout == 0 {
 		config.Timeout = webFetchTimeout
 	}
-	return &WebFetchTool{config: config}
+	if config.ItemTimeout == 0 {
+		config.ItemTimeout = webFetchItemTimeout
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = webFetchConcurrency
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = webFetchMaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = webFetchRetryBackoff
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = webFetchMaxBodySize
+	}
+	policy := config.Retry
+	if policy == nil {
+		policy = &retry.Policy{
+			MaxAttempts:    config.MaxAttempts,
+			InitialBackoff: config.RetryBackoff,
+			MaxBackoff:     webFetchMaxBackoff,
+		}
+	}
+	return &WebFetchTool{
+		config: config,
+		client: &http.Client{},
+		retry:  policy.WithRetryable(isRetryableFetchError),
+	}
 }
 
 // Info 返回工具信息.
@@ -120,18 +190,34 @@ func (t *WebFetchTool) InvokableRun(ctx context.Context, arguments string, opts
 		return t.formatError("missing required parameter: items"), nil
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, t.config.Timeout)
+	defer cancel()
+
+	// 通过信号量限制并发抓取数，结果按输入顺序写入
 	results := make([]*webFetchItemResult, len(input.Items))
+	sem := make(chan struct{}, t.config.Concurrency)
 	var wg sync.WaitGroup
 
 	for idx := range input.Items {
-		i := idx
-		item := input.Items[i]
 		wg.Add(1)
 		go func(index int, it WebFetchItem) {
 			defer wg.Done()
-			result := t.fetchSingleURL(ctx, it)
-			results[index] = result
-		}(i, item)
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[index] = &webFetchItemResult{
+					output: fmt.Sprintf("URL: %s\n错误: %v\n", it.URL, ctx.Err()),
+					err:    ctx.Err(),
+				}
+				return
+			}
+
+			itemCtx, itemCancel := context.WithTimeout(ctx, t.config.ItemTimeout)
+			defer itemCancel()
+			results[index] = t.fetchSingleURL(itemCtx, it)
+		}(idx, input.Items[idx])
 	}
 
 	wg.Wait()
@@ -160,6 +246,150 @@ func (t *WebFetchTool) fetchSingleURL(ctx context.Context, item WebFetchItem) *w
 		}
 	}
 
+	var result *webFetchItemResult
+	attempts := 0
+	_ = t.retry.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		result = t.fetchOnce(ctx, url, prompt)
+		result.attempts = attempts
+		return result.err
+	})
+	if result.attempts > 1 {
+		result.output = fmt.Sprintf("Attempts: %d\n%s", result.attempts, result.output)
+	}
+	return result
+}
+
+// fetchOnce 单次抓取.
+// 先通过 HTTP 获取内容：PDF、DOCX 等文档解析为文本；HTML 在未指定 prompt 时提取正文；
+// 其余情况（包括正文提取失败）回退到浏览器渲染.
+func (t *WebFetchTool) fetchOnce(ctx context.Context, url, prompt string) *webFetchItemResult {
+	page, err := t.fetchHTML(ctx, url)
+	if err != nil {
+		var statusErr *webFetchStatusError
+		if errors.As(err, &statusErr) {
+			return &webFetchItemResult{
+				output: fmt.Sprintf("URL: %s\n错误: %v\n", url, err),
+				err:    err,
+			}
+		}
+		return t.browserFetch(ctx, url, prompt)
+	}
+
+	if ext := documentExtension(url, page.contentType); ext != "" {
+		return t.parseDocument(ctx, url, prompt, ext, page)
+	}
+
+	if prompt == "" && strings.Contains(page.contentType, "html") {
+		if article, ok := extractReadable(bytes.NewReader(page.body)); ok {
+			return &webFetchItemResult{
+				output: buildWebFetchOutput(url, article.Title, prompt, article.Content, false),
+			}
+		}
+	}
+	return t.browserFetch(ctx, url, prompt)
+}
+
+// parseDocument 解析 PDF、DOCX 等文档内容.
+func (t *WebFetchTool) parseDocument(ctx context.Context, url, prompt, ext string, page *fetchedPage) *webFetchItemResult {
+	if page.truncated {
+		err := fmt.Errorf("document exceeds size limit of %d bytes", t.config.MaxBodySize)
+		return &webFetchItemResult{
+			output: fmt.Sprintf("URL: %s\n错误: %v\n", url, err),
+			err:    err,
+		}
+	}
+
+	fileName := path.Base(strings.SplitN(url, "?", 2)[0])
+	if !strings.EqualFold(path.Ext(fileName), ext) {
+		fileName += ext
+	}
+
+	docs, err := docparse.Parse(ctx, fileName, bytes.NewReader(page.body))
+	if err != nil {
+		return &webFetchItemResult{
+			output: fmt.Sprintf("URL: %s\n错误: 解析文档失败: %v\n", url, err),
+			err:    fmt.Errorf("failed to parse document: %w", err),
+		}
+	}
+
+	parts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		if text := strings.TrimSpace(doc.Content); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	content := strings.Join(parts, "\n\n")
+
+	truncated := false
+	if runes := []rune(content); len(runes) > webFetchMaxContentChars {
+		content = string(runes[:webFetchMaxContentChars])
+		truncated = true
+	}
+
+	return &webFetchItemResult{
+		output: buildWebFetchOutput(url, fileName, prompt, content, truncated),
+	}
+}
+
+// documentExtension 根据 Content-Type（无法识别时根据 URL 扩展名）判断是否为可解析的文档.
+func documentExtension(url, contentType string) string {
+	if ext := docparse.ExtensionForContentType(contentType); ext != "" {
+		return ext
+	}
+	if contentType == "" || strings.HasPrefix(contentType, "application/octet-stream") {
+		name := strings.SplitN(url, "?", 2)[0]
+		if docparse.Supported(name) {
+			return strings.ToLower(path.Ext(name))
+		}
+	}
+	return ""
+}
+
+// fetchedPage HTTP 抓取结果.
+type fetchedPage struct {
+	body        []byte
+	contentType string
+	// truncated 内容超过 MaxBodySize 被截断
+	truncated bool
+}
+
+// fetchHTML 通过 HTTP GET 获取页面内容，4xx/5xx 返回 webFetchStatusError.
+func (t *WebFetchTool) fetchHTML(ctx context.Context, url string) (*fetchedPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", webFetchUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/pdf,*/*;q=0.8")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, &webFetchStatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.config.MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	page := &fetchedPage{
+		body:        body,
+		contentType: strings.ToLower(resp.Header.Get("Content-Type")),
+	}
+	if int64(len(body)) > t.config.MaxBodySize {
+		page.body = body[:t.config.MaxBodySize]
+		page.truncated = true
+	}
+	return page, nil
+}
+
+// browserFetch 使用浏览器抓取单个 URL（单次尝试）.
+func (t *WebFetchTool) browserFetch(ctx context.Context, url, prompt string) *webFetchItemResult {
 	// 创建 browseruse 工具配置
 	browserConfig := &browseruse.Config{
 		Headless: t.config.Headless,
@@ -205,13 +435,34 @@ func (t *WebFetchTool) fetchSingleURL(ctx context.Context, item WebFetchItem) *w
 		}
 	}
 
-	output := buildWebFetchOutput(url, prompt, content, false)
+	output := buildWebFetchOutput(url, "", prompt, content, false)
 	return &webFetchItemResult{
 		output: output,
 		err:    nil,
 	}
 }
 
+// webFetchStatusError 带 HTTP 状态码的抓取错误.
+type webFetchStatusError struct {
+	StatusCode int
+}
+
+func (e *webFetchStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// isRetryableFetchError 判断抓取错误是否可重试：4xx 与 ctx 结束不重试，5xx 与网络错误重试.
+func isRetryableFetchError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr *webFetchStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
 // extractContent 提取页面内容.
 func (t *WebFetchTool) extractContent(ctx context.Context, browserTool *browseruse.Tool, prompt string) (string, error) {
 	// 如果有 prompt，使用智能提取
@@ -288,9 +539,12 @@ func (t *WebFetchTool) formatError(errMsg string) string {
 	return sb.String()
 }
 
-func buildWebFetchOutput(url, prompt, content string, truncated bool) string {
+func buildWebFetchOutput(url, title, prompt, content string, truncated bool) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("URL: %s\n", url))
+	if title != "" {
+		sb.WriteString(fmt.Sprintf("Title: %s\n", title))
+	}
 	if prompt != "" {
 		sb.WriteString(fmt.Sprintf("Prompt: %s\n", prompt))
 	}
@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// articlePage 返回正文足够长、可直接提取的文章页面.
+func articlePage(title string) string {
+	paragraph := strings.Repeat("This paragraph explains how the refund policy works for every order. ", 5)
+	return fmt.Sprintf(`<html><head><title>%s</title></head><body><article><p>%s</p><p>%s</p></article></body></html>`,
+		title, paragraph, paragraph)
+}
+
+// fetchArguments 返回抓取 urls 的 web_fetch 参数.
+func fetchArguments(t *testing.T, urls ...string) string {
+	t.Helper()
+	input := WebFetchInput{}
+	for _, u := range urls {
+		input.Items = append(input.Items, WebFetchItem{URL: u})
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		t.Fatalf("marshal arguments: %v", err)
+	}
+	return string(data)
+}
+
+func TestWebFetchConcurrencyLimit(t *testing.T) {
+	const limit = 2
+	var inFlight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, articlePage(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	webFetch := NewWebFetchTool(&WebFetchConfig{Concurrency: limit})
+	var urls []string
+	for i := 0; i < 6; i++ {
+		urls = append(urls, fmt.Sprintf("%s/page%d", srv.URL, i))
+	}
+	output, err := webFetch.InvokableRun(context.Background(), fetchArguments(t, urls...))
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if !strings.Contains(output, "成功抓取 6/6") {
+		t.Fatalf("output = %q, want every page fetched", output)
+	}
+	if got := peak.Load(); got > limit {
+		t.Errorf("peak in-flight requests = %d, want at most %d", got, limit)
+	} else if got < 2 {
+		t.Errorf("peak in-flight requests = %d, want pages fetched concurrently", got)
+	}
+	// 结果按输入顺序返回
+	if strings.Index(output, "Title: /page0") > strings.Index(output, "Title: /page5") {
+		t.Errorf("output = %q, want results in input order", output)
+	}
+}
+
+func TestWebFetchRetriesUntilSuccess(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, articlePage("Refunds"))
+	}))
+	defer srv.Close()
+
+	webFetch := NewWebFetchTool(&WebFetchConfig{MaxAttempts: 3, RetryBackoff: time.Millisecond})
+	output, err := webFetch.InvokableRun(context.Background(), fetchArguments(t, srv.URL))
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server received %d requests, want 3", got)
+	}
+	if !strings.Contains(output, "Attempts: 3") || !strings.Contains(output, "Title: Refunds") || !strings.Contains(output, "成功抓取 1/1") {
+		t.Errorf("output = %q, want the page fetched on the third attempt", output)
+	}
+}
+
+func TestWebFetchDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	webFetch := NewWebFetchTool(&WebFetchConfig{MaxAttempts: 3, RetryBackoff: time.Millisecond})
+	output, err := webFetch.InvokableRun(context.Background(), fetchArguments(t, srv.URL))
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server received %d requests, want no retries", got)
+	}
+	if !strings.Contains(output, "unexpected status 404") {
+		t.Errorf("output = %q, want the status error", output)
+	}
+}
+
+// minimalPDF 生成单页、包含 text 的 PDF 文档.
+func minimalPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var sb strings.Builder
+	sb.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = sb.Len()
+		fmt.Fprintf(&sb, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := sb.Len()
+	fmt.Fprintf(&sb, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&sb, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&sb, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return []byte(sb.String())
+}
+
+func TestWebFetchParsesPDF(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write(minimalPDF("Refunds are processed within three business days."))
+	}))
+	defer srv.Close()
+
+	webFetch := NewWebFetchTool(nil)
+	// URL 没有扩展名，按 Content-Type 识别为 PDF
+	output, err := webFetch.InvokableRun(context.Background(), fetchArguments(t, srv.URL+"/policy"))
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if !strings.Contains(output, "Refunds are processed within three business days.") {
+		t.Errorf("output = %q, want the PDF text", output)
+	}
+	if !strings.Contains(output, "Title: policy.pdf") || strings.Contains(output, "%PDF") {
+		t.Errorf("output = %q, want parsed text instead of raw PDF bytes", output)
+	}
+}
+
+func TestWebFetchRejectsOversizedDocuments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write(minimalPDF(strings.Repeat("large ", 100)))
+	}))
+	defer srv.Close()
+
+	webFetch := NewWebFetchTool(&WebFetchConfig{MaxBodySize: 64, MaxAttempts: 1})
+	output, err := webFetch.InvokableRun(context.Background(), fetchArguments(t, srv.URL+"/large.pdf"))
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if !strings.Contains(output, "document exceeds size limit of 64 bytes") {
+		t.Errorf("output = %q, want the size limit error", output)
+	}
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+	"github.com/eino-contrib/jsonschema"
+)
+
+// CustomToolTypeWebhook 自定义工具类型：将参数 POST 到配置的 URL.
+const CustomToolTypeWebhook = "webhook"
+
+const (
+	webhookDefaultTimeout = 30 * time.Second
+	webhookMaxTimeout     = 10 * time.Minute
+	webhookMaxBodySize    = 1 << 20
+	webhookMaxErrorChars  = 1000
+	webhookUserAgent      = "next-show-webhook-tool/1.0"
+)
+
+// webhookToolName 工具名称只允许字母、数字、下划线和连字符，与模型工具调用的命名要求一致.
+var webhookToolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ErrInvalidCustomTool 自定义工具配置不合法.
+var ErrInvalidCustomTool = errors.New("invalid custom tool config")
+
+// WebhookToolConfig HTTP Webhook 工具配置，对应 AgentTool.CustomToolConfig.
+type WebhookToolConfig struct {
+	// Type 自定义工具类型，为空时为 webhook
+	Type        string `json:"type,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Schema 参数的 JSON Schema（object 类型），为空时工具无参数
+	Schema map[string]any `json:"schema,omitempty"`
+	// URL 接收调用的地址，调用参数以 JSON 请求体 POST，响应体原样返回给模型
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// TimeoutSeconds 单次调用的超时时间，<=0 时为 30 秒
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+}
+
+// ParseWebhookToolConfig 从 CustomToolConfig 解析并校验 Webhook 工具配置.
+func ParseWebhookToolConfig(raw map[string]any) (*WebhookToolConfig, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: config is empty", ErrInvalidCustomTool)
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCustomTool, err)
+	}
+	var cfg WebhookToolConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCustomTool, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate 校验配置，返回的错误列出所有问题.
+func (c *WebhookToolConfig) Validate() error {
+	var problems []string
+	if c.Type != "" && c.Type != CustomToolTypeWebhook {
+		problems = append(problems, fmt.Sprintf("unsupported type %q", c.Type))
+	}
+	switch {
+	case c.Name == "":
+		problems = append(problems, "name is required")
+	case !webhookToolName.MatchString(c.Name):
+		problems = append(problems, "name must be 1-64 letters, digits, underscores or hyphens")
+	}
+	if strings.TrimSpace(c.Description) == "" {
+		problems = append(problems, "description is required")
+	}
+	if c.URL == "" {
+		problems = append(problems, "url is required")
+	} else if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, "url must be an absolute http(s) url")
+	}
+	if c.TimeoutSeconds < 0 || time.Duration(c.TimeoutSeconds)*time.Second > webhookMaxTimeout {
+		problems = append(problems, fmt.Sprintf("timeout_seconds must be between 0 and %d", int(webhookMaxTimeout.Seconds())))
+	}
+	if len(c.Schema) > 0 {
+		if _, err := c.jsonSchema(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidCustomTool, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// jsonSchema 解析参数 Schema，顶层必须为 object 类型.
+func (c *WebhookToolConfig) jsonSchema() (*jsonschema.Schema, error) {
+	if typ, _ := c.Schema["type"].(string); typ != "object" {
+		return nil, fmt.Errorf("schema type must be object")
+	}
+	data, err := json.Marshal(c.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	var s jsonschema.Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return &s, nil
+}
+
+// webhookError 调用失败时返回给模型的结构化错误，不中断 Agent 运行.
+type webhookError struct {
+	Error  string `json:"error"`
+	Tool   string `json:"tool"`
+	Status int    `json:"status,omitempty"`
+}
+
+// WebhookTool 将调用参数 POST 到配置 URL 的自定义工具.
+type WebhookTool struct {
+	config  *WebhookToolConfig
+	info    *schema.ToolInfo
+	client  *http.Client
+	timeout time.Duration
+}
+
+// NewWebhookTool 按配置创建 Webhook 工具.
+func NewWebhookTool(config *WebhookToolConfig) (*WebhookTool, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	info := &schema.ToolInfo{Name: config.Name, Desc: config.Description}
+	if len(config.Schema) > 0 {
+		s, err := config.jsonSchema()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCustomTool, err)
+		}
+		info.ParamsOneOf = schema.NewParamsOneOfByJSONSchema(s)
+	}
+	timeout := webhookDefaultTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	return &WebhookTool{config: config, info: info, client: &http.Client{}, timeout: timeout}, nil
+}
+
+// Info 返回工具信息.
+func (t *WebhookTool) Info(_ context.Context) (*schema.ToolInfo, error) {
+	return t.info, nil
+}
+
+// InvokableRun 将参数 POST 到配置的 URL 并返回响应体.
+// 网络错误、超时和非 2xx 响应以结构化错误字符串返回，只有调用方 ctx 结束时返回错误.
+func (t *WebhookTool) InvokableRun(ctx context.Context, argumentsInJSON string, _ ...tool.Option) (string, error) {
+	body := strings.TrimSpace(argumentsInJSON)
+	if body == "" {
+		body = "{}"
+	}
+	if !json.Valid([]byte(body)) {
+		return t.failure("arguments are not valid json", 0), nil
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, t.config.URL, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return t.failure(err.Error(), 0), nil
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	for k, v := range t.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if reqCtx.Err() != nil {
+			return t.failure(fmt.Sprintf("webhook timed out after %s", t.timeout), 0), nil
+		}
+		return t.failure(err.Error(), 0), nil
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, webhookMaxBodySize+1))
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return t.failure(fmt.Sprintf("read response: %v", err), resp.StatusCode), nil
+	}
+	text := string(data)
+	if len(data) > webhookMaxBodySize {
+		text = string(data[:webhookMaxBodySize]) + "\n...(truncated)"
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := fmt.Sprintf("webhook returned %s", resp.Status)
+		if s := []rune(strings.TrimSpace(text)); len(s) > 0 {
+			if len(s) > webhookMaxErrorChars {
+				s = append(s[:webhookMaxErrorChars], []rune("...")...)
+			}
+			msg += ": " + string(s)
+		}
+		return t.failure(msg, resp.StatusCode), nil
+	}
+	return text, nil
+}
+
+// failure 生成调用失败时返回给模型的结果.
+func (t *WebhookTool) failure(msg string, status int) string {
+	data, _ := json.Marshal(webhookError{Error: msg, Tool: t.config.Name, Status: status})
+	return string(data)
+}
+
+var _ tool.InvokableTool = (*WebhookTool)(nil)
@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// webhookConfig 返回可用的 Webhook 工具配置.
+func webhookConfig(url string) map[string]any {
+	return map[string]any{
+		"name":        "lookup_order",
+		"description": "按订单号查询订单状态",
+		"url":         url,
+		"headers":     map[string]any{"Authorization": "Bearer secret"},
+		"schema": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"order_id": map[string]any{"type": "string"}},
+			"required":   []any{"order_id"},
+		},
+	}
+}
+
+func TestParseWebhookToolConfigValidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(cfg map[string]any)
+		problem string
+	}{
+		{"missing name", func(cfg map[string]any) { delete(cfg, "name") }, "name is required"},
+		{"invalid name", func(cfg map[string]any) { cfg["name"] = "lookup order" }, "name must be"},
+		{"missing description", func(cfg map[string]any) { cfg["description"] = " " }, "description is required"},
+		{"missing url", func(cfg map[string]any) { delete(cfg, "url") }, "url is required"},
+		{"relative url", func(cfg map[string]any) { cfg["url"] = "/orders" }, "absolute http(s) url"},
+		{"unsupported scheme", func(cfg map[string]any) { cfg["url"] = "ftp://example.com/orders" }, "absolute http(s) url"},
+		{"unsupported type", func(cfg map[string]any) { cfg["type"] = "grpc" }, `unsupported type "grpc"`},
+		{"negative timeout", func(cfg map[string]any) { cfg["timeout_seconds"] = -1 }, "timeout_seconds"},
+		{"non-object schema", func(cfg map[string]any) { cfg["schema"] = map[string]any{"type": "string"} }, "schema type must be object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := webhookConfig("https://example.com/orders")
+			tt.mutate(cfg)
+			_, err := ParseWebhookToolConfig(cfg)
+			if !errors.Is(err, ErrInvalidCustomTool) || !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("ParseWebhookToolConfig() error = %v, want %q", err, tt.problem)
+			}
+		})
+	}
+
+	// 所有问题一起返回
+	_, err := ParseWebhookToolConfig(map[string]any{"type": "webhook"})
+	for _, problem := range []string{"name is required", "description is required", "url is required"} {
+		if err == nil || !strings.Contains(err.Error(), problem) {
+			t.Errorf("empty config error = %v, want %q", err, problem)
+		}
+	}
+	if _, err := ParseWebhookToolConfig(nil); !errors.Is(err, ErrInvalidCustomTool) {
+		t.Errorf("nil config error = %v, want ErrInvalidCustomTool", err)
+	}
+}
+
+func TestWebhookToolPostsArguments(t *testing.T) {
+	var got struct {
+		method, contentType, auth string
+		body                      map[string]any
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method = r.Method
+		got.contentType = r.Header.Get("Content-Type")
+		got.auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &got.body)
+		_, _ = io.WriteString(w, `{"status":"shipped"}`)
+	}))
+	defer srv.Close()
+
+	cfg, err := ParseWebhookToolConfig(webhookConfig(srv.URL))
+	if err != nil {
+		t.Fatalf("ParseWebhookToolConfig: %v", err)
+	}
+	webhook, err := NewWebhookTool(cfg)
+	if err != nil {
+		t.Fatalf("NewWebhookTool: %v", err)
+	}
+	info, _ := webhook.Info(context.Background())
+	if info.Name != "lookup_order" || info.Desc != "按订单号查询订单状态" || info.ParamsOneOf == nil {
+		t.Errorf("info = %+v, want name, description and schema from the config", info)
+	}
+
+	output, err := webhook.InvokableRun(context.Background(), `{"order_id":"A-42"}`)
+	if err != nil {
+		t.Fatalf("InvokableRun: %v", err)
+	}
+	if output != `{"status":"shipped"}` {
+		t.Errorf("output = %q, want the response body", output)
+	}
+	if got.method != http.MethodPost || got.contentType != "application/json" || got.auth != "Bearer secret" || got.body["order_id"] != "A-42" {
+		t.Errorf("request = %s %s auth=%q body=%v, want JSON arguments posted with configured headers", got.method, got.contentType, got.auth, got.body)
+	}
+}
+
+func TestWebhookToolReportsFailures(t *testing.T) {
+	// 未读完请求体时 Server 感知不到客户端断开，关闭前先放行慢请求
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+		http.Error(w, "order service down", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	failure := func(t *testing.T, url string, timeout int, args string) webhookError {
+		t.Helper()
+		webhook, err := NewWebhookTool(&WebhookToolConfig{Name: "lookup_order", Description: "查询订单", URL: url, TimeoutSeconds: timeout})
+		if err != nil {
+			t.Fatalf("NewWebhookTool: %v", err)
+		}
+		output, err := webhook.InvokableRun(context.Background(), args)
+		if err != nil {
+			t.Fatalf("InvokableRun error = %v, want the failure reported to the model", err)
+		}
+		var e webhookError
+		if err := json.Unmarshal([]byte(output), &e); err != nil || e.Tool != "lookup_order" {
+			t.Fatalf("output = %q, want a structured error", output)
+		}
+		return e
+	}
+
+	if e := failure(t, srv.URL, 0, `{}`); e.Status != http.StatusBadGateway || !strings.Contains(e.Error, "order service down") {
+		t.Errorf("error response = %+v, want status and body", e)
+	}
+	if e := failure(t, srv.URL, 0, `not json`); !strings.Contains(e.Error, "not valid json") {
+		t.Errorf("invalid arguments = %+v", e)
+	}
+	if e := failure(t, srv.URL+"/slow", 1, `{}`); !strings.Contains(e.Error, "timed out after 1s") {
+		t.Errorf("slow webhook = %+v, want timeout", e)
+	}
+
+	// 调用方取消时返回错误
+	webhook, _ := NewWebhookTool(&WebhookToolConfig{Name: "lookup_order", Description: "查询订单", URL: srv.URL + "/slow"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := webhook.InvokableRun(ctx, `{}`); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled InvokableRun error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package blob 提供原始文件的对象存储抽象（本地文件系统、S3 等）.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/pkg/s3"
+)
+
+// 存储类型.
+const (
+	TypeLocal = "local"
+	TypeS3    = "s3"
+)
+
+// ErrNotFound 对象不存在.
+var ErrNotFound = errors.New("blob not found")
+
+// Store 对象存储接口，key 为与存储无关的相对路径（如 <kbID>/<docID>/<filename>）.
+type Store interface {
+	// Put 写入对象，size 未知时传 -1.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，不存在时返回 ErrNotFound，使用完毕后需关闭.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，不存在时不报错.
+	Delete(ctx context.Context, key string) error
+}
+
+// Size 返回 Get 得到的对象内容的字节数，无法确定时返回 -1.
+func Size(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Size() int64 }:
+		return v.Size()
+	case *os.File:
+		if info, err := v.Stat(); err == nil && info.Mode().IsRegular() {
+			return info.Size()
+		}
+	}
+	return -1
+}
+
+// Pather 可直接提供本地文件路径的存储.
+type Pather interface {
+	Path(key string) (string, error)
+}
+
+// URLSigner 可生成限时下载地址的存储.
+type URLSigner interface {
+	// SignedURL 返回 expires 内有效的下载地址，fileName、contentType 用于覆盖下载响应头.
+	SignedURL(ctx context.Context, key string, expires time.Duration, fileName, contentType string) (string, error)
+}
+
+// Config 存储配置.
+type Config struct {
+	// Type 存储类型：local（默认）/ s3
+	Type string `mapstructure:"type"`
+	// LocalDir 本地存储目录，默认 data/files
+	LocalDir string `mapstructure:"local_dir"`
+	// S3 S3 存储配置
+	S3 s3.Config `mapstructure:"s3"`
+}
+
+// New 根据配置创建存储.
+func New(cfg *Config) (Store, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	switch cfg.Type {
+	case "", TypeLocal:
+		return NewLocalStore(cfg.LocalDir), nil
+	case TypeS3:
+		client, err := s3.New(&cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3Store(client), nil
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
+	}
+}
+
+// LocalPath 返回对象的本地文件路径.
+// 存储不支持直接访问时下载到 cacheDir 下（已存在则复用），供 DuckDB 等需要本地文件的组件使用.
+func LocalPath(ctx context.Context, store Store, key, cacheDir string) (string, error) {
+	if p, ok := store.(Pather); ok {
+		path, err := p.Path(key)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				return "", fmt.Errorf("%w: %s", ErrNotFound, key)
+			}
+			return "", err
+		}
+		return path, nil
+	}
+
+	path, err := safeJoin(cacheDir, key)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("create cache directory: %w", err)
+	}
+	// 先写临时文件再重命名，避免并发读到不完整的文件
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return "", fmt.Errorf("create cache file: %w", err)
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("download %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("save cache file: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,101 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	local := NewLocalStore(t.TempDir())
+	ctx := context.Background()
+	if err := local.Put(ctx, "kb/doc/a.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	r, err := local.Get(ctx, "kb/doc/a.txt")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer r.Close()
+
+	tests := []struct {
+		name string
+		r    io.Reader
+		want int64
+	}{
+		{name: "local file", r: r, want: 5},
+		{name: "s3 body", r: &sizedBody{ReadCloser: io.NopCloser(strings.NewReader("abc")), size: 3}, want: 3},
+		{name: "s3 body without length", r: &sizedBody{ReadCloser: io.NopCloser(strings.NewReader("abc")), size: -1}, want: -1},
+		{name: "bytes reader", r: bytes.NewReader([]byte("abcd")), want: 4},
+		{name: "unknown", r: io.MultiReader(strings.NewReader("x")), want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Size(tt.r); got != tt.want {
+				t.Errorf("Size() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// memStore 不支持本地路径的内存存储，模拟 S3 等远程存储.
+type memStore struct {
+	objects map[string]string
+	gets    int
+}
+
+func (s *memStore) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.objects[key] = string(data)
+	return nil
+}
+
+func (s *memStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	s.gets++
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func (s *memStore) Delete(_ context.Context, key string) error {
+	delete(s.objects, key)
+	return nil
+}
+
+func TestLocalPathDownloadsRemoteObject(t *testing.T) {
+	store := &memStore{objects: map[string]string{"kb/doc/data.csv": "a,b\n1,2\n"}}
+	cacheDir := t.TempDir()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		p, err := LocalPath(ctx, store, "kb/doc/data.csv", cacheDir)
+		if err != nil {
+			t.Fatalf("LocalPath() error = %v", err)
+		}
+		if want := filepath.Join(cacheDir, "kb", "doc", "data.csv"); p != want {
+			t.Fatalf("LocalPath() = %q, want %q", p, want)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil || string(data) != "a,b\n1,2\n" {
+			t.Fatalf("cached file = %q, %v", data, err)
+		}
+	}
+	// 第二次直接复用缓存
+	if store.gets != 1 {
+		t.Errorf("Get called %d times, want 1", store.gets)
+	}
+
+	if _, err := LocalPath(ctx, store, "kb/doc/missing.csv", cacheDir); !errors.Is(err, ErrNotFound) {
+		t.Errorf("LocalPath() for missing object error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,100 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultLocalDir 本地存储默认目录.
+const DefaultLocalDir = "data/files"
+
+// LocalStore 本地文件系统存储.
+type LocalStore struct {
+	baseDir string
+}
+
+// NewLocalStore 创建本地存储，baseDir 为空时使用 data/files.
+func NewLocalStore(baseDir string) *LocalStore {
+	if baseDir == "" {
+		baseDir = DefaultLocalDir
+	}
+	return &LocalStore{baseDir: baseDir}
+}
+
+// Path 返回对象的本地路径.
+func (s *LocalStore) Path(key string) (string, error) {
+	// 兼容旧版本直接保存的本地路径（data/files/<kbID>/<docID>/<filename>）
+	if rel, err := filepath.Rel(s.baseDir, key); err == nil && !strings.HasPrefix(rel, "..") && rel != "." {
+		key = filepath.ToSlash(rel)
+	}
+	return safeJoin(s.baseDir, key)
+}
+
+// Put 写入文件.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	path, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("write file: %w", err)
+	}
+	return f.Close()
+}
+
+// Get 打开文件.
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.Path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// Delete 删除文件并清理空目录.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.Path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// 删除 <kbID>/<docID> 等空目录，直到存储根目录
+	base := filepath.Clean(s.baseDir)
+	for dir := filepath.Dir(path); dir != base && strings.HasPrefix(dir, base); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// safeJoin 拼接目录和 key，拒绝越出目录的 key.
+func safeJoin(baseDir, key string) (string, error) {
+	cleaned := filepath.Clean("/" + filepath.FromSlash(key))
+	if cleaned == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(baseDir, cleaned), nil
+}
@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/pkg/s3"
+)
+
+// S3Store 基于 S3 的对象存储.
+type S3Store struct {
+	client *s3.Client
+}
+
+// NewS3Store 创建 S3 存储.
+func NewS3Store(client *s3.Client) *S3Store {
+	return &S3Store{client: client}
+}
+
+// Put 上传对象.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return s.client.Put(ctx, key, r, size, contentType)
+}
+
+// Get 下载对象.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.Get(ctx, key)
+	if err != nil {
+		if s3.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, err
+	}
+	return &sizedBody{ReadCloser: obj.Body, size: obj.Size}, nil
+}
+
+// sizedBody 带响应长度的对象内容，长度未知时为 -1.
+type sizedBody struct {
+	io.ReadCloser
+	size int64
+}
+
+// Size 返回对象字节数.
+func (b *sizedBody) Size() int64 {
+	return b.size
+}
+
+// Delete 删除对象.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.client.Delete(ctx, key)
+}
+
+// SignedURL 生成限时有效的预签名下载地址.
+func (s *S3Store) SignedURL(_ context.Context, key string, expires time.Duration, fileName, contentType string) (string, error) {
+	return s.client.PresignGet(key, expires, fileName, contentType)
+}
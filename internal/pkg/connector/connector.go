@@ -0,0 +1,241 @@
+// Package connector 提供外部文档源（S3、Notion、Confluence 等）的连接器，用于知识库自动同步.
+package connector
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultSignatureHeader 默认的 Webhook 签名请求头，值为 sha256=<hex(HMAC-SHA256(secret, body))>.
+const DefaultSignatureHeader = "X-Signature-256"
+
+// RedactedValue API 响应中替代密钥的占位值，更新时原样提交表示沿用已保存的密钥.
+const RedactedValue = "******"
+
+var (
+	// secretKeys sync_config 顶层的密钥字段.
+	secretKeys = []string{"webhook_secret"}
+	// secretOptionKeys options 中的密钥字段（S3 访问密钥等）.
+	secretOptionKeys = []string{"access_key_id", "secret_access_key"}
+)
+
+var (
+	// ErrInvalidSignature Webhook 签名校验失败.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrUnknownConnector 未注册的连接器类型.
+	ErrUnknownConnector = errors.New("unknown connector type")
+)
+
+// Action 文档变更类型.
+type Action string
+
+const (
+	ActionUpsert Action = "upsert" // 新增或更新
+	ActionDelete Action = "delete" // 删除
+)
+
+// ChangeEvent 文档变更通知.
+type ChangeEvent struct {
+	Action Action `json:"action"`
+	Key    string `json:"key"`
+}
+
+// Document 从外部源获取的文档.
+type Document struct {
+	Key         string
+	Name        string // 文件名（用于判断解析器）
+	Title       string
+	URI         string // 源地址，作为知识库文档的 SourceURI
+	ContentType string
+	Data        []byte
+}
+
+// Connector 外部文档源连接器.
+type Connector interface {
+	// Type 连接器类型.
+	Type() string
+	// VerifySignature 校验 Webhook 请求签名.
+	VerifySignature(header http.Header, body []byte) error
+	// ParseEvents 解析 Webhook 请求体中的变更通知.
+	ParseEvents(body []byte) ([]ChangeEvent, error)
+	// Fetch 获取变更的文档.
+	Fetch(ctx context.Context, key string) (*Document, error)
+	// List 列出源中的文档 Key（用于全量同步）.
+	List(ctx context.Context) ([]string, error)
+	// URI 返回文档 Key 对应的源地址.
+	URI(key string) string
+}
+
+// Config 连接器配置，保存在知识库的 sync_config 中.
+type Config struct {
+	// Type 连接器类型，如 s3
+	Type string `json:"type"`
+	// WebhookSecret Webhook 签名密钥，启用 Webhook 时必填
+	WebhookSecret string `json:"webhook_secret"`
+	// DisableWebhook 关闭 Webhook 通知，只通过手动同步拉取文档，此时无需 WebhookSecret
+	DisableWebhook bool `json:"disable_webhook"`
+	// SignatureHeader 签名请求头，默认 X-Signature-256
+	SignatureHeader string `json:"signature_header"`
+	// Options 连接器自身的配置（如 S3 的 bucket、region）
+	Options map[string]any `json:"options"`
+}
+
+// Factory 根据配置创建连接器.
+type Factory func(cfg *Config) (Connector, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register 注册连接器类型.
+func Register(connectorType string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[connectorType] = f
+}
+
+// ParseConfig 解析知识库的 sync_config.
+func ParseConfig(raw map[string]any) (*Config, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("marshal connector config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal connector config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// New 根据知识库的 sync_config 创建连接器.
+func New(raw map[string]any) (Connector, error) {
+	cfg, err := ParseConfig(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.RLock()
+	f, ok := factories[cfg.Type]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownConnector, cfg.Type)
+	}
+	return f(cfg)
+}
+
+// VerifyWebhook 校验 Webhook 请求：Webhook 被关闭时一律拒绝，否则校验 HMAC 签名.
+func (c *Config) VerifyWebhook(header http.Header, body []byte) error {
+	if c.DisableWebhook {
+		return fmt.Errorf("%w: webhook is disabled", ErrInvalidSignature)
+	}
+	return VerifyHMAC(c.WebhookSecret, c.SignatureHeader, header, body)
+}
+
+// Redact 返回隐藏了密钥字段的 sync_config 副本，用于 API 响应；未设置的密钥保持为空.
+func Redact(raw map[string]any) map[string]any {
+	if raw == nil {
+		return nil
+	}
+	out := make(map[string]any, len(raw))
+	for k, v := range raw {
+		out[k] = v
+	}
+	redactKeys(out, secretKeys)
+	if opts, ok := raw["options"].(map[string]any); ok {
+		copied := make(map[string]any, len(opts))
+		for k, v := range opts {
+			copied[k] = v
+		}
+		redactKeys(copied, secretOptionKeys)
+		out["options"] = copied
+	}
+	return out
+}
+
+func redactKeys(m map[string]any, keys []string) {
+	for _, k := range keys {
+		if v, ok := m[k].(string); ok && v != "" {
+			m[k] = RedactedValue
+		}
+	}
+}
+
+// RestoreRedacted 将 raw 中仍为占位值的密钥字段替换为 stored 中已保存的值，
+// 使客户端把 GET 得到的配置原样提交时不会覆盖密钥.
+func RestoreRedacted(raw, stored map[string]any) {
+	if raw == nil || stored == nil {
+		return
+	}
+	restoreKeys(raw, stored, secretKeys)
+	opts, _ := raw["options"].(map[string]any)
+	storedOpts, _ := stored["options"].(map[string]any)
+	if opts != nil && storedOpts != nil {
+		restoreKeys(opts, storedOpts, secretOptionKeys)
+	}
+}
+
+func restoreKeys(m, stored map[string]any, keys []string) {
+	for _, k := range keys {
+		if v, _ := m[k].(string); v == RedactedValue {
+			m[k] = stored[k]
+		}
+	}
+}
+
+// VerifyHMAC 校验 HMAC-SHA256 签名，支持带 sha256= 前缀的十六进制签名.
+// secret 为空时拒绝请求，未配置密钥的 Webhook 不可调用.
+func VerifyHMAC(secret, headerName string, header http.Header, body []byte) error {
+	if secret == "" {
+		return fmt.Errorf("%w: webhook secret is not configured", ErrInvalidSignature)
+	}
+	if headerName == "" {
+		headerName = DefaultSignatureHeader
+	}
+	signature := strings.TrimPrefix(strings.TrimSpace(header.Get(headerName)), "sha256=")
+	if signature == "" {
+		return fmt.Errorf("%w: missing %s header", ErrInvalidSignature, headerName)
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// parseGenericEvents 解析通用格式的变更通知：{"events":[{"action":"upsert","key":"..."}]}.
+func parseGenericEvents(body []byte) ([]ChangeEvent, error) {
+	var payload struct {
+		Events []ChangeEvent `json:"events"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode webhook payload: %w", err)
+	}
+	for i, e := range payload.Events {
+		if e.Key == "" {
+			return nil, fmt.Errorf("event %d: key is required", i)
+		}
+		switch e.Action {
+		case ActionUpsert, ActionDelete:
+		case "":
+			payload.Events[i].Action = ActionUpsert
+		default:
+			return nil, fmt.Errorf("event %d: unknown action %q", i, e.Action)
+		}
+	}
+	return payload.Events, nil
+}
@@ -0,0 +1,118 @@
+package connector
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	body := []byte(`{"events":[{"key":"a.md"}]}`)
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		wantErr bool
+	}{
+		{name: "valid", secret: "s3cret", header: sign("s3cret", body)},
+		{name: "wrong secret", secret: "s3cret", header: sign("other", body), wantErr: true},
+		{name: "missing header", secret: "s3cret", wantErr: true},
+		{name: "malformed", secret: "s3cret", header: "sha256=zz", wantErr: true},
+		{name: "empty secret fails closed", secret: "", header: sign("", body), wantErr: true},
+		{name: "empty secret without header", secret: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.header != "" {
+				header.Set(DefaultSignatureHeader, tt.header)
+			}
+			err := VerifyHMAC(tt.secret, "", header, body)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Fatalf("VerifyHMAC() error = %v, want ErrInvalidSignature", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyHMAC() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigVerifyWebhookDisabled(t *testing.T) {
+	body := []byte(`{}`)
+	cfg := &Config{WebhookSecret: "s3cret", DisableWebhook: true}
+	header := http.Header{}
+	header.Set(DefaultSignatureHeader, sign("s3cret", body))
+	if err := cfg.VerifyWebhook(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("VerifyWebhook() error = %v, want ErrInvalidSignature", err)
+	}
+	cfg.DisableWebhook = false
+	if err := cfg.VerifyWebhook(header, body); err != nil {
+		t.Fatalf("VerifyWebhook() error = %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	raw := map[string]any{
+		"type":           TypeS3,
+		"webhook_secret": "s3cret",
+		"options": map[string]any{
+			"bucket":            "docs",
+			"access_key_id":     "AKIA",
+			"secret_access_key": "hidden",
+		},
+	}
+	got := Redact(raw)
+	if got["webhook_secret"] != RedactedValue {
+		t.Errorf("webhook_secret = %v, want redacted", got["webhook_secret"])
+	}
+	opts := got["options"].(map[string]any)
+	if opts["access_key_id"] != RedactedValue || opts["secret_access_key"] != RedactedValue {
+		t.Errorf("options = %v, want keys redacted", opts)
+	}
+	if opts["bucket"] != "docs" || got["type"] != TypeS3 {
+		t.Errorf("non-secret fields changed: %v", got)
+	}
+	// 原配置不受影响
+	if raw["webhook_secret"] != "s3cret" || raw["options"].(map[string]any)["secret_access_key"] != "hidden" {
+		t.Errorf("Redact modified its input: %v", raw)
+	}
+
+	empty := Redact(map[string]any{"type": TypeS3, "webhook_secret": ""})
+	if empty["webhook_secret"] != "" {
+		t.Errorf("unset secret = %v, want empty", empty["webhook_secret"])
+	}
+}
+
+func TestRestoreRedacted(t *testing.T) {
+	stored := map[string]any{
+		"webhook_secret": "s3cret",
+		"options":        map[string]any{"access_key_id": "AKIA", "secret_access_key": "hidden"},
+	}
+	update := Redact(stored)
+	update["options"].(map[string]any)["secret_access_key"] = "rotated"
+
+	RestoreRedacted(update, stored)
+	if update["webhook_secret"] != "s3cret" {
+		t.Errorf("webhook_secret = %v, want stored value", update["webhook_secret"])
+	}
+	opts := update["options"].(map[string]any)
+	if opts["access_key_id"] != "AKIA" {
+		t.Errorf("access_key_id = %v, want stored value", opts["access_key_id"])
+	}
+	if opts["secret_access_key"] != "rotated" {
+		t.Errorf("secret_access_key = %v, want new value kept", opts["secret_access_key"])
+	}
+}
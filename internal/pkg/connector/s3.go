@@ -0,0 +1,176 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/ashwinyue/next-show/internal/pkg/s3"
+)
+
+// TypeS3 S3 连接器类型.
+const TypeS3 = "s3"
+
+// maxDocumentSize 单个文档的最大字节数.
+const maxDocumentSize = 50 << 20
+
+func init() {
+	Register(TypeS3, NewS3Connector)
+}
+
+// s3Options S3 连接器配置.
+type s3Options struct {
+	s3.Config
+	// Prefix 只同步该前缀下的对象
+	Prefix string `json:"prefix"`
+}
+
+// S3Connector 基于 S3 存储桶的文档源.
+//
+// 支持 S3 事件通知（Records 格式，可经 SNS/Lambda 等转发）和通用的 {"events":[...]} 格式.
+type S3Connector struct {
+	cfg    *Config
+	client *s3.Client
+	prefix string
+}
+
+// NewS3Connector 创建 S3 连接器.
+func NewS3Connector(cfg *Config) (Connector, error) {
+	data, err := json.Marshal(cfg.Options)
+	if err != nil {
+		return nil, fmt.Errorf("marshal s3 options: %w", err)
+	}
+	var opts s3Options
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return nil, fmt.Errorf("unmarshal s3 options: %w", err)
+	}
+
+	client, err := s3.New(&opts.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Connector{cfg: cfg, client: client, prefix: opts.Prefix}, nil
+}
+
+// Type 连接器类型.
+func (c *S3Connector) Type() string {
+	return TypeS3
+}
+
+// VerifySignature 校验 Webhook 请求签名.
+func (c *S3Connector) VerifySignature(header http.Header, body []byte) error {
+	return c.cfg.VerifyWebhook(header, body)
+}
+
+// ParseEvents 解析变更通知，忽略其它存储桶和前缀之外的对象.
+func (c *S3Connector) ParseEvents(body []byte) ([]ChangeEvent, error) {
+	var payload struct {
+		Records []struct {
+			EventName string `json:"eventName"`
+			S3        struct {
+				Bucket struct {
+					Name string `json:"name"`
+				} `json:"bucket"`
+				Object struct {
+					Key string `json:"key"`
+				} `json:"object"`
+			} `json:"s3"`
+		} `json:"Records"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode webhook payload: %w", err)
+	}
+
+	var events []ChangeEvent
+	if len(payload.Records) == 0 {
+		generic, err := parseGenericEvents(body)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range generic {
+			if c.inScope(e.Key) {
+				events = append(events, e)
+			}
+		}
+		return events, nil
+	}
+
+	for _, r := range payload.Records {
+		if r.S3.Bucket.Name != "" && r.S3.Bucket.Name != c.client.Bucket() {
+			continue
+		}
+		// S3 事件中的 Key 使用表单编码
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return nil, fmt.Errorf("decode object key %q: %w", r.S3.Object.Key, err)
+		}
+		if !c.inScope(key) {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(r.EventName, "ObjectCreated:"):
+			events = append(events, ChangeEvent{Action: ActionUpsert, Key: key})
+		case strings.HasPrefix(r.EventName, "ObjectRemoved:"):
+			events = append(events, ChangeEvent{Action: ActionDelete, Key: key})
+		}
+	}
+	return events, nil
+}
+
+// Fetch 获取对象内容.
+func (c *S3Connector) Fetch(ctx context.Context, key string) (*Document, error) {
+	obj, err := c.client.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(obj.Body, maxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read object %s: %w", key, err)
+	}
+	if len(data) > maxDocumentSize {
+		return nil, fmt.Errorf("object %s exceeds %d bytes", key, maxDocumentSize)
+	}
+
+	name := path.Base(key)
+	return &Document{
+		Key:         key,
+		Name:        name,
+		Title:       strings.TrimSuffix(name, path.Ext(name)),
+		URI:         c.client.URI(key),
+		ContentType: obj.ContentType,
+		Data:        data,
+	}, nil
+}
+
+// List 列出前缀下的对象 Key（跳过目录占位对象）.
+func (c *S3Connector) List(ctx context.Context) ([]string, error) {
+	objects, err := c.client.List(ctx, c.prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		if strings.HasSuffix(o.Key, "/") {
+			continue
+		}
+		keys = append(keys, o.Key)
+	}
+	return keys, nil
+}
+
+// URI 返回对象的 s3:// 地址.
+func (c *S3Connector) URI(key string) string {
+	return c.client.URI(key)
+}
+
+func (c *S3Connector) inScope(key string) bool {
+	return key != "" && !strings.HasSuffix(key, "/") && strings.HasPrefix(key, c.prefix)
+}
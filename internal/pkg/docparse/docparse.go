@@ -0,0 +1,156 @@
+// Package docparse 提供文档解析能力（PDF、DOCX、XLSX、CSV、纯文本）.
+package docparse
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/eino-ext/components/document/parser/docx"
+	"github.com/cloudwego/eino-ext/components/document/parser/pdf"
+	"github.com/cloudwego/eino-ext/components/document/parser/xlsx"
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	"github.com/xuri/excelize/v2"
+)
+
+// contentTypeExtensions 支持解析的 MIME 类型与扩展名的对应关系.
+var contentTypeExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":       ".xlsx",
+	"application/vnd.ms-excel": ".xls",
+	"text/csv":                 ".csv",
+	"text/plain":               ".txt",
+	"text/markdown":            ".md",
+}
+
+// ExtensionForContentType 根据 Content-Type 返回可解析的扩展名，不支持时返回空字符串.
+func ExtensionForContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	}
+	return contentTypeExtensions[strings.ToLower(mediaType)]
+}
+
+// Supported 判断文件扩展名是否支持解析.
+func Supported(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".pdf", ".docx", ".xlsx", ".xls", ".csv", ".txt", ".md":
+		return true
+	}
+	return false
+}
+
+// Parse 按文件扩展名解析文件内容.
+func Parse(ctx context.Context, fileName string, reader io.Reader) ([]*schema.Document, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+
+	var p parser.Parser
+	var err error
+
+	switch ext {
+	case ".pdf":
+		p, err = pdf.NewPDFParser(ctx, nil)
+	case ".docx":
+		p, err = docx.NewDocxParser(ctx, nil)
+	case ".xlsx", ".xls":
+		p, err = xlsx.NewXlsxParser(ctx, nil)
+	case ".csv":
+		// CSV 解析为表格文本
+		return parseCSV(reader)
+	case ".txt", ".md":
+		// 纯文本直接读取
+		content, readErr := io.ReadAll(reader)
+		if readErr != nil {
+			return nil, fmt.Errorf("read text file: %w", readErr)
+		}
+		return []*schema.Document{{Content: string(content)}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("create parser for %s: %w", ext, err)
+	}
+
+	docs, err := p.Parse(ctx, reader)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s file: %w", ext, err)
+	}
+
+	return docs, nil
+}
+
+// parseCSV 解析 CSV 文件为文档.
+func parseCSV(reader io.Reader) ([]*schema.Document, error) {
+	csvReader := csv.NewReader(reader)
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("empty csv file")
+	}
+
+	// 将 CSV 转换为表格文本格式
+	var sb strings.Builder
+	for i, row := range records {
+		if i == 0 {
+			sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+			sb.WriteString("|" + strings.Repeat("---|", len(row)) + "\n")
+		} else {
+			sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+	}
+
+	return []*schema.Document{{Content: sb.String()}}, nil
+}
+
+// ReadTable 读取 CSV / XLSX 表格，返回表头和数据行（XLSX 读取第一个工作表），其他格式返回错误.
+func ReadTable(fileName string, reader io.Reader) ([]string, [][]string, error) {
+	var records [][]string
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".csv":
+		csvReader := csv.NewReader(reader)
+		// 允许各行列数不同，缺失的单元格按空值处理
+		csvReader.FieldsPerRecord = -1
+		rows, err := csvReader.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("read csv: %w", err)
+		}
+		records = rows
+	case ".xlsx":
+		f, err := excelize.OpenReader(reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open xlsx: %w", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil, fmt.Errorf("xlsx has no sheet")
+		}
+		rows, err := f.GetRows(sheets[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("read xlsx sheet %s: %w", sheets[0], err)
+		}
+		records = rows
+	default:
+		return nil, nil, fmt.Errorf("unsupported table file type: %s", ext)
+	}
+
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("empty table file")
+	}
+	header := make([]string, len(records[0]))
+	for i, name := range records[0] {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+	}
+	return header, records[1:], nil
+}
@@ -0,0 +1,27 @@
+package embedding
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/embedding"
+
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
+)
+
+// retryEmbedder 按重试策略调用 Embedding 模型.
+type retryEmbedder struct {
+	embedder embedding.Embedder
+	policy   *retry.Policy
+}
+
+// WithRetry 包装 Embedding 模型，调用失败时按 policy 重试，policy 为 nil 时使用默认策略.
+func WithRetry(e embedding.Embedder, policy *retry.Policy) embedding.Embedder {
+	return &retryEmbedder{embedder: e, policy: policy.WithDefaults()}
+}
+
+// EmbedStrings 生成文本向量.
+func (r *retryEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	return retry.DoValue(ctx, r.policy, func(ctx context.Context) ([][]float64, error) {
+		return r.embedder.EmbedStrings(ctx, texts, opts...)
+	})
+}
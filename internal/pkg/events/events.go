@@ -0,0 +1,152 @@
+// Package events 提供进程内事件总线，用于通知知识库文档和分块的变更.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBufferSize 每个订阅者默认的事件缓冲数.
+const DefaultBufferSize = 256
+
+// Type 事件类型.
+type Type string
+
+const (
+	// DocumentCreated 文档导入完成（已分块并生成向量）.
+	DocumentCreated Type = "document.created"
+	// DocumentUpdated 文档内容或所属知识库变更（如重新分块、移动）.
+	DocumentUpdated Type = "document.updated"
+	// DocumentDeleted 文档被删除，按条件批量删除时 DocumentID 为空，Data 中带删除条件和数量.
+	DocumentDeleted Type = "document.deleted"
+	// ChunkUpdated 分块内容被修改.
+	ChunkUpdated Type = "chunk.updated"
+)
+
+// Event 知识库变更事件.
+type Event struct {
+	ID              string         `json:"id"`
+	Type            Type           `json:"type"`
+	KnowledgeBaseID string         `json:"knowledge_base_id"`
+	DocumentID      string         `json:"document_id,omitempty"`
+	ChunkID         string         `json:"chunk_id,omitempty"`
+	Data            map[string]any `json:"data,omitempty"`
+	Time            time.Time      `json:"time"`
+}
+
+// Subscriber 事件订阅者，Handle 在订阅者自己的 goroutine 中按发布顺序调用.
+type Subscriber interface {
+	Handle(ctx context.Context, e *Event) error
+}
+
+// SubscriberFunc 函数形式的订阅者.
+type SubscriberFunc func(ctx context.Context, e *Event) error
+
+// Handle 实现 Subscriber.
+func (f SubscriberFunc) Handle(ctx context.Context, e *Event) error {
+	return f(ctx, e)
+}
+
+// Bus 进程内事件总线.
+// Publish 不阻塞：每个订阅者有独立的缓冲队列和投递 goroutine，队列满时丢弃事件并记录日志，
+// 慢订阅者不会拖慢写入，也不会影响其他订阅者.
+type Bus struct {
+	bufferSize int
+
+	mu     sync.RWMutex
+	subs   []*subscription
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type subscription struct {
+	sub   Subscriber
+	types map[Type]bool // 为空时接收所有类型
+	ch    chan *Event
+}
+
+// NewBus 创建事件总线，bufferSize <= 0 时使用 DefaultBufferSize.
+func NewBus(bufferSize int) *Bus {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Bus{bufferSize: bufferSize}
+}
+
+// Subscribe 注册订阅者，types 为空时接收所有类型的事件.
+func (b *Bus) Subscribe(sub Subscriber, types ...Type) {
+	s := &subscription{sub: sub, ch: make(chan *Event, b.bufferSize)}
+	if len(types) > 0 {
+		s.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.subs = append(b.subs, s)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for e := range s.ch {
+			if err := s.sub.Handle(context.Background(), e); err != nil {
+				log.Printf("events: deliver %s %s failed: %v", e.Type, e.ID, err)
+			}
+		}
+	}()
+}
+
+// Publish 发布事件，补全 ID 和时间；b 为 nil 时忽略.
+func (b *Bus) Publish(e *Event) {
+	if b == nil || e == nil {
+		return
+	}
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	for _, s := range b.subs {
+		if s.types != nil && !s.types[e.Type] {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			log.Printf("events: subscriber queue full, dropped %s %s", e.Type, e.ID)
+		}
+	}
+}
+
+// Close 停止接收事件，等待已入队的事件投递完成.
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, s := range b.subs {
+		close(s.ch)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
+)
+
+// recorder 记录收到的事件类型.
+type recorder struct {
+	mu    sync.Mutex
+	types []Type
+}
+
+func (r *recorder) Handle(_ context.Context, e *Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types = append(r.types, e.Type)
+	return nil
+}
+
+func TestBusDeliversInOrderByType(t *testing.T) {
+	bus := NewBus(0)
+	all, deletes := &recorder{}, &recorder{}
+	bus.Subscribe(all)
+	bus.Subscribe(deletes, DocumentDeleted)
+
+	for _, typ := range []Type{DocumentCreated, ChunkUpdated, DocumentDeleted, DocumentUpdated} {
+		bus.Publish(&Event{Type: typ, KnowledgeBaseID: "kb1"})
+	}
+	bus.Close()
+	// 关闭后的发布被忽略
+	bus.Publish(&Event{Type: DocumentCreated})
+
+	want := []Type{DocumentCreated, ChunkUpdated, DocumentDeleted, DocumentUpdated}
+	if len(all.types) != len(want) {
+		t.Fatalf("all = %v, want %v", all.types, want)
+	}
+	for i := range want {
+		if all.types[i] != want[i] {
+			t.Fatalf("all = %v, want %v", all.types, want)
+		}
+	}
+	if len(deletes.types) != 1 || deletes.types[0] != DocumentDeleted {
+		t.Errorf("deletes = %v, want only document.deleted", deletes.types)
+	}
+}
+
+func TestPublishDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	bus := NewBus(1)
+	release := make(chan struct{})
+	var handled atomic.Int32
+	bus.Subscribe(SubscriberFunc(func(context.Context, *Event) error {
+		<-release
+		handled.Add(1)
+		return nil
+	}))
+	fast := &recorder{}
+	bus.Subscribe(fast)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			bus.Publish(&Event{Type: DocumentCreated})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+	close(release)
+	bus.Close()
+
+	// 慢订阅者的队列满后丢弃事件，不影响其他订阅者
+	if n := handled.Load(); n < 1 || n >= 10 {
+		t.Errorf("slow subscriber handled %d events, want some dropped", n)
+	}
+	if len(fast.types) == 0 {
+		t.Error("fast subscriber received nothing")
+	}
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(&Event{Type: DocumentCreated})
+	bus.Close()
+}
+
+func TestWebhookSinkSignsAndRetries(t *testing.T) {
+	var calls atomic.Int32
+	var body []byte
+	var signature, eventType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次返回 5xx，重试后成功
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		eventType = r.Header.Get("X-NextShow-Event")
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: srv.URL, Secret: "s3cret", Retry: &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("NewWebhookSink: %v", err)
+	}
+	e := &Event{ID: "e1", Type: DocumentDeleted, KnowledgeBaseID: "kb1", DocumentID: "d1"}
+	if err := sink.Handle(context.Background(), e); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want a retry after 503", calls.Load())
+	}
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("signature = %q, want %q", signature, want)
+	}
+	var got Event
+	if err := json.Unmarshal(body, &got); err != nil || got.DocumentID != "d1" || eventType != string(DocumentDeleted) {
+		t.Errorf("delivered %s event %+v (%v)", eventType, got, err)
+	}
+}
+
+func TestWebhookSinkDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	sink, err := NewWebhookSink(WebhookConfig{URL: srv.URL, Retry: &retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("NewWebhookSink: %v", err)
+	}
+	if err := sink.Handle(context.Background(), &Event{ID: "e1", Type: DocumentCreated}); err == nil {
+		t.Fatal("Handle succeeded on 400")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want no retry on 4xx", calls.Load())
+	}
+	if _, err := NewWebhookSink(WebhookConfig{}); err == nil {
+		t.Error("NewWebhookSink without URL succeeded")
+	}
+}
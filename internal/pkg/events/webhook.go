@@ -0,0 +1,125 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
+)
+
+// DefaultWebhookTimeout 单次 Webhook 请求的默认超时时间.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// SignatureHeader Webhook 请求体的 HMAC-SHA256 签名（hex），配置了 Secret 时发送.
+const SignatureHeader = "X-NextShow-Signature"
+
+// WebhookConfig Webhook 订阅配置.
+type WebhookConfig struct {
+	// URL 接收事件的地址，事件以 JSON POST
+	URL string `mapstructure:"url"`
+	// Secret 不为空时用其对请求体签名，放在 X-NextShow-Signature 头中
+	Secret string `mapstructure:"secret"`
+	// Types 订阅的事件类型，为空时订阅所有类型
+	Types []string `mapstructure:"types"`
+	// Timeout 单次请求超时时间，默认 10s
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Retry 投递失败（网络错误或 5xx）时的重试策略，为空时使用默认策略
+	Retry *retry.Policy `mapstructure:"retry"`
+}
+
+// errPermanent 不需要重试的投递错误（如 4xx）.
+var errPermanent = errors.New("permanent webhook error")
+
+// WebhookSink 将事件 POST 到外部地址的订阅者.
+type WebhookSink struct {
+	url     string
+	secret  string
+	timeout time.Duration
+	policy  *retry.Policy
+	client  *http.Client
+}
+
+// NewWebhookSink 创建 Webhook 订阅者.
+func NewWebhookSink(cfg WebhookConfig) (*WebhookSink, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook url is required")
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	policy := cfg.Retry.WithRetryable(func(err error) bool {
+		return !errors.Is(err, errPermanent)
+	})
+	return &WebhookSink{
+		url:     cfg.URL,
+		secret:  cfg.Secret,
+		timeout: timeout,
+		policy:  policy,
+		client:  &http.Client{},
+	}, nil
+}
+
+// Subscribe 按配置的事件类型将 Webhook 注册到总线.
+func (w *WebhookSink) Subscribe(bus *Bus, types []string) {
+	ts := make([]Type, len(types))
+	for i, t := range types {
+		ts[i] = Type(t)
+	}
+	bus.Subscribe(w, ts...)
+}
+
+// Handle 实现 Subscriber.
+func (w *WebhookSink) Handle(ctx context.Context, e *Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return w.policy.Do(ctx, func(ctx context.Context) error {
+		return w.post(ctx, e, body)
+	})
+}
+
+// post 发送一次请求，2xx 视为成功，4xx 不重试.
+func (w *WebhookSink) post(ctx context.Context, e *Event, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-NextShow-Event", string(e.Type))
+	req.Header.Set("X-NextShow-Event-ID", e.ID)
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("webhook %s returned %d", w.url, resp.StatusCode)
+	default:
+		return fmt.Errorf("%w: webhook %s returned %d", errPermanent, w.url, resp.StatusCode)
+	}
+}
@@ -0,0 +1,245 @@
+// Package limiter 提供 Agent 运行的全局和租户级并发限制.
+package limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultQueueTimeout 默认的排队超时时间.
+const DefaultQueueTimeout = 30 * time.Second
+
+var (
+	// ErrQueueFull 排队人数已满.
+	ErrQueueFull = errors.New("server busy: run queue is full")
+	// ErrQueueTimeout 排队超时.
+	ErrQueueTimeout = errors.New("server busy: timed out waiting for a run slot")
+)
+
+// Config 并发限制配置.
+type Config struct {
+	// MaxConcurrent 全局最大并发运行数，<=0 不限制
+	MaxConcurrent int `mapstructure:"max_concurrent"`
+	// PerTenantMaxConcurrent 单个租户的最大并发运行数，<=0 不限制
+	PerTenantMaxConcurrent int `mapstructure:"per_tenant_max_concurrent"`
+	// MaxQueue 最大排队数，0 表示不排队（无空闲时直接拒绝）
+	MaxQueue int `mapstructure:"max_queue"`
+	// QueueTimeout 排队超时时间，默认 30s
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+}
+
+// Stats 并发运行统计.
+type Stats struct {
+	Active        int            `json:"active"`
+	Queued        int            `json:"queued"`
+	MaxConcurrent int            `json:"max_concurrent"`
+	MaxQueue      int            `json:"max_queue"`
+	TenantActive  map[string]int `json:"tenant_active,omitempty"`
+	Rejected      int64          `json:"rejected"`
+	TimedOut      int64          `json:"timed_out"`
+}
+
+// Limiter 并发限制器，nil 表示不限制.
+type Limiter struct {
+	cfg    Config
+	global chan struct{}
+
+	mu       sync.Mutex
+	tenants  map[string]chan struct{}
+	queued   int
+	rejected int64
+	timedOut int64
+}
+
+// New 创建并发限制器，全局和租户限制均未配置时返回 nil.
+func New(cfg *Config) *Limiter {
+	if cfg == nil || (cfg.MaxConcurrent <= 0 && cfg.PerTenantMaxConcurrent <= 0) {
+		return nil
+	}
+	l := &Limiter{cfg: *cfg, tenants: make(map[string]chan struct{})}
+	if l.cfg.QueueTimeout <= 0 {
+		l.cfg.QueueTimeout = DefaultQueueTimeout
+	}
+	if l.cfg.MaxConcurrent > 0 {
+		l.global = make(chan struct{}, l.cfg.MaxConcurrent)
+	}
+	return l
+}
+
+// Ticket 一次运行的并发许可.
+type Ticket struct {
+	l        *Limiter
+	tenant   chan struct{}
+	acquired bool
+	queued   bool
+	released bool
+}
+
+// Reserve 申请运行许可：有空闲时立即获得，否则进入排队；排队已满时返回 ErrQueueFull.
+// tenantID 为空时只受全局限制.
+func (l *Limiter) Reserve(tenantID string) (*Ticket, error) {
+	if l == nil {
+		return &Ticket{acquired: true}, nil
+	}
+
+	t := &Ticket{l: l, tenant: l.tenantSlots(tenantID)}
+	if t.tryAcquire() {
+		t.acquired = true
+		return t, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.queued >= l.cfg.MaxQueue {
+		l.rejected++
+		return nil, ErrQueueFull
+	}
+	l.queued++
+	t.queued = true
+	return t, nil
+}
+
+// Queued 是否正在排队.
+func (t *Ticket) Queued() bool {
+	return t.queued
+}
+
+// Wait 等待获得运行许可，超时返回 ErrQueueTimeout.
+func (t *Ticket) Wait(ctx context.Context) error {
+	if t.acquired {
+		return nil
+	}
+	defer t.leaveQueue()
+
+	timer := time.NewTimer(t.l.cfg.QueueTimeout)
+	defer timer.Stop()
+
+	if t.tenant != nil {
+		select {
+		case t.tenant <- struct{}{}:
+		case <-timer.C:
+			t.l.recordTimeout()
+			return ErrQueueTimeout
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if t.l.global != nil {
+		select {
+		case t.l.global <- struct{}{}:
+		case <-timer.C:
+			t.releaseTenant()
+			t.l.recordTimeout()
+			return ErrQueueTimeout
+		case <-ctx.Done():
+			t.releaseTenant()
+			return ctx.Err()
+		}
+	}
+	t.acquired = true
+	return nil
+}
+
+// Release 归还运行许可，可重复调用.
+func (t *Ticket) Release() {
+	if t.l == nil || t.released {
+		return
+	}
+	t.released = true
+	t.leaveQueue()
+	if !t.acquired {
+		return
+	}
+	if t.l.global != nil {
+		<-t.l.global
+	}
+	t.releaseTenant()
+}
+
+// Stats 返回当前运行和排队情况.
+func (l *Limiter) Stats() Stats {
+	if l == nil {
+		return Stats{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := Stats{
+		Queued:        l.queued,
+		MaxConcurrent: l.cfg.MaxConcurrent,
+		MaxQueue:      l.cfg.MaxQueue,
+		Rejected:      l.rejected,
+		TimedOut:      l.timedOut,
+	}
+	if l.global != nil {
+		stats.Active = len(l.global)
+	}
+	for tenantID, slots := range l.tenants {
+		if n := len(slots); n > 0 {
+			if stats.TenantActive == nil {
+				stats.TenantActive = make(map[string]int)
+			}
+			stats.TenantActive[tenantID] = n
+		}
+	}
+	return stats
+}
+
+// tenantSlots 返回租户的许可通道，未配置租户限制或 tenantID 为空时返回 nil.
+func (l *Limiter) tenantSlots(tenantID string) chan struct{} {
+	if tenantID == "" || l.cfg.PerTenantMaxConcurrent <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slots, ok := l.tenants[tenantID]
+	if !ok {
+		slots = make(chan struct{}, l.cfg.PerTenantMaxConcurrent)
+		l.tenants[tenantID] = slots
+	}
+	return slots
+}
+
+func (l *Limiter) recordTimeout() {
+	l.mu.Lock()
+	l.timedOut++
+	l.mu.Unlock()
+}
+
+// tryAcquire 非阻塞地获取租户和全局许可.
+func (t *Ticket) tryAcquire() bool {
+	if t.tenant != nil {
+		select {
+		case t.tenant <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	if t.l.global != nil {
+		select {
+		case t.l.global <- struct{}{}:
+		default:
+			t.releaseTenant()
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Ticket) releaseTenant() {
+	if t.tenant != nil {
+		<-t.tenant
+	}
+}
+
+func (t *Ticket) leaveQueue() {
+	if !t.queued {
+		return
+	}
+	t.queued = false
+	t.l.mu.Lock()
+	t.l.queued--
+	t.l.mu.Unlock()
+}
@@ -0,0 +1,327 @@
+// Package mcpclient 提供最小化的 MCP（Model Context Protocol）客户端，用于检查 MCP Server 的连通性和发现工具.
+// 支持 stdio、Streamable HTTP 和旧版 HTTP+SSE 传输.
+package mcpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ProtocolVersion 客户端请求的 MCP 协议版本.
+const ProtocolVersion = "2025-03-26"
+
+// 传输类型.
+const (
+	TransportStdio          = "stdio"
+	TransportStreamableHTTP = "streamable_http"
+	TransportSSE            = "sse"
+)
+
+// maxToolPages tools/list 分页的最大页数，避免服务端返回的游标循环.
+const maxToolPages = 100
+
+var (
+	// ErrUnsupportedTransport 不支持的传输类型.
+	ErrUnsupportedTransport = errors.New("unsupported mcp transport")
+	// ErrInvalidConfig 连接配置不完整.
+	ErrInvalidConfig = errors.New("invalid mcp server config")
+	// ErrClosed 连接已关闭或 Server 进程已退出.
+	ErrClosed = errors.New("mcp connection closed")
+)
+
+// Config MCP Server 连接配置.
+type Config struct {
+	// Transport 传输类型：stdio / streamable_http / sse
+	Transport string
+	// Command、Args、Env stdio 传输启动 Server 进程的命令、参数和附加环境变量
+	Command string
+	Args    []string
+	Env     map[string]string
+	// URL、Headers HTTP 传输的 Server 地址和附加请求头
+	URL     string
+	Headers map[string]string
+}
+
+// ServerInfo initialize 返回的 Server 信息.
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Tool tools/list 返回的工具定义.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"inputSchema,omitempty"`
+}
+
+// Content 工具调用结果中的内容块，非文本内容（图片、资源等）只保留类型.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// CallToolResult tools/call 的结果，IsError 为 true 表示工具执行失败，Content 中为错误信息.
+type CallToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Text 按顺序合并结果中的文本内容.
+func (r *CallToolResult) Text() string {
+	var texts []string
+	for _, c := range r.Content {
+		if c.Type == "text" {
+			texts = append(texts, c.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// RPCError JSON-RPC 错误响应.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// message JSON-RPC 消息，请求、响应和通知共用.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// isResponse 是否为对客户端请求的响应.
+func (m *message) isResponse() bool {
+	return len(m.ID) > 0 && m.Method == ""
+}
+
+// transport 传输层.
+type transport interface {
+	// roundTrip 发送请求并等待 ID 相同的响应.
+	roundTrip(ctx context.Context, req *message) (*message, error)
+	// notify 发送通知，不等待响应.
+	notify(ctx context.Context, msg *message) error
+	close() error
+}
+
+// Session 已完成初始化握手的 MCP 连接.
+type Session struct {
+	t      transport
+	nextID atomic.Int64
+
+	// ServerInfo、ProtocolVersion initialize 返回的 Server 信息和协商的协议版本
+	ServerInfo      ServerInfo
+	ProtocolVersion string
+}
+
+// Connect 按传输类型连接 MCP Server 并完成 initialize 握手，使用完毕后需调用 Close.
+func Connect(ctx context.Context, cfg *Config) (*Session, error) {
+	var t transport
+	var err error
+	switch cfg.Transport {
+	case TransportStdio:
+		t, err = newStdioTransport(cfg)
+	case TransportStreamableHTTP:
+		t, err = newHTTPTransport(cfg)
+	case TransportSSE:
+		t, err = newSSETransport(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedTransport, cfg.Transport)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Session{t: t}
+	var init struct {
+		ProtocolVersion string     `json:"protocolVersion"`
+		ServerInfo      ServerInfo `json:"serverInfo"`
+	}
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      ServerInfo{Name: "next-show", Version: "1.0.0"},
+	}
+	if err := s.call(ctx, "initialize", params, &init); err != nil {
+		_ = t.close()
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	s.ServerInfo = init.ServerInfo
+	s.ProtocolVersion = init.ProtocolVersion
+
+	if err := t.notify(ctx, &message{JSONRPC: "2.0", Method: "notifications/initialized"}); err != nil {
+		_ = t.close()
+		return nil, fmt.Errorf("initialized notification: %w", err)
+	}
+	return s, nil
+}
+
+// Ping 发送 ping 请求.
+func (s *Session) Ping(ctx context.Context) error {
+	return s.call(ctx, "ping", nil, nil)
+}
+
+// ListTools 调用 tools/list 并合并所有分页.
+func (s *Session) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	cursor := ""
+	for page := 0; page < maxToolPages; page++ {
+		var params any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		var result struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := s.call(ctx, "tools/list", params, &result); err != nil {
+			return nil, fmt.Errorf("tools/list: %w", err)
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+	return nil, fmt.Errorf("tools/list: more than %d pages", maxToolPages)
+}
+
+// CallTool 调用工具，arguments 为 JSON 对象，为空时按无参数调用.
+func (s *Session) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	params := map[string]any{"name": name, "arguments": arguments}
+	var result CallToolResult
+	if err := s.call(ctx, "tools/call", params, &result); err != nil {
+		return nil, fmt.Errorf("tools/call %s: %w", name, err)
+	}
+	return &result, nil
+}
+
+// Close 关闭连接，stdio 传输会结束 Server 进程.
+func (s *Session) Close() error {
+	return s.t.close()
+}
+
+// call 发送请求并将结果解码到 result（为 nil 时忽略结果）.
+func (s *Session) call(ctx context.Context, method string, params, result any) error {
+	req := &message{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(strconv.FormatInt(s.nextID.Add(1), 10)),
+		Method:  method,
+	}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("marshal params: %w", err)
+		}
+		req.Params = raw
+	}
+
+	resp, err := s.t.roundTrip(ctx, req)
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// pendingCalls 按请求 ID 等待响应，用于响应异步到达的传输（stdio、SSE）.
+type pendingCalls struct {
+	mu      sync.Mutex
+	waiters map[string]chan *message
+	done    chan struct{}
+	err     error
+}
+
+func newPendingCalls() *pendingCalls {
+	return &pendingCalls{waiters: make(map[string]chan *message), done: make(chan struct{})}
+}
+
+// add 登记等待的请求.
+func (p *pendingCalls) add(id json.RawMessage) chan *message {
+	ch := make(chan *message, 1)
+	p.mu.Lock()
+	p.waiters[string(id)] = ch
+	p.mu.Unlock()
+	return ch
+}
+
+// remove 取消等待.
+func (p *pendingCalls) remove(id json.RawMessage) {
+	p.mu.Lock()
+	delete(p.waiters, string(id))
+	p.mu.Unlock()
+}
+
+// deliver 将响应交给等待的请求，没有等待者时丢弃.
+func (p *pendingCalls) deliver(msg *message) {
+	p.mu.Lock()
+	ch, ok := p.waiters[string(msg.ID)]
+	delete(p.waiters, string(msg.ID))
+	p.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+// fail 标记连接已断开，所有等待中的请求返回 err.
+func (p *pendingCalls) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	p.err = err
+	close(p.done)
+}
+
+// wait 等待响应、连接断开或 ctx 结束.
+func (p *pendingCalls) wait(ctx context.Context, id json.RawMessage, ch chan *message) (*message, error) {
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-p.done:
+		p.remove(id)
+		return nil, p.err
+	case <-ctx.Done():
+		p.remove(id)
+		return nil, ctx.Err()
+	}
+}
+
+// replyToServer 生成对 Server 发起的请求的响应：ping 返回空结果，其余请求客户端不支持.
+func replyToServer(req *message) *message {
+	reply := &message{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		reply.Result = json.RawMessage("{}")
+	} else {
+		reply.Error = &RPCError{Code: -32601, Message: "method not found: " + req.Method}
+	}
+	return reply
+}
@@ -0,0 +1,343 @@
+package mcpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionHeader Streamable HTTP 传输的会话 ID 请求头.
+const sessionHeader = "Mcp-Session-Id"
+
+// closeTimeout 关闭 HTTP 会话请求的超时时间.
+const closeTimeout = 5 * time.Second
+
+// maxErrorBody 错误响应中读取的最大字节数.
+const maxErrorBody = 1 << 10
+
+// httpTransport Streamable HTTP 传输：每条消息单独 POST，响应为 JSON 或 SSE 流.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+func newHTTPTransport(cfg *Config) (*httpTransport, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("%w: http transport requires a server url", ErrInvalidConfig)
+	}
+	return &httpTransport{url: cfg.URL, headers: cfg.Headers, client: &http.Client{}}, nil
+}
+
+// post 发送一条消息，记录 Server 返回的会话 ID.
+func (t *httpTransport) post(ctx context.Context, msg *message) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, statusError(resp)
+	}
+	if id := resp.Header.Get(sessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (t *httpTransport) setHeaders(req *http.Request) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(sessionHeader, t.sessionID)
+	}
+	t.mu.Unlock()
+}
+
+func (t *httpTransport) roundTrip(ctx context.Context, req *message) (*message, error) {
+	resp, err := t.post(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		// 响应以 SSE 流返回，其中可能先有 Server 的通知，直到出现 ID 相同的响应
+		var result *message
+		err := readEvents(resp.Body, func(_, data string) bool {
+			var msg message
+			if json.Unmarshal([]byte(data), &msg) == nil && msg.isResponse() && string(msg.ID) == string(req.ID) {
+				result = &msg
+				return false
+			}
+			return true
+		})
+		if result != nil {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read event stream: %w", err)
+		}
+		return nil, fmt.Errorf("%w: event stream ended without a response", ErrClosed)
+	}
+
+	var msg message
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &msg, nil
+}
+
+func (t *httpTransport) notify(ctx context.Context, msg *message) error {
+	resp, err := t.post(ctx, msg)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// close 存在会话时通知 Server 结束会话，失败时忽略.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return nil
+	}
+	t.setHeaders(req)
+	if resp, err := t.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// sseTransport 旧版 HTTP+SSE 传输：GET 建立事件流，Server 通过 endpoint 事件告知 POST 地址，响应从事件流返回.
+type sseTransport struct {
+	headers map[string]string
+	client  *http.Client
+	body    io.ReadCloser
+	cancel  context.CancelFunc
+	pending *pendingCalls
+
+	endpoint string
+}
+
+func newSSETransport(ctx context.Context, cfg *Config) (*sseTransport, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("%w: sse transport requires a server url", ErrInvalidConfig)
+	}
+	base, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+
+	// 事件流在整个连接期间保持，不使用调用方的 ctx
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, cfg.URL, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	t := &sseTransport{headers: cfg.Headers, client: &http.Client{}, cancel: cancel, pending: newPendingCalls()}
+
+	connected := make(chan error, 1)
+	go func() {
+		resp, err := t.client.Do(req)
+		if err != nil {
+			connected <- err
+			return
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			connected <- statusError(resp)
+			resp.Body.Close()
+			return
+		}
+		t.body = resp.Body
+		t.readLoop(base, connected)
+	}()
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		return t, nil
+	case <-ctx.Done():
+		cancel()
+		return nil, ctx.Err()
+	}
+}
+
+// readLoop 读取事件流：第一个 endpoint 事件通过 connected 通知连接建立，之后的 message 事件为 JSON-RPC 消息.
+func (t *sseTransport) readLoop(base *url.URL, connected chan<- error) {
+	defer t.body.Close()
+	gotEndpoint, notified := false, false
+	notify := func(err error) {
+		if !notified {
+			notified = true
+			connected <- err
+		}
+	}
+	err := readEvents(t.body, func(event, data string) bool {
+		switch event {
+		case "endpoint":
+			if gotEndpoint {
+				return true
+			}
+			ref, err := base.Parse(strings.TrimSpace(data))
+			if err != nil {
+				notify(fmt.Errorf("invalid endpoint event %q: %w", data, err))
+				return false
+			}
+			t.endpoint = ref.String()
+			gotEndpoint = true
+			notify(nil)
+		case "", "message":
+			var msg message
+			if json.Unmarshal([]byte(data), &msg) != nil {
+				return true
+			}
+			if msg.isResponse() {
+				t.pending.deliver(&msg)
+			} else if len(msg.ID) > 0 {
+				go func() { _ = t.send(context.Background(), replyToServer(&msg)) }()
+			}
+		}
+		return true
+	})
+	if !gotEndpoint {
+		if err == nil {
+			err = fmt.Errorf("%w: event stream ended before endpoint event", ErrClosed)
+		}
+		notify(err)
+	}
+	t.pending.fail(fmt.Errorf("%w: event stream ended", ErrClosed))
+}
+
+// send 将消息 POST 到 endpoint，响应内容从事件流返回.
+func (t *sseTransport) send(ctx context.Context, msg *message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return statusError(resp)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (t *sseTransport) roundTrip(ctx context.Context, req *message) (*message, error) {
+	ch := t.pending.add(req.ID)
+	if err := t.send(ctx, req); err != nil {
+		t.pending.remove(req.ID)
+		return nil, err
+	}
+	return t.pending.wait(ctx, req.ID, ch)
+}
+
+func (t *sseTransport) notify(ctx context.Context, msg *message) error {
+	return t.send(ctx, msg)
+}
+
+func (t *sseTransport) close() error {
+	t.cancel()
+	return nil
+}
+
+// readEvents 解析 SSE 事件流，fn 返回 false 时停止读取.
+func readEvents(r io.Reader, fn func(event, data string) bool) error {
+	br := bufio.NewReader(r)
+	var event string
+	var data []string
+	for {
+		line, err := br.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "" && err == nil:
+			if len(data) > 0 && !fn(event, strings.Join(data, "\n")) {
+				return nil
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// 注释（心跳）
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		if err != nil {
+			if len(data) > 0 {
+				fn(event, strings.Join(data, "\n"))
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// statusError 返回非 2xx 响应的错误，附带响应体的开头部分.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		return fmt.Errorf("mcp server returned %s", resp.Status)
+	}
+	return fmt.Errorf("mcp server returned %s: %s", resp.Status, msg)
+}
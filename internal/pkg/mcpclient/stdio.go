@@ -0,0 +1,173 @@
+package mcpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stdioExitTimeout 关闭标准输入后等待 Server 进程退出的时间，超时后强制结束.
+const stdioExitTimeout = 2 * time.Second
+
+// stderrTailSize 保留的 Server 标准错误输出字节数，进程异常退出时附在错误信息中.
+const stderrTailSize = 4 << 10
+
+// stdioTransport 通过子进程的标准输入输出交换以换行分隔的 JSON-RPC 消息.
+type stdioTransport struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  *tailBuffer
+	pending *pendingCalls
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	exited    chan struct{}
+}
+
+func newStdioTransport(cfg *Config) (*stdioTransport, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("%w: stdio transport requires a command", ErrInvalidConfig)
+	}
+	cmd := exec.Command(cfg.Command, cfg.Args...)
+	cmd.Env = os.Environ()
+	for k, v := range cfg.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+	t := &stdioTransport{
+		cmd:     cmd,
+		stdin:   stdin,
+		stderr:  &tailBuffer{max: stderrTailSize},
+		pending: newPendingCalls(),
+		exited:  make(chan struct{}),
+	}
+	cmd.Stderr = t.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", cfg.Command, err)
+	}
+
+	go t.readLoop(stdout)
+	return t, nil
+}
+
+// readLoop 读取 Server 输出的消息，直到进程退出.
+func (t *stdioTransport) readLoop(stdout io.Reader) {
+	r := bufio.NewReader(stdout)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			t.handle(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	waitErr := t.cmd.Wait()
+	close(t.exited)
+
+	err := fmt.Errorf("%w: server process exited", ErrClosed)
+	if waitErr != nil {
+		err = fmt.Errorf("%w: server process exited: %v", ErrClosed, waitErr)
+	}
+	if tail := strings.TrimSpace(t.stderr.String()); tail != "" {
+		err = fmt.Errorf("%w (stderr: %s)", err, tail)
+	}
+	t.pending.fail(err)
+}
+
+// handle 分发一条消息，非 JSON 的输出（如日志）会被忽略.
+func (t *stdioTransport) handle(line []byte) {
+	var msg message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return
+	}
+	switch {
+	case msg.isResponse():
+		t.pending.deliver(&msg)
+	case len(msg.ID) > 0:
+		_ = t.write(replyToServer(&msg))
+	}
+}
+
+func (t *stdioTransport) write(msg *message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%w: %v", ErrClosed, err)
+	}
+	return nil
+}
+
+func (t *stdioTransport) roundTrip(ctx context.Context, req *message) (*message, error) {
+	ch := t.pending.add(req.ID)
+	if err := t.write(req); err != nil {
+		t.pending.remove(req.ID)
+		// 进程已退出时返回带退出状态和标准错误输出的错误
+		select {
+		case <-t.pending.done:
+			return nil, t.pending.err
+		case <-time.After(stdioExitTimeout):
+			return nil, err
+		}
+	}
+	return t.pending.wait(ctx, req.ID, ch)
+}
+
+func (t *stdioTransport) notify(_ context.Context, msg *message) error {
+	return t.write(msg)
+}
+
+// close 关闭标准输入通知 Server 退出，超时后强制结束进程.
+func (t *stdioTransport) close() error {
+	t.closeOnce.Do(func() {
+		_ = t.stdin.Close()
+		select {
+		case <-t.exited:
+		case <-time.After(stdioExitTimeout):
+			_ = t.cmd.Process.Kill()
+			<-t.exited
+		}
+	})
+	return nil
+}
+
+// tailBuffer 只保留最后 max 字节的 io.Writer.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = b.buf[len(b.buf)-b.max:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/cloudwego/eino-ext/components/model/agenticark"
 	"github.com/cloudwego/eino-ext/components/model/agenticopenai"
 	"github.com/cloudwego/eino/components/model"
+
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
 )
 
 // ModelConfig 模型配置。
@@ -20,18 +22,30 @@ type ModelConfig struct {
 	// Agentic 特有配置
 	Thinking    bool     `json:"thinking,omitempty"`     // 启用推理模式
 	ServerTools []string `json:"server_tools,omitempty"` // ["web_search"]
+
+	// Retry 调用失败时的重试策略，为空时不重试
+	Retry *retry.Policy `json:"-"`
 }
 
 // CreateAgenticModel 创建 AgenticModel。
 func CreateAgenticModel(ctx context.Context, cfg *ModelConfig) (model.AgenticModel, error) {
+	var m model.AgenticModel
+	var err error
 	switch cfg.Provider {
 	case "ark":
-		return createARKAgentic(ctx, cfg)
+		m, err = createARKAgentic(ctx, cfg)
 	case "openai":
-		return createOpenAIAgentic(ctx, cfg)
+		m, err = createOpenAIAgentic(ctx, cfg)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", cfg.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Retry != nil {
+		m = WithRetry(m, cfg.Retry)
+	}
+	return m, nil
 }
 
 // createARKAgentic 创建 ARK AgenticModel。
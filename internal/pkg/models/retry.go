@@ -0,0 +1,45 @@
+package models
+
+import (
+	"context"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/ashwinyue/next-show/internal/pkg/retry"
+)
+
+// retryModel 按重试策略调用 AgenticModel。
+// 流式调用只重试建立流的过程，流开始返回后的错误不重试，避免重复输出。
+type retryModel struct {
+	model  model.AgenticModel
+	policy *retry.Policy
+}
+
+// WithRetry 包装 AgenticModel，调用失败时按 policy 重试，policy 为 nil 时使用默认策略。
+func WithRetry(m model.AgenticModel, policy *retry.Policy) model.AgenticModel {
+	return &retryModel{model: m, policy: policy.WithDefaults()}
+}
+
+// Generate 生成回复。
+func (r *retryModel) Generate(ctx context.Context, input []*schema.AgenticMessage, opts ...model.Option) (*schema.AgenticMessage, error) {
+	return retry.DoValue(ctx, r.policy, func(ctx context.Context) (*schema.AgenticMessage, error) {
+		return r.model.Generate(ctx, input, opts...)
+	})
+}
+
+// Stream 流式生成回复。
+func (r *retryModel) Stream(ctx context.Context, input []*schema.AgenticMessage, opts ...model.Option) (*schema.StreamReader[*schema.AgenticMessage], error) {
+	return retry.DoValue(ctx, r.policy, func(ctx context.Context) (*schema.StreamReader[*schema.AgenticMessage], error) {
+		return r.model.Stream(ctx, input, opts...)
+	})
+}
+
+// WithTools 返回绑定工具的新模型，同样带有重试。
+func (r *retryModel) WithTools(tools []*schema.ToolInfo) (model.AgenticModel, error) {
+	m, err := r.model.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &retryModel{model: m, policy: r.policy}, nil
+}
@@ -0,0 +1,78 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// KeywordRule 关键词规则.
+type KeywordRule struct {
+	Category string   `json:"category" mapstructure:"category"`
+	Keywords []string `json:"keywords" mapstructure:"keywords"`
+}
+
+// KeywordProvider 基于关键词规则的审核提供方（大小写不敏感）.
+type KeywordProvider struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	category string
+	pattern  *regexp.Regexp
+}
+
+// NewKeywordProvider 创建关键词审核提供方.
+func NewKeywordProvider(rules []KeywordRule) (*KeywordProvider, error) {
+	p := &KeywordProvider{}
+	for _, rule := range rules {
+		var quoted []string
+		for _, kw := range rule.Keywords {
+			if kw = strings.TrimSpace(kw); kw != "" {
+				quoted = append(quoted, regexp.QuoteMeta(kw))
+			}
+		}
+		if len(quoted) == 0 {
+			continue
+		}
+		pattern, err := regexp.Compile("(?i)(" + strings.Join(quoted, "|") + ")")
+		if err != nil {
+			return nil, fmt.Errorf("compile keyword rule %q: %w", rule.Category, err)
+		}
+		category := rule.Category
+		if category == "" {
+			category = "keyword"
+		}
+		p.rules = append(p.rules, compiledRule{category: category, pattern: pattern})
+	}
+	return p, nil
+}
+
+// Name 返回提供方名称.
+func (p *KeywordProvider) Name() string {
+	return "keyword"
+}
+
+// Check 检查文本是否命中关键词规则.
+func (p *KeywordProvider) Check(ctx context.Context, text string) (*Verdict, error) {
+	verdict := &Verdict{}
+	seen := make(map[string]bool)
+	for _, rule := range p.rules {
+		matches := rule.pattern.FindAllString(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		verdict.Flagged = true
+		verdict.Categories = append(verdict.Categories, rule.category)
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				verdict.Spans = append(verdict.Spans, m)
+			}
+		}
+	}
+	return verdict, nil
+}
+
+var _ Provider = (*KeywordProvider)(nil)
@@ -0,0 +1,229 @@
+// Package moderation 提供内容审核能力（导入文档审核、回答审核）.
+package moderation
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// ErrContentBlocked 内容被审核拦截.
+var ErrContentBlocked = errors.New("content blocked by moderation")
+
+// Action 审核命中后的处理动作.
+type Action string
+
+const (
+	ActionBlock  Action = "block"  // 拦截：拒绝导入 / 中断回答
+	ActionFlag   Action = "flag"   // 标记：放行并记录
+	ActionRedact Action = "redact" // 脱敏：替换命中片段后放行
+)
+
+// Target 审核对象类型.
+type Target string
+
+const (
+	TargetDocument Target = "document"
+	TargetAnswer   Target = "answer"
+)
+
+// Verdict 审核提供方的判定结果.
+type Verdict struct {
+	Flagged    bool     `json:"flagged"`
+	Categories []string `json:"categories,omitempty"`
+	// Spans 命中的原文片段，用于 redact；提供方无法定位时为空
+	Spans []string `json:"spans,omitempty"`
+}
+
+// Provider 审核提供方接口.
+type Provider interface {
+	Name() string
+	Check(ctx context.Context, text string) (*Verdict, error)
+}
+
+// Recorder 审核结果记录接口.
+type Recorder interface {
+	Create(ctx context.Context, record *model.ModerationRecord) error
+}
+
+// Config 审核配置.
+type Config struct {
+	// ImportAction 文档导入命中后的动作，默认 block
+	ImportAction Action
+	// AnswerAction 模型回答命中后的动作，默认 redact
+	AnswerAction Action
+	// FailOpen 提供方调用失败时是否放行；false 时按命中处理并拦截
+	FailOpen bool
+	// RedactPlaceholder 脱敏替换文本，默认 "***"
+	RedactPlaceholder string
+}
+
+// Result 单次审核结果.
+type Result struct {
+	Flagged    bool
+	Blocked    bool
+	Action     Action
+	Categories []string
+	// Content 处理后的内容（redact 时为脱敏文本，否则为原文）
+	Content string
+	// Err 提供方调用错误（已按 fail-open/fail-closed 处理）
+	Err error
+}
+
+// Moderator 内容审核器，为 nil 时所有方法视为未启用.
+type Moderator struct {
+	provider    Provider
+	recorder    Recorder
+	importAct   Action
+	answerAct   Action
+	failOpen    bool
+	placeholder string
+}
+
+// NewModerator 创建内容审核器.
+func NewModerator(provider Provider, recorder Recorder, cfg *Config) *Moderator {
+	m := &Moderator{
+		provider:    provider,
+		recorder:    recorder,
+		importAct:   ActionBlock,
+		answerAct:   ActionRedact,
+		placeholder: "***",
+	}
+	if cfg != nil {
+		if cfg.ImportAction != "" {
+			m.importAct = cfg.ImportAction
+		}
+		if cfg.AnswerAction != "" {
+			m.answerAct = cfg.AnswerAction
+		}
+		if cfg.RedactPlaceholder != "" {
+			m.placeholder = cfg.RedactPlaceholder
+		}
+		m.failOpen = cfg.FailOpen
+	}
+	return m
+}
+
+// Enabled 是否启用审核.
+func (m *Moderator) Enabled() bool {
+	return m != nil && m.provider != nil
+}
+
+// AnswerAction 返回回答审核动作.
+func (m *Moderator) AnswerAction() Action {
+	return m.answerAct
+}
+
+// CheckDocument 审核导入文档内容并记录结果.
+func (m *Moderator) CheckDocument(ctx context.Context, documentID, text string) *Result {
+	result := m.Evaluate(ctx, m.importAct, text)
+	m.Record(ctx, TargetDocument, documentID, result)
+	return result
+}
+
+// CheckAnswer 审核模型回答并记录结果.
+func (m *Moderator) CheckAnswer(ctx context.Context, sessionID, text string) *Result {
+	result := m.Evaluate(ctx, m.answerAct, text)
+	m.Record(ctx, TargetAnswer, sessionID, result)
+	return result
+}
+
+// Evaluate 审核内容并按动作处理，不记录结果.
+// redact 动作下提供方无法定位命中片段时退化为拦截.
+func (m *Moderator) Evaluate(ctx context.Context, action Action, text string) *Result {
+	return m.evaluate(ctx, action, text, false)
+}
+
+// EvaluateAnswer 按回答审核动作审核一段回答，不记录结果.
+// 流式回答按窗口审核，redact 动作下提供方无法定位命中片段时（如 OpenAI）将整段替换为占位符，
+// 只影响命中的窗口，不中断回答.
+func (m *Moderator) EvaluateAnswer(ctx context.Context, text string) *Result {
+	return m.evaluate(ctx, m.answerAct, text, true)
+}
+
+func (m *Moderator) evaluate(ctx context.Context, action Action, text string, redactWhole bool) *Result {
+	result := &Result{Content: text}
+	if !m.Enabled() || strings.TrimSpace(text) == "" {
+		return result
+	}
+
+	verdict, err := m.provider.Check(ctx, text)
+	if err != nil {
+		result.Err = err
+		if m.failOpen {
+			return result
+		}
+		// fail-closed: 无法确认内容安全时直接拦截
+		result.Flagged = true
+		result.Blocked = true
+		result.Action = ActionBlock
+		return result
+	}
+	if verdict == nil || !verdict.Flagged {
+		return result
+	}
+
+	result.Flagged = true
+	result.Categories = verdict.Categories
+	result.Action = action
+
+	switch action {
+	case ActionFlag:
+	case ActionRedact:
+		if len(verdict.Spans) == 0 {
+			if redactWhole {
+				result.Content = m.placeholder
+				return result
+			}
+			// 无法定位命中片段，退化为拦截
+			result.Blocked = true
+			result.Action = ActionBlock
+			return result
+		}
+		result.Content = m.redact(text, verdict.Spans)
+	default:
+		result.Blocked = true
+		result.Action = ActionBlock
+	}
+	return result
+}
+
+// Record 记录审核结果，未命中且无错误时不记录.
+func (m *Moderator) Record(ctx context.Context, target Target, targetID string, result *Result) {
+	if !m.Enabled() || m.recorder == nil || result == nil {
+		return
+	}
+	if !result.Flagged && result.Err == nil {
+		return
+	}
+
+	record := &model.ModerationRecord{
+		ID:         uuid.New().String(),
+		Target:     string(target),
+		TargetID:   targetID,
+		Provider:   m.provider.Name(),
+		Flagged:    result.Flagged,
+		Action:     string(result.Action),
+		Categories: model.JSONSlice(result.Categories),
+	}
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+	// 记录失败不影响主流程
+	_ = m.recorder.Create(ctx, record)
+}
+
+// redact 将命中片段替换为占位符.
+func (m *Moderator) redact(text string, spans []string) string {
+	pairs := make([]string, 0, len(spans)*2)
+	for _, span := range spans {
+		if span != "" {
+			pairs = append(pairs, span, m.placeholder)
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
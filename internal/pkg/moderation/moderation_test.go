@@ -0,0 +1,43 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+)
+
+// unlocatedProvider 命中但无法定位片段的提供方（如 OpenAI）.
+type unlocatedProvider struct{}
+
+func (unlocatedProvider) Name() string { return "unlocated" }
+
+func (unlocatedProvider) Check(context.Context, string) (*Verdict, error) {
+	return &Verdict{Flagged: true, Categories: []string{"harassment"}}, nil
+}
+
+func TestRedactWithoutSpans(t *testing.T) {
+	m := NewModerator(unlocatedProvider{}, nil, &Config{AnswerAction: ActionRedact, RedactPlaceholder: "[x]"})
+	ctx := context.Background()
+
+	// 导入等整段审核无法定位片段时拦截
+	if result := m.Evaluate(ctx, ActionRedact, "text"); !result.Blocked || result.Action != ActionBlock {
+		t.Errorf("Evaluate() = %+v, want blocked", result)
+	}
+	// 回答窗口替换为占位符，不拦截
+	result := m.EvaluateAnswer(ctx, "text")
+	if result.Blocked || result.Content != "[x]" || result.Action != ActionRedact || !result.Flagged {
+		t.Errorf("EvaluateAnswer() = %+v, want window replaced by placeholder", result)
+	}
+}
+
+func TestEvaluateAnswerRedactsSpans(t *testing.T) {
+	provider, err := NewKeywordProvider([]KeywordRule{{Category: "profanity", Keywords: []string{"darn"}}})
+	if err != nil {
+		t.Fatalf("NewKeywordProvider() error = %v", err)
+	}
+	m := NewModerator(provider, nil, &Config{AnswerAction: ActionRedact})
+
+	result := m.EvaluateAnswer(context.Background(), "well Darn it")
+	if result.Blocked || result.Content != "well *** it" {
+		t.Errorf("EvaluateAnswer() = %+v, want span redacted", result)
+	}
+}
@@ -0,0 +1,115 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAIConfig OpenAI 兼容审核接口配置.
+type OpenAIConfig struct {
+	APIKey  string
+	BaseURL string // 默认 https://api.openai.com/v1
+	Model   string // 默认 omni-moderation-latest
+	Timeout time.Duration
+}
+
+// OpenAIProvider 调用 OpenAI 兼容 /moderations 接口的审核提供方.
+type OpenAIProvider struct {
+	apiKey  string
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAIProvider 创建 OpenAI 兼容审核提供方.
+func NewOpenAIProvider(cfg *OpenAIConfig) (*OpenAIProvider, error) {
+	if cfg == nil || cfg.APIKey == "" {
+		return nil, fmt.Errorf("moderation api_key is required")
+	}
+	p := &OpenAIProvider{
+		apiKey:  cfg.APIKey,
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		model:   cfg.Model,
+		client:  &http.Client{Timeout: cfg.Timeout},
+	}
+	if p.baseURL == "" {
+		p.baseURL = "https://api.openai.com/v1"
+	}
+	if p.model == "" {
+		p.model = "omni-moderation-latest"
+	}
+	if p.client.Timeout == 0 {
+		p.client.Timeout = 10 * time.Second
+	}
+	return p, nil
+}
+
+// Name 返回提供方名称.
+func (p *OpenAIProvider) Name() string {
+	return "openai"
+}
+
+type openAIModerationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// Check 调用审核接口检查文本.
+func (p *OpenAIProvider) Check(ctx context.Context, text string) (*Verdict, error) {
+	body, err := json.Marshal(map[string]string{
+		"model": p.model,
+		"input": text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("moderation api error: status=%d, body=%s", resp.StatusCode, respBody)
+	}
+
+	var result openAIModerationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	verdict := &Verdict{}
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+		verdict.Flagged = true
+		for category, hit := range r.Categories {
+			if hit {
+				verdict.Categories = append(verdict.Categories, category)
+			}
+		}
+	}
+	sort.Strings(verdict.Categories)
+	return verdict, nil
+}
+
+var _ Provider = (*OpenAIProvider)(nil)
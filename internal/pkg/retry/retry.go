@@ -0,0 +1,168 @@
+// Package retry 提供可配置的重试策略，供模型、Embedding、工具等外部调用统一使用.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// 默认策略参数.
+const (
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 10 * time.Second
+	DefaultMultiplier     = 2.0
+	DefaultJitter         = 0.2
+)
+
+// Policy 重试策略.
+type Policy struct {
+	// MaxAttempts 最大尝试次数（含首次），1 表示不重试，<=0 使用默认值 3
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// InitialBackoff 首次重试前的等待时间，默认 500ms
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	// MaxBackoff 等待时间上限，默认 10s
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+	// Multiplier 每次重试等待时间的增长倍数，默认 2
+	Multiplier float64 `mapstructure:"multiplier"`
+	// Jitter 等待时间的随机浮动比例（0-1），例如 0.2 表示在 ±20% 内浮动，避免大量请求同时重试
+	Jitter float64 `mapstructure:"jitter"`
+	// Retryable 判断错误是否可重试，为空时除 ctx 取消和超时外的错误都重试
+	Retryable func(error) bool `mapstructure:"-"`
+}
+
+// DefaultPolicy 返回默认重试策略.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Multiplier:     DefaultMultiplier,
+		Jitter:         DefaultJitter,
+	}
+}
+
+// WithDefaults 返回未设置的字段使用默认值的副本，p 为 nil 时返回默认策略.
+func (p *Policy) WithDefaults() *Policy {
+	if p == nil {
+		return DefaultPolicy()
+	}
+	out := *p
+	if out.MaxAttempts <= 0 {
+		out.MaxAttempts = DefaultMaxAttempts
+	}
+	if out.InitialBackoff <= 0 {
+		out.InitialBackoff = DefaultInitialBackoff
+	}
+	if out.MaxBackoff <= 0 {
+		out.MaxBackoff = DefaultMaxBackoff
+	}
+	if out.Multiplier < 1 {
+		out.Multiplier = DefaultMultiplier
+	}
+	if out.Jitter < 0 {
+		out.Jitter = 0
+	}
+	if out.Jitter > 1 {
+		out.Jitter = 1
+	}
+	return &out
+}
+
+// WithRetryable 返回使用指定可重试判断的副本.
+func (p *Policy) WithRetryable(fn func(error) bool) *Policy {
+	out := *p.WithDefaults()
+	out.Retryable = fn
+	return &out
+}
+
+// Backoff 返回第 attempt 次失败后（attempt 从 1 开始）不含随机浮动的等待时间：
+// InitialBackoff * Multiplier^(attempt-1)，不超过 MaxBackoff.
+func (p *Policy) Backoff(attempt int) time.Duration {
+	p = p.WithDefaults()
+	if attempt < 1 {
+		attempt = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if backoff > float64(p.MaxBackoff) || math.IsInf(backoff, 0) || math.IsNaN(backoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+// jittered 在 backoff 上加入 ±Jitter 比例的随机浮动.
+func (p *Policy) jittered(backoff time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return backoff
+	}
+	delta := (rand.Float64()*2 - 1) * p.Jitter * float64(backoff)
+	return time.Duration(float64(backoff) + delta)
+}
+
+// ShouldRetry 判断错误是否可重试.
+func (p *Policy) ShouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return true
+}
+
+// Do 执行 fn，失败且可重试时按策略等待后重试，返回最后一次的错误.
+// ctx 结束或剩余时间不足以等待下一次重试时不再重试.
+func (p *Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	p = p.WithDefaults()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || !p.ShouldRetry(err) {
+			return err
+		}
+		if !Wait(ctx, p.jittered(p.Backoff(attempt))) {
+			return err
+		}
+	}
+}
+
+// DoValue 与 Policy.Do 相同，返回 fn 最后一次的结果.
+func DoValue[T any](ctx context.Context, p *Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := p.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// Wait 等待 d，ctx 结束或 ctx 剩余时间不足 d 时立即返回 false.
+func Wait(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= d {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Config 各类外部调用的重试策略，未配置的使用默认策略.
+type Config struct {
+	// Embedding Embedding 模型调用
+	Embedding *Policy `mapstructure:"embedding"`
+	// Chat 对话模型调用
+	Chat *Policy `mapstructure:"chat"`
+	// WebFetch web_fetch 工具抓取网页
+	WebFetch *Policy `mapstructure:"web_fetch"`
+}
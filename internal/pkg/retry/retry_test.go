@@ -0,0 +1,143 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffSchedule(t *testing.T) {
+	p := &Policy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, w := range want {
+		if got := p.Backoff(i + 1); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := p.Backoff(0); got != 100*time.Millisecond {
+		t.Errorf("Backoff(0) = %v, want initial backoff", got)
+	}
+	if got := p.Backoff(10000); got != time.Second {
+		t.Errorf("Backoff(10000) = %v, want max backoff", got)
+	}
+
+	// 未设置的字段使用默认值
+	var nilPolicy *Policy
+	if got := nilPolicy.Backoff(2); got != DefaultInitialBackoff*2 {
+		t.Errorf("default Backoff(2) = %v, want %v", got, DefaultInitialBackoff*2)
+	}
+}
+
+func TestJitterStaysInRange(t *testing.T) {
+	p := (&Policy{Jitter: 0.2}).WithDefaults()
+	for range 100 {
+		got := p.jittered(time.Second)
+		if got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("jittered(1s) = %v, want within ±20%%", got)
+		}
+	}
+	if got := (&Policy{Jitter: -1}).WithDefaults().jittered(time.Second); got != time.Second {
+		t.Errorf("jittered without jitter = %v, want 1s", got)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	errTransient := errors.New("503 service unavailable")
+	errBadRequest := errors.New("400 bad request")
+	p := (&Policy{}).WithRetryable(func(err error) bool { return errors.Is(err, errTransient) })
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"canceled", context.Canceled, false},
+		{"deadline", context.DeadlineExceeded, false},
+		{"retryable", errTransient, true},
+		{"not retryable", errBadRequest, false},
+	}
+	for _, tt := range tests {
+		if got := p.ShouldRetry(tt.err); got != tt.want {
+			t.Errorf("%s: ShouldRetry() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !DefaultPolicy().ShouldRetry(errBadRequest) {
+		t.Error("default policy should retry any error except cancellation")
+	}
+}
+
+func TestDoRetries(t *testing.T) {
+	errTransient := errors.New("transient")
+	p := &Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	calls := 0
+	err := p.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Do() = %v after %d calls, want success on the 3rd call", err, calls)
+	}
+
+	calls = 0
+	err = p.Do(context.Background(), func(context.Context) error {
+		calls++
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) || calls != 3 {
+		t.Errorf("Do() = %v after %d calls, want last error after 3 calls", err, calls)
+	}
+
+	// 不可重试的错误立即返回
+	calls = 0
+	permanent := p.WithRetryable(func(error) bool { return false })
+	if err := permanent.Do(context.Background(), func(context.Context) error {
+		calls++
+		return errTransient
+	}); !errors.Is(err, errTransient) || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want a single call", err, calls)
+	}
+}
+
+func TestDoStopsWhenDeadlineTooClose(t *testing.T) {
+	p := &Policy{MaxAttempts: 5, InitialBackoff: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	_ = p.Do(ctx, func(context.Context) error {
+		calls++
+		return errors.New("fail")
+	})
+	if calls != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Do() made %d calls in %v, want it to give up without waiting", calls, time.Since(start))
+	}
+}
+
+func TestDoValue(t *testing.T) {
+	p := &Policy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+	calls := 0
+	got, err := DoValue(context.Background(), p, func(context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("transient")
+		}
+		return 42, nil
+	})
+	if err != nil || got != 42 {
+		t.Errorf("DoValue() = %d, %v, want 42", got, err)
+	}
+}
@@ -0,0 +1,425 @@
+// Package s3 提供兼容 S3 协议的对象存储客户端（AWS S3、MinIO 等）.
+//
+// 仅实现知识库需要的少量操作，使用 AWS Signature V4 签名.
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	shortDateFormat = "20060102"
+)
+
+// Config S3 客户端配置.
+type Config struct {
+	// Endpoint 服务地址，为空时使用 https://s3.<region>.amazonaws.com
+	Endpoint string `json:"endpoint" mapstructure:"endpoint"`
+	// Region 区域，默认 us-east-1
+	Region string `json:"region" mapstructure:"region"`
+	// Bucket 存储桶
+	Bucket string `json:"bucket" mapstructure:"bucket"`
+	// AccessKeyID 访问密钥 ID
+	AccessKeyID string `json:"access_key_id" mapstructure:"access_key_id"`
+	// SecretAccessKey 访问密钥
+	SecretAccessKey string `json:"secret_access_key" mapstructure:"secret_access_key"`
+	// PathStyle 使用路径风格地址（MinIO 等自建服务通常需要开启）
+	PathStyle bool `json:"path_style" mapstructure:"path_style"`
+	// Timeout 单次请求超时，默认 60s
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+// Client S3 客户端.
+type Client struct {
+	endpoint   *url.URL
+	region     string
+	bucket     string
+	accessKey  string
+	secretKey  string
+	pathStyle  bool
+	httpClient *http.Client
+}
+
+// Object 对象信息.
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// ObjectReader 对象内容，使用完毕后需关闭 Body.
+type ObjectReader struct {
+	Object
+	ContentType string
+	Body        io.ReadCloser
+}
+
+// ErrorResponse S3 返回的错误.
+type ErrorResponse struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *ErrorResponse) Error() string {
+	return fmt.Sprintf("s3: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsNotFound 判断错误是否为对象不存在.
+func IsNotFound(err error) bool {
+	if e, ok := err.(*ErrorResponse); ok {
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// New 创建 S3 客户端.
+func New(cfg *Config) (*Client, error) {
+	if cfg == nil || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3: bucket is required")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q", endpoint)
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	return &Client{
+		endpoint:   u,
+		region:     region,
+		bucket:     cfg.Bucket,
+		accessKey:  cfg.AccessKeyID,
+		secretKey:  cfg.SecretAccessKey,
+		pathStyle:  cfg.PathStyle,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Bucket 返回存储桶名称.
+func (c *Client) Bucket() string {
+	return c.bucket
+}
+
+// URI 返回对象的 s3:// 地址.
+func (c *Client) URI(key string) string {
+	return "s3://" + c.bucket + "/" + key
+}
+
+// List 列出前缀下的所有对象（自动翻页）.
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	var token string
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				ETag         string    `xml:"ETag"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3: decode list response: %w", err)
+		}
+
+		for _, o := range result.Contents {
+			objects = append(objects, Object{
+				Key:          o.Key,
+				Size:         o.Size,
+				ETag:         strings.Trim(o.ETag, `"`),
+				LastModified: o.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Get 获取对象内容.
+func (c *Client) Get(ctx context.Context, key string) (*ObjectReader, error) {
+	resp, err := c.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &ObjectReader{
+		Object: Object{
+			Key:          key,
+			Size:         resp.ContentLength,
+			ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+			LastModified: lastModified,
+		},
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        resp.Body,
+	}, nil
+}
+
+// Put 上传对象，size 为内容长度（未知时传 -1，部分兼容服务要求已知长度）.
+func (c *Client) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.doWithLength(ctx, http.MethodPut, key, header, body, size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Delete 删除对象，对象不存在时不报错.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PresignGet 生成限时有效的对象下载地址（查询参数签名）.
+// fileName、contentType 非空时通过 response-content-disposition/response-content-type 覆盖响应头.
+func (c *Client) PresignGet(key string, expires time.Duration, fileName, contentType string) (string, error) {
+	if expires < time.Second || expires > 7*24*time.Hour {
+		return "", fmt.Errorf("s3: presign expiry must be between 1s and 7 days")
+	}
+	return c.presignGet(key, expires, fileName, contentType, time.Now().UTC()), nil
+}
+
+func (c *Client) presignGet(key string, expires time.Duration, fileName, contentType string, now time.Time) string {
+	amzDate := now.Format(amzDateFormat)
+	scope := c.scope(now)
+
+	query := url.Values{
+		"X-Amz-Algorithm":     {signAlgorithm},
+		"X-Amz-Credential":    {c.accessKey + "/" + scope},
+		"X-Amz-Date":          {amzDate},
+		"X-Amz-Expires":       {strconv.Itoa(int(expires.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	if fileName != "" {
+		query.Set("response-content-disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	}
+	if contentType != "" {
+		query.Set("response-content-type", contentType)
+	}
+
+	u := c.objectURL(key, query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	u.RawQuery += "&X-Amz-Signature=" + c.signature(now, amzDate, scope, canonicalRequest)
+	return u.String()
+}
+
+// do 发送签名请求，非 2xx 响应转换为 ErrorResponse.
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("s3: create request: %w", err)
+	}
+	return c.send(req, method, key, header)
+}
+
+// doWithLength 发送带请求体的签名请求，size >= 0 时设置 Content-Length.
+func (c *Client) doWithLength(ctx context.Context, method, key string, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, nil).String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("s3: create request: %w", err)
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+	return c.send(req, method, key, header)
+}
+
+func (c *Client) send(req *http.Request, method, key string, header http.Header) (*http.Response, error) {
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	c.sign(req, time.Now().UTC())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3: %s %s: %w", method, key, err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	e := &ErrorResponse{StatusCode: resp.StatusCode}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = xml.Unmarshal(data, e)
+	if e.Code == "" {
+		e.Code = http.StatusText(resp.StatusCode)
+	}
+	return nil, e
+}
+
+// objectURL 构造对象地址，路径风格为 <endpoint>/<bucket>/<key>，否则为 <bucket>.<host>/<key>.
+func (c *Client) objectURL(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if c.pathStyle {
+		path += "/" + c.bucket
+	} else {
+		u.Host = c.bucket + "." + u.Host
+	}
+	u.Path = path + "/" + key
+	u.RawPath = path + "/" + encodePath(key)
+	u.RawQuery = encodeQuery(query)
+	return &u
+}
+
+// sign 使用 Signature V4 签名请求头.
+func (c *Client) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format(amzDateFormat)
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	var names []string
+	for k := range req.Header {
+		lower := strings.ToLower(k)
+		if lower == "host" || lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			names = append(names, lower)
+		}
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(req.Header.Get(name)) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := c.scope(now)
+	signature := c.signature(now, amzDate, scope, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, c.accessKey, scope, signedHeaders, signature))
+	req.Header.Del("Host")
+	req.Host = req.URL.Host
+}
+
+func (c *Client) scope(now time.Time) string {
+	return now.Format(shortDateFormat) + "/" + c.region + "/s3/aws4_request"
+}
+
+func (c *Client) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := signAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), now.Format(shortDateFormat))
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// encodePath 按 RFC 3986 编码对象 Key，保留路径分隔符.
+func encodePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// encodeQuery 按 Signature V4 要求排序并编码查询参数.
+func encodeQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape 按 RFC 3986 编码（空格编码为 %20，不编码 -_.~）.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -3,8 +3,9 @@ package sse
 
 import (
 	"context"
-	"fmt"
 	"io"
+	"log"
+	"sync"
 
 	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/components/model"
@@ -13,12 +14,22 @@ import (
 
 // AgenticAdapter 将 Agentic 流式事件转换为 SSE 事件。
 type AgenticAdapter struct {
-	writer Writer
+	writer   Writer
+	registry *Registry
+	wg       sync.WaitGroup
 }
 
-// NewAgenticAdapter 创建 Agentic 适配器。
+// NewAgenticAdapter 创建使用默认注册表的 Agentic 适配器。
 func NewAgenticAdapter(writer Writer) *AgenticAdapter {
-	return &AgenticAdapter{writer: writer}
+	return NewAgenticAdapterWithRegistry(writer, DefaultRegistry())
+}
+
+// NewAgenticAdapterWithRegistry 创建使用指定注册表的 Agentic 适配器。
+func NewAgenticAdapterWithRegistry(writer Writer, registry *Registry) *AgenticAdapter {
+	if registry == nil {
+		registry = DefaultRegistry()
+	}
+	return &AgenticAdapter{writer: writer, registry: registry}
 }
 
 // NewCallback 创建 AgenticModel Callback Handler。
@@ -35,7 +46,9 @@ func (a *AgenticAdapter) handleStreamOutput(
 	output *schema.StreamReader[callbacks.CallbackOutput],
 ) context.Context {
 
+	a.wg.Add(1)
 	go func() {
+		defer a.wg.Done()
 		defer output.Close()
 
 		for {
@@ -51,9 +64,9 @@ func (a *AgenticAdapter) handleStreamOutput(
 				break
 			}
 
-			// 转换为 AgenticCallbackOutput
+			// 转换为 AgenticCallbackOutput，工具等非模型节点的输出为 nil
 			modelOutput := model.ConvAgenticCallbackOutput(chunk)
-			if modelOutput.Message == nil {
+			if modelOutput == nil || modelOutput.Message == nil {
 				continue
 			}
 
@@ -67,141 +80,18 @@ func (a *AgenticAdapter) handleStreamOutput(
 	return ctx
 }
 
-// convertBlock 转换 ContentBlock 为 SSE 事件。
+// Wait 等待所有流式输出转换完毕，Agent 运行结束后调用以确保事件均已发送。
+func (a *AgenticAdapter) Wait() {
+	a.wg.Wait()
+}
+
+// convertBlock 通过注册表将 ContentBlock 转换为 SSE 事件并发送。
 func (a *AgenticAdapter) convertBlock(block *schema.ContentBlock) {
-	if block == nil {
-		return
+	events, err := a.registry.Convert(block)
+	for _, event := range events {
+		_ = a.writer.Send(event)
 	}
-
-	switch block.Type {
-	// ========== 推理过程 ==========
-	case schema.ContentBlockTypeReasoning:
-		if block.Reasoning != nil {
-			a.writer.Send(Event{
-				Type:    EventTypeThinking,
-				Content: block.Reasoning.Text,
-			})
-		}
-
-	// ========== 自定义工具调用 ==========
-	case schema.ContentBlockTypeFunctionToolCall:
-		if block.FunctionToolCall != nil {
-			a.writer.Send(Event{
-				Type: EventTypeToolCall,
-				ToolCalls: []map[string]any{
-					{
-						"name":      block.FunctionToolCall.Name,
-						"arguments": block.FunctionToolCall.Arguments,
-						"id":        block.FunctionToolCall.CallID,
-					},
-				},
-			})
-		}
-
-	// ========== 自定义工具结果 ==========
-	case schema.ContentBlockTypeFunctionToolResult:
-		if block.FunctionToolResult != nil {
-			a.writer.Send(Event{
-				Type:    EventTypeToolResult,
-				Content: block.FunctionToolResult.Result,
-			})
-		}
-
-	// ========== Server Tool 调用 ==========
-	case schema.ContentBlockTypeServerToolCall:
-		if block.ServerToolCall != nil {
-			a.writer.Send(Event{
-				Type: EventTypeToolCall,
-				ToolCalls: []map[string]any{
-					{
-						"name":        block.ServerToolCall.Name,
-						"server_tool": true,
-						"id":          block.ServerToolCall.CallID,
-					},
-				},
-			})
-		}
-
-	// ========== Server Tool 结果 ==========
-	case schema.ContentBlockTypeServerToolResult:
-		if block.ServerToolResult != nil {
-			result := fmt.Sprintf("%v", block.ServerToolResult.Result)
-			a.writer.Send(Event{
-				Type:    EventTypeToolResult,
-				Content: result,
-			})
-		}
-
-	// ========== MCP Tool 调用 ==========
-	case schema.ContentBlockTypeMCPToolCall:
-		if block.MCPToolCall != nil {
-			a.writer.Send(Event{
-				Type: EventTypeToolCall,
-				ToolCalls: []map[string]any{
-					{
-						"name":         block.MCPToolCall.Name,
-						"mcp_tool":     true,
-						"server_label": block.MCPToolCall.ServerLabel,
-						"arguments":    block.MCPToolCall.Arguments,
-						"id":           block.MCPToolCall.CallID,
-					},
-				},
-			})
-		}
-
-	// ========== MCP Tool 结果 ==========
-	case schema.ContentBlockTypeMCPToolResult:
-		if block.MCPToolResult != nil {
-			a.writer.Send(Event{
-				Type:    EventTypeToolResult,
-				Content: block.MCPToolResult.Result,
-			})
-		}
-
-	// ========== 文本生成 ==========
-	case schema.ContentBlockTypeAssistantGenText:
-		if block.AssistantGenText != nil {
-			a.writer.Send(Event{
-				Type:    EventTypeAnswer,
-				Content: block.AssistantGenText.Text,
-				Done:    false,
-			})
-		}
-
-	// ========== 图像生成 ==========
-	case schema.ContentBlockTypeAssistantGenImage:
-		if block.AssistantGenImage != nil {
-			a.writer.Send(Event{
-				Type: EventTypeAnswer,
-				Data: map[string]any{
-					"type": "image",
-					"url":  block.AssistantGenImage.URL,
-				},
-			})
-		}
-
-	// ========== 音频生成 ==========
-	case schema.ContentBlockTypeAssistantGenAudio:
-		if block.AssistantGenAudio != nil {
-			a.writer.Send(Event{
-				Type: EventTypeAnswer,
-				Data: map[string]any{
-					"type": "audio",
-					"url":  block.AssistantGenAudio.URL,
-				},
-			})
-		}
-
-	// ========== 视频生成 ==========
-	case schema.ContentBlockTypeAssistantGenVideo:
-		if block.AssistantGenVideo != nil {
-			a.writer.Send(Event{
-				Type: EventTypeAnswer,
-				Data: map[string]any{
-					"type": "video",
-					"url":  block.AssistantGenVideo.URL,
-				},
-			})
-		}
+	if err != nil {
+		log.Printf("sse: convert content block %s: %v", block.Type, err)
 	}
 }
@@ -0,0 +1,280 @@
+// Package sse 提供 SSE 协议封装.
+package sse
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// 默认缓冲事件数.
+const defaultBufferSize = 256
+
+// ErrClientLagging 客户端读取过慢，连接已按 disconnect 策略断开.
+var ErrClientLagging = errors.New("sse client lagging")
+
+// ErrWriterClosed 写入器已关闭.
+var ErrWriterClosed = errors.New("sse writer closed")
+
+// LagPolicy 客户端读取跟不上时的处理策略.
+type LagPolicy string
+
+const (
+	LagPolicyDrop       LagPolicy = "drop"       // 丢弃新事件，恢复后发送 client_lagging 诊断事件
+	LagPolicyDisconnect LagPolicy = "disconnect" // 停止发送并通知调用方断开
+)
+
+// BufferedWriterConfig 缓冲写入器配置.
+type BufferedWriterConfig struct {
+	// BufferSize 每个连接的最大缓冲事件数，默认 256
+	BufferSize int
+	// LagPolicy 缓冲区满时的处理策略，默认 drop
+	LagPolicy LagPolicy
+	// OnDisconnect disconnect 策略触发时调用（通常用于取消 Agent 运行）
+	OnDisconnect func()
+	// HeartbeatInterval 空闲超过该时长时发送 SSE 注释心跳，防止代理断开空闲连接；0 表示不发送.
+	// 底层 Writer 需实现 CommentWriter
+	HeartbeatInterval time.Duration
+}
+
+// BufferedWriter 带有界缓冲队列的 SSE 写入器.
+//
+// 事件先进入队列，由后台 goroutine 串行写入底层 Writer，
+// 读取缓慢的客户端不会阻塞 Agent 运行；队列满时按 LagPolicy 丢弃或断开.
+// 完成和错误事件不会被丢弃，使用完毕后必须调用 Close.
+type BufferedWriter struct {
+	inner        Writer
+	queue        chan Event
+	policy       LagPolicy
+	onDisconnect func()
+	heartbeat    time.Duration
+	// closing 由 Close 关闭，通知后台 goroutine 发送剩余事件后退出，并唤醒阻塞在 sendReliable 的调用方.
+	// 队列本身从不关闭，避免与未持锁的 sendReliable 竞争导致向已关闭的 channel 发送
+	closing chan struct{}
+	done    chan struct{}
+
+	mu      sync.Mutex
+	closed  bool
+	lagging bool
+	dropped int
+	err     error
+
+	// sendErr 底层写入失败的错误（由后台 goroutine 设置，单独加锁使后台写入不依赖 mu）
+	errMu   sync.Mutex
+	sendErr error
+}
+
+// NewBufferedWriter 创建缓冲写入器并启动后台发送.
+func NewBufferedWriter(inner Writer, cfg *BufferedWriterConfig) *BufferedWriter {
+	size := defaultBufferSize
+	policy := LagPolicyDrop
+	var onDisconnect func()
+	var heartbeat time.Duration
+	if cfg != nil {
+		if cfg.BufferSize > 0 {
+			size = cfg.BufferSize
+		}
+		if cfg.LagPolicy != "" {
+			policy = cfg.LagPolicy
+		}
+		onDisconnect = cfg.OnDisconnect
+		heartbeat = cfg.HeartbeatInterval
+	}
+
+	w := &BufferedWriter{
+		inner:        inner,
+		queue:        make(chan Event, size),
+		policy:       policy,
+		onDisconnect: onDisconnect,
+		heartbeat:    heartbeat,
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go w.drain()
+	return w
+}
+
+// drain 串行地将队列中的事件写入底层 Writer，空闲时发送心跳，Close 后停止.
+func (w *BufferedWriter) drain() {
+	defer close(w.done)
+
+	var tick <-chan time.Time
+	commenter, ok := w.inner.(CommentWriter)
+	if ok && w.heartbeat > 0 {
+		ticker := time.NewTicker(w.heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	lastWrite := time.Now()
+	for {
+		select {
+		case event := <-w.queue:
+			w.setSendErr(w.inner.Send(event))
+			lastWrite = time.Now()
+		case <-w.closing:
+			for {
+				select {
+				case event := <-w.queue:
+					w.setSendErr(w.inner.Send(event))
+				default:
+					return
+				}
+			}
+		case <-tick:
+			if time.Since(lastWrite) < w.heartbeat {
+				continue
+			}
+			w.setSendErr(commenter.SendComment("ping"))
+			lastWrite = time.Now()
+		}
+	}
+}
+
+// setSendErr 记录第一次底层写入错误.
+func (w *BufferedWriter) setSendErr(err error) {
+	if err == nil {
+		return
+	}
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	if w.sendErr == nil {
+		w.sendErr = err
+	}
+}
+
+// SetHeaders 设置 SSE 响应头（需在发送任何事件前调用）.
+func (w *BufferedWriter) SetHeaders() {
+	w.inner.SetHeaders()
+}
+
+// Flush 事件由后台 goroutine 写入后立即刷新，此处无需操作.
+func (w *BufferedWriter) Flush() {}
+
+// Send 将事件放入队列，队列满时按 LagPolicy 处理.
+func (w *BufferedWriter) Send(event Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.checkLocked(); err != nil {
+		return err
+	}
+
+	if w.lagging {
+		// 客户端恢复后先告知丢弃了多少事件
+		select {
+		case w.queue <- Event{Type: EventTypeClientLagging, Data: map[string]any{"dropped": w.dropped}}:
+			w.lagging = false
+			w.dropped = 0
+		default:
+			w.dropped++
+			return nil
+		}
+	}
+
+	select {
+	case w.queue <- event:
+		return nil
+	default:
+	}
+
+	if w.policy == LagPolicyDisconnect {
+		log.Printf("sse: client lagging (buffer %d full), disconnecting", cap(w.queue))
+		w.err = ErrClientLagging
+		if w.onDisconnect != nil {
+			w.onDisconnect()
+		}
+		return w.err
+	}
+
+	if !w.lagging {
+		log.Printf("sse: client lagging (buffer %d full), dropping events", cap(w.queue))
+	}
+	w.lagging = true
+	w.dropped++
+	return nil
+}
+
+// checkLocked 返回写入器当前的不可用原因，调用方需持有 mu.
+func (w *BufferedWriter) checkLocked() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	return w.sendErr
+}
+
+// sendReliable 阻塞地将事件放入队列，用于不可丢弃的事件.
+// 阻塞等待队列空位时不持有 mu，Close 可以随时关闭写入器并唤醒等待.
+func (w *BufferedWriter) sendReliable(event Event) error {
+	w.mu.Lock()
+	if err := w.checkLocked(); err != nil {
+		w.mu.Unlock()
+		return err
+	}
+	events := []Event{event}
+	if w.lagging {
+		events = []Event{{Type: EventTypeClientLagging, Data: map[string]any{"dropped": w.dropped}}, event}
+		w.lagging = false
+		w.dropped = 0
+	}
+	w.mu.Unlock()
+
+	for _, e := range events {
+		select {
+		case <-w.closing:
+			return ErrWriterClosed
+		default:
+		}
+		select {
+		case w.queue <- e:
+		case <-w.closing:
+			return ErrWriterClosed
+		}
+	}
+	return nil
+}
+
+// SendStart 发送开始事件.
+func (w *BufferedWriter) SendStart(sessionID, messageID string) error {
+	return w.sendReliable(Event{
+		Type:               EventTypeQuery,
+		ID:                 messageID,
+		AssistantMessageID: messageID,
+		Data:               map[string]interface{}{"session_id": sessionID},
+	})
+}
+
+// SendError 发送错误事件（不会被丢弃）.
+func (w *BufferedWriter) SendError(message string) error {
+	return w.sendReliable(Event{Type: EventTypeError, Content: message})
+}
+
+// SendServerBusy 发送服务繁忙事件（不会被丢弃）.
+func (w *BufferedWriter) SendServerBusy(message string) error {
+	return w.sendReliable(Event{Type: EventTypeServerBusy, Content: message})
+}
+
+// SendComplete 发送完成事件（不会被丢弃）.
+func (w *BufferedWriter) SendComplete(sessionID, messageID string) error {
+	return w.sendReliable(Event{Type: EventTypeComplete, SessionID: sessionID, ID: messageID})
+}
+
+// Close 停止接收新事件，等待已缓冲的事件发送完毕.
+func (w *BufferedWriter) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.closing)
+	w.mu.Unlock()
+
+	<-w.done
+}
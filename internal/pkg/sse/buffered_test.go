@@ -0,0 +1,273 @@
+package sse
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowWriter 模拟读取缓慢的客户端：每次 Send 都等待 release.
+type slowWriter struct {
+	started chan struct{} // 每次开始 Send 时收到一个信号
+	release chan struct{} // 关闭后 Send 不再阻塞
+
+	mu     sync.Mutex
+	events []Event
+}
+
+func newSlowWriter() *slowWriter {
+	return &slowWriter{started: make(chan struct{}, 64), release: make(chan struct{})}
+}
+
+func (w *slowWriter) Send(event Event) error {
+	w.started <- struct{}{}
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = append(w.events, event)
+	return nil
+}
+
+func (w *slowWriter) Flush()                         {}
+func (w *slowWriter) SetHeaders()                    {}
+func (w *slowWriter) SendStart(_, _ string) error    { return nil }
+func (w *slowWriter) SendError(_ string) error       { return nil }
+func (w *slowWriter) SendComplete(_, _ string) error { return nil }
+
+func (w *slowWriter) types() []EventType {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	types := make([]EventType, len(w.events))
+	for i, e := range w.events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+// waitStarted 等待后台 goroutine 开始向客户端写入（此后队列中的事件都在排队）.
+func waitStarted(t *testing.T, w *slowWriter) {
+	t.Helper()
+	select {
+	case <-w.started:
+	case <-time.After(time.Second):
+		t.Fatal("writer did not start sending")
+	}
+}
+
+func TestBufferedWriterDropPolicy(t *testing.T) {
+	inner := newSlowWriter()
+	w := NewBufferedWriter(inner, &BufferedWriterConfig{BufferSize: 2, LagPolicy: LagPolicyDrop})
+
+	// 第一个事件被后台 goroutine 取出并阻塞在客户端，之后两个填满缓冲，其余被丢弃
+	if err := w.Send(Event{Type: "delta"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	waitStarted(t, inner)
+	for i := 0; i < 5; i++ {
+		if err := w.Send(Event{Type: "delta"}); err != nil {
+			t.Fatalf("Send() error = %v, drop policy must not fail", err)
+		}
+	}
+
+	close(inner.release)
+	if err := w.SendComplete("s1", "m1"); err != nil {
+		t.Fatalf("SendComplete() error = %v", err)
+	}
+	w.Close()
+
+	got := inner.types()
+	want := []EventType{"delta", "delta", "delta", EventTypeClientLagging, EventTypeComplete}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("events = %v, want %v", got, want)
+		}
+	}
+	inner.mu.Lock()
+	dropped := inner.events[3].Data["dropped"]
+	inner.mu.Unlock()
+	if dropped != 3 {
+		t.Errorf("dropped = %v, want 3", dropped)
+	}
+}
+
+func TestBufferedWriterDisconnectPolicy(t *testing.T) {
+	inner := newSlowWriter()
+	disconnected := make(chan struct{})
+	w := NewBufferedWriter(inner, &BufferedWriterConfig{
+		BufferSize:   1,
+		LagPolicy:    LagPolicyDisconnect,
+		OnDisconnect: func() { close(disconnected) },
+	})
+
+	if err := w.Send(Event{Type: "delta"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	waitStarted(t, inner)
+	if err := w.Send(Event{Type: "delta"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := w.Send(Event{Type: "delta"}); !errors.Is(err, ErrClientLagging) {
+		t.Fatalf("Send() on full buffer error = %v, want ErrClientLagging", err)
+	}
+	select {
+	case <-disconnected:
+	default:
+		t.Error("OnDisconnect was not called")
+	}
+	if err := w.SendComplete("s1", "m1"); !errors.Is(err, ErrClientLagging) {
+		t.Errorf("SendComplete() after disconnect error = %v, want ErrClientLagging", err)
+	}
+
+	close(inner.release)
+	w.Close()
+}
+
+func TestBufferedWriterCloseUnblocksReliableSend(t *testing.T) {
+	for _, policy := range []LagPolicy{LagPolicyDrop, LagPolicyDisconnect} {
+		t.Run(string(policy), func(t *testing.T) {
+			inner := newSlowWriter()
+			w := NewBufferedWriter(inner, &BufferedWriterConfig{BufferSize: 1, LagPolicy: policy})
+
+			if err := w.Send(Event{Type: "delta"}); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			waitStarted(t, inner)
+			if err := w.Send(Event{Type: "delta"}); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+
+			// 缓冲已满，完成事件阻塞等待空位
+			sent := make(chan error, 1)
+			go func() { sent <- w.SendComplete("s1", "m1") }()
+			select {
+			case err := <-sent:
+				t.Fatalf("SendComplete() returned %v before the buffer had room", err)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			closed := make(chan struct{})
+			go func() {
+				w.Close()
+				close(closed)
+			}()
+			select {
+			case err := <-sent:
+				if !errors.Is(err, ErrWriterClosed) {
+					t.Errorf("SendComplete() error = %v, want ErrWriterClosed", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Close did not unblock SendComplete (deadlock)")
+			}
+
+			// 客户端恢复后 Close 发送完剩余事件并返回
+			close(inner.release)
+			select {
+			case <-closed:
+			case <-time.After(time.Second):
+				t.Fatal("Close did not return")
+			}
+			if err := w.Send(Event{Type: "delta"}); !errors.Is(err, ErrWriterClosed) {
+				t.Errorf("Send() after Close error = %v, want ErrWriterClosed", err)
+			}
+		})
+	}
+}
+
+// commentWriter 记录事件和心跳注释的写入器，注释按 SSE 格式记为 ": <comment>".
+type commentWriter struct {
+	mu       sync.Mutex
+	events   int
+	comments []string
+}
+
+func (w *commentWriter) Send(Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events++
+	return nil
+}
+
+func (w *commentWriter) SendComment(comment string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.comments = append(w.comments, ": "+comment)
+	return nil
+}
+
+func (w *commentWriter) Flush()                         {}
+func (w *commentWriter) SetHeaders()                    {}
+func (w *commentWriter) SendStart(_, _ string) error    { return nil }
+func (w *commentWriter) SendError(_ string) error       { return nil }
+func (w *commentWriter) SendComplete(_, _ string) error { return nil }
+
+func (w *commentWriter) snapshot() (int, []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.events, append([]string(nil), w.comments...)
+}
+
+func TestBufferedWriterHeartbeatWhileIdle(t *testing.T) {
+	inner := &commentWriter{}
+	w := NewBufferedWriter(inner, &BufferedWriterConfig{HeartbeatInterval: 10 * time.Millisecond})
+
+	time.Sleep(80 * time.Millisecond)
+	w.Close()
+
+	_, comments := inner.snapshot()
+	if len(comments) < 2 {
+		t.Fatalf("comments = %q, want repeated heartbeats while idle", comments)
+	}
+	for _, c := range comments {
+		if c != ": ping" {
+			t.Fatalf("comments = %q, want only \": ping\"", comments)
+		}
+	}
+
+	// Close 后不再发送心跳
+	time.Sleep(30 * time.Millisecond)
+	if _, after := inner.snapshot(); len(after) != len(comments) {
+		t.Errorf("heartbeat sent after Close: %d -> %d comments", len(comments), len(after))
+	}
+}
+
+func TestBufferedWriterNoHeartbeatWhileEventsFlow(t *testing.T) {
+	inner := &commentWriter{}
+	w := NewBufferedWriter(inner, &BufferedWriterConfig{HeartbeatInterval: 50 * time.Millisecond})
+
+	// 事件间隔远小于心跳间隔，连接一直活跃
+	for i := 0; i < 40; i++ {
+		if err := w.Send(Event{Type: "delta"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	_, comments := inner.snapshot()
+	if len(comments) != 0 {
+		t.Errorf("comments = %q while events were flowing, want none", comments)
+	}
+
+	// 事件停止后恢复心跳
+	time.Sleep(150 * time.Millisecond)
+	w.Close()
+	events, comments := inner.snapshot()
+	if len(comments) == 0 {
+		t.Error("no heartbeat after events stopped")
+	}
+	if events != 40 {
+		t.Errorf("sent %d events, want 40", events)
+	}
+}
+
+func TestBufferedWriterHeartbeatDisabled(t *testing.T) {
+	inner := &commentWriter{}
+	w := NewBufferedWriter(inner, &BufferedWriterConfig{})
+	time.Sleep(30 * time.Millisecond)
+	w.Close()
+	if _, comments := inner.snapshot(); len(comments) != 0 {
+		t.Errorf("comments = %q, want none without HeartbeatInterval", comments)
+	}
+}
@@ -0,0 +1,232 @@
+// Package sse 提供从 Agentic 流式事件到 SSE 的适配器。
+package sse
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// BlockType 转换器的注册键，内置类型取 schema.ContentBlockType 的值，扩展可使用任意自定义类型。
+type BlockType string
+
+// BlockHandler 将 ContentBlock 转换为 SSE 事件，返回空表示不发送。
+type BlockHandler func(block *schema.ContentBlock) []Event
+
+// Registry SSE 事件注册表，维护 ContentBlock 类型到事件转换器的映射和已注册的事件类型。
+//
+// 同一 ContentBlock 类型可注册多个转换器，按注册顺序依次执行，
+// 扩展（新工具、中间件）可以在不修改内置转换逻辑的情况下追加新的事件类型。
+type Registry struct {
+	mu         sync.RWMutex
+	handlers   map[BlockType][]BlockHandler
+	eventTypes map[EventType]struct{}
+}
+
+// NewRegistry 创建包含内置事件类型和转换器的注册表。
+func NewRegistry() *Registry {
+	r := &Registry{
+		handlers:   make(map[BlockType][]BlockHandler),
+		eventTypes: make(map[EventType]struct{}),
+	}
+	for _, t := range []EventType{
+		EventTypeQuery, EventTypeAnswer, EventTypeThinking, EventTypeToolCall,
+		EventTypeToolResult, EventTypeReferences, EventTypeComplete, EventTypeError, EventTypeUsage,
+		EventTypeClientLagging,
+	} {
+		r.eventTypes[t] = struct{}{}
+	}
+	registerBuiltinHandlers(r)
+	return r
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry 返回全局默认注册表。
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// RegisterEventType 在默认注册表中注册自定义事件类型。
+func RegisterEventType(t EventType) {
+	defaultRegistry.RegisterEventType(t)
+}
+
+// RegisterBlockHandler 在默认注册表中为 ContentBlock 类型追加转换器。
+func RegisterBlockHandler(blockType BlockType, h BlockHandler) {
+	defaultRegistry.RegisterBlockHandler(blockType, h)
+}
+
+// RegisterEventType 注册自定义事件类型。
+func (r *Registry) RegisterEventType(t EventType) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.eventTypes[t] = struct{}{}
+}
+
+// IsRegistered 判断事件类型是否已注册。
+func (r *Registry) IsRegistered(t EventType) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.eventTypes[t]
+	return ok
+}
+
+// RegisterBlockHandler 为 ContentBlock 类型追加转换器。
+func (r *Registry) RegisterBlockHandler(blockType BlockType, h BlockHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[blockType] = append(r.handlers[blockType], h)
+}
+
+// Convert 依次执行 ContentBlock 类型对应的转换器，返回未注册类型的事件时报错。
+func (r *Registry) Convert(block *schema.ContentBlock) ([]Event, error) {
+	if block == nil {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	handlers := r.handlers[BlockType(block.Type)]
+	r.mu.RUnlock()
+
+	var events []Event
+	for _, h := range handlers {
+		for _, event := range h(block) {
+			if !r.IsRegistered(event.Type) {
+				return events, fmt.Errorf("unregistered sse event type: %s", event.Type)
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// registerBuiltinHandlers 注册内置的推理、工具调用、工具结果和生成内容转换器。
+func registerBuiltinHandlers(r *Registry) {
+	// ========== 推理过程 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeReasoning), func(block *schema.ContentBlock) []Event {
+		if block.Reasoning == nil {
+			return nil
+		}
+		return []Event{{Type: EventTypeThinking, Content: block.Reasoning.Text}}
+	})
+
+	// ========== 自定义工具调用 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeFunctionToolCall), func(block *schema.ContentBlock) []Event {
+		if block.FunctionToolCall == nil {
+			return nil
+		}
+		return []Event{{
+			Type: EventTypeToolCall,
+			ToolCalls: []map[string]any{
+				{
+					"name":      block.FunctionToolCall.Name,
+					"arguments": block.FunctionToolCall.Arguments,
+					"id":        block.FunctionToolCall.CallID,
+				},
+			},
+		}}
+	})
+
+	// ========== 自定义工具结果 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeFunctionToolResult), func(block *schema.ContentBlock) []Event {
+		if block.FunctionToolResult == nil {
+			return nil
+		}
+		return []Event{{Type: EventTypeToolResult, Content: block.FunctionToolResult.Result}}
+	})
+
+	// ========== Server Tool 调用 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeServerToolCall), func(block *schema.ContentBlock) []Event {
+		if block.ServerToolCall == nil {
+			return nil
+		}
+		return []Event{{
+			Type: EventTypeToolCall,
+			ToolCalls: []map[string]any{
+				{
+					"name":        block.ServerToolCall.Name,
+					"server_tool": true,
+					"id":          block.ServerToolCall.CallID,
+				},
+			},
+		}}
+	})
+
+	// ========== Server Tool 结果 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeServerToolResult), func(block *schema.ContentBlock) []Event {
+		if block.ServerToolResult == nil {
+			return nil
+		}
+		return []Event{{Type: EventTypeToolResult, Content: fmt.Sprintf("%v", block.ServerToolResult.Result)}}
+	})
+
+	// ========== MCP Tool 调用 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeMCPToolCall), func(block *schema.ContentBlock) []Event {
+		if block.MCPToolCall == nil {
+			return nil
+		}
+		return []Event{{
+			Type: EventTypeToolCall,
+			ToolCalls: []map[string]any{
+				{
+					"name":         block.MCPToolCall.Name,
+					"mcp_tool":     true,
+					"server_label": block.MCPToolCall.ServerLabel,
+					"arguments":    block.MCPToolCall.Arguments,
+					"id":           block.MCPToolCall.CallID,
+				},
+			},
+		}}
+	})
+
+	// ========== MCP Tool 结果 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeMCPToolResult), func(block *schema.ContentBlock) []Event {
+		if block.MCPToolResult == nil {
+			return nil
+		}
+		return []Event{{Type: EventTypeToolResult, Content: block.MCPToolResult.Result}}
+	})
+
+	// ========== 文本生成 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeAssistantGenText), func(block *schema.ContentBlock) []Event {
+		if block.AssistantGenText == nil {
+			return nil
+		}
+		return []Event{{Type: EventTypeAnswer, Content: block.AssistantGenText.Text, Done: false}}
+	})
+
+	// ========== 图像生成 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeAssistantGenImage), func(block *schema.ContentBlock) []Event {
+		if block.AssistantGenImage == nil {
+			return nil
+		}
+		return []Event{{
+			Type: EventTypeAnswer,
+			Data: map[string]any{"type": "image", "url": block.AssistantGenImage.URL},
+		}}
+	})
+
+	// ========== 音频生成 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeAssistantGenAudio), func(block *schema.ContentBlock) []Event {
+		if block.AssistantGenAudio == nil {
+			return nil
+		}
+		return []Event{{
+			Type: EventTypeAnswer,
+			Data: map[string]any{"type": "audio", "url": block.AssistantGenAudio.URL},
+		}}
+	})
+
+	// ========== 视频生成 ==========
+	r.RegisterBlockHandler(BlockType(schema.ContentBlockTypeAssistantGenVideo), func(block *schema.ContentBlock) []Event {
+		if block.AssistantGenVideo == nil {
+			return nil
+		}
+		return []Event{{
+			Type: EventTypeAnswer,
+			Data: map[string]any{"type": "video", "url": block.AssistantGenVideo.URL},
+		}}
+	})
+}
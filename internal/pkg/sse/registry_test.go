@@ -0,0 +1,46 @@
+package sse
+
+import (
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestRegistryCustomBlockType(t *testing.T) {
+	const (
+		progressBlock BlockType = "x_progress"
+		progressEvent EventType = "progress"
+	)
+	r := NewRegistry()
+	r.RegisterBlockHandler(progressBlock, func(block *schema.ContentBlock) []Event {
+		return []Event{{Type: progressEvent, Content: block.Extra["step"].(string)}}
+	})
+
+	block := &schema.ContentBlock{Type: schema.ContentBlockType(progressBlock), Extra: map[string]any{"step": "parsing"}}
+	if _, err := r.Convert(block); err == nil {
+		t.Fatal("Convert with unregistered event type succeeded, want error")
+	}
+
+	r.RegisterEventType(progressEvent)
+	events, err := r.Convert(block)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != progressEvent || events[0].Content != "parsing" {
+		t.Fatalf("events = %+v, want one progress event", events)
+	}
+}
+
+func TestRegistryBuiltinHandlersKept(t *testing.T) {
+	r := NewRegistry()
+	events, err := r.Convert(&schema.ContentBlock{
+		Type:      schema.ContentBlockTypeReasoning,
+		Reasoning: &schema.Reasoning{Text: "thinking"},
+	})
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != EventTypeThinking {
+		t.Fatalf("events = %+v, want one thinking event", events)
+	}
+}
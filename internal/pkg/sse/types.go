@@ -15,10 +15,20 @@ const (
 	EventTypeToolCall EventType = "tool_call"
 	// EventTypeToolResult 工具执行结果
 	EventTypeToolResult EventType = "tool_result"
+	// EventTypeReferences 知识库检索命中的分块（回答的引用来源）
+	EventTypeReferences EventType = "references"
 	// EventTypeComplete 完成事件
 	EventTypeComplete EventType = "stop"
 	// EventTypeError 错误事件
 	EventTypeError EventType = "error"
+	// EventTypeUsage 运行用量与费用汇总
+	EventTypeUsage EventType = "usage"
+	// EventTypeClientLagging 客户端读取过慢，部分事件已被丢弃
+	EventTypeClientLagging EventType = "client_lagging"
+	// EventTypeQueued 服务繁忙，运行正在排队
+	EventTypeQueued EventType = "queued"
+	// EventTypeServerBusy 服务繁忙，排队超时未能开始运行
+	EventTypeServerBusy EventType = "server_busy"
 )
 
 // Event SSE 事件结构（对齐 WeKnora）.
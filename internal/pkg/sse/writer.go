@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,6 +20,11 @@ type Writer interface {
 	SendComplete(sessionID, messageID string) error
 }
 
+// CommentWriter 支持发送 SSE 注释行的写入器（用于心跳保活）.
+type CommentWriter interface {
+	SendComment(comment string) error
+}
+
 // GinWriter 基于 Gin 的 SSE 写入器.
 type GinWriter struct {
 	c       *gin.Context
@@ -53,6 +59,15 @@ func (w *GinWriter) Send(event Event) error {
 	return nil
 }
 
+// SendComment 发送 SSE 注释行，客户端会忽略该行，仅用于保持连接活跃.
+func (w *GinWriter) SendComment(comment string) error {
+	if _, err := fmt.Fprintf(w.c.Writer, ": %s\n\n", comment); err != nil {
+		return fmt.Errorf("write comment failed: %w", err)
+	}
+	w.Flush()
+	return nil
+}
+
 // Flush 刷新缓冲区.
 func (w *GinWriter) Flush() {
 	defer func() { _ = recover() }()
@@ -80,3 +95,55 @@ func (w *GinWriter) SendStart(sessionID, messageID string) error {
 		Data:               map[string]interface{}{"session_id": sessionID},
 	})
 }
+
+// RecordingWriter 将事件记录在内存中的写入器，用于非流式地收集运行过程.
+type RecordingWriter struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewRecordingWriter 创建记录写入器.
+func NewRecordingWriter() *RecordingWriter {
+	return &RecordingWriter{}
+}
+
+// Send 记录事件.
+func (w *RecordingWriter) Send(event Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.events = append(w.events, event)
+	return nil
+}
+
+// Flush 无需操作.
+func (w *RecordingWriter) Flush() {}
+
+// SetHeaders 无需操作.
+func (w *RecordingWriter) SetHeaders() {}
+
+// SendStart 记录开始事件.
+func (w *RecordingWriter) SendStart(sessionID, messageID string) error {
+	return w.Send(Event{
+		Type:               EventTypeQuery,
+		ID:                 messageID,
+		AssistantMessageID: messageID,
+		Data:               map[string]interface{}{"session_id": sessionID},
+	})
+}
+
+// SendError 记录错误事件.
+func (w *RecordingWriter) SendError(message string) error {
+	return w.Send(Event{Type: EventTypeError, Content: message})
+}
+
+// SendComplete 记录完成事件.
+func (w *RecordingWriter) SendComplete(sessionID, messageID string) error {
+	return w.Send(Event{Type: EventTypeComplete, SessionID: sessionID, ID: messageID})
+}
+
+// Events 返回已记录的事件副本.
+func (w *RecordingWriter) Events() []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]Event(nil), w.events...)
+}
@@ -0,0 +1,56 @@
+// Package tokenizer 提供文本的 token 计数，用于按 token 控制分块大小.
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Counter 统计文本的 token 数.
+// 需要精确计数时可以接入 tiktoken 等 BPE 分词器实现该接口.
+type Counter interface {
+	Count(text string) int
+}
+
+// Estimator 在没有词表的情况下近似 tiktoken（cl100k_base）的 token 数.
+// 估算偏保守：按估算值切分的分块实际 token 数通常不超过估算值.
+//   - 连续的字母数字按每 4 个字节 1 个 token 计（不足 4 个按 1 个计）
+//   - 中日韩等表意文字和假名、谚文每个字符计 1 个 token
+//   - 其他标点和符号每个字符计 1 个 token，空白不计
+type Estimator struct{}
+
+// Count 估算文本的 token 数.
+func (Estimator) Count(text string) int {
+	tokens := 0
+	wordBytes := 0
+	flush := func() {
+		if wordBytes > 0 {
+			tokens += (wordBytes + 3) / 4
+			wordBytes = 0
+		}
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordBytes += utf8.RuneLen(r)
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// isCJK 判断字符是否为中日韩表意文字、假名或谚文.
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
@@ -0,0 +1,25 @@
+package tokenizer
+
+import "testing"
+
+func TestEstimatorCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"   \n\t", 0},
+		{"cat", 1},
+		{"hello", 2},
+		{"hello world", 4},
+		{"hello, world!", 6},
+		{"中文分块", 4},
+		{"GPT4 模型", 3},
+		{"ひらがな", 4},
+	}
+	for _, tt := range tests {
+		if got := (Estimator{}).Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Package trace 提供可观测性集成.
+package trace
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// StreamEventType 流式事件类型.
+type StreamEventType string
+
+const (
+	StreamEventModelStart StreamEventType = "model_start" // 模型开始生成
+	StreamEventModelDelta StreamEventType = "model_delta" // 模型增量输出
+	StreamEventModelEnd   StreamEventType = "model_end"   // 模型生成结束
+	StreamEventToolStart  StreamEventType = "tool_start"  // 工具开始执行
+	StreamEventToolDelta  StreamEventType = "tool_delta"  // 流式工具增量输出
+	StreamEventToolEnd    StreamEventType = "tool_end"    // 工具执行结束
+	StreamEventError      StreamEventType = "error"       // 组件执行出错
+)
+
+// StreamEvent 转发给调用方的流式事件.
+type StreamEvent struct {
+	Type      StreamEventType      `json:"type"`
+	Component components.Component `json:"component"`
+	Name      string               `json:"name"`
+	// Content 模型文本增量 / 工具参数 / 工具结果
+	Content string `json:"content,omitempty"`
+	// Reasoning 模型推理增量
+	Reasoning string `json:"reasoning,omitempty"`
+	// ToolCalls 模型发起的工具调用名称
+	ToolCalls  []string          `json:"tool_calls,omitempty"`
+	TokenUsage *model.TokenUsage `json:"token_usage,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Time       time.Time         `json:"time"`
+}
+
+// ChannelTracerConfig 通道追踪器配置.
+type ChannelTracerConfig struct {
+	// Channel 接收事件的通道，由调用方创建和关闭（与 Callback 至少设置一个）
+	Channel chan<- *StreamEvent
+	// Callback 接收事件的回调，可能被多个 goroutine 并发调用
+	Callback func(ctx context.Context, event *StreamEvent)
+	// DropOnFull 通道已满时丢弃事件而不是阻塞模型执行
+	DropOnFull bool
+}
+
+// ChannelTracer 将模型流式增量和工具事件转发到通道或回调的追踪器.
+//
+// 生命周期：
+//   - 通过 Register 全局注册，或通过 Handler 配合 compose.WithCallbacks 仅用于单次运行；
+//   - 每个回调处理器拿到的是独立的流副本，可与 LogTracer、CozeLoopTracer 同时注册；
+//   - 流式输出在后台 goroutine 中读取，事件可能在 Invoke/Stream 返回后才到达；
+//   - 调用 Close 后不再投递任何事件，之后调用方才可以安全关闭 Channel.
+type ChannelTracer struct {
+	handler    callbacks.Handler
+	ch         chan<- *StreamEvent
+	callback   func(ctx context.Context, event *StreamEvent)
+	dropOnFull bool
+
+	// done 在 Close 时关闭，唤醒阻塞在 Channel 上的投递
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewChannelTracer 创建通道追踪器.
+func NewChannelTracer(cfg *ChannelTracerConfig) *ChannelTracer {
+	t := &ChannelTracer{done: make(chan struct{})}
+	if cfg != nil {
+		t.ch = cfg.Channel
+		t.callback = cfg.Callback
+		t.dropOnFull = cfg.DropOnFull
+	}
+	t.handler = t.buildHandler()
+	return t
+}
+
+// Register 注册全局回调处理器.
+func (t *ChannelTracer) Register() {
+	callbacks.AppendGlobalHandlers(t.handler)
+}
+
+// Handler 获取回调处理器.
+func (t *ChannelTracer) Handler() callbacks.Handler {
+	return t.handler
+}
+
+// Close 停止投递事件，返回后不会再向 Channel 写入.
+//
+// 阻塞在 Channel 上的投递会被立即放弃，因此即使没有读取方 Close 也不会阻塞；
+// Close 仍会等待正在执行的 Callback 返回.
+func (t *ChannelTracer) Close() {
+	t.closeOnce.Do(func() { close(t.done) })
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+}
+
+// emit 投递事件.
+func (t *ChannelTracer) emit(ctx context.Context, event *StreamEvent) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return
+	}
+
+	event.Time = time.Now()
+	if t.callback != nil {
+		t.callback(ctx, event)
+	}
+	if t.ch == nil {
+		return
+	}
+	if t.dropOnFull {
+		select {
+		case t.ch <- event:
+		default:
+		}
+		return
+	}
+	select {
+	case t.ch <- event:
+	case <-ctx.Done():
+	case <-t.done:
+	}
+}
+
+func (t *ChannelTracer) buildHandler() callbacks.Handler {
+	builder := callbacks.NewHandlerBuilder()
+	builder.
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
+			switch info.Component {
+			case components.ComponentOfTool:
+				t.emit(ctx, &StreamEvent{
+					Type:      StreamEventToolStart,
+					Component: info.Component,
+					Name:      info.Name,
+					Content:   tool.ConvCallbackInput(input).ArgumentsInJSON,
+				})
+			case components.ComponentOfChatModel, components.ComponentOfAgenticModel:
+				t.emit(ctx, newStreamEvent(StreamEventModelStart, info))
+			}
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			switch info.Component {
+			case components.ComponentOfTool:
+				t.emit(ctx, &StreamEvent{
+					Type:      StreamEventToolEnd,
+					Component: info.Component,
+					Name:      info.Name,
+					Content:   tool.ConvCallbackOutput(output).Response,
+				})
+			case components.ComponentOfChatModel, components.ComponentOfAgenticModel:
+				event := t.modelEvent(StreamEventModelEnd, info, output)
+				if event != nil {
+					t.emit(ctx, event)
+				}
+			}
+			return ctx
+		}).
+		OnStartWithStreamInputFn(func(ctx context.Context, info *callbacks.RunInfo, input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
+			input.Close()
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			switch info.Component {
+			case components.ComponentOfTool, components.ComponentOfChatModel, components.ComponentOfAgenticModel:
+				go t.consumeStream(ctx, info, output)
+			default:
+				output.Close()
+			}
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			event := newStreamEvent(StreamEventError, info)
+			event.Error = err.Error()
+			t.emit(ctx, event)
+			return ctx
+		})
+	return builder.Build()
+}
+
+// consumeStream 读取流式输出并逐块转发，结束时发送 end 事件.
+func (t *ChannelTracer) consumeStream(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) {
+	defer output.Close()
+
+	isTool := info.Component == components.ComponentOfTool
+	var usage *model.TokenUsage
+	for {
+		chunk, err := output.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			event := newStreamEvent(StreamEventError, info)
+			event.Error = err.Error()
+			t.emit(ctx, event)
+			return
+		}
+
+		if isTool {
+			if resp := tool.ConvCallbackOutput(chunk).Response; resp != "" {
+				event := newStreamEvent(StreamEventToolDelta, info)
+				event.Content = resp
+				t.emit(ctx, event)
+			}
+			continue
+		}
+
+		event := t.modelEvent(StreamEventModelDelta, info, chunk)
+		if event == nil {
+			continue
+		}
+		if event.TokenUsage != nil {
+			usage = event.TokenUsage
+		}
+		if event.Content != "" || event.Reasoning != "" || len(event.ToolCalls) > 0 {
+			event.TokenUsage = nil
+			t.emit(ctx, event)
+		}
+	}
+
+	if isTool {
+		t.emit(ctx, newStreamEvent(StreamEventToolEnd, info))
+		return
+	}
+	end := newStreamEvent(StreamEventModelEnd, info)
+	end.TokenUsage = usage
+	t.emit(ctx, end)
+}
+
+// modelEvent 将 ChatModel / AgenticModel 输出转换为事件.
+func (t *ChannelTracer) modelEvent(eventType StreamEventType, info *callbacks.RunInfo, output callbacks.CallbackOutput) *StreamEvent {
+	event := newStreamEvent(eventType, info)
+
+	if info.Component == components.ComponentOfChatModel {
+		cco := model.ConvCallbackOutput(output)
+		if cco == nil {
+			return nil
+		}
+		event.TokenUsage = cco.TokenUsage
+		if cco.Message != nil {
+			event.Content = cco.Message.Content
+			event.Reasoning = cco.Message.ReasoningContent
+			for _, tc := range cco.Message.ToolCalls {
+				event.ToolCalls = append(event.ToolCalls, tc.Function.Name)
+			}
+		}
+		return event
+	}
+
+	aco := model.ConvAgenticCallbackOutput(output)
+	if aco == nil {
+		return nil
+	}
+	event.TokenUsage = aco.TokenUsage
+	if aco.Message != nil {
+		for _, block := range aco.Message.ContentBlocks {
+			if block == nil {
+				continue
+			}
+			switch block.Type {
+			case schema.ContentBlockTypeAssistantGenText:
+				if block.AssistantGenText != nil {
+					event.Content += block.AssistantGenText.Text
+				}
+			case schema.ContentBlockTypeReasoning:
+				if block.Reasoning != nil {
+					event.Reasoning += block.Reasoning.Text
+				}
+			case schema.ContentBlockTypeFunctionToolCall:
+				if block.FunctionToolCall != nil {
+					event.ToolCalls = append(event.ToolCalls, block.FunctionToolCall.Name)
+				}
+			case schema.ContentBlockTypeMCPToolCall:
+				if block.MCPToolCall != nil {
+					event.ToolCalls = append(event.ToolCalls, block.MCPToolCall.Name)
+				}
+			}
+		}
+	}
+	return event
+}
+
+func newStreamEvent(eventType StreamEventType, info *callbacks.RunInfo) *StreamEvent {
+	return &StreamEvent{
+		Type:      eventType,
+		Component: info.Component,
+		Name:      info.Name,
+	}
+}
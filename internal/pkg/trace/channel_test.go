@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+)
+
+func TestChannelTracerCloseUnblocksPendingSend(t *testing.T) {
+	ch := make(chan *StreamEvent) // 无缓冲且无人读取
+	tracer := NewChannelTracer(&ChannelTracerConfig{Channel: ch})
+	info := &callbacks.RunInfo{Name: "chat", Component: components.ComponentOfChatModel}
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		tracer.emit(context.Background(), newStreamEvent(StreamEventModelStart, info))
+	}()
+	time.Sleep(20 * time.Millisecond) // 等待投递阻塞在 Channel 上
+
+	closed := make(chan struct{})
+	go func() {
+		tracer.Close()
+		tracer.Close() // 重复调用不 panic
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked on a pending send")
+	}
+	<-sent
+
+	tracer.emit(context.Background(), newStreamEvent(StreamEventModelEnd, info))
+	select {
+	case event := <-ch:
+		t.Fatalf("received %s after Close", event.Type)
+	default:
+	}
+}
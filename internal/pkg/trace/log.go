@@ -3,8 +3,12 @@ package trace
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudwego/eino/callbacks"
@@ -24,15 +28,70 @@ const (
 	colorReset  = "\033[0m"
 )
 
+// LogFormat 日志输出格式.
+type LogFormat string
+
+const (
+	LogFormatPretty LogFormat = "pretty" // 人类可读格式（默认）
+	LogFormatJSON   LogFormat = "json"   // 每行一个 JSON 对象，便于接入结构化日志
+)
+
+// LogTracerConfig 本地日志追踪器配置.
+type LogTracerConfig struct {
+	// Verbose 详细模式（不截断内容）
+	Verbose bool
+	// Writer 日志输出目标，默认 os.Stdout
+	Writer io.Writer
+	// Format 输出格式，默认 pretty
+	Format LogFormat
+	// NoColor 是否关闭 ANSI 颜色；为 nil 时自动判断（非终端或设置了 NO_COLOR 环境变量时关闭）
+	NoColor *bool
+	// RedactKeys 工具参数和结果中需要脱敏的字段名，为 nil 时使用 DefaultRedactKeys，空切片表示不脱敏
+	RedactKeys []string
+}
+
 // LogTracer 本地日志追踪器.
 type LogTracer struct {
-	handler callbacks.Handler
-	verbose bool
+	handler  callbacks.Handler
+	verbose  bool
+	writer   io.Writer
+	format   LogFormat
+	color    bool
+	redactor *redactor
+	mu       sync.Mutex
 }
 
-// NewLogTracer 创建本地日志追踪器.
+// NewLogTracer 创建本地日志追踪器（输出到 stdout）.
 func NewLogTracer(verbose bool) *LogTracer {
-	t := &LogTracer{verbose: verbose}
+	return NewLogTracerWithConfig(&LogTracerConfig{Verbose: verbose})
+}
+
+// NewLogTracerWithConfig 根据配置创建本地日志追踪器.
+func NewLogTracerWithConfig(cfg *LogTracerConfig) *LogTracer {
+	if cfg == nil {
+		cfg = &LogTracerConfig{}
+	}
+	t := &LogTracer{
+		verbose: cfg.Verbose,
+		writer:  cfg.Writer,
+		format:  cfg.Format,
+	}
+	if t.writer == nil {
+		t.writer = os.Stdout
+	}
+	if t.format == "" {
+		t.format = LogFormatPretty
+	}
+	redactKeys := cfg.RedactKeys
+	if redactKeys == nil {
+		redactKeys = DefaultRedactKeys
+	}
+	t.redactor = newRedactor(redactKeys)
+	if cfg.NoColor != nil {
+		t.color = !*cfg.NoColor
+	} else {
+		t.color = isTerminal(t.writer) && os.Getenv("NO_COLOR") == ""
+	}
 	t.handler = t.buildHandler()
 	return t
 }
@@ -47,109 +106,185 @@ func (t *LogTracer) Handler() callbacks.Handler {
 	return t.handler
 }
 
+// logEvent 日志事件类型.
+type logEvent string
+
+const (
+	logEventStart       logEvent = "start"
+	logEventEnd         logEvent = "end"
+	logEventStreamStart logEvent = "stream_start"
+	logEventStreamEnd   logEvent = "stream_end"
+	logEventError       logEvent = "error"
+)
+
+// logEntry 单条日志记录.
+type logEntry struct {
+	Time             time.Time `json:"time"`
+	Event            logEvent  `json:"event"`
+	Component        string    `json:"component"`
+	Name             string    `json:"name"`
+	Args             string    `json:"args,omitempty"`
+	Result           string    `json:"result,omitempty"`
+	Message          string    `json:"message,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	Error            string    `json:"error,omitempty"`
+}
+
 func (t *LogTracer) buildHandler() callbacks.Handler {
 	builder := callbacks.NewHandlerBuilder()
 	builder.
 		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, input callbacks.CallbackInput) context.Context {
-			timestamp := time.Now().Format("15:04:05.000")
-			switch info.Component {
-			case components.ComponentOfTool:
+			entry := newLogEntry(logEventStart, info)
+			if info.Component == components.ComponentOfTool {
 				tci := tool.ConvCallbackInput(input)
-				args := tci.ArgumentsInJSON
+				args := t.redactor.redact(tci.ArgumentsInJSON)
 				if len(args) > 200 && !t.verbose {
 					args = args[:200] + "..."
 				}
-				fmt.Printf("%s[%s]%s %s▶ TOOL%s [%s] args: %s\n",
-					colorCyan, timestamp, colorReset,
-					colorYellow, colorReset,
-					info.Name, args)
-			case components.ComponentOfChatModel, components.ComponentOfAgenticModel:
-				fmt.Printf("%s[%s]%s %s▶ MODEL%s [%s] generating...\n",
-					colorCyan, timestamp, colorReset,
-					colorBlue, colorReset,
-					info.Name)
-			default:
-				fmt.Printf("%s[%s]%s %s▶ %s%s [%s] started\n",
-					colorCyan, timestamp, colorReset,
-					colorPurple, info.Component, colorReset,
-					info.Name)
+				entry.Args = args
 			}
+			t.write(entry)
 			return ctx
 		}).
 		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
-			timestamp := time.Now().Format("15:04:05.000")
+			entry := newLogEntry(logEventEnd, info)
 			switch info.Component {
 			case components.ComponentOfTool:
 				tco := tool.ConvCallbackOutput(output)
-				result := tco.Response
+				result := t.redactor.redact(tco.Response)
 				if len(result) > 200 && !t.verbose {
 					result = result[:200] + "..."
 				}
-				fmt.Printf("%s[%s]%s %s◀ TOOL%s [%s] result: %s\n",
-					colorCyan, timestamp, colorReset,
-					colorYellow, colorReset,
-					info.Name, result)
+				entry.Result = result
 			case components.ComponentOfChatModel:
 				cco := model.ConvCallbackOutput(output)
-				msg := formatMessage(cco.Message, t.verbose)
-				usage := ""
+				entry.Message = formatMessage(cco.Message, t.verbose)
 				if cco.TokenUsage != nil {
-					usage = fmt.Sprintf(" (tokens: %d→%d)", cco.TokenUsage.PromptTokens, cco.TokenUsage.CompletionTokens)
+					entry.PromptTokens = cco.TokenUsage.PromptTokens
+					entry.CompletionTokens = cco.TokenUsage.CompletionTokens
 				}
-				fmt.Printf("%s[%s]%s %s◀ MODEL%s [%s] %s%s\n",
-					colorCyan, timestamp, colorReset,
-					colorBlue, colorReset,
-					info.Name, msg, usage)
 			case components.ComponentOfAgenticModel:
 				aco := model.ConvAgenticCallbackOutput(output)
-				if aco.Message != nil {
-					msg := formatAgenticMessage(aco.Message, t.verbose)
-					usage := ""
-					if aco.TokenUsage != nil {
-						usage = fmt.Sprintf(" (tokens: %d→%d)", aco.TokenUsage.PromptTokens, aco.TokenUsage.CompletionTokens)
-					}
-					fmt.Printf("%s[%s]%s %s◀ MODEL%s [%s] %s%s\n",
-						colorCyan, timestamp, colorReset,
-						colorBlue, colorReset,
-						info.Name, msg, usage)
+				if aco.Message == nil {
+					return ctx
+				}
+				entry.Message = formatAgenticMessage(aco.Message, t.verbose)
+				if aco.TokenUsage != nil {
+					entry.PromptTokens = aco.TokenUsage.PromptTokens
+					entry.CompletionTokens = aco.TokenUsage.CompletionTokens
 				}
-			default:
-				fmt.Printf("%s[%s]%s %s◀ %s%s [%s] completed\n",
-					colorCyan, timestamp, colorReset,
-					colorPurple, info.Component, colorReset,
-					info.Name)
 			}
+			t.write(entry)
 			return ctx
 		}).
 		OnStartWithStreamInputFn(func(ctx context.Context, info *callbacks.RunInfo, input *schema.StreamReader[callbacks.CallbackInput]) context.Context {
 			input.Close()
-			timestamp := time.Now().Format("15:04:05.000")
-			fmt.Printf("%s[%s]%s %s▶ %s%s [%s] stream started\n",
-				colorCyan, timestamp, colorReset,
-				colorGreen, info.Component, colorReset,
-				info.Name)
+			t.write(newLogEntry(logEventStreamStart, info))
 			return ctx
 		}).
 		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
 			output.Close()
-			timestamp := time.Now().Format("15:04:05.000")
-			fmt.Printf("%s[%s]%s %s◀ %s%s [%s] stream completed\n",
-				colorCyan, timestamp, colorReset,
-				colorGreen, info.Component, colorReset,
-				info.Name)
+			t.write(newLogEntry(logEventStreamEnd, info))
 			return ctx
 		}).
 		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
-			timestamp := time.Now().Format("15:04:05.000")
-			fmt.Printf("%s[%s]%s %s✖ ERROR%s [%s:%s] %v\n",
-				colorCyan, timestamp, colorReset,
-				colorRed, colorReset,
-				info.Component, info.Name, err)
+			entry := newLogEntry(logEventError, info)
+			entry.Error = err.Error()
+			t.write(entry)
 			return ctx
 		})
 	return builder.Build()
 }
 
+func newLogEntry(event logEvent, info *callbacks.RunInfo) *logEntry {
+	return &logEntry{
+		Time:      time.Now(),
+		Event:     event,
+		Component: string(info.Component),
+		Name:      info.Name,
+	}
+}
+
+// write 按配置格式输出日志.
+func (t *LogTracer) write(entry *logEntry) {
+	var line string
+	if t.format == LogFormatJSON {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		line = string(data) + "\n"
+	} else {
+		line = t.formatPretty(entry)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = io.WriteString(t.writer, line)
+}
+
+// formatPretty 格式化为人类可读的单行日志.
+func (t *LogTracer) formatPretty(e *logEntry) string {
+	c := func(color string) string {
+		if t.color {
+			return color
+		}
+		return ""
+	}
+	reset := c(colorReset)
+	prefix := fmt.Sprintf("%s[%s]%s ", c(colorCyan), e.Time.Format("15:04:05.000"), reset)
+
+	isTool := e.Component == string(components.ComponentOfTool)
+	isModel := e.Component == string(components.ComponentOfChatModel) || e.Component == string(components.ComponentOfAgenticModel)
+
+	var body string
+	switch e.Event {
+	case logEventStart:
+		switch {
+		case isTool:
+			body = fmt.Sprintf("%s▶ TOOL%s [%s] args: %s", c(colorYellow), reset, e.Name, e.Args)
+		case isModel:
+			body = fmt.Sprintf("%s▶ MODEL%s [%s] generating...", c(colorBlue), reset, e.Name)
+		default:
+			body = fmt.Sprintf("%s▶ %s%s [%s] started", c(colorPurple), e.Component, reset, e.Name)
+		}
+	case logEventEnd:
+		switch {
+		case isTool:
+			body = fmt.Sprintf("%s◀ TOOL%s [%s] result: %s", c(colorYellow), reset, e.Name, e.Result)
+		case isModel:
+			usage := ""
+			if e.PromptTokens > 0 || e.CompletionTokens > 0 {
+				usage = fmt.Sprintf(" (tokens: %d→%d)", e.PromptTokens, e.CompletionTokens)
+			}
+			body = fmt.Sprintf("%s◀ MODEL%s [%s] %s%s", c(colorBlue), reset, e.Name, e.Message, usage)
+		default:
+			body = fmt.Sprintf("%s◀ %s%s [%s] completed", c(colorPurple), e.Component, reset, e.Name)
+		}
+	case logEventStreamStart:
+		body = fmt.Sprintf("%s▶ %s%s [%s] stream started", c(colorGreen), e.Component, reset, e.Name)
+	case logEventStreamEnd:
+		body = fmt.Sprintf("%s◀ %s%s [%s] stream completed", c(colorGreen), e.Component, reset, e.Name)
+	case logEventError:
+		body = fmt.Sprintf("%s✖ ERROR%s [%s:%s] %s", c(colorRed), reset, e.Component, e.Name, e.Error)
+	}
+	return prefix + body + "\n"
+}
+
+// isTerminal 判断 writer 是否为终端.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func formatMessage(m *schema.Message, verbose bool) string {
 	if m == nil {
 		return "<nil>"
@@ -0,0 +1,104 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/schema"
+)
+
+// emitSpans 依次触发一次工具调用、一次模型输出和一次错误.
+func emitSpans(h callbacks.Handler) {
+	ctx := context.Background()
+	toolInfo := &callbacks.RunInfo{Name: "web_fetch", Component: components.ComponentOfTool}
+	modelInfo := &callbacks.RunInfo{Name: "chat", Component: components.ComponentOfChatModel}
+
+	h.OnStart(ctx, toolInfo, &tool.CallbackInput{ArgumentsInJSON: `{"url":"https://example.com"}`})
+	h.OnEnd(ctx, toolInfo, &tool.CallbackOutput{Response: "fetched"})
+	h.OnEnd(ctx, modelInfo, &model.CallbackOutput{
+		Message:    schema.AssistantMessage("hello", nil),
+		TokenUsage: &model.TokenUsage{PromptTokens: 12, CompletionTokens: 3},
+	})
+	h.OnError(ctx, modelInfo, errors.New("rate limited"))
+}
+
+func TestLogTracerWritesPlainLines(t *testing.T) {
+	var buf bytes.Buffer
+	noColor := true
+	tracer := NewLogTracerWithConfig(&LogTracerConfig{Writer: &buf, NoColor: &noColor})
+	emitSpans(tracer.Handler())
+
+	out := buf.String()
+	if strings.Contains(out, "\033[") {
+		t.Fatalf("output contains ANSI escape codes: %q", out)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	want := []string{
+		`▶ TOOL [web_fetch] args: {"url":"https://example.com"}`,
+		`◀ TOOL [web_fetch] result: fetched`,
+		`◀ MODEL [chat] [assistant] "hello" (tokens: 12→3)`,
+		`✖ ERROR [ChatModel:chat] rate limited`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), out)
+	}
+	timestamp := regexp.MustCompile(`^\[\d{2}:\d{2}:\d{2}\.\d{3}\] `)
+	for i, line := range lines {
+		if !timestamp.MatchString(line) {
+			t.Errorf("line %d = %q, want a timestamp prefix", i, line)
+			continue
+		}
+		if body := timestamp.ReplaceAllString(line, ""); body != want[i] {
+			t.Errorf("line %d = %q, want %q", i, body, want[i])
+		}
+	}
+}
+
+func TestLogTracerColor(t *testing.T) {
+	// 未指定时按输出目标判断，写入 Buffer 等非终端时不输出颜色
+	var auto bytes.Buffer
+	emitSpans(NewLogTracerWithConfig(&LogTracerConfig{Writer: &auto}).Handler())
+	if strings.Contains(auto.String(), "\033[") {
+		t.Errorf("non-terminal output contains ANSI escape codes: %q", auto.String())
+	}
+
+	var colored bytes.Buffer
+	noColor := false
+	emitSpans(NewLogTracerWithConfig(&LogTracerConfig{Writer: &colored, NoColor: &noColor}).Handler())
+	if !strings.Contains(colored.String(), colorYellow+"▶ TOOL"+colorReset) {
+		t.Errorf("output = %q, want coloured tool spans when colour is forced on", colored.String())
+	}
+}
+
+func TestLogTracerJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	tracer := NewLogTracerWithConfig(&LogTracerConfig{Writer: &buf, Format: LogFormatJSON})
+	emitSpans(tracer.Handler())
+
+	var entries []logEntry
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var e logEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(entries))
+	}
+	if e := entries[2]; e.Event != logEventEnd || e.Name != "chat" || e.PromptTokens != 12 || e.CompletionTokens != 3 {
+		t.Errorf("model entry = %+v, want chat end with token usage", e)
+	}
+	if e := entries[3]; e.Event != logEventError || e.Error != "rate limited" {
+		t.Errorf("error entry = %+v, want the error message", e)
+	}
+}
@@ -0,0 +1,117 @@
+// Package trace 提供可观测性集成.
+package trace
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// redactedValue 脱敏后的占位值.
+const redactedValue = "******"
+
+// DefaultRedactKeys 默认需要脱敏的字段名.
+var DefaultRedactKeys = []string{"password", "passwd", "token", "secret", "key", "authorization", "credential"}
+
+// redactor 按字段名对 JSON / key=value 文本进行脱敏.
+//
+// 字段名统一转小写并去掉 "_"、"-" 后，以任一脱敏关键字结尾即视为敏感字段，
+// 因此 api_key、access_token、client_secret 会被脱敏，而 keyword、max_tokens 不会.
+type redactor struct {
+	keys    []string
+	pattern *regexp.Regexp
+}
+
+func newRedactor(keys []string) *redactor {
+	r := &redactor{}
+	var quoted []string
+	for _, k := range keys {
+		k = normalizeRedactKey(k)
+		if k == "" {
+			continue
+		}
+		r.keys = append(r.keys, k)
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+	if len(quoted) > 0 {
+		// 匹配非 JSON 文本中的 key=value / key: value / "key": "value"
+		r.pattern = regexp.MustCompile(`(?i)("?[\w-]*(?:` + strings.Join(quoted, "|") + `)"?\s*[:=]\s*"?(?:(?:bearer|basic)\s+)?)([^\s",}&]+)`)
+	}
+	return r
+}
+
+// redact 脱敏文本，无法解析为 JSON 时按正则替换.
+func (r *redactor) redact(text string) string {
+	if r == nil || len(r.keys) == 0 || text == "" {
+		return text
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err == nil {
+		if masked, changed := r.redactValue(v); changed {
+			if data, err := json.Marshal(masked); err == nil {
+				return string(data)
+			}
+		}
+		return text
+	}
+
+	return r.pattern.ReplaceAllStringFunc(text, func(match string) string {
+		sub := r.pattern.FindStringSubmatch(match)
+		if len(sub) < 3 || !r.isSensitive(extractKey(sub[1])) {
+			return match
+		}
+		return sub[1] + redactedValue
+	})
+}
+
+// redactValue 递归脱敏 JSON 值.
+func (r *redactor) redactValue(v any) (any, bool) {
+	changed := false
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if r.isSensitive(k) {
+				val[k] = redactedValue
+				changed = true
+				continue
+			}
+			if masked, c := r.redactValue(child); c {
+				val[k] = masked
+				changed = true
+			}
+		}
+	case []any:
+		for i, child := range val {
+			if masked, c := r.redactValue(child); c {
+				val[i] = masked
+				changed = true
+			}
+		}
+	}
+	return v, changed
+}
+
+func (r *redactor) isSensitive(key string) bool {
+	key = normalizeRedactKey(key)
+	for _, k := range r.keys {
+		if strings.HasSuffix(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeRedactKey(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	key = strings.ReplaceAll(key, "_", "")
+	return strings.ReplaceAll(key, "-", "")
+}
+
+// extractKey 从 `"key": "` / `key=` 前缀中提取字段名.
+func extractKey(prefix string) string {
+	if i := strings.IndexAny(prefix, ":="); i >= 0 {
+		prefix = prefix[:i]
+	}
+	return strings.Trim(strings.TrimSpace(prefix), "\"")
+}
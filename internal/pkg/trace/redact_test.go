@@ -0,0 +1,110 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/tool"
+)
+
+func TestRedact(t *testing.T) {
+	r := newRedactor(DefaultRedactKeys)
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "api key in json",
+			in:   `{"api_key":"sk-123","query":"go"}`,
+			want: `{"api_key":"******","query":"go"}`,
+		},
+		{
+			name: "api key variants",
+			in:   `{"apiKey":"a","x-api-key":"b","API_KEY":"c"}`,
+			want: `{"API_KEY":"******","apiKey":"******","x-api-key":"******"}`,
+		},
+		{
+			name: "bearer token in header map",
+			in:   `{"headers":{"Authorization":"Bearer abc.def","Accept":"*/*"}}`,
+			want: `{"headers":{"Accept":"*/*","Authorization":"******"}}`,
+		},
+		{
+			name: "password in nested maps",
+			in:   `{"user":{"name":"alice","login":{"password":"hunter2"}}}`,
+			want: `{"user":{"login":{"password":"******"},"name":"alice"}}`,
+		},
+		{
+			name: "password in array of maps",
+			in:   `{"accounts":[{"id":1,"password":"a"},{"id":2,"db_passwd":"b"}]}`,
+			want: `{"accounts":[{"id":1,"password":"******"},{"db_passwd":"******","id":2}]}`,
+		},
+		{
+			name: "sensitive key with object value",
+			in:   `{"credentials":{"user":"u"},"client_secret":{"v":1}}`,
+			want: `{"client_secret":"******","credentials":{"user":"u"}}`,
+		},
+		{
+			name: "nothing sensitive keeps original text",
+			in:   `{"keyword": "go", "max_tokens": 10}`,
+			want: `{"keyword": "go", "max_tokens": 10}`,
+		},
+		{
+			name: "bearer token in text",
+			in:   "Authorization: Bearer abc.def",
+			want: "Authorization: Bearer ******",
+		},
+		{
+			name: "api key in query string",
+			in:   "GET /search?api_key=sk-123&q=go",
+			want: "GET /search?api_key=******&q=go",
+		},
+		{
+			name: "password in text",
+			in:   `login failed for password="hunter2", retry`,
+			want: `login failed for password="******", retry`,
+		},
+		{
+			name: "plain text untouched",
+			in:   "keyword=go max_tokens=10",
+			want: "keyword=go max_tokens=10",
+		},
+	}
+	for _, tt := range tests {
+		if got := r.redact(tt.in); got != tt.want {
+			t.Errorf("%s: redact(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactDisabled(t *testing.T) {
+	in := `{"password":"hunter2"}`
+	if got := newRedactor([]string{}).redact(in); got != in {
+		t.Errorf("redact with no keys = %q, want input unchanged", got)
+	}
+}
+
+func TestLogTracerRedactsToolArguments(t *testing.T) {
+	var buf bytes.Buffer
+	noColor := true
+	tracer := NewLogTracerWithConfig(&LogTracerConfig{Writer: &buf, NoColor: &noColor})
+	info := &callbacks.RunInfo{Name: "webhook", Component: components.ComponentOfTool}
+	ctx := context.Background()
+
+	tracer.Handler().OnStart(ctx, info, &tool.CallbackInput{ArgumentsInJSON: `{"auth":{"token":"t-1"},"id":7}`})
+	tracer.Handler().OnEnd(ctx, info, &tool.CallbackOutput{Response: "Authorization: Bearer t-2"})
+
+	out := buf.String()
+	for _, secret := range []string{"t-1", "t-2"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output leaks %q: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, `args: {"auth":{"token":"******"},"id":7}`) || !strings.Contains(out, "result: Authorization: Bearer ******") {
+		t.Errorf("output = %q, want masked arguments and result", out)
+	}
+}
@@ -0,0 +1,286 @@
+// Package trace 提供可观测性集成.
+package trace
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// defaultPriceKey 价格表中的兜底模型名.
+const defaultPriceKey = "default"
+
+// ModelPrice 模型单价（每 1K token）.
+type ModelPrice struct {
+	PromptPer1K     float64 `json:"prompt_per_1k" mapstructure:"prompt_per_1k"`
+	CompletionPer1K float64 `json:"completion_per_1k" mapstructure:"completion_per_1k"`
+}
+
+// Pricing 模型价格表，key 为模型名（大小写不敏感），"default" 作为未命中时的兜底价格.
+type Pricing map[string]ModelPrice
+
+// Cost 计算单次调用费用，未配置价格时返回 0.
+func (p Pricing) Cost(modelName string, promptTokens, completionTokens int) float64 {
+	price, ok := p.lookup(modelName)
+	if !ok {
+		return 0
+	}
+	return float64(promptTokens)/1000*price.PromptPer1K + float64(completionTokens)/1000*price.CompletionPer1K
+}
+
+func (p Pricing) lookup(modelName string) (ModelPrice, bool) {
+	name := strings.ToLower(modelName)
+	for k, v := range p {
+		if strings.ToLower(k) == name {
+			return v, true
+		}
+	}
+	price, ok := p[defaultPriceKey]
+	return price, ok
+}
+
+// ModelUsage 单个模型的用量.
+type ModelUsage struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// UsageSummary 单次运行的用量汇总，费用单位与价格表一致.
+type UsageSummary struct {
+	ModelUsage
+	Models map[string]*ModelUsage `json:"models,omitempty"`
+}
+
+// Map 转换为 map（用于持久化和事件输出）.
+func (s *UsageSummary) Map() map[string]any {
+	models := make(map[string]any, len(s.Models))
+	for name, m := range s.Models {
+		models[name] = map[string]any{
+			"calls":             m.Calls,
+			"prompt_tokens":     m.PromptTokens,
+			"completion_tokens": m.CompletionTokens,
+			"total_tokens":      m.TotalTokens,
+			"cost":              m.Cost,
+		}
+	}
+	return map[string]any{
+		"calls":             s.Calls,
+		"prompt_tokens":     s.PromptTokens,
+		"completion_tokens": s.CompletionTokens,
+		"total_tokens":      s.TotalTokens,
+		"cost":              s.Cost,
+		"models":            models,
+	}
+}
+
+// RunUsage 单次运行的用量累加器（并发安全）.
+//
+// 子运行（如子 Agent）的用量会同时计入所有父运行.
+type RunUsage struct {
+	parent  *RunUsage
+	mu      sync.Mutex
+	summary UsageSummary
+	pending sync.WaitGroup
+}
+
+type runUsageKey struct{}
+
+// WithRunUsage 在 ctx 中创建运行级用量累加器.
+// ctx 中已存在累加器时创建其子累加器，用于嵌套的子 Agent 运行.
+func WithRunUsage(ctx context.Context) (context.Context, *RunUsage) {
+	u := &RunUsage{
+		parent:  RunUsageFromContext(ctx),
+		summary: UsageSummary{Models: make(map[string]*ModelUsage)},
+	}
+	return context.WithValue(ctx, runUsageKey{}, u), u
+}
+
+// RunUsageFromContext 获取 ctx 中的用量累加器，不存在时返回 nil.
+func RunUsageFromContext(ctx context.Context) *RunUsage {
+	u, _ := ctx.Value(runUsageKey{}).(*RunUsage)
+	return u
+}
+
+// Add 累加一次模型调用的用量.
+func (u *RunUsage) Add(modelName string, usage *model.TokenUsage, cost float64) {
+	if usage == nil {
+		return
+	}
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+	for cur := u; cur != nil; cur = cur.parent {
+		cur.mu.Lock()
+		m, ok := cur.summary.Models[modelName]
+		if !ok {
+			m = &ModelUsage{}
+			cur.summary.Models[modelName] = m
+		}
+		for _, target := range []*ModelUsage{m, &cur.summary.ModelUsage} {
+			target.Calls++
+			target.PromptTokens += usage.PromptTokens
+			target.CompletionTokens += usage.CompletionTokens
+			target.TotalTokens += total
+			target.Cost += cost
+		}
+		cur.mu.Unlock()
+	}
+}
+
+// Wait 等待仍在统计中的流式输出完成.
+func (u *RunUsage) Wait() {
+	u.pending.Wait()
+}
+
+// Summary 返回当前用量汇总的副本.
+func (u *RunUsage) Summary() *UsageSummary {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := &UsageSummary{
+		ModelUsage: u.summary.ModelUsage,
+		Models:     make(map[string]*ModelUsage, len(u.summary.Models)),
+	}
+	for name, m := range u.summary.Models {
+		copied := *m
+		s.Models[name] = &copied
+	}
+	return s
+}
+
+// track 标记一个进行中的流式统计，返回完成函数.
+func (u *RunUsage) track() func() {
+	for cur := u; cur != nil; cur = cur.parent {
+		cur.pending.Add(1)
+	}
+	return func() {
+		for cur := u; cur != nil; cur = cur.parent {
+			cur.pending.Done()
+		}
+	}
+}
+
+// UsageTracer 将模型调用的 token 用量和费用累加到 ctx 中的 RunUsage.
+// ctx 中没有 RunUsage 时不做任何统计.
+type UsageTracer struct {
+	handler callbacks.Handler
+	pricing Pricing
+}
+
+// NewUsageTracer 创建用量追踪器.
+func NewUsageTracer(pricing Pricing) *UsageTracer {
+	t := &UsageTracer{pricing: pricing}
+	t.handler = t.buildHandler()
+	return t
+}
+
+// Register 注册全局回调处理器.
+func (t *UsageTracer) Register() {
+	callbacks.AppendGlobalHandlers(t.handler)
+}
+
+// Handler 获取回调处理器.
+func (t *UsageTracer) Handler() callbacks.Handler {
+	return t.handler
+}
+
+func (t *UsageTracer) buildHandler() callbacks.Handler {
+	builder := callbacks.NewHandlerBuilder()
+	builder.
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, output callbacks.CallbackOutput) context.Context {
+			usage := RunUsageFromContext(ctx)
+			if usage == nil || !isModelComponent(info.Component) {
+				return ctx
+			}
+			if name, tu := extractUsage(info, output); tu != nil {
+				if name == "" {
+					name = info.Name
+				}
+				t.add(usage, name, tu)
+			}
+			return ctx
+		}).
+		OnEndWithStreamOutputFn(func(ctx context.Context, info *callbacks.RunInfo, output *schema.StreamReader[callbacks.CallbackOutput]) context.Context {
+			usage := RunUsageFromContext(ctx)
+			if usage == nil || !isModelComponent(info.Component) {
+				output.Close()
+				return ctx
+			}
+
+			done := usage.track()
+			go func() {
+				defer done()
+				defer output.Close()
+
+				var modelName string
+				var last *model.TokenUsage
+				for {
+					chunk, err := output.Recv()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						return
+					}
+					name, tu := extractUsage(info, chunk)
+					if name != "" {
+						modelName = name
+					}
+					if tu != nil {
+						last = tu
+					}
+				}
+				if last != nil {
+					if modelName == "" {
+						modelName = info.Name
+					}
+					t.add(usage, modelName, last)
+				}
+			}()
+			return ctx
+		})
+	return builder.Build()
+}
+
+func (t *UsageTracer) add(usage *RunUsage, modelName string, tu *model.TokenUsage) {
+	usage.Add(modelName, tu, t.pricing.Cost(modelName, tu.PromptTokens, tu.CompletionTokens))
+}
+
+func isModelComponent(c components.Component) bool {
+	return c == components.ComponentOfChatModel || c == components.ComponentOfAgenticModel
+}
+
+// extractUsage 从模型回调输出中提取模型名和 token 用量，输出中未携带模型配置时模型名为空.
+func extractUsage(info *callbacks.RunInfo, output callbacks.CallbackOutput) (string, *model.TokenUsage) {
+	if info.Component == components.ComponentOfChatModel {
+		cco := model.ConvCallbackOutput(output)
+		if cco == nil {
+			return "", nil
+		}
+		var name string
+		if cco.Config != nil {
+			name = cco.Config.Model
+		}
+		return name, cco.TokenUsage
+	}
+
+	aco := model.ConvAgenticCallbackOutput(output)
+	if aco == nil {
+		return "", nil
+	}
+	var name string
+	if aco.Config != nil {
+		name = aco.Config.Model
+	}
+	return name, aco.TokenUsage
+}
@@ -18,7 +18,7 @@ type AgentStore interface {
 	Update(ctx context.Context, agent *model.Agent) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, offset, limit int) ([]*model.Agent, int64, error)
-	ListAll(ctx context.Context) ([]*model.Agent, error)
+	ListAll(ctx context.Context, opts *ListOptions) ([]*model.Agent, error)
 	ListEnabled(ctx context.Context) ([]*model.Agent, error)
 	ListByRole(ctx context.Context, role model.AgentRole) ([]*model.Agent, error)
 }
@@ -89,9 +89,19 @@ func (s *agentStore) ListEnabled(ctx context.Context) ([]*model.Agent, error) {
 	return agents, nil
 }
 
-func (s *agentStore) ListAll(ctx context.Context) ([]*model.Agent, error) {
+// agentListSpec Agent 列表支持的排序和过滤字段.
+var agentListSpec = &listSpec{
+	sorts:   map[string]string{"created_at": "created_at", "updated_at": "updated_at", "name": "name"},
+	filters: map[string]string{"type": "agent_type", "role": "agent_role"},
+}
+
+func (s *agentStore) ListAll(ctx context.Context, opts *ListOptions) ([]*model.Agent, error) {
+	db, err := agentListSpec.apply(s.db.WithContext(ctx), opts)
+	if err != nil {
+		return nil, err
+	}
 	var agents []*model.Agent
-	if err := s.db.WithContext(ctx).Order("created_at DESC").Find(&agents).Error; err != nil {
+	if err := db.Find(&agents).Error; err != nil {
 		return nil, err
 	}
 	return agents, nil
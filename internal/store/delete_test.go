@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingPool 记录执行的语句的连接池，不连接数据库.
+// failOn 不为空时，包含该子串的语句返回错误.
+type recordingPool struct {
+	mu        sync.Mutex
+	log       []string
+	failOn    string
+	committed int
+	rolled    int
+	queries   []capturedQuery
+	execs     []capturedQuery
+}
+
+func (p *recordingPool) record(stmt string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.log = append(p.log, stmt)
+}
+
+func (p *recordingPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+
+func (p *recordingPool) ExecContext(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.record(query)
+	p.mu.Lock()
+	p.execs = append(p.execs, capturedQuery{sql: query, vars: args})
+	p.mu.Unlock()
+	if p.failOn != "" && strings.Contains(query, p.failOn) {
+		return nil, errors.New("simulated failure")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (p *recordingPool) QueryContext(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.queries = append(p.queries, capturedQuery{sql: query, vars: args})
+	return nil, errQueryCaptured
+}
+
+func (p *recordingPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p *recordingPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	p.record("BEGIN")
+	return &recordingTx{pool: p}, nil
+}
+
+// recordingTx 事务内的连接，与连接池共用记录；不能再开启事务，gorm 因此不会嵌套默认事务.
+type recordingTx struct {
+	pool *recordingPool
+}
+
+func (tx *recordingTx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return tx.pool.PrepareContext(ctx, query)
+}
+
+func (tx *recordingTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.pool.ExecContext(ctx, query, args...)
+}
+
+func (tx *recordingTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.pool.QueryContext(ctx, query, args...)
+}
+
+func (tx *recordingTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return tx.pool.QueryRowContext(ctx, query, args...)
+}
+
+func (tx *recordingTx) Commit() error {
+	tx.pool.record("COMMIT")
+	tx.pool.committed++
+	return nil
+}
+
+func (tx *recordingTx) Rollback() error {
+	tx.pool.record("ROLLBACK")
+	tx.pool.rolled++
+	return nil
+}
+
+func newRecordingDB(t *testing.T, pool *recordingPool) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("open recording db: %v", err)
+	}
+	return db
+}
+
+func TestDeleteDocumentCascadesInOneTransaction(t *testing.T) {
+	pool := &recordingPool{}
+	s := &knowledgeStore{db: newRecordingDB(t, pool)}
+
+	if err := s.DeleteDocument(context.Background(), "doc1"); err != nil {
+		t.Fatalf("DeleteDocument: %v", err)
+	}
+	if len(pool.log) != 6 || pool.log[0] != "BEGIN" || pool.log[5] != "COMMIT" {
+		t.Fatalf("statements = %q, want four deletes in one transaction", pool.log)
+	}
+	// 分块标签和向量按文档的分块删除，检索和孤立数据检查都不会再看到它们
+	chunkSubquery := "chunk_id IN (SELECT \"id\" FROM \"knowledge_chunks\" WHERE document_id = $1"
+	want := []struct{ table, cond string }{
+		{"chunk_tags", chunkSubquery},
+		{"embeddings", chunkSubquery},
+		{"knowledge_chunks", "document_id = $1"},
+		{"knowledge_documents", "id = $1"},
+	}
+	for i, w := range want {
+		stmt := pool.log[i+1]
+		if !strings.HasPrefix(stmt, `DELETE FROM "`+w.table+`"`) || !strings.Contains(stmt, w.cond) {
+			t.Errorf("statement %d = %q, want delete from %s where %s", i+1, stmt, w.table, w.cond)
+		}
+	}
+}
+
+func TestDeleteDocumentRollsBackOnFailure(t *testing.T) {
+	pool := &recordingPool{failOn: `DELETE FROM "knowledge_chunks"`}
+	s := &knowledgeStore{db: newRecordingDB(t, pool)}
+
+	if err := s.DeleteDocument(context.Background(), "doc1"); err == nil || !strings.Contains(err.Error(), "delete chunks") {
+		t.Fatalf("DeleteDocument error = %v, want delete chunks failure", err)
+	}
+	if pool.committed != 0 || pool.rolled != 1 {
+		t.Errorf("committed = %d, rolled back = %d, want rollback only", pool.committed, pool.rolled)
+	}
+	for _, stmt := range pool.log {
+		if strings.HasPrefix(stmt, `DELETE FROM "knowledge_documents"`) {
+			t.Errorf("document deleted after chunk deletion failed: %q", pool.log)
+		}
+	}
+}
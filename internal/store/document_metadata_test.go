@@ -0,0 +1,46 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestVectorSearchFiltersByDocumentMetadata(t *testing.T) {
+	s := &knowledgeStore{}
+	filter := map[string]any{"department": "legal"}
+
+	tests := []struct {
+		name      string
+		kbIDs     []string
+		wantQuery string
+		wantArg   int
+	}{
+		{name: "with knowledge bases", kbIDs: []string{"kb1"}, wantQuery: "d.id = c.document_id AND d.metadata @> $3::jsonb", wantArg: 2},
+		{name: "all knowledge bases", wantQuery: "d.id = c.document_id AND d.metadata @> $2::jsonb", wantArg: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := s.buildVectorSearchQuery(tt.kbIDs, []float32{0.1, 0.2}, 5, &SearchOptions{DocumentMetadata: filter})
+			if err != nil {
+				t.Fatalf("buildVectorSearchQuery: %v", err)
+			}
+			if !strings.Contains(query, "EXISTS (SELECT 1 FROM knowledge_documents d WHERE "+tt.wantQuery+")") {
+				t.Fatalf("query missing document metadata filter:\n%s", query)
+			}
+			if got := args[tt.wantArg]; got != `{"department":"legal"}` {
+				t.Fatalf("filter arg = %v, want department json", got)
+			}
+			// LIMIT 占位符跟在过滤参数之后
+			if !strings.HasSuffix(query, fmt.Sprintf("LIMIT $%d", len(args))) || args[len(args)-1] != 5 {
+				t.Fatalf("limit placeholder mismatched: %s %v", query, args)
+			}
+		})
+	}
+}
+
+func TestDocumentMetadataClauseRejectsUnmarshalable(t *testing.T) {
+	if _, _, err := documentMetadataClause(map[string]any{"department": func() {}}, 2); err == nil {
+		t.Fatal("documentMetadataClause with func value succeeded, want error")
+	}
+}
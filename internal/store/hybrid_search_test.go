@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// errQueryCaptured 由 recordingPool 的查询返回，测试只检查生成的 SQL 和参数.
+var errQueryCaptured = errors.New("query captured")
+
+// capturedQuery 执行的 SQL 和参数.
+type capturedQuery struct {
+	sql  string
+	vars []interface{}
+}
+
+// newCaptureStore 返回查询都被 recordingPool 记录的 knowledgeStore，pgvector 视为可用（未检测）.
+func newCaptureStore(t *testing.T) (*knowledgeStore, *recordingPool) {
+	t.Helper()
+	pool := &recordingPool{}
+	return &knowledgeStore{db: newRecordingDB(t, pool), vector: &vectorStatus{}}, pool
+}
+
+func TestHybridSearchFusion(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      SearchOptions
+		wantScore string
+		wantVars  []interface{} // 合并阶段的参数（LIMIT 之前）
+	}{
+		{
+			name:      "weighted sum by default",
+			wantScore: "($8 * vector_score + $9 * bm25_score) as score",
+			wantVars:  []interface{}{0.7, 0.3},
+		},
+		{
+			name:      "rrf default k",
+			opts:      SearchOptions{Fusion: FusionRRF},
+			wantScore: "(COALESCE(1.0 / ($8 + vector_rank), 0) + COALESCE(1.0 / ($8 + bm25_rank), 0)) as score",
+			wantVars:  []interface{}{DefaultRRFK},
+		},
+		{
+			name:      "rrf custom k",
+			opts:      SearchOptions{Fusion: FusionRRF, RRFK: 10},
+			wantScore: "(COALESCE(1.0 / ($8 + vector_rank), 0) + COALESCE(1.0 / ($8 + bm25_rank), 0)) as score",
+			wantVars:  []interface{}{10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, pool := newCaptureStore(t)
+			_, err := s.HybridSearch(context.Background(), []string{"kb1"}, []float32{0.1, 0.2}, "refund", 5, 0.7, 0.3, tt.opts)
+			if !errors.Is(err, errQueryCaptured) {
+				t.Fatalf("HybridSearch() error = %v, want captured query", err)
+			}
+			// 其余查询是全文检索配置和查询失败后的回退检查
+			var q capturedQuery
+			for _, captured := range pool.queries {
+				if strings.Contains(captured.sql, "FROM combined") {
+					q = captured
+				}
+			}
+			if q.sql == "" {
+				t.Fatalf("no hybrid query among %d captured queries", len(pool.queries))
+			}
+			if !strings.Contains(q.sql, tt.wantScore) {
+				t.Errorf("query missing score %q:\n%s", tt.wantScore, q.sql)
+			}
+			// 排名在各路结果内按各自分数计算，合并后按混合分数排序
+			for _, want := range []string{
+				"ROW_NUMBER() OVER (ORDER BY vector_score DESC) AS vector_rank",
+				"ROW_NUMBER() OVER (ORDER BY bm25_score DESC) AS bm25_rank",
+				"MIN(vector_rank) as vector_rank",
+				"ORDER BY score DESC",
+			} {
+				if !strings.Contains(q.sql, want) {
+					t.Errorf("query missing %q", want)
+				}
+			}
+			n := len(q.vars)
+			if got := q.vars[n-1-len(tt.wantVars) : n-1]; !slices.Equal(got, tt.wantVars) {
+				t.Errorf("fusion vars = %v, want %v", got, tt.wantVars)
+			}
+			if q.vars[n-1] != 5 {
+				t.Errorf("limit = %v, want 5", q.vars[n-1])
+			}
+		})
+	}
+}
+
+func TestHybridSearchRejectsUnknownFusion(t *testing.T) {
+	s, pool := newCaptureStore(t)
+	_, err := s.HybridSearch(context.Background(), nil, []float32{0.1}, "q", 5, 0.7, 0.3, SearchOptions{Fusion: "borda"})
+	if err == nil || errors.Is(err, errQueryCaptured) || len(pool.queries) != 0 {
+		t.Errorf("HybridSearch(borda) error = %v, queries = %d, want validation error without querying", err, len(pool.queries))
+	}
+}
+
+func TestFullTextSearchKeepsPositionalArgs(t *testing.T) {
+	s, pool := newCaptureStore(t)
+	opts := SearchOptions{DocumentMetadata: map[string]any{"lang": "en"}}
+	if _, err := s.SearchChunksByFullText(context.Background(), []string{"kb1"}, "refund", 5, opts); !errors.Is(err, errQueryCaptured) {
+		t.Fatalf("SearchChunksByFullText() error = %v, want captured query", err)
+	}
+	q := pool.queries[len(pool.queries)-1]
+	// @@ 和 @> 不能让 gorm 按命名参数解析而丢弃 $n 参数
+	if !strings.Contains(q.sql, "@@") || !strings.Contains(q.sql, "@>") {
+		t.Fatalf("unexpected query:\n%s", q.sql)
+	}
+	if len(q.vars) != 5 || q.vars[0] != "refund" || q.vars[4] != 5 {
+		t.Errorf("vars = %v, want [refund config kbIDs metadata 5]", q.vars)
+	}
+}
+
+func TestSearchTagFilterBindsTagIDs(t *testing.T) {
+	tagIDs := []string{"products", "phones"}
+	opts := SearchOptions{DistanceFunction: DistanceCosine, DocumentMetadata: map[string]any{"lang": "en"}, TagIDs: tagIDs}
+	searches := map[string]func(s *knowledgeStore) error{
+		"vector": func(s *knowledgeStore) error {
+			_, err := s.SearchChunksByVectorWithOptions(context.Background(), []string{"kb1"}, []float32{0.1}, 5, opts)
+			return err
+		},
+		"full text": func(s *knowledgeStore) error {
+			_, err := s.SearchChunksByFullText(context.Background(), []string{"kb1"}, "refund", 5, opts)
+			return err
+		},
+		"hybrid": func(s *knowledgeStore) error {
+			_, err := s.HybridSearch(context.Background(), []string{"kb1"}, []float32{0.1}, "refund", 5, 0.7, 0.3, opts)
+			return err
+		},
+	}
+	tagClause := regexp.MustCompile(`ct\.tag_id = ANY\(\$(\d+)\)`)
+	for name, search := range searches {
+		t.Run(name, func(t *testing.T) {
+			s, pool := newCaptureStore(t)
+			if err := search(s); !errors.Is(err, errQueryCaptured) {
+				t.Fatalf("search error = %v, want captured query", err)
+			}
+			var q capturedQuery
+			for _, captured := range pool.queries {
+				if strings.Contains(captured.sql, "chunk_tags") {
+					q = captured
+				}
+			}
+			matches := tagClause.FindAllStringSubmatch(q.sql, -1)
+			if len(matches) == 0 {
+				t.Fatalf("no tag filter among %d captured queries", len(pool.queries))
+			}
+			// 混合检索的两路共用同一个参数
+			for _, m := range matches {
+				n, _ := strconv.Atoi(m[1])
+				if n < 1 || n > len(q.vars) || !reflect.DeepEqual(q.vars[n-1], tagIDs) {
+					t.Errorf("tag filter $%s not bound to %v (vars %v)", m[1], tagIDs, q.vars)
+				}
+			}
+		})
+	}
+}
@@ -3,10 +3,16 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/ashwinyue/next-show/internal/model"
 )
@@ -42,6 +48,18 @@ func (d DistanceFunction) Operator() string {
 	}
 }
 
+// OpClass returns the pgvector operator class used to index vectors for the distance function.
+func (d DistanceFunction) OpClass() string {
+	switch d {
+	case DistanceL2:
+		return "vector_l2_ops"
+	case DistanceIP:
+		return "vector_ip_ops"
+	default:
+		return "vector_cosine_ops"
+	}
+}
+
 // Validate checks if the distance function is valid.
 func (d DistanceFunction) Validate() error {
 	switch d {
@@ -52,6 +70,29 @@ func (d DistanceFunction) Validate() error {
 	}
 }
 
+// FusionMethod 混合检索合并向量检索和全文检索结果的方式.
+type FusionMethod string
+
+const (
+	// FusionWeightedSum 按权重对两路原始分数加权求和（默认）.
+	FusionWeightedSum FusionMethod = "weighted_sum"
+	// FusionRRF Reciprocal Rank Fusion：两路分别排名，分数为各路 1/(k + 排名) 之和，与分数尺度无关.
+	FusionRRF FusionMethod = "rrf"
+)
+
+// DefaultRRFK RRF 的默认平滑常数 k.
+const DefaultRRFK = 60
+
+// Validate checks if the fusion method is valid.
+func (f FusionMethod) Validate() error {
+	switch f {
+	case FusionWeightedSum, FusionRRF:
+		return nil
+	default:
+		return fmt.Errorf("invalid fusion method: %s", f)
+	}
+}
+
 // KnowledgeStore 知识库存储接口.
 type KnowledgeStore interface {
 	// KnowledgeBase CRUD
@@ -64,8 +105,13 @@ type KnowledgeStore interface {
 	// Document CRUD
 	CreateDocument(ctx context.Context, doc *model.KnowledgeDocument) error
 	GetDocument(ctx context.Context, id string) (*model.KnowledgeDocument, error)
-	ListDocumentsByKnowledgeBase(ctx context.Context, kbID string) ([]*model.KnowledgeDocument, error)
+	ListDocumentsByKnowledgeBase(ctx context.Context, kbID string, opts *ListOptions) ([]*model.KnowledgeDocument, error)
+	ListDocumentsBySourceURI(ctx context.Context, kbID, sourceURI string) ([]*model.KnowledgeDocument, error)
+	GetDocumentByHash(ctx context.Context, kbID, hash string, inFlightSince time.Time) (*model.KnowledgeDocument, error)
+	FailStaleImports(ctx context.Context, updatedBefore time.Time, message string) (int64, error)
 	UpdateDocument(ctx context.Context, doc *model.KnowledgeDocument) error
+	UpdateDocumentSummary(ctx context.Context, id, contentHash, summary string) error
+	DocumentContentHash(ctx context.Context, id string) (string, error)
 	DeleteDocument(ctx context.Context, id string) error
 
 	// Chunk CRUD
@@ -74,7 +120,7 @@ type KnowledgeStore interface {
 	ListChunksByKnowledgeBase(ctx context.Context, kbID string, limit, offset int) ([]*model.KnowledgeChunk, int64, error)
 	UpdateChunk(ctx context.Context, chunk *model.KnowledgeChunk) error
 	DeleteChunk(ctx context.Context, id string) error
-	SearchChunksByKeyword(ctx context.Context, kbIDs []string, keywords []string, limit int) ([]*model.KnowledgeChunk, error)
+	SearchChunksByKeyword(ctx context.Context, kbIDs []string, keywords []string, limit int, matchAll bool) ([]*ChunkWithScore, error)
 
 	// Chunk & Embedding Write
 	CreateChunks(ctx context.Context, chunks []*model.KnowledgeChunk) error
@@ -86,10 +132,10 @@ type KnowledgeStore interface {
 	SearchChunksByVectorWithOptions(ctx context.Context, kbIDs []string, embedding []float32, limit int, options ...SearchOptions) ([]*ChunkWithScore, error)
 
 	// BM25 Full-Text Search
-	SearchChunksByFullText(ctx context.Context, kbIDs []string, query string, limit int) ([]*ChunkWithScore, error)
+	SearchChunksByFullText(ctx context.Context, kbIDs []string, query string, limit int, options ...SearchOptions) ([]*ChunkWithScore, error)
 
 	// Hybrid Search (Vector + BM25)
-	HybridSearch(ctx context.Context, kbIDs []string, embedding []float32, query string, limit int, vectorWeight, bm25Weight float64) ([]*ChunkWithScore, error)
+	HybridSearch(ctx context.Context, kbIDs []string, embedding []float32, query string, limit int, vectorWeight, bm25Weight float64, options ...SearchOptions) ([]*ChunkWithScore, error)
 
 	// Tag CRUD
 	CreateTag(ctx context.Context, tag *model.KnowledgeTag) error
@@ -103,6 +149,115 @@ type KnowledgeStore interface {
 	RemoveTagFromChunk(ctx context.Context, chunkID, tagID string) error
 	ListTagsByChunk(ctx context.Context, chunkID string) ([]*model.KnowledgeTag, error)
 	ListChunksByTag(ctx context.Context, tagID string, limit, offset int) ([]*model.KnowledgeChunk, int64, error)
+	ListTagUsageByTenant(ctx context.Context, tenantID string) ([]*TagUsage, error)
+	ApplyTagToChunks(ctx context.Context, tagID string, chunkIDs []string, batchSize int) (int64, error)
+
+	// Maintenance
+	CountOrphans(ctx context.Context, kind OrphanKind) (int64, error)
+	DeleteOrphans(ctx context.Context, kind OrphanKind, batchSize int) (int64, error)
+	ListChunksAfter(ctx context.Context, kbID, afterID string, limit int) ([]*model.KnowledgeChunk, error)
+	RebuildSearchIndexes(ctx context.Context) error
+	StartReindexJob(ctx context.Context, job *model.ReindexJob) (bool, error)
+	UpdateReindexJob(ctx context.Context, job *model.ReindexJob) error
+	GetReindexJob(ctx context.Context, id string) (*model.ReindexJob, error)
+	LatestIndexRebuild(ctx context.Context) (*model.ReindexJob, error)
+	FailRunningReindexJobs(ctx context.Context, message string) (int64, error)
+	EnsureVectorIndex(ctx context.Context, distanceFunc DistanceFunction, m, efConstruction int) (VectorIndexMethod, error)
+	CheckVectorSupport(ctx context.Context) (*VectorSupport, error)
+	VectorSupport() *VectorSupport
+
+	// Clone
+	CountDocuments(ctx context.Context, kbID string) (int64, error)
+	CloneTags(ctx context.Context, srcKBID, dstKBID string) (map[string]string, error)
+	CloneDocuments(ctx context.Context, srcKBID, dstKBID, afterID string, limit int, tagIDs map[string]string) ([]*DocumentCopy, error)
+
+	// Bulk delete
+	DeleteDocuments(ctx context.Context, kbID string, filter *DocumentFilter, batchSize int) (int64, error)
+
+	// Rechunk
+	ListDocumentChunks(ctx context.Context, docID string) ([]*model.KnowledgeChunk, error)
+	ReplaceDocumentChunks(ctx context.Context, change *ChunkReplacement) error
+
+	// Move
+	ListEmbeddingSpaces(ctx context.Context, kbID, docID string) ([]*EmbeddingSpace, error)
+	MoveDocument(ctx context.Context, docID, targetKBID string) error
+
+	// Archive
+	ListDocumentsAfter(ctx context.Context, kbID, afterID string, limit int) ([]*model.KnowledgeDocument, error)
+	ListChunkEmbeddings(ctx context.Context, chunkIDs []string) (map[string]*model.Embedding, error)
+	ListChunkTagIDs(ctx context.Context, chunkIDs []string) (map[string][]string, error)
+	CreateChunkTags(ctx context.Context, chunkTags []*model.ChunkTag) error
+	VectorDimension(ctx context.Context) (int, error)
+
+	// Related
+	DocumentCentroid(ctx context.Context, docID string) ([]float32, error)
+}
+
+// DocumentFilter 批量删除文档的过滤条件，多个条件同时满足.
+type DocumentFilter struct {
+	SourceType  string
+	ParseStatus string
+	IDs         []string
+}
+
+// ChunkReplacement 重新分块时对文档分块的变更.
+type ChunkReplacement struct {
+	// Keep 保留的分块 ID 及其新的序号，保留的分块沿用原有向量和标签
+	Keep map[string]int
+	// Remove 删除的分块 ID，连同其向量和标签一起删除
+	Remove []string
+	// Chunks 新增的分块
+	Chunks []*model.KnowledgeChunk
+	// Embeddings 新增分块的向量
+	Embeddings []*model.Embedding
+}
+
+// EmbeddingSpace 向量空间（维度和模型），维度或模型不同的向量不能放在同一知识库中检索.
+type EmbeddingSpace struct {
+	Dim   int    `json:"dim" gorm:"column:embedding_dim"`
+	Model string `json:"model" gorm:"column:embedding_model"`
+}
+
+// DocumentCopy 复制出的文档.
+type DocumentCopy struct {
+	// SourceID 源文档 ID
+	SourceID string
+	// ID 新文档 ID
+	ID         string
+	SourceType model.DocumentSourceType
+	SourceURI  string
+}
+
+// OrphanKind 孤立数据类型.
+type OrphanKind string
+
+const (
+	// OrphanChunks 所属文档已删除的分块.
+	OrphanChunks OrphanKind = "chunks"
+	// OrphanEmbeddings 对应分块已删除的向量.
+	OrphanEmbeddings OrphanKind = "embeddings"
+	// OrphanChunkTags 分块或标签已删除的关联.
+	OrphanChunkTags OrphanKind = "chunk_tags"
+)
+
+// orphanQueries 各类孤立数据所在的表及判定条件.
+var orphanQueries = map[OrphanKind]struct {
+	table string
+	cond  string
+}{
+	OrphanChunks: {
+		table: "knowledge_chunks",
+		cond:  "NOT EXISTS (SELECT 1 FROM knowledge_documents d WHERE d.id = knowledge_chunks.document_id)",
+	},
+	OrphanEmbeddings: {
+		table: "embeddings",
+		cond:  "NOT EXISTS (SELECT 1 FROM knowledge_chunks c WHERE c.id = embeddings.chunk_id)",
+	},
+	OrphanChunkTags: {
+		table: "chunk_tags",
+		cond: "NOT EXISTS (SELECT 1 FROM knowledge_chunks c WHERE c.id = chunk_tags.chunk_id)" +
+			" OR NOT EXISTS (SELECT 1 FROM knowledge_tags t WHERE t.id = chunk_tags.tag_id)",
+	},
 }
 
 func (s *knowledgeStore) CreateChunks(ctx context.Context, chunks []*model.KnowledgeChunk) error {
@@ -117,6 +272,11 @@ func (s *knowledgeStore) CreateEmbeddings(ctx context.Context, embeddings []*mod
 		return nil
 	}
 
+	return insertEmbeddings(s.db.WithContext(ctx), embeddings)
+}
+
+// insertEmbeddings 写入向量，已存在的分块向量被覆盖.
+func insertEmbeddings(db *gorm.DB, embeddings []*model.Embedding) error {
 	// 使用 Raw SQL 写入 embedding，确保 pgvector cast 正确
 	query := `INSERT INTO embeddings (knowledge_base_id, chunk_id, embedding, embedding_dim, embedding_model, metadata)
 			VALUES ($1, $2, $3::vector, $4, $5, $6)
@@ -126,10 +286,14 @@ func (s *knowledgeStore) CreateEmbeddings(ctx context.Context, embeddings []*mod
 		if e == nil {
 			continue
 		}
-		if err := s.db.WithContext(ctx).Exec(query,
+		vector, err := vectorToString(e.Embedding)
+		if err != nil {
+			return fmt.Errorf("embedding of chunk %s: %w", e.ChunkID, err)
+		}
+		if err := db.Exec(query,
 			e.KnowledgeBaseID,
 			e.ChunkID,
-			vectorToString(e.Embedding),
+			vector,
 			e.EmbeddingDim,
 			e.EmbeddingModel,
 			e.Metadata,
@@ -147,11 +311,13 @@ type ChunkWithScore struct {
 }
 
 type knowledgeStore struct {
-	db *gorm.DB
+	db         *gorm.DB
+	vector     *vectorStatus
+	textSearch *textSearchStatus
 }
 
-func newKnowledgeStore(db *gorm.DB) KnowledgeStore {
-	return &knowledgeStore{db: db}
+func newKnowledgeStore(db *gorm.DB, vector *vectorStatus, textSearch *textSearchStatus) KnowledgeStore {
+	return &knowledgeStore{db: db, vector: vector, textSearch: textSearch}
 }
 
 func (s *knowledgeStore) CreateKnowledgeBase(ctx context.Context, kb *model.KnowledgeBase) error {
@@ -194,9 +360,19 @@ func (s *knowledgeStore) GetDocument(ctx context.Context, id string) (*model.Kno
 	return &doc, nil
 }
 
-func (s *knowledgeStore) ListDocumentsByKnowledgeBase(ctx context.Context, kbID string) ([]*model.KnowledgeDocument, error) {
+// documentListSpec 文档列表支持的排序和过滤字段.
+var documentListSpec = &listSpec{
+	sorts:   map[string]string{"created_at": "created_at", "updated_at": "updated_at", "name": "title"},
+	filters: map[string]string{"status": "parse_status", "source_type": "source_type"},
+}
+
+func (s *knowledgeStore) ListDocumentsByKnowledgeBase(ctx context.Context, kbID string, opts *ListOptions) ([]*model.KnowledgeDocument, error) {
+	db, err := documentListSpec.apply(s.db.WithContext(ctx).Where("knowledge_base_id = ?", kbID), opts)
+	if err != nil {
+		return nil, err
+	}
 	var docs []*model.KnowledgeDocument
-	if err := s.db.WithContext(ctx).Where("knowledge_base_id = ?", kbID).Find(&docs).Error; err != nil {
+	if err := db.Find(&docs).Error; err != nil {
 		return nil, err
 	}
 	return docs, nil
@@ -206,8 +382,86 @@ func (s *knowledgeStore) UpdateDocument(ctx context.Context, doc *model.Knowledg
 	return s.db.WithContext(ctx).Save(doc).Error
 }
 
+func (s *knowledgeStore) UpdateDocumentSummary(ctx context.Context, id, contentHash, summary string) error {
+	return s.db.WithContext(ctx).Model(&model.KnowledgeDocument{}).Where("id = ?", id).
+		Updates(map[string]any{"summary": summary, "summary_hash": contentHash}).Error
+}
+
+// DocumentContentHash 按分块顺序计算文档全部分块内容的哈希，文档没有分块时返回空字符串.
+func (s *knowledgeStore) DocumentContentHash(ctx context.Context, id string) (string, error) {
+	var hash *string
+	err := s.db.WithContext(ctx).Raw(
+		"SELECT md5(string_agg(md5(content), '' ORDER BY chunk_index, id)) FROM knowledge_chunks WHERE document_id = ?", id,
+	).Scan(&hash).Error
+	if err != nil || hash == nil {
+		return "", err
+	}
+	return *hash, nil
+}
+
+func (s *knowledgeStore) ListDocumentsBySourceURI(ctx context.Context, kbID, sourceURI string) ([]*model.KnowledgeDocument, error) {
+	var docs []*model.KnowledgeDocument
+	if err := s.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND source_uri = ?", kbID, sourceURI).
+		Order("created_at DESC").
+		Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// inFlightParseStatuses 导入尚未结束的文档状态.
+var inFlightParseStatuses = []model.DocumentParseStatus{
+	model.DocumentParseStatusPending,
+	model.DocumentParseStatusParsing,
+	model.DocumentParseStatusEmbedding,
+}
+
+// GetDocumentByHash 返回知识库中文件哈希相同的最新文档，不存在时返回 gorm.ErrRecordNotFound.
+// 只匹配已导入成功的文档，以及 inFlightSince 之后仍有进展的导入中文档；长时间无进展的导入视为中断，不参与去重.
+func (s *knowledgeStore) GetDocumentByHash(ctx context.Context, kbID, hash string, inFlightSince time.Time) (*model.KnowledgeDocument, error) {
+	var doc model.KnowledgeDocument
+	if err := s.db.WithContext(ctx).
+		Where("knowledge_base_id = ? AND file_hash = ?", kbID, hash).
+		Where("parse_status = ? OR (parse_status IN ? AND updated_at > ?)",
+			model.DocumentParseStatusParsed, inFlightParseStatuses, inFlightSince).
+		Order("created_at DESC").
+		First(&doc).Error; err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// FailStaleImports 将 updatedBefore 之前最后更新、仍处于导入中的文档标记为失败，返回标记的文档数.
+func (s *knowledgeStore) FailStaleImports(ctx context.Context, updatedBefore time.Time, message string) (int64, error) {
+	res := s.db.WithContext(ctx).Model(&model.KnowledgeDocument{}).
+		Where("parse_status IN ? AND updated_at < ?", inFlightParseStatuses, updatedBefore).
+		Updates(map[string]any{
+			"parse_status":  model.DocumentParseStatusFailed,
+			"error_message": message,
+		})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+// DeleteDocument 在一个事务中删除文档及其分块、向量和分块标签关联，
+// 不依赖外键级联，避免未建外键的库中残留的分块仍出现在检索结果中.
 func (s *knowledgeStore) DeleteDocument(ctx context.Context, id string) error {
-	return s.db.WithContext(ctx).Delete(&model.KnowledgeDocument{}, "id = ?", id).Error
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chunkIDs := tx.Model(&model.KnowledgeChunk{}).Select("id").Where("document_id = ?", id)
+		if err := tx.Where("chunk_id IN (?)", chunkIDs).Delete(&model.ChunkTag{}).Error; err != nil {
+			return fmt.Errorf("delete chunk tags: %w", err)
+		}
+		if err := tx.Where("chunk_id IN (?)", chunkIDs).Delete(&model.Embedding{}).Error; err != nil {
+			return fmt.Errorf("delete embeddings: %w", err)
+		}
+		if err := tx.Where("document_id = ?", id).Delete(&model.KnowledgeChunk{}).Error; err != nil {
+			return fmt.Errorf("delete chunks: %w", err)
+		}
+		return tx.Delete(&model.KnowledgeDocument{}, "id = ?", id).Error
+	})
 }
 
 func (s *knowledgeStore) GetChunk(ctx context.Context, id string) (*model.KnowledgeChunk, error) {
@@ -235,38 +489,119 @@ func (s *knowledgeStore) ListChunksByDocument(ctx context.Context, docID string,
 	return chunks, total, nil
 }
 
-func (s *knowledgeStore) SearchChunksByKeyword(ctx context.Context, kbIDs []string, keywords []string, limit int) ([]*model.KnowledgeChunk, error) {
-	if len(keywords) == 0 {
+// SearchChunksByKeyword 按关键词（不区分大小写的子串匹配）搜索分块，matchAll 为 true 时要求包含所有关键词，否则包含任一即可.
+// 分数 = 命中的不同关键词数 + 总出现次数 n 折算的 n/(n+1)，即先按命中关键词数、再按出现次数降序排列.
+func (s *knowledgeStore) SearchChunksByKeyword(ctx context.Context, kbIDs []string, keywords []string, limit int, matchAll bool) ([]*ChunkWithScore, error) {
+	var terms []string
+	seen := make(map[string]struct{}, len(keywords))
+	for _, kw := range keywords {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		if kw == "" {
+			continue
+		}
+		if _, ok := seen[kw]; ok {
+			continue
+		}
+		seen[kw] = struct{}{}
+		terms = append(terms, kw)
+	}
+	if len(terms) == 0 {
 		return nil, nil
 	}
 
-	db := s.db.WithContext(ctx).Model(&model.KnowledgeChunk{}).Where("is_enabled = ?", true)
+	// 参数按占位符在 SQL 中出现的顺序排列：先打分表达式，再过滤条件
+	var hits, occurrences, conds []string
+	var args, condArgs []interface{}
+	for _, kw := range terms {
+		pattern := "%" + escapeLike(kw) + "%"
+		hits = append(hits, `(c.content ILIKE ? ESCAPE '\')::int`)
+		occurrences = append(occurrences, "(char_length(lower(c.content)) - char_length(replace(lower(c.content), ?, ''))) / char_length(?)")
+		args = append(args, pattern, kw, kw)
+		conds = append(conds, `c.content ILIKE ? ESCAPE '\'`)
+		condArgs = append(condArgs, pattern)
+	}
+	join := " OR "
+	if matchAll {
+		join = " AND "
+	}
+	args = append(args, condArgs...)
 
+	query := `
+		SELECT id, knowledge_base_id, document_id, chunk_index, content,
+		       content_hash, metadata, is_enabled, created_at, updated_at,
+		       hits + occurrences::float / (occurrences + 1) AS score
+		FROM (
+			SELECT c.id, c.knowledge_base_id, c.document_id, c.chunk_index, c.content,
+			       c.content_hash, c.metadata, c.is_enabled, c.created_at, c.updated_at,
+			       ` + strings.Join(hits, " + ") + ` AS hits,
+			       ` + strings.Join(occurrences, " + ") + ` AS occurrences
+			FROM knowledge_chunks c
+			WHERE c.is_enabled = true AND (` + strings.Join(conds, join) + `)`
 	if len(kbIDs) > 0 {
-		db = db.Where("knowledge_base_id IN ?", kbIDs)
+		query += " AND c.knowledge_base_id IN ?"
+		args = append(args, kbIDs)
 	}
+	query += `
+		) matched
+		ORDER BY score DESC, chunk_index ASC
+		LIMIT ?`
+	args = append(args, limit)
 
-	// 构建关键词搜索条件 (ILIKE for case-insensitive)
-	for _, kw := range keywords {
-		db = db.Where("content ILIKE ?", "%"+kw+"%")
+	rows, err := s.db.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	var chunks []*model.KnowledgeChunk
-	if err := db.Limit(limit).Find(&chunks).Error; err != nil {
-		return nil, err
+	var results []*ChunkWithScore
+	for rows.Next() {
+		var chunk model.KnowledgeChunk
+		var score float64
+		if err := rows.Scan(
+			&chunk.ID, &chunk.KnowledgeBaseID, &chunk.DocumentID, &chunk.ChunkIndex, &chunk.Content,
+			&chunk.ContentHash, &chunk.Metadata, &chunk.IsEnabled, &chunk.CreatedAt, &chunk.UpdatedAt,
+			&score,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, &ChunkWithScore{Chunk: &chunk, Score: score})
 	}
+	return results, rows.Err()
+}
 
-	return chunks, nil
+// escapeLike 转义 LIKE 模式中的通配符，使关键词按字面匹配.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
+// ErrInvalidScoreThreshold 分数阈值超出距离函数的分数范围.
+var ErrInvalidScoreThreshold = errors.New("invalid score threshold")
+
 // SearchOptions 搜索选项
 type SearchOptions struct {
 	// DistanceFunction 距离函数，默认余弦相似度
 	DistanceFunction DistanceFunction
-	// ScoreThreshold 分数阈值，只返回分数 >= threshold的结果
+	// ScoreThreshold 分数阈值，只返回分数 >= threshold的结果；余弦和 L2 的分数不超过 1，阈值需在 (0, 1] 内
 	ScoreThreshold *float64
-	// WhereClause 自定义 WHERE 条件，例如 "metadata->>'category' = 'tech'"
+	// WhereClause 自定义 WHERE 条件，例如 "c.metadata->>'category' = 'tech'"，
+	// 只能引用分块列（可带 c. 前缀），不允许注释、分号、函数调用和子查询
+	//
+	// Deprecated: 原始 SQL 仅经过简单检查，请使用 MetadataFilters.
 	WhereClause string
+	// MetadataFilters 按分块元数据过滤的结构化条件（参数化，AND 组合），例如 category eq "tech"
+	MetadataFilters []MetadataFilter
+	// DocumentMetadata 按所属文档的元数据过滤（JSONB 包含匹配），例如 {"department": "legal"}
+	DocumentMetadata map[string]any
+	// EFSearch HNSW 索引检索时的候选列表大小（hnsw.ef_search），越大召回越高、越慢，<=0 使用数据库设置
+	EFSearch int
+	// Fusion 混合检索的结果合并方式，为空时使用 FusionWeightedSum
+	Fusion FusionMethod
+	// RRFK Fusion 为 FusionRRF 时的平滑常数 k，<=0 使用 DefaultRRFK
+	RRFK int
+	// ExcludeDocumentIDs 排除这些文档的分块
+	ExcludeDocumentIDs []string
+	// TagIDs 只返回带有其中任一标签的分块
+	TagIDs []string
 }
 
 // SearchChunksByVector 保留原有签名以兼容现有代码
@@ -297,6 +632,10 @@ func (s *knowledgeStore) SearchChunksByVectorWithOptions(ctx context.Context, kb
 		return nil, fmt.Errorf("invalid distance function: %w", err)
 	}
 
+	if !s.vectorSearchAvailable(ctx) {
+		return nil, ErrVectorSearchUnavailable
+	}
+
 	// 构建查询
 	query, args, err := s.buildVectorSearchQuery(kbIDs, embedding, limit, &opts)
 	if err != nil {
@@ -304,29 +643,50 @@ func (s *knowledgeStore) SearchChunksByVectorWithOptions(ctx context.Context, kb
 	}
 
 	// 执行查询
-	rows, err := s.db.WithContext(ctx).Raw(query, args...).Rows()
-	if err != nil {
-		return nil, fmt.Errorf("execute search query: %w", err)
-	}
-	defer rows.Close()
-
 	var results []*ChunkWithScore
-	for rows.Next() {
-		var chunk model.KnowledgeChunk
-		var score float64
-		if err := rows.Scan(
-			&chunk.ID, &chunk.KnowledgeBaseID, &chunk.DocumentID, &chunk.ChunkIndex, &chunk.Content,
-			&chunk.ContentHash, &chunk.Metadata, &chunk.IsEnabled, &chunk.CreatedAt, &chunk.UpdatedAt,
-			&score,
-		); err != nil {
-			return nil, fmt.Errorf("scan result: %w", err)
+	search := func(db *gorm.DB) error {
+		rows, err := rawPositional(db, query, args...).Rows()
+		if err != nil {
+			return fmt.Errorf("execute search query: %w", err)
 		}
-		results = append(results, &ChunkWithScore{
-			Chunk: &chunk,
-			Score: s.calculateScore(score, opts.DistanceFunction),
-		})
+		defer rows.Close()
+
+		for rows.Next() {
+			var chunk model.KnowledgeChunk
+			var score float64
+			if err := rows.Scan(
+				&chunk.ID, &chunk.KnowledgeBaseID, &chunk.DocumentID, &chunk.ChunkIndex, &chunk.Content,
+				&chunk.ContentHash, &chunk.Metadata, &chunk.IsEnabled, &chunk.CreatedAt, &chunk.UpdatedAt,
+				&score,
+			); err != nil {
+				return fmt.Errorf("scan result: %w", err)
+			}
+			results = append(results, &ChunkWithScore{
+				Chunk: &chunk,
+				Score: s.calculateScore(score, opts.DistanceFunction),
+			})
+		}
+		return nil
 	}
 
+	if opts.EFSearch <= 0 {
+		err = search(s.db.WithContext(ctx))
+	} else {
+		// SET LOCAL 只在事务内生效，不影响连接池中的其他查询
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", opts.EFSearch)).Error; err != nil {
+				return fmt.Errorf("set hnsw.ef_search: %w", err)
+			}
+			return search(tx)
+		})
+	}
+	if isVectorUnavailableError(err) {
+		s.markVectorUnavailable(err)
+		return nil, fmt.Errorf("%w: %v", ErrVectorSearchUnavailable, err)
+	}
+	if err != nil {
+		return nil, err
+	}
 	return results, nil
 }
 
@@ -340,7 +700,11 @@ func (s *knowledgeStore) buildVectorSearchQuery(kbIDs []string, queryVector []fl
 	op := opts.DistanceFunction.Operator()
 
 	// 构建基础查询
-	args := []interface{}{vectorToString(queryVector)}
+	vector, err := vectorToString(queryVector)
+	if err != nil {
+		return "", nil, fmt.Errorf("query vector: %w", err)
+	}
+	args := []interface{}{vector}
 	query := fmt.Sprintf(`
 		SELECT c.id, c.knowledge_base_id, c.document_id, c.chunk_index, c.content,
 		       c.content_hash, c.metadata, c.is_enabled, c.created_at, c.updated_at,
@@ -355,19 +719,54 @@ func (s *knowledgeStore) buildVectorSearchQuery(kbIDs []string, queryVector []fl
 		args = append(args, kbIDs)
 	}
 
+	// 排除指定文档
+	if len(opts.ExcludeDocumentIDs) > 0 {
+		query += fmt.Sprintf(" AND c.document_id <> ALL($%d)", len(args)+1)
+		args = append(args, opts.ExcludeDocumentIDs)
+	}
+
 	// 添加自定义 WHERE 条件
 	if opts.WhereClause != "" {
-		// 简单的 SQL 注入检测
-		if !isSafeSQL(opts.WhereClause) {
-			return "", nil, fmt.Errorf("unsafe where clause: %s", opts.WhereClause)
+		// 按白名单校验后拼接
+		if err := validateWhereClause(opts.WhereClause); err != nil {
+			return "", nil, err
+		}
+		query += " AND (" + opts.WhereClause + ")"
+	}
+
+	// 添加文档元数据过滤
+	if len(opts.DocumentMetadata) > 0 {
+		clause, arg, err := documentMetadataClause(opts.DocumentMetadata, len(args)+1)
+		if err != nil {
+			return "", nil, err
 		}
-		query += " AND " + opts.WhereClause
+		query += clause
+		args = append(args, arg)
+	}
+
+	// 添加分块元数据过滤
+	if len(opts.MetadataFilters) > 0 {
+		clause, filterArgs, err := metadataFiltersClause(opts.MetadataFilters, len(args)+1)
+		if err != nil {
+			return "", nil, err
+		}
+		query += clause
+		args = append(args, filterArgs...)
+	}
+
+	// 添加标签过滤
+	if len(opts.TagIDs) > 0 {
+		query += chunkTagsClause(len(args) + 1)
+		args = append(args, opts.TagIDs)
 	}
 
 	// 添加分数阈值过滤
 	if opts.ScoreThreshold != nil && *opts.ScoreThreshold > 0 {
+		if err := validateScoreThreshold(*opts.ScoreThreshold, opts.DistanceFunction); err != nil {
+			return "", nil, err
+		}
 		thresholdDistance := s.calculateThresholdDistance(*opts.ScoreThreshold, opts.DistanceFunction)
-		query += fmt.Sprintf(" AND (e.embedding %s $%d) < %f", op, len(args)+1, thresholdDistance)
+		query += fmt.Sprintf(" AND (e.embedding %s $1::vector) <= $%d", op, len(args)+1)
 		args = append(args, thresholdDistance)
 	}
 
@@ -384,26 +783,41 @@ func (s *knowledgeStore) calculateScore(distance float64, distanceFunc DistanceF
 	case DistanceCosine:
 		// 余弦距离：分数 = 1 - 距离
 		return 1 - distance
-	case DistanceL2, DistanceIP:
-		// L2 和 IP：使用倒数作为分数
-		if distance == 0 {
-			return 1.0
-		}
+	case DistanceL2:
+		// L2：使用倒数作为分数
 		return 1.0 / (1.0 + distance)
+	case DistanceIP:
+		// IP：<#> 返回负内积，取反后内积越大分数越高（与 vectorScoreExpr 一致）
+		return -distance
 	default:
 		return 1 - distance
 	}
 }
 
+// validateScoreThreshold 校验阈值在距离函数的分数范围内：余弦和 L2 的分数最大为 1，
+// 超过 1 的阈值换算出的距离为负数，不会匹配任何结果.
+func validateScoreThreshold(scoreThreshold float64, distanceFunc DistanceFunction) error {
+	if distanceFunc == DistanceIP {
+		return nil
+	}
+	if scoreThreshold <= 0 || scoreThreshold > 1 {
+		return fmt.Errorf("%w: %v must be in (0, 1] for %s distance", ErrInvalidScoreThreshold, scoreThreshold, distanceFunc)
+	}
+	return nil
+}
+
 // calculateThresholdDistance 计算阈值对应的距离值
 func (s *knowledgeStore) calculateThresholdDistance(scoreThreshold float64, distanceFunc DistanceFunction) float64 {
 	switch distanceFunc {
 	case DistanceCosine:
 		// 余弦：距离 = 1 - 分数
 		return 1 - scoreThreshold
-	case DistanceL2, DistanceIP:
-		// L2 和 IP：距离已经是正确尺度
-		return scoreThreshold
+	case DistanceL2:
+		// L2：分数 = 1 / (1 + 距离)，距离 = 1 / 分数 - 1
+		return 1/scoreThreshold - 1
+	case DistanceIP:
+		// IP：分数 = -距离（负内积）
+		return -scoreThreshold
 	default:
 		return scoreThreshold
 	}
@@ -440,39 +854,51 @@ func quoteIdentifier(name string) string {
 	return "\"" + name + "\""
 }
 
-// isSafeSQL 检查 SQL 片段是否安全（简单的安全检查）
-func isSafeSQL(sql string) bool {
-	// 检查危险关键字
-	dangerous := []string{"DROP", "DELETE", "UPDATE", "INSERT", "ALTER", "CREATE", "EXEC", "EXECUTE"}
-	upperSQL := fmt.Sprintf(" %s ", strings.ToUpper(sql))
+// rawPositional 执行使用 $n 占位符的原生查询.
+// SQL 含 @（如 @@、@>）时 gorm 的 Raw 按命名参数解析并丢弃位置参数，包一层 Expr 让参数原样传给驱动.
+func rawPositional(db *gorm.DB, query string, args ...interface{}) *gorm.DB {
+	return db.Raw("?", gorm.Expr(query, args...))
+}
 
-	for _, keyword := range dangerous {
-		if strings.Contains(upperSQL, fmt.Sprintf(" %s ", keyword)) {
-			return false
-		}
+// documentMetadataClause 构建按文档元数据过滤的 SQL 片段（关联 knowledge_documents）.
+func documentMetadataClause(metadata map[string]any, argIdx int) (string, interface{}, error) {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", nil, fmt.Errorf("marshal document metadata filter: %w", err)
 	}
-	return true
+	clause := fmt.Sprintf(" AND EXISTS (SELECT 1 FROM knowledge_documents d WHERE d.id = c.document_id AND d.metadata @> $%d::jsonb)", argIdx)
+	return clause, string(data), nil
+}
+
+// chunkTagsClause 构建按分块标签过滤的 SQL 片段，分块带有参数中任一标签即匹配.
+func chunkTagsClause(argIdx int) string {
+	return fmt.Sprintf(" AND EXISTS (SELECT 1 FROM chunk_tags ct WHERE ct.chunk_id = c.id AND ct.tag_id = ANY($%d))", argIdx)
 }
 
 // SearchChunksByFullText 使用 PostgreSQL 全文搜索 (BM25-like).
-func (s *knowledgeStore) SearchChunksByFullText(ctx context.Context, kbIDs []string, query string, limit int) ([]*ChunkWithScore, error) {
+func (s *knowledgeStore) SearchChunksByFullText(ctx context.Context, kbIDs []string, query string, limit int, options ...SearchOptions) ([]*ChunkWithScore, error) {
 	if query == "" {
 		return nil, nil
 	}
 
+	var opts SearchOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
 	// 使用 PostgreSQL 全文搜索，ts_rank 提供类似 BM25 的排名
-	// plainto_tsquery 自动处理查询词
+	// plainto_tsquery 自动处理查询词，切词使用知识库的文本检索配置（$2）
 	sqlQuery := `
 		SELECT c.id, c.knowledge_base_id, c.document_id, c.chunk_index, c.content, 
 		       c.content_hash, c.metadata, c.is_enabled, c.created_at, c.updated_at,
-		       ts_rank_cd(to_tsvector('simple', c.content), plainto_tsquery('simple', $1)) as score
+		       ts_rank_cd(to_tsvector($2::regconfig, c.content), plainto_tsquery($2::regconfig, $1)) as score
 		FROM knowledge_chunks c
 		WHERE c.is_enabled = true
-		  AND to_tsvector('simple', c.content) @@ plainto_tsquery('simple', $1)
+		  AND to_tsvector($2::regconfig, c.content) @@ plainto_tsquery($2::regconfig, $1)
 	`
 
-	args := []interface{}{query}
-	argIdx := 2
+	args := []interface{}{query, s.textSearchConfig(ctx, kbIDs)}
+	argIdx := 3
 
 	if len(kbIDs) > 0 {
 		sqlQuery += " AND c.knowledge_base_id = ANY($" + fmt.Sprintf("%d", argIdx) + ")"
@@ -480,10 +906,36 @@ func (s *knowledgeStore) SearchChunksByFullText(ctx context.Context, kbIDs []str
 		argIdx++
 	}
 
+	if len(opts.DocumentMetadata) > 0 {
+		clause, arg, err := documentMetadataClause(opts.DocumentMetadata, argIdx)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery += clause
+		args = append(args, arg)
+		argIdx++
+	}
+
+	if len(opts.MetadataFilters) > 0 {
+		clause, filterArgs, err := metadataFiltersClause(opts.MetadataFilters, argIdx)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery += clause
+		args = append(args, filterArgs...)
+		argIdx += len(filterArgs)
+	}
+
+	if len(opts.TagIDs) > 0 {
+		sqlQuery += chunkTagsClause(argIdx)
+		args = append(args, opts.TagIDs)
+		argIdx++
+	}
+
 	sqlQuery += " ORDER BY score DESC LIMIT $" + fmt.Sprintf("%d", argIdx)
 	args = append(args, limit)
 
-	rows, err := s.db.WithContext(ctx).Raw(sqlQuery, args...).Rows()
+	rows, err := rawPositional(s.db.WithContext(ctx), sqlQuery, args...).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -510,30 +962,93 @@ func (s *knowledgeStore) SearchChunksByFullText(ctx context.Context, kbIDs []str
 }
 
 // HybridSearch 混合检索（向量 + 全文搜索）.
-func (s *knowledgeStore) HybridSearch(ctx context.Context, kbIDs []string, embedding []float32, query string, limit int, vectorWeight, bm25Weight float64) ([]*ChunkWithScore, error) {
-	// 使用 RRF (Reciprocal Rank Fusion) 合并向量搜索和全文搜索结果
-	// hybrid_score = vectorWeight * vector_score + bm25Weight * bm25_score
+// pgvector 不可用时退化为仅全文检索.
+func (s *knowledgeStore) HybridSearch(ctx context.Context, kbIDs []string, embedding []float32, query string, limit int, vectorWeight, bm25Weight float64, options ...SearchOptions) ([]*ChunkWithScore, error) {
+	opts := SearchOptions{
+		DistanceFunction: DistanceCosine,
+	}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.DistanceFunction == "" {
+		opts.DistanceFunction = DistanceCosine
+	}
+	if err := opts.DistanceFunction.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Fusion == "" {
+		opts.Fusion = FusionWeightedSum
+	}
+	if err := opts.Fusion.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.RRFK <= 0 {
+		opts.RRFK = DefaultRRFK
+	}
+	op := opts.DistanceFunction.Operator()
+
+	if !s.vectorSearchAvailable(ctx) {
+		return s.SearchChunksByFullText(ctx, kbIDs, query, limit, opts)
+	}
+
+	// 元数据过滤条件在两路检索中共用同一组参数
+	metadataClause := ""
+	var metadataArgs []interface{}
+	if len(opts.DocumentMetadata) > 0 {
+		clause, arg, err := documentMetadataClause(opts.DocumentMetadata, 2)
+		if err != nil {
+			return nil, err
+		}
+		metadataClause = clause
+		metadataArgs = append(metadataArgs, arg)
+	}
+	if len(opts.MetadataFilters) > 0 {
+		clause, filterArgs, err := metadataFiltersClause(opts.MetadataFilters, 2+len(metadataArgs))
+		if err != nil {
+			return nil, err
+		}
+		metadataClause += clause
+		metadataArgs = append(metadataArgs, filterArgs...)
+	}
+	if len(opts.TagIDs) > 0 {
+		metadataClause += chunkTagsClause(2 + len(metadataArgs))
+		metadataArgs = append(metadataArgs, opts.TagIDs)
+	}
+
+	// 两路各取 limit*2 条结果后合并：
+	// weighted_sum: hybrid_score = vectorWeight * vector_score + bm25Weight * bm25_score
+	// rrf:          hybrid_score = 1/(k + vector_rank) + 1/(k + bm25_rank)，未出现在某一路的结果该项为 0
 	sqlQuery := `
 		WITH vector_results AS (
 			SELECT c.id, c.knowledge_base_id, c.document_id, c.chunk_index, c.content, 
 			       c.content_hash, c.metadata, c.is_enabled, c.created_at, c.updated_at,
-			       1 - (e.embedding <=> $1::vector) as vector_score,
+			       ` + vectorScoreExpr(opts.DistanceFunction) + ` as vector_score,
 			       0::float as bm25_score
 			FROM knowledge_chunks c
 			JOIN embeddings e ON e.chunk_id = c.id
 			WHERE c.is_enabled = true
 	`
 
-	args := []interface{}{vectorToString(embedding)}
+	vector, err := vectorToString(embedding)
+	if err != nil {
+		return nil, fmt.Errorf("query vector: %w", err)
+	}
+	args := []interface{}{vector}
 	argIdx := 2
 
+	if metadataClause != "" {
+		sqlQuery += metadataClause
+		args = append(args, metadataArgs...)
+		argIdx += len(metadataArgs)
+	}
+
 	if len(kbIDs) > 0 {
 		sqlQuery += " AND c.knowledge_base_id = ANY($" + fmt.Sprintf("%d", argIdx) + ")"
 		args = append(args, kbIDs)
 		argIdx++
 	}
 
-	sqlQuery += " ORDER BY e.embedding <=> $1::vector LIMIT $" + fmt.Sprintf("%d", argIdx)
+	sqlQuery += " ORDER BY e.embedding " + op + " $1::vector LIMIT $" + fmt.Sprintf("%d", argIdx)
 	args = append(args, limit*2) // 获取更多结果用于合并
 	argIdx++
 
@@ -543,13 +1058,13 @@ func (s *knowledgeStore) HybridSearch(ctx context.Context, kbIDs []string, embed
 			SELECT c.id, c.knowledge_base_id, c.document_id, c.chunk_index, c.content, 
 			       c.content_hash, c.metadata, c.is_enabled, c.created_at, c.updated_at,
 			       0::float as vector_score,
-			       ts_rank_cd(to_tsvector('simple', c.content), plainto_tsquery('simple', $` + fmt.Sprintf("%d", argIdx) + `)) as bm25_score
+			       ts_rank_cd(to_tsvector($` + fmt.Sprintf("%d", argIdx+1) + `::regconfig, c.content), plainto_tsquery($` + fmt.Sprintf("%d", argIdx+1) + `::regconfig, $` + fmt.Sprintf("%d", argIdx) + `)) as bm25_score
 			FROM knowledge_chunks c
 			WHERE c.is_enabled = true
-			  AND to_tsvector('simple', c.content) @@ plainto_tsquery('simple', $` + fmt.Sprintf("%d", argIdx) + `)
-	`
-	args = append(args, query)
-	argIdx++
+			  AND to_tsvector($` + fmt.Sprintf("%d", argIdx+1) + `::regconfig, c.content) @@ plainto_tsquery($` + fmt.Sprintf("%d", argIdx+1) + `::regconfig, $` + fmt.Sprintf("%d", argIdx) + `)
+	` + metadataClause
+	args = append(args, query, s.textSearchConfig(ctx, kbIDs))
+	argIdx += 2
 
 	if len(kbIDs) > 0 {
 		sqlQuery += " AND c.knowledge_base_id = ANY($" + fmt.Sprintf("%d", argIdx) + ")"
@@ -561,32 +1076,50 @@ func (s *knowledgeStore) HybridSearch(ctx context.Context, kbIDs []string, embed
 	args = append(args, limit*2)
 	argIdx++
 
-	// 合并结果并计算混合分数
+	// 合并结果并计算混合分数，排名在各路结果内单独计算（在子查询 LIMIT 之后，不影响向量索引的使用）
+	var scoreExpr string
+	if opts.Fusion == FusionRRF {
+		scoreExpr = "(COALESCE(1.0 / ($" + fmt.Sprintf("%d", argIdx) + " + vector_rank), 0) + COALESCE(1.0 / ($" + fmt.Sprintf("%d", argIdx) + " + bm25_rank), 0))"
+		args = append(args, opts.RRFK)
+		argIdx++
+	} else {
+		scoreExpr = "($" + fmt.Sprintf("%d", argIdx) + " * vector_score + $" + fmt.Sprintf("%d", argIdx+1) + " * bm25_score)"
+		args = append(args, vectorWeight, bm25Weight)
+		argIdx += 2
+	}
 	sqlQuery += `
 		),
 		combined AS (
 			SELECT id, knowledge_base_id, document_id, chunk_index, content, 
 			       content_hash, metadata, is_enabled, created_at, updated_at,
 			       MAX(vector_score) as vector_score,
-			       MAX(bm25_score) as bm25_score
+			       MAX(bm25_score) as bm25_score,
+			       MIN(vector_rank) as vector_rank,
+			       MIN(bm25_rank) as bm25_rank
 			FROM (
-				SELECT * FROM vector_results
+				SELECT *, ROW_NUMBER() OVER (ORDER BY vector_score DESC) AS vector_rank, NULL::bigint AS bm25_rank
+				FROM vector_results
 				UNION ALL
-				SELECT * FROM bm25_results
+				SELECT *, NULL::bigint AS vector_rank, ROW_NUMBER() OVER (ORDER BY bm25_score DESC) AS bm25_rank
+				FROM bm25_results
 			) all_results
 			GROUP BY id, knowledge_base_id, document_id, chunk_index, content, 
 			         content_hash, metadata, is_enabled, created_at, updated_at
 		)
 		SELECT id, knowledge_base_id, document_id, chunk_index, content, 
 		       content_hash, metadata, is_enabled, created_at, updated_at,
-		       ($` + fmt.Sprintf("%d", argIdx) + ` * vector_score + $` + fmt.Sprintf("%d", argIdx+1) + ` * bm25_score) as score
+		       ` + scoreExpr + ` as score
 		FROM combined
 		ORDER BY score DESC
-		LIMIT $` + fmt.Sprintf("%d", argIdx+2)
+		LIMIT $` + fmt.Sprintf("%d", argIdx)
 
-	args = append(args, vectorWeight, bm25Weight, limit)
+	args = append(args, limit)
 
-	rows, err := s.db.WithContext(ctx).Raw(sqlQuery, args...).Rows()
+	rows, err := rawPositional(s.db.WithContext(ctx), sqlQuery, args...).Rows()
+	if isVectorUnavailableError(err) {
+		s.markVectorUnavailable(err)
+		return s.SearchChunksByFullText(ctx, kbIDs, query, limit, opts)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -612,28 +1145,47 @@ func (s *knowledgeStore) HybridSearch(ctx context.Context, kbIDs []string, embed
 	return results, nil
 }
 
-// vectorToString 将 float32 切片转换为 pgvector 格式字符串.
-func vectorToString(v []float32) string {
-	if len(v) == 0 {
-		return "[]"
+// vectorScoreExpr 返回混合检索中向量相似度分数的 SQL 表达式（越大越相似）.
+func vectorScoreExpr(d DistanceFunction) string {
+	dist := "(e.embedding " + d.Operator() + " $1::vector)"
+	switch d {
+	case DistanceL2:
+		return "1 / (1 + " + dist + ")"
+	case DistanceIP:
+		// <#> 返回负内积
+		return "-" + dist
+	default:
+		return "1 - " + dist
 	}
-	s := "["
+}
+
+// ErrInvalidVector 向量包含 NaN 或 Inf，pgvector 不接受.
+var ErrInvalidVector = errors.New("vector contains NaN or Inf")
+
+// vectorToString 将 float32 切片转换为 pgvector 格式字符串，任一分量为 NaN 或 Inf 时返回 ErrInvalidVector.
+func vectorToString(v []float32) (string, error) {
+	var sb strings.Builder
+	sb.WriteByte('[')
 	for i, f := range v {
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return "", fmt.Errorf("%w: component %d is %v", ErrInvalidVector, i, f)
+		}
 		if i > 0 {
-			s += ","
+			sb.WriteByte(',')
 		}
-		s += ftoa(f)
+		sb.WriteString(ftoa(f))
 	}
-	s += "]"
-	return s
+	sb.WriteByte(']')
+	return sb.String(), nil
 }
 
 func itoa(i int) string {
 	return string(rune('0'+i%10)) + ""
 }
 
+// ftoa 以能精确还原 float32 的最短形式输出（可能为科学计数法，pgvector 可以解析）.
 func ftoa(f float32) string {
-	return fmt.Sprintf("%f", f)
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
 }
 
 // Chunk 扩展方法
@@ -696,12 +1248,22 @@ func (s *knowledgeStore) UpdateTag(ctx context.Context, tag *model.KnowledgeTag)
 	return s.db.WithContext(ctx).Save(tag).Error
 }
 
+// DeleteTag 删除标签及其分块关联，子标签移到被删除标签的父标签下.
 func (s *knowledgeStore) DeleteTag(ctx context.Context, id string) error {
-	// 先删除关联的 chunk_tags
-	if err := s.db.WithContext(ctx).Where("tag_id = ?", id).Delete(&model.ChunkTag{}).Error; err != nil {
-		return err
-	}
-	return s.db.WithContext(ctx).Delete(&model.KnowledgeTag{}, "id = ?", id).Error
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tag model.KnowledgeTag
+		if err := tx.Where("id = ?", id).First(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.KnowledgeTag{}).Where("parent_id = ?", id).Update("parent_id", tag.ParentID).Error; err != nil {
+			return err
+		}
+		// 先删除关联的 chunk_tags
+		if err := tx.Where("tag_id = ?", id).Delete(&model.ChunkTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.KnowledgeTag{}, "id = ?", id).Error
+	})
 }
 
 // ChunkTag 关联方法
@@ -748,3 +1310,377 @@ func (s *knowledgeStore) ListChunksByTag(ctx context.Context, tagID string, limi
 
 	return chunks, total, nil
 }
+
+// CountOrphans 统计孤立数据条数.
+func (s *knowledgeStore) CountOrphans(ctx context.Context, kind OrphanKind) (int64, error) {
+	q, ok := orphanQueries[kind]
+	if !ok {
+		return 0, fmt.Errorf("unknown orphan kind: %s", kind)
+	}
+	var count int64
+	err := s.db.WithContext(ctx).Table(q.table).Where(q.cond).Count(&count).Error
+	return count, err
+}
+
+// DeleteOrphans 删除至多 batchSize 条孤立数据，返回实际删除条数.
+func (s *knowledgeStore) DeleteOrphans(ctx context.Context, kind OrphanKind, batchSize int) (int64, error) {
+	q, ok := orphanQueries[kind]
+	if !ok {
+		return 0, fmt.Errorf("unknown orphan kind: %s", kind)
+	}
+	query := fmt.Sprintf("DELETE FROM %s WHERE id IN (SELECT id FROM %s WHERE %s LIMIT ?)", q.table, q.table, q.cond)
+	result := s.db.WithContext(ctx).Exec(query, batchSize)
+	return result.RowsAffected, result.Error
+}
+
+// ListChunksAfter 按 ID 顺序列出知识库中 ID 大于 afterID 的分块（键集分页，用于批处理）.
+func (s *knowledgeStore) ListChunksAfter(ctx context.Context, kbID, afterID string, limit int) ([]*model.KnowledgeChunk, error) {
+	var chunks []*model.KnowledgeChunk
+	db := s.db.WithContext(ctx).Where("knowledge_base_id = ?", kbID)
+	if afterID != "" {
+		db = db.Where("id > ?", afterID)
+	}
+	if err := db.Order("id").Limit(limit).Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// RebuildSearchIndexes 并发重建分块和向量表上的索引（全文、向量索引等）.
+// 索引建在整张表上，重建覆盖所有知识库，无法只重建单个知识库.
+// REINDEX CONCURRENTLY 先构建新索引再替换旧索引，期间不阻塞读写，不能在事务中执行.
+func (s *knowledgeStore) RebuildSearchIndexes(ctx context.Context) error {
+	for _, table := range []string{"knowledge_chunks", "embeddings"} {
+		if err := s.db.WithContext(ctx).Exec("REINDEX TABLE CONCURRENTLY " + table).Error; err != nil {
+			return fmt.Errorf("reindex %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// StartReindexJob 登记运行中的重建任务，锁定知识库行使同一知识库的登记串行执行，
+// 已有运行中的任务时不写入并返回 false.
+func (s *knowledgeStore) StartReindexJob(ctx context.Context, job *model.ReindexJob) (bool, error) {
+	started := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var kb model.KnowledgeBase
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", job.KnowledgeBaseID).First(&kb).Error; err != nil {
+			return err
+		}
+		var running int64
+		if err := tx.Model(&model.ReindexJob{}).
+			Where("knowledge_base_id = ? AND status = ?", job.KnowledgeBaseID, model.ReindexStatusRunning).
+			Count(&running).Error; err != nil {
+			return err
+		}
+		if running > 0 {
+			return nil
+		}
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		started = true
+		return nil
+	})
+	return started, err
+}
+
+func (s *knowledgeStore) UpdateReindexJob(ctx context.Context, job *model.ReindexJob) error {
+	return s.db.WithContext(ctx).Save(job).Error
+}
+
+func (s *knowledgeStore) GetReindexJob(ctx context.Context, id string) (*model.ReindexJob, error) {
+	var job model.ReindexJob
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// LatestIndexRebuild 返回最近一次重建全局索引的任务，没有时返回 nil.
+func (s *knowledgeStore) LatestIndexRebuild(ctx context.Context) (*model.ReindexJob, error) {
+	var jobs []*model.ReindexJob
+	if err := s.db.WithContext(ctx).Where("rebuild_indexes").Order("started_at DESC").Limit(1).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return jobs[0], nil
+}
+
+// FailRunningReindexJobs 将运行中的重建任务标记为失败，用于服务启动时处理重启前中断的任务.
+func (s *knowledgeStore) FailRunningReindexJobs(ctx context.Context, message string) (int64, error) {
+	res := s.db.WithContext(ctx).Model(&model.ReindexJob{}).
+		Where("status = ?", model.ReindexStatusRunning).
+		Updates(map[string]any{
+			"status":      model.ReindexStatusFailed,
+			"error":       message,
+			"finished_at": time.Now(),
+		})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+// CountDocuments 统计知识库中的文档数.
+func (s *knowledgeStore) CountDocuments(ctx context.Context, kbID string) (int64, error) {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&model.KnowledgeDocument{}).Where("knowledge_base_id = ?", kbID).Count(&count).Error
+	return count, err
+}
+
+// CloneTags 将源知识库的标签复制到目标知识库，返回源标签 ID 到新标签 ID 的映射.
+func (s *knowledgeStore) CloneTags(ctx context.Context, srcKBID, dstKBID string) (map[string]string, error) {
+	tags, err := s.ListTagsByKnowledgeBase(ctx, srcKBID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string, len(tags))
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, tag := range tags {
+			clone := &model.KnowledgeTag{
+				KnowledgeBaseID: dstKBID,
+				Name:            tag.Name,
+				Color:           tag.Color,
+				Description:     tag.Description,
+				ChunkCount:      tag.ChunkCount,
+			}
+			if err := tx.Create(clone).Error; err != nil {
+				return fmt.Errorf("clone tag %s: %w", tag.ID, err)
+			}
+			ids[tag.ID] = clone.ID
+		}
+		// 所有标签创建后再按映射设置父标签
+		for _, tag := range tags {
+			if tag.ParentID == nil {
+				continue
+			}
+			parentID, ok := ids[*tag.ParentID]
+			if !ok {
+				continue
+			}
+			if err := tx.Model(&model.KnowledgeTag{}).Where("id = ?", ids[tag.ID]).Update("parent_id", parentID).Error; err != nil {
+				return fmt.Errorf("clone tag %s parent: %w", tag.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CloneDocuments 按 ID 顺序将源知识库中 ID 大于 afterID 的至多 limit 篇文档复制到目标知识库，
+// 连同分块、向量和分块标签（通过 tagIDs 映射到新标签）在一个事务中写入，所有记录使用新 ID.
+// 返回的结果按源文档 ID 排序，最后一项的 SourceID 可作为下一批的 afterID.
+func (s *knowledgeStore) CloneDocuments(ctx context.Context, srcKBID, dstKBID, afterID string, limit int, tagIDs map[string]string) ([]*DocumentCopy, error) {
+	tagMap, err := json.Marshal(tagIDs)
+	if err != nil {
+		return nil, fmt.Errorf("marshal tag ids: %w", err)
+	}
+
+	var copies []*DocumentCopy
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 本批文档的新旧 ID 映射，事务结束时删除
+		batch := "SELECT id AS old_id, gen_random_uuid() AS new_id FROM knowledge_documents WHERE knowledge_base_id = ?"
+		args := []interface{}{srcKBID}
+		if afterID != "" {
+			batch += " AND id > ?"
+			args = append(args, afterID)
+		}
+		batch += " ORDER BY id LIMIT ?"
+		args = append(args, limit)
+		if err := tx.Exec("CREATE TEMP TABLE clone_doc_map ON COMMIT DROP AS "+batch, args...).Error; err != nil {
+			return fmt.Errorf("map documents: %w", err)
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO knowledge_documents (id, knowledge_base_id, source_type, title, source_uri, file_hash,
+				content_text, summary, summary_hash, metadata, parse_status, error_message, created_at, updated_at)
+			SELECT m.new_id, ?, d.source_type, d.title, d.source_uri, d.file_hash,
+				d.content_text, d.summary, d.summary_hash, d.metadata, d.parse_status, d.error_message, d.created_at, NOW()
+			FROM knowledge_documents d JOIN clone_doc_map m ON m.old_id = d.id`, dstKBID).Error; err != nil {
+			return fmt.Errorf("copy documents: %w", err)
+		}
+
+		if err := tx.Exec(`
+			CREATE TEMP TABLE clone_chunk_map ON COMMIT DROP AS
+			SELECT c.id AS old_id, gen_random_uuid() AS new_id, m.new_id AS document_id
+			FROM knowledge_chunks c JOIN clone_doc_map m ON m.old_id = c.document_id`).Error; err != nil {
+			return fmt.Errorf("map chunks: %w", err)
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO knowledge_chunks (id, knowledge_base_id, document_id, chunk_index, content, content_hash,
+				metadata, is_enabled, created_at, updated_at)
+			SELECT m.new_id, ?, m.document_id, c.chunk_index, c.content, c.content_hash,
+				c.metadata, c.is_enabled, c.created_at, NOW()
+			FROM knowledge_chunks c JOIN clone_chunk_map m ON m.old_id = c.id`, dstKBID).Error; err != nil {
+			return fmt.Errorf("copy chunks: %w", err)
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO embeddings (id, knowledge_base_id, chunk_id, embedding, embedding_dim, embedding_model,
+				metadata, created_at, updated_at)
+			SELECT gen_random_uuid(), ?, m.new_id, e.embedding, e.embedding_dim, e.embedding_model,
+				e.metadata, e.created_at, NOW()
+			FROM embeddings e JOIN clone_chunk_map m ON m.old_id = e.chunk_id`, dstKBID).Error; err != nil {
+			return fmt.Errorf("copy embeddings: %w", err)
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO chunk_tags (id, chunk_id, tag_id, created_at)
+			SELECT gen_random_uuid(), m.new_id, t.new_id::uuid, NOW()
+			FROM chunk_tags ct
+			JOIN clone_chunk_map m ON m.old_id = ct.chunk_id
+			JOIN jsonb_each_text(?::jsonb) AS t(old_id, new_id) ON t.old_id = ct.tag_id::text`, string(tagMap)).Error; err != nil {
+			return fmt.Errorf("copy chunk tags: %w", err)
+		}
+
+		return tx.Raw(`
+			SELECT m.old_id AS source_id, m.new_id AS id, d.source_type, d.source_uri
+			FROM clone_doc_map m JOIN knowledge_documents d ON d.id = m.old_id
+			ORDER BY m.old_id`).Scan(&copies).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+// ListEmbeddingSpaces 返回知识库中已有向量的维度和模型组合，docID 不为空时只统计该文档的向量.
+func (s *knowledgeStore) ListEmbeddingSpaces(ctx context.Context, kbID, docID string) ([]*EmbeddingSpace, error) {
+	db := s.db.WithContext(ctx).Model(&model.Embedding{}).
+		Distinct("embeddings.embedding_dim", "embeddings.embedding_model").
+		Where("embeddings.knowledge_base_id = ?", kbID)
+	if docID != "" {
+		db = db.Joins("JOIN knowledge_chunks ON knowledge_chunks.id = embeddings.chunk_id").
+			Where("knowledge_chunks.document_id = ?", docID)
+	}
+	var spaces []*EmbeddingSpace
+	if err := db.Scan(&spaces).Error; err != nil {
+		return nil, err
+	}
+	return spaces, nil
+}
+
+// MoveDocument 在一个事务中将文档连同分块和向量移动到目标知识库.
+// 分块标签按名称改挂到目标知识库的同名标签，目标知识库没有同名标签的关联被删除.
+func (s *knowledgeStore) MoveDocument(ctx context.Context, docID, targetKBID string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chunkIDs := tx.Model(&model.KnowledgeChunk{}).Select("id").Where("document_id = ?", docID)
+
+		if err := tx.Model(&model.KnowledgeDocument{}).Where("id = ?", docID).
+			Update("knowledge_base_id", targetKBID).Error; err != nil {
+			return fmt.Errorf("move document: %w", err)
+		}
+		if err := tx.Model(&model.Embedding{}).Where("chunk_id IN (?)", chunkIDs).
+			Update("knowledge_base_id", targetKBID).Error; err != nil {
+			return fmt.Errorf("move embeddings: %w", err)
+		}
+		if err := tx.Model(&model.KnowledgeChunk{}).Where("document_id = ?", docID).
+			Update("knowledge_base_id", targetKBID).Error; err != nil {
+			return fmt.Errorf("move chunks: %w", err)
+		}
+
+		if err := tx.Exec(`
+			UPDATE chunk_tags ct SET tag_id = tt.id
+			FROM knowledge_tags st, knowledge_tags tt
+			WHERE ct.tag_id = st.id AND st.knowledge_base_id <> ?
+				AND tt.knowledge_base_id = ? AND tt.name = st.name
+				AND ct.chunk_id IN (?)`, targetKBID, targetKBID, chunkIDs).Error; err != nil {
+			return fmt.Errorf("remap chunk tags: %w", err)
+		}
+		if err := tx.Exec(`
+			DELETE FROM chunk_tags ct USING knowledge_tags t
+			WHERE ct.tag_id = t.id AND t.knowledge_base_id <> ? AND ct.chunk_id IN (?)`,
+			targetKBID, chunkIDs).Error; err != nil {
+			return fmt.Errorf("delete chunk tags: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListDocumentChunks 按序号列出文档的所有分块（包括已禁用的分块）.
+func (s *knowledgeStore) ListDocumentChunks(ctx context.Context, docID string) ([]*model.KnowledgeChunk, error) {
+	var chunks []*model.KnowledgeChunk
+	err := s.db.WithContext(ctx).Where("document_id = ?", docID).Order("chunk_index ASC").Find(&chunks).Error
+	return chunks, err
+}
+
+// ReplaceDocumentChunks 在一个事务中应用重新分块的结果：删除旧分块、更新保留分块的序号、写入新分块和向量.
+func (s *knowledgeStore) ReplaceDocumentChunks(ctx context.Context, change *ChunkReplacement) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(change.Remove) > 0 {
+			if err := tx.Where("chunk_id IN ?", change.Remove).Delete(&model.Embedding{}).Error; err != nil {
+				return fmt.Errorf("delete embeddings: %w", err)
+			}
+			if err := tx.Where("chunk_id IN ?", change.Remove).Delete(&model.ChunkTag{}).Error; err != nil {
+				return fmt.Errorf("delete chunk tags: %w", err)
+			}
+			if err := tx.Where("id IN ?", change.Remove).Delete(&model.KnowledgeChunk{}).Error; err != nil {
+				return fmt.Errorf("delete chunks: %w", err)
+			}
+		}
+		for id, index := range change.Keep {
+			if err := tx.Model(&model.KnowledgeChunk{}).Where("id = ?", id).Update("chunk_index", index).Error; err != nil {
+				return fmt.Errorf("update chunk %s: %w", id, err)
+			}
+		}
+		if len(change.Chunks) > 0 {
+			if err := tx.Create(&change.Chunks).Error; err != nil {
+				return fmt.Errorf("create chunks: %w", err)
+			}
+		}
+		if err := insertEmbeddings(tx, change.Embeddings); err != nil {
+			return fmt.Errorf("create embeddings: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteDocuments 删除知识库中符合条件的文档，连同分块、向量和分块标签.
+// 每批至多 batchSize 篇文档在一个事务中删除，返回删除的文档数.
+func (s *knowledgeStore) DeleteDocuments(ctx context.Context, kbID string, filter *DocumentFilter, batchSize int) (int64, error) {
+	var deleted int64
+	for {
+		db := s.db.WithContext(ctx).Model(&model.KnowledgeDocument{}).Where("knowledge_base_id = ?", kbID)
+		if filter.SourceType != "" {
+			db = db.Where("source_type = ?", filter.SourceType)
+		}
+		if filter.ParseStatus != "" {
+			db = db.Where("parse_status = ?", filter.ParseStatus)
+		}
+		if len(filter.IDs) > 0 {
+			db = db.Where("id IN ?", filter.IDs)
+		}
+		var ids []string
+		if err := db.Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+			return deleted, fmt.Errorf("list documents: %w", err)
+		}
+		if len(ids) == 0 {
+			return deleted, nil
+		}
+
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			chunkIDs := tx.Model(&model.KnowledgeChunk{}).Select("id").Where("document_id IN ?", ids)
+			if err := tx.Where("chunk_id IN (?)", chunkIDs).Delete(&model.ChunkTag{}).Error; err != nil {
+				return fmt.Errorf("delete chunk tags: %w", err)
+			}
+			if err := tx.Where("chunk_id IN (?)", chunkIDs).Delete(&model.Embedding{}).Error; err != nil {
+				return fmt.Errorf("delete embeddings: %w", err)
+			}
+			if err := tx.Where("document_id IN ?", ids).Delete(&model.KnowledgeChunk{}).Error; err != nil {
+				return fmt.Errorf("delete chunks: %w", err)
+			}
+			return tx.Where("id IN ?", ids).Delete(&model.KnowledgeDocument{}).Error
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += int64(len(ids))
+	}
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// ListDocumentsAfter 按 ID 顺序列出知识库中 ID 大于 afterID 的文档（键集分页，用于批处理）.
+func (s *knowledgeStore) ListDocumentsAfter(ctx context.Context, kbID, afterID string, limit int) ([]*model.KnowledgeDocument, error) {
+	var docs []*model.KnowledgeDocument
+	db := s.db.WithContext(ctx).Where("knowledge_base_id = ?", kbID)
+	if afterID != "" {
+		db = db.Where("id > ?", afterID)
+	}
+	if err := db.Order("id").Limit(limit).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// ListChunkEmbeddings 返回分块的向量（分块 ID -> 向量），没有向量的分块不在结果中.
+func (s *knowledgeStore) ListChunkEmbeddings(ctx context.Context, chunkIDs []string) (map[string]*model.Embedding, error) {
+	result := make(map[string]*model.Embedding, len(chunkIDs))
+	if len(chunkIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.WithContext(ctx).Raw(`
+		SELECT knowledge_base_id, chunk_id, embedding::text, embedding_dim, embedding_model, metadata
+		FROM embeddings WHERE chunk_id IN ?`, chunkIDs).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e model.Embedding
+		var vector string
+		if err := rows.Scan(&e.KnowledgeBaseID, &e.ChunkID, &vector, &e.EmbeddingDim, &e.EmbeddingModel, &e.Metadata); err != nil {
+			return nil, err
+		}
+		if e.Embedding, err = parseVector(vector); err != nil {
+			return nil, fmt.Errorf("parse embedding of chunk %s: %w", e.ChunkID, err)
+		}
+		result[e.ChunkID] = &e
+	}
+	return result, rows.Err()
+}
+
+// ListChunkTagIDs 返回分块关联的标签 ID（分块 ID -> 标签 ID 列表）.
+func (s *knowledgeStore) ListChunkTagIDs(ctx context.Context, chunkIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(chunkIDs) == 0 {
+		return result, nil
+	}
+	var chunkTags []*model.ChunkTag
+	if err := s.db.WithContext(ctx).Where("chunk_id IN ?", chunkIDs).Order("created_at").Find(&chunkTags).Error; err != nil {
+		return nil, err
+	}
+	for _, ct := range chunkTags {
+		result[ct.ChunkID] = append(result[ct.ChunkID], ct.TagID)
+	}
+	return result, nil
+}
+
+// CreateChunkTags 批量写入分块标签关联.
+func (s *knowledgeStore) CreateChunkTags(ctx context.Context, chunkTags []*model.ChunkTag) error {
+	if len(chunkTags) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Create(&chunkTags).Error
+}
+
+// VectorDimension 返回 embeddings.embedding 列声明的向量维度，未限定维度时返回 0.
+func (s *knowledgeStore) VectorDimension(ctx context.Context) (int, error) {
+	var typmod int
+	if err := s.db.WithContext(ctx).Raw(`
+		SELECT atttypmod FROM pg_attribute
+		WHERE attrelid = 'embeddings'::regclass AND attname = 'embedding'`).Scan(&typmod).Error; err != nil {
+		return 0, fmt.Errorf("get vector dimension: %w", err)
+	}
+	// pgvector 的 typmod 即维度，未限定时为 -1
+	if typmod < 0 {
+		return 0, nil
+	}
+	return typmod, nil
+}
+
+// parseVector 解析 pgvector 的文本格式，例如 "[0.1,0.2,0.3]".
+func parseVector(s string) ([]float32, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("invalid vector %q", s)
+	}
+	s = s[1 : len(s)-1]
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	v := make([]float32, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector element %q: %w", p, err)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// DocumentCentroid 返回文档启用分块向量的平均值，文档没有向量时返回 nil.
+// 文档的分块存在多种维度的向量时（例如迁移过 Embedding 模型），只取数量最多的维度.
+func (s *knowledgeStore) DocumentCentroid(ctx context.Context, docID string) ([]float32, error) {
+	var centroid *string
+	if err := s.db.WithContext(ctx).Raw(`
+		SELECT AVG(e.embedding)::text
+		FROM embeddings e
+		JOIN knowledge_chunks c ON c.id = e.chunk_id
+		WHERE c.document_id = ? AND c.is_enabled = true
+		GROUP BY e.embedding_dim
+		ORDER BY COUNT(*) DESC
+		LIMIT 1`, docID).Scan(&centroid).Error; err != nil {
+		return nil, fmt.Errorf("average document embeddings: %w", err)
+	}
+	if centroid == nil {
+		return nil, nil
+	}
+	return parseVector(*centroid)
+}
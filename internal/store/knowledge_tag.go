@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// TagUsage 标签及其关联的分块数.
+type TagUsage struct {
+	TagID             string `gorm:"column:tag_id"`
+	Name              string `gorm:"column:name"`
+	Color             string `gorm:"column:color"`
+	KnowledgeBaseID   string `gorm:"column:knowledge_base_id"`
+	KnowledgeBaseName string `gorm:"column:knowledge_base_name"`
+	ChunkCount        int64  `gorm:"column:chunk_count"`
+}
+
+// ListTagUsageByTenant 列出租户所有知识库的标签及其关联的分块数（按 chunk_tags 实时统计），按标签名和知识库名排序.
+func (s *knowledgeStore) ListTagUsageByTenant(ctx context.Context, tenantID string) ([]*TagUsage, error) {
+	var usage []*TagUsage
+	err := s.db.WithContext(ctx).
+		Table("knowledge_tags AS t").
+		Select("t.id AS tag_id, t.name, t.color, t.knowledge_base_id, kb.name AS knowledge_base_name, COUNT(ct.id) AS chunk_count").
+		Joins("JOIN knowledge_bases kb ON kb.id = t.knowledge_base_id").
+		Joins("LEFT JOIN chunk_tags ct ON ct.tag_id = t.id").
+		Where("kb.tenant_id = ?", tenantID).
+		Group("t.id, kb.name").
+		Order("t.name, kb.name, t.id").
+		Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// ApplyTagToChunks 在一个事务中按 batchSize 分批为分块添加标签，已带有该标签的分块跳过，返回新增关联数.
+func (s *knowledgeStore) ApplyTagToChunks(ctx context.Context, tagID string, chunkIDs []string, batchSize int) (int64, error) {
+	if len(chunkIDs) == 0 {
+		return 0, nil
+	}
+	if batchSize <= 0 {
+		batchSize = len(chunkIDs)
+	}
+	var tagged int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(chunkIDs); start += batchSize {
+			batch := chunkIDs[start:min(start+batchSize, len(chunkIDs))]
+			result := tx.Exec(`
+				INSERT INTO chunk_tags (id, chunk_id, tag_id, created_at)
+				SELECT gen_random_uuid(), c.id, ?, NOW()
+				FROM knowledge_chunks c
+				WHERE c.id IN ?
+				  AND NOT EXISTS (SELECT 1 FROM chunk_tags ct WHERE ct.chunk_id = c.id AND ct.tag_id = ?)`,
+				tagID, batch, tagID)
+			if result.Error != nil {
+				return result.Error
+			}
+			tagged += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return tagged, nil
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestApplyTagToChunksBatchesInOneTransaction(t *testing.T) {
+	pool := &recordingPool{}
+	s := &knowledgeStore{db: newRecordingDB(t, pool)}
+
+	tagged, err := s.ApplyTagToChunks(context.Background(), "tag1", []string{"c1", "c2", "c3", "c4", "c5"}, 2)
+	if err != nil {
+		t.Fatalf("ApplyTagToChunks() error = %v", err)
+	}
+	if tagged != 3 {
+		t.Errorf("tagged = %d, want the rows affected by 3 batches", tagged)
+	}
+	var inserts int
+	for _, stmt := range pool.log {
+		if strings.Contains(stmt, "INSERT INTO chunk_tags") {
+			inserts++
+		}
+	}
+	if pool.log[0] != "BEGIN" || inserts != 3 || pool.committed != 1 {
+		t.Errorf("statements = %v, committed = %d, want 3 inserts in one transaction", pool.log, pool.committed)
+	}
+
+	// 任一批失败时整体回滚
+	pool = &recordingPool{failOn: "INSERT INTO chunk_tags"}
+	s = &knowledgeStore{db: newRecordingDB(t, pool)}
+	if _, err := s.ApplyTagToChunks(context.Background(), "tag1", []string{"c1", "c2", "c3"}, 2); err == nil {
+		t.Fatal("ApplyTagToChunks() succeeded, want error")
+	}
+	if pool.committed != 0 || pool.rolled != 1 {
+		t.Errorf("committed = %d, rolled back = %d, want rollback only", pool.committed, pool.rolled)
+	}
+
+	// 没有分块时不执行语句
+	pool = &recordingPool{}
+	s = &knowledgeStore{db: newRecordingDB(t, pool)}
+	if tagged, err := s.ApplyTagToChunks(context.Background(), "tag1", nil, 2); err != nil || tagged != 0 || len(pool.log) != 0 {
+		t.Errorf("ApplyTagToChunks(nil) = %d, %v, statements %v, want nothing", tagged, err, pool.log)
+	}
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidListOption 不支持的排序或过滤字段.
+var ErrInvalidListOption = errors.New("invalid list option")
+
+// ListOptions 列表查询的排序和过滤选项，nil 表示按创建时间倒序、不过滤.
+type ListOptions struct {
+	// SortBy 排序字段：created_at / updated_at / name，为空时按 created_at 排序
+	SortBy string
+	// SortDesc 是否降序
+	SortDesc bool
+	// Filters 过滤条件（字段 -> 值），支持的字段由各列表方法决定
+	Filters map[string]string
+}
+
+// listSpec 列表允许的排序字段和过滤字段（选项中的字段名 -> 列名）.
+// 只有登记的字段才会拼入 SQL，防止注入.
+type listSpec struct {
+	sorts   map[string]string
+	filters map[string]string
+}
+
+// where 应用过滤条件.
+func (spec *listSpec) where(db *gorm.DB, opts *ListOptions) (*gorm.DB, error) {
+	if opts == nil {
+		return db, nil
+	}
+	for field, value := range opts.Filters {
+		column, ok := spec.filters[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported filter %q", ErrInvalidListOption, field)
+		}
+		db = db.Where(clause.Eq{Column: clause.Column{Name: column}, Value: value})
+	}
+	return db, nil
+}
+
+// order 返回排序条件，未指定排序字段时按 created_at 倒序.
+func (spec *listSpec) order(opts *ListOptions) (clause.OrderByColumn, error) {
+	if opts == nil || opts.SortBy == "" {
+		return clause.OrderByColumn{Column: clause.Column{Name: "created_at"}, Desc: true}, nil
+	}
+	column, ok := spec.sorts[opts.SortBy]
+	if !ok {
+		return clause.OrderByColumn{}, fmt.Errorf("%w: unsupported sort field %q", ErrInvalidListOption, opts.SortBy)
+	}
+	return clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: opts.SortDesc}, nil
+}
+
+// apply 应用过滤和排序条件.
+func (spec *listSpec) apply(db *gorm.DB, opts *ListOptions) (*gorm.DB, error) {
+	db, err := spec.where(db, opts)
+	if err != nil {
+		return nil, err
+	}
+	order, err := spec.order(opts)
+	if err != nil {
+		return nil, err
+	}
+	return db.Order(order), nil
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// newDryRunDB 只生成 SQL 不执行的 PostgreSQL 连接.
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	return db
+}
+
+func TestAgentListSortAndFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *ListOptions
+		want string
+	}{
+		{
+			name: "default order",
+			want: `SELECT * FROM "agents" ORDER BY "created_at" DESC`,
+		},
+		{
+			name: "filter by type sorted by name",
+			opts: &ListOptions{SortBy: "name", Filters: map[string]string{"type": "react"}},
+			want: `SELECT * FROM "agents" WHERE "agent_type" = $1 ORDER BY "name"`,
+		},
+		{
+			name: "filter by role newest updated first",
+			opts: &ListOptions{SortBy: "updated_at", SortDesc: true, Filters: map[string]string{"role": "specialist"}},
+			want: `SELECT * FROM "agents" WHERE "agent_role" = $1 ORDER BY "updated_at" DESC`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := agentListSpec.apply(newDryRunDB(t).Session(&gorm.Session{}), tt.opts)
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			stmt := db.Find(&[]*model.Agent{}).Statement
+			if got := strings.TrimSpace(stmt.SQL.String()); got != tt.want {
+				t.Errorf("sql = %s\nwant  %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOptionsRejectUnknownFields(t *testing.T) {
+	s := &agentStore{db: newDryRunDB(t)}
+	for name, opts := range map[string]*ListOptions{
+		"sort injection":   {SortBy: "name; DROP TABLE agents"},
+		"unknown sort":     {SortBy: "system_prompt"},
+		"unknown filter":   {Filters: map[string]string{"agent_type = 'x' OR 1=1 --": "x"}},
+		"column not alias": {Filters: map[string]string{"agent_type": "react"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.ListAll(context.Background(), opts); !errors.Is(err, ErrInvalidListOption) {
+				t.Errorf("ListAll() error = %v, want ErrInvalidListOption", err)
+			}
+		})
+	}
+}
@@ -134,6 +134,10 @@ type AgentToolStore interface {
 	Delete(ctx context.Context, id string) error
 	ListByAgent(ctx context.Context, agentID string) ([]*model.AgentTool, error)
 	ListEnabledByAgent(ctx context.Context, agentID string) ([]*model.AgentTool, error)
+	// ReplaceByAgent 在同一事务中将 Agent 的工具集替换为 tools（删除不在列表中的工具，新增或更新其余工具）
+	ReplaceByAgent(ctx context.Context, agentID string, tools []*model.AgentTool) error
+	// UpdatePriorities 在同一事务中批量更新 Agent 工具的优先级（toolID -> priority）
+	UpdatePriorities(ctx context.Context, agentID string, priorities map[string]int) error
 }
 
 type agentToolStore struct {
@@ -166,7 +170,7 @@ func (s *agentToolStore) Delete(ctx context.Context, id string) error {
 
 func (s *agentToolStore) ListByAgent(ctx context.Context, agentID string) ([]*model.AgentTool, error) {
 	var agentTools []*model.AgentTool
-	if err := s.db.WithContext(ctx).Where("agent_id = ?", agentID).Order("priority DESC").Find(&agentTools).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("agent_id = ?", agentID).Order("priority DESC, builtin_tool_name ASC, id ASC").Find(&agentTools).Error; err != nil {
 		return nil, err
 	}
 	return agentTools, nil
@@ -174,8 +178,45 @@ func (s *agentToolStore) ListByAgent(ctx context.Context, agentID string) ([]*mo
 
 func (s *agentToolStore) ListEnabledByAgent(ctx context.Context, agentID string) ([]*model.AgentTool, error) {
 	var agentTools []*model.AgentTool
-	if err := s.db.WithContext(ctx).Where("agent_id = ? AND is_enabled = ?", agentID, true).Order("priority DESC").Find(&agentTools).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("MCPTool").Where("agent_id = ? AND is_enabled = ?", agentID, true).Order("priority DESC, builtin_tool_name ASC, id ASC").Find(&agentTools).Error; err != nil {
 		return nil, err
 	}
 	return agentTools, nil
 }
+
+func (s *agentToolStore) ReplaceByAgent(ctx context.Context, agentID string, tools []*model.AgentTool) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		keepIDs := make([]string, 0, len(tools))
+		for _, t := range tools {
+			keepIDs = append(keepIDs, t.ID)
+		}
+
+		query := tx.Where("agent_id = ?", agentID)
+		if len(keepIDs) > 0 {
+			query = query.Where("id NOT IN ?", keepIDs)
+		}
+		if err := query.Delete(&model.AgentTool{}).Error; err != nil {
+			return err
+		}
+
+		for _, t := range tools {
+			if err := tx.Save(t).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *agentToolStore) UpdatePriorities(ctx context.Context, agentID string, priorities map[string]int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for id, priority := range priorities {
+			if err := tx.Model(&model.AgentTool{}).
+				Where("id = ? AND agent_id = ?", id, agentID).
+				Update("priority", priority).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
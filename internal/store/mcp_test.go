@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestReplaceAgentToolsInOneTransaction(t *testing.T) {
+	pool := &recordingPool{}
+	s := &agentToolStore{db: newRecordingDB(t, pool)}
+
+	tools := []*model.AgentTool{
+		{ID: "keep", AgentID: "a1", ToolType: model.ToolTypeBuiltin, BuiltinToolName: "web_fetch", IsEnabled: true, Priority: 2},
+		{ID: "new", AgentID: "a1", ToolType: model.ToolTypeBuiltin, BuiltinToolName: "calculator", IsEnabled: true},
+	}
+	if err := s.ReplaceByAgent(context.Background(), "a1", tools); err != nil {
+		t.Fatalf("ReplaceByAgent: %v", err)
+	}
+	if len(pool.log) != 5 || pool.log[0] != "BEGIN" || pool.log[4] != "COMMIT" || pool.committed != 1 {
+		t.Fatalf("statements = %q, want delete and two saves in one transaction", pool.log)
+	}
+
+	// 先删除该 Agent 下不在新列表中的工具，保留的和新增的工具不受影响
+	del := pool.execs[0]
+	if !strings.HasPrefix(del.sql, `DELETE FROM "agent_tools"`) ||
+		!strings.Contains(del.sql, "agent_id = $1 AND id NOT IN ($2,$3)") {
+		t.Fatalf("delete = %q, want delete of the agent's other tools", del.sql)
+	}
+	if !slices.Equal(del.vars, []interface{}{"a1", "keep", "new"}) {
+		t.Errorf("delete args = %v, want [a1 keep new]", del.vars)
+	}
+
+	// 再按主键逐个保存新列表中的工具，保留的工具沿用原 ID
+	for i, want := range tools {
+		save := pool.execs[i+1]
+		if !strings.HasPrefix(save.sql, `UPDATE "agent_tools"`) || !strings.HasSuffix(save.sql, `WHERE "id" = $11`) {
+			t.Errorf("save %d = %q, want update by id", i, save.sql)
+			continue
+		}
+		if got := save.vars[len(save.vars)-1]; got != want.ID {
+			t.Errorf("save %d id = %v, want %s", i, got, want.ID)
+		}
+		if got := save.vars[7]; got != want.Priority {
+			t.Errorf("save %d priority = %v, want %d", i, got, want.Priority)
+		}
+	}
+}
+
+func TestReplaceAgentToolsWithEmptyListClearsAgent(t *testing.T) {
+	pool := &recordingPool{}
+	s := &agentToolStore{db: newRecordingDB(t, pool)}
+
+	if err := s.ReplaceByAgent(context.Background(), "a1", nil); err != nil {
+		t.Fatalf("ReplaceByAgent: %v", err)
+	}
+	if len(pool.log) != 3 || pool.log[0] != "BEGIN" || pool.log[2] != "COMMIT" {
+		t.Fatalf("statements = %q, want a single delete in a transaction", pool.log)
+	}
+	if del := pool.log[1]; !strings.HasSuffix(del, "WHERE agent_id = $1") {
+		t.Errorf("delete = %q, want all tools of the agent removed", del)
+	}
+}
+
+func TestReplaceAgentToolsRollsBackOnFailure(t *testing.T) {
+	pool := &recordingPool{failOn: `UPDATE "agent_tools"`}
+	s := &agentToolStore{db: newRecordingDB(t, pool)}
+
+	tools := []*model.AgentTool{{ID: "keep", AgentID: "a1", ToolType: model.ToolTypeBuiltin, BuiltinToolName: "web_fetch"}}
+	if err := s.ReplaceByAgent(context.Background(), "a1", tools); err == nil {
+		t.Fatal("ReplaceByAgent succeeded, want save failure")
+	}
+	// 保存失败时删除一并回滚，Agent 不会落到工具集为空的中间状态
+	if pool.committed != 0 || pool.rolled != 1 {
+		t.Errorf("committed = %d, rolled back = %d, want rollback only", pool.committed, pool.rolled)
+	}
+	if !strings.HasPrefix(pool.log[1], `DELETE FROM "agent_tools"`) || pool.log[len(pool.log)-1] != "ROLLBACK" {
+		t.Errorf("statements = %q, want delete rolled back", pool.log)
+	}
+}
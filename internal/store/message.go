@@ -17,6 +17,7 @@ type MessageStore interface {
 	Update(ctx context.Context, message *model.Message) error
 	ListBySession(ctx context.Context, sessionID string) ([]*model.Message, error)
 	ListBySessionWithFilter(ctx context.Context, sessionID string, beforeTime time.Time, limit int) ([]*model.Message, error)
+	TrimSession(ctx context.Context, sessionID string, keep int) (int64, error)
 }
 
 type messageStore struct {
@@ -78,3 +79,33 @@ func (s *messageStore) ListBySessionWithFilter(ctx context.Context, sessionID st
 
 	return messages, nil
 }
+
+// TrimSession 只保留会话最新的 keep 条非工具消息及其之后的工具结果，返回删除的消息数.
+// 被删除消息的子消息会清空 parent_message_id，避免悬空引用.
+func (s *messageStore) TrimSession(ctx context.Context, sessionID string, keep int) (int64, error) {
+	var cutoffs []time.Time
+	if err := s.db.WithContext(ctx).Model(&model.Message{}).
+		Where("session_id = ? AND role != ?", sessionID, model.MessageRoleTool).
+		Order("created_at DESC").Offset(keep-1).Limit(1).
+		Pluck("created_at", &cutoffs).Error; err != nil {
+		return 0, err
+	}
+	if len(cutoffs) == 0 {
+		return 0, nil
+	}
+	cutoff := cutoffs[0]
+
+	var deleted int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Message{}).
+			Where("session_id = ? AND parent_message_id IN (?)", sessionID,
+				tx.Model(&model.Message{}).Select("id").Where("session_id = ? AND created_at < ?", sessionID, cutoff)).
+			Update("parent_message_id", "").Error; err != nil {
+			return err
+		}
+		result := tx.Where("session_id = ? AND created_at < ?", sessionID, cutoff).Delete(&model.Message{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
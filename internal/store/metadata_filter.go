@@ -0,0 +1,191 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidMetadataFilter 分块元数据过滤条件不合法.
+var ErrInvalidMetadataFilter = errors.New("invalid metadata filter")
+
+// MetadataFilterOperator 分块元数据过滤运算符.
+type MetadataFilterOperator string
+
+const (
+	// MetadataFilterEq 字段值等于 Value（按 JSON 值比较，区分类型）.
+	MetadataFilterEq MetadataFilterOperator = "eq"
+	// MetadataFilterNeq 字段值不等于 Value，字段不存在时也匹配.
+	MetadataFilterNeq MetadataFilterOperator = "neq"
+	// MetadataFilterIn 字段值（文本形式）属于 Value 列表.
+	MetadataFilterIn MetadataFilterOperator = "in"
+	// MetadataFilterGt 字段值大于 Value，Value 为数字时按数值比较，为字符串时按文本比较.
+	MetadataFilterGt MetadataFilterOperator = "gt"
+	// MetadataFilterLt 字段值小于 Value，比较规则同 gt.
+	MetadataFilterLt MetadataFilterOperator = "lt"
+	// MetadataFilterContains 字符串字段包含子串 Value，或数组字段包含元素 Value.
+	MetadataFilterContains MetadataFilterOperator = "contains"
+)
+
+// MetadataFilter 分块元数据（knowledge_chunks.metadata）过滤条件，多个条件之间为 AND 关系.
+// 字段名和值都以参数传入 SQL，不会拼接到语句中.
+type MetadataFilter struct {
+	Field    string                 `json:"field"`
+	Operator MetadataFilterOperator `json:"operator"`
+	Value    any                    `json:"value"`
+}
+
+// Validate 校验字段名、运算符和值的类型.
+func (f MetadataFilter) Validate() error {
+	if strings.TrimSpace(f.Field) == "" {
+		return fmt.Errorf("%w: field is required", ErrInvalidMetadataFilter)
+	}
+	switch f.Operator {
+	case MetadataFilterEq, MetadataFilterNeq, MetadataFilterContains:
+		if !isScalar(f.Value) {
+			return fmt.Errorf("%w: %s on %q requires a string, number or boolean value", ErrInvalidMetadataFilter, f.Operator, f.Field)
+		}
+	case MetadataFilterIn:
+		values, ok := listValues(f.Value)
+		if !ok || len(values) == 0 {
+			return fmt.Errorf("%w: in on %q requires a non-empty list", ErrInvalidMetadataFilter, f.Field)
+		}
+		for _, v := range values {
+			if !isScalar(v) {
+				return fmt.Errorf("%w: in on %q requires a list of strings, numbers or booleans", ErrInvalidMetadataFilter, f.Field)
+			}
+		}
+	case MetadataFilterGt, MetadataFilterLt:
+		if _, ok := toFloat(f.Value); !ok {
+			if _, ok := f.Value.(string); !ok {
+				return fmt.Errorf("%w: %s on %q requires a number or string value", ErrInvalidMetadataFilter, f.Operator, f.Field)
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unsupported operator %q", ErrInvalidMetadataFilter, f.Operator)
+	}
+	return nil
+}
+
+// ValidateMetadataFilters 校验所有过滤条件.
+func ValidateMetadataFilters(filters []MetadataFilter) error {
+	for _, f := range filters {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metadataFiltersClause 将过滤条件转换为以 " AND " 开头的参数化条件，占位符从 $argIdx 开始.
+func metadataFiltersClause(filters []MetadataFilter, argIdx int) (string, []interface{}, error) {
+	var sb strings.Builder
+	var args []interface{}
+	next := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", argIdx+len(args)-1)
+	}
+
+	for _, f := range filters {
+		if err := f.Validate(); err != nil {
+			return "", nil, err
+		}
+		sb.WriteString(" AND ")
+		switch f.Operator {
+		case MetadataFilterEq, MetadataFilterNeq:
+			// 使用 JSONB 包含匹配，按 JSON 值比较
+			data, err := json.Marshal(map[string]any{f.Field: f.Value})
+			if err != nil {
+				return "", nil, fmt.Errorf("marshal metadata filter: %w", err)
+			}
+			cond := fmt.Sprintf("c.metadata @> %s::jsonb", next(string(data)))
+			if f.Operator == MetadataFilterNeq {
+				cond = "NOT (" + cond + ")"
+			}
+			sb.WriteString(cond)
+		case MetadataFilterIn:
+			field := next(f.Field)
+			values, _ := listValues(f.Value)
+			texts := make([]string, len(values))
+			for i, v := range values {
+				texts[i] = scalarText(v)
+			}
+			fmt.Fprintf(&sb, "c.metadata->>%s = ANY(%s)", field, next(texts))
+		case MetadataFilterGt, MetadataFilterLt:
+			field := next(f.Field)
+			op := ">"
+			if f.Operator == MetadataFilterLt {
+				op = "<"
+			}
+			if n, ok := toFloat(f.Value); ok {
+				fmt.Fprintf(&sb, "(CASE WHEN jsonb_typeof(c.metadata->%s) = 'number' THEN (c.metadata->>%s)::numeric END) %s %s",
+					field, field, op, next(n))
+			} else {
+				fmt.Fprintf(&sb, "(CASE WHEN jsonb_typeof(c.metadata->%s) = 'string' THEN c.metadata->>%s END) %s %s",
+					field, field, op, next(f.Value.(string)))
+			}
+		case MetadataFilterContains:
+			field := next(f.Field)
+			element, err := json.Marshal([]any{f.Value})
+			if err != nil {
+				return "", nil, fmt.Errorf("marshal metadata filter: %w", err)
+			}
+			fmt.Fprintf(&sb, "(CASE jsonb_typeof(c.metadata->%s) WHEN 'array' THEN c.metadata->%s @> %s::jsonb WHEN 'string' THEN strpos(c.metadata->>%s, %s) > 0 ELSE false END)",
+				field, field, next(string(element)), field, next(scalarText(f.Value)))
+		}
+	}
+	return sb.String(), args, nil
+}
+
+// listValues 将 in 运算的列表值统一为 []any.
+func listValues(v any) ([]any, bool) {
+	switch l := v.(type) {
+	case []any:
+		return l, true
+	case []string:
+		values := make([]any, len(l))
+		for i, s := range l {
+			values[i] = s
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// isScalar 判断值是否为 JSON 标量（字符串、数字或布尔值）.
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, bool:
+		return true
+	}
+	_, ok := toFloat(v)
+	return ok
+}
+
+// toFloat 将数字类型的值转换为 float64.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// scalarText 返回标量与 ->> 运算结果一致的文本形式.
+func scalarText(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
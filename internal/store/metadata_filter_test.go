@@ -0,0 +1,126 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMetadataFiltersClause(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     MetadataFilter
+		wantClause string
+		wantArgs   []interface{}
+	}{
+		{
+			name:       "eq",
+			filter:     MetadataFilter{Field: "page", Operator: MetadataFilterEq, Value: 3},
+			wantClause: " AND c.metadata @> $4::jsonb",
+			wantArgs:   []interface{}{`{"page":3}`},
+		},
+		{
+			name:       "neq",
+			filter:     MetadataFilter{Field: "lang", Operator: MetadataFilterNeq, Value: "en"},
+			wantClause: " AND NOT (c.metadata @> $4::jsonb)",
+			wantArgs:   []interface{}{`{"lang":"en"}`},
+		},
+		{
+			name:       "in",
+			filter:     MetadataFilter{Field: "lang", Operator: MetadataFilterIn, Value: []any{"en", 2, true}},
+			wantClause: " AND c.metadata->>$4 = ANY($5)",
+			wantArgs:   []interface{}{"lang", []string{"en", "2", "true"}},
+		},
+		{
+			name:       "gt number",
+			filter:     MetadataFilter{Field: "page", Operator: MetadataFilterGt, Value: 10},
+			wantClause: " AND (CASE WHEN jsonb_typeof(c.metadata->$4) = 'number' THEN (c.metadata->>$4)::numeric END) > $5",
+			wantArgs:   []interface{}{"page", 10.0},
+		},
+		{
+			name:       "lt string",
+			filter:     MetadataFilter{Field: "date", Operator: MetadataFilterLt, Value: "2024-01-01"},
+			wantClause: " AND (CASE WHEN jsonb_typeof(c.metadata->$4) = 'string' THEN c.metadata->>$4 END) < $5",
+			wantArgs:   []interface{}{"date", "2024-01-01"},
+		},
+		{
+			name:       "contains",
+			filter:     MetadataFilter{Field: "tags", Operator: MetadataFilterContains, Value: "faq"},
+			wantClause: " AND (CASE jsonb_typeof(c.metadata->$4) WHEN 'array' THEN c.metadata->$4 @> $5::jsonb WHEN 'string' THEN strpos(c.metadata->>$4, $6) > 0 ELSE false END)",
+			wantArgs:   []interface{}{"tags", `["faq"]`, "faq"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clause, args, err := metadataFiltersClause([]MetadataFilter{tt.filter}, 4)
+			if err != nil {
+				t.Fatalf("metadataFiltersClause: %v", err)
+			}
+			if clause != tt.wantClause {
+				t.Errorf("clause = %q, want %q", clause, tt.wantClause)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestMetadataFiltersClauseCombinesWithAnd(t *testing.T) {
+	clause, args, err := metadataFiltersClause([]MetadataFilter{
+		{Field: "lang", Operator: MetadataFilterEq, Value: "en"},
+		{Field: "page", Operator: MetadataFilterGt, Value: 2},
+	}, 2)
+	if err != nil {
+		t.Fatalf("metadataFiltersClause: %v", err)
+	}
+	if strings.Count(clause, " AND ") != 2 || !strings.Contains(clause, "$2::jsonb") || !strings.Contains(clause, "> $4") {
+		t.Errorf("clause = %q, want two AND conditions numbered from $2", clause)
+	}
+	if len(args) != 3 {
+		t.Errorf("args = %v, want 3", args)
+	}
+}
+
+func TestMetadataFilterValidate(t *testing.T) {
+	invalid := []MetadataFilter{
+		{Field: " ", Operator: MetadataFilterEq, Value: "x"},
+		{Field: "lang", Operator: "like", Value: "x"},
+		{Field: "lang", Operator: MetadataFilterEq, Value: map[string]any{"a": 1}},
+		{Field: "lang", Operator: MetadataFilterIn, Value: []any{}},
+		{Field: "lang", Operator: MetadataFilterIn, Value: "en"},
+		{Field: "lang", Operator: MetadataFilterIn, Value: []any{[]any{"nested"}}},
+		{Field: "page", Operator: MetadataFilterGt, Value: true},
+	}
+	for _, f := range invalid {
+		if err := f.Validate(); !errors.Is(err, ErrInvalidMetadataFilter) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidMetadataFilter", f, err)
+		}
+	}
+	if _, _, err := metadataFiltersClause(invalid[:1], 2); !errors.Is(err, ErrInvalidMetadataFilter) {
+		t.Errorf("metadataFiltersClause with invalid filter = %v, want ErrInvalidMetadataFilter", err)
+	}
+}
+
+func TestVectorSearchAppliesMetadataFilters(t *testing.T) {
+	s := &knowledgeStore{}
+	query, args, err := s.buildVectorSearchQuery([]string{"kb1"}, []float32{0.1, 0.2}, 5, &SearchOptions{
+		MetadataFilters: []MetadataFilter{{Field: "lang", Operator: MetadataFilterIn, Value: []string{"en", "zh"}}},
+	})
+	if err != nil {
+		t.Fatalf("buildVectorSearchQuery: %v", err)
+	}
+	// 字段名和值都作为参数传入，不拼接到 SQL 中
+	if strings.Contains(query, "'lang'") || strings.Contains(query, "'en'") {
+		t.Fatalf("filter values inlined into query:\n%s", query)
+	}
+	clause := fmt.Sprintf("c.metadata->>$%d = ANY($%d)", len(args)-2, len(args)-1)
+	if !strings.Contains(query, clause) {
+		t.Fatalf("query missing %q:\n%s", clause, query)
+	}
+	if !strings.HasSuffix(query, fmt.Sprintf("LIMIT $%d", len(args))) {
+		t.Fatalf("limit placeholder mismatched: %s %v", query, args)
+	}
+}
@@ -0,0 +1,46 @@
+// Package store 提供数据访问层.
+package store
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// ModerationStore 内容审核记录存储接口.
+type ModerationStore interface {
+	Create(ctx context.Context, record *model.ModerationRecord) error
+	List(ctx context.Context, target string, offset, limit int) ([]*model.ModerationRecord, int64, error)
+}
+
+type moderationStore struct {
+	db *gorm.DB
+}
+
+func newModerationStore(db *gorm.DB) ModerationStore {
+	return &moderationStore{db: db}
+}
+
+func (s *moderationStore) Create(ctx context.Context, record *model.ModerationRecord) error {
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+func (s *moderationStore) List(ctx context.Context, target string, offset, limit int) ([]*model.ModerationRecord, int64, error) {
+	query := s.db.WithContext(ctx).Model(&model.ModerationRecord{})
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var records []*model.ModerationRecord
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&records).Error; err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
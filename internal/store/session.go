@@ -3,6 +3,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 
@@ -18,8 +19,32 @@ type SessionStore interface {
 	UpdateTitle(ctx context.Context, id, title string) error
 	UpdateStatus(ctx context.Context, id string, status model.SessionStatus) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, userID string, offset, limit int) ([]*model.Session, int64, error)
+	List(ctx context.Context, userID string, offset, limit int, opts *ListOptions) ([]*model.Session, int64, error)
 	ListByAgent(ctx context.Context, agentID string, offset, limit int) ([]*model.Session, int64, error)
+	UpdatePinned(ctx context.Context, id string, pinned bool) error
+
+	// Retention
+	ListExpired(ctx context.Context, scope RetentionScope, before time.Time, limit int) ([]string, error)
+	ListOverMessageLimit(ctx context.Context, scope RetentionScope, maxMessages, limit int) ([]string, error)
+	Purge(ctx context.Context, id string) (int64, error)
+}
+
+// RetentionScope 保留策略作用的会话范围（按会话用户所属租户划分）.
+type RetentionScope struct {
+	// TenantID 只包含该租户用户的会话
+	TenantID string
+	// ExcludeTenantIDs 排除这些租户用户的会话（有独立策略的租户）
+	ExcludeTenantIDs []string
+}
+
+func (sc RetentionScope) apply(db *gorm.DB) *gorm.DB {
+	if sc.TenantID != "" {
+		return db.Where("sessions.user_id IN (SELECT id FROM users WHERE tenant_id = ?)", sc.TenantID)
+	}
+	if len(sc.ExcludeTenantIDs) > 0 {
+		return db.Where("sessions.user_id NOT IN (SELECT id FROM users WHERE tenant_id IN ?)", sc.ExcludeTenantIDs)
+	}
+	return db
 }
 
 type sessionStore struct {
@@ -66,15 +91,29 @@ func (s *sessionStore) Delete(ctx context.Context, id string) error {
 	return s.db.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).Update("status", model.SessionStatusDeleted).Error
 }
 
-func (s *sessionStore) List(ctx context.Context, userID string, offset, limit int) ([]*model.Session, int64, error) {
+// sessionListSpec 会话列表支持的排序和过滤字段.
+var sessionListSpec = &listSpec{
+	sorts:   map[string]string{"created_at": "created_at", "updated_at": "updated_at", "name": "title"},
+	filters: map[string]string{"status": "status", "agent_id": "agent_id"},
+}
+
+func (s *sessionStore) List(ctx context.Context, userID string, offset, limit int, opts *ListOptions) ([]*model.Session, int64, error) {
 	var sessions []*model.Session
 	var total int64
 
 	db := s.db.WithContext(ctx).Model(&model.Session{}).Where("user_id = ? AND status != ?", userID, model.SessionStatusDeleted)
+	db, err := sessionListSpec.where(db, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	order, err := sessionListSpec.order(opts)
+	if err != nil {
+		return nil, 0, err
+	}
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
-	if err := db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&sessions).Error; err != nil {
+	if err := db.Offset(offset).Limit(limit).Order(order).Find(&sessions).Error; err != nil {
 		return nil, 0, err
 	}
 	return sessions, total, nil
@@ -93,3 +132,51 @@ func (s *sessionStore) ListByAgent(ctx context.Context, agentID string, offset,
 	}
 	return sessions, total, nil
 }
+
+func (s *sessionStore) UpdatePinned(ctx context.Context, id string, pinned bool) error {
+	return s.db.WithContext(ctx).Model(&model.Session{}).Where("id = ?", id).Update("pinned", pinned).Error
+}
+
+// ListExpired 列出最后更新时间早于 before 的未置顶会话 ID（含已软删除的会话）.
+func (s *sessionStore) ListExpired(ctx context.Context, scope RetentionScope, before time.Time, limit int) ([]string, error) {
+	var ids []string
+	db := s.db.WithContext(ctx).Model(&model.Session{}).Where("pinned = ? AND updated_at < ?", false, before)
+	if err := scope.apply(db).Order("updated_at").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// ListOverMessageLimit 列出非工具消息数超过 maxMessages 的未置顶会话 ID.
+func (s *sessionStore) ListOverMessageLimit(ctx context.Context, scope RetentionScope, maxMessages, limit int) ([]string, error) {
+	var ids []string
+	db := s.db.WithContext(ctx).Model(&model.Session{}).
+		Where("pinned = ? AND status != ?", false, model.SessionStatusDeleted).
+		Where("(SELECT COUNT(*) FROM messages m WHERE m.session_id = sessions.id AND m.role != ?) > ?", model.MessageRoleTool, maxMessages)
+	if err := scope.apply(db).Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Purge 在事务中删除会话及其消息和 Checkpoint，返回删除的消息数.
+func (s *sessionStore) Purge(ctx context.Context, id string) (int64, error) {
+	var deleted int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("checkpoint_id IN (?)",
+			tx.Model(&model.Checkpoint{}).Select("checkpoint_id").Where("session_id = ?", id),
+		).Delete(&model.CheckpointEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("session_id = ?", id).Delete(&model.Checkpoint{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("session_id = ?", id).Delete(&model.Message{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Where("id = ?", id).Delete(&model.Session{}).Error
+	})
+	return deleted, err
+}
@@ -15,7 +15,7 @@ type SkillStore interface {
 	Get(ctx context.Context, id string) (*model.Skill, error)
 	Update(ctx context.Context, skill *model.Skill) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, offset, limit int) ([]*model.Skill, int64, error)
+	List(ctx context.Context, offset, limit int, opts *ListOptions) ([]*model.Skill, int64, error)
 	ListAll(ctx context.Context) ([]*model.Skill, error)
 	ListByCategory(ctx context.Context, category string) ([]*model.Skill, error)
 	ListEnabled(ctx context.Context) ([]*model.Skill, error)
@@ -50,15 +50,28 @@ func (s *skillStore) Delete(ctx context.Context, id string) error {
 	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Skill{}).Error
 }
 
-func (s *skillStore) List(ctx context.Context, offset, limit int) ([]*model.Skill, int64, error) {
+// skillListSpec Skill 列表支持的排序和过滤字段.
+var skillListSpec = &listSpec{
+	sorts:   map[string]string{"created_at": "created_at", "updated_at": "updated_at", "name": "name"},
+	filters: map[string]string{"category": "category"},
+}
+
+func (s *skillStore) List(ctx context.Context, offset, limit int, opts *ListOptions) ([]*model.Skill, int64, error) {
 	var skills []*model.Skill
 	var total int64
 
-	db := s.db.WithContext(ctx).Model(&model.Skill{})
+	db, err := skillListSpec.where(s.db.WithContext(ctx).Model(&model.Skill{}), opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	order, err := skillListSpec.order(opts)
+	if err != nil {
+		return nil, 0, err
+	}
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
-	if err := db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&skills).Error; err != nil {
+	if err := db.Offset(offset).Limit(limit).Order(order).Find(&skills).Error; err != nil {
 		return nil, 0, err
 	}
 	return skills, total, nil
@@ -20,18 +20,21 @@ type Store interface {
 	Tenants() TenantStore
 	Users() UserStore
 	Skills() SkillStore
+	Moderation() ModerationStore
 	// DB 返回底层数据库连接（用于事务等场景）
 	DB() *gorm.DB
 }
 
 // dataStore 存储层实现.
 type dataStore struct {
-	db *gorm.DB
+	db         *gorm.DB
+	vector     *vectorStatus
+	textSearch *textSearchStatus
 }
 
 // NewStore 创建存储层实例.
 func NewStore(db *gorm.DB) Store {
-	return &dataStore{db: db}
+	return &dataStore{db: db, vector: &vectorStatus{}, textSearch: newTextSearchStatus()}
 }
 
 func (s *dataStore) Providers() ProviderStore {
@@ -71,7 +74,7 @@ func (s *dataStore) AgentTools() AgentToolStore {
 }
 
 func (s *dataStore) Knowledge() KnowledgeStore {
-	return newKnowledgeStore(s.db)
+	return newKnowledgeStore(s.db, s.vector, s.textSearch)
 }
 
 func (s *dataStore) WebSearch() WebSearchStore {
@@ -94,6 +97,10 @@ func (s *dataStore) Skills() SkillStore {
 	return newSkillStore(s.db)
 }
 
+func (s *dataStore) Moderation() ModerationStore {
+	return newModerationStore(s.db)
+}
+
 func (s *dataStore) DB() *gorm.DB {
 	return s.db
 }
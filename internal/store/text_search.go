@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+// DefaultTextSearchConfig 默认的文本检索配置，按空白和标点切词，对中文几乎是逐字切分.
+const DefaultTextSearchConfig = "simple"
+
+// ErrInvalidTextSearchConfig 文本检索配置名不合法.
+var ErrInvalidTextSearchConfig = errors.New("invalid text search config")
+
+// textSearchConfigPattern 文本检索配置名（PostgreSQL 标识符，可带 schema 前缀）.
+var textSearchConfigPattern = regexp.MustCompile(`^([a-z_][a-z0-9_]*\.)?[a-z_][a-z0-9_]*$`)
+
+// ValidateTextSearchConfig 校验文本检索配置名的格式，不检查配置是否已安装.
+func ValidateTextSearchConfig(name string) error {
+	if !textSearchConfigPattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidTextSearchConfig, name)
+	}
+	return nil
+}
+
+// textSearchStatus 记录文本检索配置是否存在，由同一 dataStore 创建的 knowledgeStore 共享.
+// 不存在的配置只记录一次警告，之后直接退化为 DefaultTextSearchConfig.
+type textSearchStatus struct {
+	mu      sync.Mutex
+	configs map[string]bool
+}
+
+func newTextSearchStatus() *textSearchStatus {
+	return &textSearchStatus{configs: make(map[string]bool)}
+}
+
+// textSearchConfig 返回检索 kbIDs 时使用的文本检索配置.
+// 各知识库配置一致时使用该配置，否则（或未指定知识库时）使用 DefaultTextSearchConfig；
+// 配置在数据库中不存在（扩展未安装）时同样退化为 DefaultTextSearchConfig.
+func (s *knowledgeStore) textSearchConfig(ctx context.Context, kbIDs []string) string {
+	if len(kbIDs) == 0 {
+		return DefaultTextSearchConfig
+	}
+	var configs []string
+	if err := s.db.WithContext(ctx).Model(&model.KnowledgeBase{}).
+		Distinct("text_search_config").
+		Where("id IN ?", kbIDs).
+		Pluck("text_search_config", &configs).Error; err != nil || len(configs) != 1 {
+		return DefaultTextSearchConfig
+	}
+	name := configs[0]
+	if name == "" || name == DefaultTextSearchConfig || ValidateTextSearchConfig(name) != nil {
+		return DefaultTextSearchConfig
+	}
+
+	s.textSearch.mu.Lock()
+	defer s.textSearch.mu.Unlock()
+	exists, checked := s.textSearch.configs[name]
+	if !checked {
+		if err := s.db.WithContext(ctx).Raw("SELECT to_regconfig(?) IS NOT NULL", name).Scan(&exists).Error; err != nil {
+			return DefaultTextSearchConfig
+		}
+		s.textSearch.configs[name] = exists
+		if !exists {
+			log.Printf("WARNING: text search config %q not found (extension not installed?), falling back to %q", name, DefaultTextSearchConfig)
+		}
+	}
+	if !exists {
+		return DefaultTextSearchConfig
+	}
+	return name
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VectorIndexMethod 向量索引类型.
+type VectorIndexMethod string
+
+const (
+	// VectorIndexHNSW HNSW 索引，需要 pgvector >= 0.5.0
+	VectorIndexHNSW VectorIndexMethod = "hnsw"
+	// VectorIndexIVFFlat IVFFlat 索引，pgvector 不支持 HNSW 时使用
+	VectorIndexIVFFlat VectorIndexMethod = "ivfflat"
+)
+
+const (
+	defaultHNSWM              = 16
+	defaultHNSWEfConstruction = 64
+	defaultIVFFlatLists       = 100
+)
+
+// EnsureVectorIndex 为 embeddings 表创建与距离函数对应的向量索引，索引已存在时不做任何操作.
+// pgvector 支持 HNSW 时创建 HNSW 索引（m、efConstruction <= 0 时使用默认值 16、64），否则退化为 IVFFlat 索引.
+// 返回实际使用的索引类型.
+func (s *knowledgeStore) EnsureVectorIndex(ctx context.Context, distanceFunc DistanceFunction, m, efConstruction int) (VectorIndexMethod, error) {
+	if err := distanceFunc.Validate(); err != nil {
+		return "", err
+	}
+
+	var version string
+	if err := s.db.WithContext(ctx).Raw("SELECT extversion FROM pg_extension WHERE extname = 'vector'").Scan(&version).Error; err != nil {
+		return "", fmt.Errorf("get pgvector version: %w", err)
+	}
+	if version == "" {
+		return "", fmt.Errorf("pgvector extension is not installed")
+	}
+
+	method := VectorIndexIVFFlat
+	if supportsHNSW(version) {
+		method = VectorIndexHNSW
+	}
+
+	name := fmt.Sprintf("idx_embeddings_vector_%s_%s", method, distanceFunc)
+	var with string
+	switch method {
+	case VectorIndexHNSW:
+		if m <= 0 {
+			m = defaultHNSWM
+		}
+		if efConstruction <= 0 {
+			efConstruction = defaultHNSWEfConstruction
+		}
+		with = fmt.Sprintf("m = %d, ef_construction = %d", m, efConstruction)
+	default:
+		with = fmt.Sprintf("lists = %d", defaultIVFFlatLists)
+	}
+
+	query := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON embeddings USING %s (embedding %s) WITH (%s)",
+		quoteIdentifier(name), method, distanceFunc.OpClass(), with)
+	if err := s.db.WithContext(ctx).Exec(query).Error; err != nil {
+		return "", fmt.Errorf("create %s index: %w", method, err)
+	}
+	return method, nil
+}
+
+// supportsHNSW 判断 pgvector 版本是否支持 HNSW 索引（0.5.0 起）.
+func supportsHNSW(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	return major > 0 || minor >= 5
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func norm(a []float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// distance 按 pgvector 运算符的语义计算距离：<=> 余弦距离、<-> 欧氏距离、<#> 负内积.
+func distance(fn DistanceFunction, a, b []float64) float64 {
+	switch fn {
+	case DistanceL2:
+		var sum float64
+		for i := range a {
+			sum += (a[i] - b[i]) * (a[i] - b[i])
+		}
+		return math.Sqrt(sum)
+	case DistanceIP:
+		return -dot(a, b)
+	default:
+		return 1 - dot(a, b)/(norm(a)*norm(b))
+	}
+}
+
+func TestCalculateScoreRanksMostSimilarHighest(t *testing.T) {
+	s := &knowledgeStore{}
+	query := []float64{1, 0, 0}
+	chunks := map[string][]float64{
+		"same":       {1, 0, 0},
+		"close":      {0.8, 0.2, 0},
+		"orthogonal": {0, 1, 0},
+		"opposite":   {-1, 0, 0},
+	}
+	for _, fn := range []DistanceFunction{DistanceCosine, DistanceL2, DistanceIP} {
+		t.Run(string(fn), func(t *testing.T) {
+			scores := make(map[string]float64, len(chunks))
+			for id, v := range chunks {
+				scores[id] = s.calculateScore(distance(fn, query, v), fn)
+			}
+			order := []string{"same", "close", "orthogonal", "opposite"}
+			for i := 1; i < len(order); i++ {
+				if scores[order[i-1]] <= scores[order[i]] {
+					t.Errorf("score(%s) = %v <= score(%s) = %v, want more similar chunks to score higher",
+						order[i-1], scores[order[i-1]], order[i], scores[order[i]])
+				}
+			}
+		})
+	}
+
+	// IP：分数即内积
+	if got := s.calculateScore(distance(DistanceIP, query, chunks["close"]), DistanceIP); math.Abs(got-0.8) > 1e-9 {
+		t.Errorf("ip score = %v, want inner product 0.8", got)
+	}
+}
+
+func TestThresholdDistanceRoundTrip(t *testing.T) {
+	s := &knowledgeStore{}
+	for _, fn := range []DistanceFunction{DistanceCosine, DistanceL2, DistanceIP} {
+		for _, threshold := range []float64{0.25, 0.5, 1} {
+			d := s.calculateThresholdDistance(threshold, fn)
+			if d < 0 && fn != DistanceIP {
+				t.Errorf("%s threshold %v gives negative distance %v", fn, threshold, d)
+			}
+			if got := s.calculateScore(d, fn); math.Abs(got-threshold) > 1e-9 {
+				t.Errorf("%s: score at threshold distance = %v, want %v", fn, got, threshold)
+			}
+		}
+	}
+}
+
+func TestBuildVectorSearchQueryScoreThreshold(t *testing.T) {
+	s := &knowledgeStore{}
+	tests := []struct {
+		fn        DistanceFunction
+		threshold float64
+		wantErr   bool
+	}{
+		{DistanceL2, 0.5, false},
+		{DistanceL2, 1, false},
+		{DistanceL2, 1.5, true},
+		{DistanceCosine, 2, true},
+		{DistanceIP, 3, false},
+	}
+	for _, tt := range tests {
+		threshold := tt.threshold
+		_, args, err := s.buildVectorSearchQuery(nil, []float32{1, 0}, 5, &SearchOptions{DistanceFunction: tt.fn, ScoreThreshold: &threshold})
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidScoreThreshold) {
+				t.Errorf("%s threshold %v: error = %v, want ErrInvalidScoreThreshold", tt.fn, tt.threshold, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s threshold %v: error = %v", tt.fn, tt.threshold, err)
+			continue
+		}
+		// 参数依次为查询向量、阈值距离、limit
+		if d := args[len(args)-2].(float64); d < 0 && tt.fn != DistanceIP {
+			t.Errorf("%s threshold %v: distance %v is negative", tt.fn, tt.threshold, d)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrVectorSearchUnavailable pgvector 扩展不可用，无法执行向量检索.
+var ErrVectorSearchUnavailable = errors.New("vector search unavailable: pgvector extension is not installed")
+
+// vectorRecheckInterval 向量检索不可用时重新检测 pgvector 的最小间隔.
+const vectorRecheckInterval = 30 * time.Second
+
+// vectorStatus 记录 pgvector 是否可用，由同一 dataStore 创建的 knowledgeStore 共享.
+// 检测前视为可用；不可用时检索退化为全文检索，每隔 vectorRecheckInterval 重新检测一次，扩展安装后自动恢复.
+type vectorStatus struct {
+	mu        sync.Mutex
+	checked   bool
+	available bool
+	reason    string
+	checkedAt time.Time
+}
+
+// VectorSupport pgvector 可用状态.
+type VectorSupport struct {
+	Available bool      `json:"available"`
+	Reason    string    `json:"reason,omitempty"`
+	CheckedAt time.Time `json:"checked_at,omitempty"`
+}
+
+// CheckVectorSupport 检测 pgvector 扩展是否已安装并更新可用状态.
+func (s *knowledgeStore) CheckVectorSupport(ctx context.Context) (*VectorSupport, error) {
+	var version string
+	if err := s.db.WithContext(ctx).Raw("SELECT extversion FROM pg_extension WHERE extname = 'vector'").Scan(&version).Error; err != nil {
+		return nil, fmt.Errorf("get pgvector version: %w", err)
+	}
+	if version == "" {
+		s.vector.set(false, "pgvector extension is not installed")
+	} else {
+		s.vector.set(true, "")
+	}
+	return s.vector.snapshot(), nil
+}
+
+// VectorSupport 返回最近一次检测到的 pgvector 可用状态，不访问数据库.
+func (s *knowledgeStore) VectorSupport() *VectorSupport {
+	return s.vector.snapshot()
+}
+
+// vectorSearchAvailable 判断当前是否可以执行向量检索，不可用且距上次检测超过间隔时重新检测.
+func (s *knowledgeStore) vectorSearchAvailable(ctx context.Context) bool {
+	if !s.vector.shouldRecheck() {
+		return s.vector.isAvailable()
+	}
+	support, err := s.CheckVectorSupport(ctx)
+	if err != nil {
+		return s.vector.isAvailable()
+	}
+	if support.Available {
+		log.Printf("pgvector is available again, vector search re-enabled")
+	}
+	return support.Available
+}
+
+// markVectorUnavailable 查询报错表明 pgvector 不可用时记录状态.
+func (s *knowledgeStore) markVectorUnavailable(err error) {
+	if s.vector.isAvailable() {
+		log.Printf("WARNING: pgvector unavailable, falling back to full-text search: %v", err)
+	}
+	s.vector.set(false, err.Error())
+}
+
+func (v *vectorStatus) set(available bool, reason string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.checked = true
+	v.available = available
+	v.reason = reason
+	v.checkedAt = time.Now()
+}
+
+func (v *vectorStatus) isAvailable() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return !v.checked || v.available
+}
+
+// shouldRecheck 不可用状态超过检测间隔后返回 true，并刷新检测时间避免并发请求重复检测.
+func (v *vectorStatus) shouldRecheck() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.checked || v.available || time.Since(v.checkedAt) < vectorRecheckInterval {
+		return false
+	}
+	v.checkedAt = time.Now()
+	return true
+}
+
+func (v *vectorStatus) snapshot() *VectorSupport {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return &VectorSupport{
+		Available: !v.checked || v.available,
+		Reason:    v.reason,
+		CheckedAt: v.checkedAt,
+	}
+}
+
+// isVectorUnavailableError 判断查询错误是否由 pgvector 扩展缺失导致
+// （vector 类型或运算符不存在、扩展库文件缺失等）.
+func isVectorUnavailableError(err error) bool {
+	if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	msg := err.Error()
+	for _, pattern := range []string{
+		`type "vector" does not exist`,
+		"operator does not exist: vector",
+		"operator does not exist: public.vector",
+		`extension "vector"`,
+		"$libdir/vector",
+		`relation "embeddings" does not exist`,
+	} {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
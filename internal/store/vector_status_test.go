@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// errFullTextRan 模拟数据库中全文检索的返回，用于确认执行了全文检索.
+var errFullTextRan = errors.New("full-text query executed")
+
+// newNoVectorStore 返回模拟未安装 pgvector 的 knowledgeStore：
+// 使用向量运算符的查询报 vector 类型不存在，其余查询返回 errFullTextRan.
+func newNoVectorStore(t *testing.T) (*knowledgeStore, *[]string) {
+	t.Helper()
+	db := newDryRunDB(t)
+	db.Logger = logger.Discard
+	var queries []string
+	err := db.Callback().Row().After("gorm:row").Register("test:no_vector", func(tx *gorm.DB) {
+		sql := tx.Statement.SQL.String()
+		queries = append(queries, sql)
+		if strings.Contains(sql, "<=>") {
+			tx.AddError(errors.New(`ERROR: type "vector" does not exist (SQLSTATE 42704)`))
+			return
+		}
+		tx.AddError(errFullTextRan)
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return &knowledgeStore{db: db, vector: &vectorStatus{}}, &queries
+}
+
+func TestHybridSearchFallsBackWithoutPgvector(t *testing.T) {
+	s, queries := newNoVectorStore(t)
+	ctx := context.Background()
+
+	// 首次检索时向量查询报错，记录不可用并退化为全文检索
+	_, err := s.HybridSearch(ctx, []string{"kb1"}, []float32{0.1, 0.2}, "refund", 5, 0.7, 0.3)
+	if !errors.Is(err, errFullTextRan) {
+		t.Fatalf("HybridSearch() error = %v, want full-text fallback", err)
+	}
+	if len(*queries) != 2 || !strings.Contains((*queries)[0], "<=>") || strings.Contains((*queries)[1], "<=>") {
+		t.Fatalf("queries = %q, want hybrid query then full-text query", *queries)
+	}
+	support := s.VectorSupport()
+	if support.Available || !strings.Contains(support.Reason, `type "vector" does not exist`) {
+		t.Errorf("VectorSupport() = %+v, want unavailable with the database error", support)
+	}
+
+	// 之后的检索直接走全文检索，不再尝试向量查询
+	*queries = nil
+	if _, err := s.HybridSearch(ctx, []string{"kb1"}, []float32{0.1, 0.2}, "refund", 5, 0.7, 0.3); !errors.Is(err, errFullTextRan) {
+		t.Fatalf("HybridSearch() error = %v, want full-text fallback", err)
+	}
+	if len(*queries) != 1 || strings.Contains((*queries)[0], "<=>") {
+		t.Errorf("queries = %q, want only the full-text query", *queries)
+	}
+
+	// 纯向量检索返回 ErrVectorSearchUnavailable，不访问数据库
+	*queries = nil
+	if _, err := s.SearchChunksByVectorWithOptions(ctx, []string{"kb1"}, []float32{0.1, 0.2}, 5); !errors.Is(err, ErrVectorSearchUnavailable) {
+		t.Errorf("SearchChunksByVectorWithOptions() error = %v, want ErrVectorSearchUnavailable", err)
+	}
+	if len(*queries) != 0 {
+		t.Errorf("queries = %q, want none", *queries)
+	}
+}
+
+func TestVectorSearchErrorMarksUnavailable(t *testing.T) {
+	s, _ := newNoVectorStore(t)
+
+	_, err := s.SearchChunksByVectorWithOptions(context.Background(), []string{"kb1"}, []float32{0.1, 0.2}, 5)
+	if !errors.Is(err, ErrVectorSearchUnavailable) {
+		t.Fatalf("SearchChunksByVectorWithOptions() error = %v, want ErrVectorSearchUnavailable", err)
+	}
+	if s.VectorSupport().Available {
+		t.Error("VectorSupport().Available = true after vector type error")
+	}
+}
+
+func TestVectorStatusRecheck(t *testing.T) {
+	v := &vectorStatus{}
+	if !v.isAvailable() || v.shouldRecheck() {
+		t.Fatal("unchecked status should be available without recheck")
+	}
+
+	v.set(false, "pgvector extension is not installed")
+	if v.isAvailable() || v.shouldRecheck() {
+		t.Fatal("status just marked unavailable should not recheck yet")
+	}
+
+	// 超过检测间隔后只有一个调用方重新检测
+	v.checkedAt = time.Now().Add(-vectorRecheckInterval - time.Second)
+	if !v.shouldRecheck() {
+		t.Fatal("shouldRecheck() = false after the recheck interval")
+	}
+	if v.shouldRecheck() {
+		t.Error("shouldRecheck() = true twice, want the check time refreshed")
+	}
+
+	v.set(true, "")
+	if !v.isAvailable() || v.shouldRecheck() {
+		t.Error("available status should not recheck")
+	}
+}
+
+func TestIsVectorUnavailableError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{gorm.ErrRecordNotFound, false},
+		{errors.New(`ERROR: type "vector" does not exist (SQLSTATE 42704)`), true},
+		{errors.New("ERROR: operator does not exist: vector <=> unknown"), true},
+		{errors.New(`ERROR: could not open extension control file ".../extension "vector"...`), true},
+		{errors.New(`ERROR: could not access file "$libdir/vector": No such file or directory`), true},
+		{errors.New("ERROR: syntax error at or near \"SELECT\""), false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		if got := isVectorUnavailableError(tt.err); got != tt.want {
+			t.Errorf("isVectorUnavailableError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ashwinyue/next-show/internal/model"
+)
+
+func TestVectorToString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []float32
+		want string
+	}{
+		{"empty", nil, "[]"},
+		{"zero vector", []float32{0, 0, 0}, "[0,0,0]"},
+		{"negative values", []float32{-0.5, 1, -2.25}, "[-0.5,1,-2.25]"},
+		{"shortest float32 form", []float32{0.1, 1.0 / 3}, "[0.1,0.33333334]"},
+		{"tiny value keeps precision", []float32{1e-8}, "[1e-08]"},
+	}
+	for _, tt := range tests {
+		got, err := vectorToString(tt.in)
+		if err != nil {
+			t.Errorf("%s: vectorToString() error = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: vectorToString() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVectorToStringRoundTrips(t *testing.T) {
+	in := []float32{1e-8, -3.4028235e38, 0.123456789, 42}
+	s, err := vectorToString(in)
+	if err != nil {
+		t.Fatalf("vectorToString() error = %v", err)
+	}
+	parts := strings.Split(strings.Trim(s, "[]"), ",")
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(p, 32)
+		if err != nil || float32(f) != in[i] {
+			t.Errorf("component %d = %q parses to %v (%v), want %v", i, p, f, err, in[i])
+		}
+	}
+}
+
+func TestVectorToStringRejectsNaNAndInf(t *testing.T) {
+	for _, v := range []float32{float32(math.NaN()), float32(math.Inf(1)), float32(math.Inf(-1))} {
+		if _, err := vectorToString([]float32{0.1, v}); !errors.Is(err, ErrInvalidVector) {
+			t.Errorf("vectorToString(%v) error = %v, want ErrInvalidVector", v, err)
+		}
+	}
+}
+
+func TestCreateEmbeddingsRejectsNaN(t *testing.T) {
+	s := &knowledgeStore{db: newDryRunDB(t)}
+	err := s.CreateEmbeddings(context.Background(), []*model.Embedding{
+		{ChunkID: "c1", Embedding: []float32{0.1, 0.2}},
+		{ChunkID: "c2", Embedding: []float32{float32(math.NaN()), 0.2}},
+	})
+	if !errors.Is(err, ErrInvalidVector) || !strings.Contains(err.Error(), "c2") {
+		t.Errorf("CreateEmbeddings() error = %v, want ErrInvalidVector naming chunk c2", err)
+	}
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsafeWhereClause SearchOptions.WhereClause 含有不允许的内容.
+var ErrUnsafeWhereClause = errors.New("unsafe where clause")
+
+// whereClauseColumns WhereClause 可以引用的分块列，可带 c. 前缀.
+var whereClauseColumns = map[string]bool{
+	"id":                true,
+	"knowledge_base_id": true,
+	"document_id":       true,
+	"chunk_index":       true,
+	"content":           true,
+	"content_hash":      true,
+	"metadata":          true,
+	"created_at":        true,
+	"updated_at":        true,
+}
+
+// whereClauseKeywords WhereClause 可以使用的关键字.
+var whereClauseKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "is": true, "null": true,
+	"true": true, "false": true, "in": true, "like": true, "ilike": true, "between": true,
+}
+
+// whereClauseTypes :: 类型转换允许的目标类型.
+var whereClauseTypes = map[string]bool{
+	"int": true, "integer": true, "bigint": true, "numeric": true, "float": true,
+	"text": true, "jsonb": true, "boolean": true, "date": true, "timestamp": true, "timestamptz": true,
+}
+
+// whereClauseOperators 允许的运算符，按长度从长到短匹配.
+var whereClauseOperators = []string{
+	"->>", "#>>",
+	"->", "#>", "@>", "<@", "<=", ">=", "<>", "!=", "::", "||", "~~",
+	"=", "<", ">", "+", "-", "*", "/", "%", ",",
+}
+
+// validateWhereClause 按白名单校验 WhereClause：只允许分块列、字符串和数字字面量、比较和 JSONB 运算符、
+// 少量关键字以及用于分组或 IN 列表的括号；注释、分号、引号标识符、函数调用和子查询都会被拒绝.
+// gorm 的 Raw 会把任意位置（包括字符串字面量内）的 ? 替换为绑定参数，因此 ?、?|、?& 一律拒绝.
+func validateWhereClause(sql string) error {
+	if strings.Contains(sql, "?") {
+		return fmt.Errorf("%w: ? is not allowed", ErrUnsafeWhereClause)
+	}
+	depth := 0
+	prevIdent := false // 上一个记号是否为列名或类型名（其后紧跟括号即函数调用）
+	prevCast := false  // 上一个记号是否为 ::
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+			continue
+		case ch == '\'':
+			end, err := scanStringLiteral(sql, i)
+			if err != nil {
+				return err
+			}
+			i = end
+			prevIdent, prevCast = false, false
+			continue
+		case isDigit(ch):
+			for i < len(sql) && (isDigit(sql[i]) || sql[i] == '.') {
+				i++
+			}
+			prevIdent, prevCast = false, false
+			continue
+		case isIdentStart(ch):
+			start := i
+			for i < len(sql) && (isIdentStart(sql[i]) || isDigit(sql[i]) || sql[i] == '.') {
+				i++
+			}
+			word := strings.ToLower(sql[start:i])
+			if i < len(sql) && sql[i] == '\'' {
+				// E'...'、U&'...' 等带前缀的字符串
+				return fmt.Errorf("%w: prefixed string literal %q", ErrUnsafeWhereClause, word)
+			}
+			switch {
+			case prevCast:
+				if !whereClauseTypes[word] {
+					return fmt.Errorf("%w: cast to %q is not allowed", ErrUnsafeWhereClause, word)
+				}
+				prevIdent = true
+			case whereClauseKeywords[word]:
+				prevIdent = false
+			case whereClauseColumns[strings.TrimPrefix(word, "c.")]:
+				prevIdent = true
+			default:
+				return fmt.Errorf("%w: identifier %q is not allowed", ErrUnsafeWhereClause, word)
+			}
+			prevCast = false
+			continue
+		case ch == '(':
+			if prevIdent {
+				return fmt.Errorf("%w: function calls are not allowed", ErrUnsafeWhereClause)
+			}
+			depth++
+			i++
+			prevCast = false
+			continue
+		case ch == ')':
+			if depth == 0 {
+				return fmt.Errorf("%w: unbalanced parentheses", ErrUnsafeWhereClause)
+			}
+			depth--
+			i++
+			prevIdent, prevCast = false, false
+			continue
+		}
+
+		if strings.HasPrefix(sql[i:], "--") || strings.HasPrefix(sql[i:], "/*") {
+			return fmt.Errorf("%w: comments are not allowed", ErrUnsafeWhereClause)
+		}
+		op := matchOperator(sql[i:])
+		if op == "" {
+			return fmt.Errorf("%w: character %q is not allowed", ErrUnsafeWhereClause, ch)
+		}
+		i += len(op)
+		prevIdent = false
+		prevCast = op == "::"
+	}
+	if depth != 0 {
+		return fmt.Errorf("%w: unbalanced parentheses", ErrUnsafeWhereClause)
+	}
+	if prevCast {
+		return fmt.Errorf("%w: incomplete cast", ErrUnsafeWhereClause)
+	}
+	return nil
+}
+
+// scanStringLiteral 扫描从 start 开始的单引号字符串（” 为转义的引号），返回结束位置.
+func scanStringLiteral(sql string, start int) (int, error) {
+	for i := start + 1; i < len(sql); i++ {
+		if sql[i] != '\'' {
+			continue
+		}
+		if i+1 < len(sql) && sql[i+1] == '\'' {
+			i++
+			continue
+		}
+		return i + 1, nil
+	}
+	return 0, fmt.Errorf("%w: unterminated string literal", ErrUnsafeWhereClause)
+}
+
+// matchOperator 返回 s 开头的允许运算符，不匹配时返回空字符串.
+func matchOperator(s string) string {
+	for _, op := range whereClauseOperators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateWhereClauseAllowed(t *testing.T) {
+	for _, clause := range []string{
+		"c.metadata->>'category' = 'tech'",
+		"chunk_index >= 3 AND chunk_index < 10",
+		"(c.metadata->>'score')::numeric > 0.5",
+		"metadata @> '{\"lang\": \"zh\"}'::jsonb",
+		"document_id IN ('a', 'b') OR content ILIKE '%go%'",
+		"metadata #>> '{a,b}' IS NOT NULL",
+		"content = 'it''s'",
+	} {
+		if err := validateWhereClause(clause); err != nil {
+			t.Errorf("validateWhereClause(%q) = %v, want nil", clause, err)
+		}
+	}
+}
+
+func TestValidateWhereClauseRejectsBypass(t *testing.T) {
+	cases := []struct {
+		name   string
+		clause string
+	}{
+		{"line comment", "chunk_index = 1 -- AND knowledge_base_id = 'x'"},
+		{"block comment", "chunk_index = 1 /* */ OR true"},
+		{"semicolon", "true; DROP TABLE chunks"},
+		{"stacked statement in string end", "content = 'a'; DELETE FROM chunks"},
+		{"subquery", "id IN (SELECT id FROM chunks)"},
+		{"parenthesized subquery", "(select 1) = 1"},
+		{"function call", "pg_sleep(10) IS NULL"},
+		{"column used as function", "content(1) = 1"},
+		{"allowed type as function", "text(content) = 'a'"},
+		{"cast to disallowed type", "content::regclass IS NULL"},
+		{"bind placeholder", "content = ?"},
+		{"placeholder in string literal", "content = 'what?'"},
+		{"jsonb any key operator", "metadata ?| '{a}'"},
+		{"jsonb all keys operator", "metadata ?& '{a}'"},
+		{"jsonb key operator", "metadata ? 'a'"},
+		{"escape string", "content = E'\\x27'"},
+		{"quoted identifier", "\"knowledge_base_id\" = 'x'"},
+		{"unknown column", "password = 'x'"},
+		{"unterminated string", "content = 'abc"},
+		{"unbalanced open", "(chunk_index = 1"},
+		{"unbalanced close", "chunk_index = 1) OR (true"},
+		{"dollar quoting", "content = $$x$$"},
+		{"incomplete cast", "content::"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateWhereClause(tc.clause); !errors.Is(err, ErrUnsafeWhereClause) {
+				t.Errorf("validateWhereClause(%q) = %v, want ErrUnsafeWhereClause", tc.clause, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_knowledge_documents_metadata_publish_date;
+DROP INDEX IF EXISTS idx_knowledge_documents_metadata_department;
+DROP INDEX IF EXISTS idx_knowledge_documents_metadata_author;
+DROP INDEX IF EXISTS idx_knowledge_documents_metadata;
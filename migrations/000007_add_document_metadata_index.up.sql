@@ -0,0 +1,9 @@
+-- 文档元数据索引：支持按文档元数据过滤检索
+
+-- JSONB 包含查询（metadata @> '{"department": "legal"}'）
+CREATE INDEX IF NOT EXISTS idx_knowledge_documents_metadata ON knowledge_documents USING gin (metadata jsonb_path_ops);
+
+-- 常用键的表达式索引（metadata->>'key' = ...）
+CREATE INDEX IF NOT EXISTS idx_knowledge_documents_metadata_author ON knowledge_documents ((metadata->>'author'));
+CREATE INDEX IF NOT EXISTS idx_knowledge_documents_metadata_department ON knowledge_documents ((metadata->>'department'));
+CREATE INDEX IF NOT EXISTS idx_knowledge_documents_metadata_publish_date ON knowledge_documents ((metadata->>'publish_date'));
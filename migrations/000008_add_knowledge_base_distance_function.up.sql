@@ -0,0 +1,2 @@
+-- 知识库向量检索的距离函数：向量索引按此创建，检索默认使用
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS distance_function VARCHAR(10) NOT NULL DEFAULT 'cosine';
@@ -0,0 +1 @@
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS text_search_config;
@@ -0,0 +1,2 @@
+-- 知识库全文检索使用的文本检索配置（例如 zhparser、jieba），对应扩展未安装时检索退化为 simple
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS text_search_config VARCHAR(64) NOT NULL DEFAULT 'simple';
@@ -0,0 +1 @@
+ALTER TABLE mcp_servers DROP COLUMN IF EXISTS max_retries;
@@ -0,0 +1,2 @@
+-- MCP 工具调用失败（超时、连接断开）时的重试次数，0 表示不重试
+ALTER TABLE mcp_servers ADD COLUMN IF NOT EXISTS max_retries INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_knowledge_tags_parent_id;
+ALTER TABLE knowledge_tags DROP COLUMN IF EXISTS parent_id;
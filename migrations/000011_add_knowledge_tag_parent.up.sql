@@ -0,0 +1,3 @@
+-- 标签层级：parent_id 指向同一知识库中的父标签，为空表示顶层标签
+ALTER TABLE knowledge_tags ADD COLUMN IF NOT EXISTS parent_id UUID;
+CREATE INDEX IF NOT EXISTS idx_knowledge_tags_parent_id ON knowledge_tags(parent_id);
@@ -0,0 +1,2 @@
+ALTER TABLE knowledge_documents DROP COLUMN IF EXISTS summary_hash;
+ALTER TABLE knowledge_documents DROP COLUMN IF EXISTS summary;
@@ -0,0 +1,3 @@
+-- 文档摘要缓存：summary_hash 为生成摘要时分块内容的哈希，与当前分块内容不一致时摘要失效
+ALTER TABLE knowledge_documents ADD COLUMN IF NOT EXISTS summary TEXT;
+ALTER TABLE knowledge_documents ADD COLUMN IF NOT EXISTS summary_hash VARCHAR(32);
@@ -0,0 +1,22 @@
+-- 知识库重建任务：进度随执行写入，服务重启后仍可查询；重启时运行中的任务标记为失败
+CREATE TABLE IF NOT EXISTS knowledge_reindex_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    knowledge_base_id UUID NOT NULL,
+    re_embed BOOLEAN NOT NULL DEFAULT false,
+    refresh BOOLEAN NOT NULL DEFAULT false,
+    rebuild_indexes BOOLEAN NOT NULL DEFAULT false,
+    document_ids JSONB,
+    status VARCHAR(20) NOT NULL,
+    stage VARCHAR(20),
+    total_chunks BIGINT NOT NULL DEFAULT 0,
+    processed_chunks BIGINT NOT NULL DEFAULT 0,
+    reprocessed_documents BIGINT NOT NULL DEFAULT 0,
+    skipped_documents BIGINT NOT NULL DEFAULT 0,
+    failed_documents BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_reindex_jobs_kb ON knowledge_reindex_jobs(knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_reindex_jobs_status ON knowledge_reindex_jobs(status);